@@ -0,0 +1,41 @@
+package main
+
+import "charm.land/catwalk/pkg/render"
+
+// diff compares the words of b with a. It returns which words of b are not
+// in their longest common subsequence, and the similarity of the two: twice
+// the common words over the total, from 0 (nothing shared) to 1 (the same).
+// Replies too long to compare word by word are compared by word counts
+// instead, which is cheaper but ignores order.
+func diff(a, b []string) ([]bool, float64) {
+	ops, ok := render.WordDiff(a, b)
+	if !ok {
+		return diffCounts(a, b)
+	}
+	changed := make([]bool, 0, len(b))
+	for _, op := range ops {
+		if op.Kind != '-' {
+			changed = append(changed, op.Kind == '+')
+		}
+	}
+	return changed, render.DiffSimilarity(ops)
+}
+
+// diffCounts marks the tokens of b that occur more often than in a.
+func diffCounts(a, b []string) ([]bool, float64) {
+	counts := map[string]int{}
+	for _, t := range a {
+		counts[t]++
+	}
+	changed := make([]bool, len(b))
+	common := 0
+	for i, t := range b {
+		if counts[t] > 0 {
+			counts[t]--
+			common++
+		} else {
+			changed[i] = true
+		}
+	}
+	return changed, 2 * float64(common) / float64(len(a)+len(b))
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"charm.land/catwalk/pkg/chat"
+	"github.com/sashabaranov/go-openai"
+)
+
+// grid is the parameter values to try. An empty list means the parameter is
+// left at the provider default.
+type grid struct {
+	temperatures []float64
+	topPs        []float64
+	efforts      []string
+}
+
+// parseGrid reads the parameter lists from the flags.
+func parseGrid() (grid, error) {
+	var g grid
+	var err error
+	if g.temperatures, err = parseFloats("temperature", *temperatures, 0, 2); err != nil {
+		return grid{}, err
+	}
+	if g.topPs, err = parseFloats("top-p", *topPs, 0, 1); err != nil {
+		return grid{}, err
+	}
+	for _, e := range strings.Split(*efforts, ",") {
+		if e = strings.ToLower(strings.TrimSpace(e)); e != "" && !slices.Contains(g.efforts, e) {
+			g.efforts = append(g.efforts, e)
+		}
+	}
+	return g, nil
+}
+
+func parseFloats(name, list string, lo, hi float64) ([]float64, error) {
+	var values []float64
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v < lo || v > hi {
+			return nil, fmt.Errorf("--%s: %q is not a number from %g to %g", name, s, lo, hi)
+		}
+		if !slices.Contains(values, v) {
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
+
+// check reports parameters the target's model cannot take, suggesting the
+// provider's models that can.
+func (g grid) check(t target) error {
+	for _, e := range g.efforts {
+		req := openai.ChatCompletionRequest{ReasoningEffort: e}
+		if err := chat.CheckFeatures(t.provider, t.model, req, nil); err != nil {
+			return fmt.Errorf("--reasoning-effort: %w", err)
+		}
+	}
+	return nil
+}
+
+// arms returns every combination of target and parameter values, each
+// repeated runs times, grouped by target.
+func (g grid) arms(targets []target, runs int) []arm {
+	// A nil entry stands for the provider default
+	temperatures := optional(g.temperatures)
+	topPs := optional(g.topPs)
+	efforts := g.efforts
+	if len(efforts) == 0 {
+		efforts = []string{""}
+	}
+
+	var arms []arm
+	for _, t := range targets {
+		for _, temperature := range temperatures {
+			for _, topP := range topPs {
+				for _, effort := range efforts {
+					for run := 1; run <= runs; run++ {
+						arms = append(arms, arm{target: t, temperature: temperature, topP: topP, effort: effort, run: run})
+					}
+				}
+			}
+		}
+	}
+	return arms
+}
+
+func optional(values []float64) []*float64 {
+	if len(values) == 0 {
+		return []*float64{nil}
+	}
+	ptrs := make([]*float64, len(values))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	return ptrs
+}
+
+// apply sets the arm's parameters on a request. The client omits zero
+// floats, so an explicit 0 is sent as the smallest non-zero value instead.
+func (a arm) apply(req *openai.ChatCompletionRequest) {
+	nonZero := func(f float64) float32 {
+		if f == 0 {
+			return math.SmallestNonzeroFloat32
+		}
+		return float32(f)
+	}
+	if a.temperature != nil {
+		req.Temperature = nonZero(*a.temperature)
+	}
+	if a.topP != nil {
+		req.TopP = nonZero(*a.topP)
+	}
+	req.ReasoningEffort = a.effort
+}
+
+// model names the arm's model as provider/model.
+func (a arm) model() string {
+	return string(a.target.provider.ID) + "/" + a.target.model.ID
+}
+
+// params describes the arm's parameters, or "defaults" if none are set.
+func (a arm) params() string {
+	var parts []string
+	if a.temperature != nil {
+		parts = append(parts, "temperature="+strconv.FormatFloat(*a.temperature, 'g', -1, 64))
+	}
+	if a.topP != nil {
+		parts = append(parts, "top_p="+strconv.FormatFloat(*a.topP, 'g', -1, 64))
+	}
+	if a.effort != "" {
+		parts = append(parts, "effort="+a.effort)
+	}
+	if *runs > 1 {
+		parts = append(parts, fmt.Sprintf("run %d", a.run))
+	}
+	if len(parts) == 0 {
+		return "defaults"
+	}
+	return strings.Join(parts, " ")
+}
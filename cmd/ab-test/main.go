@@ -0,0 +1,332 @@
+// Package main provides ab-test, which sends the same prompt to a model
+// several times across a grid of sampling parameters (temperature, top_p,
+// reasoning effort), or to two or more models, and shows the replies side by
+// side with the words that differ from the first arm highlighted, along with
+// each arm's tokens, latency, and cost.
+//
+// Usage:
+//
+//	ab-test --models openai/gpt-4o-mini --temperature 0,0.7,1.2 --prompt "Name a color."
+//	ab-test --models gpt-4o,claude-sonnet-4-5 --prompt-file question.md
+//	ab-test --models openai/o4-mini --reasoning-effort low,high --prompt "Is 1001 prime?"
+//	ab-test --models gpt-4o-mini --temperature 1 --runs 3 --format json < prompt.txt
+//
+// Environment Variables:
+//
+//	CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)
+//	<PROVIDER>_API_KEY - API keys, as named by each provider in the catalog
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/snapshot"
+	"charm.land/catwalk/pkg/theme"
+	"charm.land/catwalk/pkg/transport"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sashabaranov/go-openai"
+)
+
+var (
+	modelList      = flag.String("models", "", "Comma-separated models, as provider/model or model (required)")
+	prompt         = flag.String("prompt", "", "Prompt to send (default: --prompt-file, or stdin)")
+	promptFile     = flag.String("prompt-file", "", "Read the prompt from this file")
+	system         = flag.String("system", "", "System prompt")
+	temperatures   = flag.String("temperature", "", "Comma-separated temperatures to try, e.g. 0,0.7,1.2")
+	topPs          = flag.String("top-p", "", "Comma-separated top_p values to try, e.g. 0.5,1")
+	efforts        = flag.String("reasoning-effort", "", "Comma-separated reasoning efforts to try, e.g. low,high")
+	runs           = flag.Int("runs", 1, "Requests per arm, to see how much replies vary")
+	maxTokens      = flag.Int("max-tokens", 0, "Maximum reply tokens (default: each model's default)")
+	clampMax       = flag.Bool("clamp-max-tokens", false, "Lower --max-tokens to each model's output limit instead of failing")
+	parallel       = flag.Int("parallel", 4, "Requests sent concurrently")
+	timeout        = flag.Duration("timeout", 2*time.Minute, "Timeout per request")
+	width          = flag.Int("width", 0, "Output width for side-by-side columns (default: terminal width, or 160)")
+	outputFormat   = flag.String("format", "text", "Output format: text or json")
+	catalogVersion = flag.String("catalog-version", "", "Use a stored catalog snapshot (ETag, YYYY-MM-DD, or latest) instead of live data")
+	network        = transport.RegisterFlags(flag.CommandLine)
+	showHelp       = flag.Bool("help", false, "Show help message")
+)
+
+// Styles for formatting, in the colors of the aimodels theme
+var (
+	colors = theme.Current()
+
+	headerStyle  = lipgloss.NewStyle().Bold(true).Foreground(colors.Header)
+	armStyle     = lipgloss.NewStyle().Bold(true).Foreground(colors.Accent)
+	infoStyle    = lipgloss.NewStyle().Foreground(colors.Muted)
+	costStyle    = lipgloss.NewStyle().Foreground(colors.Highlight)
+	errorStyle   = lipgloss.NewStyle().Foreground(colors.Error)
+	borderStyle  = lipgloss.NewStyle().Foreground(colors.Border)
+	changedStyle = lipgloss.NewStyle().Foreground(colors.Inverse).Background(colors.OK)
+)
+
+// target is a model with a client for its provider.
+type target struct {
+	client   *openai.Client
+	provider catwalk.Provider
+	model    catwalk.Model
+	// maxTokens is --max-tokens, clamped to the model's output limit
+	maxTokens int
+}
+
+// arm is one combination of model and parameters. Nil or empty parameters
+// use the provider default.
+type arm struct {
+	target      target
+	temperature *float64
+	topP        *float64
+	effort      string
+	// run numbers repeated requests of the same arm from 1.
+	run int
+}
+
+// result is an arm's reply and what it cost.
+type result struct {
+	arm          arm
+	reply        string
+	inputTokens  int
+	outputTokens int
+	cost         float64
+	latency      time.Duration
+	err          error
+}
+
+func main() {
+	render.SetupConsole()
+	flag.Parse()
+
+	if *showHelp {
+		printHelp()
+		return
+	}
+	if *modelList == "" {
+		printHelp()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, errorStyle.Render("Error: "+err.Error()))
+		os.Exit(catwalk.ExitCode(err))
+	}
+}
+
+func run(ctx context.Context) error {
+	switch strings.ToLower(*outputFormat) {
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown format: %s (use text or json)", *outputFormat)
+	}
+	if *runs < 1 {
+		return errors.New("--runs must be at least 1")
+	}
+	text, err := readPrompt()
+	if err != nil {
+		return err
+	}
+	grid, err := parseGrid()
+	if err != nil {
+		return err
+	}
+
+	httpClient, err := network.Client()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	providers, err := snapshot.Fetch(ctx, catwalk.NewWithHTTPClient(httpClient), *catalogVersion)
+	if err != nil {
+		return fmt.Errorf("failed to fetch providers: %w", err)
+	}
+	base, err := network.Transport()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	// Resolve every model and check the grid against it before spending
+	// anything
+	var targets []target
+	for _, name := range strings.Split(*modelList, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		resolved, err := chat.Resolve(providers, name, base)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		t := target{client: resolved.Client, provider: resolved.Provider, model: resolved.Model}
+		if err := grid.check(t); err != nil {
+			return err
+		}
+		if t.maxTokens, err = replyLimit(t.model); err != nil {
+			return err
+		}
+		targets = append(targets, t)
+	}
+	arms := grid.arms(targets, *runs)
+	if len(arms) < 2 {
+		return errors.New("nothing to compare: give two or more models, several parameter values, or --runs > 1")
+	}
+
+	if *outputFormat == "text" {
+		fmt.Fprintf(os.Stderr, "%s %d requests...\n", infoStyle.Render("Sending"), len(arms))
+	}
+	results := sendAll(ctx, arms, text)
+	if ctx.Err() != nil {
+		return ctx.Err() //nolint:wrapcheck
+	}
+
+	if strings.EqualFold(*outputFormat, "json") {
+		return outputJSON(results)
+	}
+	outputText(results)
+	return nil
+}
+
+// replyLimit returns --max-tokens for a model, or an error if the model cannot
+// reply at that length, since providers reject such requests with an opaque
+// 400. With --clamp-max-tokens it returns the model's limit instead.
+func replyLimit(m catwalk.Model) (int, error) {
+	err := m.CheckMaxTokens(int64(*maxTokens))
+	if err == nil {
+		return *maxTokens, nil
+	}
+	if !*clampMax {
+		return 0, fmt.Errorf("%w: lower --max-tokens, or set --clamp-max-tokens", err)
+	}
+	limit := m.ClampMaxTokens(int64(*maxTokens))
+	if *outputFormat == "text" {
+		fmt.Fprintf(os.Stderr, "%s --max-tokens to %d for %s\n", infoStyle.Render("Clamping"), limit, m.ID)
+	}
+	return int(limit), nil
+}
+
+// readPrompt returns --prompt, the contents of --prompt-file, or stdin.
+func readPrompt() (string, error) {
+	var text string
+	switch {
+	case *prompt != "":
+		text = *prompt
+	case *promptFile != "":
+		data, err := os.ReadFile(*promptFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read prompt: %w", err)
+		}
+		text = string(data)
+	default:
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read prompt: %w", err)
+		}
+		text = string(data)
+	}
+	if strings.TrimSpace(text) == "" {
+		return "", errors.New("empty prompt: set --prompt or --prompt-file, or pipe it on stdin")
+	}
+	return text, nil
+}
+
+// sendAll sends the prompt once per arm, --parallel at a time, and returns
+// the results in arm order.
+func sendAll(ctx context.Context, arms []arm, text string) []result {
+	results := make([]result, len(arms))
+	sem := make(chan struct{}, max(*parallel, 1))
+	var wg sync.WaitGroup
+	for i, a := range arms {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			ctx, cancel := context.WithTimeout(ctx, *timeout)
+			defer cancel()
+			results[i] = send(ctx, a, text)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func send(ctx context.Context, a arm, text string) result {
+	session := chat.New(a.target.client, a.target.provider, a.target.model)
+	session.SetConfig(chat.Config{MaxTokens: a.target.maxTokens, Prepare: a.apply})
+	if *system != "" {
+		session.SetSystem(*system)
+	}
+
+	start := time.Now()
+	resp, err := session.Send(ctx, text)
+	r := result{arm: a, latency: time.Since(start), err: err}
+	if resp != nil {
+		r.reply = resp.Content
+		r.inputTokens, r.outputTokens, r.cost = resp.InputTokens, resp.OutputTokens, resp.Cost
+	}
+	return r
+}
+
+// printHelp displays usage information
+func printHelp() {
+	fmt.Println("ab-test - Compare replies to one prompt across models and sampling parameters")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  ab-test --models <list> [options] (--prompt <text> | --prompt-file <file> | < file)")
+	fmt.Println()
+	fmt.Println("Every combination of model and parameter values is an arm, sent --runs times.")
+	fmt.Println("Replies are shown side by side; words that differ from the first arm are")
+	fmt.Println("highlighted (marked {+like this+} when output is not a color terminal), with")
+	fmt.Println("each arm's similarity to the first, tokens, latency, and cost.")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --models <list>            Models to compare, as provider/model or a model ID (required)")
+	fmt.Println("  --prompt <text>            Prompt to send")
+	fmt.Println("  --prompt-file <file>       Read the prompt from a file (default: stdin)")
+	fmt.Println("  --system <text>            System prompt")
+	fmt.Println("  --temperature <list>       Temperatures to try, e.g. 0,0.7,1.2 (0-2)")
+	fmt.Println("  --top-p <list>             top_p values to try, e.g. 0.5,1 (0-1)")
+	fmt.Println("  --reasoning-effort <list>  Reasoning efforts to try, e.g. low,medium,high; only for")
+	fmt.Println("                             models that can reason, checked against their levels")
+	fmt.Println("  --runs <n>                 Requests per arm (default: 1)")
+	fmt.Println("  --max-tokens <n>           Maximum reply tokens (default: each model's default)")
+	fmt.Println("  --clamp-max-tokens         Lower --max-tokens to each model's output limit instead")
+	fmt.Println("                             of failing")
+	fmt.Println("  --parallel <n>             Requests sent concurrently (default: 4)")
+	fmt.Println("  --timeout <d>              Timeout per request (default: 2m)")
+	fmt.Println("  --width <n>                Width for the side-by-side columns (default: terminal")
+	fmt.Println("                             width, or 160 when piped)")
+	fmt.Println("  --format <fmt>             text (default) or json")
+	fmt.Println("  --catalog-version <v>      Use a stored catalog snapshot")
+	fmt.Println("  --proxy <url>              Proxy URL (default: HTTPS_PROXY/HTTP_PROXY from the environment)")
+	fmt.Println("  --ca-cert <pem>            PEM file with additional CA certificates to trust")
+	fmt.Println("  --insecure-skip-verify     Skip TLS certificate verification (unsafe)")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  ab-test --models openai/gpt-4o-mini --temperature 0,0.7,1.2 --prompt \"Name a color.\"")
+	fmt.Println("  ab-test --models gpt-4o,claude-sonnet-4-5 --prompt-file question.md")
+	fmt.Println("  ab-test --models openai/o4-mini --reasoning-effort low,high --prompt \"Is 1001 prime?\"")
+	fmt.Println("  ab-test --models gpt-4o-mini --temperature 1 --runs 3 --format json < prompt.txt")
+	fmt.Println()
+	fmt.Println("Cost is computed from catalog prices, or billed amounts where the provider")
+	fmt.Println("reports them (OpenRouter).")
+	fmt.Println()
+	fmt.Println("Exit Status:")
+	fmt.Println("  0 success, 1 error, 2 invalid usage, 3 provider not found,")
+	fmt.Println("  4 model not found, 5 missing API key, 10 unsupported feature")
+	fmt.Println()
+	fmt.Println("Environment Variables:")
+	fmt.Println("  CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)")
+	fmt.Println("  AIMODELS_THEME - Output theme (auto, dark, light, high-contrast, plain) or theme file")
+}
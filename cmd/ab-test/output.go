@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"charm.land/catwalk/pkg/render"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// minColumnWidth is the narrowest a reply column may be.
+const minColumnWidth = 36
+
+// outputText shows a summary table of the arms, then their replies side by
+// side, with the words that differ from the first arm highlighted.
+func outputText(results []result) {
+	baseline := render.DiffWords(results[0].reply)
+	tokens := make([][]string, len(results))
+	changed := make([][]bool, len(results))
+	similarity := make([]float64, len(results))
+	for i, r := range results {
+		tokens[i] = render.DiffWords(r.reply)
+		changed[i], similarity[i] = diff(baseline, tokens[i])
+	}
+	// The baseline is what the others are compared with, so nothing in it
+	// is marked
+	changed[0] = make([]bool, len(tokens[0]))
+
+	fmt.Println()
+	fmt.Println(headerStyle.Render(fmt.Sprintf("A/B Test: %d arms", len(results))))
+	tbl := render.NewTable(
+		render.Column{Title: "#", Align: render.AlignRight},
+		render.Column{Title: "Model", Style: armStyle},
+		render.Column{Title: "Parameters"},
+		render.Column{Title: "Similar", Align: render.AlignRight},
+		render.Column{Title: "In", Align: render.AlignRight},
+		render.Column{Title: "Out", Align: render.AlignRight},
+		render.Column{Title: "Latency", Align: render.AlignRight},
+		render.Column{Title: "Cost", Align: render.AlignRight, Style: costStyle},
+	)
+	var inputTokens, outputTokens int
+	var cost float64
+	for i, r := range results {
+		similar := fmt.Sprintf("%.0f%%", similarity[i]*100)
+		switch {
+		case r.err != nil:
+			similar = "error"
+		case i == 0:
+			similar = "base"
+		}
+		tbl.AddRow(fmt.Sprint(i+1), r.arm.model(), r.arm.params(), similar,
+			fmt.Sprint(r.inputTokens), fmt.Sprint(r.outputTokens),
+			fmt.Sprintf("%.1fs", r.latency.Seconds()), fmt.Sprintf("$%.6f", r.cost))
+		inputTokens += r.inputTokens
+		outputTokens += r.outputTokens
+		cost += r.cost
+	}
+	tbl.AddSeparator()
+	tbl.AddRow("", "Total", "", "", fmt.Sprint(inputTokens), fmt.Sprint(outputTokens), "", fmt.Sprintf("$%.6f", cost))
+	tbl.Print()
+
+	total := *width
+	if total <= 0 {
+		total = render.TerminalWidth()
+	}
+	if total <= 0 {
+		total = 160
+	}
+	separator := borderStyle.Render(" " + render.Symbol("│", "|") + " ")
+	perRow := max(1, min(len(results), (total+3)/(minColumnWidth+3)))
+	colWidth := max(minColumnWidth, (total-3*(perRow-1))/perRow)
+
+	for start := 0; start < len(results); start += perRow {
+		end := min(start+perRow, len(results))
+		columns := make([][]string, 0, end-start)
+		for i := start; i < end; i++ {
+			r := results[i]
+			lines := []string{
+				armStyle.Render(fmt.Sprintf("#%d %s", i+1, fit(r.arm.model(), colWidth-4))),
+				infoStyle.Render(fit(r.arm.params(), colWidth)),
+				borderStyle.Render(render.Rule(colWidth)),
+			}
+			if r.err != nil {
+				lines = append(lines, wrap(render.DiffWords("Error: "+r.err.Error()), nil, colWidth, errorStyle)...)
+			} else {
+				lines = append(lines, wrap(tokens[i], changed[i], colWidth, lipgloss.NewStyle())...)
+			}
+			columns = append(columns, lines)
+		}
+
+		fmt.Println()
+		height := 0
+		for _, c := range columns {
+			height = max(height, len(c))
+		}
+		for row := range height {
+			cells := make([]string, len(columns))
+			for i, c := range columns {
+				if row < len(c) {
+					cells[i] = c[row]
+				}
+				if i < len(columns)-1 {
+					cells[i] += strings.Repeat(" ", max(0, colWidth-lipgloss.Width(cells[i])))
+				}
+			}
+			fmt.Println(strings.Join(cells, separator))
+		}
+	}
+	fmt.Println()
+	if len(results) > 1 {
+		legend := "Highlighted words differ from #1."
+		if !colored() {
+			legend = "Words marked {+like this+} differ from #1."
+		}
+		fmt.Println(infoStyle.Render(legend))
+	}
+}
+
+// colored reports whether styles render with color, so changed words can
+// be highlighted rather than marked.
+func colored() bool {
+	return lipgloss.ColorProfile() != termenv.Ascii
+}
+
+// wrap lays out tokens in lines of at most width cells, highlighting the
+// changed ones.
+func wrap(tokens []string, changed []bool, width int, style lipgloss.Style) []string {
+	var lines []string
+	var line strings.Builder
+	used := 0
+	flush := func() {
+		lines = append(lines, line.String())
+		line.Reset()
+		used = 0
+	}
+
+	for i, t := range tokens {
+		if t == "\n" {
+			flush()
+			continue
+		}
+		word := style.Render(t)
+		if i < len(changed) && changed[i] {
+			if colored() {
+				word = changedStyle.Render(t)
+			} else {
+				t = "{+" + t + "+}"
+				word = t
+			}
+		}
+		w := lipgloss.Width(t)
+		if w > width {
+			// Too long for any line: cut it to fit
+			t = fit(t, width)
+			word, w = style.Render(t), lipgloss.Width(t)
+		}
+		if used > 0 && used+1+w > width {
+			flush()
+		}
+		if used > 0 {
+			line.WriteByte(' ')
+			used++
+		}
+		line.WriteString(word)
+		used += w
+	}
+	if used > 0 || len(lines) == 0 {
+		flush()
+	}
+	return lines
+}
+
+// fit truncates s to width cells.
+func fit(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	ellipsis := render.Symbol("…", "...")
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+lipgloss.Width(ellipsis) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + ellipsis
+}
+
+// jsonResult is one arm in the JSON output.
+type jsonResult struct {
+	Arm             int      `json:"arm"`
+	Provider        string   `json:"provider"`
+	Model           string   `json:"model"`
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"top_p,omitempty"`
+	ReasoningEffort string   `json:"reasoning_effort,omitempty"`
+	Run             int      `json:"run"`
+	Reply           string   `json:"reply"`
+	Similarity      float64  `json:"similarity"`
+	InputTokens     int      `json:"input_tokens"`
+	OutputTokens    int      `json:"output_tokens"`
+	Cost            float64  `json:"cost"`
+	LatencyMS       int64    `json:"latency_ms"`
+	Error           string   `json:"error,omitempty"`
+}
+
+// outputJSON writes the arms with their replies, similarity to the first
+// arm, and cost.
+func outputJSON(results []result) error {
+	baseline := render.DiffWords(results[0].reply)
+	out := struct {
+		Arms      []jsonResult `json:"arms"`
+		TotalCost float64      `json:"total_cost"`
+	}{}
+	for i, r := range results {
+		_, similarity := diff(baseline, render.DiffWords(r.reply))
+		j := jsonResult{
+			Arm:             i + 1,
+			Provider:        string(r.arm.target.provider.ID),
+			Model:           r.arm.target.model.ID,
+			Temperature:     r.arm.temperature,
+			TopP:            r.arm.topP,
+			ReasoningEffort: r.arm.effort,
+			Run:             r.arm.run,
+			Reply:           r.reply,
+			Similarity:      similarity,
+			InputTokens:     r.inputTokens,
+			OutputTokens:    r.outputTokens,
+			Cost:            r.cost,
+			LatencyMS:       r.latency.Milliseconds(),
+		}
+		if r.err != nil {
+			j.Error = r.err.Error()
+		}
+		out.Arms = append(out.Arms, j)
+		out.TotalCost += r.cost
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	return nil
+}
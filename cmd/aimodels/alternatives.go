@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/selector"
+)
+
+func runAlternatives(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("alternatives", flag.ExitOnError)
+	modelName := fs.String("model", "", "Model to replace: ID, provider/model, or the start of an ID (required)")
+	providerID := fs.String("provider", "", "Provider the model is used at (default: the first that lists it)")
+	otherProviders := fs.Bool("other-providers", false, "Only suggest models at other providers")
+	only := fs.String("only", "", "Comma-separated provider IDs to suggest from (default: all)")
+	minContext := fs.Int64("min-context", 0, "Smallest context window to accept (default: the model's)")
+	in := fs.Int64("in", 0, "Input tokens of the workload to estimate savings for")
+	out := fs.Int64("out", 0, "Output tokens of the workload")
+	limit := fs.Int("limit", 10, "Number of alternatives to list (0 for all)")
+	allowFree := fs.Bool("allow-free", false, "Include models with no listed price")
+	fs.Usage = printAlternativesHelp
+	_ = fs.Parse(args)
+
+	if *modelName == "" || fs.NArg() > 0 {
+		printAlternativesHelp()
+		return errUsage
+	}
+	if *minContext < 0 || *in < 0 || *out < 0 || *limit < 0 {
+		fmt.Fprintln(os.Stderr, errorStyle.Render("--min-context, --in, --out, and --limit must not be negative"))
+		return errUsage
+	}
+
+	providers, err := fetchProviders(ctx)
+	if err != nil {
+		return err
+	}
+	p, m, err := findAlternativesModel(providers, *providerID, *modelName)
+	if err != nil {
+		return err
+	}
+	original := selector.Match{Provider: *p, Model: *m}
+
+	opts := selector.AlternativeOptions{
+		MinContext:     *minContext,
+		OtherProviders: *otherProviders,
+		AllowFree:      *allowFree,
+		InputTokens:    *in,
+		OutputTokens:   *out,
+	}
+	for _, id := range splitList(*only) {
+		opts.Providers = append(opts.Providers, catwalk.InferenceProvider(id))
+	}
+	alts := selector.Alternatives(providers, original, opts)
+	printAlternatives(original, alts, opts, *limit)
+	return nil
+}
+
+// findAlternativesModel looks up the model to replace as the cost command
+// does, falling back to the closest completion of a partial ID, such as
+// claude-opus for claude-opus-4.
+func findAlternativesModel(providers []catwalk.Provider, providerID, name string) (*catwalk.Provider, *catwalk.Model, error) {
+	p, m, err := findCostModel(providers, providerID, name)
+	if !errors.Is(err, catwalk.ErrModelNotFound) {
+		return p, m, err
+	}
+
+	var ids []string
+	for _, p := range providers {
+		if providerID != "" && !strings.EqualFold(string(p.ID), providerID) {
+			continue
+		}
+		for _, m := range p.Models {
+			ids = append(ids, m.ID)
+		}
+	}
+	matches := catwalk.Complete(name, ids, 1)
+	if len(matches) == 0 || !strings.HasPrefix(strings.ToLower(matches[0]), strings.ToLower(name)) {
+		return nil, nil, err
+	}
+	p, m, findErr := findCostModel(providers, providerID, matches[0])
+	if findErr != nil {
+		return nil, nil, err
+	}
+	fmt.Fprintln(os.Stderr, infoStyle.Render(fmt.Sprintf("Using %s/%s for %s", p.ID, m.ID, name)))
+	return p, m, nil
+}
+
+// printAlternatives displays the ranked alternatives with their savings
+func printAlternatives(original selector.Match, alts []selector.Alternative, opts selector.AlternativeOptions, limit int) {
+	workload := "per 1M input + 1M output tokens"
+	if opts.InputTokens > 0 || opts.OutputTokens > 0 {
+		workload = fmt.Sprintf("for %s input + %s output tokens", formatTokens(opts.InputTokens), formatTokens(opts.OutputTokens))
+	}
+	base := opts.Cost(original.Model)
+
+	fmt.Println()
+	fmt.Println(headerStyle.Render("Cheaper alternatives to " + nameStyle.Render(string(original.Provider.ID)+"/"+original.Model.ID)))
+	fmt.Println(infoStyle.Render(fmt.Sprintf("%s at $%.2f %s, %s context%s", original.Model.ID, base, workload,
+		formatTokens(original.Model.ContextWindow), capabilityNote(original.Model))))
+	fmt.Println()
+
+	if base == 0 {
+		fmt.Println(warnStyle.Render("The catalog lists no prices for this model, so nothing is cheaper."))
+		return
+	}
+	if len(alts) == 0 {
+		fmt.Println(warnStyle.Render("No cheaper model has the same capabilities and context."))
+		fmt.Println(infoStyle.Render("Try a smaller --min-context, or drop --other-providers or --only."))
+		return
+	}
+
+	tbl := render.NewTable(
+		render.Column{Title: "#", Align: render.AlignRight},
+		render.Column{Title: "Model", MinWidth: 24, Style: nameStyle},
+		render.Column{Title: "Context", Align: render.AlignRight},
+		render.Column{Title: "$/1M in", Align: render.AlignRight},
+		render.Column{Title: "$/1M out", Align: render.AlignRight},
+		render.Column{Title: "Est. cost", Align: render.AlignRight},
+		render.Column{Title: "Savings", Align: render.AlignRight, Style: okStyle},
+		render.Column{Title: "Match"},
+	)
+	shown := alts
+	if limit > 0 && len(shown) > limit {
+		shown = shown[:limit]
+	}
+	for i, a := range shown {
+		match := fmt.Sprintf("%.0f", a.Similarity)
+		if a.SameModel {
+			match = "same model"
+		}
+		tbl.AddRow(fmt.Sprint(i+1), string(a.Provider.ID)+"/"+a.Model.ID, formatTokens(a.Model.ContextWindow),
+			fmt.Sprintf("$%.2f", a.Model.CostPer1MIn), fmt.Sprintf("$%.2f", a.Model.CostPer1MOut),
+			fmt.Sprintf("$%.2f", a.Cost), fmt.Sprintf("%.0f%% ($%.2f)", 100*a.Savings, base-a.Cost), match)
+	}
+	tbl.Print()
+	if len(shown) < len(alts) {
+		fmt.Println(infoStyle.Render(fmt.Sprintf("%d more; show them with --limit 0.", len(alts)-len(shown))))
+	}
+	fmt.Println(infoStyle.Render("Match rates how close a substitute each model is, from catalog data only;"))
+	fmt.Println(infoStyle.Render("check quality on your own prompts, as with eval, before switching."))
+}
+
+// capabilityNote lists the capabilities alternatives must keep
+func capabilityNote(m catwalk.Model) string {
+	var caps []string
+	if m.CanReason {
+		caps = append(caps, "reasoning")
+	}
+	if m.SupportsImages {
+		caps = append(caps, "vision")
+	}
+	if len(caps) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(caps, ", ")
+}
+
+// printAlternativesHelp displays usage information for the alternatives
+// command
+func printAlternativesHelp() {
+	fmt.Println("aimodels alternatives - Suggest cheaper substitutes for a model")
+	fmt.Println()
+	fmt.Println("Lists models that cost less than the given one and keep its capabilities:")
+	fmt.Println("reasoning and vision if it has them, and at least its context window.")
+	fmt.Println("The closest substitutes come first: the same model at another provider,")
+	fmt.Println("then models in the same latency class and family priced nearest to it.")
+	fmt.Println("Savings are estimated for a workload given with --in and --out, or per")
+	fmt.Println("1M input plus 1M output tokens. Overrides are applied, so negotiated")
+	fmt.Println("prices are used.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels alternatives --model <id> [options]")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --model <id>        Model to replace: ID, provider/model, or the start of")
+	fmt.Println("                      an ID (required)")
+	fmt.Println("  --provider <id>     Provider the model is used at (default: the first in")
+	fmt.Println("                      the catalog that lists it)")
+	fmt.Println("  --other-providers   Only suggest models at other providers")
+	fmt.Println("  --only <ids>        Comma-separated provider IDs to suggest from")
+	fmt.Println("  --min-context <n>   Smallest context window to accept (default: the model's)")
+	fmt.Println("  --in <n>            Input tokens of the workload to estimate savings for")
+	fmt.Println("  --out <n>           Output tokens of the workload")
+	fmt.Println("  --limit <n>         Number of alternatives to list, 0 for all (default: 10)")
+	fmt.Println("  --allow-free        Include models with no listed price")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels alternatives --model claude-opus")
+	fmt.Println("  aimodels alternatives --model anthropic/claude-opus-4-1 --other-providers")
+	fmt.Println("  aimodels alternatives --model gpt-4o --in 30000000 --out 2000000 --min-context 64000")
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/tokenizer"
+)
+
+// section is a part of a prompt: the text under a heading up to the next
+// heading or code fence, a fenced block, or what comes before either.
+type section struct {
+	title      string
+	start, end int // lines, from 1
+	tokens     int
+}
+
+var (
+	headingPattern = regexp.MustCompile(`^ {0,3}(#{1,6})\s+(.*?)[\s#]*$`)
+	fencePattern   = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})\\s*([^`\\s]*)")
+)
+
+// heatWidth is the width of the heat bars.
+const heatWidth = 16
+
+// preamble titles the text before the first heading.
+const preamble = "(before the first heading)"
+
+func runAnalyze(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
+	modelName := fs.String("model", "", "Model ID, or provider/model (default: the one set with aimodels init)")
+	providerID := fs.String("provider", "", "Provider to price the model at (default: the first that lists it)")
+	top := fs.Int("top", 3, "Most expensive sections to highlight")
+	calls := fs.Int64("calls", 1, "Requests sending the prompt, to price them all")
+	fs.Usage = printAnalyzeHelp
+	_ = fs.Parse(args)
+
+	// The file may come before the flags, as in "analyze prompt.md --model x"
+	var file string
+	if fs.NArg() > 0 {
+		file = fs.Arg(0)
+		_ = fs.Parse(fs.Args()[1:])
+	}
+	defaultModel(modelName, providerID)
+	if file == "" || *modelName == "" || fs.NArg() > 0 {
+		printAnalyzeHelp()
+		return errUsage
+	}
+	if *top < 0 || *calls < 1 {
+		fmt.Fprintln(os.Stderr, errorStyle.Render("--top must not be negative and --calls must be at least 1"))
+		return errUsage
+	}
+
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read prompt: %w", err)
+	}
+	sections := splitSections(string(data))
+	if len(sections) == 0 {
+		return fmt.Errorf("%s is empty", file)
+	}
+
+	providers, err := fetchProviders(ctx)
+	if err != nil {
+		return err
+	}
+	p, m, err := findCostModel(providers, *providerID, *modelName)
+	if err != nil {
+		return err
+	}
+	price := m.CostPer1MIn * float64(*calls) / 1_000_000
+
+	// Rank sections by tokens, which orders them by cost too
+	total, largest := 0, 0
+	ranked := make([]int, len(sections))
+	for i, s := range sections {
+		total += s.tokens
+		largest = max(largest, s.tokens)
+		ranked[i] = i
+	}
+	slices.SortStableFunc(ranked, func(a, b int) int { return cmp.Compare(sections[b].tokens, sections[a].tokens) })
+	hot := map[int]int{}
+	for rank, i := range ranked[:min(*top, len(ranked))] {
+		hot[i] = rank + 1
+	}
+
+	costTitle := "Cost"
+	if *calls > 1 {
+		costTitle = "Cost ×" + strconv.FormatInt(*calls, 10)
+	}
+	tbl := render.NewTable(
+		render.Column{Title: "Rank", Align: render.AlignRight},
+		render.Column{Title: "Section", MinWidth: 16},
+		render.Column{Title: "Lines", Align: render.AlignRight},
+		render.Column{Title: "Tokens", Align: render.AlignRight},
+		render.Column{Title: "Share", Align: render.AlignRight},
+		render.Column{Title: costTitle, Align: render.AlignRight},
+		render.Column{Title: "Heat", MinWidth: heatWidth},
+	)
+	for i, s := range sections {
+		rank, style := "", infoStyle
+		if r, ok := hot[i]; ok {
+			rank, style = strconv.Itoa(r), warnStyle
+		}
+		bar := style.Render(render.Bar(float64(s.tokens)/float64(max(largest, 1)), heatWidth))
+		tbl.AddRow(rank, s.title, fmt.Sprintf("%d-%d", s.start, s.end), strconv.Itoa(s.tokens),
+			fmt.Sprintf("%.1f%%", share(s.tokens, total)), fmt.Sprintf("$%.6f", float64(s.tokens)*price), bar)
+	}
+	tbl.AddSeparator()
+	tbl.AddRow("", "Total", "", strconv.Itoa(total), "100.0%", fmt.Sprintf("$%.6f", float64(total)*price), "")
+
+	fmt.Println()
+	fmt.Println(headerStyle.Render("Prompt cost of " + nameStyle.Render(file) + " on " + nameStyle.Render(string(p.ID)+"/"+m.ID)))
+	fmt.Println(infoStyle.Render(fmt.Sprintf("%d sections, ~%s tokens (estimated) at $%.2f/1M input", len(sections), formatTokens(int64(total)), m.CostPer1MIn)))
+	tbl.Print()
+
+	if m.CostPer1MIn == 0 {
+		fmt.Println(warnStyle.Render("The catalog lists no input price for this model."))
+		return nil
+	}
+	if len(hot) > 0 && len(sections) > 1 {
+		var names []string
+		tokens := 0
+		for _, i := range ranked[:len(hot)] {
+			names = append(names, sections[i].title)
+			tokens += sections[i].tokens
+		}
+		fmt.Println(warnStyle.Render("Most expensive: " + strings.Join(names, ", ")))
+		fmt.Println(infoStyle.Render(fmt.Sprintf("Together they are %.0f%% of the prompt, $%.6f; every 1K tokens trimmed saves $%.6f.",
+			share(tokens, total), float64(tokens)*price, 1000*price)))
+	}
+	return nil
+}
+
+// share returns n as a percentage of total
+func share(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+// splitSections splits a prompt at Markdown headings and code fences, with
+// each fenced block a section of its own. Text after a block, under the same
+// heading, continues that heading's section as another. Blank sections are
+// dropped.
+func splitSections(text string) []section {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var sections []section
+	heading := preamble
+	current := section{title: heading, start: 1}
+	var body strings.Builder
+	flush := func(end int) {
+		if strings.TrimSpace(body.String()) != "" {
+			current.end = end
+			current.tokens = tokenizer.Count(body.String())
+			sections = append(sections, current)
+		}
+		body.Reset()
+	}
+
+	fence := ""
+	for i, line := range lines {
+		n := i + 1
+		trimmed := strings.TrimSpace(line)
+		switch m := fencePattern.FindStringSubmatch(strings.TrimRight(line, "\r\n")); {
+		case fence != "":
+			body.WriteString(line)
+			// A closing fence is at least as long as the opening one
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				flush(n)
+				fence = ""
+				current = section{title: heading + " (continued)", start: n + 1}
+			}
+			continue
+		case m != nil:
+			flush(n - 1)
+			fence = m[1]
+			title := "code block"
+			if m[2] != "" {
+				title = m[2] + " code block"
+			}
+			current = section{title: title + " in " + heading, start: n}
+			if heading == preamble {
+				current.title = title
+			}
+		default:
+			if h := headingPattern.FindStringSubmatch(strings.TrimRight(line, "\r\n")); h != nil {
+				flush(n - 1)
+				heading = h[1] + " " + h[2]
+				current = section{title: heading, start: n}
+			}
+		}
+		body.WriteString(line)
+	}
+	flush(len(lines))
+	return sections
+}
+
+// printAnalyzeHelp displays usage information for the analyze command
+func printAnalyzeHelp() {
+	fmt.Println("aimodels analyze - Show which sections of a prompt cost the most")
+	fmt.Println()
+	fmt.Println("Splits a prompt file at Markdown headings and code fences, estimates the")
+	fmt.Println("tokens of each section, and prices them at the model's input rate. The")
+	fmt.Println("heat bars compare each section with the largest, and the --top most")
+	fmt.Println("expensive are ranked and highlighted, to show where trimming pays off.")
+	fmt.Println("Token counts are estimates (see pkg/tokenizer); overrides are applied.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels analyze <file|-> --model <id> [options]")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --model <id>        Model ID, or provider/model (required unless set with")
+	fmt.Println("                      aimodels init)")
+	fmt.Println("  --provider <id>     Provider to price the model at (default: the first")
+	fmt.Println("                      in the catalog that lists it)")
+	fmt.Println("  --top <n>           Most expensive sections to highlight (default: 3)")
+	fmt.Println("  --calls <n>         Price this many requests sending the prompt (default: 1)")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels analyze system-prompt.md --model gpt-4o")
+	fmt.Println("  aimodels analyze agent.md --model anthropic/claude-sonnet-4-5 --calls 10000")
+	fmt.Println("  cat prompt.md | aimodels analyze - --model gpt-4o-mini --top 5")
+}
@@ -0,0 +1,554 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/selector"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/term"
+)
+
+// lineupComparison is the JSON output of compare-providers: the models of
+// providers A and B lined up by class, with B's deltas from A.
+type lineupComparison struct {
+	A     string      `json:"a"`
+	B     string      `json:"b"`
+	Pairs []lineupRow `json:"pairs"`
+}
+
+type lineupRow struct {
+	Class selector.Class `json:"class"`
+	A     *lineupModel   `json:"a"`
+	B     *lineupModel   `json:"b"`
+	// Delta is set when both sides have a model.
+	Delta *lineupDelta `json:"delta,omitempty"`
+}
+
+type lineupModel struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	CostPer1MIn   float64  `json:"cost_per_1m_in"`
+	CostPer1MOut  float64  `json:"cost_per_1m_out"`
+	ContextWindow int64    `json:"context_window"`
+	Capabilities  []string `json:"capabilities"`
+
+	model catwalk.Model
+}
+
+type lineupDelta struct {
+	// Percentage changes from A's price to B's, or null if A's is zero.
+	InputPct  *float64 `json:"input_pct"`
+	OutputPct *float64 `json:"output_pct"`
+	// Context is B's context window minus A's, in tokens.
+	Context int64 `json:"context"`
+	// Capabilities only B has, and only A has.
+	Added   []string `json:"capabilities_added"`
+	Removed []string `json:"capabilities_removed"`
+}
+
+func runCompareProviders(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("compare-providers", flag.ExitOnError)
+	classList := fs.String("class", "", "Comma-separated classes to compare: small, medium, large, reasoning (default: all)")
+	format := fs.String("format", "table", "Output format: table or json")
+	fs.Usage = printCompareProvidersHelp
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		printCompareProvidersHelp()
+		return errUsage
+	}
+	switch *format {
+	case "table", "json":
+	default:
+		return fmt.Errorf("unknown format: %s (use table or json)", *format)
+	}
+	var classes []selector.Class
+	for _, name := range splitList(*classList) {
+		c, err := selector.ParseClass(name)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		classes = append(classes, c)
+	}
+
+	providers, err := fetchProviders(ctx)
+	if err != nil {
+		return err
+	}
+	a, err := catwalk.FindProvider(providers, fs.Arg(0))
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	b, err := catwalk.FindProvider(providers, fs.Arg(1))
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	lineups := compareLineups(*a, *b, classes)
+	switch {
+	case *format == "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(lineups); err != nil {
+			return fmt.Errorf("failed to write JSON: %w", err)
+		}
+	case !term.IsTerminal(os.Stdout.Fd()):
+		printLineups(*a, *b, lineups)
+	default:
+		v := lineupView{a: *a, b: *b, lineups: lineups, width: 80}
+		for _, c := range selector.Classes {
+			if len(classes) == 0 || slices.Contains(classes, c) {
+				v.classes = append(v.classes, c)
+			}
+		}
+		if _, err := tea.NewProgram(v, tea.WithAltScreen()).Run(); err != nil {
+			return fmt.Errorf("compare-providers failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// compareLineups lines up the models of a and b, keeping only classes if
+// any are given.
+func compareLineups(a, b catwalk.Provider, classes []selector.Class) lineupComparison {
+	lineups := lineupComparison{A: string(a.ID), B: string(b.ID), Pairs: []lineupRow{}}
+	for _, p := range selector.AlignLineups(a, b) {
+		if len(classes) > 0 && !slices.Contains(classes, p.Class) {
+			continue
+		}
+		row := lineupRow{Class: p.Class, A: newLineupModel(p.A), B: newLineupModel(p.B)}
+		if row.A != nil && row.B != nil {
+			row.Delta = &lineupDelta{
+				InputPct:  pctChange(p.A.CostPer1MIn, p.B.CostPer1MIn),
+				OutputPct: pctChange(p.A.CostPer1MOut, p.B.CostPer1MOut),
+				Context:   p.B.ContextWindow - p.A.ContextWindow,
+				Added:     missingFrom(row.B.Capabilities, row.A.Capabilities),
+				Removed:   missingFrom(row.A.Capabilities, row.B.Capabilities),
+			}
+		}
+		lineups.Pairs = append(lineups.Pairs, row)
+	}
+	return lineups
+}
+
+func newLineupModel(m *catwalk.Model) *lineupModel {
+	if m == nil {
+		return nil
+	}
+	l := &lineupModel{ID: m.ID, Name: m.Name, CostPer1MIn: m.CostPer1MIn, CostPer1MOut: m.CostPer1MOut,
+		ContextWindow: m.ContextWindow, Capabilities: []string{}, model: *m}
+	for _, c := range capabilities {
+		if c.has(*m) {
+			l.Capabilities = append(l.Capabilities, strings.ReplaceAll(strings.ToLower(c.name), " ", "_"))
+		}
+	}
+	return l
+}
+
+// pctChange returns the percentage change from a to b, or nil if a is
+// zero.
+func pctChange(a, b float64) *float64 {
+	if a == 0 {
+		return nil
+	}
+	pct := math.Round((b-a)/a*1000) / 10
+	return &pct
+}
+
+// missingFrom returns the items of list that other lacks.
+func missingFrom(list, other []string) []string {
+	out := []string{}
+	for _, s := range list {
+		if !slices.Contains(other, s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// rows returns the pairs of one class.
+func (c lineupComparison) rows(class selector.Class) []lineupRow {
+	var rows []lineupRow
+	for _, r := range c.Pairs {
+		if r.Class == class {
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+// modelCell shows a side of a pair, or a dash if it has no model.
+func modelCell(m *lineupModel) string {
+	if m == nil {
+		return "-"
+	}
+	return m.ID
+}
+
+// priceCell shows one price of a pair and, when both sides have it, how
+// much B's differs, green if B is cheaper.
+func priceCell(a, b *lineupModel, price func(*lineupModel) float64) string {
+	switch {
+	case a == nil:
+		return fmt.Sprintf("$%.2f", price(b))
+	case b == nil:
+		return fmt.Sprintf("$%.2f", price(a))
+	}
+	return fmt.Sprintf("$%.2f %s $%.2f", price(a), render.Symbol("→", "->"), price(b)) + deltaNote(price(a), price(b), true)
+}
+
+// contextCell shows the context windows of a pair, green if B's is larger.
+func contextCell(a, b *lineupModel) string {
+	switch {
+	case a == nil:
+		return formatTokens(b.ContextWindow)
+	case b == nil:
+		return formatTokens(a.ContextWindow)
+	}
+	return formatTokens(a.ContextWindow) + " " + render.Symbol("→", "->") + " " + formatTokens(b.ContextWindow) +
+		deltaNote(float64(a.ContextWindow), float64(b.ContextWindow), false)
+}
+
+// deltaNote formats the change from a to b as " (+20%)", styled as good or
+// bad news; lowerIsBetter is set for prices.
+func deltaNote(a, b float64, lowerIsBetter bool) string {
+	pct := pctChange(a, b)
+	if pct == nil || *pct == 0 {
+		return ""
+	}
+	style := okStyle
+	if (*pct > 0) == lowerIsBetter {
+		style = warnStyle
+	}
+	return " " + style.Render(fmt.Sprintf("(%+.0f%%)", *pct))
+}
+
+// capabilityCell shows the capabilities B gains and loses against A, or a
+// lone model's capabilities.
+func capabilityCell(r lineupRow) string {
+	if r.Delta == nil {
+		return strings.Join(cmp.Or(r.A, r.B).Capabilities, ", ")
+	}
+	var parts []string
+	for _, c := range r.Delta.Added {
+		parts = append(parts, okStyle.Render("+"+c))
+	}
+	for _, c := range r.Delta.Removed {
+		parts = append(parts, warnStyle.Render("-"+c))
+	}
+	if len(parts) == 0 {
+		return infoStyle.Render("same")
+	}
+	return strings.Join(parts, " ")
+}
+
+// lineupSummary shows each class's model count and median input price on
+// both sides.
+func lineupSummary(a, b catwalk.Provider, lineups lineupComparison, classes []selector.Class) *render.Table {
+	tbl := render.NewTable(
+		render.Column{Title: "Class", Style: nameStyle},
+		render.Column{Title: string(a.ID), Align: render.AlignRight},
+		render.Column{Title: string(b.ID), Align: render.AlignRight},
+		render.Column{Title: "Median in " + string(a.ID), Align: render.AlignRight},
+		render.Column{Title: "Median in " + string(b.ID), Align: render.AlignRight},
+	)
+	for _, class := range classes {
+		var inA, inB []float64
+		for _, r := range lineups.rows(class) {
+			if r.A != nil {
+				inA = append(inA, r.A.CostPer1MIn)
+			}
+			if r.B != nil {
+				inB = append(inB, r.B.CostPer1MIn)
+			}
+		}
+		medianCell := func(prices []float64) string {
+			if len(prices) == 0 {
+				return "-"
+			}
+			return fmt.Sprintf("$%.2f", median(prices))
+		}
+		tbl.AddRow(string(class), fmt.Sprint(len(inA)), fmt.Sprint(len(inB)), medianCell(inA), medianCell(inB))
+	}
+	return tbl
+}
+
+// printLineups writes the summary and every class once, for when output is
+// not a terminal.
+func printLineups(a, b catwalk.Provider, lineups lineupComparison) {
+	var classes []selector.Class
+	for _, c := range selector.Classes {
+		if len(lineups.rows(c)) > 0 {
+			classes = append(classes, c)
+		}
+	}
+	fmt.Println()
+	fmt.Println(headerStyle.Render(fmt.Sprintf("%s vs %s", a.Name, b.Name)))
+	if len(classes) == 0 {
+		fmt.Println(warnStyle.Render("Neither provider has models in the selected classes."))
+		return
+	}
+	lineupSummary(a, b, lineups, classes).Print()
+
+	for _, class := range classes {
+		tbl := render.NewTable(
+			render.Column{Title: string(a.ID), MinWidth: 16, Style: nameStyle},
+			render.Column{Title: string(b.ID), MinWidth: 16, Style: nameStyle},
+			render.Column{Title: "$/1M in", Align: render.AlignRight},
+			render.Column{Title: "$/1M out", Align: render.AlignRight},
+			render.Column{Title: "Context", Align: render.AlignRight},
+			render.Column{Title: "Capabilities"},
+		)
+		for _, r := range lineups.rows(class) {
+			tbl.AddRow(modelCell(r.A), modelCell(r.B),
+				priceCell(r.A, r.B, func(m *lineupModel) float64 { return m.CostPer1MIn }),
+				priceCell(r.A, r.B, func(m *lineupModel) float64 { return m.CostPer1MOut }),
+				contextCell(r.A, r.B), capabilityCell(r))
+		}
+		fmt.Println()
+		fmt.Println(headerStyle.Render(strings.ToUpper(string(class[:1])) + string(class[1:])))
+		tbl.Print()
+	}
+	fmt.Println(infoStyle.Render(fmt.Sprintf("Deltas are %s's from %s's. Classes are guessed from model IDs,", b.ID, a.ID)))
+	fmt.Println(infoStyle.Render("and models are paired with the closest price in the same class."))
+}
+
+// lineupView is the interactive comparison: a tab per class listing the
+// pairs, and Enter shows a pair side by side in full.
+type lineupView struct {
+	a, b    catwalk.Provider
+	lineups lineupComparison
+	classes []selector.Class
+
+	tab    int
+	cursor []int // selected pair on each tab
+	detail bool  // showing the selected pair
+	width  int
+	height int
+}
+
+// Init initializes the view
+func (v lineupView) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles key presses and resizes
+func (v lineupView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if v.cursor == nil {
+		v.cursor = make([]int, len(v.classes))
+	}
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.width, v.height = msg.Width, msg.Height
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return v, tea.Quit
+		case "esc", "backspace":
+			switch {
+			case v.detail:
+				v.detail = false
+			case msg.String() == "esc":
+				return v, tea.Quit
+			}
+		case "tab", "right", "l":
+			if !v.detail {
+				v.tab = (v.tab + 1) % len(v.classes)
+			}
+		case "shift+tab", "left", "h":
+			if !v.detail {
+				v.tab = (v.tab + len(v.classes) - 1) % len(v.classes)
+			}
+		case "up", "k":
+			v.move(-1)
+		case "down", "j":
+			v.move(1)
+		case "enter":
+			v.detail = len(v.rows()) > 0
+		}
+	}
+	return v, nil
+}
+
+// move moves the selection by n pairs.
+func (v *lineupView) move(n int) {
+	if !v.detail {
+		v.cursor[v.tab] = min(max(v.cursor[v.tab]+n, 0), max(len(v.rows())-1, 0))
+	}
+}
+
+// rows returns the pairs of the current tab.
+func (v lineupView) rows() []lineupRow {
+	return v.lineups.rows(v.classes[v.tab])
+}
+
+// View renders the comparison
+func (v lineupView) View() string {
+	var sb strings.Builder
+	sb.WriteString(headerStyle.Render(fmt.Sprintf("%s vs %s", v.a.Name, v.b.Name)))
+	sb.WriteString("\n")
+	sb.WriteString(lineupSummary(v.a, v.b, v.lineups, v.classes).Render())
+	sb.WriteString("\n")
+	for i, c := range v.classes {
+		label := fmt.Sprintf("%s (%d)", c, len(v.lineups.rows(c)))
+		if i == v.tab {
+			sb.WriteString(activeTab.Render(label))
+		} else {
+			sb.WriteString(tabStyle.Render(label))
+		}
+	}
+	sb.WriteString("\n\n")
+
+	var lines []string
+	help := "←/→ class • ↑/↓ select • enter details • q quit"
+	if v.detail {
+		lines = strings.Split(v.detailTable(), "\n")
+		help = "esc back • q quit"
+	} else {
+		lines = v.pairLines()
+	}
+	for _, line := range lines {
+		sb.WriteString(ansi.Truncate(line, v.width, "…"))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(infoStyle.Render(help))
+	return sb.String()
+}
+
+// pairLines lists the current tab's pairs with their input price deltas.
+func (v lineupView) pairLines() []string {
+	rows := v.rows()
+	if len(rows) == 0 {
+		return []string{infoStyle.Render("Neither provider has models in this class.")}
+	}
+	cursor := 0
+	if v.cursor != nil {
+		cursor = v.cursor[v.tab]
+	}
+	listRows := 20
+	if v.height > 0 {
+		listRows = max(v.height-len(v.classes)-12, 3)
+	}
+	start, end := visible(cursor, len(rows), listRows)
+	var lines []string
+	for i := start; i < end; i++ {
+		r := rows[i]
+		a, b := fmt.Sprintf("%-30s", ansi.Truncate(modelCell(r.A), 30, "…")), fmt.Sprintf("%-30s", ansi.Truncate(modelCell(r.B), 30, "…"))
+		prefix := "  "
+		if i == cursor {
+			prefix = selectedStyle.Render(render.Symbol("▸ ", "> "))
+			a, b = selectedStyle.Render(a), selectedStyle.Render(b)
+		}
+		lines = append(lines, fmt.Sprintf("%s%s %s %s  %s", prefix, a, b,
+			priceCell(r.A, r.B, func(m *lineupModel) float64 { return m.CostPer1MIn }), capabilityCell(r)))
+	}
+	return lines
+}
+
+// detailTable shows the selected pair side by side in full.
+func (v lineupView) detailTable() string {
+	r := v.rows()[v.cursor[v.tab]]
+	tbl := render.NewTable(
+		render.Column{Title: "", Style: infoStyle},
+		render.Column{Title: string(v.a.ID), MinWidth: 16},
+		render.Column{Title: string(v.b.ID), MinWidth: 16},
+		render.Column{Title: "Change"},
+	)
+	side := func(m *lineupModel, value func(catwalk.Model) string) string {
+		if m == nil {
+			return "-"
+		}
+		return value(m.model)
+	}
+	change := func(get func(catwalk.Model) float64, lowerIsBetter bool) string {
+		if r.A == nil || r.B == nil {
+			return ""
+		}
+		return strings.TrimSpace(deltaNote(get(r.A.model), get(r.B.model), lowerIsBetter))
+	}
+	price := func(get func(catwalk.Model) float64) func(catwalk.Model) string {
+		return func(m catwalk.Model) string { return fmt.Sprintf("$%.2f", get(m)) }
+	}
+	numbers := []struct {
+		label         string
+		get           func(catwalk.Model) float64
+		format        func(catwalk.Model) string
+		lowerIsBetter bool
+	}{
+		{"$/1M in", func(m catwalk.Model) float64 { return m.CostPer1MIn }, nil, true},
+		{"$/1M out", func(m catwalk.Model) float64 { return m.CostPer1MOut }, nil, true},
+		{"$/1M cached in", func(m catwalk.Model) float64 { return m.CostPer1MInCached }, nil, true},
+		{"Context", func(m catwalk.Model) float64 { return float64(m.ContextWindow) },
+			func(m catwalk.Model) string { return formatTokens(m.ContextWindow) }, false},
+		{"Max output", func(m catwalk.Model) float64 { return float64(m.OutputLimit()) },
+			func(m catwalk.Model) string { return formatTokens(m.OutputLimit()) }, false},
+	}
+
+	tbl.AddRow("Model", side(r.A, func(m catwalk.Model) string { return m.ID }), side(r.B, func(m catwalk.Model) string { return m.ID }), "")
+	tbl.AddRow("Name", side(r.A, func(m catwalk.Model) string { return m.Name }), side(r.B, func(m catwalk.Model) string { return m.Name }), "")
+	tbl.AddSeparator()
+	for _, n := range numbers {
+		format := n.format
+		if format == nil {
+			format = price(n.get)
+		}
+		tbl.AddRow(n.label, side(r.A, format), side(r.B, format), change(n.get, n.lowerIsBetter))
+	}
+	tbl.AddSeparator()
+	for _, c := range capabilities {
+		has := func(m catwalk.Model) string {
+			if c.has(m) {
+				return okStyle.Render(render.Symbol("✓", "yes"))
+			}
+			return infoStyle.Render("-")
+		}
+		tbl.AddRow(c.name, side(r.A, has), side(r.B, has), "")
+	}
+	return tbl.Render()
+}
+
+// printCompareProvidersHelp displays usage information for the
+// compare-providers command
+func printCompareProvidersHelp() {
+	fmt.Println("aimodels compare-providers - Diff two providers' model lineups")
+	fmt.Println()
+	fmt.Println("Lines up comparable models of two providers by class (small, medium,")
+	fmt.Println("large, and dedicated reasoning models) and shows the second provider's")
+	fmt.Println("price, context, and capability deltas from the first, side by side.")
+	fmt.Println("Classes are guessed from model IDs, such as mini or haiku for small and")
+	fmt.Println("opus or pro for large. Within a class, models priced most alike are")
+	fmt.Println("paired; models left over are listed alone. In a terminal, select a pair")
+	fmt.Println("to see it in full; otherwise the tables are printed once.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels compare-providers [options] <provider-a> <provider-b>")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --class <names>   Comma-separated classes to compare: small, medium, large,")
+	fmt.Println("                    reasoning (default: all)")
+	fmt.Println("  --format <fmt>    table (default), or json with each pair's models and")
+	fmt.Println("                    deltas for programmatic use")
+	fmt.Println()
+	fmt.Println("Keys:")
+	fmt.Println("  ←/→, tab    Switch between classes")
+	fmt.Println("  ↑/↓, enter  Select a pair and show it in full")
+	fmt.Println("  esc         Go back; q quits")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels compare-providers openai anthropic")
+	fmt.Println("  aimodels compare-providers --class small,reasoning openai gemini")
+	fmt.Println("  aimodels compare-providers --format json openai anthropic | jq '.pairs[] | select(.class == \"large\")'")
+}
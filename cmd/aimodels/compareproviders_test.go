@@ -0,0 +1,75 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/selector"
+)
+
+func TestCompareLineups(t *testing.T) {
+	a := catwalk.Provider{ID: "openai", Models: []catwalk.Model{
+		{ID: "gpt-4o", CostPer1MIn: 2.5, CostPer1MOut: 10, ContextWindow: 128_000, SupportsImages: true, CostPer1MInCached: 1.25},
+		{ID: "gpt-4o-mini", CostPer1MIn: 0.15, CostPer1MOut: 0.6, ContextWindow: 128_000},
+		{ID: "o3", CostPer1MIn: 2, CostPer1MOut: 8, CanReason: true},
+	}}
+	b := catwalk.Provider{ID: "anthropic", Models: []catwalk.Model{
+		{ID: "claude-sonnet-4", CostPer1MIn: 3, CostPer1MOut: 15, ContextWindow: 200_000, CanReason: true},
+		{ID: "claude-haiku-4"},
+	}}
+
+	tests := []struct {
+		name    string
+		classes []selector.Class
+		want    []string
+	}{
+		{"all classes", nil, []string{"small gpt-4o-mini claude-haiku-4", "medium gpt-4o claude-sonnet-4", "reasoning o3 -"}},
+		{"selected classes", []selector.Class{selector.ClassReasoning, selector.ClassMedium}, []string{"medium gpt-4o claude-sonnet-4", "reasoning o3 -"}},
+		{"class neither has", []selector.Class{selector.ClassLarge}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := compareLineups(a, b, tt.classes)
+			if c.A != "openai" || c.B != "anthropic" || c.Pairs == nil {
+				t.Errorf("A, B, Pairs = %q, %q, %v", c.A, c.B, c.Pairs)
+			}
+			var got []string
+			for _, r := range c.Pairs {
+				got = append(got, string(r.Class)+" "+modelCell(r.A)+" "+modelCell(r.B))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("pairs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	pairs := compareLineups(a, b, nil).Pairs
+	if d := pairs[2].Delta; d != nil {
+		t.Errorf("lone o3 has a delta: %+v", d)
+	}
+
+	medium := pairs[1].Delta
+	if medium == nil {
+		t.Fatal("gpt-4o and claude-sonnet-4 have no delta")
+	}
+	if medium.InputPct == nil || *medium.InputPct != 20 || medium.OutputPct == nil || *medium.OutputPct != 50 {
+		t.Errorf("InputPct, OutputPct = %v, %v, want 20, 50", medium.InputPct, medium.OutputPct)
+	}
+	if medium.Context != 72_000 {
+		t.Errorf("Context = %d, want 72000", medium.Context)
+	}
+	if !slices.Equal(medium.Added, []string{"reasoning"}) || !slices.Equal(medium.Removed, []string{"vision", "caching"}) {
+		t.Errorf("Added, Removed = %q, %q, want [reasoning], [vision caching]", medium.Added, medium.Removed)
+	}
+
+	// claude-haiku-4 is unpriced: its change from gpt-4o-mini is -100%,
+	// and no change can be given from a zero price.
+	small := pairs[0].Delta
+	if small.InputPct == nil || *small.InputPct != -100 {
+		t.Errorf("small InputPct = %v, want -100", small.InputPct)
+	}
+	if pct := pctChange(0, 1); pct != nil {
+		t.Errorf("pctChange(0, 1) = %v, want nil", *pct)
+	}
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/config"
+	"charm.land/catwalk/pkg/selector"
+	"charm.land/catwalk/pkg/snapshot"
+)
+
+// completionScripts are the shell scripts printed by 'aimodels completion'.
+// Each passes the command line up to the cursor to 'aimodels __complete' and
+// offers the lines it prints, falling back to file names when there are none.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for aimodels
+_aimodels() {
+    local line="${COMP_LINE:0:COMP_POINT}"
+    local -a words
+    read -ra words <<< "$line"
+    [[ -z "$line" || "$line" == *[[:space:]] ]] && words+=("")
+    # bash splits words at = and :, so strip what it will not replace
+    local keep="${words[${#words[@]}-1]}"
+    keep="${keep%"${COMP_WORDS[COMP_CWORD]}"}"
+    local IFS=$'\n'
+    COMPREPLY=($(aimodels __complete "$line" 2>/dev/null))
+    COMPREPLY=("${COMPREPLY[@]#"$keep"}")
+    if [[ ${#COMPREPLY[@]} -eq 0 ]]; then
+        compopt -o default
+    fi
+}
+complete -F _aimodels aimodels
+`,
+	"zsh": `#compdef aimodels
+# zsh completion for aimodels
+_aimodels() {
+    local -a candidates
+    candidates=("${(@f)$(aimodels __complete "${(j: :)words[1,CURRENT]}" 2>/dev/null)}")
+    candidates=(${candidates:#})
+    if (( ${#candidates} )); then
+        compadd -Q -- "${candidates[@]}"
+    else
+        _files
+    fi
+}
+if [ "$funcstack[1]" = "_aimodels" ]; then
+    _aimodels "$@"
+else
+    compdef _aimodels aimodels
+fi
+`,
+	"fish": `# fish completion for aimodels
+function __aimodels_complete
+    set -l line (commandline -cp)
+    set -l candidates (aimodels __complete "$line" 2>/dev/null)
+    if test (count $candidates) -gt 0
+        printf '%s\n' $candidates
+    else
+        __fish_complete_path (commandline -ct)
+    end
+end
+complete -c aimodels -f -a '(__aimodels_complete)'
+`,
+	"powershell": `# PowerShell completion for aimodels
+Register-ArgumentCompleter -Native -CommandName aimodels -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $line = $commandAst.ToString()
+    $length = $cursorPosition - $commandAst.Extent.StartOffset
+    if ($line.Length -lt $length) {
+        $line = $line.PadRight($length)
+    } else {
+        $line = $line.Substring(0, $length)
+    }
+    aimodels __complete $line 2>$null | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`,
+}
+
+// completionSpec lists the subcommands and flags of a command. Flags that
+// take values are completed differently from boolean ones, so the two are
+// kept apart.
+type completionSpec struct {
+	subcommands []string
+	flags       []string
+	bools       []string
+	// providerArgs is how many arguments are provider IDs.
+	providerArgs int
+}
+
+var globalCompletion = completionSpec{
+	flags: []string{"catalog-version", "overrides", "config", "proxy", "ca-cert"},
+	bools: []string{"insecure-skip-verify"},
+}
+
+var commandCompletions = map[string]completionSpec{
+	"init":              {flags: []string{"catalog-url", "provider", "model", "theme"}, bools: []string{"yes", "force"}},
+	"keys":              {subcommands: []string{"verify"}, flags: []string{"provider", "require", "timeout"}},
+	"export-config":     {flags: []string{"target", "provider", "model", "output"}},
+	"snapshots":         {},
+	"matrix":            {flags: []string{"provider"}, bools: []string{"markdown"}},
+	"search":            {flags: []string{"provider", "limit", "format"}},
+	"stats":             {flags: []string{"provider", "format"}},
+	"compare-providers": {flags: []string{"class", "format"}, providerArgs: 2},
+	"mirror":            {flags: []string{"db", "sqlite"}, bools: []string{"dump"}},
+	"sql":               {flags: []string{"db", "format", "sqlite"}},
+	"prompts":           {subcommands: []string{"list", "show", "add"}, flags: []string{"dir", "file"}, bools: []string{"force"}},
+	"usage":             {subcommands: []string{"import", "report"}, flags: []string{"openai-csv", "anthropic-csv", "openrouter-csv", "tolerance", "format", "month", "output", "tag-prefix", "top"}},
+	"convert":           {flags: []string{"output", "conversation"}, bools: []string{"list"}},
+	"dataset":           {subcommands: []string{"build"}, flags: []string{"from", "format", "output", "tag", "min-rating", "min-tokens", "max-tokens", "epochs", "training-price"}, bools: []string{"strip-system"}},
+	"dashboard":         {flags: []string{"days", "daily-budget", "weekly-budget"}},
+	"lint-catalog":      {flags: []string{"provider", "ignore", "format"}, bools: []string{"strict"}},
+	"gen-docs":          {flags: []string{"provider", "model", "format", "output", "title"}},
+	"cost":              {subcommands: []string{"repl", "from-log"}, flags: []string{"model", "provider", "in", "out", "cache-read", "cache-write", "precision", "pin", "history", "model-candidates", "month", "format"}, bools: []string{"quiet"}},
+	"analyze":           {flags: []string{"model", "provider", "top", "calls"}},
+	"alternatives":      {flags: []string{"model", "provider", "only", "min-context", "in", "out", "limit"}, bools: []string{"other-providers", "allow-free"}},
+	"completion":        {subcommands: slices.Sorted(maps.Keys(completionScripts))},
+}
+
+func runCompletion(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("completion", flag.ExitOnError)
+	fs.Usage = printCompletionHelp
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		printCompletionHelp()
+		return errUsage
+	}
+	script, ok := completionScripts[strings.ToLower(fs.Arg(0))]
+	if !ok {
+		printCompletionHelp()
+		return errUsage
+	}
+	fmt.Print(script)
+	return nil
+}
+
+// runComplete prints the completions for a command line, one per line. It
+// is called by the completion scripts with the line up to the cursor.
+func runComplete(_ context.Context, args []string) error {
+	line := strings.Join(args, " ")
+	words := strings.Fields(line)
+	if line == "" || unicode.IsSpace(rune(line[len(line)-1])) {
+		words = append(words, "")
+	}
+	if len(words) > 1 {
+		// The first word is the program name
+		words = words[1:]
+	}
+	for _, c := range complete(words) {
+		fmt.Println(c)
+	}
+	return nil
+}
+
+// complete returns the completions of the last word, given the words before
+// it. Provider and model IDs come from the latest stored catalog snapshot,
+// so completing never waits on the network.
+func complete(words []string) []string {
+	current := words[len(words)-1]
+	words = words[:len(words)-1]
+
+	// Walk past the global flags to the command and its arguments
+	version := "latest"
+	spec := globalCompletion
+	command := ""
+	var args []string
+	for i := 0; i < len(words); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(words[i], "-"), "=")
+		switch {
+		case command != "":
+			args = append(args, words[i])
+		case !strings.HasPrefix(words[i], "-"):
+			command = words[i]
+			spec = commandCompletions[command]
+		case name == "catalog-version" && hasValue:
+			version = value
+		case name == "catalog-version" && i+1 < len(words):
+			version = words[i+1]
+			i++
+		case slices.Contains(globalCompletion.flags, name) && !hasValue:
+			i++
+		}
+	}
+
+	// A flag's value, either after = or as the next word
+	flagName, prefix, value := "", "", current
+	if name, v, ok := strings.Cut(current, "="); ok && strings.HasPrefix(current, "-") {
+		flagName, prefix, value = strings.TrimLeft(name, "-"), name+"=", v
+	} else if n := len(words); n > 0 && strings.HasPrefix(words[n-1], "-") && !strings.Contains(words[n-1], "=") {
+		if name := strings.TrimLeft(words[n-1], "-"); slices.Contains(spec.flags, name) {
+			flagName = name
+		}
+	}
+	if flagName != "" {
+		return completeList(prefix, value, flagValues(command, flagName, version))
+	}
+
+	if strings.HasPrefix(current, "-") {
+		var names []string
+		for _, f := range slices.Concat(spec.flags, spec.bools) {
+			names = append(names, "--"+f)
+		}
+		return completeList("", current, names)
+	}
+	if command == "" {
+		return completeList("", current, slices.Sorted(maps.Keys(commandCompletions)))
+	}
+	if len(args) == 0 && len(spec.subcommands) > 0 {
+		return completeList("", current, spec.subcommands)
+	}
+	if positional(args, spec) < spec.providerArgs {
+		return completeList("", current, flagValues(command, "provider", version))
+	}
+	return nil
+}
+
+// positional counts the arguments that are neither flags nor their values.
+func positional(args []string, spec completionSpec) int {
+	n := 0
+	for i := 0; i < len(args); i++ {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		switch {
+		case !strings.HasPrefix(args[i], "-"):
+			n++
+		case slices.Contains(spec.flags, name) && !hasValue:
+			i++
+		}
+	}
+	return n
+}
+
+// flagValues returns the values a flag may take, or nil to complete file
+// names.
+func flagValues(command, name, version string) []string {
+	switch name {
+	case "provider", "require", "only":
+		var ids []string
+		for _, p := range cachedProviders(version) {
+			ids = append(ids, string(p.ID))
+		}
+		return ids
+	case "model":
+		var ids []string
+		for _, p := range cachedProviders(version) {
+			for _, m := range p.Models {
+				ids = append(ids, m.ID)
+			}
+		}
+		slices.Sort(ids)
+		return slices.Compact(ids)
+	case "target":
+		return slices.Sorted(maps.Keys(exporters))
+	case "ignore":
+		return catwalk.Checks
+	case "class":
+		var names []string
+		for _, c := range selector.Classes {
+			names = append(names, string(c))
+		}
+		return names
+	case "format":
+		if command == "sql" {
+			return []string{"table", "csv", "json"}
+		}
+		if command == "gen-docs" {
+			return []string{"markdown", "html"}
+		}
+		if command == "dataset" {
+			return []string{"openai", "anthropic"}
+		}
+		if command == "stats" {
+			return []string{"table", "csv"}
+		}
+		return []string{"table", "json"}
+	case "theme":
+		names := make([]string, len(config.Themes))
+		for i, t := range config.Themes {
+			names[i] = string(t)
+		}
+		return names
+	case "catalog-version":
+		values := []string{"latest"}
+		if store, err := snapshot.OpenDefault(); err == nil {
+			entries, _ := store.List()
+			for _, e := range entries {
+				values = append(values, e.ETag)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// completeList returns the candidates that complete value, which may be a
+// comma-separated list whose last item is being typed. Items already in the
+// list are not offered again.
+func completeList(prefix, value string, candidates []string) []string {
+	head, part := "", value
+	if i := strings.LastIndex(value, ","); i >= 0 {
+		head, part = value[:i+1], value[i+1:]
+	}
+	listed := strings.Split(head, ",")
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, part) && !slices.Contains(listed, c) {
+			matches = append(matches, prefix+head+c)
+		}
+	}
+	return matches
+}
+
+// cachedProviders loads a stored catalog snapshot, or returns nil if there
+// is none.
+func cachedProviders(version string) []catwalk.Provider {
+	store, err := snapshot.OpenDefault()
+	if err != nil {
+		return nil
+	}
+	e, err := store.Resolve(version)
+	if err != nil {
+		return nil
+	}
+	providers, err := store.Load(e)
+	if err != nil {
+		return nil
+	}
+	return providers
+}
+
+// printCompletionHelp displays usage information for the completion command
+func printCompletionHelp() {
+	fmt.Println("aimodels completion - Generate shell completion scripts")
+	fmt.Println()
+	fmt.Println("Prints a completion script for commands, flags, and their values.")
+	fmt.Println("Provider and model IDs (--provider open<TAB>, --model gpt-<TAB>) come")
+	fmt.Println("from the latest stored catalog snapshot, so completion works offline;")
+	fmt.Println("any live catalog fetch, such as 'aimodels matrix', refreshes it.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels completion bash|zsh|fish|powershell")
+	fmt.Println()
+	fmt.Println("Setup:")
+	fmt.Println("  bash        echo 'source <(aimodels completion bash)' >> ~/.bashrc")
+	fmt.Println("  zsh         aimodels completion zsh > \"${fpath[1]}/_aimodels\"")
+	fmt.Println("  fish        aimodels completion fish > ~/.config/fish/completions/aimodels.fish")
+	fmt.Println("  powershell  aimodels completion powershell | Out-String | Invoke-Expression")
+	fmt.Println("              (add the line to $PROFILE to keep it)")
+}
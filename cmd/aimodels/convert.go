@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/transcript"
+)
+
+func runConvert(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	output := fs.String("output", "", "Write the transcript to this file instead of stdout")
+	conversation := fs.String("conversation", "", "Convert only this conversation: its number in --list, ID, or part of its title")
+	list := fs.Bool("list", false, "List the conversations in the export instead of converting")
+	fs.Usage = printConvertHelp
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		printConvertHelp()
+		return errUsage
+	}
+	convs, err := transcript.ReadExport(fs.Arg(0))
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	if *list {
+		listConversations(convs)
+		return nil
+	}
+	if *conversation != "" {
+		c, err := transcript.FindConversation(convs, *conversation)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		convs = []transcript.Conversation{c}
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output: %w", err)
+		}
+		defer f.Close() //nolint:errcheck
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	var replies int
+	for _, c := range convs {
+		for _, e := range c.Entries() {
+			if err := enc.Encode(e); err != nil {
+				return fmt.Errorf("failed to write transcript: %w", err)
+			}
+			replies++
+		}
+	}
+	if *output != "" {
+		fmt.Printf("%s %d conversations (%d replies) to %s\n", okStyle.Render("Converted"), len(convs), replies, *output)
+	}
+	return nil
+}
+
+func listConversations(convs []transcript.Conversation) {
+	fmt.Println()
+	fmt.Println(headerStyle.Render("Conversations"))
+	fmt.Println(borderStyle.Render(render.Rule(80)))
+	for i, c := range convs {
+		created := ""
+		if !c.Created.IsZero() {
+			created = c.Created.Local().Format(time.DateOnly)
+		}
+		title := c.Title
+		if title == "" {
+			title = c.ID
+		}
+		fmt.Printf("%4d  %s  %s %s\n",
+			i+1,
+			infoStyle.Render(fmt.Sprintf("%-10s", created)),
+			nameStyle.Render(fmt.Sprintf("%-48s", truncate(title, 48))),
+			infoStyle.Render(fmt.Sprintf("%3d messages", len(c.Messages))))
+	}
+	fmt.Println(borderStyle.Render(render.Rule(80)))
+	fmt.Printf("Total: %d conversations\n", len(convs))
+}
+
+// printConvertHelp displays usage information for the convert command
+func printConvertHelp() {
+	fmt.Println("aimodels convert - Convert ChatGPT or Claude exports to transcripts")
+	fmt.Println()
+	fmt.Println("Reads a ChatGPT or Claude data export (the zip file or the")
+	fmt.Println("conversations.json inside it) and writes JSONL transcript entries, the")
+	fmt.Println("format chat-bot --log-transcript writes: one entry per reply, with the")
+	fmt.Println("messages before it as the request. Only the text of the conversation")
+	fmt.Println("shown last is kept; attachments, tool calls, and abandoned edits are")
+	fmt.Println("dropped. Continue a conversation in chat-bot with /import <file>.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels convert [options] <export>")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --list                 List the conversations in the export")
+	fmt.Println("  --conversation <key>   Convert one conversation: its number in --list, ID,")
+	fmt.Println("                         or part of its title")
+	fmt.Println("  --output <file>        Write to a file instead of stdout")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels convert --list chatgpt-export.zip")
+	fmt.Println("  aimodels convert --output history.jsonl chatgpt-export.zip")
+	fmt.Println("  aimodels convert --conversation 'trip planning' claude/conversations.json > trip.jsonl")
+}
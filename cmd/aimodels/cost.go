@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/usage"
+)
+
+func runCost(ctx context.Context, args []string) error {
+	if len(args) > 0 && args[0] == "repl" {
+		return runCostRepl(ctx, args[1:])
+	}
+	if len(args) > 0 && args[0] == "from-log" {
+		return runCostFromLog(ctx, args[1:])
+	}
+
+	fs := flag.NewFlagSet("cost", flag.ExitOnError)
+	modelName := fs.String("model", "", "Model ID, or provider/model (default: the one set with aimodels init)")
+	providerID := fs.String("provider", "", "Provider to price the model at (default: the first that lists it)")
+	in := fs.Int64("in", 0, "Input tokens not read from or written to the cache")
+	out := fs.Int64("out", 0, "Output tokens")
+	cacheRead := fs.Int64("cache-read", 0, "Input tokens read from the prompt cache")
+	cacheWrite := fs.Int64("cache-write", 0, "Input tokens written to the prompt cache")
+	quiet := fs.Bool("quiet", false, "Print only the cost in USD, for scripts")
+	precision := fs.Int("precision", 6, "Decimal places in the cost")
+	fs.Usage = printCostHelp
+	_ = fs.Parse(args)
+	defaultModel(modelName, providerID)
+
+	if *modelName == "" || fs.NArg() > 0 {
+		printCostHelp()
+		return errUsage
+	}
+	if *in < 0 || *out < 0 || *cacheRead < 0 || *cacheWrite < 0 || *precision < 0 {
+		fmt.Fprintln(os.Stderr, errorStyle.Render("Token counts and --precision must not be negative"))
+		return errUsage
+	}
+
+	providers, err := fetchProviders(ctx)
+	if err != nil {
+		return err
+	}
+	p, m, err := findCostModel(providers, *providerID, *modelName)
+	if err != nil {
+		return err
+	}
+
+	rec := usage.Record{InputTokens: *in, OutputTokens: *out, CacheReadTokens: *cacheRead, CacheWriteTokens: *cacheWrite}
+	cost := usage.Cost(*m, rec)
+	total := strconv.FormatFloat(cost, 'f', *precision, 64)
+	if *quiet {
+		fmt.Println(total)
+		return nil
+	}
+
+	tbl := render.NewTable(
+		render.Column{Title: "Tokens", MinWidth: 12},
+		render.Column{Title: "Count", Align: render.AlignRight},
+		render.Column{Title: "$/1M", Align: render.AlignRight},
+		render.Column{Title: "Cost", Align: render.AlignRight},
+	)
+	for _, line := range []struct {
+		label  string
+		tokens int64
+		price  float64
+	}{
+		{"Input", *in, m.CostPer1MIn},
+		{"Cache read", *cacheRead, m.CostPer1MOutCached},
+		{"Cache write", *cacheWrite, m.CostPer1MInCached},
+		{"Output", *out, m.CostPer1MOut},
+	} {
+		if line.tokens == 0 && line.label != "Input" && line.label != "Output" {
+			continue
+		}
+		tbl.AddRow(line.label, strconv.FormatInt(line.tokens, 10), fmt.Sprintf("$%.2f", line.price),
+			"$"+strconv.FormatFloat(float64(line.tokens)*line.price/1_000_000, 'f', *precision, 64))
+	}
+	tbl.AddSeparator()
+	tbl.AddRow("Total", "", "", "$"+total)
+
+	fmt.Println()
+	fmt.Println(headerStyle.Render("Cost of " + nameStyle.Render(string(p.ID)+"/"+m.ID)))
+	tbl.Print()
+	if m.CostPer1MIn == 0 && m.CostPer1MOut == 0 {
+		fmt.Println(warnStyle.Render("The catalog lists no prices for this model."))
+	}
+	return nil
+}
+
+// defaultModel fills in the model set with aimodels init, and its provider,
+// when --model is not given and --provider is not another.
+func defaultModel(model, provider *string) {
+	if *model != "" || settings.Model == "" || *provider != "" && *provider != settings.Provider {
+		return
+	}
+	*model, *provider = settings.Model, settings.Provider
+}
+
+// findCostModel looks up the model to price: at providerID if set, else
+// name as provider/model, else the first provider that lists name.
+func findCostModel(providers []catwalk.Provider, providerID, name string) (*catwalk.Provider, *catwalk.Model, error) {
+	if providerID != "" {
+		p, err := catwalk.FindProvider(providers, providerID)
+		if err != nil {
+			return nil, nil, err //nolint:wrapcheck
+		}
+		m, err := p.FindModel(name)
+		if err != nil {
+			return nil, nil, err //nolint:wrapcheck
+		}
+		return p, m, nil
+	}
+
+	return catwalk.FindModel(providers, name) //nolint:wrapcheck
+}
+
+// printCostHelp displays usage information for the cost command
+func printCostHelp() {
+	fmt.Println("aimodels cost - Price a request at catalog rates")
+	fmt.Println()
+	fmt.Println("Prints what a request with the given token counts costs, with a line per")
+	fmt.Println("kind of token. --quiet prints only the total in USD, so scripts and")
+	fmt.Println("Makefiles can use it without parsing a table. Overrides are applied, so")
+	fmt.Println("negotiated prices are used.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels cost --model <id> [options]")
+	fmt.Println("  aimodels cost repl [--provider <id>] [--pin <models>] [--history <file>]")
+	fmt.Println("  aimodels cost from-log <file>... --model-candidates <models> [options]")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --model <id>        Model ID, or provider/model (required unless set with")
+	fmt.Println("                      aimodels init)")
+	fmt.Println("  --provider <id>     Provider to price the model at (default: the first")
+	fmt.Println("                      in the catalog that lists it)")
+	fmt.Println("  --in <n>            Input tokens not read from or written to the cache")
+	fmt.Println("  --out <n>           Output tokens")
+	fmt.Println("  --cache-read <n>    Input tokens read from the prompt cache")
+	fmt.Println("  --cache-write <n>   Input tokens written to the prompt cache")
+	fmt.Println("  --quiet             Print only the cost in USD, for scripts")
+	fmt.Println("  --precision <n>     Decimal places in the cost (default: 6)")
+	fmt.Println()
+	fmt.Println("REPL Options:")
+	fmt.Println("  --provider <id>     Provider to price models at")
+	fmt.Println("  --pin <models>      Comma-separated models to price every expression on")
+	fmt.Println("  --history <file>    History file, or none (default: cost_history in the")
+	fmt.Println("                      aimodels config directory)")
+	fmt.Println()
+	fmt.Println("From-log Options:")
+	fmt.Println("  --model-candidates <models>  Comma-separated models to price the logged")
+	fmt.Println("                               usage on (required)")
+	fmt.Println("  --provider <id>              Provider to price the candidates at")
+	fmt.Println("  --month <YYYY-MM>            Only replay requests made in this month")
+	fmt.Println("  --format <fmt>               Output format: table or json (default: table)")
+	fmt.Println()
+	fmt.Println("from-log answers \"what would this traffic have cost on model X\" with real")
+	fmt.Println("data: it replays the token usage in transcripts, such as chat-bot's")
+	fmt.Println("--log-transcript, at each candidate's prices, next to the cost logged.")
+	fmt.Println("Logged usage has no cache counts, so all input is priced as uncached, and")
+	fmt.Println("reasoning tokens count as output whatever the candidate. Requests larger")
+	fmt.Println("than a candidate's context window are priced anyway and counted as too long.")
+	fmt.Println()
+	fmt.Println("The REPL prices expressions as they are typed, on the models they name and")
+	fmt.Println("every pinned model. Input counts include cached and written tokens:")
+	fmt.Println()
+	printCostReplHelp()
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels cost --model gpt-4o --in 1200 --out 300")
+	fmt.Println("  aimodels cost --model anthropic/claude-sonnet-4-5 --in 50000 --cache-read 40000 --out 800")
+	fmt.Println("  COST=$(aimodels cost --model gpt-4o --in 1200 --out 300 --quiet --precision 4)")
+	fmt.Println("  aimodels cost repl --pin gpt-4o,claude-sonnet-4-5")
+	fmt.Println("  aimodels cost from-log chat.jsonl --model-candidates gpt-4o-mini,claude-haiku-4-5")
+	fmt.Println("  aimodels cost from-log logs/*.jsonl --month 2025-06 --model-candidates deepseek-chat --format json")
+}
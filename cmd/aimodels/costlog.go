@@ -0,0 +1,282 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/transcript"
+	"charm.land/catwalk/pkg/usage"
+)
+
+// replay is the token usage of logged requests, repriced on candidate
+// models in --format json
+type replay struct {
+	Files        []string          `json:"files"`
+	From         time.Time         `json:"from,omitzero"`
+	To           time.Time         `json:"to,omitzero"`
+	Requests     int               `json:"requests"`
+	InputTokens  int64             `json:"input_tokens"`
+	OutputTokens int64             `json:"output_tokens"`
+	LoggedCost   float64           `json:"logged_cost"`
+	Logged       []replayLogged    `json:"logged_models"`
+	Candidates   []replayCandidate `json:"candidates"`
+}
+
+// replayLogged is a model the logged requests were sent to
+type replayLogged struct {
+	Model    string  `json:"model"`
+	Requests int     `json:"requests"`
+	Cost     float64 `json:"cost"`
+}
+
+// replayCandidate is the logged usage priced on one candidate model
+type replayCandidate struct {
+	Provider     string  `json:"provider"`
+	Model        string  `json:"model"`
+	CostPer1MIn  float64 `json:"cost_per_1m_in"`
+	CostPer1MOut float64 `json:"cost_per_1m_out"`
+	Cost         float64 `json:"cost"`
+	// Change is the cost relative to the logged cost, as a fraction
+	// (-0.4 is 40% cheaper); nil when nothing was logged as spent.
+	Change *float64 `json:"change,omitempty"`
+	// TooLong counts the requests whose tokens exceed the model's context
+	// window, which it could not have served; they are priced anyway.
+	TooLong int `json:"too_long"`
+}
+
+func runCostFromLog(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("cost from-log", flag.ExitOnError)
+	candidates := fs.String("model-candidates", "", "Comma-separated models to price the logged usage on")
+	providerID := fs.String("provider", "", "Provider to price the candidates at (default: the first that lists each)")
+	monthFlag := fs.String("month", "", "Only replay requests made in this month, as YYYY-MM (default: all)")
+	format := fs.String("format", "table", "Output format: table or json")
+	fs.Usage = printCostHelp
+	_ = fs.Parse(args)
+
+	// Files may come before the flags, as in "from-log chat.jsonl --model-candidates x"
+	var files []string
+	for fs.NArg() > 0 {
+		files = append(files, fs.Arg(0))
+		_ = fs.Parse(fs.Args()[1:])
+	}
+	names := splitList(*candidates)
+	if len(files) == 0 || len(names) == 0 {
+		printCostHelp()
+		return errUsage
+	}
+	switch *format {
+	case "table", "json":
+	default:
+		return fmt.Errorf("unknown format: %s (use table or json)", *format)
+	}
+	var month time.Time
+	if *monthFlag != "" {
+		t, err := time.Parse("2006-01", *monthFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --month %q: use YYYY-MM, e.g. 2025-06", *monthFlag)
+		}
+		month = t
+	}
+
+	var entries []transcript.Entry
+	for _, path := range files {
+		e, err := transcript.ReadFile(path)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		entries = append(entries, e...)
+	}
+	if !month.IsZero() {
+		entries = slices.DeleteFunc(entries, func(e transcript.Entry) bool {
+			day := dayOf(e.Time)
+			return day.Before(month) || !day.Before(month.AddDate(0, 1, 0))
+		})
+	}
+
+	providers, err := fetchProviders(ctx)
+	if err != nil {
+		return err
+	}
+	var models []pricedModel
+	for _, name := range names {
+		p, m, err := findCostModel(providers, *providerID, name)
+		if err != nil {
+			return err
+		}
+		models = append(models, pricedModel{provider: p, model: m})
+	}
+
+	r := replayUsage(entries, models)
+	r.Files = files
+	if *format == "json" {
+		return writeJSON(os.Stdout, r)
+	}
+	printReplay(r, *monthFlag)
+	return nil
+}
+
+// replayUsage prices the token usage of entries on each model. Requests
+// that used no tokens, such as those that failed before a reply, are left
+// out. Input logged as read from or written to a prompt cache is priced at
+// the candidate's cache prices, where it lists them, and the rest as
+// uncached.
+func replayUsage(entries []transcript.Entry, models []pricedModel) replay {
+	r := replay{Logged: []replayLogged{}, Candidates: make([]replayCandidate, len(models))}
+	for i, pm := range models {
+		r.Candidates[i] = replayCandidate{
+			Provider:     string(pm.provider.ID),
+			Model:        pm.model.ID,
+			CostPer1MIn:  pm.model.CostPer1MIn,
+			CostPer1MOut: pm.model.CostPer1MOut,
+		}
+	}
+
+	logged := map[string]*replayLogged{}
+	for _, e := range entries {
+		if e.Usage.InputTokens == 0 && e.Usage.OutputTokens == 0 {
+			continue
+		}
+		r.Requests++
+		r.InputTokens += int64(e.Usage.InputTokens)
+		r.OutputTokens += int64(e.Usage.OutputTokens)
+		r.LoggedCost += e.Cost
+		if !e.Time.IsZero() && (r.From.IsZero() || e.Time.Before(r.From)) {
+			r.From = e.Time
+		}
+		if e.Time.After(r.To) {
+			r.To = e.Time
+		}
+
+		name := e.Provider + "/" + e.UsedModel()
+		if logged[name] == nil {
+			logged[name] = &replayLogged{Model: name}
+		}
+		logged[name].Requests++
+		logged[name].Cost += e.Cost
+
+		u := e.Usage
+		rec := usage.Record{
+			InputTokens:      int64(max(u.InputTokens-u.CacheReadTokens-u.CacheWriteTokens, 0)),
+			CacheReadTokens:  int64(u.CacheReadTokens),
+			CacheWriteTokens: int64(u.CacheWriteTokens),
+			OutputTokens:     int64(u.OutputTokens),
+		}
+		for i, pm := range models {
+			r.Candidates[i].Cost += usage.Cost(*pm.model, cacheAsInput(*pm.model, rec))
+			if window := pm.model.ContextWindow; window > 0 && int64(u.InputTokens+u.OutputTokens) > window {
+				r.Candidates[i].TooLong++
+			}
+		}
+	}
+
+	for _, l := range logged {
+		r.Logged = append(r.Logged, *l)
+	}
+	slices.SortFunc(r.Logged, func(a, b replayLogged) int {
+		return cmp.Or(cmp.Compare(b.Cost, a.Cost), cmp.Compare(b.Requests, a.Requests), strings.Compare(a.Model, b.Model))
+	})
+	if r.LoggedCost > 0 {
+		for i := range r.Candidates {
+			change := r.Candidates[i].Cost/r.LoggedCost - 1
+			r.Candidates[i].Change = &change
+		}
+	}
+	return r
+}
+
+// cacheAsInput moves the cache tokens of rec that m lists no price for to
+// its uncached input, so they are not priced as free.
+func cacheAsInput(m catwalk.Model, rec usage.Record) usage.Record {
+	if m.CostPer1MInCached == 0 {
+		rec.InputTokens += rec.CacheWriteTokens
+		rec.CacheWriteTokens = 0
+	}
+	if m.CostPer1MOutCached == 0 {
+		rec.InputTokens += rec.CacheReadTokens
+		rec.CacheReadTokens = 0
+	}
+	return rec
+}
+
+// printReplay shows the logged spend and what each candidate would have
+// cost instead, with the cheapest highlighted
+func printReplay(r replay, month string) {
+	fmt.Println()
+	title := "Replaying " + count(r.Requests, "logged request")
+	if month != "" {
+		title += " from " + month
+	}
+	fmt.Println(headerStyle.Render(title))
+	if r.Requests == 0 {
+		fmt.Println(warnStyle.Render("No requests with token usage were logged; nothing to price."))
+		return
+	}
+
+	detail := fmt.Sprintf("%s input and %s output tokens", formatTokens(r.InputTokens), formatTokens(r.OutputTokens))
+	if !r.From.IsZero() {
+		detail += fmt.Sprintf(", %s to %s", r.From.Local().Format("Jan 2 2006"), r.To.Local().Format("Jan 2 2006"))
+	}
+	fmt.Println(infoStyle.Render(detail))
+	var sent []string
+	for _, l := range r.Logged[:min(3, len(r.Logged))] {
+		sent = append(sent, fmt.Sprintf("%s (%d)", l.Model, l.Requests))
+	}
+	if rest := len(r.Logged) - len(sent); rest > 0 {
+		sent = append(sent, fmt.Sprintf("%d more", rest))
+	}
+	fmt.Println(infoStyle.Render("Sent to " + strings.Join(sent, ", ")))
+	fmt.Println()
+
+	tbl := render.NewTable(
+		render.Column{Title: "Model", MinWidth: 24},
+		render.Column{Title: "$/1M in", Align: render.AlignRight},
+		render.Column{Title: "$/1M out", Align: render.AlignRight},
+		render.Column{Title: "Cost", Align: render.AlignRight},
+		render.Column{Title: "vs logged", Align: render.AlignRight},
+		render.Column{Title: "Too long", Align: render.AlignRight},
+	)
+	tbl.AddRow("Logged", "", "", fmt.Sprintf("$%.4f", r.LoggedCost), "", "")
+	tbl.AddSeparator()
+	cheapest := slices.MinFunc(r.Candidates, func(a, b replayCandidate) int { return cmp.Compare(a.Cost, b.Cost) })
+	var tooLong, unpriced int
+	for _, c := range r.Candidates {
+		name := nameStyle.Render(c.Provider + "/" + c.Model)
+		cost := fmt.Sprintf("$%.4f", c.Cost)
+		if len(r.Candidates) > 1 && c.Cost == cheapest.Cost {
+			cost = okStyle.Render(cost)
+		}
+		vs := "-"
+		if c.Change != nil {
+			vs = fmt.Sprintf("%+.1f%%", *c.Change*100)
+		}
+		long := "-"
+		if c.TooLong > 0 {
+			long = warnStyle.Render(fmt.Sprint(c.TooLong))
+			tooLong++
+		}
+		if c.CostPer1MIn == 0 && c.CostPer1MOut == 0 {
+			unpriced++
+		}
+		tbl.AddRow(name, fmt.Sprintf("$%.2f", c.CostPer1MIn), fmt.Sprintf("$%.2f", c.CostPer1MOut), cost, vs, long)
+	}
+	tbl.Print()
+
+	if cheapest.Change != nil && *cheapest.Change < 0 {
+		fmt.Println(okStyle.Render(fmt.Sprintf("%s/%s would have saved $%.4f (%.1f%%).",
+			cheapest.Provider, cheapest.Model, r.LoggedCost-cheapest.Cost, -*cheapest.Change*100)))
+	}
+	if tooLong > 0 {
+		fmt.Println(warnStyle.Render("Too long: requests larger than the model's context window, which it could not have served."))
+	}
+	if unpriced > 0 {
+		fmt.Println(warnStyle.Render("The catalog lists no prices for " + count(unpriced, "candidate") + "."))
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/transcript"
+)
+
+// usageLog is a transcript of three billed requests, one of which read
+// from and wrote to a prompt cache, and a failed request with no usage.
+const usageLog = `{"time":"2025-06-02T10:00:00Z","provider":"openai","model":"gpt-4o","params":{},"request":[],"usage":{"input_tokens":1000000,"output_tokens":100000},"cost":3.5,"latency_ms":900}
+{"time":"2025-06-03T10:00:00Z","provider":"anthropic","model":"claude","params":{},"request":[],"usage":{"input_tokens":2000000,"output_tokens":200000,"cache_read_tokens":1000000,"cache_write_tokens":500000},"cost":6,"latency_ms":1200}
+{"time":"2025-06-04T10:00:00Z","provider":"openai","model":"gpt-4o","params":{},"request":[],"usage":{"input_tokens":0,"output_tokens":0},"cost":0,"latency_ms":30000,"error":"timeout"}
+{"time":"2025-06-05T10:00:00Z","provider":"openai","model":"gpt-4o-mini","params":{},"request":[],"usage":{"input_tokens":1000,"output_tokens":0},"cost":0.01,"latency_ms":300}
+`
+
+func TestReplayUsage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	if err := os.WriteFile(path, []byte(usageLog), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := transcript.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &catwalk.Provider{ID: "test"}
+	models := []pricedModel{
+		{provider, &catwalk.Model{
+			ID: "cached", CostPer1MIn: 1, CostPer1MOut: 4, CostPer1MInCached: 1.25, CostPer1MOutCached: 0.1,
+			ContextWindow: 1_500_000,
+		}},
+		{provider, &catwalk.Model{ID: "uncached", CostPer1MIn: 2, CostPer1MOut: 8}},
+	}
+	r := replayUsage(entries, models)
+
+	if r.Requests != 3 || r.InputTokens != 3_001_000 || r.OutputTokens != 300_000 {
+		t.Errorf("requests, input, output = %d, %d, %d, want 3, 3001000, 300000", r.Requests, r.InputTokens, r.OutputTokens)
+	}
+	if !near(r.LoggedCost, 9.51) {
+		t.Errorf("LoggedCost = %v, want 9.51", r.LoggedCost)
+	}
+	if r.From.Day() != 2 || r.To.Day() != 5 {
+		t.Errorf("From, To = %v, %v, want June 2 and June 5", r.From, r.To)
+	}
+
+	wantLogged := []replayLogged{
+		{Model: "anthropic/claude", Requests: 1, Cost: 6},
+		{Model: "openai/gpt-4o", Requests: 1, Cost: 3.5},
+		{Model: "openai/gpt-4o-mini", Requests: 1, Cost: 0.01},
+	}
+	if len(r.Logged) != len(wantLogged) {
+		t.Fatalf("Logged = %+v, want %+v", r.Logged, wantLogged)
+	}
+	for i, want := range wantLogged {
+		if got := r.Logged[i]; got.Model != want.Model || got.Requests != want.Requests || !near(got.Cost, want.Cost) {
+			t.Errorf("Logged[%d] = %+v, want %+v", i, got, want)
+		}
+	}
+
+	tests := []struct {
+		model   string
+		cost    float64
+		tooLong int
+	}{
+		// gpt-4o: 1M in at $1 and 100k out at $4 = $1.40. claude: 500k
+		// uncached in at $1, 500k written at $1.25, 1M read at $0.10 and
+		// 200k out at $4 = $2.025, and 2.2M tokens overflow the window.
+		// gpt-4o-mini: 1k in at $1 = $0.001.
+		{"cached", 1.4 + 2.025 + 0.001, 1},
+		// Without cache prices, all 2M of claude's input is uncached:
+		// $2.80 + $5.60 + $0.002.
+		{"uncached", 2.8 + 5.6 + 0.002, 0},
+	}
+	for i, tt := range tests {
+		c := r.Candidates[i]
+		if c.Provider != "test" || c.Model != tt.model {
+			t.Errorf("Candidates[%d] = %s/%s, want test/%s", i, c.Provider, c.Model, tt.model)
+		}
+		if !near(c.Cost, tt.cost) {
+			t.Errorf("%s: Cost = %v, want %v", tt.model, c.Cost, tt.cost)
+		}
+		if c.TooLong != tt.tooLong {
+			t.Errorf("%s: TooLong = %d, want %d", tt.model, c.TooLong, tt.tooLong)
+		}
+		if c.Change == nil || !near(*c.Change, tt.cost/9.51-1) {
+			t.Errorf("%s: Change = %v, want %v", tt.model, c.Change, tt.cost/9.51-1)
+		}
+	}
+}
+
+func TestReplayUsageNoCost(t *testing.T) {
+	entries := []transcript.Entry{{Provider: "ollama", Model: "llama", Usage: transcript.Usage{InputTokens: 10}}}
+	r := replayUsage(entries, []pricedModel{{&catwalk.Provider{ID: "test"}, &catwalk.Model{ID: "m", CostPer1MIn: 1}}})
+	if r.Candidates[0].Change != nil {
+		t.Errorf("Change = %v with no logged cost, want nil", *r.Candidates[0].Change)
+	}
+	if !r.From.IsZero() {
+		t.Errorf("From = %v for entries without times, want zero", r.From)
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,524 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/theme"
+	"charm.land/catwalk/pkg/usage"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+)
+
+// maxCostHistory is how many past expressions the REPL keeps.
+const maxCostHistory = 1000
+
+var replPromptStyle = lipgloss.NewStyle().Bold(true).Foreground(theme.Dark.Header)
+
+// replPrompt is shown before each expression.
+const replPrompt = "cost> "
+
+// pricedModel is a model resolved in the catalog, with the provider it is
+// priced at.
+type pricedModel struct {
+	provider *catwalk.Provider
+	model    *catwalk.Model
+}
+
+func (pm pricedModel) name() string {
+	return string(pm.provider.ID) + "/" + pm.model.ID
+}
+
+// costRepl is the state of an interactive cost session: the catalog, the
+// pinned models every expression is priced on, and the history.
+type costRepl struct {
+	providers  []catwalk.Provider
+	providerID string
+	pins       []pricedModel
+	history    []string
+	historyOut *os.File
+}
+
+func runCostRepl(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("cost repl", flag.ExitOnError)
+	providerID := fs.String("provider", "", "Provider to price models at (default: the first that lists each)")
+	pin := fs.String("pin", "", "Comma-separated models to price every expression on")
+	historyFile := fs.String("history", "", "History file, or none (default: cost_history in the aimodels config directory)")
+	fs.Usage = printCostHelp
+	_ = fs.Parse(args)
+
+	if fs.NArg() > 0 {
+		printCostHelp()
+		return errUsage
+	}
+
+	providers, err := fetchProviders(ctx)
+	if err != nil {
+		return err
+	}
+	r := &costRepl{providers: providers, providerID: *providerID}
+	if *pin != "" {
+		if msg := r.pin(strings.Split(*pin, ",")); msg != "" {
+			return errors.New(msg)
+		}
+	}
+
+	if *historyFile != "none" {
+		path := *historyFile
+		if path == "" {
+			if path, err = defaultCostHistory(); err != nil {
+				return err
+			}
+		}
+		r.history = loadCostHistory(path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+			r.historyOut, err = os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, warnStyle.Render("Warning: history will not be saved: "+err.Error()))
+			}
+		}
+		if r.historyOut != nil {
+			defer r.historyOut.Close() //nolint:errcheck
+		}
+	}
+
+	return r.run(ctx)
+}
+
+// defaultCostHistory returns the default history file,
+// <user config dir>/aimodels/cost_history.
+func defaultCostHistory() (string, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine config directory: %w", err)
+	}
+	return filepath.Join(config, "aimodels", "cost_history"), nil
+}
+
+// loadCostHistory reads the last expressions from a history file; a missing
+// file is an empty history.
+func loadCostHistory(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close() //nolint:errcheck
+	var history []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			history = append(history, line)
+		}
+	}
+	if len(history) > maxCostHistory {
+		history = history[len(history)-maxCostHistory:]
+	}
+	return history
+}
+
+func (r *costRepl) run(ctx context.Context) error {
+	live := term.IsTerminal(os.Stdin.Fd()) && term.IsTerminal(os.Stdout.Fd())
+	var scanner *bufio.Scanner
+	if live {
+		fmt.Println(headerStyle.Render("Cost REPL") + infoStyle.Render(" - type gpt-4o: 1.5k in, 600 out, 40% cached; /help for more"))
+		if len(r.pins) > 0 {
+			fmt.Println(infoStyle.Render("Pinned: " + r.pinNames()))
+		}
+	} else {
+		scanner = bufio.NewScanner(os.Stdin)
+	}
+
+	for {
+		var line string
+		if live {
+			var err error
+			line, err = r.compose(ctx)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		} else {
+			if !scanner.Scan() {
+				return scanner.Err() //nolint:wrapcheck
+			}
+			line = scanner.Text()
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		r.remember(line)
+		if strings.HasPrefix(line, "/") {
+			if quit := r.command(line); quit {
+				return nil
+			}
+			continue
+		}
+		r.evaluate(line)
+	}
+}
+
+// remember adds an expression to the history, skipping repeats of the last.
+func (r *costRepl) remember(line string) {
+	if n := len(r.history); n > 0 && r.history[n-1] == line {
+		return
+	}
+	r.history = append(r.history, line)
+	if len(r.history) > maxCostHistory {
+		r.history = r.history[1:]
+	}
+	if r.historyOut != nil {
+		_, _ = fmt.Fprintln(r.historyOut, line)
+	}
+}
+
+// command runs a slash command and reports whether the session should end.
+func (r *costRepl) command(line string) bool {
+	name, rest, _ := strings.Cut(line, " ")
+	args := strings.FieldsFunc(rest, func(c rune) bool { return c == ',' || c == ' ' })
+	switch strings.ToLower(name) {
+	case "/quit", "/exit":
+		return true
+	case "/help":
+		printCostReplHelp()
+	case "/pin":
+		if len(args) == 0 {
+			fmt.Println(errorStyle.Render("Usage: /pin <model> [model...]"))
+			break
+		}
+		if msg := r.pin(args); msg != "" {
+			fmt.Println(errorStyle.Render(msg))
+		}
+		fmt.Println(infoStyle.Render("Pinned: " + r.pinNames()))
+	case "/unpin":
+		if len(args) == 0 {
+			fmt.Println(errorStyle.Render("Usage: /unpin <model>|all"))
+			break
+		}
+		r.unpin(args)
+		fmt.Println(infoStyle.Render("Pinned: " + r.pinNames()))
+	case "/pins":
+		fmt.Println(infoStyle.Render("Pinned: " + r.pinNames()))
+	case "/history":
+		start := max(len(r.history)-20, 0)
+		for i, h := range r.history[start:] {
+			fmt.Printf("%s %s\n", infoStyle.Render(fmt.Sprintf("%4d", start+i+1)), h)
+		}
+	default:
+		fmt.Println(errorStyle.Render("Unknown command " + name + " (try /help)"))
+	}
+	return false
+}
+
+// pin adds models to the pinned set, returning a message for any that are
+// not in the catalog.
+func (r *costRepl) pin(names []string) string {
+	var missing []string
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		pm, err := r.resolve(name)
+		if err != nil {
+			missing = append(missing, err.Error())
+			continue
+		}
+		if !r.pinned(pm) {
+			r.pins = append(r.pins, pm)
+		}
+	}
+	return strings.Join(missing, "; ")
+}
+
+// unpin removes models from the pinned set; "all" removes every one.
+func (r *costRepl) unpin(names []string) {
+	for _, name := range names {
+		if strings.EqualFold(name, "all") {
+			r.pins = nil
+			return
+		}
+		kept := r.pins[:0]
+		for _, pm := range r.pins {
+			if pm.model.ID != name && pm.name() != name {
+				kept = append(kept, pm)
+			}
+		}
+		r.pins = kept
+	}
+}
+
+func (r *costRepl) pinned(pm pricedModel) bool {
+	for _, p := range r.pins {
+		if p.name() == pm.name() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *costRepl) pinNames() string {
+	if len(r.pins) == 0 {
+		return "none"
+	}
+	names := make([]string, len(r.pins))
+	for i, pm := range r.pins {
+		names[i] = pm.name()
+	}
+	return strings.Join(names, ", ")
+}
+
+func (r *costRepl) resolve(name string) (pricedModel, error) {
+	p, m, err := findCostModel(r.providers, r.providerID, name)
+	if err != nil {
+		return pricedModel{}, err
+	}
+	return pricedModel{provider: p, model: m}, nil
+}
+
+// models returns the models an estimate is priced on: those it names, then
+// the pinned ones, without repeats.
+func (r *costRepl) models(e usage.Estimate) ([]pricedModel, error) {
+	var models []pricedModel
+	seen := map[string]bool{}
+	for _, name := range e.Models {
+		pm, err := r.resolve(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[pm.name()] {
+			seen[pm.name()] = true
+			models = append(models, pm)
+		}
+	}
+	for _, pm := range r.pins {
+		if !seen[pm.name()] {
+			seen[pm.name()] = true
+			models = append(models, pm)
+		}
+	}
+	if len(models) == 0 {
+		return nil, errors.New("no model: name one (gpt-4o: 1k in, 200 out) or /pin some")
+	}
+	return models, nil
+}
+
+// replQuote is an estimate priced on one model, split by kind of token.
+type replQuote struct {
+	model                pricedModel
+	input, cache, output float64
+	perCall, total       float64
+	unpriced             bool
+}
+
+// quote prices e on each of models, and returns the index of the cheapest
+// model with a price, or -1 if none has one.
+func quote(e usage.Estimate, models []pricedModel) ([]replQuote, int) {
+	quotes := make([]replQuote, len(models))
+	cheapest := -1
+	for i, pm := range models {
+		m, rec := *pm.model, e.PerCall
+		quotes[i] = replQuote{
+			model:    pm,
+			input:    float64(rec.InputTokens) * m.CostPer1MIn / 1_000_000,
+			cache:    (float64(rec.CacheReadTokens)*m.CostPer1MOutCached + float64(rec.CacheWriteTokens)*m.CostPer1MInCached) / 1_000_000,
+			output:   float64(rec.OutputTokens) * m.CostPer1MOut / 1_000_000,
+			perCall:  usage.Cost(m, rec),
+			total:    e.Cost(m),
+			unpriced: m.CostPer1MIn == 0 && m.CostPer1MOut == 0,
+		}
+		if !quotes[i].unpriced && (cheapest < 0 || quotes[i].total < quotes[cheapest].total) {
+			cheapest = i
+		}
+	}
+	return quotes, cheapest
+}
+
+// evaluate prices an expression and prints a row per model.
+func (r *costRepl) evaluate(line string) {
+	e, err := usage.ParseEstimate(line)
+	if err != nil {
+		fmt.Println(errorStyle.Render(err.Error()))
+		return
+	}
+	models, err := r.models(e)
+	if err != nil {
+		fmt.Println(errorStyle.Render(err.Error()))
+		return
+	}
+
+	columns := []render.Column{
+		{Title: "Model", MinWidth: 20},
+		{Title: "Input", Align: render.AlignRight},
+		{Title: "Cache", Align: render.AlignRight},
+		{Title: "Output", Align: render.AlignRight},
+		{Title: "Per call", Align: render.AlignRight},
+	}
+	if e.Calls > 1 {
+		columns = append(columns, render.Column{Title: "Total", Align: render.AlignRight})
+	}
+	tbl := render.NewTable(columns...)
+	quotes, cheapest := quote(e, models)
+	for _, q := range quotes {
+		name := q.model.name()
+		if q.unpriced {
+			name += " (no price)"
+		}
+		row := []string{name, replUSD(q.input), replUSD(q.cache), replUSD(q.output), replUSD(q.perCall)}
+		if e.Calls > 1 {
+			row = append(row, replUSD(q.total))
+		}
+		tbl.AddRow(row...)
+	}
+
+	fmt.Println(infoStyle.Render(e.String()))
+	tbl.Print()
+	if len(models) > 1 && cheapest >= 0 {
+		fmt.Println(infoStyle.Render("Cheapest: ") + okStyle.Render(quotes[cheapest].model.name()))
+	}
+	fmt.Println()
+}
+
+// preview is the one-line cost shown under the prompt while typing.
+func (r *costRepl) preview(line string) string {
+	line = strings.TrimSpace(line)
+	switch {
+	case line == "":
+		return ""
+	case strings.HasPrefix(line, "/"):
+		return "command"
+	}
+	e, err := usage.ParseEstimate(line)
+	if err != nil {
+		return err.Error()
+	}
+	models, err := r.models(e)
+	if err != nil {
+		return err.Error()
+	}
+	parts := make([]string, len(models))
+	for i, pm := range models {
+		parts[i] = pm.model.ID + " " + replUSD(e.Cost(*pm.model))
+	}
+	return strings.Join(parts, " | ")
+}
+
+// replUSD formats a cost with enough places to show fractions of a cent.
+func replUSD(cost float64) string {
+	return fmt.Sprintf("$%.6f", cost)
+}
+
+// compose reads an expression with history on Up/Down and a live preview of
+// its cost below the prompt.
+func (r *costRepl) compose(ctx context.Context) (string, error) {
+	input := textinput.New()
+	input.Prompt = replPromptStyle.Render(replPrompt)
+	input.CharLimit = 0
+	input.Focus()
+	m, err := tea.NewProgram(replInput{input: input, repl: r, index: len(r.history)}, tea.WithContext(ctx)).Run()
+	if err != nil {
+		return "", fmt.Errorf("input failed: %w", err)
+	}
+	in := m.(replInput)
+	return in.input.Value(), in.err
+}
+
+// replInput is the REPL's prompt: a single-line input that walks the
+// history and previews the cost of what has been typed.
+type replInput struct {
+	input textinput.Model
+	repl  *costRepl
+	index int    // position in the history; len(history) is the new line
+	draft string // the new line, kept while browsing the history
+	done  bool
+	err   error
+}
+
+func (in replInput) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (in replInput) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		history := in.repl.history
+		switch msg.Type {
+		case tea.KeyEnter:
+			in.done = true
+			return in, tea.Quit
+		case tea.KeyCtrlC:
+			in.done, in.err = true, io.EOF
+			return in, tea.Quit
+		case tea.KeyCtrlD:
+			if in.input.Value() == "" {
+				in.done, in.err = true, io.EOF
+				return in, tea.Quit
+			}
+		case tea.KeyUp:
+			if in.index > 0 {
+				if in.index == len(history) {
+					in.draft = in.input.Value()
+				}
+				in.index--
+				in.input.SetValue(history[in.index])
+				in.input.CursorEnd()
+			}
+			return in, nil
+		case tea.KeyDown:
+			if in.index < len(history) {
+				in.index++
+				if in.index == len(history) {
+					in.input.SetValue(in.draft)
+				} else {
+					in.input.SetValue(history[in.index])
+				}
+				in.input.CursorEnd()
+			}
+			return in, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	in.input, cmd = in.input.Update(msg)
+	return in, cmd
+}
+
+func (in replInput) View() string {
+	if in.done {
+		// Leave only the submitted line in the scrollback
+		if in.err != nil {
+			return "\n"
+		}
+		return replPromptStyle.Render(replPrompt) + in.input.Value() + "\n"
+	}
+	return in.input.View() + "\n" + infoStyle.Render("  "+in.repl.preview(in.input.Value()))
+}
+
+// printCostReplHelp lists the REPL's expression syntax and commands
+func printCostReplHelp() {
+	fmt.Println("Expressions:")
+	fmt.Println("  [models:] <amount> in, <amount> out[, <amount|N%> cached][, <amount> written][, xN]")
+	fmt.Println("  Amounts take k and m suffixes. Cached and written tokens are part of the input.")
+	fmt.Println("  gpt-4o: 1.5k in, 600 out, 40% cached")
+	fmt.Println("  gpt-4o, claude-sonnet-4-5: 20k in, 1k out x10k")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  /pin <model...>     Price every expression on these models too")
+	fmt.Println("  /unpin <model|all>  Stop pricing on a pinned model")
+	fmt.Println("  /pins               List the pinned models")
+	fmt.Println("  /history            Show recent expressions (Up/Down recalls them)")
+	fmt.Println("  /help               Show this help")
+	fmt.Println("  /quit               Leave (also Ctrl-D)")
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/usage"
+)
+
+var replProviders = []catwalk.Provider{
+	{ID: "openai", Models: []catwalk.Model{
+		{ID: "gpt-4o", CostPer1MIn: 2.5, CostPer1MOut: 10, CostPer1MOutCached: 1.25},
+		{ID: "gpt-4o-mini", CostPer1MIn: 0.15, CostPer1MOut: 0.6},
+	}},
+	{ID: "azure", Models: []catwalk.Model{
+		{ID: "gpt-4o", CostPer1MIn: 2, CostPer1MOut: 8},
+	}},
+	{ID: "ollama", Models: []catwalk.Model{
+		{ID: "llama3"},
+	}},
+}
+
+func TestCostReplModels(t *testing.T) {
+	tests := []struct {
+		name       string
+		providerID string
+		pins       []string
+		expr       string
+		want       []string
+		wantErr    bool
+	}{
+		{name: "named", expr: "gpt-4o-mini, gpt-4o: 1k in", want: []string{"openai/gpt-4o-mini", "openai/gpt-4o"}},
+		{name: "pinned after named", pins: []string{"llama3", "azure/gpt-4o"}, expr: "gpt-4o-mini: 1k in",
+			want: []string{"openai/gpt-4o-mini", "ollama/llama3", "azure/gpt-4o"}},
+		{name: "pinned alone", pins: []string{"llama3"}, expr: "1k in", want: []string{"ollama/llama3"}},
+		{name: "named twice", expr: "gpt-4o, openai/gpt-4o, GPT-4O: 1k in", want: []string{"openai/gpt-4o"}},
+		{name: "named and pinned", pins: []string{"gpt-4o", "gpt-4o-mini"}, expr: "gpt-4o-mini: 1k in",
+			want: []string{"openai/gpt-4o-mini", "openai/gpt-4o"}},
+		{name: "same ID at two providers", pins: []string{"azure/gpt-4o"}, expr: "gpt-4o: 1k in",
+			want: []string{"openai/gpt-4o", "azure/gpt-4o"}},
+		{name: "provider", providerID: "azure", expr: "gpt-4o: 1k in", want: []string{"azure/gpt-4o"}},
+		{name: "not at provider", providerID: "azure", expr: "gpt-4o-mini: 1k in", wantErr: true},
+		{name: "unknown", expr: "gpt-5: 1k in", wantErr: true},
+		{name: "none", expr: "1k in", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &costRepl{providers: replProviders, providerID: tt.providerID}
+			if msg := r.pin(tt.pins); msg != "" {
+				t.Fatalf("pin: %s", msg)
+			}
+			e, err := usage.ParseEstimate(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			models, err := r.models(e)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("models(%q) = %d models, want an error", tt.expr, len(models))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("models(%q): %v", tt.expr, err)
+			}
+			var got []string
+			for _, pm := range models {
+				got = append(got, pm.name())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("models(%q) = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCostReplPin(t *testing.T) {
+	r := &costRepl{providers: replProviders}
+	if msg := r.pin([]string{"gpt-4o", " ", "openai/gpt-4o", "nope"}); msg == "" {
+		t.Error("pinning an unknown model gave no message")
+	}
+	if got := r.pinNames(); got != "openai/gpt-4o" {
+		t.Errorf("pinned %s, want openai/gpt-4o once", got)
+	}
+	r.pin([]string{"gpt-4o-mini", "llama3"})
+	r.unpin([]string{"gpt-4o-mini"})
+	if got := r.pinNames(); got != "openai/gpt-4o, ollama/llama3" {
+		t.Errorf("after unpin, pinned %s", got)
+	}
+	r.unpin([]string{"all"})
+	if got := r.pinNames(); got != "none" {
+		t.Errorf("after unpin all, pinned %s", got)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	r := &costRepl{providers: replProviders}
+	models := func(names ...string) []pricedModel {
+		t.Helper()
+		var pms []pricedModel
+		for _, name := range names {
+			pm, err := r.resolve(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pms = append(pms, pm)
+		}
+		return pms
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		models   []pricedModel
+		cheapest string
+	}{
+		{"cheapest priced", "1k in, 200 out", models("openai/gpt-4o", "gpt-4o-mini", "azure/gpt-4o"), "openai/gpt-4o-mini"},
+		{"unpriced is not cheapest", "1k in", models("llama3", "azure/gpt-4o", "openai/gpt-4o"), "azure/gpt-4o"},
+		{"all unpriced", "1k in", models("llama3"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := usage.ParseEstimate(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			quotes, cheapest := quote(e, tt.models)
+			if len(quotes) != len(tt.models) {
+				t.Fatalf("got %d quotes for %d models", len(quotes), len(tt.models))
+			}
+			got := ""
+			if cheapest >= 0 {
+				got = quotes[cheapest].model.name()
+			}
+			if got != tt.cheapest {
+				t.Errorf("cheapest = %q, want %q", got, tt.cheapest)
+			}
+			for _, q := range quotes {
+				if want := q.model.model.CostPer1MIn == 0 && q.model.model.CostPer1MOut == 0; q.unpriced != want {
+					t.Errorf("%s: unpriced = %v, want %v", q.model.name(), q.unpriced, want)
+				}
+			}
+		})
+	}
+
+	// 10k in of which 40% cached, 1k out, 5 calls at openai/gpt-4o:
+	// 6k uncached at $2.50, 4k read at $1.25 and 1k out at $10.
+	e, err := usage.ParseEstimate("10k in, 40% cached, 1k out x5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quotes, _ := quote(e, models("openai/gpt-4o"))
+	q := quotes[0]
+	for _, c := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"input", q.input, 0.015},
+		{"cache", q.cache, 0.005},
+		{"output", q.output, 0.01},
+		{"per call", q.perCall, 0.03},
+		{"total", q.total, 0.15},
+	} {
+		if !near(c.got, c.want) {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+}
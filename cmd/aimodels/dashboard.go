@@ -0,0 +1,589 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"charm.land/catwalk/pkg/budget"
+	"charm.land/catwalk/pkg/registry"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/theme"
+	"charm.land/catwalk/pkg/transcript"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/term"
+)
+
+var (
+	costStyle     = lipgloss.NewStyle().Foreground(theme.Dark.OK)
+	selectedStyle = lipgloss.NewStyle().Bold(true).Foreground(theme.Dark.Accent)
+	tabStyle      = lipgloss.NewStyle().Padding(0, 1).Foreground(theme.Dark.Muted)
+	activeTab     = lipgloss.NewStyle().Padding(0, 1).Bold(true).Foreground(theme.Dark.Inverse).Background(theme.Dark.Header)
+)
+
+// Dashboard tabs, in display order.
+const (
+	tabDays = iota
+	tabModels
+	tabTags
+	tabSessions
+)
+
+var tabNames = []string{"Days", "Models", "Tags", "Sessions"}
+
+func runDashboard(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("dashboard", flag.ExitOnError)
+	days := fs.Int("days", 30, "Days of history to show, ending today")
+	dailyBudget := fs.Float64("daily-budget", 0, "Daily spend budget in USD (0 = none)")
+	weeklyBudget := fs.Float64("weekly-budget", 0, "Weekly spend budget in USD, from Monday (0 = none)")
+	fs.Usage = printDashboardHelp
+	_ = fs.Parse(args)
+
+	if fs.NArg() == 0 || *days < 1 {
+		printDashboardHelp()
+		return errUsage
+	}
+	var entries []transcript.Entry
+	for _, path := range fs.Args() {
+		e, err := transcript.ReadFile(path)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		entries = append(entries, e...)
+	}
+
+	// Provider quotas come from the overrides file and count every entry,
+	// whatever the window
+	var quotas []budget.QuotaStatus
+	if *overridesFile != "none" {
+		overrides, err := registry.Open(*overridesFile)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		tracker, err := budget.NewQuotas(overrides.Quotas(), entries)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		quotas = tracker.Status()
+	}
+
+	d := dashboard{
+		ledger:       newLedger(entries, *days, time.Now()),
+		dailyBudget:  *dailyBudget,
+		weeklyBudget: *weeklyBudget,
+		quotas:       quotas,
+		width:        80,
+	}
+	if !term.IsTerminal(os.Stdout.Fd()) {
+		d.print()
+		return nil
+	}
+	if _, err := tea.NewProgram(d, tea.WithAltScreen()).Run(); err != nil {
+		return fmt.Errorf("dashboard failed: %w", err)
+	}
+	return nil
+}
+
+// dashboard is the interactive spend view: the overview lists the days,
+// models, tags, or chat sessions with spend, Enter drills into a group's
+// requests, Enter again shows one request in full, and c charts the
+// group's tokens and cost request by request.
+type dashboard struct {
+	ledger       ledger
+	dailyBudget  float64
+	weeklyBudget float64
+	quotas       []budget.QuotaStatus
+
+	tab    int
+	cursor [4]int      // selected group on each tab
+	group  *spendGroup // group drilled into, or nil on the overview
+	entry  int         // selected request in group
+	detail bool        // showing the selected request
+	chart  bool        // showing the group's chart
+	scroll int         // first line shown of the request detail
+	width  int
+	height int
+}
+
+// Init initializes the dashboard
+func (d dashboard) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles key presses and resizes
+func (d dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.width, d.height = msg.Width, msg.Height
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return d, tea.Quit
+		case "esc", "backspace":
+			switch {
+			case d.detail:
+				d.detail = false
+			case d.chart:
+				d.chart = false
+			case d.group != nil:
+				d.group = nil
+			case msg.String() == "esc":
+				return d, tea.Quit
+			}
+		case "tab", "right", "l":
+			if d.group == nil {
+				d.tab = (d.tab + 1) % len(tabNames)
+			}
+		case "shift+tab", "left", "h":
+			if d.group == nil {
+				d.tab = (d.tab + len(tabNames) - 1) % len(tabNames)
+			}
+		case "up", "k":
+			d.move(-1)
+		case "down", "j":
+			d.move(1)
+		case "pgup":
+			d.move(-d.rows())
+		case "pgdown":
+			d.move(d.rows())
+		case "enter":
+			switch {
+			case d.group == nil && len(d.groups()) > 0:
+				d.group = &d.groups()[d.cursor[d.tab]]
+				d.entry = 0
+			case d.group != nil && !d.detail && !d.chart:
+				d.detail, d.scroll = true, 0
+			}
+		case "c":
+			if d.group != nil && !d.detail {
+				d.chart = !d.chart
+			}
+		}
+	}
+	return d, nil
+}
+
+// move moves the selection, or scrolls the request detail, by n lines.
+func (d *dashboard) move(n int) {
+	clamp := func(i, count int) int { return min(max(i, 0), max(count-1, 0)) }
+	switch {
+	case d.detail:
+		d.scroll = max(d.scroll+n, 0)
+	case d.group != nil:
+		d.entry = clamp(d.entry+n, len(d.group.entries))
+	default:
+		d.cursor[d.tab] = clamp(d.cursor[d.tab]+n, len(d.groups()))
+	}
+}
+
+// groups returns the spend groups of the current tab.
+func (d dashboard) groups() []spendGroup {
+	switch d.tab {
+	case tabModels:
+		return d.ledger.byModel
+	case tabTags:
+		return d.ledger.byTag
+	case tabSessions:
+		return d.ledger.bySession
+	default:
+		return d.ledger.byDay
+	}
+}
+
+// rows is the number of list lines that fit below the header.
+func (d dashboard) rows() int {
+	if d.height == 0 {
+		return 20
+	}
+	return max(d.height-lipgloss.Height(d.header())-4, 3)
+}
+
+// View renders the dashboard
+func (d dashboard) View() string {
+	var sb strings.Builder
+	sb.WriteString(d.header())
+	sb.WriteString("\n\n")
+
+	var lines []string
+	var help string
+	switch {
+	case d.detail:
+		lines = d.detailLines()
+		d.scroll = min(d.scroll, max(len(lines)-d.rows(), 0))
+		lines = lines[d.scroll:min(d.scroll+d.rows(), len(lines))]
+		help = "↑/↓ scroll • esc back • q quit"
+	case d.chart:
+		sb.WriteString(headerStyle.Render(fmt.Sprintf("%s: %d requests, $%.4f", d.group.name, len(d.group.entries), d.group.cost)))
+		sb.WriteString("\n")
+		lines = d.chartLines()
+		help = "c requests • esc back • q quit"
+	case d.group != nil:
+		sb.WriteString(headerStyle.Render(fmt.Sprintf("%s: %d requests, $%.4f", d.group.name, len(d.group.entries), d.group.cost)))
+		sb.WriteString("\n")
+		lines = d.entryLines()
+		help = "↑/↓ select • enter details • c chart • esc back • q quit"
+	default:
+		for i, name := range tabNames {
+			if i == d.tab {
+				sb.WriteString(activeTab.Render(name))
+			} else {
+				sb.WriteString(tabStyle.Render(name))
+			}
+		}
+		sb.WriteString("\n")
+		lines = d.groupLines()
+		help = "←/→ tab • ↑/↓ select • enter requests • q quit"
+	}
+
+	for _, line := range lines {
+		sb.WriteString(ansi.Truncate(line, d.width, "…"))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(infoStyle.Render(help))
+	return sb.String()
+}
+
+// header shows the window's total, budget progress, and daily spend.
+func (d dashboard) header() string {
+	l := d.ledger
+	var sb strings.Builder
+	sb.WriteString(headerStyle.Render("Spend Dashboard"))
+	sb.WriteString(infoStyle.Render(fmt.Sprintf("  %d requests, $%.4f in the last %d days", l.requests, l.total, l.days)))
+	sb.WriteString("\n")
+	sb.WriteString(budgetLine("Today", l.today, d.dailyBudget))
+	sb.WriteString("\n")
+	sb.WriteString(budgetLine("This week", l.week, d.weeklyBudget))
+	sb.WriteString("\n")
+	for _, q := range d.quotas {
+		sb.WriteString(quotaLine(q))
+		sb.WriteString("\n")
+	}
+
+	// One cell per day, keeping the latest days when the window is wider
+	// than the screen
+	daily := l.daily[max(len(l.daily)-(d.width-12), 0):]
+	sb.WriteString(fmt.Sprintf("%-11s %s", "Daily", costStyle.Render(render.Sparkline(daily))))
+	return sb.String()
+}
+
+// budgetLine shows spend against a budget as a progress bar, colored by how
+// much of it is used.
+func budgetLine(label string, spent, budget float64) string {
+	line := fmt.Sprintf("%-11s %s", label, costStyle.Render(fmt.Sprintf("$%.4f", spent)))
+	if budget <= 0 {
+		return line
+	}
+	used := spent / budget
+	style := okStyle
+	switch {
+	case used > 1:
+		style = errorStyle
+	case used >= 0.8:
+		style = warnStyle
+	}
+	return fmt.Sprintf("%s  %s %s", line, style.Render(render.Bar(used, 30)),
+		style.Render(fmt.Sprintf("%3.0f%% of $%.2f", used*100, budget)))
+}
+
+// quotaLine shows a provider's use of its quota as a progress bar for the
+// most used of its limits, with what is left of each.
+func quotaLine(s budget.QuotaStatus) string {
+	used := 0.0
+	var left []string
+	if n, ok := s.RequestsLeft(); ok {
+		used = max(used, float64(s.Requests)/float64(s.Quota.Requests))
+		left = append(left, fmt.Sprintf("%d of %d requests", n, s.Quota.Requests))
+	}
+	if n, ok := s.TokensLeft(); ok {
+		used = max(used, float64(s.Tokens)/float64(s.Quota.Tokens))
+		left = append(left, fmt.Sprintf("%s of %s tokens", formatTokens(n), formatTokens(s.Quota.Tokens)))
+	}
+	period := "today"
+	if s.Quota.Period == budget.Monthly {
+		period = "this month"
+	}
+	style := okStyle
+	switch {
+	case used >= 1:
+		style = errorStyle
+	case used >= 0.8:
+		style = warnStyle
+	}
+	return fmt.Sprintf("%-11s %s %s", ansi.Truncate(string(s.Provider), 11, "…"), style.Render(render.Bar(used, 30)),
+		style.Render(strings.Join(left, ", ")+" left "+period))
+}
+
+// groupLines lists the current tab's groups with their spend and a trend:
+// a sparkline of daily spend, or for days a bar against the busiest day.
+func (d dashboard) groupLines() []string {
+	groups := d.groups()
+	if len(groups) == 0 {
+		return []string{infoStyle.Render(fmt.Sprintf("No requests in the last %d days.", d.ledger.days))}
+	}
+	top := 0.0
+	for _, g := range groups {
+		top = max(top, g.cost)
+	}
+
+	start, end := visible(d.cursor[d.tab], len(groups), d.rows())
+	var lines []string
+	for i := start; i < end; i++ {
+		g := groups[i]
+		trend := render.Sparkline(g.daily)
+		if d.tab == tabDays {
+			trend = render.Bar(g.cost/top, 30)
+		}
+		name := fmt.Sprintf("%-32s", ansi.Truncate(g.name, 32, "…"))
+		prefix := "  "
+		if i == d.cursor[d.tab] {
+			prefix = selectedStyle.Render(render.Symbol("▸ ", "> "))
+			name = selectedStyle.Render(name)
+		}
+		line := fmt.Sprintf("%s%s %s %6d req %7s tok %s", prefix, name,
+			costStyle.Render(fmt.Sprintf("%11s", fmt.Sprintf("$%.4f", g.cost))), len(g.entries),
+			formatTokens(int64(g.inputTokens+g.outputTokens)), infoStyle.Render(trend))
+		if r := g.ratings(); r != "" {
+			line += "  " + r
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// entryLines lists the requests of the drilled-into group.
+func (d dashboard) entryLines() []string {
+	entries := d.group.entries
+	start, end := visible(d.entry, len(entries), d.rows()-1)
+	var lines []string
+	for i := start; i < end; i++ {
+		e := entries[i]
+		line := fmt.Sprintf("%s %-28s %7d in %6d out %s %6.1fs  %s",
+			e.Time.Local().Format("01-02 15:04"),
+			ansi.Truncate(e.Provider+"/"+e.UsedModel(), 28, "…"),
+			e.Usage.InputTokens, e.Usage.OutputTokens,
+			costStyle.Render(fmt.Sprintf("%10s", fmt.Sprintf("$%.4f", e.Cost))),
+			float64(e.LatencyMS)/1000, snippet(e))
+		if e.Error != "" {
+			line += errorStyle.Render(" (error)")
+		}
+		if e.Truncated {
+			line += warnStyle.Render(" (truncated)")
+		}
+		switch ratingOf(e) {
+		case "good":
+			line += okStyle.Render(" (good)")
+		case "bad":
+			line += errorStyle.Render(" (bad)")
+		}
+		prefix := "  "
+		if i == d.entry {
+			prefix = selectedStyle.Render(render.Symbol("▸ ", "> "))
+		}
+		lines = append(lines, prefix+line)
+	}
+	return lines
+}
+
+// chartLines charts the drilled-into group's cumulative tokens and cost,
+// request by request from the oldest: for a session, a curve bending
+// upward is a context that keeps growing.
+func (d dashboard) chartLines() []string {
+	entries := d.group.entries
+	tokens := make([]float64, len(entries))
+	costs := make([]float64, len(entries))
+	var total, cost float64
+	for i := range entries {
+		e := entries[len(entries)-1-i]
+		total += float64(e.Usage.InputTokens + e.Usage.OutputTokens)
+		cost += e.Cost
+		tokens[i], costs[i] = total, cost
+	}
+
+	// Two charts, each with a title and two axis lines, in the rows there are
+	height := min(max((d.rows()-6)/2, 3), 12)
+	width := min(d.width-2, 100)
+	lines := []string{infoStyle.Render("Tokens so far")}
+	for _, line := range render.LineChart(tokens, width, height, func(v float64) string { return formatTokens(int64(v)) }) {
+		lines = append(lines, "  "+nameStyle.Render(line))
+	}
+	lines = append(lines, infoStyle.Render("Cost so far"))
+	for _, line := range render.LineChart(costs, width, height, func(v float64) string { return fmt.Sprintf("$%.4f", v) }) {
+		lines = append(lines, "  "+costStyle.Render(line))
+	}
+	return lines
+}
+
+// detailLines shows one request in full.
+func (d dashboard) detailLines() []string {
+	e := d.group.entries[d.entry]
+	field := func(label, value string) string {
+		return infoStyle.Render(fmt.Sprintf("%-10s ", label)) + value
+	}
+	lines := []string{
+		field("Time", e.Time.Local().Format(time.DateTime)),
+		field("Model", e.Provider+"/"+e.UsedModel()),
+		field("Tokens", fmt.Sprintf("%d in, %d out", e.Usage.InputTokens, e.Usage.OutputTokens)),
+		field("Cost", costStyle.Render(fmt.Sprintf("$%.6f", e.Cost))),
+		field("Latency", fmt.Sprintf("%.1fs", float64(e.LatencyMS)/1000)),
+	}
+	if e.ServedModel != "" {
+		lines = append(lines, field("Requested", e.Provider+"/"+e.Model))
+	}
+	if e.FailoverReason != "" {
+		lines = append(lines, field("Failover", warnStyle.Render(e.FailoverReason)))
+	}
+	if e.Session != "" {
+		lines = append(lines, field("Session", e.Session))
+	}
+	if len(e.Tags) > 0 {
+		lines = append(lines, field("Tags", strings.Join(e.Tags, ", ")))
+	}
+	if e.Error != "" {
+		lines = append(lines, field("Error", errorStyle.Render(e.Error)))
+	}
+	if e.Rating > 0 {
+		rating := fmt.Sprintf("%d/%d", e.Rating, transcript.RatingGood)
+		if verdict := ratingOf(e); verdict != "" {
+			rating = verdict
+		}
+		if e.Feedback != "" {
+			rating += ": " + e.Feedback
+		}
+		lines = append(lines, field("Rating", rating))
+	}
+
+	wrap := lipgloss.NewStyle().Width(max(d.width-2, 20))
+	if m, ok := lastUserMessage(e); ok {
+		lines = append(lines, "", headerStyle.Render("Prompt"))
+		lines = append(lines, strings.Split(wrap.Render(m.Content), "\n")...)
+	}
+	if e.Response != nil {
+		title := headerStyle.Render("Response")
+		if e.Truncated {
+			title += warnStyle.Render(" (truncated)")
+		}
+		lines = append(lines, "", title)
+		lines = append(lines, strings.Split(wrap.Render(e.Response.Content), "\n")...)
+	}
+	return lines
+}
+
+// lastUserMessage returns the prompt an entry answered.
+func lastUserMessage(e transcript.Entry) (transcript.Message, bool) {
+	for i := len(e.Request) - 1; i >= 0; i-- {
+		if e.Request[i].Role == "user" {
+			return e.Request[i], true
+		}
+	}
+	return transcript.Message{}, false
+}
+
+// snippet returns the start of an entry's prompt on one line.
+func snippet(e transcript.Entry) string {
+	m, _ := lastUserMessage(e)
+	return ansi.Truncate(strings.Join(strings.Fields(m.Content), " "), 60, "…")
+}
+
+// visible returns the range of n items to show in rows lines so that the
+// selected one is on screen.
+func visible(selected, n, rows int) (int, int) {
+	rows = max(rows, 1)
+	start := max(min(selected-rows/2, n-rows), 0)
+	return start, min(start+rows, n)
+}
+
+// print writes the overview and every tab once, for when output is not a
+// terminal.
+func (d dashboard) print() {
+	fmt.Println(d.header())
+	for tab, name := range tabNames {
+		d.tab = tab
+		groups := d.groups()
+		if len(groups) == 0 {
+			continue
+		}
+		fmt.Println()
+		fmt.Println(headerStyle.Render("By " + strings.TrimSuffix(name, "s")))
+		columns := []render.Column{
+			{Title: strings.TrimSuffix(name, "s"), Style: nameStyle},
+			{Title: "Cost", Align: render.AlignRight, Style: costStyle},
+			{Title: "Requests", Align: render.AlignRight},
+			{Title: "Tokens", Align: render.AlignRight},
+			{Title: "Trend"},
+		}
+		// Ratings get a column once any answer is rated
+		rated := slices.ContainsFunc(groups, func(g spendGroup) bool { return g.ratings() != "" })
+		if rated {
+			columns = append(columns, render.Column{Title: "Ratings"})
+		}
+		tbl := render.NewTable(columns...)
+		top := 0.0
+		for _, g := range groups {
+			top = max(top, g.cost)
+		}
+		for _, g := range groups {
+			trend := render.Sparkline(g.daily)
+			if tab == tabDays {
+				trend = render.Bar(g.cost/top, 20)
+			}
+			row := []string{g.name, fmt.Sprintf("$%.4f", g.cost), fmt.Sprint(len(g.entries)),
+				formatTokens(int64(g.inputTokens + g.outputTokens)), trend}
+			if rated {
+				row = append(row, g.ratings())
+			}
+			tbl.AddRow(row...)
+		}
+		tbl.Print()
+	}
+}
+
+// printDashboardHelp displays usage information for the dashboard command
+func printDashboardHelp() {
+	fmt.Println("aimodels dashboard - Browse spend from chat transcripts")
+	fmt.Println()
+	fmt.Println("Reads JSONL transcripts (chat-bot --log-transcript, or 'aimodels convert'")
+	fmt.Println("output) and shows spend by day, model, tag, and chat session, with a")
+	fmt.Println("sparkline of daily spend and progress bars against your budgets and")
+	fmt.Println("provider quotas. Select a group to list its requests, and a request to see")
+	fmt.Println("it in full, or press c to chart the group's tokens and cost so far, request")
+	fmt.Println("by request; a session's chart shows a context that keeps growing as a")
+	fmt.Println("curve bending upward. Entries with several tags count toward each, and")
+	fmt.Println("answers rated with chat-bot's /good and /bad are counted per row. When")
+	fmt.Println("output is not a terminal, the summary is printed once instead.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels dashboard [options] <transcript.jsonl>...")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --days <n>              Days of history to show, ending today (default: 30)")
+	fmt.Println("  --daily-budget <usd>    Budget for today's spend (0 = none)")
+	fmt.Println("  --weekly-budget <usd>   Budget for this week's spend, from Monday (0 = none)")
+	fmt.Println()
+	fmt.Println("Provider quotas, such as a free tier's daily request and token caps, are")
+	fmt.Println("read from the overrides file (see the global --overrides option):")
+	fmt.Println()
+	fmt.Println("  providers:")
+	fmt.Println("    groq:")
+	fmt.Println("      quota: {period: daily, requests: 14400, tokens: 500000}")
+	fmt.Println()
+	fmt.Println("Their use is counted from every transcript entry, whatever --days.")
+	fmt.Println()
+	fmt.Println("Keys:")
+	fmt.Println("  ←/→, tab    Switch between days, models, tags, and sessions")
+	fmt.Println("  ↑/↓, enter  Select and drill into requests")
+	fmt.Println("  c           Chart the selected group's tokens and cost")
+	fmt.Println("  esc         Go back; q quits")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels dashboard chat.jsonl")
+	fmt.Println("  aimodels dashboard --daily-budget 2 --weekly-budget 10 ~/logs/*.jsonl")
+	fmt.Println("  aimodels dashboard --days 7 chat.jsonl | less -R")
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"charm.land/catwalk/pkg/dataset"
+	"charm.land/catwalk/pkg/transcript"
+)
+
+func runDataset(_ context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "build" {
+		printDatasetHelp()
+		return errUsage
+	}
+
+	flags := flag.NewFlagSet("dataset build", flag.ExitOnError)
+	from := flags.String("from", "", "Transcript file or directory of .jsonl transcripts, comma-separated (required)")
+	format := flags.String("format", "openai", "Dataset format: openai or anthropic")
+	output := flags.String("output", "", "Write the dataset to this file instead of stdout")
+	tags := flags.String("tag", "", "Keep only replies with one of these comma-separated tags")
+	minRating := flags.Int("min-rating", 0, "Keep only replies rated at least this (1-5)")
+	minTokens := flags.Int("min-tokens", 0, "Drop examples with fewer estimated tokens")
+	maxTokens := flags.Int("max-tokens", 0, "Drop examples with more estimated tokens")
+	stripSystem := flags.Bool("strip-system", false, "Leave system prompts out of the examples")
+	epochs := flags.Int("epochs", 3, "Training epochs, for the cost estimate")
+	price := flags.Float64("training-price", 0, "Training price in USD per 1M tokens, for the cost estimate")
+	flags.Usage = printDatasetHelp
+	_ = flags.Parse(args[1:])
+
+	if *from == "" || flags.NArg() > 0 {
+		printDatasetHelp()
+		return errUsage
+	}
+	f, err := dataset.ParseFormat(*format)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	if *minRating < 0 || *minRating > 5 || *minTokens < 0 || *maxTokens < 0 || *epochs < 1 || *price < 0 {
+		fmt.Fprintln(os.Stderr, errorStyle.Render("--min-rating must be 0-5, --epochs at least 1, and the rest not negative"))
+		return errUsage
+	}
+
+	entries, files, err := readTranscripts(strings.Split(*from, ","))
+	if err != nil {
+		return err
+	}
+	filter := dataset.Filter{
+		MinRating:   *minRating,
+		MinTokens:   *minTokens,
+		MaxTokens:   *maxTokens,
+		StripSystem: *stripSystem,
+	}
+	for _, tag := range strings.Split(*tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+	examples, stats := dataset.Build(entries, filter)
+	if len(examples) == 0 {
+		return fmt.Errorf("no examples left of %d entries in %d files (%s)", stats.Entries, files, datasetDrops(stats))
+	}
+
+	var w io.Writer = os.Stdout
+	summary := os.Stderr
+	if *output != "" {
+		out, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output: %w", err)
+		}
+		defer out.Close() //nolint:errcheck
+		w, summary = out, os.Stdout
+	}
+	if err := dataset.Write(w, examples, f); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	tokens := dataset.Tokens(examples)
+	dest := "stdout"
+	if *output != "" {
+		dest = *output
+	}
+	fmt.Fprintf(summary, "%s %d examples from %d entries in %d files to %s\n",
+		okStyle.Render("Wrote"), stats.Examples, stats.Entries, files, dest)
+	if drops := datasetDrops(stats); drops != "" {
+		fmt.Fprintln(summary, infoStyle.Render("Dropped: "+drops))
+	}
+	trained := tokens * int64(*epochs)
+	line := fmt.Sprintf("Training: ~%s tokens per epoch, ~%s over %d epochs",
+		formatTokens(tokens), formatTokens(trained), *epochs)
+	if *price > 0 {
+		line += fmt.Sprintf(", ~$%.4f at $%.2f/1M", float64(trained)*(*price)/1_000_000, *price)
+	}
+	fmt.Fprintln(summary, line)
+	if *price == 0 {
+		fmt.Fprintln(summary, infoStyle.Render("The catalog has no training prices; pass --training-price for a cost estimate."))
+	}
+	return nil
+}
+
+// readTranscripts reads the transcripts at paths, descending into
+// directories for their .jsonl files, and returns the entries and the number
+// of files read.
+func readTranscripts(paths []string) ([]transcript.Entry, int, error) {
+	var entries []transcript.Entry
+	files := 0
+	read := func(path string) error {
+		e, err := transcript.ReadFile(path)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		entries = append(entries, e...)
+		files++
+		return nil
+	}
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read transcripts: %w", err)
+		}
+		if !info.IsDir() {
+			if err := read(path); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || filepath.Ext(p) != ".jsonl" {
+				return nil
+			}
+			return read(p)
+		})
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read transcripts: %w", err)
+		}
+	}
+	if files == 0 {
+		return nil, 0, errors.New("no .jsonl transcripts found")
+	}
+	return entries, files, nil
+}
+
+// datasetDrops describes the entries that did not become examples.
+func datasetDrops(s dataset.Stats) string {
+	var parts []string
+	for _, d := range []struct {
+		n    int
+		what string
+	}{
+		{s.Failed, "failed or empty"},
+		{s.Filtered, "filtered out"},
+		{s.Malformed, "not starting with a user turn"},
+		{s.Duplicates, "duplicates"},
+	} {
+		if d.n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", d.n, d.what))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printDatasetHelp displays usage information for the dataset command
+func printDatasetHelp() {
+	fmt.Println("aimodels dataset - Build fine-tuning datasets from chat transcripts")
+	fmt.Println()
+	fmt.Println("Turns logged replies (chat-bot --log-transcript, or aimodels convert) into")
+	fmt.Println("fine-tuning JSONL: one example per reply, with the conversation before it.")
+	fmt.Println("Failed requests are skipped, consecutive turns of one role are merged, and")
+	fmt.Println("repeated examples are written once. An earlier turn of a conversation that")
+	fmt.Println("a later example continues is dropped, since the later one covers it.")
+	fmt.Println("Ratings are read from the entries' rating field (1-5), which chat-bot's /good")
+	fmt.Println("and /bad set to 5 and 1.")
+	fmt.Println()
+	fmt.Println("The summary estimates the tokens a tuning job trains on, and its cost at")
+	fmt.Println("--training-price, which the catalog does not list.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels dataset build --from <path> [options]")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --from <paths>          Transcript files or directories, comma-separated")
+	fmt.Println("  --format <f>            openai (default) or anthropic (Claude on Bedrock)")
+	fmt.Println("  --output <file>         Write to a file instead of stdout")
+	fmt.Println("  --tag <tags>            Keep only replies with one of these tags")
+	fmt.Println("  --min-rating <n>        Keep only replies rated at least n (1-5)")
+	fmt.Println("  --min-tokens <n>        Drop examples with fewer estimated tokens")
+	fmt.Println("  --max-tokens <n>        Drop examples with more estimated tokens")
+	fmt.Println("  --strip-system          Leave system prompts out")
+	fmt.Println("  --epochs <n>            Training epochs, for the estimate (default: 3)")
+	fmt.Println("  --training-price <usd>  Training price per 1M tokens, for the estimate")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels dataset build --from transcripts/ --output train.jsonl")
+	fmt.Println("  aimodels dataset build --from transcripts/ --format anthropic --tag support --min-rating 4 --strip-system")
+	fmt.Println("  aimodels dataset build --from chat.jsonl --max-tokens 8000 --training-price 25 > train.jsonl")
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"go.yaml.in/yaml/v2"
+)
+
+// exporter writes the selected providers in a target tool's format.
+type exporter func(w io.Writer, providers []catwalk.Provider) error
+
+var exporters = map[string]exporter{
+	"crush":    exportCrush,
+	"aider":    exportAider,
+	"continue": exportContinue,
+	"litellm":  exportLiteLLM,
+}
+
+func runExportConfig(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export-config", flag.ExitOnError)
+	target := fs.String("target", "", "Target tool: crush, aider, continue, or litellm (required)")
+	providerList := fs.String("provider", "", "Comma-separated provider IDs to export (default: all)")
+	modelList := fs.String("model", "", "Comma-separated model IDs to export (default: all)")
+	output := fs.String("output", "", "Write to this file instead of stdout")
+	fs.Usage = printExportConfigHelp
+	_ = fs.Parse(args)
+
+	export, ok := exporters[strings.ToLower(*target)]
+	if !ok {
+		printExportConfigHelp()
+		return errUsage
+	}
+
+	providers, err := fetchProviders(ctx)
+	if err != nil {
+		return err
+	}
+	providers = selectProviders(providers, splitList(*providerList), splitList(*modelList))
+	if len(providers) == 0 {
+		return fmt.Errorf("no providers or models matched the selection")
+	}
+
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close() //nolint:errcheck
+		w = f
+	}
+	return export(w, providers)
+}
+
+// selectProviders narrows the catalog to the requested providers and models,
+// dropping providers left without models.
+func selectProviders(providers []catwalk.Provider, providerIDs, modelIDs []string) []catwalk.Provider {
+	var selected []catwalk.Provider
+	for _, p := range providers {
+		if len(providerIDs) > 0 && !containsFold(providerIDs, string(p.ID)) {
+			continue
+		}
+		if len(modelIDs) > 0 {
+			var models []catwalk.Model
+			for _, m := range p.Models {
+				if containsFold(modelIDs, m.ID) {
+					models = append(models, m)
+				}
+			}
+			p.Models = models
+		}
+		if len(p.Models) > 0 {
+			selected = append(selected, p)
+		}
+	}
+	return selected
+}
+
+// staticEndpoint returns the provider endpoint unless it is an environment
+// variable reference, in which case the target tool's default is used.
+func staticEndpoint(p catwalk.Provider) string {
+	if envVarName(p.APIEndpoint) != "" {
+		return ""
+	}
+	return p.APIEndpoint
+}
+
+// exportCrush writes a crush.json providers section. Crush resolves $VAR
+// references itself, so catalog values are kept verbatim.
+func exportCrush(w io.Writer, providers []catwalk.Provider) error {
+	type crushProvider struct {
+		Name         string            `json:"name"`
+		Type         catwalk.Type      `json:"type"`
+		BaseURL      string            `json:"base_url,omitempty"`
+		APIKey       string            `json:"api_key,omitempty"`
+		ExtraHeaders map[string]string `json:"extra_headers,omitempty"`
+		Models       []catwalk.Model   `json:"models"`
+	}
+	type crushConfig struct {
+		Schema    string                   `json:"$schema"`
+		Providers map[string]crushProvider `json:"providers"`
+	}
+
+	cfg := crushConfig{
+		Schema:    "https://charm.land/crush.json",
+		Providers: map[string]crushProvider{},
+	}
+	for _, p := range providers {
+		cfg.Providers[string(p.ID)] = crushProvider{
+			Name:         p.Name,
+			Type:         p.Type,
+			BaseURL:      p.APIEndpoint,
+			APIKey:       p.APIKey,
+			ExtraHeaders: p.DefaultHeaders,
+			Models:       p.Models,
+		}
+	}
+	return writeJSON(w, cfg)
+}
+
+// exportAider writes an .aider.model.metadata.json file describing context
+// limits and per-token pricing for each model.
+func exportAider(w io.Writer, providers []catwalk.Provider) error {
+	type aiderModel struct {
+		MaxTokens          int64   `json:"max_tokens"`
+		MaxInputTokens     int64   `json:"max_input_tokens"`
+		MaxOutputTokens    int64   `json:"max_output_tokens"`
+		InputCostPerToken  float64 `json:"input_cost_per_token"`
+		OutputCostPerToken float64 `json:"output_cost_per_token"`
+		LiteLLMProvider    string  `json:"litellm_provider"`
+		Mode               string  `json:"mode"`
+		SupportsVision     bool    `json:"supports_vision,omitempty"`
+		SupportsReasoning  bool    `json:"supports_reasoning,omitempty"`
+	}
+
+	metadata := map[string]aiderModel{}
+	for _, p := range providers {
+		prefix := liteLLMProvider(p)
+		for _, m := range p.Models {
+			metadata[prefix+"/"+m.ID] = aiderModel{
+				MaxTokens:          m.DefaultMaxTokens,
+				MaxInputTokens:     m.ContextWindow,
+				MaxOutputTokens:    m.OutputLimit(),
+				InputCostPerToken:  m.CostPer1MIn / 1_000_000,
+				OutputCostPerToken: m.CostPer1MOut / 1_000_000,
+				LiteLLMProvider:    prefix,
+				Mode:               "chat",
+				SupportsVision:     m.SupportsImages,
+				SupportsReasoning:  m.CanReason,
+			}
+		}
+	}
+	return writeJSON(w, metadata)
+}
+
+// exportContinue writes a Continue config.yaml with one entry per model.
+func exportContinue(w io.Writer, providers []catwalk.Provider) error {
+	type completionOptions struct {
+		ContextLength int64 `yaml:"contextLength,omitempty"`
+		MaxTokens     int64 `yaml:"maxTokens,omitempty"`
+	}
+	type continueModel struct {
+		Name                     string            `yaml:"name"`
+		Provider                 string            `yaml:"provider"`
+		Model                    string            `yaml:"model"`
+		APIBase                  string            `yaml:"apiBase,omitempty"`
+		APIKey                   string            `yaml:"apiKey,omitempty"`
+		RequestOptions           map[string]any    `yaml:"requestOptions,omitempty"`
+		DefaultCompletionOptions completionOptions `yaml:"defaultCompletionOptions,omitempty"`
+		Roles                    []string          `yaml:"roles"`
+	}
+	type continueConfig struct {
+		Name    string          `yaml:"name"`
+		Version string          `yaml:"version"`
+		Schema  string          `yaml:"schema"`
+		Models  []continueModel `yaml:"models"`
+	}
+
+	cfg := continueConfig{Name: "catwalk", Version: "0.0.1", Schema: "v1"}
+	for _, p := range providers {
+		var apiKey string
+		if env := envVarName(p.APIKey); env != "" {
+			apiKey = "${{ secrets." + env + " }}"
+		}
+		var requestOptions map[string]any
+		if len(p.DefaultHeaders) > 0 {
+			requestOptions = map[string]any{"headers": p.DefaultHeaders}
+		}
+		for _, m := range p.Models {
+			cfg.Models = append(cfg.Models, continueModel{
+				Name:           fmt.Sprintf("%s (%s)", m.Name, p.Name),
+				Provider:       continueProvider(p),
+				Model:          m.ID,
+				APIBase:        staticEndpoint(p),
+				APIKey:         apiKey,
+				RequestOptions: requestOptions,
+				DefaultCompletionOptions: completionOptions{
+					ContextLength: m.ContextWindow,
+					MaxTokens:     m.DefaultMaxTokens,
+				},
+				Roles: []string{"chat", "edit", "apply"},
+			})
+		}
+	}
+	return writeYAML(w, cfg)
+}
+
+// exportLiteLLM writes a LiteLLM proxy config.yaml model_list.
+func exportLiteLLM(w io.Writer, providers []catwalk.Provider) error {
+	type litellmParams struct {
+		Model        string            `yaml:"model"`
+		APIBase      string            `yaml:"api_base,omitempty"`
+		APIKey       string            `yaml:"api_key,omitempty"`
+		ExtraHeaders map[string]string `yaml:"extra_headers,omitempty"`
+	}
+	type modelInfo struct {
+		InputCostPerToken  float64 `yaml:"input_cost_per_token"`
+		OutputCostPerToken float64 `yaml:"output_cost_per_token"`
+		MaxInputTokens     int64   `yaml:"max_input_tokens,omitempty"`
+		MaxOutputTokens    int64   `yaml:"max_output_tokens,omitempty"`
+		SupportsVision     bool    `yaml:"supports_vision,omitempty"`
+		SupportsReasoning  bool    `yaml:"supports_reasoning,omitempty"`
+	}
+	type modelEntry struct {
+		ModelName     string        `yaml:"model_name"`
+		LiteLLMParams litellmParams `yaml:"litellm_params"`
+		ModelInfo     modelInfo     `yaml:"model_info"`
+	}
+	type litellmConfig struct {
+		ModelList []modelEntry `yaml:"model_list"`
+	}
+
+	// Model names must be unique, otherwise LiteLLM load-balances between
+	// them, so IDs offered by several providers are qualified.
+	seen := map[string]int{}
+	for _, p := range providers {
+		for _, m := range p.Models {
+			seen[m.ID]++
+		}
+	}
+
+	var cfg litellmConfig
+	for _, p := range providers {
+		var apiKey string
+		if env := envVarName(p.APIKey); env != "" {
+			apiKey = "os.environ/" + env
+		}
+		prefix := liteLLMProvider(p)
+		for _, m := range p.Models {
+			name := m.ID
+			if seen[m.ID] > 1 {
+				name = string(p.ID) + "/" + m.ID
+			}
+			cfg.ModelList = append(cfg.ModelList, modelEntry{
+				ModelName: name,
+				LiteLLMParams: litellmParams{
+					Model:        prefix + "/" + m.ID,
+					APIBase:      staticEndpoint(p),
+					APIKey:       apiKey,
+					ExtraHeaders: p.DefaultHeaders,
+				},
+				ModelInfo: modelInfo{
+					InputCostPerToken:  m.CostPer1MIn / 1_000_000,
+					OutputCostPerToken: m.CostPer1MOut / 1_000_000,
+					MaxInputTokens:     m.ContextWindow,
+					MaxOutputTokens:    m.OutputLimit(),
+					SupportsVision:     m.SupportsImages,
+					SupportsReasoning:  m.CanReason,
+				},
+			})
+		}
+	}
+	return writeYAML(w, cfg)
+}
+
+// liteLLMProvider maps a catwalk provider to the LiteLLM provider prefix used
+// by LiteLLM and aider. OpenAI-compatible providers use the "openai" prefix
+// together with an explicit api_base.
+func liteLLMProvider(p catwalk.Provider) string {
+	switch p.Type {
+	case catwalk.TypeAnthropic:
+		if staticEndpoint(p) != "" {
+			return string(p.ID)
+		}
+		return "anthropic"
+	case catwalk.TypeGoogle:
+		return "gemini"
+	case catwalk.TypeAzure:
+		return "azure"
+	case catwalk.TypeBedrock:
+		return "bedrock"
+	case catwalk.TypeVertexAI:
+		return "vertex_ai"
+	case catwalk.TypeOpenRouter:
+		return "openrouter"
+	default:
+		return "openai"
+	}
+}
+
+// continueProvider maps a catwalk provider to a Continue provider name.
+func continueProvider(p catwalk.Provider) string {
+	switch p.Type {
+	case catwalk.TypeAnthropic:
+		return "anthropic"
+	case catwalk.TypeGoogle:
+		return "gemini"
+	case catwalk.TypeAzure:
+		return "azure"
+	case catwalk.TypeBedrock:
+		return "bedrock"
+	case catwalk.TypeVertexAI:
+		return "vertexai"
+	case catwalk.TypeOpenRouter:
+		return "openrouter"
+	default:
+		return "openai"
+	}
+}
+
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return nil
+}
+
+func writeYAML(w io.Writer, v any) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write YAML: %w", err)
+	}
+	return nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// containsFold reports whether list contains s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// printExportConfigHelp displays usage information for export-config
+func printExportConfigHelp() {
+	fmt.Println("aimodels export-config - Export catalog data as another tool's configuration")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels export-config --target <tool> [options]")
+	fmt.Println()
+	fmt.Println("Targets:")
+	fmt.Println("  crush      crush.json providers section")
+	fmt.Println("  aider      .aider.model.metadata.json")
+	fmt.Println("  continue   Continue config.yaml")
+	fmt.Println("  litellm    LiteLLM proxy config.yaml")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --target <tool>     Target tool (required)")
+	fmt.Println("  --provider <ids>    Comma-separated provider IDs to export (default: all)")
+	fmt.Println("  --model <ids>       Comma-separated model IDs to export (default: all)")
+	fmt.Println("  --output <file>     Write to a file instead of stdout")
+	fmt.Println()
+	fmt.Println("API keys are exported as references to the catalog's environment")
+	fmt.Println("variables, never as literal values.")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels export-config --target crush --provider openai,anthropic")
+	fmt.Println("  aimodels export-config --target litellm --model gpt-4o,claude-sonnet-4-20250514")
+	fmt.Println("  aimodels export-config --target aider --output .aider.model.metadata.json")
+}
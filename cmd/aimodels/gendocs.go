@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// docsPage is a provider page of the generated reference.
+type docsPage struct {
+	catwalk.Provider
+	File   string
+	Models []docsModel
+}
+
+// docsModel is a model row, with the anchor other pages link to.
+type docsModel struct {
+	catwalk.Model
+	Anchor string
+	Badges []string
+}
+
+// docsSite is the whole reference, shared by the Markdown and HTML writers.
+type docsSite struct {
+	Title string
+	Notes []string
+	Pages []docsPage
+	// Ext is the file extension of the pages, with its dot.
+	Ext string
+}
+
+func runGenDocs(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("gen-docs", flag.ExitOnError)
+	providerList := fs.String("provider", "", "Comma-separated provider IDs to include (default: all)")
+	modelList := fs.String("model", "", "Comma-separated model IDs to include (default: all)")
+	format := fs.String("format", "markdown", "Output format: markdown or html")
+	output := fs.String("output", "model-docs", "Directory to write the pages to")
+	title := fs.String("title", "Model Reference", "Title of the index page")
+	fs.Usage = printGenDocsHelp
+	_ = fs.Parse(args)
+
+	var ext string
+	switch *format {
+	case "markdown", "md":
+		ext = ".md"
+	case "html":
+		ext = ".html"
+	default:
+		return fmt.Errorf("unknown format: %s (use markdown or html)", *format)
+	}
+
+	providers, err := fetchProviders(ctx)
+	if err != nil {
+		return err
+	}
+	providers = selectProviders(providers, splitList(*providerList), splitList(*modelList))
+	if len(providers) == 0 {
+		return fmt.Errorf("no providers matched the selection")
+	}
+
+	site := buildDocs(providers, *title, ext)
+	if err := os.MkdirAll(*output, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	write := func(file string, render func(io.Writer) error) error {
+		var buf bytes.Buffer
+		if err := render(&buf); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(*output, file), buf.Bytes(), 0o644); err != nil { //nolint:gosec
+			return fmt.Errorf("failed to write %s: %w", file, err)
+		}
+		return nil
+	}
+
+	html := ext == ".html"
+	if err := write("index"+ext, func(w io.Writer) error {
+		if html {
+			return execDocs(w, "index", site)
+		}
+		site.writeIndexMarkdown(w)
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, p := range site.Pages {
+		if err := write(p.File, func(w io.Writer) error {
+			if html {
+				return execDocs(w, "provider", struct {
+					Site docsSite
+					Page docsPage
+				}{site, p})
+			}
+			site.writePageMarkdown(w, p)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println(okStyle.Render(fmt.Sprintf("Wrote %s to %s", count(len(site.Pages)+1, "page"), *output)))
+	return nil
+}
+
+// buildDocs prepares the pages, with one file per provider and one anchor
+// per model.
+func buildDocs(providers []catwalk.Provider, title, ext string) docsSite {
+	version := *catalogVersion
+	if version == "" {
+		version = "live"
+	}
+	site := docsSite{
+		Title: title,
+		Notes: []string{fmt.Sprintf("Generated %s from the catwalk catalog (%s). Prices are USD per 1M tokens.",
+			time.Now().UTC().Format("2006-01-02"), version)},
+		Ext: ext,
+	}
+	files := map[string]bool{"index": true}
+	for _, p := range providers {
+		page := docsPage{Provider: p, File: uniqueSlug(files, string(p.ID)) + ext}
+		anchors := map[string]bool{}
+		for _, m := range p.Models {
+			dm := docsModel{Model: m, Anchor: uniqueSlug(anchors, m.ID)}
+			for _, c := range capabilities {
+				if c.has(m) {
+					dm.Badges = append(dm.Badges, c.name)
+				}
+			}
+			page.Models = append(page.Models, dm)
+		}
+		site.Pages = append(site.Pages, page)
+	}
+	return site
+}
+
+// uniqueSlug turns s into a lowercase slug of letters, digits, and dashes,
+// numbered if taken, and records it in taken.
+func uniqueSlug(taken map[string]bool, s string) string {
+	slug := strings.Trim(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '-'
+	}, s), "-")
+	if slug == "" {
+		slug = "model"
+	}
+	unique := slug
+	for i := 2; taken[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", slug, i)
+	}
+	taken[unique] = true
+	return unique
+}
+
+// Anchor returns the anchor of one of the page's models, or "" if it is not
+// among them.
+func (p docsPage) Anchor(id string) string {
+	for _, m := range p.Models {
+		if m.ID == id {
+			return m.Anchor
+		}
+	}
+	return ""
+}
+
+// docsPrice formats a price per 1M tokens, with cents unless it needs more
+// precision, and a dash for prices the catalog does not list.
+func docsPrice(v float64) string {
+	switch {
+	case v == 0:
+		return "–"
+	case math.Abs(v*100-math.Round(v*100)) < 1e-9:
+		return fmt.Sprintf("$%.2f", v)
+	default:
+		return "$" + strconv.FormatFloat(math.Round(v*1e6)/1e6, 'f', -1, 64)
+	}
+}
+
+// docsTokens formats a token limit, with a dash for unknown ones.
+func docsTokens(n int64) string {
+	if n <= 0 {
+		return "–"
+	}
+	return formatTokens(n)
+}
+
+// Endpoint returns the provider's endpoint, unless it is an environment
+// variable reference that means nothing to readers.
+func (p docsPage) Endpoint() string {
+	return staticEndpoint(p.Provider)
+}
+
+// CheapestInput returns the lowest nonzero input price of a page's models.
+func (p docsPage) CheapestInput() float64 {
+	var low float64
+	for _, m := range p.Models {
+		if m.CostPer1MIn > 0 && (low == 0 || m.CostPer1MIn < low) {
+			low = m.CostPer1MIn
+		}
+	}
+	return low
+}
+
+// writeIndexMarkdown writes the index page, linking each provider's page.
+func (s docsSite) writeIndexMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "# %s\n\n", s.Title)
+	for _, n := range s.Notes {
+		fmt.Fprintf(w, "%s\n\n", n)
+	}
+	writeMarkdownRow(w, []string{"Provider", "ID", "Type", "Models", "Cheapest input", "Default model"})
+	writeMarkdownRow(w, []string{"---", "---", "---", "--:", "--:", "---"})
+	for _, p := range s.Pages {
+		def := p.DefaultLargeModelID
+		if a := p.Anchor(def); a != "" {
+			def = fmt.Sprintf("[%s](%s#%s)", def, p.File, a)
+		}
+		writeMarkdownRow(w, []string{
+			fmt.Sprintf("[%s](%s)", p.Name, p.File), "`" + string(p.ID) + "`", string(p.Type),
+			strconv.Itoa(len(p.Models)), docsPrice(p.CheapestInput()), def,
+		})
+	}
+}
+
+// writePageMarkdown writes a provider page: its settings, then a pricing
+// table with an anchor and capability badges per model.
+func (s docsSite) writePageMarkdown(w io.Writer, p docsPage) {
+	fmt.Fprintf(w, "# %s\n\n", p.Name)
+	fmt.Fprintf(w, "[← %s](index%s)\n\n", s.Title, s.Ext)
+	fmt.Fprintf(w, "- ID: `%s`\n", p.ID)
+	fmt.Fprintf(w, "- Type: `%s`\n", p.Type)
+	if endpoint := p.Endpoint(); endpoint != "" {
+		fmt.Fprintf(w, "- Endpoint: `%s`\n", endpoint)
+	}
+	if env := p.APIKeyEnv(); env != "" {
+		fmt.Fprintf(w, "- API key: `%s`\n", env)
+	}
+	for _, d := range []struct{ name, id string }{
+		{"Default large model", p.DefaultLargeModelID},
+		{"Default small model", p.DefaultSmallModelID},
+	} {
+		if d.id == "" {
+			continue
+		}
+		if a := p.Anchor(d.id); a != "" {
+			fmt.Fprintf(w, "- %s: [`%s`](#%s)\n", d.name, d.id, a)
+		} else {
+			fmt.Fprintf(w, "- %s: `%s`\n", d.name, d.id)
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "## Models\n\n")
+
+	writeMarkdownRow(w, []string{"Model", "Name", "Context", "Max output", "Input", "Output", "Cached input", "Cached output", "Capabilities"})
+	writeMarkdownRow(w, []string{"---", "---", "--:", "--:", "--:", "--:", "--:", "--:", "---"})
+	for _, m := range p.Models {
+		badges := make([]string, len(m.Badges))
+		for i, b := range m.Badges {
+			badges[i] = "`" + b + "`"
+		}
+		writeMarkdownRow(w, []string{
+			fmt.Sprintf(`<a id="%s"></a>%s`, m.Anchor, m.ID), m.Name,
+			docsTokens(m.ContextWindow), docsTokens(m.MaxOutputTokens),
+			docsPrice(m.CostPer1MIn), docsPrice(m.CostPer1MOut),
+			docsPrice(m.CostPer1MInCached), docsPrice(m.CostPer1MOutCached),
+			strings.Join(badges, " "),
+		})
+	}
+	fmt.Fprintln(w)
+	for _, n := range s.Notes {
+		fmt.Fprintf(w, "_%s_\n", n)
+	}
+}
+
+func execDocs(w io.Writer, name string, data any) error {
+	if err := docsTemplates.ExecuteTemplate(w, name, data); err != nil {
+		return fmt.Errorf("failed to render %s page: %w", name, err)
+	}
+	return nil
+}
+
+var docsTemplates = template.Must(template.New("docs").Funcs(template.FuncMap{
+	"price":  docsPrice,
+	"tokens": docsTokens,
+	"list":   func(v ...any) []any { return v },
+}).Parse(`{{define "head"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem auto; max-width: 1200px; padding: 0 1rem; color: #1f2328; }
+h2 { margin-top: 2rem; border-bottom: 1px solid #d0d7de; padding-bottom: 0.25rem; }
+a { color: #0969da; text-decoration: none; }
+a:hover { text-decoration: underline; }
+code { background: #f6f8fa; padding: 0.1rem 0.3rem; border-radius: 4px; }
+.notes { color: #59636e; }
+table { border-collapse: collapse; width: 100%; font-size: 0.9rem; }
+th, td { padding: 0.4rem 0.6rem; border-bottom: 1px solid #d0d7de; text-align: left; }
+th { background: #f6f8fa; white-space: nowrap; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; white-space: nowrap; }
+tr:target td { background: #fff8c5; }
+.badge { display: inline-block; margin: 0.1rem; padding: 0 0.45rem; border-radius: 1rem; background: #ddf4ff; color: #0550ae; font-size: 0.75rem; white-space: nowrap; }
+</style>
+</head>
+<body>
+{{end}}
+{{define "model"}}{{$id := index . 1}}{{with (index . 0).Anchor $id}}<a href="#{{.}}"><code>{{$id}}</code></a>{{else}}<code>{{$id}}</code>{{end}}{{end}}
+{{define "index"}}{{template "head" .Title}}<h1>{{.Title}}</h1>
+{{range .Notes}}<p class="notes">{{.}}</p>
+{{end}}<table>
+<thead><tr><th>Provider</th><th>ID</th><th>Type</th><th>Models</th><th>Cheapest input</th><th>Default model</th></tr></thead>
+<tbody>
+{{range $page := .Pages}}<tr><td><a href="{{.File}}">{{.Name}}</a></td><td><code>{{.ID}}</code></td><td>{{.Type}}</td><td class="num">{{len .Models}}</td><td class="num">{{price .CheapestInput}}</td><td>{{with .Anchor .DefaultLargeModelID}}<a href="{{$page.File}}#{{.}}">{{$page.DefaultLargeModelID}}</a>{{else}}{{.DefaultLargeModelID}}{{end}}</td></tr>
+{{end}}</tbody>
+</table>
+</body>
+</html>
+{{end}}
+{{define "provider"}}{{template "head" .Page.Name}}{{$page := .Page}}<p><a href="index{{.Site.Ext}}">← {{.Site.Title}}</a></p>
+<h1>{{.Page.Name}}</h1>
+<ul>
+<li>ID: <code>{{.Page.ID}}</code></li>
+<li>Type: <code>{{.Page.Type}}</code></li>
+{{with .Page.Endpoint}}<li>Endpoint: <code>{{.}}</code></li>
+{{end}}{{with .Page.APIKeyEnv}}<li>API key: <code>{{.}}</code></li>
+{{end}}{{with .Page.DefaultLargeModelID}}<li>Default large model: {{template "model" (list $page .)}}</li>
+{{end}}{{with .Page.DefaultSmallModelID}}<li>Default small model: {{template "model" (list $page .)}}</li>
+{{end}}</ul>
+<h2>Models</h2>
+<table>
+<thead><tr><th>Model</th><th>Name</th><th>Context</th><th>Max output</th><th>Input</th><th>Output</th><th>Cached input</th><th>Cached output</th><th>Capabilities</th></tr></thead>
+<tbody>
+{{range .Page.Models}}<tr id="{{.Anchor}}"><td><a href="#{{.Anchor}}"><code>{{.ID}}</code></a></td><td>{{.Name}}</td><td class="num">{{tokens .ContextWindow}}</td><td class="num">{{tokens .MaxOutputTokens}}</td><td class="num">{{price .CostPer1MIn}}</td><td class="num">{{price .CostPer1MOut}}</td><td class="num">{{price .CostPer1MInCached}}</td><td class="num">{{price .CostPer1MOutCached}}</td><td>{{range .Badges}}<span class="badge">{{.}}</span>{{end}}</td></tr>
+{{end}}</tbody>
+</table>
+{{range .Site.Notes}}<p class="notes">{{.}}</p>
+{{end}}</body>
+</html>
+{{end}}`))
+
+// printGenDocsHelp displays usage information for gen-docs
+func printGenDocsHelp() {
+	fmt.Println("aimodels gen-docs - Generate a Markdown or HTML model reference")
+	fmt.Println()
+	fmt.Println("Writes an index page listing the providers and one page per provider with")
+	fmt.Println("its settings and a table of models: context window, output limit, prices,")
+	fmt.Println("and capability badges. Every model row has an anchor named after its ID")
+	fmt.Println("(e.g. openai.md#gpt-4o), so other documents can link to it.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels gen-docs [options]")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --provider <ids>  Comma-separated provider IDs to include (default: all)")
+	fmt.Println("  --model <ids>     Comma-separated model IDs to include (default: all)")
+	fmt.Println("  --format <fmt>    markdown (default) or html")
+	fmt.Println("  --output <dir>    Directory to write the pages to (default: model-docs)")
+	fmt.Println("  --title <text>    Title of the index page (default: Model Reference)")
+	fmt.Println()
+	fmt.Println("Existing pages in the directory are overwritten. Prices reflect --overrides,")
+	fmt.Println("so the reference can show what your organization actually pays.")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels gen-docs --output docs/models")
+	fmt.Println("  aimodels gen-docs --provider openai,anthropic --format html --output site")
+	fmt.Println("  aimodels --catalog-version 2025-06-01 gen-docs --title \"Approved Models\"")
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/config"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/theme"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// applyTheme recolors the styles for the theme chosen with aimodels init
+// or AIMODELS_THEME, recolored by the user theme file
+func applyTheme(setting config.Theme) {
+	t, err := theme.Select(setting)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, warnStyle.Render("Warning: "+err.Error()+"; using default colors"))
+		t = theme.Detect()
+	}
+	theme.Use(t)
+	headerStyle = headerStyle.Foreground(t.Header)
+	replPromptStyle = replPromptStyle.Foreground(t.Header)
+	nameStyle = nameStyle.Foreground(t.Accent)
+	selectedStyle = selectedStyle.Foreground(t.Accent)
+	infoStyle = infoStyle.Foreground(t.Muted)
+	tabStyle = tabStyle.Foreground(t.Muted)
+	okStyle = okStyle.Foreground(t.OK)
+	costStyle = costStyle.Foreground(t.OK)
+	warnStyle = warnStyle.Foreground(t.Warn)
+	errorStyle = errorStyle.Foreground(t.Error)
+	borderStyle = borderStyle.Foreground(t.Border)
+	activeTab = activeTab.Foreground(t.Inverse).Background(t.Header)
+}
+
+// themePreview shows a few words in a built-in theme's colors.
+func themePreview(name config.Theme) string {
+	t, _ := theme.Builtin(name)
+	if t.Name == config.ThemePlain {
+		return "Header  model-id  details  ok  warning  error (no color, ASCII)"
+	}
+	color := func(c lipgloss.Color, s string) string { return lipgloss.NewStyle().Foreground(c).Render(s) }
+	preview := strings.Join([]string{
+		lipgloss.NewStyle().Bold(true).Foreground(t.Header).Render("Header"),
+		color(t.Accent, "model-id"), color(t.Muted, "details"), color(t.OK, "ok"), color(t.Warn, "warning"), color(t.Error, "error"),
+	}, "  ")
+	if name == config.ThemeAuto {
+		preview += fmt.Sprintf(" (%s here)", t.Name)
+	}
+	return preview
+}
+
+// initSteps is the number of questions aimodels init asks.
+const initSteps = 4
+
+// defaultCatalogURL is where catwalk clients look without CATWALK_URL.
+const defaultCatalogURL = "http://localhost:8080"
+
+// maxListedModels is how many of a provider's models the wizard lists.
+const maxListedModels = 15
+
+// wizard asks the questions of aimodels init on stdin, or takes the
+// suggested answers when yes is set or stdin ends.
+type wizard struct {
+	in  *bufio.Reader
+	yes bool
+}
+
+// ask prints a question with its suggested answer and returns the answer,
+// or the suggestion if the reply is empty.
+func (w *wizard) ask(question, suggestion string) string {
+	fmt.Printf("%s [%s]: ", question, nameStyle.Render(suggestion))
+	if w.yes {
+		fmt.Println(suggestion)
+		return suggestion
+	}
+	line, err := w.in.ReadString('\n')
+	if line = strings.TrimSpace(line); line != "" {
+		return line
+	}
+	if errors.Is(err, io.EOF) {
+		// Out of answers: take the rest of the suggestions
+		fmt.Println(suggestion)
+		w.yes = true
+	}
+	return suggestion
+}
+
+// retry reports whether a wrong answer can be asked again.
+func (w *wizard) retry(err error) bool {
+	fmt.Println(errorStyle.Render("  " + err.Error()))
+	return !w.yes
+}
+
+func step(n int, title string) {
+	fmt.Println()
+	fmt.Println(headerStyle.Render(fmt.Sprintf("Step %d of %d: %s", n, initSteps, title)))
+}
+
+func runInit(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	catalogURL := fs.String("catalog-url", "", "Catwalk service URL (default: CATWALK_URL or http://localhost:8080)")
+	providerID := fs.String("provider", "", "Default provider, instead of asking")
+	modelName := fs.String("model", "", "Default model, instead of asking")
+	themeName := fs.String("theme", "", "Output theme, instead of asking: auto, dark, light, high-contrast, or plain")
+	yes := fs.Bool("yes", false, "Take the suggested answers without asking")
+	force := fs.Bool("force", false, "Overwrite an existing settings file without asking")
+	fs.Usage = printInitHelp
+	_ = fs.Parse(args)
+	if fs.NArg() > 0 {
+		printInitHelp()
+		return errUsage
+	}
+	if *themeName != "" && !config.Theme(*themeName).Valid() {
+		fmt.Fprintln(os.Stderr, errorStyle.Render("--theme must be auto, dark, light, high-contrast, or plain"))
+		return errUsage
+	}
+
+	path := *configFile
+	switch path {
+	case "none":
+		fmt.Fprintln(os.Stderr, errorStyle.Render("--config none leaves aimodels init nowhere to write"))
+		return errUsage
+	case "":
+		var err error
+		if path, err = config.DefaultPath(); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+	w := &wizard{in: bufio.NewReader(os.Stdin), yes: *yes}
+
+	fmt.Println(headerStyle.Render("aimodels init - Set up aimodels and the examples"))
+	fmt.Println(infoStyle.Render("Press Enter to take the suggestion in brackets. Settings go to " + path))
+	previous := settings
+	if _, err := os.Stat(path); err == nil && !*force {
+		if w.yes {
+			return fmt.Errorf("%s already exists; add --force to overwrite it", path)
+		}
+		fmt.Println()
+		if answer := w.ask("Settings exist already. Overwrite them? (y/n)", "n"); !strings.HasPrefix(strings.ToLower(answer), "y") {
+			fmt.Println(infoStyle.Render("Left " + path + " as it was."))
+			return nil
+		}
+	}
+
+	// 1. The catalog, fetched to check the URL and for the later questions
+	step(1, "Catalog")
+	url := cmp.Or(*catalogURL, os.Getenv("CATWALK_URL"), previous.CatalogURL, defaultCatalogURL)
+	var providers []catwalk.Provider
+	for {
+		if *catalogURL == "" {
+			url = w.ask("Catwalk service URL", url)
+		}
+		_ = os.Setenv("CATWALK_URL", url)
+		var err error
+		if providers, err = fetchProviders(ctx); err == nil && len(providers) > 0 {
+			break
+		}
+		if err == nil {
+			err = errors.New("the catalog lists no providers")
+		}
+		if *catalogURL != "" || !w.retry(err) {
+			return err
+		}
+		fmt.Println(infoStyle.Render("  Start it with 'go run main.go' in the catwalk repository, or give another URL."))
+	}
+	fmt.Println(okStyle.Render(fmt.Sprintf("  %s Found %d providers.", render.Symbol("✓", "ok"), len(providers))))
+
+	// 2. The default provider, preferring one with a key in the environment
+	step(2, "Provider")
+	var suggested string
+	var withKeys []string
+	tbl := render.NewTable(
+		render.Column{Title: "#", Align: render.AlignRight},
+		render.Column{Title: "Provider", MinWidth: 10},
+		render.Column{Title: "API key", MinWidth: 12},
+		render.Column{Title: "Models", Align: render.AlignRight},
+	)
+	for i, p := range providers {
+		key := warnStyle.Render("not set")
+		switch env := p.APIKeyEnv(); {
+		case env != "" && os.Getenv(env) != "":
+			key = okStyle.Render(render.Symbol("✓ ", "ok ") + env)
+			withKeys = append(withKeys, string(p.ID))
+		case env != "":
+			key = infoStyle.Render(env + " not set")
+		case p.APIKey != "":
+			key = okStyle.Render(render.Symbol("✓ ", "ok ") + "in catalog")
+			withKeys = append(withKeys, string(p.ID))
+		}
+		tbl.AddRow(strconv.Itoa(i+1), string(p.ID), key, strconv.Itoa(len(p.Models)))
+	}
+	tbl.Print()
+	if len(withKeys) > 0 {
+		suggested = withKeys[0]
+		fmt.Println(infoStyle.Render(fmt.Sprintf("API keys found for %d of %d providers: %s", len(withKeys), len(providers), strings.Join(withKeys, ", "))))
+	} else {
+		suggested = string(providers[0].ID)
+		fmt.Println(warnStyle.Render("No API keys found in the environment; you can still pick a provider and set its key later."))
+	}
+	if previous.Provider != "" {
+		if _, err := catwalk.FindProvider(providers, previous.Provider); err == nil {
+			suggested = previous.Provider
+		}
+	}
+	var provider *catwalk.Provider
+	for {
+		answer := *providerID
+		if answer == "" {
+			answer = w.ask("Default provider (number or ID)", suggested)
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(providers) {
+			provider = &providers[n-1]
+			break
+		}
+		var err error
+		if provider, err = catwalk.FindProvider(providers, answer); err == nil {
+			break
+		}
+		if *providerID != "" || !w.retry(err) {
+			return err //nolint:wrapcheck
+		}
+	}
+	if _, err := provider.ResolveAPIKey(); err != nil {
+		var keyErr *catwalk.MissingAPIKeyError
+		if errors.As(err, &keyErr) && keyErr.EnvVar != "" {
+			fmt.Println(warnStyle.Render("  Set its key before using it: export " + keyErr.EnvVar + "=<key>"))
+		}
+	}
+
+	// 3. The default model of that provider
+	step(3, "Model")
+	if len(provider.Models) == 0 {
+		return fmt.Errorf("the catalog lists no models for %s", provider.ID)
+	}
+	models := listedModels(*provider)
+	tbl = render.NewTable(
+		render.Column{Title: "#", Align: render.AlignRight},
+		render.Column{Title: "Model", MinWidth: 14},
+		render.Column{Title: "Context", Align: render.AlignRight},
+		render.Column{Title: "$/1M in", Align: render.AlignRight},
+		render.Column{Title: "$/1M out", Align: render.AlignRight},
+		render.Column{Title: ""},
+	)
+	for i, m := range models {
+		note := ""
+		switch m.ID {
+		case provider.DefaultLargeModelID:
+			note = infoStyle.Render("default large")
+		case provider.DefaultSmallModelID:
+			note = infoStyle.Render("default small")
+		}
+		tbl.AddRow(strconv.Itoa(i+1), m.ID, formatTokens(m.ContextWindow),
+			fmt.Sprintf("%.2f", m.CostPer1MIn), fmt.Sprintf("%.2f", m.CostPer1MOut), note)
+	}
+	tbl.Print()
+	if more := len(provider.Models) - len(models); more > 0 {
+		fmt.Println(infoStyle.Render(fmt.Sprintf("...and %d more; type any model ID.", more)))
+	}
+	suggested = cmp.Or(provider.DefaultLargeModelID, models[0].ID)
+	if previous.Provider == string(provider.ID) && previous.Model != "" {
+		suggested = previous.Model
+	}
+	var model *catwalk.Model
+	for {
+		answer := *modelName
+		if answer == "" {
+			answer = w.ask("Default model (number or ID)", suggested)
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(models) {
+			model = &models[n-1]
+			break
+		}
+		var err error
+		if model, err = provider.FindModel(answer); err == nil {
+			break
+		}
+		if *modelName != "" || !w.retry(err) {
+			return err //nolint:wrapcheck
+		}
+	}
+
+	// 4. The output theme, previewed
+	step(4, "Theme")
+	for i, t := range config.Themes {
+		fmt.Printf("  %d. %-13s %s\n", i+1, t, themePreview(t))
+	}
+	chosen := config.Theme(*themeName)
+	for chosen == "" {
+		answer := w.ask("Theme (number or name)", string(cmp.Or(previous.Theme, config.ThemeAuto)))
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(config.Themes) {
+			chosen = config.Themes[n-1]
+		} else if config.Theme(answer).Valid() {
+			chosen = config.Theme(answer)
+		} else if !w.retry(fmt.Errorf("unknown theme %q", answer)) {
+			return fmt.Errorf("unknown theme %q", answer)
+		}
+	}
+
+	c := config.Config{Provider: string(provider.ID), Model: model.ID, Theme: chosen}
+	if url != defaultCatalogURL {
+		c.CatalogURL = url
+	}
+	if err := c.Save(path); err != nil {
+		return err //nolint:wrapcheck
+	}
+	applyTheme(chosen)
+
+	fmt.Println()
+	fmt.Println(okStyle.Render(render.Symbol("✓", "ok") + " Saved " + path))
+	fmt.Println(infoStyle.Render(fmt.Sprintf("  Default model %s/%s, %s theme", provider.ID, model.ID, chosen)))
+	fmt.Println()
+	fmt.Println(headerStyle.Render("Next steps"))
+	fmt.Printf("  %-46s %s\n", fmt.Sprintf("aimodels keys verify --provider %s", provider.ID), infoStyle.Render("Check the API key works"))
+	fmt.Printf("  %-46s %s\n", "aimodels cost --in 1000 --out 500", infoStyle.Render("Price a request on "+model.ID))
+	fmt.Printf("  %-46s %s\n", "go run ./examples/integration/chat-bot", infoStyle.Render("Chat with it; --provider is optional now"))
+	return nil
+}
+
+// listedModels returns the models the wizard lists: the provider's
+// defaults first, then the rest in catalog order, up to maxListedModels.
+func listedModels(p catwalk.Provider) []catwalk.Model {
+	var models []catwalk.Model
+	for _, id := range []string{p.DefaultLargeModelID, p.DefaultSmallModelID} {
+		if m, err := p.FindModel(id); err == nil && id != "" && (len(models) == 0 || models[0].ID != m.ID) {
+			models = append(models, *m)
+		}
+	}
+	for _, m := range p.Models {
+		if len(models) == maxListedModels {
+			break
+		}
+		if m.ID != p.DefaultLargeModelID && m.ID != p.DefaultSmallModelID {
+			models = append(models, m)
+		}
+	}
+	return models
+}
+
+// printInitHelp displays usage information for the init command
+func printInitHelp() {
+	fmt.Println("aimodels init - Set up the default provider, model, and theme")
+	fmt.Println()
+	fmt.Println("Asks four questions and writes the settings file: the catwalk service URL,")
+	fmt.Println("checked by fetching the catalog; the default provider, with the API keys")
+	fmt.Println("found in the environment shown and the first of them suggested; one of its")
+	fmt.Println("models; and the output theme, previewed. aimodels cost and analyze then")
+	fmt.Println("price the default model without --model, chat-bot starts without --provider,")
+	fmt.Println("and every aimodels command uses the theme. Flags and CATWALK_URL still win.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels [--config <file>] init [options]")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --catalog-url <url>   Catwalk service URL (default: CATWALK_URL or http://localhost:8080)")
+	fmt.Println("  --provider <id>       Default provider, instead of asking")
+	fmt.Println("  --model <id>          Default model, instead of asking")
+	fmt.Println("  --theme <name>        auto (dark or light, from the terminal's background),")
+	fmt.Println("                        dark, light, high-contrast, or plain (no color)")
+	fmt.Println("  --yes                 Take the suggested answers without asking")
+	fmt.Println("  --force               Overwrite an existing settings file without asking")
+	fmt.Println()
+	fmt.Println("The settings file is config.yaml in the aimodels config directory (e.g.")
+	fmt.Println("~/.config/aimodels/config.yaml), or the global --config file. AIMODELS_THEME")
+	fmt.Println("overrides the theme. A theme.yaml beside it recolors any part of the theme,")
+	fmt.Println("with hex or ANSI colors for header, accent, highlight, secondary, ok, warn,")
+	fmt.Println("error, text, muted, border, and inverse, and an optional base theme.")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels init")
+	fmt.Println("  aimodels init --provider groq --theme light --yes --force")
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"charm.land/catwalk/pkg/auth"
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/render"
+)
+
+// keyStatus is the outcome of verifying a single provider key.
+type keyStatus string
+
+const (
+	keyValid   keyStatus = "valid"
+	keyInvalid keyStatus = "invalid"
+	keyMissing keyStatus = "missing"
+	keyError   keyStatus = "error"
+	keySkipped keyStatus = "skipped"
+)
+
+// keyResult is the verification result for one provider.
+type keyResult struct {
+	provider catwalk.Provider
+	envVar   string
+	key      string
+	status   keyStatus
+	detail   string
+	required bool
+}
+
+// Default endpoints for providers whose catalog endpoint is an unset
+// environment variable.
+var defaultEndpoints = map[catwalk.Type]string{
+	catwalk.TypeOpenAI:    "https://api.openai.com/v1",
+	catwalk.TypeAnthropic: "https://api.anthropic.com",
+	catwalk.TypeGoogle:    "https://generativelanguage.googleapis.com",
+}
+
+func runKeys(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "verify" {
+		printKeysHelp()
+		return errUsage
+	}
+
+	fs := flag.NewFlagSet("keys verify", flag.ExitOnError)
+	providerID := fs.String("provider", "", "Only verify this provider")
+	require := fs.String("require", "", "Comma-separated provider IDs whose keys must be present and valid")
+	timeout := fs.Duration("timeout", 10*time.Second, "Timeout for each verification request")
+	fs.Usage = printKeysHelp
+	_ = fs.Parse(args[1:])
+
+	required := map[string]bool{}
+	for _, id := range strings.Split(*require, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			required[strings.ToLower(id)] = true
+		}
+	}
+	if *providerID != "" {
+		required[strings.ToLower(*providerID)] = true
+	}
+
+	providers, err := fetchProviders(ctx)
+	if err != nil {
+		return err
+	}
+	if *providerID != "" {
+		if _, err := catwalk.FindProvider(providers, *providerID); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+	client, err := network.Client()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	var results []keyResult
+	for _, p := range providers {
+		if *providerID != "" && !strings.EqualFold(string(p.ID), *providerID) {
+			continue
+		}
+		reqCtx, cancel := context.WithTimeout(ctx, *timeout)
+		r := verifyKey(reqCtx, client, p)
+		cancel()
+		r.required = required[strings.ToLower(string(p.ID))]
+		results = append(results, r)
+	}
+
+	printKeyResults(results)
+
+	failed := 0
+	for _, r := range results {
+		if keyFailed(r) {
+			failed++
+		}
+	}
+	if failed == 1 && len(results) == 1 && results[0].status == keyMissing {
+		return &catwalk.MissingAPIKeyError{Provider: results[0].provider.ID, EnvVar: results[0].envVar}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d required key(s) failed verification", failed)
+	}
+	return nil
+}
+
+// keyFailed reports whether a result should make the command fail. Keys that
+// are set but rejected always fail; missing keys only fail when required.
+func keyFailed(r keyResult) bool {
+	switch r.status {
+	case keyInvalid:
+		return true
+	case keyMissing, keyError:
+		return r.required
+	default:
+		return false
+	}
+}
+
+// verifyKey makes a minimal authenticated request (listing models) to check
+// that the provider accepts the configured key.
+func verifyKey(ctx context.Context, client *http.Client, p catwalk.Provider) keyResult {
+	r := keyResult{provider: p, envVar: envVarName(p.APIKey)}
+
+	switch p.Type {
+	case catwalk.TypeAzure, catwalk.TypeBedrock, catwalk.TypeVertexAI:
+		r.status, r.detail = keySkipped, "uses cloud credentials"
+		return r
+	}
+	if r.envVar == "" {
+		r.status, r.detail = keySkipped, "no API key variable in catalog"
+		return r
+	}
+
+	r.key = os.Getenv(r.envVar)
+	if r.key == "" {
+		r.status, r.detail = keyMissing, "not set"
+		return r
+	}
+
+	endpoint := resolveEndpoint(p)
+	if endpoint == "" {
+		r.status, r.detail = keyError, "no API endpoint configured"
+		return r
+	}
+
+	req, err := newVerifyRequest(ctx, p, endpoint, r.key)
+	if err != nil {
+		r.status, r.detail = keyError, err.Error()
+		return r
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		r.status = keyError
+		if errors.Is(err, context.DeadlineExceeded) {
+			r.detail = "request timed out"
+		} else {
+			r.detail = err.Error()
+		}
+		return r
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		r.status = keyValid
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		r.status, r.detail = keyInvalid, resp.Status
+	default:
+		r.status, r.detail = keyError, "unexpected response: "+resp.Status
+	}
+	return r
+}
+
+// newVerifyRequest builds the cheapest authenticated request for the
+// provider's API flavor.
+func newVerifyRequest(ctx context.Context, p catwalk.Provider, endpoint, key string) (*http.Request, error) {
+	var url string
+	switch p.Type {
+	case catwalk.TypeAnthropic:
+		url = endpoint + "/v1/models"
+	case catwalk.TypeGoogle:
+		url = endpoint + "/v1beta/models"
+	default:
+		url = endpoint + "/models"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+
+	for k, v := range p.DefaultHeaders {
+		req.Header.Set(k, v)
+	}
+	if err := auth.For(p).Apply(req, key); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return req, nil
+}
+
+// envVarName returns the environment variable referenced by a catalog value
+// such as "$OPENAI_API_KEY", or "" if the value is not a reference.
+func envVarName(value string) string {
+	if name, ok := strings.CutPrefix(value, "$"); ok {
+		return name
+	}
+	return ""
+}
+
+// resolveEndpoint expands the provider endpoint, falling back to the
+// well-known default for its type when the referenced variable is unset.
+func resolveEndpoint(p catwalk.Provider) string {
+	endpoint := p.APIEndpoint
+	if name := envVarName(endpoint); name != "" {
+		endpoint = os.Getenv(name)
+	}
+	if endpoint == "" {
+		endpoint = defaultEndpoints[p.Type]
+	}
+	return strings.TrimSuffix(endpoint, "/")
+}
+
+// maskKey hides all but the first and last four characters of a key.
+func maskKey(key string) string {
+	if key == "" {
+		return "-"
+	}
+	if len(key) <= 8 {
+		return strings.Repeat("*", len(key))
+	}
+	return key[:4] + "..." + key[len(key)-4:]
+}
+
+// printKeyResults displays verification results in a table
+func printKeyResults(results []keyResult) {
+	fmt.Println()
+	fmt.Println(headerStyle.Render("API Key Verification"))
+	fmt.Println(borderStyle.Render(render.Rule(80)))
+
+	counts := map[keyStatus]int{}
+	for _, r := range results {
+		counts[r.status]++
+
+		status := fmt.Sprintf("%-8s", r.status)
+		switch r.status {
+		case keyValid:
+			status = okStyle.Render(status)
+		case keyInvalid, keyError:
+			status = errorStyle.Render(status)
+		case keyMissing:
+			if r.required {
+				status = errorStyle.Render(status)
+			} else {
+				status = warnStyle.Render(status)
+			}
+		default:
+			status = infoStyle.Render(status)
+		}
+
+		envVar := r.envVar
+		if envVar == "" {
+			envVar = "-"
+		}
+
+		fmt.Printf("%s %s %-24s %-14s %s\n",
+			nameStyle.Render(fmt.Sprintf("%-14s", r.provider.ID)),
+			status,
+			envVar,
+			maskKey(r.key),
+			infoStyle.Render(r.detail))
+	}
+
+	fmt.Println(borderStyle.Render(render.Rule(80)))
+	fmt.Printf("%d valid, %d invalid, %d missing, %d errors, %d skipped\n",
+		counts[keyValid], counts[keyInvalid], counts[keyMissing], counts[keyError], counts[keySkipped])
+}
+
+// printKeysHelp displays usage information for the keys command
+func printKeysHelp() {
+	fmt.Println("aimodels keys verify - Check provider API keys")
+	fmt.Println()
+	fmt.Println("Makes a minimal authenticated call (listing models) to each provider whose")
+	fmt.Println("API key variable is set and reports valid, invalid, and missing keys.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels keys verify [options]")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --provider <id>     Only verify this provider (its key is required)")
+	fmt.Println("  --require <ids>     Comma-separated provider IDs whose keys must be valid")
+	fmt.Println("  --timeout <dur>     Timeout for each request (default: 10s)")
+	fmt.Println()
+	fmt.Println("Exit status is non-zero if any set key is rejected, or if a required key")
+	fmt.Println("is missing or cannot be verified.")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels keys verify")
+	fmt.Println("  aimodels keys verify --provider openai")
+	fmt.Println("  aimodels keys verify --require openai,anthropic")
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"charm.land/catwalk/pkg/transcript"
+)
+
+// untagged groups the entries logged without a tag.
+const untagged = "(untagged)"
+
+// spendGroup totals the transcript entries that share a day, model, or tag.
+type spendGroup struct {
+	name         string
+	cost         float64
+	inputTokens  int
+	outputTokens int
+	daily        []float64          // cost per day of the window, oldest first
+	entries      []transcript.Entry // newest first
+
+	// good and bad count the answers rated with chat-bot's /good and /bad,
+	// or 4-5 and 1-2 on the rating scale.
+	good, bad int
+}
+
+// ratings summarizes a group's rated answers, as in "4 good, 1 bad", or
+// returns "" if none are rated.
+func (s spendGroup) ratings() string {
+	if s.good+s.bad == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d good, %d bad", s.good, s.bad)
+}
+
+// ratingOf returns "good" or "bad" for a rated entry, or "" for one that is
+// unrated or rated in between.
+func ratingOf(e transcript.Entry) string {
+	switch {
+	case e.Rating >= 4:
+		return "good"
+	case e.Rating > 0 && e.Rating <= 2:
+		return "bad"
+	}
+	return ""
+}
+
+// ledger summarizes transcript entries over a window of days ending today.
+type ledger struct {
+	days     int
+	start    time.Time // the window's first day, see dayOf
+	daily    []float64 // total cost per day, oldest first
+	total    float64
+	requests int
+
+	// today and week are the spend of the current day and calendar week
+	// (from Monday), whatever the window.
+	today, week float64
+
+	byDay, byModel, byTag, bySession []spendGroup
+}
+
+// dayOf returns the local calendar day of t as midnight UTC, so days can be
+// counted without daylight saving time getting in the way.
+func dayOf(t time.Time) time.Time {
+	y, m, d := t.Local().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// newLedger groups the entries made in the days up to and including now's.
+func newLedger(entries []transcript.Entry, days int, now time.Time) ledger {
+	today := dayOf(now)
+	weekStart := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	l := ledger{days: days, start: today.AddDate(0, 0, 1-days), daily: make([]float64, days)}
+
+	entries = slices.Clone(entries)
+	slices.SortStableFunc(entries, func(a, b transcript.Entry) int { return b.Time.Compare(a.Time) })
+
+	byDay := grouper{days: days}
+	byModel := grouper{days: days}
+	byTag := grouper{days: days}
+	bySession := grouper{days: days}
+	for _, e := range entries {
+		day := dayOf(e.Time)
+		if day.Equal(today) {
+			l.today += e.Cost
+		}
+		if !day.Before(weekStart) && !day.After(today) {
+			l.week += e.Cost
+		}
+		i := int(day.Sub(l.start).Hours() / 24)
+		if i < 0 || i >= days {
+			continue
+		}
+
+		l.daily[i] += e.Cost
+		l.total += e.Cost
+		l.requests++
+		byDay.add(day.Format("2006-01-02 Mon"), i, e)
+		byModel.add(e.Provider+"/"+e.UsedModel(), i, e)
+		if len(e.Tags) == 0 {
+			byTag.add(untagged, i, e)
+		}
+		for _, t := range e.Tags {
+			byTag.add(t, i, e)
+		}
+		if e.Session != "" {
+			bySession.add(e.Session, i, e)
+		}
+	}
+
+	// Days and sessions stay newest first; models and tags go by spend
+	l.byDay = byDay.groups
+	l.bySession = bySession.groups
+	l.byModel = byModel.sorted()
+	l.byTag = byTag.sorted()
+	return l
+}
+
+// grouper collects entries into spend groups by name, in order of first
+// appearance.
+type grouper struct {
+	days   int
+	index  map[string]int
+	groups []spendGroup
+}
+
+func (g *grouper) add(name string, day int, e transcript.Entry) {
+	if g.index == nil {
+		g.index = map[string]int{}
+	}
+	i, ok := g.index[name]
+	if !ok {
+		i = len(g.groups)
+		g.index[name] = i
+		g.groups = append(g.groups, spendGroup{name: name, daily: make([]float64, g.days)})
+	}
+	s := &g.groups[i]
+	s.cost += e.Cost
+	s.inputTokens += e.Usage.InputTokens
+	s.outputTokens += e.Usage.OutputTokens
+	s.daily[day] += e.Cost
+	s.entries = append(s.entries, e)
+	switch ratingOf(e) {
+	case "good":
+		s.good++
+	case "bad":
+		s.bad++
+	}
+}
+
+func (g *grouper) sorted() []spendGroup {
+	slices.SortStableFunc(g.groups, func(a, b spendGroup) int { return cmp.Compare(b.cost, a.cost) })
+	return g.groups
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/render"
+)
+
+// errLintFailed is returned when lint-catalog finds errors, or warnings
+// with --strict.
+var errLintFailed = errors.New("catalog check failed")
+
+func runLintCatalog(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("lint-catalog", flag.ExitOnError)
+	providerList := fs.String("provider", "", "Comma-separated provider IDs to check (default: all)")
+	ignore := fs.String("ignore", "", "Comma-separated checks to skip")
+	strict := fs.Bool("strict", false, "Fail on warnings as well as errors")
+	format := fs.String("format", "table", "Output format: table or json")
+	fs.Usage = printLintCatalogHelp
+	_ = fs.Parse(args)
+
+	switch *format {
+	case "table", "json":
+	default:
+		return fmt.Errorf("unknown format: %s (use table or json)", *format)
+	}
+	skip := splitList(*ignore)
+	for _, c := range skip {
+		if !slices.Contains(catwalk.Checks, c) {
+			return fmt.Errorf("unknown check %q (checks: %s)", c, strings.Join(catwalk.Checks, ", "))
+		}
+	}
+
+	providers, err := fetchProviders(ctx)
+	if err != nil {
+		return err
+	}
+	// Unlike selectProviders, this keeps providers without models, which
+	// are themselves an issue
+	if ids := splitList(*providerList); len(ids) > 0 {
+		providers = slices.DeleteFunc(providers, func(p catwalk.Provider) bool {
+			return !containsFold(ids, string(p.ID))
+		})
+		if len(providers) == 0 {
+			return fmt.Errorf("no providers matched the selection")
+		}
+	}
+
+	issues := slices.DeleteFunc(catwalk.Lint(providers), func(i catwalk.Issue) bool {
+		return slices.Contains(skip, i.Check)
+	})
+	var errs, warnings int
+	for _, i := range issues {
+		if i.Severity == catwalk.SeverityError {
+			errs++
+		} else {
+			warnings++
+		}
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if issues == nil {
+			issues = []catwalk.Issue{}
+		}
+		if err := enc.Encode(issues); err != nil {
+			return fmt.Errorf("failed to write JSON: %w", err)
+		}
+	} else {
+		printLintIssues(providers, issues, errs, warnings)
+	}
+
+	if errs > 0 || (*strict && warnings > 0) {
+		return fmt.Errorf("%w: %s, %s", errLintFailed, count(errs, "error"), count(warnings, "warning"))
+	}
+	return nil
+}
+
+// printLintIssues displays the issues as a table, followed by a count.
+func printLintIssues(providers []catwalk.Provider, issues []catwalk.Issue, errs, warnings int) {
+	models := 0
+	for _, p := range providers {
+		models += len(p.Models)
+	}
+	fmt.Println()
+	fmt.Println(headerStyle.Render("Catalog Check"))
+	summary := fmt.Sprintf("%s, %s: %s, %s", count(len(providers), "provider"), count(models, "model"),
+		count(errs, "error"), count(warnings, "warning"))
+	if len(issues) == 0 {
+		fmt.Println(okStyle.Render(render.Symbol("✓", "ok") + " " + summary))
+		return
+	}
+
+	tbl := render.NewTable(
+		render.Column{Title: "Severity"},
+		render.Column{Title: "Provider", Style: nameStyle},
+		render.Column{Title: "Model", MinWidth: 12},
+		render.Column{Title: "Check", MinWidth: 12},
+		render.Column{Title: "Problem", MinWidth: 20},
+	)
+	for _, i := range issues {
+		tbl.AddRow(string(i.Severity), string(i.Provider), i.Model, i.Check, i.Message)
+	}
+	tbl.Print()
+
+	style := warnStyle
+	if errs > 0 {
+		style = errorStyle
+	}
+	fmt.Println(style.Render(summary))
+}
+
+// count formats n of a noun, e.g. "1 error" or "2 errors".
+func count(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// printLintCatalogHelp displays usage information for lint-catalog
+func printLintCatalogHelp() {
+	fmt.Println("aimodels lint-catalog - Check the catalog for missing or implausible data")
+	fmt.Println()
+	fmt.Println("Reports anomalies in the provider data catwalk serves, to catch upstream")
+	fmt.Println("data issues before clients trip over them.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels lint-catalog [options]")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --provider <ids>  Comma-separated provider IDs to check (default: all)")
+	fmt.Println("  --ignore <checks> Comma-separated checks to skip")
+	fmt.Println("  --strict          Fail on warnings as well as errors")
+	fmt.Println("  --format <fmt>    table (default) or json")
+	fmt.Println()
+	fmt.Println("Checks:")
+	fmt.Println("  no-models        error    the provider lists no models")
+	fmt.Println("  default-model    error    a default model is unset or not among the models")
+	fmt.Println("  duplicate-model  error    a model ID is listed more than once")
+	fmt.Println("  context-window   error    a model's context window is 0")
+	fmt.Println("  negative-price   error    a price is below zero")
+	fmt.Println("  max-tokens       warning  default max tokens exceed the context window or")
+	fmt.Println("                            the model's output limit")
+	fmt.Println("  zero-pricing     warning  a model has no prices though the provider's others")
+	fmt.Println("                            do (models with \"free\" in their ID or name are fine)")
+	fmt.Println()
+	fmt.Println("Exits with status 1 if any errors are found, or warnings with --strict.")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels lint-catalog")
+	fmt.Println("  aimodels lint-catalog --provider openrouter --ignore zero-pricing")
+	fmt.Println("  aimodels --catalog-version latest lint-catalog --strict --format json")
+}
@@ -0,0 +1,248 @@
+// Package main provides aimodels, a command-line companion to the catwalk
+// service for working with providers, models, and API keys.
+//
+// Usage:
+//
+//	aimodels [global options] <command> [subcommand] [options]
+//
+// Commands:
+//
+//	init               Set up the default provider, model, and output theme interactively
+//	keys verify        Check provider API keys with a minimal authenticated call
+//	export-config      Convert catalog data into crush/aider/continue/litellm config
+//	snapshots          List stored catalog snapshots
+//	matrix             Show capability counts per provider, optionally as Markdown
+//	search             Find models by name, ID, family, or provider, best match first
+//	stats              Chart median prices per provider, context windows, and capability coverage
+//	compare-providers  Line up two providers' models by class with price, context, and capability deltas
+//	mirror             Load the catalog into an SQLite database
+//	sql                Query the SQLite mirror
+//	prompts            List, show, and add system prompt presets
+//	usage import       Recompute spend from OpenAI/Anthropic/OpenRouter usage exports
+//	usage report       Render a month's transcript spend as a Markdown or PDF report
+//	convert            Convert ChatGPT or Claude exports into JSONL transcripts
+//	dataset build      Turn transcripts into OpenAI or Anthropic fine-tuning JSONL
+//	dashboard          Browse transcript spend by day, model, and tag
+//	lint-catalog       Report catalog anomalies such as missing defaults or zero prices
+//	gen-docs           Render the catalog as a Markdown or HTML model reference
+//	cost               Price a request at catalog rates, optionally as a bare number
+//	cost repl          Price typed expressions such as gpt-4o: 1.5k in, 600 out
+//	cost from-log      Reprice the token usage of transcripts on other models
+//	analyze            Show the tokens and cost of each section of a prompt file
+//	alternatives       Suggest cheaper models with the same capabilities and context
+//	completion         Print a bash, zsh, fish, or PowerShell completion script
+//
+// Exit Status:
+//
+//	0 success, 1 error, 2 invalid usage, 3 provider not found,
+//	4 model not found, 5 missing API key, 6 over budget
+//
+// Environment Variables:
+//
+//	CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)
+//	HTTPS_PROXY - Proxy for outgoing requests unless --proxy is set
+//	AIMODELS_THEME - Output theme or theme file, instead of the settings file's
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/config"
+	"charm.land/catwalk/pkg/registry"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/snapshot"
+	"charm.land/catwalk/pkg/theme"
+	"charm.land/catwalk/pkg/transport"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Styles for formatting, in dark theme colors until applyTheme recolors
+// them for the chosen theme
+var (
+	headerStyle = lipgloss.NewStyle().Bold(true).Foreground(theme.Dark.Header)
+	nameStyle   = lipgloss.NewStyle().Foreground(theme.Dark.Accent)
+	infoStyle   = lipgloss.NewStyle().Foreground(theme.Dark.Muted)
+	okStyle     = lipgloss.NewStyle().Foreground(theme.Dark.OK)
+	warnStyle   = lipgloss.NewStyle().Foreground(theme.Dark.Warn)
+	errorStyle  = lipgloss.NewStyle().Foreground(theme.Dark.Error)
+	borderStyle = lipgloss.NewStyle().Foreground(theme.Dark.Border)
+)
+
+// Global flags
+var (
+	catalogVersion = flag.String("catalog-version", "", "Use a stored catalog snapshot (ETag, YYYY-MM-DD, or latest) instead of live data")
+	overridesFile  = flag.String("overrides", "", "Pricing and limit overrides file, or none (default: overrides.yaml in the aimodels config directory, if present)")
+	configFile     = flag.String("config", "", "Settings file written by aimodels init, or none (default: config.yaml in the aimodels config directory, if present)")
+	network        = transport.RegisterFlags(flag.CommandLine)
+)
+
+// settings are the defaults chosen with aimodels init.
+var settings = &config.Config{}
+
+// command is a top-level aimodels command.
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+
+	// hidden commands are left out of the help, like the one completion
+	// scripts call.
+	hidden bool
+}
+
+var commands = []command{
+	{name: "init", summary: "Set up the default provider, model, and output theme", run: runInit},
+	{name: "keys", summary: "Verify provider API keys (keys verify)", run: runKeys},
+	{name: "export-config", summary: "Export providers/models as crush, aider, continue, or litellm config", run: runExportConfig},
+	{name: "snapshots", summary: "List stored catalog snapshots", run: runSnapshots},
+	{name: "matrix", summary: "Show providers × capabilities with model counts", run: runMatrix},
+	{name: "search", summary: "Search model IDs, names, families, and providers, ranked", run: runSearch},
+	{name: "stats", summary: "Chart catalog-wide prices, context windows, and capabilities, or export CSV", run: runStats},
+	{name: "compare-providers", summary: "Diff two providers' model lineups by class, or as JSON", run: runCompareProviders},
+	{name: "mirror", summary: "Load the catalog into an SQLite database (needs sqlite3)", run: runMirror},
+	{name: "sql", summary: "Run SQL against the SQLite mirror", run: runSQL},
+	{name: "prompts", summary: "Manage system prompt presets (prompts list|show|add)", run: runPrompts},
+	{name: "usage", summary: "Recompute spend from usage exports, or report a month (usage import|report)", run: runUsage},
+	{name: "convert", summary: "Convert ChatGPT or Claude exports to JSONL transcripts", run: runConvert},
+	{name: "dataset", summary: "Build fine-tuning JSONL from transcripts (dataset build)", run: runDataset},
+	{name: "dashboard", summary: "Browse spend by day, model, and tag from chat transcripts", run: runDashboard},
+	{name: "lint-catalog", summary: "Check the catalog for missing or implausible data", run: runLintCatalog},
+	{name: "gen-docs", summary: "Generate a Markdown or HTML model reference, one page per provider", run: runGenDocs},
+	{name: "cost", summary: "Price a request's tokens at catalog rates (cost repl to explore)", run: runCost},
+	{name: "analyze", summary: "Show which sections of a prompt file cost the most", run: runAnalyze},
+	{name: "alternatives", summary: "Suggest cheaper substitutes for a model, ranked by similarity", run: runAlternatives},
+	{name: "completion", summary: "Print a shell completion script (bash, zsh, fish, powershell)", run: runCompletion},
+	{name: "__complete", run: runComplete, hidden: true},
+}
+
+// errUsage signals that a command was invoked incorrectly and its usage has
+// already been printed.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	render.SetupConsole()
+	flag.Usage = printHelp
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		printHelp()
+		os.Exit(2)
+	}
+
+	name := flag.Arg(0)
+	if s, err := config.Open(*configFile); err == nil {
+		settings = s
+		applyTheme(s.Theme)
+		s.ApplyCatalogURL()
+	} else if name != "init" {
+		// init replaces a broken file
+		fmt.Fprintln(os.Stderr, errorStyle.Render("Error: "+err.Error()))
+		fmt.Fprintln(os.Stderr, infoStyle.Render("Fix it, or run: aimodels init --force"))
+		os.Exit(1)
+	}
+
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		if err := c.run(context.Background(), flag.Args()[1:]); err != nil {
+			if errors.Is(err, errUsage) {
+				os.Exit(2)
+			}
+			fmt.Fprintln(os.Stderr, errorStyle.Render("Error: "+err.Error()))
+			if hint := errorHint(err); hint != "" {
+				fmt.Fprintln(os.Stderr, infoStyle.Render(hint))
+			}
+			os.Exit(catwalk.ExitCode(err))
+		}
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, errorStyle.Render("Unknown command: "+name))
+	printHelp()
+	os.Exit(2)
+}
+
+// errorHint suggests how to recover from a catalog error, or returns "".
+func errorHint(err error) string {
+	var keyErr *catwalk.MissingAPIKeyError
+	switch {
+	case errors.As(err, &keyErr) && keyErr.EnvVar != "":
+		return "Set it with: export " + keyErr.EnvVar + "=<key>"
+	case errors.Is(err, catwalk.ErrProviderNotFound):
+		return "List providers with: aimodels matrix"
+	case errors.Is(err, catwalk.ErrModelNotFound):
+		return "Search models with: aimodels search <words>"
+	}
+	return ""
+}
+
+// fetchProviders retrieves the provider catalog from the catwalk service, or
+// from a stored snapshot when --catalog-version is set, with overrides
+// applied.
+func fetchProviders(ctx context.Context) ([]catwalk.Provider, error) {
+	httpClient, err := network.Client()
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	providers, err := snapshot.Fetch(ctx, catwalk.NewWithHTTPClient(httpClient), *catalogVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch providers: %w", err)
+	}
+	if *overridesFile == "none" {
+		return providers, nil
+	}
+	overrides, err := registry.Open(*overridesFile)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	for _, id := range overrides.Unmatched(providers) {
+		fmt.Fprintln(os.Stderr, warnStyle.Render(fmt.Sprintf("Warning: %s: %s is not in the catalog", overrides.Path, id)))
+	}
+	return overrides.Apply(providers), nil
+}
+
+// printHelp displays usage information
+func printHelp() {
+	fmt.Println("aimodels - Work with catwalk providers, models, and API keys")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels [global options] <command> [subcommand] [options]")
+	fmt.Println()
+	fmt.Println("Global Options:")
+	fmt.Println("  --catalog-version <v>   Use a stored snapshot (ETag, YYYY-MM-DD, or latest)")
+	fmt.Println("  --overrides <file>      Negotiated prices, limits, and disabled models to merge")
+	fmt.Println("                          over the catalog, or none for list data (default:")
+	fmt.Println("                          overrides.yaml in the aimodels config directory)")
+	fmt.Println("  --config <file>         Settings from aimodels init, or none (default: config.yaml")
+	fmt.Println("                          in the aimodels config directory)")
+	fmt.Println("  --proxy <url>           Proxy URL (default: HTTPS_PROXY/HTTP_PROXY from the environment)")
+	fmt.Println("  --ca-cert <pem>         PEM file with additional CA certificates to trust")
+	fmt.Println("  --insecure-skip-verify  Skip TLS certificate verification (unsafe)")
+	fmt.Println()
+	fmt.Println("Commands:")
+	for _, c := range commands {
+		if c.hidden {
+			continue
+		}
+		fmt.Printf("  %-17s %s\n", c.name, c.summary)
+	}
+	fmt.Println()
+	fmt.Println("Run 'aimodels <command> --help' for command options, and 'aimodels init' to set up.")
+	fmt.Println()
+	fmt.Println("Exit Status:")
+	fmt.Println("  0 success, 1 error, 2 invalid usage, 3 provider not found,")
+	fmt.Println("  4 model not found, 5 missing API key, 6 over budget")
+	fmt.Println()
+	fmt.Println("Environment Variables:")
+	fmt.Println("  CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)")
+	fmt.Println("                or embedded for the catalog compiled in with -tags embedcatalog")
+	fmt.Println("  AIMODELS_THEME - Output theme (auto, dark, light, high-contrast, plain) or theme")
+	fmt.Println("                   file, instead of the settings file's")
+	fmt.Println("  HTTPS_PROXY - Proxy for outgoing requests unless --proxy is set")
+}
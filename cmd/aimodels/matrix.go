@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/render"
+)
+
+// capability is a model feature recorded in the catalog.
+type capability struct {
+	name string
+	has  func(catwalk.Model) bool
+}
+
+var capabilities = []capability{
+	{"Reasoning", func(m catwalk.Model) bool { return m.CanReason }},
+	{"Vision", func(m catwalk.Model) bool { return m.SupportsImages }},
+	{"Caching", func(m catwalk.Model) bool { return m.CostPer1MInCached > 0 || m.CostPer1MOutCached > 0 }},
+	{"Image pricing", func(m catwalk.Model) bool { return len(m.ImagePricing) > 0 }},
+	{"Audio", func(m catwalk.Model) bool { return m.AudioPricing != nil }},
+}
+
+// matrixRow counts a provider's models with each capability.
+type matrixRow struct {
+	label  string
+	models int
+	counts []int
+}
+
+func runMatrix(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("matrix", flag.ExitOnError)
+	providerList := fs.String("provider", "", "Comma-separated provider IDs to include (default: all)")
+	markdown := fs.Bool("markdown", false, "Print a Markdown table for docs")
+	fs.Usage = printMatrixHelp
+	_ = fs.Parse(args)
+
+	providers, err := fetchProviders(ctx)
+	if err != nil {
+		return err
+	}
+	providers = selectProviders(providers, splitList(*providerList), nil)
+	if len(providers) == 0 {
+		return fmt.Errorf("no providers matched the selection")
+	}
+
+	rows, total := capabilityMatrix(providers)
+	if *markdown {
+		writeMatrixMarkdown(os.Stdout, rows, total)
+		return nil
+	}
+	printMatrix(rows, total)
+	return nil
+}
+
+// capabilityMatrix counts capabilities per provider and across all of them.
+func capabilityMatrix(providers []catwalk.Provider) ([]matrixRow, matrixRow) {
+	total := matrixRow{label: "Total", counts: make([]int, len(capabilities))}
+	var rows []matrixRow
+	for _, p := range providers {
+		row := matrixRow{label: p.Name, models: len(p.Models), counts: make([]int, len(capabilities))}
+		for _, m := range p.Models {
+			for i, c := range capabilities {
+				if c.has(m) {
+					row.counts[i]++
+				}
+			}
+		}
+		for i, n := range row.counts {
+			total.counts[i] += n
+		}
+		total.models += row.models
+		rows = append(rows, row)
+	}
+	return rows, total
+}
+
+// cells formats a row's counts, leaving capabilities no model has blank so
+// the gaps stand out.
+func (r matrixRow) cells(empty string) []string {
+	cells := []string{r.label, fmt.Sprint(r.models)}
+	for _, n := range r.counts {
+		if n == 0 {
+			cells = append(cells, empty)
+		} else {
+			cells = append(cells, fmt.Sprint(n))
+		}
+	}
+	return cells
+}
+
+// printMatrix displays the matrix as a table
+func printMatrix(rows []matrixRow, total matrixRow) {
+	columns := []render.Column{
+		{Title: "Provider", MinWidth: 12, Style: nameStyle},
+		{Title: "Models", Align: render.AlignRight},
+	}
+	for _, c := range capabilities {
+		columns = append(columns, render.Column{Title: c.name, Align: render.AlignRight})
+	}
+
+	tbl := render.NewTable(columns...)
+	for _, r := range rows {
+		tbl.AddRow(r.cells("-")...)
+	}
+	tbl.AddSeparator()
+	tbl.AddRow(total.cells("-")...)
+
+	fmt.Println()
+	fmt.Println(headerStyle.Render("Capability Matrix"))
+	tbl.Print()
+	fmt.Println(infoStyle.Render("Counts are models per provider with each capability."))
+}
+
+// writeMatrixMarkdown writes the matrix as a GitHub-flavored Markdown table.
+func writeMatrixMarkdown(w io.Writer, rows []matrixRow, total matrixRow) {
+	header := []string{"Provider", "Models"}
+	align := []string{"---", "--:"}
+	for _, c := range capabilities {
+		header = append(header, c.name)
+		align = append(align, "--:")
+	}
+	writeMarkdownRow(w, header)
+	writeMarkdownRow(w, align)
+	for _, r := range rows {
+		writeMarkdownRow(w, r.cells(""))
+	}
+	totals := total.cells("")
+	totals[0] = "**" + totals[0] + "**"
+	writeMarkdownRow(w, totals)
+}
+
+func writeMarkdownRow(w io.Writer, cells []string) {
+	for i, c := range cells {
+		cells[i] = strings.ReplaceAll(c, "|", `\|`)
+	}
+	fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+}
+
+// printMatrixHelp displays usage information for the matrix command
+func printMatrixHelp() {
+	fmt.Println("aimodels matrix - Show which capabilities each provider's models have")
+	fmt.Println()
+	fmt.Println("Prints providers × capabilities with the number of models supporting")
+	fmt.Println("each: reasoning, vision (attachments), prompt caching, per-image")
+	fmt.Println("pricing, and audio.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels matrix [options]")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --provider <ids>  Comma-separated provider IDs to include (default: all)")
+	fmt.Println("  --markdown        Print a Markdown table for docs")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels matrix")
+	fmt.Println("  aimodels matrix --provider openai,anthropic")
+	fmt.Println("  aimodels matrix --markdown > docs/capabilities.md")
+}
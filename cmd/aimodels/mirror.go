@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// The mirror is written and queried with the sqlite3 command-line shell, so
+// aimodels needs no database driver.
+const defaultSQLite = "sqlite3"
+
+// mirrorSchema creates the mirror tables, replacing any earlier mirror.
+// Models are keyed by (provider_id, id) but not uniquely, since a provider
+// may list the same model ID more than once.
+const mirrorSchema = `DROP TABLE IF EXISTS image_pricing;
+DROP TABLE IF EXISTS pricing;
+DROP TABLE IF EXISTS models;
+DROP TABLE IF EXISTS providers;
+CREATE TABLE providers (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  type TEXT NOT NULL,
+  api_endpoint TEXT,
+  api_key_env TEXT,
+  default_large_model_id TEXT,
+  default_small_model_id TEXT
+);
+CREATE TABLE models (
+  provider_id TEXT NOT NULL REFERENCES providers(id),
+  id TEXT NOT NULL,
+  name TEXT NOT NULL,
+  context_window INTEGER NOT NULL,
+  default_max_tokens INTEGER NOT NULL,
+  can_reason INTEGER NOT NULL,
+  reasoning_levels TEXT,
+  default_reasoning_effort TEXT,
+  supports_images INTEGER NOT NULL
+);
+CREATE TABLE pricing (
+  provider_id TEXT NOT NULL,
+  model_id TEXT NOT NULL,
+  cost_per_1m_in REAL NOT NULL,
+  cost_per_1m_out REAL NOT NULL,
+  cost_per_1m_in_cached REAL NOT NULL,
+  cost_per_1m_out_cached REAL NOT NULL,
+  audio_cost_per_minute_in REAL,
+  audio_cost_per_minute_out REAL,
+  audio_cost_per_1m_in REAL,
+  audio_cost_per_1m_out REAL
+);
+CREATE TABLE image_pricing (
+  provider_id TEXT NOT NULL,
+  model_id TEXT NOT NULL,
+  tier TEXT NOT NULL,
+  max_pixels INTEGER,
+  cost REAL NOT NULL
+);
+CREATE INDEX models_id ON models (provider_id, id);
+CREATE INDEX pricing_model ON pricing (provider_id, model_id);
+CREATE INDEX image_pricing_model ON image_pricing (provider_id, model_id);
+`
+
+func runMirror(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("mirror", flag.ExitOnError)
+	db := fs.String("db", "models.db", "SQLite database file to write")
+	dump := fs.Bool("dump", false, "Print the SQL script instead of running it")
+	sqlite := fs.String("sqlite", defaultSQLite, "Path to the sqlite3 command-line shell")
+	fs.Usage = printMirrorHelp
+	_ = fs.Parse(args)
+
+	providers, err := fetchProviders(ctx)
+	if err != nil {
+		return err
+	}
+
+	var script bytes.Buffer
+	writeMirrorSQL(&script, providers)
+	if *dump {
+		_, err := script.WriteTo(os.Stdout)
+		return err //nolint:wrapcheck
+	}
+
+	if err := runSQLite(ctx, *sqlite, []string{"-bail", *db}, &script, io.Discard); err != nil {
+		return fmt.Errorf("failed to write mirror: %w", err)
+	}
+
+	models := 0
+	for _, p := range providers {
+		models += len(p.Models)
+	}
+	fmt.Printf("%s %d providers and %d models to %s\n",
+		okStyle.Render("Mirrored"), len(providers), models, nameStyle.Render(*db))
+	fmt.Println(infoStyle.Render("Query it with: aimodels sql --db " + *db + " \"SELECT ...\""))
+	return nil
+}
+
+// writeMirrorSQL writes a script that (re)creates the mirror from the
+// catalog in a single transaction.
+func writeMirrorSQL(w io.Writer, providers []catwalk.Provider) {
+	fmt.Fprintln(w, "BEGIN;")
+	fmt.Fprint(w, mirrorSchema)
+	for _, p := range providers {
+		insert(w, "providers", string(p.ID), p.Name, string(p.Type), p.APIEndpoint,
+			envVarName(p.APIKey), p.DefaultLargeModelID, p.DefaultSmallModelID)
+
+		for _, m := range p.Models {
+			insert(w, "models", string(p.ID), m.ID, m.Name, m.ContextWindow, m.DefaultMaxTokens,
+				m.CanReason, strings.Join(m.ReasoningLevels, ","), m.DefaultReasoningEffort, m.SupportsImages)
+
+			audio := []any{nil, nil, nil, nil}
+			if a := m.AudioPricing; a != nil {
+				audio = []any{a.CostPerMinuteIn, a.CostPerMinuteOut, a.CostPer1MIn, a.CostPer1MOut}
+			}
+			insert(w, "pricing", append([]any{string(p.ID), m.ID,
+				m.CostPer1MIn, m.CostPer1MOut, m.CostPer1MInCached, m.CostPer1MOutCached}, audio...)...)
+
+			for _, tier := range m.ImagePricing {
+				insert(w, "image_pricing", string(p.ID), m.ID, tier.Name, tier.MaxPixels, tier.Cost)
+			}
+		}
+	}
+	fmt.Fprintln(w, "COMMIT;")
+}
+
+// insert writes an INSERT statement for a row of values.
+func insert(w io.Writer, table string, values ...any) {
+	literals := make([]string, len(values))
+	for i, v := range values {
+		literals[i] = sqlLiteral(v)
+	}
+	fmt.Fprintf(w, "INSERT INTO %s VALUES (%s);\n", table, strings.Join(literals, ", "))
+}
+
+// sqlLiteral formats v as an SQLite literal. Empty strings become NULL so
+// missing catalog fields can be tested with IS NULL.
+func sqlLiteral(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		if v == "" {
+			return "NULL"
+		}
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		panic(fmt.Sprintf("unsupported SQL value %T", v))
+	}
+}
+
+func runSQL(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("sql", flag.ExitOnError)
+	db := fs.String("db", "models.db", "SQLite database file written by 'aimodels mirror'")
+	format := fs.String("format", "table", "Output format: table, csv, or json")
+	sqlite := fs.String("sqlite", defaultSQLite, "Path to the sqlite3 command-line shell")
+	fs.Usage = printSQLHelp
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		printSQLHelp()
+		return errUsage
+	}
+
+	var mode []string
+	switch strings.ToLower(*format) {
+	case "table":
+		mode = []string{"-header", "-column"}
+	case "csv":
+		mode = []string{"-header", "-csv"}
+	case "json":
+		mode = []string{"-json"}
+	default:
+		return fmt.Errorf("unknown format: %s (use table, csv, or json)", *format)
+	}
+
+	if _, err := os.Stat(*db); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%s does not exist; run 'aimodels mirror --db %s' first", *db, *db)
+		}
+		return err //nolint:wrapcheck
+	}
+
+	args = append(append([]string{"-readonly", "-bail"}, mode...), *db, fs.Arg(0))
+	return runSQLite(ctx, *sqlite, args, nil, os.Stdout)
+}
+
+// runSQLite runs the sqlite3 shell with args, returning the first line of its
+// error output if it fails.
+func runSQLite(ctx context.Context, sqlite string, args []string, stdin io.Reader, stdout io.Writer) error {
+	path, err := exec.LookPath(sqlite)
+	if err != nil {
+		return fmt.Errorf("%s not found; install the SQLite command-line shell or set --sqlite", sqlite)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			msg, _, _ = strings.Cut(msg, "\n")
+			return errors.New(strings.TrimPrefix(msg, "Error: "))
+		}
+		return fmt.Errorf("sqlite3 failed: %w", err)
+	}
+	return nil
+}
+
+// printMirrorHelp displays usage information for the mirror command
+func printMirrorHelp() {
+	fmt.Println("aimodels mirror - Load the catalog into an SQLite database")
+	fmt.Println()
+	fmt.Println("Writes the tables providers, models, pricing, and image_pricing, replacing")
+	fmt.Println("any earlier mirror in the same file. Requires the sqlite3 command-line shell.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels mirror [options]")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --db <file>      Database file to write (default: models.db)")
+	fmt.Println("  --dump           Print the SQL script instead of running it")
+	fmt.Println("  --sqlite <path>  sqlite3 shell to use (default: sqlite3 from PATH)")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels mirror --db models.db")
+	fmt.Println("  aimodels --catalog-version 2025-06-01 mirror --db june.db")
+	fmt.Println("  aimodels mirror --dump > catalog.sql")
+}
+
+// printSQLHelp displays usage information for the sql command
+func printSQLHelp() {
+	fmt.Println("aimodels sql - Query a catalog mirror with SQL")
+	fmt.Println()
+	fmt.Println("Runs a read-only query against a database written by 'aimodels mirror'.")
+	fmt.Println("Tables:")
+	fmt.Println("  providers      id, name, type, api_endpoint, api_key_env,")
+	fmt.Println("                 default_large_model_id, default_small_model_id")
+	fmt.Println("  models         provider_id, id, name, context_window, default_max_tokens,")
+	fmt.Println("                 can_reason, reasoning_levels, default_reasoning_effort,")
+	fmt.Println("                 supports_images")
+	fmt.Println("  pricing        provider_id, model_id, cost_per_1m_in, cost_per_1m_out,")
+	fmt.Println("                 cost_per_1m_in_cached, cost_per_1m_out_cached,")
+	fmt.Println("                 audio_cost_per_minute_{in,out}, audio_cost_per_1m_{in,out}")
+	fmt.Println("  image_pricing  provider_id, model_id, tier, max_pixels, cost")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels sql [options] \"SELECT ...\"")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --db <file>      Database file (default: models.db)")
+	fmt.Println("  --format <fmt>   table (default), csv, or json")
+	fmt.Println("  --sqlite <path>  sqlite3 shell to use (default: sqlite3 from PATH)")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels sql \"SELECT provider_id, COUNT(*) FROM models GROUP BY 1 ORDER BY 2 DESC\"")
+	fmt.Println("  aimodels sql --format csv \"SELECT m.provider_id, m.id, p.cost_per_1m_in")
+	fmt.Println("    FROM models m JOIN pricing p ON p.provider_id = m.provider_id AND p.model_id = m.id")
+	fmt.Println("    WHERE m.context_window >= 200000 ORDER BY p.cost_per_1m_in\"")
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+func TestSQLLiteral(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{nil, "NULL"},
+		{"", "NULL"},
+		{"gpt-4o", "'gpt-4o'"},
+		{"O'Brien's model", "'O''Brien''s model'"},
+		{"'; DROP TABLE models; --", "'''; DROP TABLE models; --'"},
+		{"line\nbreak", "'line\nbreak'"},
+		{true, "1"},
+		{false, "0"},
+		{int64(128_000), "128000"},
+		{int64(-1), "-1"},
+		{2.5, "2.5"},
+		{0.0, "0"},
+		{0.075, "0.075"},
+		{1e-7, "1e-07"},
+		{1e21, "1e+21"},
+	}
+	for _, tt := range tests {
+		if got := sqlLiteral(tt.value); got != tt.want {
+			t.Errorf("sqlLiteral(%#v) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+// mirrorProviders has a quote, a comma inside a value, missing fields, and
+// both audio and image pricing.
+var mirrorProviders = []catwalk.Provider{
+	{
+		ID: "openai", Name: "OpenAI", Type: catwalk.TypeOpenAI, APIKey: "$OPENAI_API_KEY",
+		APIEndpoint: "https://api.openai.com/v1", DefaultLargeModelID: "gpt-4o",
+		Models: []catwalk.Model{
+			{
+				ID: "gpt-4o", Name: "GPT-4o", CostPer1MIn: 2.5, CostPer1MOut: 10, CostPer1MInCached: 1.25,
+				ContextWindow: 128_000, DefaultMaxTokens: 16_384, SupportsImages: true,
+				ImagePricing: []catwalk.ImageTier{{Name: "low", MaxPixels: 512 * 512, Cost: 0.000_2}},
+			},
+			{
+				ID: "o3", Name: "o3 (it's thinking)", CostPer1MIn: 2, CostPer1MOut: 8, ContextWindow: 200_000,
+				CanReason: true, ReasoningLevels: []string{"low", "medium", "high"}, DefaultReasoningEffort: "medium",
+				AudioPricing: &catwalk.AudioPricing{CostPerMinuteIn: 0.006},
+			},
+		},
+	},
+	{ID: "local", Name: "Local", Type: catwalk.TypeOpenAICompat, APIKey: "literal-key"},
+}
+
+func TestWriteMirrorSQL(t *testing.T) {
+	var script bytes.Buffer
+	writeMirrorSQL(&script, mirrorProviders)
+	sql := script.String()
+
+	if !strings.HasPrefix(sql, "BEGIN;\n"+mirrorSchema) || !strings.HasSuffix(sql, "COMMIT;\n") {
+		t.Fatalf("script is not the schema and inserts in one transaction:\n%s", sql)
+	}
+
+	// Each INSERT has as many values as its table has columns
+	columns := map[string]int{}
+	for _, m := range regexp.MustCompile(`(?s)CREATE TABLE (\w+) \((.*?)\n\);`).FindAllStringSubmatch(mirrorSchema, -1) {
+		columns[m[1]] = strings.Count(m[2], "\n")
+	}
+	if len(columns) != 4 {
+		t.Fatalf("schema has tables %v, want 4", columns)
+	}
+	rows := map[string]int{}
+	for _, m := range regexp.MustCompile(`(?m)^INSERT INTO (\w+) VALUES \((.*)\);$`).FindAllStringSubmatch(sql, -1) {
+		rows[m[1]]++
+		if n := len(splitValues(m[2])); n != columns[m[1]] {
+			t.Errorf("INSERT INTO %s has %d values, want %d: %s", m[1], n, columns[m[1]], m[0])
+		}
+	}
+	if want := map[string]int{"providers": 2, "models": 2, "pricing": 2, "image_pricing": 1}; !maps.Equal(rows, want) {
+		t.Errorf("inserted rows %v, want %v", rows, want)
+	}
+
+	for _, want := range []string{
+		"INSERT INTO providers VALUES ('openai', 'OpenAI', 'openai', 'https://api.openai.com/v1', 'OPENAI_API_KEY', 'gpt-4o', NULL);",
+		// A literal key is not mirrored
+		"INSERT INTO providers VALUES ('local', 'Local', 'openai-compat', NULL, NULL, NULL, NULL);",
+		"INSERT INTO models VALUES ('openai', 'o3', 'o3 (it''s thinking)', 200000, 0, 1, 'low,medium,high', 'medium', 0);",
+		"INSERT INTO pricing VALUES ('openai', 'gpt-4o', 2.5, 10, 1.25, 0, NULL, NULL, NULL, NULL);",
+		"INSERT INTO pricing VALUES ('openai', 'o3', 2, 8, 0, 0, 0.006, 0, 0, 0);",
+		"INSERT INTO image_pricing VALUES ('openai', 'gpt-4o', 'low', 262144, 0.0002);",
+	} {
+		if !strings.Contains(sql, want+"\n") {
+			t.Errorf("script is missing %s", want)
+		}
+	}
+}
+
+// TestMirrorSQLite runs the script through sqlite3, when it is installed,
+// and reads values back.
+func TestMirrorSQLite(t *testing.T) {
+	if _, err := exec.LookPath(defaultSQLite); err != nil {
+		t.Skip("sqlite3 is not installed")
+	}
+	var script bytes.Buffer
+	writeMirrorSQL(&script, mirrorProviders)
+	db := filepath.Join(t.TempDir(), "models.db")
+	ctx := context.Background()
+	if err := runSQLite(ctx, defaultSQLite, []string{"-bail", db}, &script, &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	query := strings.NewReader(`SELECT name, reasoning_levels FROM models WHERE id = 'o3';
+SELECT count(*) FROM providers WHERE api_endpoint IS NULL;
+SELECT cost_per_1m_in_cached, audio_cost_per_minute_in IS NULL FROM pricing WHERE model_id = 'gpt-4o';`)
+	if err := runSQLite(ctx, defaultSQLite, []string{db}, query, &out); err != nil {
+		t.Fatal(err)
+	}
+	if want := "o3 (it's thinking)|low,medium,high\n1\n1.25|1\n"; out.String() != want {
+		t.Errorf("queried %q, want %q", out.String(), want)
+	}
+}
+
+// splitValues splits the values of an INSERT at the commas outside quotes.
+func splitValues(s string) []string {
+	var values []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\'':
+			quoted = !quoted
+		case s[i] == ',' && !quoted:
+			values = append(values, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(values, strings.TrimSpace(s[start:]))
+}
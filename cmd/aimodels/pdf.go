@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// A minimal PDF 1.4 writer for reports: A4 pages of text in the standard
+// Type 1 fonts, which every reader has, and filled bars. Text is encoded as
+// WinAnsi, so characters outside it print as "?".
+const (
+	pageWidth, pageHeight = 595.0, 842.0
+	pageMargin            = 50.0
+
+	// Tables and charts are set in 9pt Courier, whose characters are 0.6em
+	// wide, so a line of 495pt holds monoColumns of them.
+	monoSize    = 9.0
+	monoColumns = 91
+
+	// Prose is set in 10pt Helvetica, wrapped at proseColumns characters,
+	// which fits the page at its average character width.
+	proseSize    = 10.0
+	proseColumns = 88
+)
+
+// Font resources, in the order of the font objects.
+var pdfFonts = []string{"Helvetica", "Helvetica-Bold", "Courier", "Courier-Bold"}
+
+const (
+	fontProse = "/F1"
+	fontBold  = "/F2"
+	fontMono  = "/F3"
+	fontMonoB = "/F4"
+)
+
+// pdfLayout places text and shapes on pages from the top down.
+type pdfLayout struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func (l *pdfLayout) page() *bytes.Buffer {
+	return l.pages[len(l.pages)-1]
+}
+
+// room starts a new page unless height fits above the bottom margin.
+func (l *pdfLayout) room(height float64) {
+	if len(l.pages) == 0 || l.y-height < pageMargin {
+		l.pages = append(l.pages, &bytes.Buffer{})
+		l.y = pageHeight - pageMargin
+	}
+}
+
+// text sets one line of text at x in font and size, and moves down a line.
+func (l *pdfLayout) text(x float64, font string, size float64, s string) {
+	l.room(size * 1.4)
+	l.y -= size * 1.4
+	fmt.Fprintf(l.page(), "BT %s %g Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, l.y+size*0.3, pdfString(s))
+}
+
+// prose sets text wrapped to the page, indented by indent.
+func (l *pdfLayout) prose(indent float64, font, s string) {
+	for _, line := range wrap(s, proseColumns-int(indent/5)) {
+		l.text(pageMargin+indent, font, proseSize, line)
+	}
+}
+
+// rule draws a horizontal line under the last line.
+func (l *pdfLayout) rule() {
+	fmt.Fprintf(l.page(), "0.5 w 0.6 G %.2f %.2f m %.2f %.2f l S 0 G\n", pageMargin, l.y, pageWidth-pageMargin, l.y)
+}
+
+// gap moves down by height, if it fits on the page.
+func (l *pdfLayout) gap(height float64) {
+	if l.y-height >= pageMargin {
+		l.y -= height
+	}
+}
+
+// wrap splits s into lines of at most width characters, between words.
+func wrap(s string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		if line != "" && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	return append(lines, line)
+}
+
+// winAnsi maps the characters above Latin-1 that WinAnsi encodes.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94,
+	'•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// pdfString encodes s as WinAnsi and escapes it for a PDF string literal.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch c, ok := winAnsi[r]; {
+		case ok:
+			b.WriteByte(c)
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f || r >= 0xa0 && r <= 0xff:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// writePDF writes d as a PDF: the title, headings in bold, paragraphs and
+// lists in Helvetica, and tables and the chart in Courier, with page
+// numbers in the footer.
+func (d doc) writePDF(w io.Writer) error {
+	l := &pdfLayout{}
+	l.text(pageMargin, fontBold, 18, d.title)
+	l.gap(6)
+	for _, block := range d.blocks {
+		switch {
+		case block.heading != "":
+			l.room(60) // keep a heading with what follows it
+			l.gap(10)
+			l.text(pageMargin, fontBold, 13, block.heading)
+			l.rule()
+			l.gap(4)
+		case block.table != nil:
+			lines := monoTable(*block.table)
+			for i, line := range lines {
+				font := fontMono
+				if i == 0 {
+					font = fontMonoB
+				}
+				l.text(pageMargin, font, monoSize, line)
+				if i == 0 {
+					l.rule()
+				}
+			}
+			l.gap(6)
+		case block.chart != nil:
+			l.chart(block.chart)
+			l.gap(6)
+		case block.items != nil:
+			for _, item := range block.items {
+				l.room(3 * proseSize * 1.4)
+				l.text(pageMargin, fontBold, proseSize, "• "+item.lead)
+				l.prose(12, fontProse, item.text)
+				l.gap(2)
+			}
+		default:
+			l.prose(0, fontProse, block.text)
+			l.gap(6)
+		}
+	}
+	return writePDFPages(w, d.title, l.pages)
+}
+
+// chart draws a row per bar: the label, a bar scaled to the largest, and
+// the value.
+func (l *pdfLayout) chart(bars []docBar) {
+	largest := 0.0
+	label := 0
+	for _, bar := range bars {
+		largest = max(largest, bar.value)
+		label = max(label, utf8.RuneCountInString(bar.label))
+	}
+	x := pageMargin + float64(label+1)*0.6*monoSize
+	width := pageWidth - pageMargin - x - 10*0.6*monoSize
+	for _, bar := range bars {
+		l.text(pageMargin, fontMono, monoSize, bar.label)
+		length := 0.0
+		if largest > 0 {
+			length = math.Max(bar.value/largest*width, 0)
+		}
+		if length > 0 {
+			fmt.Fprintf(l.page(), "0.25 0.45 0.8 rg %.2f %.2f %.2f %.2f re f 0 g\n", x, l.y+2, length, monoSize)
+		}
+		fmt.Fprintf(l.page(), "BT %s %g Tf %.2f %.2f Td (%s) Tj ET\n", fontMono, monoSize, x+length+4, l.y+monoSize*0.3,
+			pdfString(fmt.Sprintf("$%.2f", bar.value)))
+	}
+}
+
+// monoTable lays out t as lines of aligned columns that fit monoColumns,
+// truncating the first column if they do not.
+func monoTable(t docTable) []string {
+	rows := append([][]string{t.header}, t.rows...)
+	widths := make([]int, len(t.header))
+	for _, row := range rows {
+		for i, c := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(c))
+		}
+	}
+	total := 2 * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	if over := total - monoColumns; over > 0 {
+		widths[0] = max(widths[0]-over, 8)
+	}
+	lines := make([]string, len(rows))
+	for r, row := range rows {
+		cells := make([]string, len(row))
+		for i, c := range row {
+			c = truncate(c, widths[i])
+			pad := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(c))
+			if t.right[i] {
+				cells[i] = pad + c
+			} else {
+				cells[i] = c + pad
+			}
+		}
+		lines[r] = strings.TrimRight(strings.Join(cells, "  "), " ")
+	}
+	return lines
+}
+
+// writePDFPages writes the objects of a PDF with the given page contents:
+// the catalog, the page tree, the fonts, an info dictionary, and each
+// page with its content stream, followed by the cross-reference table.
+func writePDFPages(w io.Writer, title string, pages []*bytes.Buffer) error {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1-2 are the catalog and page tree, then the fonts and the
+	// info dictionary; each page is followed by its contents
+	first := 3 + len(pdfFonts) + 1
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", first+2*i)
+	}
+	fonts := make([]string, len(pdfFonts))
+	for i := range pdfFonts {
+		fonts[i] = fmt.Sprintf("/F%d %d 0 R", i+1, 3+i)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	for _, name := range pdfFonts {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+	}
+	object(fmt.Sprintf("<< /Title (%s) /Producer (aimodels) >>", pdfString(title)))
+	for i, content := range pages {
+		fmt.Fprintf(content, "BT %s 8 Tf %.2f %.2f Td (%s) Tj ET\n", fontProse, pageWidth-pageMargin-40, pageMargin/2,
+			pdfString(fmt.Sprintf("Page %d of %d", i+1, len(pages))))
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %g %g] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, strings.Join(fonts, " "), first+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, 3+len(pdfFonts), xref)
+	_, err := w.Write(out.Bytes())
+	return err //nolint:wrapcheck
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWritePDFPages(t *testing.T) {
+	pages := []*bytes.Buffer{
+		bytes.NewBufferString("BT /F1 10 Tf 50 700 Td (first) Tj ET\n"),
+		bytes.NewBufferString("BT /F1 10 Tf 50 700 Td (second \\(page\\)) Tj ET\n"),
+	}
+	var out bytes.Buffer
+	if err := writePDFPages(&out, "Report – June", pages); err != nil {
+		t.Fatal(err)
+	}
+	pdf := out.Bytes()
+	checkPDF(t, pdf)
+
+	// The catalog, page tree, four fonts, info, and two objects a page
+	if want := 2 + len(pdfFonts) + 1 + 2*len(pages); !bytes.Contains(pdf, []byte(fmt.Sprintf("/Size %d ", want+1))) {
+		t.Errorf("trailer does not count %d objects:\n%s", want+1, pdf)
+	}
+	if !bytes.Contains(pdf, []byte("/Kids [8 0 R 10 0 R] /Count 2")) {
+		t.Errorf("page tree does not list objects 8 and 10:\n%s", pdf)
+	}
+	if !bytes.Contains(pdf, []byte("/Title (Report \x96 June)")) {
+		t.Error("title is not WinAnsi encoded")
+	}
+	if !bytes.Contains(pdf, []byte("(Page 2 of 2)")) {
+		t.Error("pages are not numbered")
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	d := doc{title: "AI Usage Report: June 2025"}
+	d.heading("Daily Spend")
+	bars := make([]docBar, 90)
+	for i := range bars {
+		bars[i] = docBar{label: fmt.Sprintf("Jun %02d", i%30+1), value: float64(i)}
+	}
+	d.chart(bars)
+	d.list([]anomaly{{lead: "Failures", text: "3 of 10 requests failed (30%)"}})
+
+	var out bytes.Buffer
+	if err := d.writePDF(&out); err != nil {
+		t.Fatal(err)
+	}
+	checkPDF(t, out.Bytes())
+	if !bytes.Contains(out.Bytes(), []byte("/Count 2 ")) {
+		t.Error("a 90-bar chart does not run onto a second page")
+	}
+}
+
+// checkPDF checks that startxref points at the cross-reference table, that
+// each of its offsets points at its object, and that stream lengths are
+// right.
+func checkPDF(t *testing.T, pdf []byte) {
+	t.Helper()
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("not a PDF:\n%s", pdf)
+	}
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d points at %q, not the xref table", xref, pdf[xref:min(xref+20, len(pdf))])
+	}
+
+	lines := strings.Split(string(pdf[xref:]), "\n")
+	var count int
+	if _, err := fmt.Sscanf(lines[1], "0 %d", &count); err != nil {
+		t.Fatalf("xref subsection header %q: %v", lines[1], err)
+	}
+	if lines[2] != "0000000000 65535 f " {
+		t.Errorf("xref entry 0 = %q", lines[2])
+	}
+	for n := 1; n < count; n++ {
+		entry := lines[2+n]
+		// Entries are exactly 20 bytes with their end of line
+		if len(entry) != 19 || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Errorf("xref entry %d = %q", n, entry)
+			continue
+		}
+		offset, _ := strconv.Atoi(entry[:10])
+		if want := fmt.Sprintf("%d 0 obj\n", n); !bytes.HasPrefix(pdf[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q, want %q", n, pdf[offset:min(offset+20, len(pdf))], want)
+		}
+	}
+	if lines[2+count] != "trailer" {
+		t.Errorf("xref table has more than %d entries", count)
+	}
+
+	for _, m := range regexp.MustCompile(`(?s)<< /Length (\d+) >>\nstream\n(.*?)endstream`).FindAllSubmatch(pdf, -1) {
+		if n, _ := strconv.Atoi(string(m[1])); n != len(m[2]) {
+			t.Errorf("stream /Length %d, but it is %d bytes", n, len(m[2]))
+		}
+	}
+}
+
+func TestPDFString(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{`f(x) \ y`, `f\(x\) \\ y`},
+		{"café €5 — “ok”", "caf\xe9 \x805 \x97 \x93ok\x94"},
+		{"2× 通", "2\xd7 ?"},
+	}
+	for _, tt := range tests {
+		if got := pdfString(tt.in); got != tt.want {
+			t.Errorf("pdfString(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"charm.land/catwalk/pkg/prompts"
+	"charm.land/catwalk/pkg/render"
+)
+
+func runPrompts(_ context.Context, args []string) error {
+	if len(args) == 0 {
+		printPromptsHelp()
+		return errUsage
+	}
+
+	fs := flag.NewFlagSet("prompts "+args[0], flag.ExitOnError)
+	dir := fs.String("dir", "", "Prompts directory (default: ~/.config/aimodels/prompts)")
+	file := fs.String("file", "", "Read the prompt from this file instead of stdin (add)")
+	force := fs.Bool("force", false, "Replace an existing preset file (add)")
+	fs.Usage = printPromptsHelp
+	_ = fs.Parse(args[1:])
+
+	lib, err := openPromptLibrary(*dir)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		return listPrompts(lib)
+	case "show":
+		if fs.NArg() != 1 {
+			printPromptsHelp()
+			return errUsage
+		}
+		p, err := lib.Get(fs.Arg(0))
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		fmt.Println(p.Content)
+		return nil
+	case "add":
+		if fs.NArg() != 1 {
+			printPromptsHelp()
+			return errUsage
+		}
+		return addPrompt(lib, fs.Arg(0), *file, *force)
+	default:
+		printPromptsHelp()
+		return errUsage
+	}
+}
+
+func openPromptLibrary(dir string) (*prompts.Library, error) {
+	if dir != "" {
+		return prompts.Open(dir), nil
+	}
+	return prompts.OpenDefault() //nolint:wrapcheck
+}
+
+func listPrompts(lib *prompts.Library) error {
+	list, err := lib.List()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	fmt.Println()
+	fmt.Println(headerStyle.Render("Prompt Presets"))
+	fmt.Println(borderStyle.Render(render.Rule(80)))
+	for _, p := range list {
+		source := "built-in"
+		if !p.IsBuiltin() {
+			source = "file"
+		}
+		fmt.Printf("%s %s %s\n",
+			nameStyle.Render(fmt.Sprintf("%-14s", p.Name)),
+			infoStyle.Render(fmt.Sprintf("%-9s", source)),
+			truncate(p.Summary(), 55))
+	}
+	fmt.Println(borderStyle.Render(render.Rule(80)))
+	fmt.Printf("Directory: %s\n", lib.Dir())
+	return nil
+}
+
+func addPrompt(lib *prompts.Library, name, file string, force bool) error {
+	var content []byte
+	var err error
+	if file != "" {
+		content, err = os.ReadFile(file)
+	} else {
+		content, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read prompt: %w", err)
+	}
+
+	p, err := lib.Add(name, string(content), force)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	fmt.Printf("%s preset %s (%s)\n", okStyle.Render("Saved"), nameStyle.Render(p.Name), p.Path)
+	if _, ok := prompts.Builtin[p.Name]; ok {
+		fmt.Println(infoStyle.Render("It replaces the built-in preset of the same name."))
+	}
+	return nil
+}
+
+// printPromptsHelp displays usage information for the prompts command
+func printPromptsHelp() {
+	fmt.Println("aimodels prompts - Manage system prompt presets")
+	fmt.Println()
+	fmt.Println("Presets are Markdown files named <name>.md in the prompts directory; the")
+	fmt.Println("file content is the system prompt. coding, writing, sql, and reviewer are")
+	fmt.Println("built in, and a file with the same name replaces them. Use a preset with")
+	fmt.Println("chat-bot --preset <name> or /preset <name>.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels prompts list [--dir <dir>]")
+	fmt.Println("  aimodels prompts show [--dir <dir>] <name>")
+	fmt.Println("  aimodels prompts add [--dir <dir>] [--file <path>] [--force] <name>")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --dir <dir>     Prompts directory (default: ~/.config/aimodels/prompts)")
+	fmt.Println("  --file <path>   Read the prompt from a file instead of stdin (add)")
+	fmt.Println("  --force         Replace an existing preset file (add)")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels prompts list")
+	fmt.Println("  aimodels prompts add --file go-review.md go-reviewer")
+	fmt.Println("  echo 'Answer in one sentence.' | aimodels prompts add brief")
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/selector"
+)
+
+// searchResult is a search match in --format json.
+type searchResult struct {
+	Provider      string           `json:"provider"`
+	Model         string           `json:"model"`
+	Name          string           `json:"name"`
+	Score         int              `json:"score"`
+	Matched       []selector.Field `json:"matched"`
+	ContextWindow int64            `json:"context_window"`
+	CostPer1MIn   float64          `json:"cost_per_1m_in"`
+	CostPer1MOut  float64          `json:"cost_per_1m_out"`
+}
+
+func runSearch(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	providerList := fs.String("provider", "", "Comma-separated provider IDs to search (default: all)")
+	limit := fs.Int("limit", 20, "Number of results to list (0 for all)")
+	format := fs.String("format", "table", "Output format: table or json")
+	fs.Usage = printSearchHelp
+	_ = fs.Parse(args)
+
+	query := strings.Join(fs.Args(), " ")
+	if strings.TrimSpace(query) == "" {
+		printSearchHelp()
+		return errUsage
+	}
+	switch *format {
+	case "table", "json":
+	default:
+		return fmt.Errorf("unknown format: %s (use table or json)", *format)
+	}
+	if *limit < 0 {
+		fmt.Fprintln(os.Stderr, errorStyle.Render("--limit must not be negative"))
+		return errUsage
+	}
+
+	providers, err := fetchProviders(ctx)
+	if err != nil {
+		return err
+	}
+	if ids := splitList(*providerList); len(ids) > 0 {
+		providers = selectProviders(providers, ids, nil)
+		if len(providers) == 0 {
+			return fmt.Errorf("no providers matched the selection")
+		}
+	}
+
+	results := selector.Search(providers, query)
+	shown := results
+	if *limit > 0 && len(shown) > *limit {
+		shown = shown[:*limit]
+	}
+
+	if *format == "json" {
+		out := make([]searchResult, 0, len(shown))
+		for _, r := range shown {
+			out = append(out, searchResult{
+				Provider:      string(r.Provider.ID),
+				Model:         r.Model.ID,
+				Name:          r.Model.Name,
+				Score:         r.Score,
+				Matched:       r.Fields,
+				ContextWindow: r.Model.ContextWindow,
+				CostPer1MIn:   r.Model.CostPer1MIn,
+				CostPer1MOut:  r.Model.CostPer1MOut,
+			})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			return fmt.Errorf("failed to write JSON: %w", err)
+		}
+		return nil
+	}
+	printSearchResults(providers, query, results, shown)
+	return nil
+}
+
+// printSearchResults lists the matches shown, one per line, with the
+// provider serving each model
+func printSearchResults(providers []catwalk.Provider, query string, results, shown []selector.SearchResult) {
+	fmt.Println()
+	if len(results) == 0 {
+		fmt.Println(warnStyle.Render(fmt.Sprintf("No models match %q.", query)))
+		var ids []string
+		for _, p := range providers {
+			for _, m := range p.Models {
+				ids = append(ids, m.ID)
+			}
+		}
+		if similar := catwalk.Suggest(query, ids, 3); len(similar) > 0 {
+			fmt.Println(infoStyle.Render("Similar IDs: " + strings.Join(similar, ", ")))
+		}
+		return
+	}
+
+	tbl := render.NewTable(
+		render.Column{Title: "Provider", Style: nameStyle},
+		render.Column{Title: "Model", MinWidth: 24},
+		render.Column{Title: "Name", MinWidth: 12},
+		render.Column{Title: "Context", Align: render.AlignRight},
+		render.Column{Title: "$/1M in", Align: render.AlignRight},
+		render.Column{Title: "$/1M out", Align: render.AlignRight},
+		render.Column{Title: "Matched"},
+	)
+	for _, r := range shown {
+		matched := make([]string, len(r.Fields))
+		for i, f := range r.Fields {
+			matched[i] = string(f)
+		}
+		tbl.AddRow(string(r.Provider.ID), r.Model.ID, r.Model.Name, formatTokens(r.Model.ContextWindow),
+			fmt.Sprintf("$%.2f", r.Model.CostPer1MIn), fmt.Sprintf("$%.2f", r.Model.CostPer1MOut), strings.Join(matched, ", "))
+	}
+	tbl.Print()
+	summary := fmt.Sprintf("%s matching %q", count(len(results), "model"), query)
+	if len(shown) < len(results) {
+		summary += fmt.Sprintf("; %d more shown with --limit 0", len(results)-len(shown))
+	}
+	fmt.Println(infoStyle.Render(summary + "."))
+}
+
+// printSearchHelp displays usage information for search
+func printSearchHelp() {
+	fmt.Println("aimodels search - Find models by name, ID, family, or provider")
+	fmt.Println()
+	fmt.Println("Searches every provider's models for all the given words, ignoring case,")
+	fmt.Println("and lists the matches best first with the provider serving each. A word")
+	fmt.Println("counts most in a model's ID or name, then in its family (the first word")
+	fmt.Println("of the ID, such as llama) or organization (as in meta-llama/...), then in")
+	fmt.Println("the provider's ID or name; and more for a whole word than part of one.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels search [options] <words>...")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --provider <ids>  Comma-separated provider IDs to search (default: all)")
+	fmt.Println("  --limit <n>       Number of results to list, or 0 for all (default: 20)")
+	fmt.Println("  --format <fmt>    table (default), or json with each match's score and")
+	fmt.Println("                    the fields it matched")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels search llama")
+	fmt.Println("  aimodels search llama 70b")
+	fmt.Println("  aimodels search --provider openrouter,groq qwen coder")
+	fmt.Println("  aimodels search --format json --limit 0 claude | jq -r '.[] | .provider + \"/\" + .model'")
+}
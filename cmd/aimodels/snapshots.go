@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/snapshot"
+)
+
+func runSnapshots(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("snapshots", flag.ExitOnError)
+	fs.Usage = printSnapshotsHelp
+	_ = fs.Parse(args)
+
+	store, err := snapshot.OpenDefault()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	entries, err := store.List()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	if len(entries) == 0 {
+		fmt.Println("No snapshots stored yet. Any live catalog fetch records one.")
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Println(headerStyle.Render("Catalog Snapshots"))
+	fmt.Println(borderStyle.Render(render.Rule(80)))
+	for _, e := range entries {
+		fmt.Printf("%s  %s\n",
+			infoStyle.Render(e.Fetched.Local().Format(time.DateTime)),
+			nameStyle.Render(e.ETag))
+	}
+	fmt.Println(borderStyle.Render(render.Rule(80)))
+	fmt.Printf("Total: %d snapshots\n", len(entries))
+	return nil
+}
+
+// printSnapshotsHelp displays usage information for the snapshots command
+func printSnapshotsHelp() {
+	fmt.Println("aimodels snapshots - List stored catalog snapshots")
+	fmt.Println()
+	fmt.Println("Every live catalog fetch is stored as a snapshot under the user cache")
+	fmt.Println("directory. Pass a snapshot's ETag (or a unique prefix), a date, or")
+	fmt.Println("\"latest\" to --catalog-version to reproduce results with that data.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels snapshots")
+}
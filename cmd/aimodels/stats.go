@@ -0,0 +1,275 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/render"
+)
+
+// statsBarWidth is the width of the bars in aimodels stats.
+const statsBarWidth = 30
+
+// contextBucket is a range of context windows, up to and including max
+// tokens.
+type contextBucket struct {
+	label string
+	max   int64
+}
+
+var contextBuckets = []contextBucket{
+	{"up to 32K", 32 << 10},
+	{"32K-128K", 128 << 10},
+	{"128K-256K", 256 << 10},
+	{"256K-1M", 1 << 20},
+	{"over 1M", math.MaxInt64},
+}
+
+// bucketOf returns the index of the context bucket of a window, or -1 if
+// the catalog does not list it.
+func bucketOf(window int64) int {
+	if window <= 0 {
+		return -1
+	}
+	return slices.IndexFunc(contextBuckets, func(b contextBucket) bool { return window <= b.max })
+}
+
+// providerStats summarizes one provider's models, or the whole catalog's.
+type providerStats struct {
+	id, name string
+	models   int
+
+	// Prices are of the models with an input or output price; free and
+	// unpriced models would drag every median to zero.
+	in, out []float64
+
+	contexts []float64
+	buckets  []int
+	// capable counts the models with each of capabilities.
+	capable []int
+}
+
+func newProviderStats(id, name string) *providerStats {
+	return &providerStats{id: id, name: name, buckets: make([]int, len(contextBuckets)), capable: make([]int, len(capabilities))}
+}
+
+func (s *providerStats) add(m catwalk.Model) {
+	s.models++
+	if m.CostPer1MIn > 0 || m.CostPer1MOut > 0 {
+		s.in = append(s.in, m.CostPer1MIn)
+		s.out = append(s.out, m.CostPer1MOut)
+	}
+	if i := bucketOf(m.ContextWindow); i >= 0 {
+		s.buckets[i]++
+		s.contexts = append(s.contexts, float64(m.ContextWindow))
+	}
+	for i, c := range capabilities {
+		if c.has(m) {
+			s.capable[i]++
+		}
+	}
+}
+
+// coverage returns the percentage of models with capability i.
+func (s *providerStats) coverage(i int) float64 {
+	return share(s.capable[i], s.models)
+}
+
+// catalogStats summarizes each provider and, last, the whole catalog.
+func catalogStats(providers []catwalk.Provider) (rows []*providerStats, all *providerStats) {
+	all = newProviderStats("all", "All providers")
+	for _, p := range providers {
+		s := newProviderStats(string(p.ID), p.Name)
+		for _, m := range p.Models {
+			s.add(m)
+			all.add(m)
+		}
+		rows = append(rows, s)
+	}
+	return rows, all
+}
+
+func runStats(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	providerList := fs.String("provider", "", "Comma-separated provider IDs to include (default: all)")
+	format := fs.String("format", "table", "Output format: table or csv")
+	fs.Usage = printStatsHelp
+	_ = fs.Parse(args)
+	if fs.NArg() > 0 {
+		printStatsHelp()
+		return errUsage
+	}
+	switch *format {
+	case "table", "csv":
+	default:
+		return fmt.Errorf("unknown format: %s (use table or csv)", *format)
+	}
+
+	providers, err := fetchProviders(ctx)
+	if err != nil {
+		return err
+	}
+	providers = selectProviders(providers, splitList(*providerList), nil)
+	if len(providers) == 0 {
+		return fmt.Errorf("no providers matched the selection")
+	}
+
+	rows, all := catalogStats(providers)
+	if *format == "csv" {
+		return writeStatsCSV(os.Stdout, rows, all)
+	}
+	printStats(rows, all)
+	return nil
+}
+
+// printStats displays the statistics as tables with bar charts.
+func printStats(rows []*providerStats, all *providerStats) {
+	fmt.Println()
+	fmt.Println(headerStyle.Render("Catalog Statistics"))
+	fmt.Println(infoStyle.Render(fmt.Sprintf("%d providers, %d models (%d priced); median $%.2f/1M input, $%.2f/1M output, %s context",
+		len(rows), all.models, len(all.in), median(all.in), median(all.out), formatTokens(int64(median(all.contexts))))))
+
+	// Cheapest first, and providers without prices last
+	byPrice := slices.Clone(rows)
+	slices.SortStableFunc(byPrice, func(a, b *providerStats) int {
+		if (len(a.in) == 0) != (len(b.in) == 0) {
+			return cmp.Compare(len(b.in), len(a.in))
+		}
+		return cmp.Compare(median(a.in), median(b.in))
+	})
+	top := 0.0
+	for _, s := range rows {
+		top = max(top, median(s.in))
+	}
+	tbl := render.NewTable(
+		render.Column{Title: "Provider", MinWidth: 12, Style: nameStyle},
+		render.Column{Title: "Models", Align: render.AlignRight},
+		render.Column{Title: "Priced", Align: render.AlignRight},
+		render.Column{Title: "Median in", Align: render.AlignRight},
+		render.Column{Title: "Median out", Align: render.AlignRight},
+		render.Column{Title: "Input range", Align: render.AlignRight},
+		render.Column{Title: "Median input price", MinWidth: statsBarWidth},
+	)
+	for _, s := range byPrice {
+		if len(s.in) == 0 {
+			tbl.AddRow(s.name, strconv.Itoa(s.models), "0", "-", "-", "-", "")
+			continue
+		}
+		tbl.AddRow(s.name, strconv.Itoa(s.models), strconv.Itoa(len(s.in)),
+			fmt.Sprintf("$%.2f", median(s.in)), fmt.Sprintf("$%.2f", median(s.out)),
+			fmt.Sprintf("$%.2f-$%.2f", slices.Min(s.in), slices.Max(s.in)),
+			costStyle.Render(render.Bar(median(s.in)/math.Max(top, 1e-9), statsBarWidth)))
+	}
+	fmt.Println()
+	fmt.Println(headerStyle.Render("Median Price by Provider"))
+	tbl.Print()
+	fmt.Println(infoStyle.Render("Prices are USD per 1M tokens, over models with a price; free and unpriced models are left out."))
+
+	tbl = render.NewTable(
+		render.Column{Title: "Context window", MinWidth: 12},
+		render.Column{Title: "Models", Align: render.AlignRight},
+		render.Column{Title: "Share", Align: render.AlignRight},
+		render.Column{Title: "", MinWidth: statsBarWidth},
+	)
+	largest := slices.Max(all.buckets)
+	for i, b := range contextBuckets {
+		tbl.AddRow(b.label, strconv.Itoa(all.buckets[i]), fmt.Sprintf("%.1f%%", share(all.buckets[i], len(all.contexts))),
+			infoStyle.Render(render.Bar(float64(all.buckets[i])/float64(max(largest, 1)), statsBarWidth)))
+	}
+	fmt.Println()
+	fmt.Println(headerStyle.Render("Context Window Distribution"))
+	tbl.Print()
+	if unknown := all.models - len(all.contexts); unknown > 0 {
+		fmt.Println(infoStyle.Render(fmt.Sprintf("%d model%s without a context window are left out.", unknown, plural(unknown))))
+	}
+
+	tbl = render.NewTable(
+		render.Column{Title: "Capability", MinWidth: 12},
+		render.Column{Title: "Models", Align: render.AlignRight},
+		render.Column{Title: "Coverage", Align: render.AlignRight},
+		render.Column{Title: "", MinWidth: statsBarWidth},
+	)
+	for i, c := range capabilities {
+		tbl.AddRow(c.name, strconv.Itoa(all.capable[i]), fmt.Sprintf("%.1f%%", all.coverage(i)),
+			okStyle.Render(render.Bar(all.coverage(i)/100, statsBarWidth)))
+	}
+	fmt.Println()
+	fmt.Println(headerStyle.Render("Capability Coverage"))
+	tbl.Print()
+	fmt.Println(infoStyle.Render("Run 'aimodels matrix' for the counts per provider, or --format csv for every figure."))
+}
+
+// writeStatsCSV writes a row of statistics per provider, then one for the
+// whole catalog with provider_id "all".
+func writeStatsCSV(w io.Writer, rows []*providerStats, all *providerStats) error {
+	header := []string{"provider_id", "provider", "models", "priced_models",
+		"median_input_per_1m", "median_output_per_1m", "min_input_per_1m", "max_input_per_1m", "median_context_window"}
+	for _, b := range contextBuckets {
+		header = append(header, "context_"+strings.NewReplacer(" ", "_", "-", "_to_").Replace(strings.ToLower(b.label)))
+	}
+	for _, c := range capabilities {
+		header = append(header, strings.ReplaceAll(strings.ToLower(c.name), " ", "_")+"_pct")
+	}
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write(header)
+	for _, s := range append(rows, all) {
+		record := []string{s.id, s.name, strconv.Itoa(s.models), strconv.Itoa(len(s.in))}
+		if len(s.in) > 0 {
+			record = append(record, csvPrice(median(s.in)), csvPrice(median(s.out)), csvPrice(slices.Min(s.in)), csvPrice(slices.Max(s.in)))
+		} else {
+			record = append(record, "", "", "", "")
+		}
+		record = append(record, strconv.FormatFloat(median(s.contexts), 'f', -1, 64))
+		for _, n := range s.buckets {
+			record = append(record, strconv.Itoa(n))
+		}
+		for i := range capabilities {
+			record = append(record, strconv.FormatFloat(s.coverage(i), 'f', 1, 64))
+		}
+		_ = cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error() //nolint:wrapcheck
+}
+
+// csvPrice formats a price for CSV to the millionth of a dollar, which
+// drops the float noise of prices such as 0.7999999999999999.
+func csvPrice(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e6)/1e6, 'f', -1, 64)
+}
+
+// printStatsHelp displays usage information for the stats command
+func printStatsHelp() {
+	fmt.Println("aimodels stats - Summarize prices, context windows, and capabilities")
+	fmt.Println()
+	fmt.Println("Prints catalog-wide statistics for market overviews: each provider's")
+	fmt.Println("median input and output price per 1M tokens with a bar chart, how")
+	fmt.Println("context windows are distributed, and the share of models with each")
+	fmt.Println("capability. Medians are over models with a price, so free and unpriced")
+	fmt.Println("models do not pull them to zero.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels stats [options]")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --provider <ids>  Comma-separated provider IDs to include (default: all)")
+	fmt.Println("  --format <fmt>    table (default), or csv with a row per provider and one")
+	fmt.Println("                    for the whole catalog (provider_id all)")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels stats")
+	fmt.Println("  aimodels stats --provider openai,anthropic,gemini")
+	fmt.Println("  aimodels stats --format csv > market.csv")
+	fmt.Println("  aimodels --catalog-version 2025-01-01 stats --format csv > january.csv")
+}
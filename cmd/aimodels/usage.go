@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/usage"
+)
+
+// usageReport is the spend recomputed from one provider's export.
+type usageReport struct {
+	Provider string             `json:"provider"`
+	File     string             `json:"file"`
+	Models   []usageReportModel `json:"models"`
+	Reported float64            `json:"reported_cost"`
+	Computed float64            `json:"computed_cost"`
+}
+
+type usageReportModel struct {
+	Model            string   `json:"model"`
+	Matched          bool     `json:"matched"`
+	Requests         int64    `json:"requests"`
+	InputTokens      int64    `json:"input_tokens"`
+	CacheReadTokens  int64    `json:"cache_read_tokens"`
+	CacheWriteTokens int64    `json:"cache_write_tokens"`
+	OutputTokens     int64    `json:"output_tokens"`
+	Reported         *float64 `json:"reported_cost,omitempty"`
+	Computed         float64  `json:"computed_cost"`
+	Discrepant       bool     `json:"discrepant"`
+}
+
+func runUsage(ctx context.Context, args []string) error {
+	if len(args) > 0 && args[0] == "report" {
+		return runUsageReport(args[1:])
+	}
+	if len(args) == 0 || args[0] != "import" {
+		printUsageHelp()
+		return errUsage
+	}
+
+	fs := flag.NewFlagSet("usage import", flag.ExitOnError)
+	openaiCSV := fs.String("openai-csv", "", "OpenAI usage export (CSV)")
+	anthropicCSV := fs.String("anthropic-csv", "", "Anthropic usage export (CSV)")
+	openrouterCSV := fs.String("openrouter-csv", "", "OpenRouter activity export (CSV)")
+	tolerance := fs.Float64("tolerance", 0.02, "Relative cost difference reported as a discrepancy")
+	format := fs.String("format", "table", "Output format: table or json")
+	fs.Usage = printUsageHelp
+	_ = fs.Parse(args[1:])
+
+	inputs := []struct {
+		file   string
+		format usage.Format
+	}{
+		{*openaiCSV, usage.OpenAI},
+		{*anthropicCSV, usage.Anthropic},
+		{*openrouterCSV, usage.OpenRouter},
+	}
+
+	var files int
+	for _, in := range inputs {
+		if in.file != "" {
+			files++
+		}
+	}
+	if files == 0 {
+		printUsageHelp()
+		return errUsage
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("unknown format: %s", *format)
+	}
+
+	providers, err := fetchProviders(ctx)
+	if err != nil {
+		return err
+	}
+
+	var reports []usageReport
+	for _, in := range inputs {
+		if in.file == "" {
+			continue
+		}
+		report, err := importUsage(providers, in.file, in.format, *tolerance)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, report)
+	}
+
+	if *format == "json" {
+		return writeJSON(os.Stdout, reports)
+	}
+	printUsageReports(reports)
+	return nil
+}
+
+// importUsage parses a usage export and recomputes its spend per model.
+func importUsage(providers []catwalk.Provider, file string, format usage.Format, tolerance float64) (usageReport, error) {
+	provider, err := catwalk.FindProvider(providers, string(format.Provider))
+	if err != nil {
+		return usageReport{}, err //nolint:wrapcheck
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return usageReport{}, fmt.Errorf("failed to open %s export: %w", format.Name, err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	records, err := usage.ParseCSV(f, format)
+	if err != nil {
+		return usageReport{}, fmt.Errorf("%s: %w", file, err)
+	}
+
+	report := usageReport{Provider: string(provider.ID), File: file}
+	for _, s := range usage.Summarize(*provider, records) {
+		m := usageReportModel{
+			Model:            s.Model,
+			Matched:          s.Matched,
+			Requests:         s.Usage.Requests,
+			InputTokens:      s.Usage.InputTokens,
+			CacheReadTokens:  s.Usage.CacheReadTokens,
+			CacheWriteTokens: s.Usage.CacheWriteTokens,
+			OutputTokens:     s.Usage.OutputTokens,
+			Computed:         s.Computed,
+			Discrepant:       s.Discrepant(tolerance),
+		}
+		if s.Usage.HasCost {
+			cost := s.Usage.Cost
+			m.Reported = &cost
+			report.Reported += cost
+		}
+		report.Computed += s.Computed
+		report.Models = append(report.Models, m)
+	}
+	return report, nil
+}
+
+func printUsageReports(reports []usageReport) {
+	var reported, computed float64
+	var discrepancies, unmatched int
+
+	for _, r := range reports {
+		fmt.Println()
+		fmt.Println(headerStyle.Render(fmt.Sprintf("Usage: %s (%s)", r.Provider, r.File)))
+		fmt.Println(borderStyle.Render(render.Rule(96)))
+		fmt.Printf("%-36s %9s %12s %12s %12s %12s\n",
+			"Model", "Requests", "Input", "Output", "Reported", "Computed")
+		fmt.Println(borderStyle.Render(render.Rule(96)))
+
+		for _, m := range r.Models {
+			reportedCost := "-"
+			if m.Reported != nil {
+				reportedCost = fmt.Sprintf("$%.4f", *m.Reported)
+			}
+			computedCost := fmt.Sprintf("$%.4f", m.Computed)
+			note := ""
+			switch {
+			case !m.Matched:
+				unmatched++
+				computedCost = "-"
+				note = warnStyle.Render("not in catalog")
+			case m.Discrepant:
+				discrepancies++
+				note = errorStyle.Render(fmt.Sprintf("differs by $%.4f", *m.Reported-m.Computed))
+			}
+
+			fmt.Printf("%s %9d %12s %12s %12s %12s %s\n",
+				nameStyle.Render(fmt.Sprintf("%-36s", truncate(m.Model, 36))),
+				m.Requests,
+				formatTokens(m.InputTokens+m.CacheReadTokens+m.CacheWriteTokens),
+				formatTokens(m.OutputTokens),
+				reportedCost,
+				computedCost,
+				note)
+		}
+
+		fmt.Println(borderStyle.Render(render.Rule(96)))
+		fmt.Printf("%-36s %9s %12s %12s %12s %12s\n", "Total", "", "", "",
+			fmt.Sprintf("$%.4f", r.Reported), fmt.Sprintf("$%.4f", r.Computed))
+		reported += r.Reported
+		computed += r.Computed
+	}
+
+	fmt.Println()
+	if len(reports) > 1 {
+		fmt.Printf("All providers: reported $%.4f, computed $%.4f\n", reported, computed)
+	}
+	if discrepancies > 0 {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("%d model(s) billed differently from catalog pricing", discrepancies)))
+	}
+	if unmatched > 0 {
+		fmt.Println(warnStyle.Render(fmt.Sprintf("%d model(s) not found in the catalog; their cost is not recomputed", unmatched)))
+	}
+	if discrepancies == 0 && unmatched == 0 {
+		fmt.Println(okStyle.Render("Reported costs match catalog pricing"))
+	}
+}
+
+// formatTokens abbreviates a token count (e.g. 1.2M).
+func formatTokens(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fK", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if render.Plain() {
+		return s[:n-3] + "..."
+	}
+	return s[:n-1] + "…"
+}
+
+func printUsageHelp() {
+	fmt.Println("aimodels usage import - Recompute spend from provider usage exports")
+	fmt.Println()
+	fmt.Println("Parses billing/usage CSV exports, maps each row to a catalog model, and")
+	fmt.Println("recomputes its cost from catalog pricing. Prints aggregate spend per model")
+	fmt.Println("and flags models whose reported cost differs from the recomputed cost.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels usage import [options]")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --openai-csv <file>      OpenAI usage export")
+	fmt.Println("  --anthropic-csv <file>   Anthropic console usage export")
+	fmt.Println("  --openrouter-csv <file>  OpenRouter activity export")
+	fmt.Println("  --tolerance <frac>       Relative difference flagged as a discrepancy (default: 0.02)")
+	fmt.Println("  --format <fmt>           Output format: table or json (default: table)")
+	fmt.Println()
+	fmt.Println("Columns are detected from the CSV header. Dated model snapshots")
+	fmt.Println("(e.g. gpt-4o-2024-08-06) are grouped under their catalog model.")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels usage import --openai-csv usage.csv")
+	fmt.Println("  aimodels usage import --anthropic-csv claude.csv --openrouter-csv activity.csv")
+	fmt.Println("  aimodels --catalog-version 2025-06-01 usage import --openai-csv june.csv")
+	fmt.Println()
+	fmt.Println("See also: aimodels usage report, a monthly spend report from transcripts.")
+}
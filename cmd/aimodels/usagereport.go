@@ -0,0 +1,510 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/transcript"
+)
+
+// Thresholds for the anomalies a usage report points out
+const (
+	// spikeFactor is how many times the median day's spend makes a day
+	// stand out, once at least minSpikeDays days have spend.
+	spikeFactor  = 3.0
+	minSpikeDays = 4
+
+	// growthFactor is how many times its previous month's spend makes a
+	// model stand out, if the increase is at least minGrowthShare of the
+	// month's spend; a new model stands out at that share.
+	growthFactor   = 2.0
+	minGrowthShare = 0.05
+
+	// costlyFactor is how many times the median request's cost makes a
+	// request stand out; at most maxCostly are listed.
+	costlyFactor = 20.0
+	maxCostly    = 3
+
+	// failureShare is the share of failed requests worth a mention.
+	failureShare = 0.05
+)
+
+// monthSpend totals the transcript entries of one calendar month.
+type monthSpend struct {
+	start    time.Time // midnight UTC on the 1st, see dayOf
+	total    float64
+	requests int
+	failed   int
+	tokens   int
+	daily    []float64 // cost per day of the month
+
+	byTag, byModel []spendGroup
+	costs          []float64 // of each request, for the median
+	entries        []transcript.Entry
+}
+
+// newMonthSpend groups the entries of the month starting at start by
+// team tag (the tags with prefix, the prefix removed) and by model.
+func newMonthSpend(entries []transcript.Entry, start time.Time, prefix string) monthSpend {
+	days := start.AddDate(0, 1, -1).Day()
+	m := monthSpend{start: start, daily: make([]float64, days)}
+	byTag := grouper{days: days}
+	byModel := grouper{days: days}
+	for _, e := range entries {
+		day := dayOf(e.Time)
+		if day.Before(start) || !day.Before(start.AddDate(0, 1, 0)) {
+			continue
+		}
+		i := day.Day() - 1
+		m.total += e.Cost
+		m.requests++
+		m.tokens += e.Usage.InputTokens + e.Usage.OutputTokens
+		m.daily[i] += e.Cost
+		m.costs = append(m.costs, e.Cost)
+		m.entries = append(m.entries, e)
+		if e.Error != "" {
+			m.failed++
+		}
+		byModel.add(e.Provider+"/"+e.UsedModel(), i, e)
+		teams := teamTags(e.Tags, prefix)
+		if len(teams) == 0 {
+			byTag.add(untagged, i, e)
+		}
+		for _, t := range teams {
+			byTag.add(t, i, e)
+		}
+	}
+	m.byTag, m.byModel = byTag.sorted(), byModel.sorted()
+	return m
+}
+
+// teamTags returns the tags with prefix, without it.
+func teamTags(tags []string, prefix string) []string {
+	var teams []string
+	for _, t := range tags {
+		if team, ok := strings.CutPrefix(t, prefix); ok && team != "" {
+			teams = append(teams, team)
+		}
+	}
+	return teams
+}
+
+// costOf returns the spend of the group named name, or 0.
+func costOf(groups []spendGroup, name string) float64 {
+	for _, g := range groups {
+		if g.name == name {
+			return g.cost
+		}
+	}
+	return 0
+}
+
+// median returns the median of values, or 0 if there are none.
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Sorted(slices.Values(values))
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// change describes the change from previous to current, as in "+23.5%",
+// "new", or "-" for no spend either month.
+func change(current, previous float64) string {
+	switch {
+	case previous == 0 && current == 0:
+		return "-"
+	case previous == 0:
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f%%", (current-previous)/previous*100)
+}
+
+// anomaly is something in a month's spend worth a closer look: a lead such
+// as a day or model, and what stands out about it.
+type anomaly struct {
+	lead, text string
+}
+
+// anomalies finds the days, models, and requests whose spend stands out in
+// month, compared with its other days and requests and with previous.
+func anomalies(month, previous monthSpend) []anomaly {
+	var found []anomaly
+
+	// Days far above the median day with spend
+	var spent []float64
+	for _, c := range month.daily {
+		if c > 0 {
+			spent = append(spent, c)
+		}
+	}
+	if base := median(spent); len(spent) >= minSpikeDays && base > 0 {
+		for i, c := range month.daily {
+			if c < spikeFactor*base {
+				continue
+			}
+			day := month.start.AddDate(0, 0, i)
+			top := topModelOn(month.entries, day)
+			found = append(found, anomaly{
+				lead: day.Format("Mon Jan 2"),
+				text: fmt.Sprintf("$%.2f spent, %.1f× the median day ($%.2f); mostly %s", c, c/base, base, top),
+			})
+		}
+	}
+
+	// Models whose spend grew sharply or that are new and significant
+	for _, g := range month.byModel {
+		prev := costOf(previous.byModel, g.name)
+		if g.cost-prev < minGrowthShare*month.total {
+			continue
+		}
+		switch {
+		case prev == 0:
+			found = append(found, anomaly{
+				lead: g.name,
+				text: fmt.Sprintf("new this month, $%.2f (%.0f%% of spend)", g.cost, g.cost/month.total*100),
+			})
+		case g.cost >= growthFactor*prev:
+			found = append(found, anomaly{
+				lead: g.name,
+				text: fmt.Sprintf("$%.2f, up from $%.2f in %s (%.1f×)", g.cost, prev, previous.start.Format("January"), g.cost/prev),
+			})
+		}
+	}
+
+	// The most expensive requests far above the median request
+	if base := median(month.costs); base > 0 {
+		costly := slices.Clone(month.entries)
+		slices.SortStableFunc(costly, func(a, b transcript.Entry) int { return cmp.Compare(b.Cost, a.Cost) })
+		for _, e := range costly[:min(maxCostly, len(costly))] {
+			if e.Cost < costlyFactor*base {
+				break
+			}
+			text := fmt.Sprintf("one request cost $%.2f, %.0f× the median request, on %s", e.Cost, e.Cost/base, e.Time.Local().Format("Jan 2 15:04"))
+			if len(e.Tags) > 0 {
+				text += " (" + strings.Join(e.Tags, ", ") + ")"
+			}
+			found = append(found, anomaly{lead: e.Provider + "/" + e.UsedModel(), text: text})
+		}
+	}
+
+	if month.requests > 0 && float64(month.failed)/float64(month.requests) >= failureShare {
+		found = append(found, anomaly{
+			lead: "Failures",
+			text: fmt.Sprintf("%d of %d requests failed (%.0f%%)", month.failed, month.requests, float64(month.failed)/float64(month.requests)*100),
+		})
+	}
+	return found
+}
+
+// topModelOn returns the model with the most spend on day.
+func topModelOn(entries []transcript.Entry, day time.Time) string {
+	costs := map[string]float64{}
+	top := ""
+	for _, e := range entries {
+		if !dayOf(e.Time).Equal(day) {
+			continue
+		}
+		name := e.Provider + "/" + e.UsedModel()
+		costs[name] += e.Cost
+		if top == "" || costs[name] > costs[top] {
+			top = name
+		}
+	}
+	return top
+}
+
+// usageDoc builds the report of month against previous: a summary, spend
+// by team, the top models, daily spend, and anomalies.
+func usageDoc(month, previous monthSpend, top int, prefix string, files int) doc {
+	name, prevName := month.start.Format("January 2006"), previous.start.Format("January 2006")
+	d := doc{title: "AI Usage Report: " + name}
+	d.paragraph(fmt.Sprintf("Spend logged in %d transcript file%s, compared with %s. Generated %s.",
+		files, plural(files), prevName, time.Now().Format("January 2, 2006")))
+
+	d.heading("Summary")
+	summary := docTable{header: []string{"", name, prevName, "Change"}, right: []bool{false, true, true, true}}
+	perRequest := func(m monthSpend) float64 {
+		if m.requests == 0 {
+			return 0
+		}
+		return m.total / float64(m.requests)
+	}
+	summary.add("Spend", fmt.Sprintf("$%.2f", month.total), fmt.Sprintf("$%.2f", previous.total), change(month.total, previous.total))
+	summary.add("Requests", fmt.Sprint(month.requests), fmt.Sprint(previous.requests), change(float64(month.requests), float64(previous.requests)))
+	summary.add("Tokens", formatTokens(int64(month.tokens)), formatTokens(int64(previous.tokens)), change(float64(month.tokens), float64(previous.tokens)))
+	summary.add("Cost per request", fmt.Sprintf("$%.4f", perRequest(month)), fmt.Sprintf("$%.4f", perRequest(previous)),
+		change(perRequest(month), perRequest(previous)))
+	d.table(summary)
+
+	if month.requests == 0 {
+		d.paragraph("No requests were logged this month.")
+		return d
+	}
+
+	d.heading("Spend by Team")
+	if prefix != "" {
+		d.paragraph(fmt.Sprintf("Teams are the tags starting with %q. A request with several counts toward each.", prefix))
+	} else {
+		d.paragraph("Teams are the transcript tags. A request with several counts toward each.")
+	}
+	teams := docTable{header: []string{"Team", "Spend", "Share", "Requests", prevName, "Change"}, right: []bool{false, true, true, true, true, true}}
+	for _, g := range month.byTag {
+		prev := costOf(previous.byTag, g.name)
+		teams.add(g.name, fmt.Sprintf("$%.2f", g.cost), fmt.Sprintf("%.1f%%", costShare(g.cost, month.total)),
+			fmt.Sprint(len(g.entries)), fmt.Sprintf("$%.2f", prev), change(g.cost, prev))
+	}
+	d.table(teams)
+
+	d.heading("Top Models")
+	models := docTable{header: []string{"Model", "Spend", "Share", "Requests", "Tokens", prevName, "Change"}, right: []bool{false, true, true, true, true, true, true}}
+	for _, g := range month.byModel[:min(top, len(month.byModel))] {
+		prev := costOf(previous.byModel, g.name)
+		models.add(g.name, fmt.Sprintf("$%.2f", g.cost), fmt.Sprintf("%.1f%%", costShare(g.cost, month.total)), fmt.Sprint(len(g.entries)),
+			formatTokens(int64(g.inputTokens+g.outputTokens)), fmt.Sprintf("$%.2f", prev), change(g.cost, prev))
+	}
+	if rest := len(month.byModel) - top; rest > 0 {
+		cost := month.total
+		for _, g := range month.byModel[:top] {
+			cost -= g.cost
+		}
+		models.add(fmt.Sprintf("%d other model%s", rest, plural(rest)), fmt.Sprintf("$%.2f", cost), fmt.Sprintf("%.1f%%", costShare(cost, month.total)), "", "", "", "")
+	}
+	d.table(models)
+
+	d.heading("Daily Spend")
+	// The current month is charted up to today
+	days := month.daily
+	if today := dayOf(time.Now()); today.Before(month.start.AddDate(0, 1, 0)) {
+		days = days[:today.Day()]
+	}
+	bars := make([]docBar, len(days))
+	for i, c := range days {
+		bars[i] = docBar{label: month.start.AddDate(0, 0, i).Format("Jan 02"), value: c}
+	}
+	d.chart(bars)
+
+	d.heading("Anomalies")
+	found := anomalies(month, previous)
+	if len(found) == 0 {
+		d.paragraph("None: no day, model, or request stands out from the rest of the month.")
+	}
+	d.list(found)
+	return d
+}
+
+// costShare returns part as a percentage of total.
+func costShare(part, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return part / total * 100
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// runUsageReport renders a month's spend from transcripts as Markdown or
+// PDF.
+func runUsageReport(args []string) error {
+	fs := flag.NewFlagSet("usage report", flag.ExitOnError)
+	monthFlag := fs.String("month", "", "Month to report, as YYYY-MM (default: the current month)")
+	format := fs.String("format", "md", "Output format: md or pdf")
+	output := fs.String("output", "", "File to write (default: stdout for md, usage-YYYY-MM.pdf for pdf)")
+	prefix := fs.String("tag-prefix", "", "Only tags with this prefix name teams, e.g. team: (default: every tag)")
+	top := fs.Int("top", 10, "Models listed by spend")
+	fs.Usage = printUsageReportHelp
+	_ = fs.Parse(args)
+
+	if fs.NArg() == 0 || *top < 1 {
+		printUsageReportHelp()
+		return errUsage
+	}
+	if *format != "md" && *format != "pdf" {
+		return fmt.Errorf("unknown format: %s (use md or pdf)", *format)
+	}
+	start := dayOf(time.Now())
+	start = start.AddDate(0, 0, 1-start.Day())
+	if *monthFlag != "" {
+		t, err := time.Parse("2006-01", *monthFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --month %q: use YYYY-MM, e.g. 2025-06", *monthFlag)
+		}
+		start = t
+	}
+
+	var entries []transcript.Entry
+	for _, path := range fs.Args() {
+		e, err := transcript.ReadFile(path)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		entries = append(entries, e...)
+	}
+	month := newMonthSpend(entries, start, *prefix)
+	previous := newMonthSpend(entries, start.AddDate(0, -1, 0), *prefix)
+	d := usageDoc(month, previous, *top, *prefix, fs.NArg())
+
+	path := *output
+	if path == "" && *format == "pdf" {
+		path = "usage-" + start.Format("2006-01") + ".pdf"
+	}
+	var w io.Writer = os.Stdout
+	if path != "" && path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create report: %w", err)
+		}
+		defer f.Close() //nolint:errcheck
+		w = f
+	}
+	var err error
+	if *format == "pdf" {
+		err = d.writePDF(w)
+	} else {
+		err = d.writeMarkdown(w)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	if w != os.Stdout {
+		fmt.Fprintln(os.Stderr, okStyle.Render(fmt.Sprintf("Wrote %s: $%.2f across %d request%s", path, month.total, month.requests, plural(month.requests))))
+	}
+	return nil
+}
+
+// doc is a report rendered as Markdown or PDF: a title and a sequence of
+// headings, paragraphs, tables, bar charts, and lists.
+type doc struct {
+	title  string
+	blocks []docBlock
+}
+
+type docBlock struct {
+	heading, text string
+	table         *docTable
+	chart         []docBar
+	items         []anomaly
+}
+
+type docTable struct {
+	header []string
+	right  []bool // right-aligned columns
+	rows   [][]string
+}
+
+func (t *docTable) add(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+type docBar struct {
+	label string
+	value float64
+}
+
+func (d *doc) heading(text string)   { d.blocks = append(d.blocks, docBlock{heading: text}) }
+func (d *doc) paragraph(text string) { d.blocks = append(d.blocks, docBlock{text: text}) }
+func (d *doc) table(t docTable)      { d.blocks = append(d.blocks, docBlock{table: &t}) }
+func (d *doc) chart(bars []docBar)   { d.blocks = append(d.blocks, docBlock{chart: bars}) }
+func (d *doc) list(items []anomaly) {
+	if len(items) > 0 {
+		d.blocks = append(d.blocks, docBlock{items: items})
+	}
+}
+
+// writeMarkdown writes d as GitHub-flavored Markdown, with the chart as
+// text bars in a code block.
+func (d doc) writeMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# " + d.title + "\n")
+	for _, block := range d.blocks {
+		b.WriteString("\n")
+		switch {
+		case block.heading != "":
+			b.WriteString("## " + block.heading + "\n")
+		case block.table != nil:
+			t := block.table
+			cell := func(s string) string { return strings.ReplaceAll(s, "|", `\|`) }
+			b.WriteString("|")
+			for _, h := range t.header {
+				b.WriteString(" " + cell(h) + " |")
+			}
+			b.WriteString("\n|")
+			for _, right := range t.right {
+				if right {
+					b.WriteString("---:|")
+				} else {
+					b.WriteString("---|")
+				}
+			}
+			b.WriteString("\n")
+			for _, row := range t.rows {
+				b.WriteString("|")
+				for _, c := range row {
+					b.WriteString(" " + cell(c) + " |")
+				}
+				b.WriteString("\n")
+			}
+		case block.chart != nil:
+			largest := 0.0
+			for _, bar := range block.chart {
+				largest = max(largest, bar.value)
+			}
+			b.WriteString("```\n")
+			for _, bar := range block.chart {
+				fmt.Fprintf(&b, "%s %s $%.2f\n", bar.label, render.Bar(bar.value/math.Max(largest, 1e-9), 40), bar.value)
+			}
+			b.WriteString("```\n")
+		case block.items != nil:
+			for _, item := range block.items {
+				b.WriteString("- **" + item.lead + "**: " + item.text + "\n")
+			}
+		default:
+			b.WriteString(block.text + "\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err //nolint:wrapcheck
+}
+
+func printUsageReportHelp() {
+	fmt.Println("aimodels usage report - Render a month's spend as a shareable report")
+	fmt.Println()
+	fmt.Println("Reads JSONL transcripts (chat-bot's --log-transcript, the spend ledger) and")
+	fmt.Println("writes a Markdown or PDF report for stakeholders: spend, requests, and tokens")
+	fmt.Println("against the previous month, spend by team tag, the top models, a chart of")
+	fmt.Println("daily spend, and anomalies. Anomalies are days over 3× the median day, models")
+	fmt.Println("whose spend doubled or that are new and at least 5% of the month, requests")
+	fmt.Println("over 20× the median request, and a failure rate of 5% or more.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  aimodels usage report [options] <transcript.jsonl>...")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --month <YYYY-MM>     Month to report (default: the current month)")
+	fmt.Println("  --format <fmt>        md or pdf (default: md)")
+	fmt.Println("  --output <file>       File to write (default: stdout for md, usage-YYYY-MM.pdf for pdf)")
+	fmt.Println("  --tag-prefix <p>      Only tags starting with p name teams, without it, so")
+	fmt.Println("                        team:search reports as search (default: every tag)")
+	fmt.Println("  --top <n>             Models listed by spend; the rest are summed (default: 10)")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  aimodels usage report --month 2025-06 chat.jsonl > june.md")
+	fmt.Println("  aimodels usage report --month 2025-06 --format pdf --tag-prefix team: logs/*.jsonl")
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"charm.land/catwalk/pkg/transcript"
+)
+
+// spend returns an entry made at noon on a day of 2025, local time as
+// dayOf uses, sent to openai/model.
+func spend(month time.Month, day int, model string, cost float64, tags ...string) transcript.Entry {
+	return transcript.Entry{
+		Time:     time.Date(2025, month, day, 12, 0, 0, 0, time.Local),
+		Provider: "openai",
+		Model:    model,
+		Cost:     cost,
+		Usage:    transcript.Usage{InputTokens: 100, OutputTokens: 10},
+		Tags:     tags,
+	}
+}
+
+var june = time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+func TestNewMonthSpend(t *testing.T) {
+	failed := spend(time.June, 15, "gpt-4o", 0)
+	failed.Error = "timeout"
+	entries := []transcript.Entry{
+		spend(time.May, 31, "gpt-4o", 100, "team:search"),
+		spend(time.June, 1, "gpt-4o", 1, "team:search", "env:prod"),
+		spend(time.June, 1, "gpt-4o-mini", 0.5, "team:ads", "team:search"),
+		spend(time.June, 30, "gpt-4o", 2),
+		spend(time.July, 1, "gpt-4o", 100, "team:ads"),
+		failed,
+	}
+
+	m := newMonthSpend(entries, june, "team:")
+	if m.total != 3.5 || m.requests != 4 || m.failed != 1 || m.tokens != 440 {
+		t.Errorf("total = %v, requests = %d, failed = %d, tokens = %d; want 3.5, 4, 1, 440", m.total, m.requests, m.failed, m.tokens)
+	}
+	if len(m.daily) != 30 || m.daily[0] != 1.5 || m.daily[29] != 2 {
+		t.Errorf("daily = %v, want 30 days from $1.50 to $2", m.daily)
+	}
+
+	// A request with several team tags counts toward each
+	want := map[string]float64{"search": 1.5, "ads": 0.5, untagged: 2}
+	if len(m.byTag) != len(want) {
+		t.Errorf("byTag = %+v, want %v", m.byTag, want)
+	}
+	for name, cost := range want {
+		if got := costOf(m.byTag, name); got != cost {
+			t.Errorf("team %s spent %v, want %v", name, got, cost)
+		}
+	}
+	if got := costOf(m.byModel, "openai/gpt-4o"); got != 3 {
+		t.Errorf("openai/gpt-4o spent %v, want 3", got)
+	}
+
+	// The previous month is its own window, 31 days for May
+	prev := newMonthSpend(entries, june.AddDate(0, -1, 0), "team:")
+	if prev.total != 100 || len(prev.daily) != 31 || costOf(prev.byTag, "search") != 100 {
+		t.Errorf("previous month = %+v", prev)
+	}
+
+	// Without a prefix every tag names a team
+	if got := costOf(newMonthSpend(entries, june, "").byTag, "env:prod"); got != 1 {
+		t.Errorf("tag env:prod spent %v without a prefix, want 1", got)
+	}
+}
+
+func TestTeamTags(t *testing.T) {
+	tests := []struct {
+		tags   []string
+		prefix string
+		want   []string
+	}{
+		{[]string{"team:search", "env:prod", "team:"}, "team:", []string{"search"}},
+		{[]string{"search", "ads"}, "", []string{"search", "ads"}},
+		{[]string{"env:prod"}, "team:", nil},
+		{nil, "team:", nil},
+	}
+	for _, tt := range tests {
+		if got := teamTags(tt.tags, tt.prefix); !slices.Equal(got, tt.want) {
+			t.Errorf("teamTags(%q, %q) = %q, want %q", tt.tags, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestChange(t *testing.T) {
+	tests := []struct {
+		current, previous float64
+		want              string
+	}{
+		{0, 0, "-"},
+		{5, 0, "new"},
+		{15, 10, "+50.0%"},
+		{5, 10, "-50.0%"},
+		{0, 10, "-100.0%"},
+		{10, 10, "+0.0%"},
+	}
+	for _, tt := range tests {
+		if got := change(tt.current, tt.previous); got != tt.want {
+			t.Errorf("change(%v, %v) = %q, want %q", tt.current, tt.previous, got, tt.want)
+		}
+	}
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   float64
+	}{
+		{nil, 0},
+		{[]float64{3}, 3},
+		{[]float64{5, 1, 3}, 3},
+		{[]float64{4, 1, 3, 2}, 2.5},
+	}
+	for _, tt := range tests {
+		if got := median(tt.values); got != tt.want {
+			t.Errorf("median(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestAnomalies(t *testing.T) {
+	// steady is $1 a day on gpt-4o for the first four days of a month
+	steady := func(month time.Month) []transcript.Entry {
+		var entries []transcript.Entry
+		for day := 1; day <= 4; day++ {
+			entries = append(entries, spend(month, day, "gpt-4o", 1))
+		}
+		return entries
+	}
+	cheap := func(n int, cost float64) []transcript.Entry {
+		var entries []transcript.Entry
+		for i := range n {
+			entries = append(entries, spend(time.June, 1+i%3, "gpt-4o", cost))
+		}
+		return entries
+	}
+	failed := spend(time.June, 1, "gpt-4o", 0.1)
+	failed.Error = "HTTP 500"
+
+	tests := []struct {
+		name            string
+		month, previous []transcript.Entry
+		want            []string
+	}{
+		{"steady", steady(time.June), steady(time.May), nil},
+		{
+			"spike day",
+			append(steady(time.June), spend(time.June, 5, "gpt-4o", 5)),
+			append(steady(time.May), spend(time.May, 5, "gpt-4o", 5)),
+			[]string{"Thu Jun 5"},
+		},
+		{
+			// Days with spend are too few to judge spikes
+			"spike on few days",
+			[]transcript.Entry{spend(time.June, 1, "gpt-4o", 1), spend(time.June, 2, "gpt-4o", 10)},
+			[]transcript.Entry{spend(time.May, 1, "gpt-4o", 11)},
+			nil,
+		},
+		{
+			"new model",
+			append(steady(time.June), spend(time.June, 1, "o3", 1)),
+			steady(time.May),
+			[]string{"openai/o3"},
+		},
+		{
+			// New but under 5% of the month's spend
+			"small new model",
+			append(steady(time.June), spend(time.June, 1, "o3", 0.1)),
+			steady(time.May),
+			nil,
+		},
+		{
+			"growth",
+			steady(time.June),
+			[]transcript.Entry{spend(time.May, 1, "gpt-4o", 1.5)},
+			[]string{"openai/gpt-4o"},
+		},
+		{
+			"costly request",
+			append(cheap(30, 0.01), spend(time.June, 2, "gpt-4o", 1)),
+			[]transcript.Entry{spend(time.May, 1, "gpt-4o", 1.3)},
+			[]string{"openai/gpt-4o"},
+		},
+		{
+			"failures",
+			append(cheap(19, 0.1), failed),
+			[]transcript.Entry{spend(time.May, 1, "gpt-4o", 2)},
+			[]string{"Failures"},
+		},
+		{
+			"few failures",
+			append(cheap(20, 0.1), failed),
+			[]transcript.Entry{spend(time.May, 1, "gpt-4o", 2.1)},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			month := newMonthSpend(tt.month, june, "")
+			previous := newMonthSpend(tt.previous, june.AddDate(0, -1, 0), "")
+			var leads []string
+			for _, a := range anomalies(month, previous) {
+				leads = append(leads, a.lead)
+			}
+			if !slices.Equal(leads, tt.want) {
+				t.Errorf("anomalies = %q, want %q", leads, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,529 @@
+// Package main provides eval, which runs a YAML suite of golden prompts
+// against one or more models and compares their pass rates, latency, and
+// cost. Each case's reply is checked with regex, JSON, or LLM-judge
+// assertions; see pkg/eval for the suite format.
+//
+// Usage:
+//
+//	eval --suite support.yaml --models openai/gpt-4o,anthropic/claude-sonnet-4-5
+//	eval --suite support.yaml --models openai/gpt-4o-mini --judge openai/gpt-4o
+//	eval --suite support.yaml --models gpt-4o,gpt-4o-mini --format html > eval.html
+//	eval --suite support.yaml --models gpt-4o --min-pass-rate 0.9   # Fail CI below 90%
+//	eval --suite support.yaml --models openai/gpt-4o,anthropic/claude-sonnet-4-5 --batch   # Half price, results within 24h
+//	eval --suite support.yaml --models gpt-4o --jsonl results.jsonl --resume   # Pick up after an interruption
+//	eval --suite support.yaml --models gpt-4o --policy policy.yaml   # Refuse models the organization bans
+//	eval --suite support.yaml --models gpt-4o,gpt-4o-mini --breaker-failures 3 --breaker-cooldown 1m   # Skip a provider that is down
+//	eval --suite support.yaml --models gpt-4o --parallel 16 --overrides overrides.yaml   # Pace to the rate_limit set there
+//
+// Environment Variables:
+//
+//	CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)
+//	<PROVIDER>_API_KEY - API keys, as named by each provider in the catalog
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"charm.land/catwalk/pkg/batch"
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/eval"
+	"charm.land/catwalk/pkg/policy"
+	"charm.land/catwalk/pkg/registry"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/snapshot"
+	"charm.land/catwalk/pkg/theme"
+	"charm.land/catwalk/pkg/transport"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+)
+
+var (
+	suiteFile      = flag.String("suite", "", "YAML suite of cases to run (required)")
+	modelList      = flag.String("models", "", "Comma-separated models to evaluate, as provider/model or model (required)")
+	judgeModel     = flag.String("judge", "", "Model grading judge assertions, as provider/model or model")
+	parallel       = flag.Int("parallel", 4, "Cases run concurrently per model")
+	timeout        = flag.Duration("timeout", 2*time.Minute, "Timeout per case, including judge calls")
+	batchMode      = flag.Bool("batch", false, "Send each model's cases as one discounted batch where the provider has a Batch API")
+	batchPoll      = flag.Duration("batch-poll", batch.DefaultPollInterval, "How often to check on a batch")
+	jsonlFile      = flag.String("jsonl", "", "Write each case's result as a JSON line as soon as it completes, to this file or - for stdout")
+	resume         = flag.Bool("resume", false, "Skip the cases already completed in the --jsonl file and append the rest")
+	minPassRate    = flag.Float64("min-pass-rate", 0, "Exit with status 1 if any model's pass rate is below this (0-1)")
+	outputFormat   = flag.String("format", "table", "Output format: table, json, csv, or html")
+	catalogVersion = flag.String("catalog-version", "", "Use a stored catalog snapshot (ETag, YYYY-MM-DD, or latest) instead of live data")
+	overridesFile  = flag.String("overrides", "", "Pricing overrides file, or none (default: the aimodels overrides.yaml, if present)")
+	policyFile     = flag.String("policy", "", "Organization policy of allowed providers and models, or none (default: the aimodels policy.yaml, if present)")
+	breakerLimit   = flag.Int("breaker-failures", transport.DefaultThreshold, "Consecutive failures that make a provider skipped for --breaker-cooldown (0 disables)")
+	breakerWait    = flag.Duration("breaker-cooldown", transport.DefaultCooldown, "How long a failing provider is skipped before a trial request")
+	pace           = flag.Bool("pace", true, "Hold requests to stay under each model's rate limits, from --overrides and the provider's headers")
+	network        = transport.RegisterFlags(flag.CommandLine)
+	showHelp       = flag.Bool("help", false, "Show help message")
+)
+
+// Styles for formatting, in the colors of the aimodels theme
+var (
+	colors = theme.Current()
+
+	headerStyle = lipgloss.NewStyle().Bold(true).Foreground(colors.Header)
+	modelStyle  = lipgloss.NewStyle().Bold(true).Foreground(colors.Accent)
+	infoStyle   = lipgloss.NewStyle().Foreground(colors.Muted)
+	passStyle   = lipgloss.NewStyle().Foreground(colors.OK)
+	failStyle   = lipgloss.NewStyle().Foreground(colors.Error)
+	costStyle   = lipgloss.NewStyle().Foreground(colors.Highlight)
+	borderStyle = lipgloss.NewStyle().Foreground(colors.Border)
+)
+
+// errBelowThreshold is returned when a model misses --min-pass-rate.
+var errBelowThreshold = errors.New("pass rate below --min-pass-rate")
+
+// modelRun is a suite's results on one model.
+type modelRun struct {
+	target  eval.Target
+	results []eval.Result
+	summary eval.Summary
+}
+
+func main() {
+	render.SetupConsole()
+	flag.Parse()
+
+	if *showHelp {
+		printHelp()
+		return
+	}
+	if *suiteFile == "" || *modelList == "" || *resume && (*jsonlFile == "" || *jsonlFile == "-") {
+		printHelp()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, failStyle.Render("Error: "+err.Error()))
+		os.Exit(catwalk.ExitCode(err))
+	}
+}
+
+func run(ctx context.Context) error {
+	suite, err := eval.Load(*suiteFile)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	if *minPassRate < 0 || *minPassRate > 1 {
+		return fmt.Errorf("--min-pass-rate must be between 0 and 1")
+	}
+	if *breakerLimit < 0 || *breakerWait <= 0 {
+		return fmt.Errorf("--breaker-failures must not be negative and --breaker-cooldown must be positive")
+	}
+	switch strings.ToLower(*outputFormat) {
+	case "table", "json", "csv", "html":
+	default:
+		return fmt.Errorf("unknown format: %s (use table, json, csv, or html)", *outputFormat)
+	}
+
+	httpClient, err := network.Client()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	providers, err := snapshot.Fetch(ctx, catwalk.NewWithHTTPClient(httpClient), *catalogVersion)
+	if err != nil {
+		return fmt.Errorf("failed to fetch providers: %w", err)
+	}
+	var overrides *registry.Overrides
+	if *overridesFile != "none" {
+		if overrides, err = registry.Open(*overridesFile); err != nil {
+			return err //nolint:wrapcheck
+		}
+		providers = overrides.Apply(providers)
+	}
+	var orgPolicy *policy.Policy
+	if *policyFile != "none" {
+		if orgPolicy, err = policy.Open(*policyFile); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+	base, err := network.Transport()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	// Stop sending to a provider that keeps failing, rather than failing
+	// every remaining case against it
+	var breaker *transport.Breaker
+	if *breakerLimit > 0 {
+		breaker = transport.NewBreaker(*breakerLimit, *breakerWait)
+		breaker.OnEvent = logCircuit
+	}
+	// Pace requests under each model's known limits rather than retrying
+	// after the provider refuses them
+	var scheduler *chat.Scheduler
+	if *pace {
+		scheduler = chat.NewScheduler()
+		scheduler.OnLimit = logLimit
+	}
+
+	// Resolve every model and key before spending anything
+	var targets []eval.Target
+	for _, name := range strings.Split(*modelList, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		t, err := resolveTarget(providers, name, base, breaker, scheduler, overrides)
+		if err != nil {
+			return err
+		}
+		if err := orgPolicy.Check(t.Provider, t.Model); err != nil {
+			return err //nolint:wrapcheck
+		}
+		if *batchMode {
+			if err := useBatch(&t, breaker.Transport(string(t.Provider.ID), base)); err != nil {
+				return err
+			}
+		}
+		targets = append(targets, t)
+	}
+	opts := eval.Options{Parallel: *parallel, Timeout: *timeout}
+	if *judgeModel != "" {
+		t, err := resolveTarget(providers, *judgeModel, base, breaker, scheduler, overrides)
+		if err != nil {
+			return fmt.Errorf("judge: %w", err)
+		}
+		if err := orgPolicy.Check(t.Provider, t.Model); err != nil {
+			return fmt.Errorf("judge: %w", err)
+		}
+		opts.Judge = &t
+	} else if suite.NeedsJudge() {
+		return fmt.Errorf("the suite has judge assertions; set --judge")
+	}
+
+	// Stream results as they complete, after reading back the ones an
+	// interrupted run already wrote
+	var records []eval.Record
+	var jsonl *json.Encoder
+	if *jsonlFile != "" {
+		out, err := openJSONL(&records)
+		if err != nil {
+			return err
+		}
+		defer out.Close() //nolint:errcheck
+		jsonl = json.NewEncoder(out)
+	}
+
+	var runs []modelRun
+	for _, t := range targets {
+		opts := opts
+		opts.Done = eval.Completed(records, t)
+		skipped := breaker.State(string(t.Provider.ID)) == transport.StateOpen
+		if *outputFormat == "table" {
+			verb := "Running"
+			if skipped {
+				verb = failStyle.Render("Skipping")
+			}
+			fmt.Fprintf(os.Stderr, "%s %s (%d cases%s)...\n",
+				infoStyle.Render(verb), t.Model.ID, len(suite.Cases), resumedNote(len(opts.Done)))
+		}
+		bar := newProgress(modelName(modelRun{target: t}), len(suite.Cases), opts.Done)
+		var writeErr error
+		opts.OnResult = func(r eval.Result) {
+			bar.add(r)
+			if jsonl != nil && writeErr == nil {
+				writeErr = jsonl.Encode(eval.NewRecord(t, r))
+			}
+		}
+		var results []eval.Result
+		if skipped {
+			results = skipCases(suite, opts, breaker.Allow(string(t.Provider.ID)))
+		} else {
+			results = suite.Run(ctx, t, opts)
+		}
+		bar.finish()
+		runs = append(runs, modelRun{target: t, results: results, summary: eval.Summarize(results)})
+		if writeErr != nil {
+			return fmt.Errorf("failed to write results: %w", writeErr)
+		}
+		if ctx.Err() != nil {
+			if *jsonlFile != "" && *jsonlFile != "-" {
+				fmt.Fprintln(os.Stderr, infoStyle.Render("Interrupted; run again with --resume to skip the completed cases."))
+			}
+			return ctx.Err() //nolint:wrapcheck
+		}
+	}
+
+	// The JSON lines are the output when they go to stdout
+	if *jsonlFile == "-" {
+		return checkPassRates(runs)
+	}
+
+	switch strings.ToLower(*outputFormat) {
+	case "json":
+		err = outputJSON(suite, runs)
+	case "csv":
+		err = outputCSV(runs)
+	case "html":
+		err = outputHTML(suite, runs)
+	default:
+		outputTable(suite, runs)
+	}
+	if err != nil {
+		return err
+	}
+	return checkPassRates(runs)
+}
+
+// checkPassRates returns an error for the first model below --min-pass-rate
+func checkPassRates(runs []modelRun) error {
+	for _, r := range runs {
+		if r.summary.PassRate() < *minPassRate {
+			return fmt.Errorf("%s: %.0f%% %w (%.0f%%)", modelName(r),
+				r.summary.PassRate()*100, errBelowThreshold, *minPassRate*100)
+		}
+	}
+	return nil
+}
+
+// openJSONL opens the --jsonl output. With --resume, the records already in
+// the file are read into records and new ones appended; otherwise the file
+// is replaced.
+func openJSONL(records *[]eval.Record) (io.WriteCloser, error) {
+	if *jsonlFile == "-" {
+		return nopCloser{os.Stdout}, nil
+	}
+	if !*resume {
+		f, err := os.Create(*jsonlFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open results: %w", err)
+		}
+		return f, nil
+	}
+
+	data, err := os.ReadFile(*jsonlFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read results: %w", err)
+	}
+	if *records, err = eval.ReadRecords(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("%s: %w", *jsonlFile, err)
+	}
+	// A last line cut short by the interruption is dropped, and a complete
+	// one ended, so appended records start on a line of their own
+	end := bytes.LastIndexByte(data, '\n') + 1
+	last := bytes.TrimSpace(data[end:])
+	if len(last) > 0 && !json.Valid(last) {
+		if err := os.Truncate(*jsonlFile, int64(end)); err != nil {
+			return nil, fmt.Errorf("failed to repair results: %w", err)
+		}
+	}
+	f, err := os.OpenFile(*jsonlFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open results: %w", err)
+	}
+	if len(last) > 0 && json.Valid(last) {
+		if _, err := f.WriteString("\n"); err != nil {
+			f.Close() //nolint:errcheck
+			return nil, fmt.Errorf("failed to write results: %w", err)
+		}
+	}
+	return f, nil
+}
+
+// nopCloser keeps stdout open when the results go there
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// resumedNote says how many of a model's cases are skipped, if any
+func resumedNote(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", %d already done", n)
+}
+
+// skipCases fails the cases not already done with err, without sending
+// them, as when the provider's circuit is open
+func skipCases(suite *eval.Suite, opts eval.Options, err error) []eval.Result {
+	results := make([]eval.Result, len(suite.Cases))
+	for i, c := range suite.Cases {
+		if r, ok := opts.Done[c.Name]; ok {
+			results[i] = r
+			continue
+		}
+		results[i] = eval.Result{Case: c.Name, Error: err.Error()}
+		opts.OnResult(results[i])
+	}
+	return results
+}
+
+// logCircuit reports a provider's circuit opening or closing on stderr,
+// above the progress line
+func logCircuit(e transport.Event) {
+	style := infoStyle
+	if e.State == transport.StateOpen {
+		style = failStyle
+	}
+	line := style.Render(e.String())
+	if term.IsTerminal(os.Stderr.Fd()) {
+		line = "\r\x1b[K" + line
+	}
+	fmt.Fprintln(os.Stderr, line)
+}
+
+// logLimit reports a model's rate limit learned from response headers
+func logLimit(provider catwalk.InferenceProvider, model string, l chat.Limit) {
+	line := infoStyle.Render(fmt.Sprintf("%s/%s: pacing to %s", provider, model, l))
+	if term.IsTerminal(os.Stderr.Fd()) {
+		line = "\r\x1b[K" + line
+	}
+	fmt.Fprintln(os.Stderr, line)
+}
+
+// resolveTarget finds a model, given as provider/model or as a model ID
+// offered by any provider, and creates a client with the provider's key
+// whose requests go through the provider's circuit in breaker and are
+// paced by scheduler, under the model's limit in overrides if it has one.
+func resolveTarget(providers []catwalk.Provider, name string, base http.RoundTripper, breaker *transport.Breaker, scheduler *chat.Scheduler, overrides *registry.Overrides) (eval.Target, error) {
+	provider, model, err := catwalk.FindModel(providers, name)
+	if err != nil {
+		return eval.Target{}, err //nolint:wrapcheck
+	}
+	if l, ok := overrides.Limit(provider.ID, model.ID); ok && scheduler != nil {
+		scheduler.SetLimit(provider.ID, model.ID, l.Chat())
+	}
+
+	client, err := chat.NewClientFor(*provider, scheduler.Transport(breaker.Transport(string(provider.ID), base)))
+	if err != nil {
+		return eval.Target{}, err //nolint:wrapcheck
+	}
+	return eval.Target{
+		Client:     client,
+		Provider:   *provider,
+		Model:      *model,
+		Middleware: []chat.Middleware{scheduler.Middleware()},
+	}, nil
+}
+
+// useBatch sends the target's cases through its provider's Batch API, or
+// says why they will be sent one by one.
+func useBatch(t *eval.Target, base http.RoundTripper) error {
+	if !batch.Supports(t.Provider) {
+		fmt.Fprintln(os.Stderr, infoStyle.Render(fmt.Sprintf(
+			"%s has no batch API; %s runs case by case at full price.", t.Provider.Name, t.Model.ID)))
+		return nil
+	}
+	key, err := t.Provider.ResolveAPIKey()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	c, err := batch.NewClient(t.Provider, t.Model, key, base)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	c.PollInterval = *batchPoll
+	model := t.Model.ID
+	c.OnStatus = func(s batch.Status) {
+		if *outputFormat != "table" {
+			return
+		}
+		line := fmt.Sprintf("  batch %s: %s", s.ID, s.State)
+		if s.Total > 0 {
+			line += fmt.Sprintf(", %d/%d done", s.Completed, s.Total)
+		}
+		if s.Failed > 0 {
+			line += fmt.Sprintf(", %d failed", s.Failed)
+		}
+		fmt.Fprintln(os.Stderr, infoStyle.Render(model+line))
+	}
+	t.Batch = c
+	return nil
+}
+
+// printHelp displays usage information
+func printHelp() {
+	fmt.Println("eval - Run golden prompts against models and compare quality, latency, and cost")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  eval --suite <file> --models <list> [options]")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --suite <file>          YAML suite of cases (required)")
+	fmt.Println("  --models <list>         Models to evaluate, as provider/model or a model ID (required)")
+	fmt.Println("  --judge <model>         Model that grades judge assertions")
+	fmt.Println("  --parallel <n>          Cases run concurrently per model (default: 4)")
+	fmt.Println("  --timeout <d>           Timeout per case, including judge calls (default: 2m)")
+	fmt.Println("  --batch                 Send each model's cases as one batch through the OpenAI or")
+	fmt.Println("                          Anthropic Batch API, at 50% off; results can take up to 24h.")
+	fmt.Println("                          Other providers run case by case. Ctrl-C cancels the batch")
+	fmt.Println("  --batch-poll <d>        How often to check on a batch (default: 30s)")
+	fmt.Println("  --jsonl <file>          Write each case's result as a JSON line as soon as it")
+	fmt.Println("                          completes (- for stdout, replacing the --format report)")
+	fmt.Println("  --resume                Skip the cases the --jsonl file already holds for each")
+	fmt.Println("                          model and append the rest; cases whose request failed run")
+	fmt.Println("                          again")
+	fmt.Println("  --min-pass-rate <r>     Exit with status 1 if a model passes less than r (0-1)")
+	fmt.Println("  --format <fmt>          table (default), json, csv, or html")
+	fmt.Println("  --catalog-version <v>   Use a stored catalog snapshot")
+	fmt.Println("  --overrides <file>      Negotiated prices to use instead of list prices, or none")
+	fmt.Println("                          (default: the aimodels overrides.yaml, if present)")
+	fmt.Println("  --policy <file>         Organization policy of allowed providers and models, or")
+	fmt.Println("                          none (default: the aimodels policy.yaml, if present).")
+	fmt.Println("                          Every model, the judge included, must pass it before any")
+	fmt.Println("                          case runs")
+	fmt.Println("  --breaker-failures <n>  Consecutive failed requests (network errors, 408, 429,")
+	fmt.Println("                          5xx) after which a provider is skipped for the cool-down;")
+	fmt.Println("                          0 disables (default: 5)")
+	fmt.Println("  --breaker-cooldown <d>  How long a failing provider is skipped before one trial")
+	fmt.Println("                          request decides whether to resume (default: 30s)")
+	fmt.Println("  --pace                  Hold each request until the model's rate limits allow it:")
+	fmt.Println("                          the rate_limit in --overrides, limits the provider reports")
+	fmt.Println("                          in response headers, and waits a 429 asks for; --pace=false")
+	fmt.Println("                          sends at once (default: true)")
+	fmt.Println("  --proxy <url>           Proxy URL (default: HTTPS_PROXY/HTTP_PROXY from the environment)")
+	fmt.Println("  --ca-cert <pem>         PEM file with additional CA certificates to trust")
+	fmt.Println("  --insecure-skip-verify  Skip TLS certificate verification (unsafe)")
+	fmt.Println()
+	fmt.Println("Suite Format (YAML):")
+	fmt.Println("  name: support")
+	fmt.Println("  system: You are a support agent for Acme.   # optional, per case too")
+	fmt.Println("  max_tokens: 300                             # optional")
+	fmt.Println("  cases:")
+	fmt.Println("    - name: refund-policy")
+	fmt.Println("      prompt: How many days do I have to return an item?")
+	fmt.Println("      expect:")
+	fmt.Println("        - regex: '\\b30\\b'               # must match")
+	fmt.Println("        - not_regex: '(?i)sorry'        # must not match")
+	fmt.Println("        - json: {days: 30}              # reply is JSON containing these fields")
+	fmt.Println("        - judge: The reply is polite.   # graded PASS/FAIL by --judge")
+	fmt.Println()
+	fmt.Println("Cost is computed from catalog prices with overrides applied, halved for batched")
+	fmt.Println("cases; judge calls are reported separately. Batched cases have no latency.")
+	fmt.Println("When stderr is a terminal, a progress line shows each model's done and failed")
+	fmt.Println("cases, tokens, and cost so far.")
+	fmt.Println()
+	fmt.Println("A provider that keeps failing is skipped until its cool-down ends: its cases")
+	fmt.Println("fail at once without being sent, and a line on stderr says when it stopped and")
+	fmt.Println("resumed. Skipped cases count as failed requests, which --resume runs again.")
+	fmt.Println()
+	fmt.Println("With --pace, requests wait their turn under each model's requests and tokens")
+	fmt.Println("per window, so a high --parallel stays within the provider's limits instead")
+	fmt.Println("of failing with 429s. A line on stderr says when a limit is learned.")
+	fmt.Println()
+	fmt.Println("Exit Status:")
+	fmt.Println("  0 success, 1 error or pass rate below --min-pass-rate, 2 invalid usage,")
+	fmt.Println("  3 provider not found, 4 model not found, 5 missing API key, 9 model not")
+	fmt.Println("  allowed by --policy")
+	fmt.Println()
+	fmt.Println("Environment Variables:")
+	fmt.Println("  CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)")
+	fmt.Println("  AIMODELS_THEME - Output theme (auto, dark, light, high-contrast, plain) or theme file")
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"charm.land/catwalk/pkg/eval"
+	"charm.land/catwalk/pkg/render"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/term"
+)
+
+// progress is a live line on stderr showing how many of a model's cases
+// are done and failed, with their tokens and cost so far. It draws nothing
+// when stderr is not a terminal.
+type progress struct {
+	model  string
+	total  int
+	done   int
+	failed int
+	tokens int
+	cost   float64
+	live   bool
+}
+
+// newProgress starts a progress line for a model's cases, counting the ones
+// resumed from an earlier run as done.
+func newProgress(model string, total int, resumed map[string]eval.Result) *progress {
+	p := &progress{model: model, total: total, live: term.IsTerminal(os.Stderr.Fd())}
+	for _, r := range resumed {
+		p.count(r)
+	}
+	p.draw()
+	return p
+}
+
+// add counts a finished case and redraws the line. Calls are serialized by
+// eval.Options.OnResult.
+func (p *progress) add(r eval.Result) {
+	p.count(r)
+	p.draw()
+}
+
+func (p *progress) count(r eval.Result) {
+	p.done++
+	if !r.Passed {
+		p.failed++
+	}
+	p.tokens += r.InputTokens + r.OutputTokens
+	p.cost += r.Cost + r.JudgeCost
+}
+
+func (p *progress) draw() {
+	if !p.live {
+		return
+	}
+	fraction := 0.0
+	if p.total > 0 {
+		fraction = float64(p.done) / float64(p.total)
+	}
+	failed := fmt.Sprintf("%d failed", p.failed)
+	if p.failed > 0 {
+		failed = failStyle.Render(failed)
+	}
+	line := fmt.Sprintf("%s %s %d/%d  %s  %s tokens  %s", modelStyle.Render(truncate(p.model, 24)),
+		infoStyle.Render(render.Bar(fraction, 20)), p.done, p.total, failed,
+		formatTokens(p.tokens), costStyle.Render(fmt.Sprintf("$%.4f", p.cost)))
+	width, _, err := term.GetSize(os.Stderr.Fd())
+	if err != nil || width <= 0 {
+		width = 80
+	}
+	fmt.Fprint(os.Stderr, "\r"+ansi.Truncate(line, width-1, "…")+"\x1b[K")
+}
+
+// finish ends the progress line.
+func (p *progress) finish() {
+	if p.live {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// formatTokens abbreviates a token count, as in 12.3K
+func formatTokens(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fK", float64(n)/1_000)
+	}
+	return fmt.Sprint(n)
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/eval"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/report"
+)
+
+// modelName returns the display name of a run's model.
+func modelName(r modelRun) string {
+	return string(r.target.Provider.ID) + "/" + r.target.Model.ID
+}
+
+// outputTable shows a summary per model, a case × model pass matrix, and
+// the reasons for each failure
+func outputTable(suite *eval.Suite, runs []modelRun) {
+	fmt.Println()
+	fmt.Println(headerStyle.Render(fmt.Sprintf("Eval: %s (%d cases)", suiteName(suite), len(suite.Cases))))
+	fmt.Println(borderStyle.Render(render.DoubleRule(96)))
+	fmt.Printf("%-36s %8s %9s %10s %10s %10s %10s\n",
+		"Model", "Passed", "Pass rate", "Mean lat.", "P95 lat.", "Cost", "Judge")
+	fmt.Println(borderStyle.Render(render.Rule(96)))
+	for _, r := range runs {
+		s := r.summary
+		rate := fmt.Sprintf("%9s", fmt.Sprintf("%.0f%%", s.PassRate()*100))
+		if s.Passed == s.Cases {
+			rate = passStyle.Render(rate)
+		} else {
+			rate = failStyle.Render(rate)
+		}
+		name := modelName(r)
+		if r.target.Batch != nil {
+			name += " (batch)"
+		}
+		fmt.Printf("%s %8s %s %10s %10s %s %10s\n",
+			modelStyle.Render(fmt.Sprintf("%-36s", truncate(name, 36))),
+			fmt.Sprintf("%d/%d", s.Passed, s.Cases),
+			rate,
+			formatLatency(s.MeanLatency),
+			formatLatency(s.P95Latency),
+			costStyle.Render(fmt.Sprintf("%10s", fmt.Sprintf("$%.4f", s.Cost))),
+			fmt.Sprintf("$%.4f", s.JudgeCost))
+	}
+
+	// Case matrix, one column per model
+	const colWidth = 12
+	fmt.Println()
+	fmt.Println(headerStyle.Render("Cases"))
+	fmt.Printf("%-24s", "Case")
+	for i := range runs {
+		fmt.Printf(" %*s", colWidth, fmt.Sprintf("#%d", i+1))
+	}
+	fmt.Println()
+	fmt.Println(borderStyle.Render(render.Rule(24 + len(runs)*(colWidth+1))))
+	for i, c := range suite.Cases {
+		fmt.Printf("%-24s", truncate(c.Name, 24))
+		for _, r := range runs {
+			res := r.results[i]
+			cell := fmt.Sprintf("%*s", colWidth, render.Symbol("✓", "ok")+" "+formatLatency(res.Latency))
+			switch {
+			case res.Error != "":
+				cell = failStyle.Render(fmt.Sprintf("%*s", colWidth, "error"))
+			case !res.Passed:
+				cell = failStyle.Render(fmt.Sprintf("%*s", colWidth, render.Symbol("✗", "FAIL")))
+			default:
+				cell = passStyle.Render(cell)
+			}
+			fmt.Printf(" %s", cell)
+		}
+		fmt.Println()
+	}
+	for i, r := range runs {
+		fmt.Println(infoStyle.Render(fmt.Sprintf("#%d %s", i+1, modelName(r))))
+	}
+
+	// Why cases failed
+	var failures []string
+	for _, r := range runs {
+		for _, res := range r.results {
+			reasons := res.Failures
+			if res.Error != "" {
+				reasons = []string{"request failed: " + res.Error}
+			}
+			for _, reason := range reasons {
+				failures = append(failures, fmt.Sprintf("  %s %s / %s: %s",
+					failStyle.Render(render.Symbol("✗", "x")), modelName(r), res.Case, reason))
+			}
+		}
+	}
+	if len(failures) > 0 {
+		fmt.Println()
+		fmt.Println(headerStyle.Render("Failures"))
+		fmt.Println(strings.Join(failures, "\n"))
+	}
+	fmt.Println()
+}
+
+// jsonRun is the JSON form of a model's results.
+type jsonRun struct {
+	Provider    string       `json:"provider"`
+	Model       string       `json:"model"`
+	Cases       int          `json:"cases"`
+	Passed      int          `json:"passed"`
+	Errors      int          `json:"errors"`
+	PassRate    float64      `json:"pass_rate"`
+	MeanLatency float64      `json:"mean_latency_ms"`
+	P95Latency  float64      `json:"p95_latency_ms"`
+	Cost        float64      `json:"cost"`
+	JudgeCost   float64      `json:"judge_cost"`
+	Results     []jsonResult `json:"results"`
+}
+
+type jsonResult struct {
+	Case         string   `json:"case"`
+	Passed       bool     `json:"passed"`
+	Failures     []string `json:"failures,omitempty"`
+	Error        string   `json:"error,omitempty"`
+	Reply        string   `json:"reply"`
+	LatencyMS    float64  `json:"latency_ms"`
+	Batched      bool     `json:"batched,omitempty"`
+	InputTokens  int      `json:"input_tokens"`
+	OutputTokens int      `json:"output_tokens"`
+	Cost         float64  `json:"cost"`
+	JudgeCost    float64  `json:"judge_cost,omitempty"`
+}
+
+// outputJSON writes the full results, including replies, as JSON
+func outputJSON(suite *eval.Suite, runs []modelRun) error {
+	out := struct {
+		Suite string    `json:"suite"`
+		Runs  []jsonRun `json:"runs"`
+	}{Suite: suiteName(suite)}
+	for _, r := range runs {
+		s := r.summary
+		jr := jsonRun{
+			Provider:    string(r.target.Provider.ID),
+			Model:       r.target.Model.ID,
+			Cases:       s.Cases,
+			Passed:      s.Passed,
+			Errors:      s.Errors,
+			PassRate:    s.PassRate(),
+			MeanLatency: ms(s.MeanLatency),
+			P95Latency:  ms(s.P95Latency),
+			Cost:        s.Cost,
+			JudgeCost:   s.JudgeCost,
+		}
+		for _, res := range r.results {
+			jr.Results = append(jr.Results, jsonResult{
+				Case:         res.Case,
+				Passed:       res.Passed,
+				Failures:     res.Failures,
+				Error:        res.Error,
+				Reply:        res.Reply,
+				LatencyMS:    ms(res.Latency),
+				Batched:      res.Batched,
+				InputTokens:  res.InputTokens,
+				OutputTokens: res.OutputTokens,
+				Cost:         res.Cost,
+				JudgeCost:    res.JudgeCost,
+			})
+		}
+		out.Runs = append(out.Runs, jr)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out) //nolint:wrapcheck
+}
+
+// outputCSV writes one row per model and case
+func outputCSV(runs []modelRun) error {
+	writer := csv.NewWriter(os.Stdout)
+	header := []string{"provider", "model", "case", "passed", "failures", "latency_ms",
+		"input_tokens", "output_tokens", "cost", "judge_cost"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	for _, r := range runs {
+		for _, res := range r.results {
+			reasons := res.Failures
+			if res.Error != "" {
+				reasons = []string{"request failed: " + res.Error}
+			}
+			record := []string{
+				string(r.target.Provider.ID),
+				r.target.Model.ID,
+				res.Case,
+				strconv.FormatBool(res.Passed),
+				strings.Join(reasons, "; "),
+				strconv.FormatFloat(ms(res.Latency), 'f', 0, 64),
+				strconv.Itoa(res.InputTokens),
+				strconv.Itoa(res.OutputTokens),
+				strconv.FormatFloat(res.Cost, 'f', 6, 64),
+				strconv.FormatFloat(res.JudgeCost, 'f', 6, 64),
+			}
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("failed to write CSV: %w", err)
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error() //nolint:wrapcheck
+}
+
+// outputHTML writes a standalone comparison report
+func outputHTML(suite *eval.Suite, runs []modelRun) error {
+	summary := report.Table{
+		Title:   "Models",
+		Columns: []string{"Model", "Passed", "Pass rate", "Mean latency", "P95 latency", "Cost", "Judge cost"},
+	}
+	rates := report.Chart{Title: "Pass Rate", Format: "%.0f%%"}
+	costs := report.Chart{Title: "Cost per Suite Run", Format: "$%.4f"}
+	cases := report.Table{Title: "Cases", Columns: []string{"Case"}}
+	var labels []string
+	var models []catwalk.Model
+	for _, r := range runs {
+		s := r.summary
+		summary.AddRow(report.Text(modelName(r)), report.Int(int64(s.Passed)),
+			report.Number("%.0f%%", s.PassRate()*100),
+			report.Number("%.2fs", s.MeanLatency.Seconds()), report.Number("%.2fs", s.P95Latency.Seconds()),
+			report.USD(s.Cost), report.USD(s.JudgeCost))
+		rates.Bars = append(rates.Bars, report.Bar{Label: modelName(r), Value: s.PassRate() * 100})
+		costs.Bars = append(costs.Bars, report.Bar{Label: modelName(r), Value: s.Cost})
+		cases.Columns = append(cases.Columns, modelName(r))
+		labels = append(labels, modelName(r))
+		models = append(models, r.target.Model)
+	}
+	for i, c := range suite.Cases {
+		cells := []report.Cell{report.Text(c.Name)}
+		for _, r := range runs {
+			res := r.results[i]
+			text := "pass"
+			switch {
+			case res.Error != "":
+				text = "error: " + res.Error
+			case !res.Passed:
+				text = "fail: " + strings.Join(res.Failures, "; ")
+			}
+			cells = append(cells, report.Text(text))
+		}
+		cases.AddRow(cells...)
+	}
+
+	return report.Report{ //nolint:wrapcheck
+		Title:        "Eval: " + suiteName(suite),
+		Notes:        []string{fmt.Sprintf("%d cases; cost from catalog prices", len(suite.Cases))},
+		Tables:       []report.Table{summary, cases},
+		Charts:       []report.Chart{rates, costs},
+		Capabilities: report.Capabilities(labels, models),
+	}.WriteHTML(os.Stdout)
+}
+
+func suiteName(suite *eval.Suite) string {
+	if suite.Name != "" {
+		return suite.Name
+	}
+	return *suiteFile
+}
+
+func formatLatency(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2fs", d.Seconds())
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// truncate shortens s to n runes, marking the cut with "..."
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
@@ -0,0 +1,332 @@
+// Package main provides extract, which pulls structured data out of a
+// document: given a JSON schema, it picks a model that supports structured
+// output (or uses --model), asks it for JSON matching the schema, validates
+// the reply and sends back what is wrong until it is valid, and prints the
+// JSON with what the extraction cost.
+//
+// Usage:
+//
+//	extract --schema invoice.json --input invoice.txt
+//	extract --schema invoice.json --model openai/gpt-4o-mini < invoice.txt
+//	extract --schema person.json --input bio.md --instructions "Extract the author, not the people quoted"
+//	extract --schema invoice.json --input invoice.txt --envelope | jq .cost
+//	extract --schema invoice.json --input invoice.txt --model groq/llama-3.3-70b-versatile --prompt-only
+//
+// Environment Variables:
+//
+//	CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)
+//	<PROVIDER>_API_KEY - API keys, as named by each provider in the catalog
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"charm.land/catwalk/internal/cli"
+	"charm.land/catwalk/pkg/auth"
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/extract"
+	"charm.land/catwalk/pkg/registry"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/snapshot"
+	"charm.land/catwalk/pkg/tokenizer"
+	"charm.land/catwalk/pkg/transport"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	schemaFile     = flag.String("schema", "", "JSON Schema the output must match (required)")
+	inputFile      = flag.String("input", "-", "Document to extract from, or - for stdin")
+	modelName      = flag.String("model", "", "Model to use, as provider/model or model (default: the cheapest with structured output and a key)")
+	instructions   = flag.String("instructions", "", "What to extract, when the schema alone does not say")
+	retries        = flag.Int("retries", extract.DefaultRetries, "Times an invalid reply is sent back with its problems")
+	promptOnly     = flag.Bool("prompt-only", false, "Send the schema in the prompt only, for providers that reject a response format")
+	maxTokens      = flag.Int("max-tokens", 0, "Max tokens per reply (0 = model default)")
+	envelope       = flag.Bool("envelope", false, "Print a JSON object with the data, model, attempts, tokens, and cost")
+	timeout        = flag.Duration("timeout", 5*time.Minute, "Timeout for the whole extraction")
+	catalogVersion = flag.String("catalog-version", "", "Use a stored catalog snapshot (ETag, YYYY-MM-DD, or latest) instead of live data")
+	overridesFile  = flag.String("overrides", "", "Pricing overrides and probe results, or none (default: the aimodels overrides.yaml, if present)")
+	network        = transport.RegisterFlags(flag.CommandLine)
+	showHelp       = flag.Bool("help", false, "Show help message")
+)
+
+// failStyle shows errors and validation problems; the rest of the output
+// uses the shared cli styles
+var failStyle = lipgloss.NewStyle().Foreground(cli.Colors.Error)
+
+// expectedOutputTokens is the reply size assumed when choosing the cheapest
+// model, since it is not known before the model answers.
+const expectedOutputTokens = 1000
+
+// output is what --envelope prints.
+type output struct {
+	Data         json.RawMessage `json:"data,omitempty"`
+	Valid        bool            `json:"valid"`
+	Problems     []string        `json:"problems,omitempty"`
+	Provider     string          `json:"provider"`
+	Model        string          `json:"model"`
+	Attempts     int             `json:"attempts"`
+	InputTokens  int             `json:"input_tokens"`
+	OutputTokens int             `json:"output_tokens"`
+	Cost         float64         `json:"cost"`
+}
+
+func main() {
+	render.SetupConsole()
+	flag.Parse()
+
+	if *showHelp {
+		printHelp()
+		return
+	}
+	if *schemaFile == "" || flag.NArg() > 0 {
+		printHelp()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, failStyle.Render("Error: "+err.Error()))
+		os.Exit(catwalk.ExitCode(err))
+	}
+}
+
+func run(ctx context.Context) error {
+	schema, err := extract.LoadSchema(*schemaFile)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	document, err := readInput(*inputFile)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(document) == "" {
+		return errors.New("the input document is empty")
+	}
+
+	httpClient, err := network.Client()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	providers, err := snapshot.Fetch(ctx, catwalk.NewWithHTTPClient(httpClient), *catalogVersion)
+	if err != nil {
+		return fmt.Errorf("failed to fetch providers: %w", err)
+	}
+	var overrides *registry.Overrides
+	if *overridesFile != "none" {
+		if overrides, err = registry.Open(*overridesFile); err != nil {
+			return err //nolint:wrapcheck
+		}
+		providers = overrides.Apply(providers)
+	}
+	base, err := network.Transport()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	var target extract.Target
+	if *modelName != "" {
+		var resolved chat.Target
+		resolved, err = chat.Resolve(providers, *modelName, base)
+		target = extract.Target(resolved)
+	} else {
+		target, err = pickTarget(providers, overrides, schema, document, base)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, cli.MutedStyle.Render(fmt.Sprintf("Extracting with %s/%s...", target.Provider.ID, target.Model.ID)))
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	opts := extract.Options{
+		Instructions: *instructions,
+		Retries:      *retries,
+		PromptOnly:   *promptOnly,
+		MaxTokens:    *maxTokens,
+		Overrides:    overrides,
+	}
+	if opts.Retries == 0 {
+		// Zero means the default to Extract
+		opts.Retries = -1
+	}
+	result, err := extract.Extract(ctx, target, schema, document, opts)
+	if errors.Is(err, catwalk.ErrUnsupportedFeature) {
+		return fmt.Errorf("%w, or set --prompt-only", err)
+	}
+	if err != nil && !errors.Is(err, extract.ErrInvalid) {
+		return err //nolint:wrapcheck
+	}
+
+	if *envelope {
+		out := output{
+			Valid:        result.Valid(),
+			Problems:     result.Problems,
+			Provider:     string(target.Provider.ID),
+			Model:        target.Model.ID,
+			Attempts:     result.Attempts,
+			InputTokens:  result.InputTokens,
+			OutputTokens: result.OutputTokens,
+			Cost:         result.Cost,
+		}
+		if json.Valid([]byte(result.Data)) {
+			out.Data = json.RawMessage(result.Data)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(out); encErr != nil {
+			return fmt.Errorf("failed to write output: %w", encErr)
+		}
+	} else if result.Valid() {
+		fmt.Println(result.Data)
+	}
+
+	fmt.Fprintln(os.Stderr, cli.CostStyle.Render(fmt.Sprintf("%d attempt%s, %d input + %d output tokens, $%.6f",
+		result.Attempts, plural(result.Attempts), result.InputTokens, result.OutputTokens, result.Cost)))
+	if len(result.Problems) > 1 {
+		for _, p := range result.Problems[1:] {
+			fmt.Fprintln(os.Stderr, failStyle.Render("  "+p))
+		}
+	}
+	return err //nolint:wrapcheck
+}
+
+// readInput reads the document from a file, or from stdin for "-".
+func readInput(path string) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return string(data), nil
+}
+
+// pickTarget chooses the cheapest model that supports structured output,
+// has an API key set, and fits the document in its context window. Models
+// without a listed price are tried last, since their cost is unknown.
+func pickTarget(providers []catwalk.Provider, overrides *registry.Overrides, schema *extract.Schema, document string, base http.RoundTripper) (extract.Target, error) {
+	prompt := int64(tokenizer.Count(string(schema.JSON())) + tokenizer.CountMessage(chat.RoleUser, *instructions+document))
+
+	type candidate struct {
+		provider *catwalk.Provider
+		model    *catwalk.Model
+		key      string
+		cost     float64
+	}
+	var candidates []candidate
+	for i := range providers {
+		p := &providers[i]
+		key, err := p.ResolveAPIKey()
+		if err != nil && auth.For(*p).NeedsKey() {
+			continue
+		}
+		for j := range p.Models {
+			m := &p.Models[j]
+			if !extract.Supports(overrides, *p, *m) {
+				continue
+			}
+			if m.ContextWindow > 0 && prompt+expectedOutputTokens > m.ContextWindow {
+				continue
+			}
+			cost := chat.Cost(*m, int(prompt), expectedOutputTokens)
+			candidates = append(candidates, candidate{p, m, key, cost})
+		}
+	}
+	if len(candidates) == 0 {
+		return extract.Target{}, errors.New("no model with structured output has an API key set and room for the document; set a provider's API key or use --model")
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		if (a.cost == 0) != (b.cost == 0) {
+			if a.cost == 0 {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(a.cost, b.cost)
+	})
+	c := candidates[0]
+	return extract.Target{Client: chat.NewClient(*c.provider, c.key, base), Provider: *c.provider, Model: *c.model}, nil
+}
+
+// plural returns "s" unless n is 1
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// printHelp displays usage information
+func printHelp() {
+	fmt.Println("extract - Extract JSON matching a schema from a document")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  extract --schema <file> [--input <file>] [options]")
+	fmt.Println()
+	fmt.Println("The model is asked for JSON matching the schema, sent as the request's")
+	fmt.Println("response format unless --prompt-only is set. Each reply is validated against")
+	fmt.Println("the schema, and what is wrong with an invalid one is sent back for another")
+	fmt.Println("attempt. The JSON goes to stdout; the model, attempts, tokens, and cost go to")
+	fmt.Println("stderr, or into the output with --envelope.")
+	fmt.Println()
+	fmt.Println("Without --model, the cheapest model known to support structured output is")
+	fmt.Println("used among providers with an API key set: OpenAI and Azure models, and any")
+	fmt.Println("model cmd/probe recorded a working json_schema probe for. Cost is estimated")
+	fmt.Println("from the document's tokens and a 1,000-token reply.")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --schema <file>         JSON Schema the output must match (required)")
+	fmt.Println("  --input <file>          Document to extract from (default: stdin)")
+	fmt.Println("  --model <name>          Model to use, as provider/model or a model ID")
+	fmt.Println("  --instructions <text>   What to extract, when the schema alone does not say")
+	fmt.Println("  --retries <n>           Times an invalid reply is sent back (default: 2)")
+	fmt.Println("  --prompt-only           Send the schema in the prompt only, for providers that")
+	fmt.Println("                          reject a JSON schema response format")
+	fmt.Println("  --max-tokens <n>        Max tokens per reply (default: the model's)")
+	fmt.Println("  --envelope              Print {data, valid, problems, provider, model, attempts,")
+	fmt.Println("                          input_tokens, output_tokens, cost} instead of the data")
+	fmt.Println("  --timeout <d>           Timeout for the whole extraction (default: 5m)")
+	fmt.Println("  --catalog-version <v>   Use a stored catalog snapshot")
+	fmt.Println("  --overrides <file>      Negotiated prices and probe results, or none")
+	fmt.Println("                          (default: the aimodels overrides.yaml, if present)")
+	fmt.Println()
+	cli.PrintNetworkHelp()
+	fmt.Println("Validated Schema Keywords:")
+	fmt.Println("  type, enum, const, properties, required, additionalProperties, items,")
+	fmt.Println("  minItems, maxItems, minLength, maxLength, pattern, minimum, maximum, anyOf.")
+	fmt.Println("  Others are sent to the model but not checked.")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  extract --schema invoice.json --input invoice.txt")
+	fmt.Println("  extract --schema invoice.json --model openai/gpt-4o-mini < invoice.txt")
+	fmt.Println("  extract --schema person.json --input bio.md --instructions \"Extract the author\"")
+	fmt.Println("  extract --schema invoice.json --input invoice.txt --envelope | jq .cost")
+	fmt.Println()
+	fmt.Println("Exit Status:")
+	fmt.Println("  0 success, 1 error or no valid reply, 2 invalid usage, 3 provider not found,")
+	fmt.Println("  4 model not found, 5 missing API key, 10 unsupported feature")
+	fmt.Println()
+	fmt.Println("Environment Variables:")
+	fmt.Println("  CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)")
+	fmt.Println("  AIMODELS_THEME - Output theme (auto, dark, light, high-contrast, plain) or theme file")
+}
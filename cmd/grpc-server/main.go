@@ -0,0 +1,241 @@
+// Package main provides grpc-server, which serves the catwalk catalog, model
+// selection, and cost estimates over gRPC, so services in any language can
+// use the same selection and pricing logic as the Go packages.
+//
+// The service is defined in proto/catwalk/v1/catalog.proto:
+//
+//	ListProviders  List providers, optionally filtered by type
+//	FindModels     Models matching a query expression and requirements
+//	EstimateCost   Price a workload on one or more models
+//
+// The standard grpc.health.v1.Health/Check service is also served. Only
+// unary calls without compression are supported, which is all the service
+// needs; the server speaks the gRPC wire protocol over HTTP/2 directly, with
+// cleartext (h2c) unless --tls-cert and --tls-key are given.
+//
+// Usage:
+//
+//	grpc-server --addr :50051
+//	grpc-server --addr :50051 --tls-cert server.pem --tls-key server.key
+//	grpcurl -plaintext -import-path proto -proto catwalk/v1/catalog.proto \
+//	  -d '{"models": ["gpt-4o"], "input_tokens": 10000, "output_tokens": 2000}' \
+//	  localhost:50051 catwalk.v1.Catalog/EstimateCost
+//
+// Environment Variables:
+//
+//	CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/snapshot"
+	"charm.land/catwalk/pkg/transport"
+)
+
+var (
+	addr           = flag.String("addr", ":50051", "Address to listen on")
+	tlsCert        = flag.String("tls-cert", "", "PEM certificate to serve TLS with (default: cleartext HTTP/2)")
+	tlsKey         = flag.String("tls-key", "", "PEM private key for --tls-cert")
+	catalogVersion = flag.String("catalog-version", "", "Serve a stored catalog snapshot (ETag, YYYY-MM-DD, or latest) instead of live data")
+	network        = transport.RegisterFlags(flag.CommandLine)
+)
+
+// gRPC status codes.
+const (
+	codeOK                = 0
+	codeInvalidArgument   = 3
+	codeNotFound          = 5
+	codeResourceExhausted = 8
+	codeUnimplemented     = 12
+	codeInternal          = 13
+)
+
+// maxMessageSize is the largest request accepted, matching the default of
+// gRPC servers.
+const maxMessageSize = 4 << 20
+
+// status is an RPC error with a gRPC status code.
+type status struct {
+	code    int
+	message string
+}
+
+func (s *status) Error() string { return s.message }
+
+func statusf(code int, format string, args ...any) error {
+	return &status{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+func invalidArgument(err error) error {
+	return &status{code: codeInvalidArgument, message: err.Error()}
+}
+
+// statusOf maps an RPC's error to a status. Catalog lookups fail with
+// NOT_FOUND; anything else is an internal error.
+func statusOf(err error) *status {
+	var s *status
+	switch {
+	case errors.As(err, &s):
+		return s
+	case errors.Is(err, catwalk.ErrProviderNotFound), errors.Is(err, catwalk.ErrModelNotFound):
+		return &status{code: codeNotFound, message: err.Error()}
+	default:
+		return &status{code: codeInternal, message: err.Error()}
+	}
+}
+
+func main() {
+	flag.Parse()
+	log.SetPrefix("grpc-server: ")
+	if (*tlsCert == "") != (*tlsKey == "") {
+		log.Fatal("Error: --tls-cert and --tls-key must be set together")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	httpClient, err := network.Client()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	providers, err := snapshot.Fetch(ctx, catwalk.NewWithHTTPClient(httpClient), *catalogVersion)
+	if err != nil {
+		log.Fatalf("Error fetching providers: %v", err)
+	}
+
+	// gRPC clients open cleartext connections with the HTTP/2 preface
+	// directly, so h2c must be enabled alongside HTTP/1 and TLS HTTP/2
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           &server{providers: providers},
+		Protocols:         &protocols,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown) //nolint:errcheck
+	}()
+
+	log.Printf("serving %d providers on %s", len(providers), *addr)
+	if *tlsCert != "" {
+		err = srv.ListenAndServeTLS(*tlsCert, *tlsKey)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+// server answers unary gRPC calls against a provider catalog.
+type server struct {
+	providers []catwalk.Provider
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if r.ProtoMajor != 2 || r.Method != http.MethodPost ||
+		(contentType != "application/grpc" && contentType != "application/grpc+proto") {
+		http.Error(w, "This is a gRPC server; see proto/catwalk/v1/catalog.proto.", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// The status goes in trailers, after the response message
+	w.Header().Set("Content-Type", "application/grpc")
+	w.WriteHeader(http.StatusOK)
+
+	handle, ok := methods[r.URL.Path]
+	if !ok {
+		writeStatus(w, &status{code: codeUnimplemented, message: "unknown method " + r.URL.Path})
+		return
+	}
+	req, err := readMessage(r.Body)
+	if err != nil {
+		writeStatus(w, statusOf(err))
+		return
+	}
+	resp, err := handle(s.providers, req)
+	if err != nil {
+		writeStatus(w, statusOf(err))
+		return
+	}
+	if err := writeMessage(w, resp); err != nil {
+		log.Printf("%s: %v", r.URL.Path, err)
+		return
+	}
+	writeStatus(w, &status{code: codeOK})
+}
+
+// readMessage reads the single length-prefixed message of a unary call.
+func readMessage(r io.Reader) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, invalidArgument(fmt.Errorf("failed to read request: %w", err))
+	}
+	if prefix[0] != 0 {
+		return nil, statusf(codeUnimplemented, "compressed requests are not supported")
+	}
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > maxMessageSize {
+		return nil, statusf(codeResourceExhausted, "request of %d bytes exceeds the %d byte limit", size, maxMessageSize)
+	}
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, invalidArgument(fmt.Errorf("failed to read request: %w", err))
+	}
+	return msg, nil
+}
+
+// writeMessage writes a length-prefixed, uncompressed message.
+func writeMessage(w io.Writer, msg []byte) error {
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg))) //nolint:gosec
+	if _, err := w.Write(append(frame, msg...)); err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
+	}
+	return nil
+}
+
+// writeStatus sets the grpc-status and grpc-message trailers.
+func writeStatus(w http.ResponseWriter, s *status) {
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(s.code))
+	if s.message != "" {
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", percentEncode(s.message))
+	}
+}
+
+// percentEncode escapes a status message as gRPC requires: bytes outside
+// printable ASCII, and '%' itself, become %XX.
+func percentEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
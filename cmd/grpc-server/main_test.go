@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The field numbers below are those of proto/catwalk/v1/catalog.proto, so
+// the tests catch the codec drifting from the schema.
+
+func TestUnmarshalListProvidersRequest(t *testing.T) {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendString(b, "openai")
+
+	var got listProvidersRequest
+	if err := got.unmarshal(b); err != nil {
+		t.Fatal(err)
+	}
+	if want := (listProvidersRequest{Type: "openai"}); got != want {
+		t.Errorf("unmarshal = %+v, want %+v", got, want)
+	}
+}
+
+func TestUnmarshalFindModelsRequest(t *testing.T) {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendString(b, "cost_in < 1")
+	for _, p := range []string{"openai", "anthropic"} {
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendString(b, p)
+	}
+	b = protowire.AppendTag(b, 3, protowire.VarintType)
+	b = protowire.AppendVarint(b, 128_000)
+	b = protowire.AppendTag(b, 4, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, math.Float64bits(2.5))
+	for _, num := range []protowire.Number{5, 6, 7} {
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	b = protowire.AppendTag(b, 8, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(sortContext))
+	b = protowire.AppendTag(b, 9, protowire.VarintType)
+	b = protowire.AppendVarint(b, 10)
+	// Unknown fields are skipped
+	b = protowire.AppendTag(b, 99, protowire.BytesType)
+	b = protowire.AppendString(b, "future")
+
+	var got findModelsRequest
+	if err := got.unmarshal(b); err != nil {
+		t.Fatal(err)
+	}
+	want := findModelsRequest{
+		Query:          "cost_in < 1",
+		Providers:      []string{"openai", "anthropic"},
+		MinContext:     128_000,
+		MaxCostPer1MIn: 2.5,
+		Reasoning:      true,
+		Vision:         true,
+		AllowFree:      true,
+		Sort:           sortContext,
+		Limit:          10,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unmarshal = %+v, want %+v", got, want)
+	}
+}
+
+func TestUnmarshalEstimateCostRequest(t *testing.T) {
+	var b []byte
+	for _, m := range []string{"gpt-4o", "claude-sonnet-4"} {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendString(b, m)
+	}
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendString(b, "openai")
+	for num, n := range map[protowire.Number]uint64{3: 10_000, 4: 2_000, 5: 500, 6: 1_000} {
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		b = protowire.AppendVarint(b, n)
+	}
+
+	var got estimateCostRequest
+	if err := got.unmarshal(b); err != nil {
+		t.Fatal(err)
+	}
+	want := estimateCostRequest{
+		Models:            []string{"gpt-4o", "claude-sonnet-4"},
+		Provider:          "openai",
+		InputTokens:       10_000,
+		CachedInputTokens: 2_000,
+		OutputTokens:      500,
+		CacheWriteTokens:  1_000,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unmarshal = %+v, want %+v", got, want)
+	}
+}
+
+func TestUnmarshalHealthCheckRequest(t *testing.T) {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendString(b, "catwalk.v1.Catalog")
+
+	var got healthCheckRequest
+	if err := got.unmarshal(b); err != nil {
+		t.Fatal(err)
+	}
+	if got.Service != "catwalk.v1.Catalog" {
+		t.Errorf("unmarshal = %+v, want service catwalk.v1.Catalog", got)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"truncated", protowire.AppendTag(nil, 1, protowire.BytesType)},
+		{"wrong wire type", protowire.AppendVarint(protowire.AppendTag(nil, 1, protowire.VarintType), 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req listProvidersRequest
+			if err := req.unmarshal(tt.data); err == nil {
+				t.Errorf("unmarshal(%x) = nil, want an error", tt.data)
+			}
+		})
+	}
+}
+
+func TestMarshalProvider(t *testing.T) {
+	p := catwalk.Provider{
+		ID:                  "openai",
+		Name:                "OpenAI",
+		Type:                catwalk.TypeOpenAI,
+		APIEndpoint:         "https://api.openai.com/v1",
+		DefaultLargeModelID: "gpt-4o",
+		DefaultSmallModelID: "gpt-4o-mini",
+		Models:              []catwalk.Model{{ID: "gpt-4o"}, {ID: "gpt-4o-mini"}},
+	}
+	want := map[protowire.Number][]any{
+		1: {"openai"},
+		2: {"OpenAI"},
+		3: {string(catwalk.TypeOpenAI)},
+		4: {"https://api.openai.com/v1"},
+		5: {"gpt-4o"},
+		6: {"gpt-4o-mini"},
+		7: {uint64(2)},
+	}
+	if got := decodeFields(t, marshalProvider(p)); !reflect.DeepEqual(got, want) {
+		t.Errorf("marshalProvider = %v, want %v", got, want)
+	}
+}
+
+func TestMarshalModel(t *testing.T) {
+	p := catwalk.Provider{ID: "anthropic"}
+	m := catwalk.Model{
+		ID:                 "claude-sonnet-4",
+		Name:               "Claude Sonnet 4",
+		CostPer1MIn:        3,
+		CostPer1MOut:       15,
+		CostPer1MInCached:  3.75,
+		CostPer1MOutCached: 0.3,
+		ContextWindow:      200_000,
+		DefaultMaxTokens:   50_000,
+		CanReason:          true,
+		SupportsImages:     true,
+		ReasoningLevels:    []string{"low", "high"},
+	}
+	want := map[protowire.Number][]any{
+		1:  {"anthropic"},
+		2:  {"claude-sonnet-4"},
+		3:  {"Claude Sonnet 4"},
+		4:  {3.0},
+		5:  {15.0},
+		6:  {3.75},
+		7:  {0.3},
+		8:  {uint64(200_000)},
+		9:  {uint64(50_000)},
+		10: {uint64(1)},
+		11: {uint64(1)},
+		12: {"low", "high"},
+	}
+	if got := decodeFields(t, marshalModel(p, m)); !reflect.DeepEqual(got, want) {
+		t.Errorf("marshalModel = %v, want %v", got, want)
+	}
+
+	// Zero values are omitted
+	if got := marshalModel(catwalk.Provider{}, catwalk.Model{}); len(got) != 0 {
+		t.Errorf("marshalModel of zero values = %x, want nothing", got)
+	}
+}
+
+func TestMarshalCostEstimate(t *testing.T) {
+	e := costEstimate{Provider: "openai", Model: "gpt-4o", TotalCost: 0.045}
+	want := map[protowire.Number][]any{1: {"openai"}, 2: {"gpt-4o"}, 3: {0.045}}
+	if got := decodeFields(t, e.marshal()); !reflect.DeepEqual(got, want) {
+		t.Errorf("marshal = %v, want %v", got, want)
+	}
+}
+
+func TestMarshalRepeated(t *testing.T) {
+	got := decodeFields(t, marshalRepeated([][]byte{[]byte("a"), []byte("bc")}))
+	want := map[protowire.Number][]any{1: {"a", "bc"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("marshalRepeated = %v, want %v", got, want)
+	}
+}
+
+func TestUnaryCall(t *testing.T) {
+	providers := []catwalk.Provider{
+		{ID: "openai", Models: []catwalk.Model{{ID: "gpt-4o", CostPer1MIn: 1, CostPer1MOut: 2}}},
+		{ID: "azure", Models: []catwalk.Model{{ID: "gpt-4o", CostPer1MIn: 2, CostPer1MOut: 4}}},
+	}
+	ts := httptest.NewUnstartedServer(&server{providers: providers})
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+
+	var req []byte
+	req = protowire.AppendTag(req, 1, protowire.BytesType)
+	req = protowire.AppendString(req, "gpt-4o")
+	req = appendInt(req, 3, 1_000_000)
+	req = appendInt(req, 5, 1_000_000)
+
+	resp, msg := call(t, ts, "/catwalk.v1.Catalog/EstimateCost", req)
+	if got := resp.Trailer.Get("Grpc-Status"); got != "0" {
+		t.Fatalf("grpc-status = %q (%s), want 0", got, resp.Trailer.Get("Grpc-Message"))
+	}
+	estimates := decodeFields(t, msg)[1]
+	if len(estimates) != 2 {
+		t.Fatalf("got %d estimates, want 2", len(estimates))
+	}
+	// Cheapest first
+	for i, want := range []map[protowire.Number][]any{
+		{1: {"openai"}, 2: {"gpt-4o"}, 3: {3.0}},
+		{1: {"azure"}, 2: {"gpt-4o"}, 3: {6.0}},
+	} {
+		if got := decodeFields(t, []byte(estimates[i].(string))); !reflect.DeepEqual(got, want) {
+			t.Errorf("estimate %d = %v, want %v", i, got, want)
+		}
+	}
+
+	tests := []struct {
+		name string
+		path string
+		req  []byte
+		want string
+	}{
+		{"unknown model", "/catwalk.v1.Catalog/EstimateCost", protowire.AppendString(protowire.AppendTag(nil, 1, protowire.BytesType), "gpt-5x"), "5"},
+		{"no models", "/catwalk.v1.Catalog/EstimateCost", nil, "3"},
+		{"unknown method", "/catwalk.v1.Catalog/Nope", nil, "12"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := call(t, ts, tt.path, tt.req)
+			if got := resp.Trailer.Get("Grpc-Status"); got != tt.want {
+				t.Errorf("grpc-status = %q (%s), want %s", got, resp.Trailer.Get("Grpc-Message"), tt.want)
+			}
+		})
+	}
+}
+
+// call makes a unary gRPC call over HTTP/2 and returns the response, with
+// its trailers read, and the response message, if any.
+func call(t *testing.T, ts *httptest.Server, path string, msg []byte) (*http.Response, []byte) {
+	t.Helper()
+	var body bytes.Buffer
+	if err := writeMessage(&body, msg); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, ts.URL+path, &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("got %s, want HTTP/2", resp.Proto)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) == 0 {
+		return resp, nil
+	}
+	if len(data) < 5 || data[0] != 0 || int(binary.BigEndian.Uint32(data[1:5])) != len(data)-5 {
+		t.Fatalf("malformed response frame %x", data)
+	}
+	return resp, data[5:]
+}
+
+// decodeFields decodes a message independently of the codec under test,
+// as the values of each field: strings for length-delimited fields, uint64
+// for varints, and float64 for fixed64 fields, which are all doubles here.
+func decodeFields(t *testing.T, b []byte) map[protowire.Number][]any {
+	t.Helper()
+	fields := map[protowire.Number][]any{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatal(protowire.ParseError(n))
+		}
+		b = b[n:]
+		var v any
+		switch typ {
+		case protowire.BytesType:
+			var s []byte
+			s, n = protowire.ConsumeBytes(b)
+			v = string(s)
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			var bits uint64
+			bits, n = protowire.ConsumeFixed64(b)
+			v = math.Float64frombits(bits)
+		default:
+			t.Fatalf("field %d: unexpected wire type %d", num, typ)
+		}
+		if n < 0 {
+			t.Fatal(protowire.ParseError(n))
+		}
+		b = b[n:]
+		fields[num] = append(fields[num], v)
+	}
+	return fields
+}
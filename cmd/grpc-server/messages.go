@@ -0,0 +1,300 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The messages of proto/catwalk/v1/catalog.proto, encoded by hand with
+// protowire so the server needs no generated code. Requests are only
+// decoded and responses only encoded, as a server needs. Field numbers must
+// match the .proto file.
+
+type listProvidersRequest struct {
+	Type string
+}
+
+type findModelsRequest struct {
+	Query          string
+	Providers      []string
+	MinContext     int64
+	MaxCostPer1MIn float64
+	Reasoning      bool
+	Vision         bool
+	AllowFree      bool
+	Sort           sortOrder
+	Limit          int32
+}
+
+// sortOrder is the SortOrder enum.
+type sortOrder int32
+
+const (
+	sortUnspecified sortOrder = iota
+	sortCost
+	sortContext
+	sortName
+)
+
+type estimateCostRequest struct {
+	Models            []string
+	Provider          string
+	InputTokens       int64
+	CachedInputTokens int64
+	OutputTokens      int64
+	CacheWriteTokens  int64
+}
+
+// costEstimate is the CostEstimate message.
+type costEstimate struct {
+	Provider  string
+	Model     string
+	TotalCost float64
+}
+
+// healthCheckRequest is grpc.health.v1.HealthCheckRequest.
+type healthCheckRequest struct {
+	Service string
+}
+
+func (r *listProvidersRequest) unmarshal(b []byte) error {
+	return unmarshalFields(b, func(num protowire.Number, v value) error {
+		if num == 1 {
+			return v.string(&r.Type)
+		}
+		return nil
+	})
+}
+
+func (r *findModelsRequest) unmarshal(b []byte) error {
+	return unmarshalFields(b, func(num protowire.Number, v value) error {
+		switch num {
+		case 1:
+			return v.string(&r.Query)
+		case 2:
+			var s string
+			err := v.string(&s)
+			r.Providers = append(r.Providers, s)
+			return err
+		case 3:
+			return v.int64(&r.MinContext)
+		case 4:
+			return v.double(&r.MaxCostPer1MIn)
+		case 5:
+			return v.bool(&r.Reasoning)
+		case 6:
+			return v.bool(&r.Vision)
+		case 7:
+			return v.bool(&r.AllowFree)
+		case 8:
+			var n int64
+			err := v.int64(&n)
+			r.Sort = sortOrder(n)
+			return err
+		case 9:
+			var n int64
+			err := v.int64(&n)
+			r.Limit = int32(n) //nolint:gosec
+			return err
+		}
+		return nil
+	})
+}
+
+func (r *estimateCostRequest) unmarshal(b []byte) error {
+	return unmarshalFields(b, func(num protowire.Number, v value) error {
+		switch num {
+		case 1:
+			var s string
+			err := v.string(&s)
+			r.Models = append(r.Models, s)
+			return err
+		case 2:
+			return v.string(&r.Provider)
+		case 3:
+			return v.int64(&r.InputTokens)
+		case 4:
+			return v.int64(&r.CachedInputTokens)
+		case 5:
+			return v.int64(&r.OutputTokens)
+		case 6:
+			return v.int64(&r.CacheWriteTokens)
+		}
+		return nil
+	})
+}
+
+func (r *healthCheckRequest) unmarshal(b []byte) error {
+	return unmarshalFields(b, func(num protowire.Number, v value) error {
+		if num == 1 {
+			return v.string(&r.Service)
+		}
+		return nil
+	})
+}
+
+// marshalProvider encodes the Provider message.
+func marshalProvider(p catwalk.Provider) []byte {
+	var b []byte
+	b = appendString(b, 1, string(p.ID))
+	b = appendString(b, 2, p.Name)
+	b = appendString(b, 3, string(p.Type))
+	b = appendString(b, 4, p.APIEndpoint)
+	b = appendString(b, 5, p.DefaultLargeModelID)
+	b = appendString(b, 6, p.DefaultSmallModelID)
+	b = appendInt(b, 7, int64(len(p.Models)))
+	return b
+}
+
+// marshalModel encodes the Model message.
+func marshalModel(p catwalk.Provider, m catwalk.Model) []byte {
+	var b []byte
+	b = appendString(b, 1, string(p.ID))
+	b = appendString(b, 2, m.ID)
+	b = appendString(b, 3, m.Name)
+	b = appendDouble(b, 4, m.CostPer1MIn)
+	b = appendDouble(b, 5, m.CostPer1MOut)
+	b = appendDouble(b, 6, m.CostPer1MInCached)
+	b = appendDouble(b, 7, m.CostPer1MOutCached)
+	b = appendInt(b, 8, m.ContextWindow)
+	b = appendInt(b, 9, m.DefaultMaxTokens)
+	b = appendBool(b, 10, m.CanReason)
+	b = appendBool(b, 11, m.SupportsImages)
+	for _, level := range m.ReasoningLevels {
+		b = protowire.AppendTag(b, 12, protowire.BytesType)
+		b = protowire.AppendString(b, level)
+	}
+	return b
+}
+
+func (e costEstimate) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, e.Provider)
+	b = appendString(b, 2, e.Model)
+	b = appendDouble(b, 3, e.TotalCost)
+	return b
+}
+
+// marshalRepeated encodes a response whose only field, number 1, is a list
+// of already encoded messages.
+func marshalRepeated(messages [][]byte) []byte {
+	var b []byte
+	for _, m := range messages {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, m)
+	}
+	return b
+}
+
+// Fields with the zero value are omitted, as proto3 requires for scalars.
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendInt(b []byte, num protowire.Number, n int64) []byte {
+	if n == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(n)) //nolint:gosec
+}
+
+func appendDouble(b []byte, num protowire.Number, f float64) []byte {
+	if f == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(f))
+}
+
+func appendBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, 1)
+}
+
+// value is a decoded field: a number for varint and fixed64 fields, or the
+// bytes of a length-delimited one.
+type value struct {
+	typ   protowire.Type
+	num   uint64
+	bytes []byte
+}
+
+var errWireType = errors.New("wrong wire type")
+
+func (v value) string(dst *string) error {
+	if v.typ != protowire.BytesType {
+		return errWireType
+	}
+	*dst = string(v.bytes)
+	return nil
+}
+
+func (v value) int64(dst *int64) error {
+	if v.typ != protowire.VarintType {
+		return errWireType
+	}
+	*dst = int64(v.num) //nolint:gosec
+	return nil
+}
+
+func (v value) double(dst *float64) error {
+	if v.typ != protowire.Fixed64Type {
+		return errWireType
+	}
+	*dst = math.Float64frombits(v.num)
+	return nil
+}
+
+func (v value) bool(dst *bool) error {
+	if v.typ != protowire.VarintType {
+		return errWireType
+	}
+	*dst = v.num != 0
+	return nil
+}
+
+// unmarshalFields calls field for each field in b. Unknown field numbers are
+// ignored by the callers, so newer clients can talk to older servers.
+func unmarshalFields(b []byte, field func(protowire.Number, value) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("invalid message: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		v := value{typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			v.num, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			v.num, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			v.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return fmt.Errorf("invalid message: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		if err := field(num, v); err != nil {
+			return fmt.Errorf("invalid message: field %d: %w", num, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/query"
+	"charm.land/catwalk/pkg/selector"
+	"charm.land/catwalk/pkg/usage"
+)
+
+// method is a unary RPC: it decodes a request and returns the encoded
+// response.
+type method func(providers []catwalk.Provider, req []byte) ([]byte, error)
+
+// methods maps gRPC paths to their handlers.
+var methods = map[string]method{
+	"/catwalk.v1.Catalog/ListProviders": listProviders,
+	"/catwalk.v1.Catalog/FindModels":    findModels,
+	"/catwalk.v1.Catalog/EstimateCost":  estimateCost,
+	"/grpc.health.v1.Health/Check":      healthCheck,
+}
+
+func listProviders(providers []catwalk.Provider, data []byte) ([]byte, error) {
+	var req listProvidersRequest
+	if err := req.unmarshal(data); err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	var out [][]byte
+	for _, p := range providers {
+		if req.Type != "" && !strings.EqualFold(string(p.Type), req.Type) {
+			continue
+		}
+		out = append(out, marshalProvider(p))
+	}
+	return marshalRepeated(out), nil
+}
+
+func findModels(providers []catwalk.Provider, data []byte) ([]byte, error) {
+	var req findModelsRequest
+	if err := req.unmarshal(data); err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	var expr *query.Expr
+	if req.Query != "" {
+		var err error
+		if expr, err = query.Parse(req.Query); err != nil {
+			return nil, invalidArgument(err)
+		}
+	}
+	reqs := selector.Requirements{
+		MinContext:     req.MinContext,
+		MaxCostPer1MIn: req.MaxCostPer1MIn,
+		Reasoning:      req.Reasoning,
+		Vision:         req.Vision,
+		AllowFree:      req.AllowFree,
+	}
+	for _, id := range req.Providers {
+		p, err := catwalk.FindProvider(providers, id)
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		reqs.Providers = append(reqs.Providers, p.ID)
+	}
+
+	var matches []selector.Match
+	for _, m := range selector.New(providers).Matching(reqs) {
+		if expr == nil || expr.Match(m.Provider, m.Model) {
+			matches = append(matches, m)
+		}
+	}
+
+	switch req.Sort {
+	case sortUnspecified:
+	case sortCost:
+		sort.SliceStable(matches, func(i, j int) bool {
+			return selector.BlendedCost(matches[i].Model) < selector.BlendedCost(matches[j].Model)
+		})
+	case sortContext:
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].Model.ContextWindow > matches[j].Model.ContextWindow
+		})
+	case sortName:
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].Model.Name < matches[j].Model.Name })
+	default:
+		return nil, statusf(codeInvalidArgument, "unknown sort order %d", req.Sort)
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = 50
+	}
+	out := make([][]byte, 0, min(len(matches), limit))
+	for _, m := range matches[:min(len(matches), limit)] {
+		out = append(out, marshalModel(m.Provider, m.Model))
+	}
+	return marshalRepeated(out), nil
+}
+
+func estimateCost(providers []catwalk.Provider, data []byte) ([]byte, error) {
+	var req estimateCostRequest
+	if err := req.unmarshal(data); err != nil {
+		return nil, invalidArgument(err)
+	}
+	switch {
+	case len(req.Models) == 0:
+		return nil, invalidArgument(errors.New("models is required"))
+	case req.InputTokens < 0 || req.CachedInputTokens < 0 || req.OutputTokens < 0 || req.CacheWriteTokens < 0:
+		return nil, invalidArgument(errors.New("token counts cannot be negative"))
+	case req.CachedInputTokens+req.CacheWriteTokens > req.InputTokens:
+		return nil, invalidArgument(errors.New("cached_input_tokens plus cache_write_tokens cannot exceed input_tokens"))
+	}
+
+	var estimates []costEstimate
+	for _, id := range req.Models {
+		matches, err := selector.Offers(providers, id, req.Provider)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			estimates = append(estimates, costEstimate{
+				Provider: string(m.Provider.ID),
+				Model:    m.Model.ID,
+				TotalCost: usage.Cost(m.Model, usage.Record{
+					InputTokens:      req.InputTokens - req.CachedInputTokens - req.CacheWriteTokens,
+					CacheReadTokens:  req.CachedInputTokens,
+					CacheWriteTokens: req.CacheWriteTokens,
+					OutputTokens:     req.OutputTokens,
+				}),
+			})
+		}
+	}
+	sort.SliceStable(estimates, func(i, j int) bool { return estimates[i].TotalCost < estimates[j].TotalCost })
+
+	out := make([][]byte, len(estimates))
+	for i, e := range estimates {
+		out[i] = e.marshal()
+	}
+	return marshalRepeated(out), nil
+}
+
+// healthCheck implements the standard gRPC health service, so load balancers
+// and orchestrators can probe the server. It is serving once the catalog is
+// loaded, which happens before the server starts listening.
+func healthCheck(_ []catwalk.Provider, data []byte) ([]byte, error) {
+	var req healthCheckRequest
+	if err := req.unmarshal(data); err != nil {
+		return nil, invalidArgument(err)
+	}
+	if req.Service != "" && req.Service != "catwalk.v1.Catalog" {
+		return nil, statusf(codeNotFound, "unknown service %s", req.Service)
+	}
+	const serving = 1
+	return appendInt(nil, 1, serving), nil
+}
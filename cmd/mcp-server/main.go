@@ -0,0 +1,263 @@
+// Package main provides mcp-server, a Model Context Protocol server that
+// exposes the catwalk catalog and cost calculations to agents and editors.
+//
+// The server speaks JSON-RPC 2.0 over stdio (one message per line), so it
+// can be registered with any MCP client, e.g. in Claude Desktop:
+//
+//	{"mcpServers": {"catwalk": {"command": "mcp-server"}}}
+//
+// Tools:
+//
+//	list_providers  List providers, optionally filtered by type
+//	list_models     List models, filtered by provider or a query expression
+//	get_model       Show one model's pricing and capabilities
+//	find_cheapest   Find the cheapest model meeting context/capability/price limits
+//	calculate_cost  Estimate the cost of a workload on one or more models
+//
+// Usage:
+//
+//	mcp-server [--catalog-version <v>] [--proxy <url>] [--ca-cert <pem>]
+//
+// Environment Variables:
+//
+//	CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/snapshot"
+	"charm.land/catwalk/pkg/transport"
+)
+
+var (
+	catalogVersion = flag.String("catalog-version", "", "Serve a stored catalog snapshot (ETag, YYYY-MM-DD, or latest) instead of live data")
+	network        = transport.RegisterFlags(flag.CommandLine)
+)
+
+// Protocol versions this server implements, newest first.
+var protocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+func main() {
+	flag.Parse()
+
+	// stdout carries the protocol, so all logging goes to stderr
+	log.SetOutput(os.Stderr)
+	log.SetPrefix("mcp-server: ")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	httpClient, err := network.Client()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	providers, err := snapshot.Fetch(ctx, catwalk.NewWithHTTPClient(httpClient), *catalogVersion)
+	if err != nil {
+		log.Fatalf("Error fetching providers: %v", err)
+	}
+	log.Printf("serving %d providers", len(providers))
+
+	if err := serve(ctx, os.Stdin, os.Stdout, newServer(providers)); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+// serve reads requests from r and writes responses to w until r is closed
+// or ctx is cancelled.
+func serve(ctx context.Context, r io.Reader, w io.Writer, s *server) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	encoder := json.NewEncoder(w)
+
+	lines := make(chan []byte)
+	go func() {
+		defer close(lines)
+		for scanner.Scan() {
+			lines <- append([]byte(nil), scanner.Bytes()...)
+		}
+	}()
+
+	for {
+		var line []byte
+		select {
+		case <-ctx.Done():
+			return nil
+		case l, ok := <-lines:
+			if !ok {
+				return scanner.Err()
+			}
+			line = l
+		}
+		if len(line) == 0 {
+			continue
+		}
+
+		resp := s.handle(line)
+		if resp == nil {
+			continue // notification
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+	}
+}
+
+// server dispatches MCP requests against a provider catalog.
+type server struct {
+	providers []catwalk.Provider
+	tools     []tool
+}
+
+func newServer(providers []catwalk.Provider) *server {
+	return &server{providers: providers, tools: tools()}
+}
+
+// handle processes one JSON-RPC message, returning nil for notifications.
+func (s *server) handle(line []byte) *response {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, "parse error"}}
+	}
+	if len(req.ID) == 0 {
+		return nil
+	}
+
+	resp := &response{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &rpcError{codeInvalidRequest, "invalid request"}
+		return resp
+	}
+
+	result, err := s.dispatch(req)
+	if err != nil {
+		var rerr *rpcError
+		if !errors.As(err, &rerr) {
+			rerr = &rpcError{codeInvalidParams, err.Error()}
+		}
+		resp.Error = rerr
+		return resp
+	}
+	resp.Result = result
+	return resp
+}
+
+func (s *server) dispatch(req request) (any, error) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		version := protocolVersions[0]
+		for _, v := range protocolVersions {
+			if v == params.ProtocolVersion {
+				version = v
+			}
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "catwalk", "version": "1.0.0"},
+			"instructions":    "Query AI provider and model pricing, capabilities, and costs from the catwalk catalog.",
+		}, nil
+
+	case "ping":
+		return map[string]any{}, nil
+
+	case "tools/list":
+		list := make([]map[string]any, len(s.tools))
+		for i, t := range s.tools {
+			list[i] = map[string]any{
+				"name":        t.name,
+				"description": t.description,
+				"inputSchema": t.schema,
+			}
+		}
+		return map[string]any{"tools": list}, nil
+
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+		for _, t := range s.tools {
+			if t.name == params.Name {
+				return s.call(t, params.Arguments), nil
+			}
+		}
+		return nil, fmt.Errorf("unknown tool: %s", params.Name)
+
+	default:
+		return nil, &rpcError{codeMethodNotFound, "method not found: " + req.Method}
+	}
+}
+
+// call runs a tool. Tool failures are reported in the result, as MCP
+// expects, so the model can see and react to them.
+func (s *server) call(t tool, args json.RawMessage) map[string]any {
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+	out, err := t.run(s.providers, args)
+	if err != nil {
+		return map[string]any{
+			"content": []map[string]any{{"type": "text", "text": err.Error()}},
+			"isError": true,
+		}
+	}
+
+	text, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return map[string]any{
+			"content": []map[string]any{{"type": "text", "text": err.Error()}},
+			"isError": true,
+		}
+	}
+	return map[string]any{
+		"content": []map[string]any{{"type": "text", "text": string(text)}},
+	}
+}
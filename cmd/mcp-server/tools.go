@@ -0,0 +1,342 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/query"
+	"charm.land/catwalk/pkg/selector"
+	"charm.land/catwalk/pkg/usage"
+)
+
+// tool is an MCP tool: a JSON schema for its arguments and a function that
+// computes a JSON-serializable result from the catalog.
+type tool struct {
+	name        string
+	description string
+	schema      map[string]any
+	run         func(providers []catwalk.Provider, args json.RawMessage) (any, error)
+}
+
+// modelSummary is the compact model description returned by list tools.
+type modelSummary struct {
+	Provider       string  `json:"provider"`
+	ID             string  `json:"id"`
+	Name           string  `json:"name"`
+	CostPer1MIn    float64 `json:"cost_per_1m_in"`
+	CostPer1MOut   float64 `json:"cost_per_1m_out"`
+	ContextWindow  int64   `json:"context_window"`
+	CanReason      bool    `json:"can_reason"`
+	SupportsImages bool    `json:"supports_images"`
+}
+
+func summarize(p catwalk.Provider, m catwalk.Model) modelSummary {
+	return modelSummary{
+		Provider:       string(p.ID),
+		ID:             m.ID,
+		Name:           m.Name,
+		CostPer1MIn:    m.CostPer1MIn,
+		CostPer1MOut:   m.CostPer1MOut,
+		ContextWindow:  m.ContextWindow,
+		CanReason:      m.CanReason,
+		SupportsImages: m.SupportsImages,
+	}
+}
+
+// objectSchema builds a JSON schema for an object with the given properties.
+func objectSchema(properties map[string]any, required ...string) map[string]any {
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func prop(typ, description string) map[string]any {
+	return map[string]any{"type": typ, "description": description}
+}
+
+func tools() []tool {
+	return []tool{
+		{
+			name:        "list_providers",
+			description: "List AI inference providers in the catalog with their type, model count, and default models.",
+			schema: objectSchema(map[string]any{
+				"type": prop("string", "Only providers of this API type (e.g. openai, anthropic, openai-compat)"),
+			}),
+			run: listProviders,
+		},
+		{
+			name: "list_models",
+			description: "List models with pricing (USD per 1M tokens), context window, and capabilities. " +
+				"Filter by provider and/or a query expression such as " +
+				"'cost_in < 1 && context >= 128000 && (reason || vision)'. Fields: " + queryFields() + ".",
+			schema: objectSchema(map[string]any{
+				"provider": prop("string", "Provider ID (e.g. openai, anthropic, openrouter)"),
+				"query":    prop("string", "Filter expression over model fields"),
+				"sort":     map[string]any{"type": "string", "enum": []string{"cost", "context", "name"}, "description": "Sort order (default: catalog order)"},
+				"limit":    prop("integer", "Maximum number of models to return (default 50)"),
+			}),
+			run: listModels,
+		},
+		{
+			name:        "get_model",
+			description: "Get full details of a model: all prices (including cached tokens), context window, default max tokens, reasoning levels, and which providers offer it.",
+			schema: objectSchema(map[string]any{
+				"model":    prop("string", "Model ID"),
+				"provider": prop("string", "Provider ID, to pick one of several providers offering the model"),
+			}, "model"),
+			run: getModel,
+		},
+		{
+			name: "find_cheapest",
+			description: "Find the cheapest models (by input plus output price per 1M tokens) that meet the requirements, " +
+				"e.g. the cheapest vision model with at least 200K context.",
+			schema: objectSchema(map[string]any{
+				"min_context":        prop("integer", "Minimum context window in tokens"),
+				"max_cost_per_1m_in": prop("number", "Maximum input price in USD per 1M tokens"),
+				"reasoning":          prop("boolean", "Require reasoning support"),
+				"vision":             prop("boolean", "Require image input support"),
+				"providers":          map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Only consider these provider IDs"},
+				"allow_free":         prop("boolean", "Include models with no listed price (often unknown or subscription pricing)"),
+				"limit":              prop("integer", "Number of models to return (default 5)"),
+			}),
+			run: findCheapest,
+		},
+		{
+			name:        "calculate_cost",
+			description: "Estimate the USD cost of a workload (input, cached input, and output tokens) on one or more models.",
+			schema: objectSchema(map[string]any{
+				"models":              map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Model IDs to price"},
+				"provider":            prop("string", "Provider ID, when a model is offered by several providers"),
+				"input_tokens":        prop("integer", "Input tokens, including cached ones"),
+				"cached_input_tokens": prop("integer", "Input tokens served from the prompt cache"),
+				"output_tokens":       prop("integer", "Output tokens"),
+			}, "models"),
+			run: calculateCost,
+		},
+	}
+}
+
+func queryFields() string {
+	var names []string
+	for _, f := range query.Fields() {
+		names = append(names, f[0])
+	}
+	return strings.Join(names, ", ")
+}
+
+func decode(args json.RawMessage, v any) error {
+	if err := json.Unmarshal(args, v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+func listProviders(providers []catwalk.Provider, args json.RawMessage) (any, error) {
+	var in struct {
+		Type string `json:"type"`
+	}
+	if err := decode(args, &in); err != nil {
+		return nil, err
+	}
+
+	type providerInfo struct {
+		ID                  string `json:"id"`
+		Name                string `json:"name"`
+		Type                string `json:"type"`
+		Models              int    `json:"models"`
+		DefaultLargeModelID string `json:"default_large_model_id,omitempty"`
+		DefaultSmallModelID string `json:"default_small_model_id,omitempty"`
+	}
+	out := []providerInfo{}
+	for _, p := range providers {
+		if in.Type != "" && !strings.EqualFold(string(p.Type), in.Type) {
+			continue
+		}
+		out = append(out, providerInfo{
+			ID:                  string(p.ID),
+			Name:                p.Name,
+			Type:                string(p.Type),
+			Models:              len(p.Models),
+			DefaultLargeModelID: p.DefaultLargeModelID,
+			DefaultSmallModelID: p.DefaultSmallModelID,
+		})
+	}
+	return out, nil
+}
+
+func listModels(providers []catwalk.Provider, args json.RawMessage) (any, error) {
+	var in struct {
+		Provider string `json:"provider"`
+		Query    string `json:"query"`
+		Sort     string `json:"sort"`
+		Limit    int    `json:"limit"`
+	}
+	if err := decode(args, &in); err != nil {
+		return nil, err
+	}
+
+	var expr *query.Expr
+	if in.Query != "" {
+		var err error
+		if expr, err = query.Parse(in.Query); err != nil {
+			return nil, fmt.Errorf("invalid query: %w", err)
+		}
+	}
+
+	out := []modelSummary{}
+	for _, p := range providers {
+		if in.Provider != "" && !strings.EqualFold(string(p.ID), in.Provider) {
+			continue
+		}
+		for _, m := range p.Models {
+			if expr == nil || expr.Match(p, m) {
+				out = append(out, summarize(p, m))
+			}
+		}
+	}
+
+	switch in.Sort {
+	case "cost":
+		sort.SliceStable(out, func(i, j int) bool {
+			return out[i].CostPer1MIn+out[i].CostPer1MOut < out[j].CostPer1MIn+out[j].CostPer1MOut
+		})
+	case "context":
+		sort.SliceStable(out, func(i, j int) bool { return out[i].ContextWindow > out[j].ContextWindow })
+	case "name":
+		sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	case "":
+	default:
+		return nil, fmt.Errorf("invalid sort %q (use cost, context, or name)", in.Sort)
+	}
+
+	if in.Limit <= 0 {
+		in.Limit = 50
+	}
+	return out[:min(len(out), in.Limit)], nil
+}
+
+func getModel(providers []catwalk.Provider, args json.RawMessage) (any, error) {
+	var in struct {
+		Model    string `json:"model"`
+		Provider string `json:"provider"`
+	}
+	if err := decode(args, &in); err != nil {
+		return nil, err
+	}
+	if in.Model == "" {
+		return nil, errors.New("model is required")
+	}
+
+	matches, err := selector.Offers(providers, in.Model, in.Provider)
+	if err != nil {
+		return nil, err
+	}
+	type offering struct {
+		Provider string        `json:"provider"`
+		Model    catwalk.Model `json:"model"`
+	}
+	out := make([]offering, len(matches))
+	for i, m := range matches {
+		out[i] = offering{Provider: string(m.Provider.ID), Model: m.Model}
+	}
+	return out, nil
+}
+
+func findCheapest(providers []catwalk.Provider, args json.RawMessage) (any, error) {
+	var in struct {
+		MinContext     int64    `json:"min_context"`
+		MaxCostPer1MIn float64  `json:"max_cost_per_1m_in"`
+		Reasoning      bool     `json:"reasoning"`
+		Vision         bool     `json:"vision"`
+		Providers      []string `json:"providers"`
+		AllowFree      bool     `json:"allow_free"`
+		Limit          int      `json:"limit"`
+	}
+	if err := decode(args, &in); err != nil {
+		return nil, err
+	}
+
+	req := selector.Requirements{
+		MinContext:     in.MinContext,
+		MaxCostPer1MIn: in.MaxCostPer1MIn,
+		Reasoning:      in.Reasoning,
+		Vision:         in.Vision,
+		AllowFree:      in.AllowFree,
+	}
+	for _, id := range in.Providers {
+		req.Providers = append(req.Providers, catwalk.InferenceProvider(strings.ToLower(id)))
+	}
+
+	var matches []selector.Match
+	for _, m := range selector.New(providers).Matching(req) {
+		if in.AllowFree || selector.BlendedCost(m.Model) > 0 {
+			matches = append(matches, m)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, selector.ErrNoMatch
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return selector.BlendedCost(matches[i].Model) < selector.BlendedCost(matches[j].Model)
+	})
+
+	if in.Limit <= 0 {
+		in.Limit = 5
+	}
+	out := make([]modelSummary, 0, in.Limit)
+	for _, m := range matches[:min(len(matches), in.Limit)] {
+		out = append(out, summarize(m.Provider, m.Model))
+	}
+	return out, nil
+}
+
+func calculateCost(providers []catwalk.Provider, args json.RawMessage) (any, error) {
+	var in struct {
+		Models            []string `json:"models"`
+		Provider          string   `json:"provider"`
+		InputTokens       int64    `json:"input_tokens"`
+		CachedInputTokens int64    `json:"cached_input_tokens"`
+		OutputTokens      int64    `json:"output_tokens"`
+	}
+	if err := decode(args, &in); err != nil {
+		return nil, err
+	}
+	if len(in.Models) == 0 {
+		return nil, errors.New("models is required")
+	}
+	if in.CachedInputTokens > in.InputTokens {
+		return nil, errors.New("cached_input_tokens cannot exceed input_tokens")
+	}
+
+	type estimate struct {
+		Provider  string  `json:"provider"`
+		Model     string  `json:"model"`
+		TotalCost float64 `json:"total_cost_usd"`
+	}
+	var out []estimate
+	for _, id := range in.Models {
+		matches, err := selector.Offers(providers, id, in.Provider)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			out = append(out, estimate{
+				Provider: string(m.Provider.ID),
+				Model:    m.Model.ID,
+				TotalCost: usage.Cost(m.Model, usage.Record{
+					InputTokens:     in.InputTokens - in.CachedInputTokens,
+					CacheReadTokens: in.CachedInputTokens,
+					OutputTokens:    in.OutputTokens,
+				}),
+			})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].TotalCost < out[j].TotalCost })
+	return out, nil
+}
@@ -295,6 +295,7 @@ func main() {
 			}
 			if model.TopProvider.MaxCompletionTokens != nil {
 				m.DefaultMaxTokens = *model.TopProvider.MaxCompletionTokens / 2
+				m.MaxOutputTokens = *model.TopProvider.MaxCompletionTokens
 			} else {
 				m.DefaultMaxTokens = model.ContextLength / 10
 			}
@@ -367,6 +368,7 @@ func main() {
 		// Set max tokens based on the best endpoint
 		if bestEndpoint.MaxCompletionTokens != nil {
 			m.DefaultMaxTokens = *bestEndpoint.MaxCompletionTokens / 2
+			m.MaxOutputTokens = *bestEndpoint.MaxCompletionTokens
 		} else {
 			m.DefaultMaxTokens = bestEndpoint.ContextLength / 10
 		}
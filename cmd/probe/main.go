@@ -0,0 +1,416 @@
+// Package main provides probe, which checks which of a model's capabilities
+// actually work on its provider's endpoint: it sends an image, offers a
+// tool, asks for JSON matching a schema, and optionally fills most of the
+// context window, then compares the outcome with the catalog. With --write
+// the results are recorded in the overrides registry, where they correct
+// the catalog for aimodels, eval, and the other tools.
+//
+// Usage:
+//
+//	probe --model openai/gpt-4o
+//	probe --model openrouter/qwen/qwen3-vl-235b --write
+//	probe --model groq/llama-3.3-70b-versatile --capabilities all --max-cost 0.10
+//	probe --model gpt-4o-mini --capabilities images,tools --format json
+//
+// Environment Variables:
+//
+//	CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)
+//	<PROVIDER>_API_KEY - API keys, as named by each provider in the catalog
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/probe"
+	"charm.land/catwalk/pkg/registry"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/snapshot"
+	"charm.land/catwalk/pkg/theme"
+	"charm.land/catwalk/pkg/transport"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	modelName       = flag.String("model", "", "Model to probe, as provider/model or model (required)")
+	capabilities    = flag.String("capabilities", strings.Join(probe.Default, ","), "Comma-separated capabilities to probe, or all")
+	contextFraction = flag.Float64("context-fraction", 0.9, "Share of the context window the long-context probe fills")
+	maxCost         = flag.Float64("max-cost", 0.50, "Skip the long-context probe if its input would cost more than this, in USD (0 for no limit)")
+	write           = flag.Bool("write", false, "Record the results in the overrides file")
+	overridesFile   = flag.String("overrides", "", "Overrides file to record results in (default: the aimodels overrides.yaml)")
+	timeout         = flag.Duration("timeout", 2*time.Minute, "Timeout per probe")
+	outputFormat    = flag.String("format", "text", "Output format: text or json")
+	catalogVersion  = flag.String("catalog-version", "", "Use a stored catalog snapshot (ETag, YYYY-MM-DD, or latest) instead of live data")
+	network         = transport.RegisterFlags(flag.CommandLine)
+	showHelp        = flag.Bool("help", false, "Show help message")
+)
+
+// Styles for formatting, in the colors of the aimodels theme
+var (
+	colors = theme.Current()
+
+	headerStyle = lipgloss.NewStyle().Bold(true).Foreground(colors.Header)
+	infoStyle   = lipgloss.NewStyle().Foreground(colors.Muted)
+	costStyle   = lipgloss.NewStyle().Foreground(colors.Highlight)
+	okStyle     = lipgloss.NewStyle().Foreground(colors.OK)
+	warnStyle   = lipgloss.NewStyle().Foreground(colors.Warn)
+	errorStyle  = lipgloss.NewStyle().Foreground(colors.Error)
+)
+
+// skipped is a capability that was asked for but not probed.
+type skipped struct {
+	capability string
+	reason     string
+}
+
+func main() {
+	render.SetupConsole()
+	flag.Parse()
+
+	if *showHelp {
+		printHelp()
+		return
+	}
+	if *modelName == "" {
+		printHelp()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, errorStyle.Render("Error: "+err.Error()))
+		os.Exit(catwalk.ExitCode(err))
+	}
+}
+
+func run(ctx context.Context) error {
+	switch strings.ToLower(*outputFormat) {
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown format: %s (use text or json)", *outputFormat)
+	}
+	wanted, err := parseCapabilities(*capabilities)
+	if err != nil {
+		return err
+	}
+
+	httpClient, err := network.Client()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	providers, err := snapshot.Fetch(ctx, catwalk.NewWithHTTPClient(httpClient), *catalogVersion)
+	if err != nil {
+		return fmt.Errorf("failed to fetch providers: %w", err)
+	}
+	base, err := network.Transport()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	// The catalog is compared as published, so overrides from an earlier
+	// probe do not hide a mismatch
+	resolved, err := chat.Resolve(providers, *modelName, base)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	target := probe.Target(resolved)
+	opts := probe.Options{ContextFraction: *contextFraction}
+	wanted, skips := budget(target.Model, wanted, opts)
+
+	if *outputFormat == "text" {
+		fmt.Fprintf(os.Stderr, "%s %s/%s...\n", infoStyle.Render("Probing"), target.Provider.ID, target.Model.ID)
+	}
+	results := make([]probe.Result, 0, len(wanted))
+	for _, c := range wanted {
+		pctx, cancel := context.WithTimeout(ctx, *timeout)
+		results = append(results, probe.Run(pctx, target, []string{c}, opts)...)
+		cancel()
+		if ctx.Err() != nil {
+			return ctx.Err() //nolint:wrapcheck
+		}
+	}
+
+	var saved string
+	if *write {
+		if saved, err = record(target, results); err != nil {
+			return err
+		}
+	}
+
+	if strings.EqualFold(*outputFormat, "json") {
+		return outputJSON(target, results, skips, saved)
+	}
+	outputText(target, results, skips, saved)
+	return nil
+}
+
+// parseCapabilities splits a comma-separated list, expanding "all".
+func parseCapabilities(list string) ([]string, error) {
+	var out []string
+	for _, c := range strings.Split(list, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		switch {
+		case c == "":
+		case c == "all":
+			return probe.All, nil
+		case !slices.Contains(probe.All, c):
+			return nil, fmt.Errorf("unknown capability: %s (use %s, or all)", c, strings.Join(probe.All, ", "))
+		case !slices.Contains(out, c):
+			out = append(out, c)
+		}
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no capabilities to probe")
+	}
+	return out, nil
+}
+
+// budget drops the long-context probe if its prompt would cost more than
+// --max-cost, and says why.
+func budget(m catwalk.Model, wanted []string, opts probe.Options) ([]string, []skipped) {
+	if !slices.Contains(wanted, probe.LongContext) || *maxCost <= 0 {
+		return wanted, nil
+	}
+	tokens := opts.LongContextTokens(m)
+	cost := chat.Cost(m, int(tokens), 0)
+	if cost <= *maxCost {
+		return wanted, nil
+	}
+	kept := slices.DeleteFunc(slices.Clone(wanted), func(c string) bool { return c == probe.LongContext })
+	return kept, []skipped{{
+		capability: probe.LongContext,
+		reason:     fmt.Sprintf("a %d-token prompt would cost $%.2f, above --max-cost $%.2f", tokens, cost, *maxCost),
+	}}
+}
+
+// record saves conclusive results under the model's probe key in the
+// overrides file and returns the file's path.
+func record(target probe.Target, results []probe.Result) (string, error) {
+	overrides, err := registry.Open(*overridesFile)
+	if errors.Is(err, os.ErrNotExist) {
+		overrides, err = nil, nil
+	}
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	path := *overridesFile
+	if overrides == nil {
+		overrides = &registry.Overrides{}
+	}
+	if path == "" {
+		if path, err = registry.DefaultPath(); err != nil {
+			return "", err //nolint:wrapcheck
+		}
+	}
+
+	p := registry.Probe{At: time.Now().UTC().Truncate(time.Second)}
+	found := false
+	for _, r := range results {
+		if r.Inconclusive {
+			continue
+		}
+		works := r.Works
+		switch r.Capability {
+		case probe.Images:
+			p.Images = &works
+		case probe.Tools:
+			p.Tools = &works
+		case probe.JSONSchema:
+			p.JSONSchema = &works
+		case probe.LongContext:
+			p.LongContext, p.ContextTokens = &works, r.PromptTokens
+		}
+		found = true
+	}
+	if !found {
+		return "", errors.New("no conclusive results to record")
+	}
+	overrides.SetProbe(target.Provider.ID, target.Model.ID, p)
+	if err := overrides.Save(path); err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	return path, nil
+}
+
+// claim describes what the catalog says about a capability.
+func claim(advertised *bool) string {
+	switch {
+	case advertised == nil:
+		return "-"
+	case *advertised:
+		return "yes"
+	default:
+		return "no"
+	}
+}
+
+// outcome describes a result for the table, styled by whether it agrees
+// with the catalog.
+func outcome(r probe.Result) string {
+	switch {
+	case r.Inconclusive:
+		return warnStyle.Render("error")
+	case r.Mismatch():
+		return errorStyle.Render(map[bool]string{true: "works", false: "fails"}[r.Works])
+	case r.Works:
+		return okStyle.Render("works")
+	default:
+		return "fails"
+	}
+}
+
+func outputText(target probe.Target, results []probe.Result, skips []skipped, saved string) {
+	fmt.Println(headerStyle.Render(fmt.Sprintf("%s (%s/%s)", cmp.Or(target.Model.Name, target.Model.ID), target.Provider.ID, target.Model.ID)))
+	fmt.Println()
+
+	tbl := render.NewTable(
+		render.Column{Title: "Capability"},
+		render.Column{Title: "Catalog"},
+		render.Column{Title: "Result"},
+		render.Column{Title: "Detail", MinWidth: 20},
+		render.Column{Title: "Cost", Align: render.AlignRight, Style: costStyle},
+	)
+	var total float64
+	mismatches, errs := 0, 0
+	for _, r := range results {
+		tbl.AddRow(r.Capability, claim(r.Advertised), outcome(r), r.Detail, fmt.Sprintf("$%.6f", r.Cost))
+		total += r.Cost
+		if r.Mismatch() {
+			mismatches++
+		}
+		if r.Inconclusive {
+			errs++
+		}
+	}
+	for _, s := range skips {
+		tbl.AddRow(s.capability, "", infoStyle.Render("skipped"), s.reason, "")
+	}
+	tbl.Print()
+
+	fmt.Println()
+	fmt.Printf("%s %s\n", infoStyle.Render("Total cost:"), costStyle.Render(fmt.Sprintf("$%.6f", total)))
+	switch mismatches {
+	case 0:
+		fmt.Println(okStyle.Render("The catalog agrees with every conclusive probe."))
+	case 1:
+		fmt.Println(errorStyle.Render("1 result contradicts the catalog."))
+	default:
+		fmt.Println(errorStyle.Render(fmt.Sprintf("%d results contradict the catalog.", mismatches)))
+	}
+	if errs > 0 {
+		fmt.Println(warnStyle.Render(fmt.Sprintf("%d probes failed for reasons unrelated to the capability; run them again later.", errs)))
+	}
+	if saved != "" {
+		fmt.Printf("%s %s\n", infoStyle.Render("Recorded in"), saved)
+	} else if mismatches > 0 {
+		fmt.Println(infoStyle.Render("Run with --write to record the results in the overrides file."))
+	}
+}
+
+// jsonResult is one probe in JSON output.
+type jsonResult struct {
+	Capability   string  `json:"capability"`
+	Advertised   *bool   `json:"advertised"`
+	Works        bool    `json:"works"`
+	Mismatch     bool    `json:"mismatch"`
+	Inconclusive bool    `json:"inconclusive,omitempty"`
+	Skipped      bool    `json:"skipped,omitempty"`
+	Detail       string  `json:"detail"`
+	PromptTokens int64   `json:"prompt_tokens,omitempty"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+}
+
+func outputJSON(target probe.Target, results []probe.Result, skips []skipped, saved string) error {
+	out := struct {
+		Provider  catwalk.InferenceProvider `json:"provider"`
+		Model     string                    `json:"model"`
+		Results   []jsonResult              `json:"results"`
+		TotalCost float64                   `json:"total_cost"`
+		SavedTo   string                    `json:"saved_to,omitempty"`
+	}{Provider: target.Provider.ID, Model: target.Model.ID, Results: []jsonResult{}, SavedTo: saved}
+	for _, r := range results {
+		out.Results = append(out.Results, jsonResult{
+			Capability:   r.Capability,
+			Advertised:   r.Advertised,
+			Works:        r.Works,
+			Mismatch:     r.Mismatch(),
+			Inconclusive: r.Inconclusive,
+			Detail:       r.Detail,
+			PromptTokens: r.PromptTokens,
+			InputTokens:  r.InputTokens,
+			OutputTokens: r.OutputTokens,
+			Cost:         r.Cost,
+		})
+		out.TotalCost += r.Cost
+	}
+	for _, s := range skips {
+		out.Results = append(out.Results, jsonResult{Capability: s.capability, Skipped: true, Detail: s.reason})
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out) //nolint:wrapcheck
+}
+
+// printHelp displays usage information
+func printHelp() {
+	fmt.Println("probe - Check which advertised capabilities work on a model's endpoint")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  probe --model <provider/model> [options]")
+	fmt.Println()
+	fmt.Println("Each capability is tested with one small request and compared with the catalog:")
+	fmt.Println("  images        a 16x16 red square; the model must name the color")
+	fmt.Println("  tools         a get_weather tool; the model must call it with a city")
+	fmt.Println("  json_schema   a strict schema for a person; the reply must match it")
+	fmt.Println("  long_context  a prompt filling --context-fraction of the context window,")
+	fmt.Println("                with a passphrase at the start the model must recall")
+	fmt.Println()
+	fmt.Println("Failures caused by the API key, rate limits, or server errors are reported as")
+	fmt.Println("errors and are neither counted against the catalog nor recorded.")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --model <name>             Model to probe, as provider/model or a model ID (required)")
+	fmt.Println("  --capabilities <list>      Capabilities to probe, or all (default: images,tools,json_schema)")
+	fmt.Println("  --context-fraction <f>     Share of the context window to fill (default: 0.9)")
+	fmt.Println("  --max-cost <usd>           Skip the long-context probe if its input would cost more")
+	fmt.Println("                             (default: 0.50; 0 for no limit)")
+	fmt.Println("  --write                    Record the results in the overrides file, where the")
+	fmt.Println("                             images result replaces the catalog's image support")
+	fmt.Println("  --overrides <file>         Overrides file to write (default: the aimodels overrides.yaml)")
+	fmt.Println("  --timeout <d>              Timeout per probe (default: 2m)")
+	fmt.Println("  --format <fmt>             text (default) or json")
+	fmt.Println("  --catalog-version <v>      Use a stored catalog snapshot")
+	fmt.Println("  --proxy <url>              Proxy URL (default: HTTPS_PROXY/HTTP_PROXY from the environment)")
+	fmt.Println("  --ca-cert <pem>            PEM file with additional CA certificates to trust")
+	fmt.Println("  --insecure-skip-verify     Skip TLS certificate verification (unsafe)")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  probe --model openai/gpt-4o")
+	fmt.Println("  probe --model openrouter/qwen/qwen3-vl-235b --write")
+	fmt.Println("  probe --model groq/llama-3.3-70b-versatile --capabilities all --max-cost 0.10")
+	fmt.Println("  probe --model gpt-4o-mini --capabilities images,tools --format json")
+	fmt.Println()
+	fmt.Println("Exit Status:")
+	fmt.Println("  0 success, 1 error, 2 invalid usage, 3 provider not found,")
+	fmt.Println("  4 model not found, 5 missing API key")
+	fmt.Println()
+	fmt.Println("Environment Variables:")
+	fmt.Println("  CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)")
+	fmt.Println("  AIMODELS_THEME - Output theme (auto, dark, light, high-contrast, plain) or theme file")
+}
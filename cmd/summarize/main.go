@@ -0,0 +1,408 @@
+// Package main provides summarize, which summarizes a document of any
+// length: one that fits the model's context window is summarized in one
+// request, and a longer one is split into overlapping chunks whose summaries
+// are combined (map-reduce). It prints the summary with what it cost, and
+// recommends the cheapest model that could have read the whole document at
+// once.
+//
+// Usage:
+//
+//	summarize --input report.txt
+//	summarize --model openai/gpt-4o-mini --overlap 400 < transcript.txt
+//	summarize --input book.md --instructions "One paragraph per chapter"
+//	summarize --input book.md --model groq/llama-3.1-8b-instant --plan
+//
+// Environment Variables:
+//
+//	CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)
+//	<PROVIDER>_API_KEY - API keys, as named by each provider in the catalog
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"charm.land/catwalk/internal/cli"
+	"charm.land/catwalk/pkg/auth"
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/registry"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/snapshot"
+	"charm.land/catwalk/pkg/summarize"
+	"charm.land/catwalk/pkg/tokenizer"
+	"charm.land/catwalk/pkg/transport"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	inputFile      = flag.String("input", "-", "Document to summarize, or - for stdin")
+	modelName      = flag.String("model", "", "Model to use, as provider/model or model (default: the cheapest with a key)")
+	instructions   = flag.String("instructions", "", "What the summary should focus on, or how long it should be")
+	chunkTokens    = flag.Int("chunk-tokens", 0, "Max document tokens per request (0 = fit the model's context window)")
+	overlap        = flag.Int("overlap", summarize.DefaultOverlap, "Tokens each chunk repeats from the one before")
+	maxTokens      = flag.Int("max-tokens", summarize.DefaultReplyTokens, "Max tokens per reply")
+	parallel       = flag.Int("parallel", 4, "Chunks summarized at once")
+	planOnly       = flag.Bool("plan", false, "Print the chunks, requests, and estimated cost without sending anything")
+	timeout        = flag.Duration("timeout", 10*time.Minute, "Timeout for the whole summary")
+	catalogVersion = flag.String("catalog-version", "", "Use a stored catalog snapshot (ETag, YYYY-MM-DD, or latest) instead of live data")
+	overridesFile  = flag.String("overrides", "", "Pricing overrides, or none (default: the aimodels overrides.yaml, if present)")
+	network        = transport.RegisterFlags(flag.CommandLine)
+	showHelp       = flag.Bool("help", false, "Show help message")
+)
+
+// failStyle shows errors; the rest of the output uses the shared cli
+// styles
+var failStyle = lipgloss.NewStyle().Foreground(cli.Colors.Error)
+
+// candidate is a model the document could be summarized with, and the
+// estimated cost of doing so.
+type candidate struct {
+	provider *catwalk.Provider
+	model    *catwalk.Model
+	key      string
+	hasKey   bool
+	plan     summarize.Plan
+	cost     float64
+}
+
+func main() {
+	render.SetupConsole()
+	flag.Parse()
+
+	if *showHelp {
+		printHelp()
+		return
+	}
+	if flag.NArg() > 0 || *parallel < 1 || *maxTokens < 1 || *chunkTokens < 0 || *overlap < 0 {
+		printHelp()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, failStyle.Render("Error: "+err.Error()))
+		os.Exit(catwalk.ExitCode(err))
+	}
+}
+
+func run(ctx context.Context) error {
+	document, err := readInput(*inputFile)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(document) == "" {
+		return errors.New("the input document is empty")
+	}
+
+	httpClient, err := network.Client()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	providers, err := snapshot.Fetch(ctx, catwalk.NewWithHTTPClient(httpClient), *catalogVersion)
+	if err != nil {
+		return fmt.Errorf("failed to fetch providers: %w", err)
+	}
+	if *overridesFile != "none" {
+		overrides, err := registry.Open(*overridesFile)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		providers = overrides.Apply(providers)
+	}
+
+	opts := summarize.Options{
+		Instructions: *instructions,
+		ChunkTokens:  *chunkTokens,
+		Overlap:      *overlap,
+		MaxTokens:    *maxTokens,
+		Parallel:     *parallel,
+		Progress:     progress,
+	}
+	if opts.Overlap == 0 {
+		// Zero means the default to Summarize
+		opts.Overlap = -1
+	}
+
+	var c candidate
+	if *modelName != "" {
+		c, err = resolveCandidate(providers, *modelName, document, opts)
+	} else {
+		c, err = pickCandidate(providers, document, opts)
+	}
+	if err != nil {
+		return err
+	}
+	best, bestKeyed := recommend(providers, document)
+
+	if *planOnly {
+		printPlan(c, tokenizer.Count(document))
+		printRecommendation(&c, best, bestKeyed)
+		return nil
+	}
+	if !c.hasKey {
+		_, err := c.provider.ResolveAPIKey()
+		return err //nolint:wrapcheck
+	}
+
+	base, err := network.Transport()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	target := summarize.Target{
+		Client:   chat.NewClient(*c.provider, c.key, base),
+		Provider: *c.provider,
+		Model:    *c.model,
+	}
+	how := "in one request"
+	if c.plan.Chunks > 1 {
+		how = fmt.Sprintf("in %d chunks of up to %d tokens", c.plan.Chunks, c.plan.ChunkTokens)
+	}
+	fmt.Fprintln(os.Stderr, cli.MutedStyle.Render(fmt.Sprintf("Summarizing with %s/%s %s...", c.provider.ID, c.model.ID, how)))
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	result, err := summarize.Summarize(ctx, target, document, opts)
+	if err == nil {
+		fmt.Println(result.Summary)
+	}
+
+	fmt.Fprintln(os.Stderr, cli.CostStyle.Render(fmt.Sprintf("%d request%s, %d input + %d output tokens, $%.6f",
+		result.Requests, plural(result.Requests), result.InputTokens, result.OutputTokens, result.Cost)))
+	if err == nil {
+		printRecommendation(&c, best, bestKeyed)
+	}
+	return err //nolint:wrapcheck
+}
+
+// progress reports each request on stderr
+func progress(s summarize.Step) {
+	switch {
+	case s.Pass == 0 && s.Count == 1:
+		return
+	case s.Pass == 0:
+		fmt.Fprintln(os.Stderr, cli.MutedStyle.Render(fmt.Sprintf("  Summarizing part %d/%d", s.Index, s.Count)))
+	default:
+		fmt.Fprintln(os.Stderr, cli.MutedStyle.Render(fmt.Sprintf("  Combining %d/%d, pass %d", s.Index, s.Count, s.Pass)))
+	}
+}
+
+// printPlan shows how the document would be summarized
+func printPlan(c candidate, tokens int) {
+	fmt.Printf("Model:      %s/%s (context %d)\n", c.provider.ID, c.model.ID, c.model.ContextWindow)
+	fmt.Printf("Document:   ~%d tokens\n", tokens)
+	if c.plan.Chunks == 1 {
+		fmt.Println("Chunks:     1 (fits in one request)")
+	} else {
+		fmt.Printf("Chunks:     %d of up to %d tokens, combined in %d pass%s\n", c.plan.Chunks, c.plan.ChunkTokens, c.plan.Passes, pluralES(c.plan.Passes))
+	}
+	fmt.Printf("Requests:   %d\n", c.plan.Requests)
+	fmt.Printf("Tokens:     up to %d input + %d output\n", c.plan.InputTokens, c.plan.OutputTokens)
+	if c.cost > 0 {
+		fmt.Println(cli.CostStyle.Render(fmt.Sprintf("Cost:       up to $%.6f", c.cost)))
+	} else {
+		fmt.Println("Cost:       unknown (no listed price)")
+	}
+	if !c.hasKey {
+		fmt.Println(failStyle.Render(fmt.Sprintf("No API key is set for %s.", c.provider.ID)))
+	}
+}
+
+// printRecommendation names the cheapest model that reads the whole
+// document in one request, if it is not the one used
+func printRecommendation(used, best, bestKeyed *candidate) {
+	if best == nil {
+		fmt.Fprintln(os.Stderr, cli.MutedStyle.Render("No priced model has a context window that fits the whole document."))
+		return
+	}
+	show := func(label string, c *candidate) {
+		if c == nil || (c.provider.ID == used.provider.ID && c.model.ID == used.model.ID) {
+			return
+		}
+		fmt.Fprintln(os.Stderr, cli.MutedStyle.Render(fmt.Sprintf("%s: %s/%s, ~$%.6f (context %d)",
+			label, c.provider.ID, c.model.ID, c.cost, c.model.ContextWindow)))
+	}
+	if best.hasKey {
+		show("Cheapest single pass", best)
+		return
+	}
+	show("Cheapest single pass (no API key set)", best)
+	show("Cheapest single pass with a key", bestKeyed)
+}
+
+// recommend returns the cheapest priced model whose context window fits the
+// whole document, and the cheapest of those with an API key set if that is
+// another one.
+func recommend(providers []catwalk.Provider, document string) (best, bestKeyed *candidate) {
+	var fits []candidate
+	for i := range providers {
+		p := &providers[i]
+		key, keyErr := p.ResolveAPIKey()
+		hasKey := keyErr == nil || !auth.For(*p).NeedsKey()
+		for j := range p.Models {
+			m := &p.Models[j]
+			if m.CostPer1MIn+m.CostPer1MOut == 0 || !summarize.Fits(*m, document, *instructions, *maxTokens) {
+				continue
+			}
+			plan, err := summarize.NewPlan(*m, document, summarize.Options{Instructions: *instructions, MaxTokens: *maxTokens})
+			if err != nil {
+				continue
+			}
+			fits = append(fits, candidate{p, m, key, hasKey, plan, plan.Cost(*m)})
+		}
+	}
+	if len(fits) == 0 {
+		return nil, nil
+	}
+	slices.SortStableFunc(fits, func(a, b candidate) int { return cmp.Compare(a.cost, b.cost) })
+	best = &fits[0]
+	if best.hasKey {
+		return best, nil
+	}
+	if i := slices.IndexFunc(fits, func(c candidate) bool { return c.hasKey }); i >= 0 {
+		bestKeyed = &fits[i]
+	}
+	return best, bestKeyed
+}
+
+// readInput reads the document from a file, or from stdin for "-".
+func readInput(path string) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return string(data), nil
+}
+
+// resolveCandidate finds a model, given as provider/model or as a model ID
+// offered by any provider, and plans the summary with it.
+func resolveCandidate(providers []catwalk.Provider, name, document string, opts summarize.Options) (candidate, error) {
+	provider, model, err := catwalk.FindModel(providers, name)
+	if err != nil {
+		return candidate{}, err //nolint:wrapcheck
+	}
+	plan, err := summarize.NewPlan(*model, document, opts)
+	if err != nil {
+		return candidate{}, fmt.Errorf("%w, or set --chunk-tokens", err)
+	}
+	key, err := provider.ResolveAPIKey()
+	hasKey := err == nil || !auth.For(*provider).NeedsKey()
+	return candidate{provider, model, key, hasKey, plan, plan.Cost(*model)}, nil
+}
+
+// pickCandidate chooses the model with an API key set that summarizes the
+// document for the least, chunked or not. Models without a listed price are
+// tried last, since their cost is unknown.
+func pickCandidate(providers []catwalk.Provider, document string, opts summarize.Options) (candidate, error) {
+	var candidates []candidate
+	for i := range providers {
+		p := &providers[i]
+		key, err := p.ResolveAPIKey()
+		if err != nil && auth.For(*p).NeedsKey() {
+			continue
+		}
+		for j := range p.Models {
+			m := &p.Models[j]
+			plan, err := summarize.NewPlan(*m, document, opts)
+			if err != nil {
+				continue
+			}
+			candidates = append(candidates, candidate{p, m, key, true, plan, plan.Cost(*m)})
+		}
+	}
+	if len(candidates) == 0 {
+		return candidate{}, errors.New("no model with a known context window has an API key set; set a provider's API key or use --model")
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		if (a.cost == 0) != (b.cost == 0) {
+			if a.cost == 0 {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(a.cost, b.cost)
+	})
+	return candidates[0], nil
+}
+
+// plural returns "s" unless n is 1
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// pluralES returns "es" unless n is 1
+func pluralES(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "es"
+}
+
+// printHelp displays usage information
+func printHelp() {
+	fmt.Println("summarize - Summarize a document of any length")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  summarize [--input <file>] [options]")
+	fmt.Println()
+	fmt.Println("A document that fits the model's context window is summarized in one request.")
+	fmt.Println("A longer one is split into chunks that fit, at line breaks where possible, each")
+	fmt.Println("repeating --overlap tokens from the one before so nothing is lost at a cut.")
+	fmt.Println("Every chunk is summarized, then the summaries are combined, over more passes")
+	fmt.Println("if they do not fit together, until one is left. The summary goes to stdout;")
+	fmt.Println("progress, tokens, and cost go to stderr.")
+	fmt.Println()
+	fmt.Println("Without --model, the model with an API key set that costs the least for the")
+	fmt.Println("whole summary is used. Afterwards, the cheapest priced model whose context")
+	fmt.Println("window fits the document in one request is recommended, if it is another one.")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --input <file>          Document to summarize (default: stdin)")
+	fmt.Println("  --model <name>          Model to use, as provider/model or a model ID")
+	fmt.Println("  --instructions <text>   What the summary should focus on, or how long it should be")
+	fmt.Println("  --chunk-tokens <n>      Max document tokens per request (default: fit the model's")
+	fmt.Println("                          context window)")
+	fmt.Println("  --overlap <n>           Tokens each chunk repeats from the one before (default: 200)")
+	fmt.Println("  --max-tokens <n>        Max tokens per reply (default: 1024)")
+	fmt.Println("  --parallel <n>          Chunks summarized at once (default: 4)")
+	fmt.Println("  --plan                  Print the chunks, requests, and estimated cost, and the")
+	fmt.Println("                          recommended model, without sending anything")
+	fmt.Println("  --timeout <d>           Timeout for the whole summary (default: 10m)")
+	fmt.Println("  --catalog-version <v>   Use a stored catalog snapshot")
+	fmt.Println("  --overrides <file>      Negotiated prices, or none (default: the aimodels")
+	fmt.Println("                          overrides.yaml, if present)")
+	fmt.Println()
+	cli.PrintNetworkHelp()
+	fmt.Println("Examples:")
+	fmt.Println("  summarize --input report.txt")
+	fmt.Println("  summarize --model openai/gpt-4o-mini --overlap 400 < transcript.txt")
+	fmt.Println("  summarize --input book.md --instructions \"One paragraph per chapter\"")
+	fmt.Println("  summarize --input book.md --model groq/llama-3.1-8b-instant --plan")
+	fmt.Println()
+	fmt.Println("Exit Status:")
+	fmt.Println("  0 success, 1 error, 2 invalid usage, 3 provider not found, 4 model not found,")
+	fmt.Println("  5 missing API key")
+	fmt.Println()
+	cli.PrintEnvHelp()
+}
@@ -219,6 +219,7 @@ func main() {
 			DefaultReasoningEffort: defaultReasoning,
 			ReasoningLevels:        reasoningLevels,
 			SupportsImages:         supportsImages,
+			MaxOutputTokens:        model.MaxOutputLength,
 		}
 
 		// Set max tokens based on max_output_length if available, but cap at
@@ -176,6 +176,7 @@ func main() {
 			CostPer1MOutCached:     costPer1MOutCached,
 			ContextWindow:          model.ContextWindow,
 			DefaultMaxTokens:       defaultMaxTokens,
+			MaxOutputTokens:        model.MaxTokens,
 			CanReason:              canReason,
 			ReasoningLevels:        reasoningLevels,
 			DefaultReasoningEffort: defaultReasoning,
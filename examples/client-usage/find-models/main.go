@@ -3,78 +3,174 @@
 // This example demonstrates:
 // - Searching models across all providers
 // - Filtering by multiple criteria (cost, context, reasoning, vision)
-// - Interactive mode for step-by-step filtering using bubbletea
+// - Checking that a prompt plus its output budget fits each model's context window
+// - Interactive mode for step-by-step filtering using bubbletea, with model ID autocomplete
 // - Scoring and ranking models
 // - Side-by-side model comparison
+// - Enriching the catalog with benchmark scores
+// - Composing arbitrary filters with a query expression
+// - Exporting results as a standalone HTML report
+// - Recommending models for a use case such as code review, without knowing which knobs matter
+// - Limiting results to the best few models of each provider for vendor diversity
+// - Filtering by latency tier (realtime, fast, standard, batch) from overrides or benchmarks
+// - Ranking by the total cost of a workload instead of per-1M prices
+// - Saving filter combinations as named presets in the config directory
 //
 // Usage:
-//   go run main.go --max-cost 1.0 --min-context 100000       # Non-interactive search
-//   go run main.go --reasoning --vision                         # Filter by capabilities
-//   go run main.go --interactive                                # Interactive mode
-//   go run main.go --compare "gpt-4o,claude-3-opus"          # Compare specific models
-//   go run main.go --help                                      # Show help message
+//
+//	go run main.go --max-cost 1.0 --min-context 100000       # Non-interactive search
+//	go run main.go --reasoning --vision                         # Filter by capabilities
+//	go run main.go --interactive                                # Interactive mode
+//	go run main.go --compare "gpt-4o,claude-3-opus"          # Compare specific models
+//	go run main.go --benchmarks scores.json                    # Rank with benchmark quality
+//	go run main.go --cheapest --reasoning --min-context 128000 # Print only the cheapest match
+//	go run main.go --prompt-tokens 150000 --output-tokens 16000 # Models the request fits in
+//	go run main.go --query 'cost_in < 1 && (reason || vision)'  # Filter with an expression
+//	go run main.go --reasoning --format html > report.html     # HTML report
+//	go run main.go --use-case "code review"                    # Recommend models for a task
+//	go run main.go --reasoning --per-provider 2                # Best 2 models of each provider
+//	go run main.go --max-latency-tier fast --vision            # Only realtime and fast models
+//	go run main.go --reasoning --workload input=2000,output=800,calls=10000  # Cheapest for the job
+//	go run main.go --vision --max-cost 1 --save-as cheap-vision # Search, and save the filters
+//	go run main.go --preset cheap-vision --per-provider 1       # Search with saved filters
+//	go run main.go presets list                                 # List saved presets
+//	go run main.go --help                                      # Show help message
 //
 // Environment Variables:
-//   CATWALK_URL - URL of the catwalk service (default: http://localhost:8080)
+//
+//	CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)
 package main
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"maps"
 	"math"
-	"sort"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
+	"charm.land/catwalk/internal/cli"
+	"charm.land/catwalk/pkg/benchmarks"
 	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/query"
+	"charm.land/catwalk/pkg/registry"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/report"
+	"charm.land/catwalk/pkg/selector"
+	"charm.land/catwalk/pkg/snapshot"
+	"charm.land/catwalk/pkg/transport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"go.yaml.in/yaml/v2"
 )
 
 var (
 	// Command-line flags (for non-interactive mode)
-	maxCost       = flag.Float64("max-cost", 0, "Maximum cost per 1M input tokens (0 = no limit)")
-	minContext    = flag.Int64("min-context", 0, "Minimum context window (0 = no limit)")
-	reasoning     = flag.Bool("reasoning", false, "Filter by reasoning capability")
-	vision        = flag.Bool("vision", false, "Filter by vision capability")
-	interactive   = flag.Bool("interactive", false, "Interactive mode")
-	compareModels = flag.String("compare", "", "Comma-separated list of models to compare")
-	showHelp      = flag.Bool("help", false, "Show help message")
+	maxCost        = flag.Float64("max-cost", 0, "Maximum cost per 1M input tokens (0 = no limit)")
+	minContext     = flag.Int64("min-context", 0, "Minimum context window (0 = no limit)")
+	promptTokens   = flag.Int64("prompt-tokens", 0, "Prompt size in tokens that must fit alongside the output budget")
+	outputTokens   = flag.Int64("output-tokens", 0, "Output budget in tokens (0 = each model's default max tokens)")
+	reasoning      = flag.Bool("reasoning", false, "Filter by reasoning capability")
+	vision         = flag.Bool("vision", false, "Filter by vision capability")
+	interactive    = flag.Bool("interactive", false, "Interactive mode")
+	compareModels  = flag.String("compare", "", "Comma-separated list of models to compare")
+	benchmarkSrc   = flag.String("benchmarks", "", "Benchmark dataset (JSON file or URL) used for quality scoring")
+	queryExpr      = flag.String("query", "", "Filter expression over model fields, e.g. 'cost_in < 1 && context >= 128000'")
+	cheapest       = flag.Bool("cheapest", false, "Print only the cheapest matching model (provider<TAB>model)")
+	useCase        = flag.String("use-case", "", "Recommend models for a use case, e.g. \"code review\" or \"agentic coding\"")
+	perProvider    = flag.Int("per-provider", 0, "Show at most this many of the best models from each provider (0 = no limit)")
+	maxLatencyTier = flag.String("max-latency-tier", "", "Slowest latency tier to include: realtime, fast, standard, or batch")
+	overridesFile  = flag.String("overrides", "", "Pricing and latency tier overrides file, or none (default: the aimodels overrides.yaml, if present)")
+	catalogVersion = flag.String("catalog-version", "", "Use a stored catalog snapshot (ETag, YYYY-MM-DD, or latest) instead of live data")
+	workloadSpec   = flag.String("workload", "", "Rank by the total cost of a workload, e.g. input=2000,output=800,calls=10000")
+	outputFormat   = flag.String("format", "text", "Output format for search and compare: text or html")
+	presetName     = flag.String("preset", "", "Search with the filters saved under this name; other flags add to or replace them")
+	saveAs         = flag.String("save-as", "", "Save this search's filters under a name for --preset")
+	network        = transport.RegisterFlags(flag.CommandLine)
+	showHelp       = flag.Bool("help", false, "Show help message")
 )
 
 // Styles for formatting
 var (
-	headerStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("86"))
-	nameStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
-	scoreStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("228"))
-	costStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("228"))
-	contextStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("81"))
-	providerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("120"))
-	borderStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	nameStyle     = lipgloss.NewStyle().Bold(true).Foreground(cli.Colors.Accent)
+	scoreStyle    = lipgloss.NewStyle().Foreground(cli.Colors.Highlight)
+	providerStyle = lipgloss.NewStyle().Foreground(cli.Colors.OK)
 )
 
+// tiers looks up latency tiers for display and --max-latency-tier
+var tiers latencyTiers
+
+// workload is the parsed --workload, or nil to rank by score
+var workload *selector.Workload
+
+// modelMatch points into the fetched catalog, so listing every model copies
+// no model or provider data
 type modelMatch struct {
-	model    catwalk.Model
-	provider catwalk.Provider
-	score    float64
+	model      *catwalk.Model
+	provider   *catwalk.Provider
+	score      float64
+	cost       float64 // of the --workload
+	quality    benchmarks.Quality
+	hasQuality bool
 }
 
 func main() {
+	render.SetupConsole()
 	flag.Parse()
 
 	if *showHelp {
 		printHelp()
 		return
 	}
+	if flag.Arg(0) == "presets" {
+		if err := runPresets(flag.Args()[1:]); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
+	}
+	if err := applyPreset(*presetName); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if *saveAs != "" {
+		if err := savePreset(*saveAs); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	}
+
+	html := strings.EqualFold(*outputFormat, "html")
+	if !html && !strings.EqualFold(*outputFormat, "text") {
+		log.Fatalf("Unknown format: %s (use 'text' or 'html')", *outputFormat)
+	}
+	if *workloadSpec != "" {
+		w, err := selector.ParseWorkload(*workloadSpec)
+		if err != nil {
+			log.Fatalf("Error: invalid --workload: %v", err)
+		}
+		workload = &w
+		// Each call's prompt and reply must fit, unless a budget is given
+		if !budgeted() {
+			*promptTokens, *outputTokens = w.InputTokens, w.OutputTokens
+		}
+	}
 
 	// Create catwalk client
-	client := catwalk.New()
+	httpClient, err := network.Client()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	client := catwalk.NewWithHTTPClient(httpClient)
 	ctx := context.Background()
 
-	// Fetch providers
-	providers, err := client.GetProviders(ctx, "")
+	// Fetch providers (live, or from a pinned snapshot)
+	providers, err := snapshot.Fetch(ctx, client, *catalogVersion)
 	if err != nil {
 		if err == catwalk.ErrNotModified {
 			log.Println("Data not modified (cached)")
@@ -82,21 +178,90 @@ func main() {
 		}
 		log.Fatalf("Error fetching providers: %v", err)
 	}
+	if *overridesFile != "none" {
+		tiers.overrides, err = registry.Open(*overridesFile)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		providers = tiers.overrides.Apply(providers)
+	}
+
+	// Narrow the catalog with the query expression before any other filtering
+	if *queryExpr != "" {
+		expr, err := query.Parse(*queryExpr)
+		if err != nil {
+			log.Fatalf("Error: invalid --query: %v", err)
+		}
+		providers = filterQuery(providers, expr)
+	}
+
+	// Load benchmark scores, which may also record latency tiers
+	var dataset benchmarks.Dataset
+	if *benchmarkSrc != "" {
+		dataset, err = benchmarks.Load(ctx, *benchmarkSrc)
+		if err != nil {
+			log.Fatalf("Error loading benchmarks: %v", err)
+		}
+		tiers.dataset = dataset
+	}
+
+	// Drop models slower than the latency tier, so every mode respects it
+	if *maxLatencyTier != "" {
+		limit, err := selector.ParseLatencyTier(*maxLatencyTier)
+		if err != nil {
+			log.Fatalf("Error: invalid --max-latency-tier: %v", err)
+		}
+		providers = filterLatency(providers, limit)
+	}
+
+	// Resolve the use-case profile, tightened by any filter flags
+	var profile *selector.UseCase
+	if *useCase != "" {
+		u, err := selector.FindUseCase(*useCase)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		u.Requirements = withFlags(u.Requirements)
+		profile = &u
+	}
+
+	// Print just the cheapest match for scripting
+	if *cheapest {
+		req := withFlags(selector.Requirements{})
+		match, err := selector.New(providers).CheapestWith(req)
+		if profile != nil {
+			match, err = cheapestFor(providers, *profile)
+		} else if workload != nil {
+			match, err = cheapestOf(selector.New(providers).Matching(req))
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s\t%s\n", match.Provider.ID, match.Model.ID)
+		return
+	}
 
 	// Collect all models
 	var allModels []modelMatch
-	for _, p := range providers {
-		for _, m := range p.Models {
+	for i := range providers {
+		p := &providers[i]
+		for j := range p.Models {
 			allModels = append(allModels, modelMatch{
-				model:    m,
+				model:    &p.Models[j],
 				provider: p,
 			})
 		}
 	}
 
+	// Merge benchmark scores into the catalog
+	for i := range allModels {
+		allModels[i].quality, allModels[i].hasQuality = dataset.Quality(allModels[i].model.ID)
+	}
+
 	// Handle different modes
 	if *compareModels != "" {
-		compareModelsList(providers, strings.Split(*compareModels, ","))
+		compareModelsList(providers, strings.Split(*compareModels, ","), dataset, html)
 		return
 	}
 
@@ -105,16 +270,269 @@ func main() {
 		return
 	}
 
+	if profile != nil {
+		matches := topPerProvider(rankByWorkload(recommend(providers, *profile, dataset)), *perProvider)
+		if len(matches) == 0 {
+			fmt.Println("No models found for this use case.")
+			return
+		}
+		if html {
+			outputHTML("Recommended for "+profile.Name, matches, true)
+			return
+		}
+		displayRecommendations(*profile, matches)
+		return
+	}
+
 	// Non-interactive search
 	matches := filterModels(allModels, *maxCost, *minContext, *reasoning, *vision)
+	if budgeted() {
+		matches = filterBudget(matches, *promptTokens, *outputTokens)
+	}
 	if len(matches) == 0 {
 		fmt.Println("No models found matching criteria.")
 		return
 	}
 
+	matches = topPerProvider(rankByWorkload(scoreModels(matches)), *perProvider)
+	if html {
+		outputHTML("Matching Models", matches, true)
+		return
+	}
 	displayMatches(matches)
 }
 
+// presetFlags are the flags a preset saves: the filters and what the
+// ranking weighs, but not the mode or output format
+var presetFlags = []string{
+	"max-cost", "min-context", "prompt-tokens", "output-tokens", "reasoning", "vision",
+	"query", "use-case", "per-provider", "max-latency-tier", "workload", "benchmarks",
+}
+
+// presetNamePattern matches preset names
+var presetNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// presetsPath returns the presets file, aimodels/find-models.yaml in the
+// user config directory
+func presetsPath() (string, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine config directory: %w", err)
+	}
+	return filepath.Join(config, "aimodels", "find-models.yaml"), nil
+}
+
+// loadPresets reads the saved presets, each a map of flag names to values;
+// a missing file has none
+func loadPresets() (map[string]map[string]string, error) {
+	path, err := presetsPath()
+	if err != nil {
+		return nil, err
+	}
+	presets := map[string]map[string]string{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return presets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read presets: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &presets); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return presets, nil
+}
+
+// writePresets replaces the presets file
+func writePresets(presets map[string]map[string]string) error {
+	path, err := presetsPath()
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(presets)
+	if err != nil {
+		return fmt.Errorf("failed to encode presets: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write presets: %w", err)
+	}
+	return nil
+}
+
+// applyPreset sets the flags saved under name, except those given on the
+// command line
+func applyPreset(name string) error {
+	if name == "" {
+		return nil
+	}
+	presets, err := loadPresets()
+	if err != nil {
+		return err
+	}
+	preset, ok := presets[name]
+	if !ok {
+		return fmt.Errorf("no preset named %q%s", name, presetHint(presets, name))
+	}
+	given := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	for flagName, value := range preset {
+		if given[flagName] || !slices.Contains(presetFlags, flagName) {
+			continue
+		}
+		if err := flag.Set(flagName, value); err != nil {
+			return fmt.Errorf("preset %s: --%s: %w", name, flagName, err)
+		}
+	}
+	return nil
+}
+
+// presetHint suggests saved presets like name, or lists them all
+func presetHint(presets map[string]map[string]string, name string) string {
+	names := slices.Sorted(maps.Keys(presets))
+	if len(names) == 0 {
+		return " (none saved; create one with --save-as)"
+	}
+	if similar := catwalk.Suggest(name, names, 3); len(similar) > 0 {
+		return " (did you mean " + strings.Join(similar, ", ") + "?)"
+	}
+	return " (saved: " + strings.Join(names, ", ") + ")"
+}
+
+// savePreset saves the filter flags now set, from the command line or a
+// --preset, under name
+func savePreset(name string) error {
+	if !presetNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid preset name %q: use letters, digits, - and _", name)
+	}
+	preset := map[string]string{}
+	for _, flagName := range presetFlags {
+		if f := flag.Lookup(flagName); f.Value.String() != f.DefValue {
+			preset[flagName] = f.Value.String()
+		}
+	}
+	if len(preset) == 0 {
+		return errors.New("--save-as needs at least one filter to save")
+	}
+	presets, err := loadPresets()
+	if err != nil {
+		return err
+	}
+	presets[name] = preset
+	if err := writePresets(presets); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Saved preset %s: %s\n", name, describePreset(preset))
+	return nil
+}
+
+// describePreset returns a preset as the flags it sets
+func describePreset(preset map[string]string) string {
+	var parts []string
+	for _, flagName := range presetFlags {
+		value, ok := preset[flagName]
+		if !ok {
+			continue
+		}
+		switch {
+		case value == "true":
+			parts = append(parts, "--"+flagName)
+		case strings.ContainsAny(value, " '\"&|<>!()"):
+			parts = append(parts, fmt.Sprintf("--%s %q", flagName, value))
+		default:
+			parts = append(parts, "--"+flagName+" "+value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// runPresets handles "presets list" and "presets delete <name>"
+func runPresets(args []string) error {
+	presets, err := loadPresets()
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		if len(presets) == 0 {
+			fmt.Println("No saved presets; create one with --save-as <name>.")
+			return nil
+		}
+		names := slices.Sorted(maps.Keys(presets))
+		width := len(slices.MaxFunc(names, func(a, b string) int { return cmp.Compare(len(a), len(b)) }))
+		for _, name := range names {
+			fmt.Printf("%s  %s\n", nameStyle.Render(fmt.Sprintf("%-*s", width, name)), describePreset(presets[name]))
+		}
+		return nil
+	case len(args) == 2 && args[0] == "delete":
+		name := args[1]
+		if _, ok := presets[name]; !ok {
+			return fmt.Errorf("no preset named %q%s", name, presetHint(presets, name))
+		}
+		delete(presets, name)
+		if err := writePresets(presets); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted preset %s\n", name)
+		return nil
+	default:
+		return errors.New("usage: find-models presets list | presets delete <name>")
+	}
+}
+
+// filterQuery returns providers with only the models matching expr
+func filterQuery(providers []catwalk.Provider, expr *query.Expr) []catwalk.Provider {
+	filtered := make([]catwalk.Provider, 0, len(providers))
+	for _, p := range providers {
+		var models []catwalk.Model
+		for _, m := range p.Models {
+			if expr.Match(p, m) {
+				models = append(models, m)
+			}
+		}
+		p.Models = models
+		filtered = append(filtered, p)
+	}
+	return filtered
+}
+
+// latencyTiers finds a model's latency tier in the overrides, then the
+// benchmark dataset, and otherwise guesses it from the model's name
+type latencyTiers struct {
+	overrides *registry.Overrides
+	dataset   benchmarks.Dataset
+}
+
+// of returns the model's tier and whether it was recorded rather than guessed
+func (l latencyTiers) of(p *catwalk.Provider, m *catwalk.Model) (selector.LatencyTier, bool) {
+	if t, ok := l.overrides.LatencyTier(p.ID, m.ID); ok {
+		return t, true
+	}
+	if scores, ok := l.dataset.Lookup(m.ID); ok && scores.LatencyTier != "" {
+		return scores.LatencyTier, true
+	}
+	return selector.GuessLatencyTier(*p, *m), false
+}
+
+// filterLatency returns providers with only the models at or faster than
+// the given tier
+func filterLatency(providers []catwalk.Provider, limit selector.LatencyTier) []catwalk.Provider {
+	filtered := make([]catwalk.Provider, 0, len(providers))
+	for _, p := range providers {
+		var models []catwalk.Model
+		for j := range p.Models {
+			if t, _ := tiers.of(&p, &p.Models[j]); t.AtMost(limit) {
+				models = append(models, p.Models[j])
+			}
+		}
+		p.Models = models
+		filtered = append(filtered, p)
+	}
+	return filtered
+}
+
 // filterModels applies filters to model list
 func filterModels(models []modelMatch, maxCost float64, minContext int64, reasoning, vision bool) []modelMatch {
 	var filtered []modelMatch
@@ -146,6 +564,153 @@ func filterModels(models []modelMatch, maxCost float64, minContext int64, reason
 	return filtered
 }
 
+// withFlags adds the filter flags to a set of requirements, keeping the
+// stricter of each limit
+func withFlags(req selector.Requirements) selector.Requirements {
+	req.MinContext = max(req.MinContext, *minContext)
+	if *maxCost > 0 && (req.MaxCostPer1MIn == 0 || *maxCost < req.MaxCostPer1MIn) {
+		req.MaxCostPer1MIn = *maxCost
+	}
+	req.PromptTokens, req.OutputTokens = *promptTokens, *outputTokens
+	req.Reasoning = req.Reasoning || *reasoning
+	req.Vision = req.Vision || *vision
+	return req
+}
+
+// recommend ranks the models meeting a use case's requirements by its
+// score, plus the benchmark quality bonus when scores are loaded
+func recommend(providers []catwalk.Provider, profile selector.UseCase, dataset benchmarks.Dataset) []modelMatch {
+	var matches []modelMatch
+	ranked := profile.Rank(providers)
+	for i := range ranked {
+		r := &ranked[i]
+		mm := modelMatch{model: &r.Model, provider: &r.Provider, score: r.Score}
+		mm.quality, mm.hasQuality = dataset.Quality(r.Model.ID)
+		if mm.hasQuality {
+			mm.score += mm.quality.Score / 4
+		}
+		matches = append(matches, mm)
+	}
+	slices.SortStableFunc(matches, func(a, b modelMatch) int { return cmp.Compare(b.score, a.score) })
+	return matches
+}
+
+// cheapestFor returns the cheapest model meeting a use case's requirements
+func cheapestFor(providers []catwalk.Provider, profile selector.UseCase) (selector.Match, error) {
+	var matches []selector.Match
+	for _, r := range profile.Rank(providers) {
+		matches = append(matches, r.Match)
+	}
+	return cheapestOf(matches)
+}
+
+// cheapestOf returns the match with the lowest modelCost, the first of equals
+func cheapestOf(matches []selector.Match) (selector.Match, error) {
+	if len(matches) == 0 {
+		return selector.Match{}, selector.ErrNoMatch
+	}
+	best := matches[0]
+	for _, m := range matches[1:] {
+		if modelCost(m.Model) < modelCost(best.Model) {
+			best = m
+		}
+	}
+	return best, nil
+}
+
+// modelCost is the price models are compared by: the --workload's total
+// cost, or else the blended price per 1M tokens
+func modelCost(m catwalk.Model) float64 {
+	if workload != nil {
+		return workload.Cost(m)
+	}
+	return selector.BlendedCost(m)
+}
+
+// rankByWorkload orders ranked models by the --workload's total cost,
+// cheapest first and models without a listed price last; equal costs keep
+// their order. Without --workload it returns models as they are
+func rankByWorkload(models []modelMatch) []modelMatch {
+	if workload == nil {
+		return models
+	}
+	for i := range models {
+		models[i].cost = workload.Cost(*models[i].model)
+	}
+	slices.SortStableFunc(models, func(a, b modelMatch) int {
+		if ua, ub := unpriced(a), unpriced(b); ua != ub {
+			if ua {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(a.cost, b.cost)
+	})
+	return models
+}
+
+// unpriced reports whether the catalog lists no price for a model
+func unpriced(mm modelMatch) bool {
+	return mm.model.CostPer1MIn == 0 && mm.model.CostPer1MOut == 0
+}
+
+// formatUSD formats a cost, with more decimals for fractions of a cent
+func formatUSD(v float64) string {
+	if v != 0 && v < 0.01 {
+		return fmt.Sprintf("$%.4f", v)
+	}
+	return fmt.Sprintf("$%.2f", v)
+}
+
+// budgeted reports whether a prompt or output budget was given
+func budgeted() bool {
+	return *promptTokens > 0 || *outputTokens > 0
+}
+
+// filterBudget keeps models whose context window holds the prompt plus the
+// output budget, within the model's max output
+func filterBudget(models []modelMatch, prompt, output int64) []modelMatch {
+	var filtered []modelMatch
+	for _, mm := range models {
+		if selector.Fits(*mm.model, prompt, output) {
+			filtered = append(filtered, mm)
+		}
+	}
+	return filtered
+}
+
+// headroom returns the context left after the prompt and output budget
+func headroom(m catwalk.Model) int64 {
+	return m.ContextWindow - *promptTokens - selector.ReservedOutput(m, *outputTokens)
+}
+
+// topPerProvider keeps the first n models of each provider, so one vendor's
+// near-identical models don't crowd out the rest. Models must be ranked best
+// first; n <= 0 keeps them all
+func topPerProvider(models []modelMatch, n int) []modelMatch {
+	if n <= 0 {
+		return models
+	}
+	counts := map[catwalk.InferenceProvider]int{}
+	var kept []modelMatch
+	for _, mm := range models {
+		if counts[mm.provider.ID] < n {
+			counts[mm.provider.ID]++
+			kept = append(kept, mm)
+		}
+	}
+	return kept
+}
+
+// shownMatches is how many ranked models are listed: the top 10, or all of
+// them when --per-provider already limits the list
+func shownMatches(models []modelMatch) int {
+	if *perProvider > 0 {
+		return len(models)
+	}
+	return min(10, len(models))
+}
+
 // scoreModels calculates match scores for models
 func scoreModels(models []modelMatch) []modelMatch {
 	for i := range models {
@@ -177,71 +742,173 @@ func scoreModels(models []modelMatch) []modelMatch {
 			score += 10
 		}
 
+		// Bonus for benchmark quality (up to +25 for a perfect score)
+		if mm.hasQuality {
+			score += mm.quality.Score / 4
+		}
+
 		mm.score = score
 	}
 
 	// Sort by score (descending)
-	sort.Slice(models, func(i, j int) bool {
-		return models[i].score > models[j].score
-	})
+	slices.SortFunc(models, func(a, b modelMatch) int { return cmp.Compare(b.score, a.score) })
 
 	return models
 }
 
-// displayMatches shows matching models
+// displayMatches shows scored matching models
 func displayMatches(models []modelMatch) {
-	// Score models
-	models = scoreModels(models)
+	fmt.Println()
+	fmt.Println(cli.HeaderStyle.Render("Matching Models"))
+	fmt.Println(cli.BorderStyle.Render(render.DoubleRule(80)))
+	if workload != nil {
+		fmt.Println("Ranked by the cost of " + workload.String())
+	}
+	fmt.Println()
+
+	for i, mm := range models[:shownMatches(models)] {
+		printMatch(i, mm)
+	}
+
+	printShown(models)
+}
 
+// displayRecommendations shows a use case's requirements and its top models
+func displayRecommendations(profile selector.UseCase, models []modelMatch) {
 	fmt.Println()
-	fmt.Println(headerStyle.Render("Matching Models"))
-	fmt.Println(borderStyle.Render(strings.Repeat("═", 80)))
+	fmt.Println(cli.HeaderStyle.Render("Recommended for " + profile.Name))
+	fmt.Println(cli.BorderStyle.Render(render.DoubleRule(80)))
+	fmt.Println(profile.Description)
+	fmt.Printf("  Requires: %s\n", describeRequirements(profile))
+	if workload != nil {
+		fmt.Printf("  Ranked by: cost of %s\n", workload)
+	} else {
+		fmt.Printf("  Ranked by: %s\n", describeWeights(profile.Weights))
+	}
 	fmt.Println()
 
-	for i, mm := range models {
-		if i >= 10 { // Show top 10 matches
-			break
-		}
+	for i, mm := range models[:shownMatches(models)] {
+		printMatch(i, mm)
+	}
 
-		fmt.Printf("%s #%d %s\n",
-			scoreStyle.Render(fmt.Sprintf("[%.0f]", mm.score)),
-			i+1,
-			nameStyle.Render(mm.model.Name))
-		fmt.Printf("  Provider: %s\n", providerStyle.Render(mm.provider.Name))
-		fmt.Printf("  Cost: $%.2f/1M in, $%.2f/1M out | Context: %dK\n",
-			mm.model.CostPer1MIn, mm.model.CostPer1MOut, mm.model.ContextWindow/1000)
+	printShown(models)
+}
 
-		if mm.model.CanReason {
-			fmt.Printf("  %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("120")).Render("✓ Reasoning"))
-		}
-		if mm.model.SupportsImages {
-			fmt.Printf("  %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("120")).Render("✓ Vision"))
+// printShown prints how many of the ranked models were listed
+func printShown(models []modelMatch) {
+	if *perProvider > 0 {
+		fmt.Printf(cli.BorderStyle.Render("Showing %d matches, at most %d per provider\n"), len(models), *perProvider)
+		return
+	}
+	fmt.Printf(cli.BorderStyle.Render("Showing top %d of %d matches\n"), shownMatches(models), len(models))
+}
+
+// describeRequirements lists what a use case needs, including filter flags
+func describeRequirements(profile selector.UseCase) string {
+	req := profile.Requirements
+	var needs []string
+	if req.Reasoning {
+		needs = append(needs, "reasoning")
+	}
+	if req.Vision {
+		needs = append(needs, "vision")
+	}
+	if profile.Tools {
+		needs = append(needs, "tool calling")
+	}
+	if profile.Fast {
+		needs = append(needs, "low latency (small/fast model tiers)")
+	}
+	if req.MinContext > 0 {
+		needs = append(needs, fmt.Sprintf("context >= %dK", req.MinContext/1000))
+	}
+	if req.MaxCostPer1MIn > 0 {
+		needs = append(needs, fmt.Sprintf("max $%g/1M input", req.MaxCostPer1MIn))
+	}
+	if *maxLatencyTier != "" {
+		needs = append(needs, "latency tier "+strings.ToLower(*maxLatencyTier)+" or faster")
+	}
+	if budgeted() {
+		needs = append(needs, fmt.Sprintf("fits %d prompt + %s output tokens", *promptTokens, outputBudget()))
+	}
+	return strings.Join(needs, ", ")
+}
+
+// describeWeights lists the ranking factors, most important first
+func describeWeights(w selector.Weights) string {
+	type factor struct {
+		name   string
+		weight float64
+	}
+	factors := []factor{{"capability", w.Capability}, {"reasoning", w.Reasoning}, {"context", w.Context}, {"cost", w.Cost}, {"speed", w.Speed}}
+	slices.SortStableFunc(factors, func(a, b factor) int { return cmp.Compare(b.weight, a.weight) })
+
+	var parts []string
+	for _, f := range factors {
+		if f.weight > 0 {
+			parts = append(parts, fmt.Sprintf("%s x%g", f.name, f.weight))
 		}
+	}
+	return strings.Join(parts, ", ")
+}
 
-		fmt.Println()
+// printMatch prints one ranked model
+func printMatch(i int, mm modelMatch) {
+	rank := fmt.Sprintf("[%.0f]", mm.score)
+	switch {
+	case workload != nil && unpriced(mm):
+		rank = "[no price]"
+	case workload != nil:
+		rank = "[" + formatUSD(mm.cost) + "]"
+	}
+	fmt.Printf("%s #%d %s\n",
+		scoreStyle.Render(rank),
+		i+1,
+		nameStyle.Render(mm.model.Name))
+	fmt.Printf("  Provider: %s\n", providerStyle.Render(mm.provider.Name))
+	if workload != nil && !unpriced(mm) {
+		fmt.Printf("  Workload: %s total, %s per call\n", formatUSD(mm.cost), formatUSD(workload.PerCall(*mm.model)))
+	}
+	fmt.Printf("  Cost: $%.2f/1M in, $%.2f/1M out | Context: %dK\n",
+		mm.model.CostPer1MIn, mm.model.CostPer1MOut, mm.model.ContextWindow/1000)
+	if budgeted() {
+		fmt.Printf("  Max output: %dK | Headroom: %d tokens\n", mm.model.OutputLimit()/1000, headroom(*mm.model))
+	}
+	if tier, known := tiers.of(mm.provider, mm.model); known {
+		fmt.Printf("  Latency: %s\n", tier)
+	} else if *maxLatencyTier != "" {
+		fmt.Printf("  Latency: %s (guessed from the model name)\n", tier)
 	}
 
-	fmt.Printf(borderStyle.Render("Showing top %d of %d matches\n"), min(10, len(models)), len(models))
+	if mm.model.CanReason {
+		fmt.Printf("  %s\n", cli.CapabilityStyle.Render(render.Symbol("✓", "+")+" Reasoning"))
+	}
+	if mm.model.SupportsImages {
+		fmt.Printf("  %s\n", cli.CapabilityStyle.Render(render.Symbol("✓", "+")+" Vision"))
+	}
+	if mm.hasQuality {
+		fmt.Printf("  Quality: %s from %d of %d benchmarks | $%.4f per quality point\n",
+			scoreStyle.Render(fmt.Sprintf("%.1f", mm.quality.Score)), mm.quality.Benchmarks, len(benchmarks.Benchmarks),
+			benchmarks.CostPerQualityPoint(*mm.model, mm.quality.Score))
+	}
+
+	fmt.Println()
 }
 
 // compareModelsList compares specific models side-by-side
-func compareModelsList(providers []catwalk.Provider, modelNames []string) {
-	var models []struct {
-		model    catwalk.Model
-		provider catwalk.Provider
-	}
+func compareModelsList(providers []catwalk.Provider, modelNames []string, dataset benchmarks.Dataset, html bool) {
+	var models []modelMatch
 
 	// Find models
 	for _, name := range modelNames {
 		name = strings.TrimSpace(name)
-		for _, p := range providers {
-			for _, m := range p.Models {
+		for i := range providers {
+			p := &providers[i]
+			for j := range p.Models {
+				m := &p.Models[j]
 				if strings.EqualFold(m.ID, name) ||
 					strings.Contains(strings.ToLower(m.Name), strings.ToLower(name)) {
-					models = append(models, struct {
-						model    catwalk.Model
-						provider catwalk.Provider
-					}{m, p})
+					models = append(models, modelMatch{model: m, provider: p})
 					break
 				}
 			}
@@ -253,10 +920,18 @@ func compareModelsList(providers []catwalk.Provider, modelNames []string) {
 		return
 	}
 
+	if html {
+		for i := range models {
+			models[i].quality, models[i].hasQuality = dataset.Quality(models[i].model.ID)
+		}
+		outputHTML("Model Comparison", models, false)
+		return
+	}
+
 	// Display comparison
 	fmt.Println()
-	fmt.Println(headerStyle.Render("Model Comparison"))
-	fmt.Println(borderStyle.Render(strings.Repeat("═", 80)))
+	fmt.Println(cli.HeaderStyle.Render("Model Comparison"))
+	fmt.Println(cli.BorderStyle.Render(render.DoubleRule(80)))
 	fmt.Println()
 
 	for _, m := range models {
@@ -264,13 +939,128 @@ func compareModelsList(providers []catwalk.Provider, modelNames []string) {
 		fmt.Printf("  Provider: %s\n", providerStyle.Render(m.provider.Name))
 		fmt.Printf("  Cost: $%.2f/1M in, $%.2f/1M out\n",
 			m.model.CostPer1MIn, m.model.CostPer1MOut)
+		if workload != nil {
+			fmt.Printf("  Workload: %s total, %s per call\n", formatUSD(workload.Cost(*m.model)), formatUSD(workload.PerCall(*m.model)))
+		}
 		fmt.Printf("  Context: %dK tokens\n", m.model.ContextWindow/1000)
 		fmt.Printf("  Reasoning: %s | Vision: %s\n",
-			boolToStr(m.model.CanReason), boolToStr(m.model.SupportsImages))
+			cli.YesNo(m.model.CanReason), cli.YesNo(m.model.SupportsImages))
+		if quality, ok := dataset.Quality(m.model.ID); ok {
+			fmt.Printf("  Quality: %.1f from %d of %d benchmarks | $%.4f per quality point\n",
+				quality.Score, quality.Benchmarks, len(benchmarks.Benchmarks), benchmarks.CostPerQualityPoint(*m.model, quality.Score))
+		}
 		fmt.Println()
 	}
 }
 
+// filterNote describes the filters given on the command line
+func filterNote() string {
+	var filters []string
+	if *useCase != "" {
+		filters = append(filters, "use case "+*useCase)
+	}
+	if *maxCost > 0 {
+		filters = append(filters, fmt.Sprintf("max $%g/1M input", *maxCost))
+	}
+	if *minContext > 0 {
+		filters = append(filters, fmt.Sprintf("context >= %d", *minContext))
+	}
+	if budgeted() {
+		filters = append(filters, fmt.Sprintf("fits %d prompt + %s output tokens", *promptTokens, outputBudget()))
+	}
+	if *reasoning {
+		filters = append(filters, "reasoning")
+	}
+	if *vision {
+		filters = append(filters, "vision")
+	}
+	if *queryExpr != "" {
+		filters = append(filters, "query "+*queryExpr)
+	}
+	if *perProvider > 0 {
+		filters = append(filters, fmt.Sprintf("at most %d per provider", *perProvider))
+	}
+	if workload != nil {
+		filters = append(filters, "ranked by the cost of "+workload.String())
+	}
+	if len(filters) == 0 {
+		return "Filters: none"
+	}
+	return "Filters: " + strings.Join(filters, ", ")
+}
+
+// outputBudget describes the --output-tokens budget
+func outputBudget() string {
+	if *outputTokens == 0 {
+		return "default"
+	}
+	return fmt.Sprint(*outputTokens)
+}
+
+// outputHTML writes models as a standalone HTML report with a sortable table,
+// a price chart, and a capability matrix
+func outputHTML(title string, models []modelMatch, scored bool) {
+	columns := []string{"Model", "Provider", "$/1M In", "$/1M Out", "Context", "Quality", "Benchmarks"}
+	if workload != nil {
+		columns = append(columns, "Workload Cost")
+	}
+	if scored {
+		columns = append([]string{"Score"}, columns...)
+	}
+	tbl := report.Table{Columns: columns}
+	chart := report.Chart{Title: "Price per 1M Tokens (Input + Output)", Format: "$%.2f"}
+	if workload != nil {
+		chart.Title = "Cost of " + workload.String()
+	}
+	var labels []string
+	var catalog []catwalk.Model
+	for _, mm := range models {
+		quality, measured := report.Text("–"), report.Text("–")
+		if mm.hasQuality {
+			quality, measured = report.Number("%.1f", mm.quality.Score), report.Int(int64(mm.quality.Benchmarks))
+		}
+		row := []report.Cell{
+			report.Text(mm.model.Name),
+			report.Text(mm.provider.Name),
+			report.Number("$%.2f", mm.model.CostPer1MIn),
+			report.Number("$%.2f", mm.model.CostPer1MOut),
+			report.Int(mm.model.ContextWindow),
+			quality,
+			measured,
+		}
+		if workload != nil {
+			row = append(row, report.Number("$%.2f", workload.Cost(*mm.model)))
+		}
+		if scored {
+			row = append([]report.Cell{report.Number("%.0f", mm.score)}, row...)
+		}
+		tbl.AddRow(row...)
+
+		label := mm.model.Name + " (" + mm.provider.Name + ")"
+		chart.Bars = append(chart.Bars, report.Bar{Label: label, Value: modelCost(*mm.model)})
+		labels = append(labels, label)
+		catalog = append(catalog, *mm.model)
+	}
+
+	notes := []string{fmt.Sprintf("%d models", len(models))}
+	if scored {
+		notes = append(notes, filterNote())
+	}
+	if *catalogVersion != "" {
+		notes = append(notes, "Catalog snapshot: "+*catalogVersion)
+	}
+	r := report.Report{
+		Title:        title,
+		Notes:        notes,
+		Tables:       []report.Table{tbl},
+		Charts:       []report.Chart{chart},
+		Capabilities: report.Capabilities(labels, catalog),
+	}
+	if err := r.WriteHTML(os.Stdout); err != nil {
+		log.Fatalf("Error writing HTML: %v", err)
+	}
+}
+
 // runInteractiveMode runs interactive filtering interface
 func runInteractiveMode(models []modelMatch) {
 	p := tea.NewProgram(initialModel(models))
@@ -281,11 +1071,16 @@ func runInteractiveMode(models []modelMatch) {
 
 // initialModel creates initial model for interactive interface
 func initialModel(models []modelMatch) model {
+	ids := make([]string, len(models))
+	for i, mm := range models {
+		ids[i] = mm.model.ID
+	}
 	return model{
-		models:      models,
-		filtered:    models,
+		models:       models,
+		filtered:     models,
 		step:         stepMaxCost,
 		currentInput: "",
+		ids:          cli.NewDropdown(ids, 5),
 	}
 }
 
@@ -295,6 +1090,11 @@ type model struct {
 	filtered     []modelMatch
 	step         step
 	currentInput string
+
+	// Completions for the model ID typed at the results step, and the ID
+	// whose details are shown
+	ids    cli.Dropdown
+	lookup string
 }
 
 type step int
@@ -335,13 +1135,45 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case stepCapabilities:
 				m.step = stepResults
 			case stepResults:
-				return m, tea.Quit
+				if m.currentInput == "" {
+					return m, tea.Quit
+				}
+				m.lookup = m.currentInput
+				if id, ok := m.ids.Selected(); ok && !m.listed(m.lookup) {
+					m.lookup = id
+				}
+				m.currentInput = ""
+				m.ids.Filter("")
 			}
 
 		case tea.KeyBackspace:
 			if len(m.currentInput) > 0 {
 				m.currentInput = m.currentInput[:len(m.currentInput)-1]
+				if m.step == stepResults {
+					m.ids.Filter(m.currentInput)
+				}
+			}
+
+		case tea.KeyUp:
+			m.ids.Move(-1)
+
+		case tea.KeyDown:
+			m.ids.Move(1)
+
+		case tea.KeyTab:
+			if id, ok := m.ids.Selected(); ok {
+				m.currentInput = id
+				m.ids.Filter("")
+			}
+
+		case tea.KeyRunes:
+			// Model IDs can hold any character
+			if m.step == stepResults {
+				m.currentInput += string(msg.Runes)
+				m.ids.Filter(m.currentInput)
+				break
 			}
+			fallthrough
 
 		default:
 			// Handle character input for numeric values
@@ -362,7 +1194,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m model) View() string {
 	var s strings.Builder
 
-	s.WriteString(headerStyle.Render("Find Models - Interactive Mode"))
+	s.WriteString(cli.HeaderStyle.Render("Find Models - Interactive Mode"))
 	s.WriteString("\n\n")
 
 	switch m.step {
@@ -386,18 +1218,50 @@ func (m model) View() string {
 			s.WriteString(fmt.Sprintf("%d. %s (%s) - $%.2f/1M in\n",
 				i+1, mm.model.Name, mm.provider.Name, mm.model.CostPer1MIn))
 		}
-		s.WriteString("\nPress Enter to exit...")
+		if m.lookup != "" {
+			s.WriteString("\n" + m.details(m.lookup))
+		}
+		s.WriteString("\nType a model ID to look it up (Tab completes), or press Enter to exit: ")
+		s.WriteString(m.currentInput)
+		s.WriteString("\n" + m.ids.View())
 	}
 
 	return s.String()
 }
 
-// boolToStr converts boolean to string
-func boolToStr(b bool) string {
-	if b {
-		return "Yes"
+// listed reports whether id is a model ID in the catalog
+func (m model) listed(id string) bool {
+	return slices.ContainsFunc(m.models, func(mm modelMatch) bool { return strings.EqualFold(mm.model.ID, id) })
+}
+
+// details describes the model with the given ID at each provider listing it
+func (m model) details(id string) string {
+	var s strings.Builder
+	for _, mm := range m.models {
+		if !strings.EqualFold(mm.model.ID, id) {
+			continue
+		}
+		if s.Len() == 0 {
+			s.WriteString(nameStyle.Render(mm.model.Name) + " " + cli.MutedStyle.Render(mm.model.ID) + "\n")
+		}
+		var caps []string
+		if mm.model.CanReason {
+			caps = append(caps, "reasoning")
+		}
+		if mm.model.SupportsImages {
+			caps = append(caps, "vision")
+		}
+		s.WriteString(fmt.Sprintf("  %s: $%.2f/1M in, $%.2f/1M out | Context: %dK",
+			providerStyle.Render(mm.provider.Name), mm.model.CostPer1MIn, mm.model.CostPer1MOut, mm.model.ContextWindow/1000))
+		if len(caps) > 0 {
+			s.WriteString(" | " + cli.CapabilityStyle.Render(strings.Join(caps, ", ")))
+		}
+		s.WriteString("\n")
 	}
-	return "No"
+	if s.Len() == 0 {
+		return cli.MutedStyle.Render("No model "+id+" in the catalog.") + "\n"
+	}
+	return s.String()
 }
 
 // printHelp displays usage information
@@ -406,31 +1270,102 @@ func printHelp() {
 	fmt.Println()
 	fmt.Println("Usage:")
 	fmt.Println("  go run main.go [options]")
+	fmt.Println("  go run main.go presets list | presets delete <name>")
 	fmt.Println()
 	fmt.Println("Filter Options:")
 	fmt.Println("  --max-cost <float>      Maximum cost per 1M input tokens (0 = no limit)")
 	fmt.Println("  --min-context <int>     Minimum context window (0 = no limit)")
+	fmt.Println("  --prompt-tokens <int>   Prompt size that must fit in the context window together")
+	fmt.Println("                          with the output budget")
+	fmt.Println("  --output-tokens <int>   Output budget; models whose max output tokens are")
+	fmt.Println("                          fewer are excluded. 0 reserves each model's default")
+	fmt.Println("                          max tokens")
 	fmt.Println("  --reasoning              Filter by reasoning capability")
 	fmt.Println("  --vision                Filter by vision capability")
+	fmt.Println("  --max-latency-tier <t>  Slowest latency tier to include: realtime, fast, standard,")
+	fmt.Println("                          or batch. Tiers come from latency_tier in overrides, then")
+	fmt.Println("                          in benchmarks; otherwise they are guessed from the model")
+	fmt.Println("                          name (realtime, mini/flash/haiku... fast, else standard)")
+	fmt.Println("  --overrides <file>      Pricing and latency tier overrides, or none (default: the")
+	fmt.Println("                          aimodels overrides.yaml, if present)")
+	fmt.Println("  --query <expr>          Filter expression combining comparisons (< <= > >= == != ~)")
+	fmt.Println("                          with && || ! and parentheses. Fields:")
+	for _, f := range query.Fields() {
+		fmt.Printf("                            %-16s %s\n", f[0], f[1])
+	}
 	fmt.Println()
 	fmt.Println("Interactive Options:")
-	fmt.Println("  --interactive            Interactive filtering mode")
+	fmt.Println("  --interactive            Interactive filtering mode; at the results, type a model")
+	fmt.Println("                          ID to look it up (Tab completes it, Up/Down choose)")
 	fmt.Println("  --compare <models>      Comma-separated list of models to compare")
 	fmt.Println()
+	fmt.Println("Scripting Options:")
+	fmt.Println("  --cheapest              Print only the cheapest model matching the filters")
+	fmt.Println("                          as \"<provider>\\t<model>\" (exit 1 if none match)")
+	fmt.Println()
+	fmt.Println("Workload Options:")
+	fmt.Println("  --workload <spec>       Rank and show models by what a workload would cost in total,")
+	fmt.Println("                          cheapest first, instead of by score. The spec is")
+	fmt.Println("                          input=<tokens>,output=<tokens>[,calls=<n>][,cached=<0-1>],")
+	fmt.Println("                          per call; models must fit one call's input and output")
+	fmt.Println("                          unless --prompt-tokens or --output-tokens is given. Applies")
+	fmt.Println("                          to search, --use-case, --cheapest, --compare, and html output")
+	fmt.Println()
+	fmt.Println("Diversity Options:")
+	fmt.Println("  --per-provider <n>      Show only the best n models of each provider, instead of the")
+	fmt.Println("                          overall top 10 (search, --use-case, and html output). Useful")
+	fmt.Println("                          for comparing vendors or staying with contracted ones")
+	fmt.Println()
+	fmt.Println("Use-Case Options:")
+	fmt.Println("  --use-case <name>       Recommend models for a task, ranked by what matters for it.")
+	fmt.Println("                          Filter flags tighten the profile's requirements. Profiles:")
+	for _, u := range selector.UseCases() {
+		fmt.Printf("                            %-28s %s\n", u.Name, strings.Join(u.Aliases, ", "))
+	}
+	fmt.Println("                          Low latency is judged from model tiers (mini, flash, haiku,")
+	fmt.Println("                          ...) since the catalog has no latency data. Models without")
+	fmt.Println("                          a listed price are skipped.")
+	fmt.Println()
+	fmt.Println("Quality Options:")
+	fmt.Println("  --benchmarks <src>      Benchmark dataset (JSON file or URL) keyed by model ID,")
+	fmt.Println("                          e.g. {\"gpt-4o\": {\"mmlu\": 88.7, \"gpqa\": 53.6, \"swe_bench\": 33.2}}")
+	fmt.Println("                          An entry may also give a \"latency_tier\". A model's quality")
+	fmt.Println("                          is its mean percentile rank, 0-100, among the models with")
+	fmt.Println("                          each benchmark it reports, shown with how many it reports")
+	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  go run main.go --max-cost 1.0 --min-context 100000")
 	fmt.Println("  go run main.go --reasoning --vision")
 	fmt.Println("  go run main.go --interactive")
+	fmt.Println("  go run main.go --use-case \"code review\"")
+	fmt.Println("  go run main.go --use-case \"long-document summarization\" --max-cost 1")
+	fmt.Println("  go run main.go --reasoning --per-provider 2")
+	fmt.Println("  go run main.go --max-latency-tier fast --vision")
+	fmt.Println("  go run main.go --reasoning --workload input=2000,output=800,calls=10000")
 	fmt.Println("  go run main.go --compare \"gpt-4o,claude-3-opus\"")
+	fmt.Println("  go run main.go --reasoning --benchmarks scores.json")
+	fmt.Println("  go run main.go --cheapest --vision --min-context 200000")
+	fmt.Println("  go run main.go --prompt-tokens 150000 --output-tokens 16000")
+	fmt.Println("  go run main.go --query 'cost_in < 1 && context >= 128000 && (reason || vision)'")
+	fmt.Println("  go run main.go --query 'provider == \"openrouter\" && id ~ \"claude\"'")
+	fmt.Println("  go run main.go --vision --max-cost 1 --min-context 128000 --save-as cheap-vision")
+	fmt.Println("  go run main.go --preset cheap-vision --cheapest")
+	fmt.Println("  go run main.go presets list")
 	fmt.Println()
-	fmt.Println("Environment Variables:")
-	fmt.Println("  CATWALK_URL - URL of the catwalk service (default: http://localhost:8080)")
-}
-
-// min returns the minimum of two integers
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
+	fmt.Println("Preset Options:")
+	fmt.Println("  --save-as <name>        Save the filters of this search (the filter, use-case,")
+	fmt.Println("                          per-provider, workload, and benchmark flags) under a name,")
+	fmt.Println("                          in aimodels/find-models.yaml in the config directory")
+	fmt.Println("  --preset <name>         Search with saved filters; flags given with it add to or")
+	fmt.Println("                          replace them, and --save-as saves the combination")
+	fmt.Println("  presets list            List saved presets with their flags")
+	fmt.Println("  presets delete <name>   Delete a saved preset")
+	fmt.Println()
+	fmt.Println("Output Options:")
+	fmt.Println("  --format <fmt>          text (default) or html: a standalone report with a sortable")
+	fmt.Println("                          table, price chart, and capability matrix (search and compare)")
+	fmt.Println()
+	cli.PrintCatalogHelp()
+	cli.PrintNetworkHelp()
+	cli.PrintEnvHelp()
 }
@@ -5,6 +5,7 @@
 // - Filtering models by capabilities (reasoning, vision)
 // - Sorting models by various criteria
 // - Formatting output in table, JSON, and CSV formats
+// - Custom output shapes with Go templates
 //
 // Usage:
 //
@@ -13,14 +14,16 @@
 //	go run main.go --provider openai --sort cost          # Sort by cost
 //	go run main.go --provider openai --format json        # Output in JSON format
 //	go run main.go --provider openai --format csv         # Output in CSV format
+//	go run main.go --provider openai --template '{{.Name}}\t{{.CostPer1MIn}}'
 //	go run main.go --help                               # Show help message
 //
 // Environment Variables:
 //
-//	CATWALK_URL - URL of the catwalk service (default: http://localhost:8080)
+//	CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/csv"
 	"encoding/json"
@@ -28,38 +31,41 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"sort"
+	"slices"
 	"strconv"
 	"strings"
+	"text/template"
 
+	"charm.land/catwalk/internal/cli"
 	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/snapshot"
+	"charm.land/catwalk/pkg/transport"
 	"github.com/charmbracelet/lipgloss"
 )
 
 var (
 	// Command-line flags
-	providerID   = flag.String("provider", "", "Provider ID (required)")
-	reasoning    = flag.Bool("reasoning", false, "Filter by reasoning capability")
-	vision       = flag.Bool("vision", false, "Filter by vision capability")
-	sortBy       = flag.String("sort", "name", "Sort by: name, cost, context")
-	outputFormat = flag.String("format", "table", "Output format: table, json, or csv")
-	showHelp     = flag.Bool("help", false, "Show help message")
+	providerID     = flag.String("provider", "", "Provider ID (required)")
+	reasoning      = flag.Bool("reasoning", false, "Filter by reasoning capability")
+	vision         = flag.Bool("vision", false, "Filter by vision capability")
+	sortBy         = flag.String("sort", "name", "Sort by: name, cost, context")
+	outputFormat   = flag.String("format", "table", "Output format: table, json, or csv")
+	tmplText       = flag.String("template", "", "Go template applied to each model (overrides --format)")
+	catalogVersion = flag.String("catalog-version", "", "Use a stored catalog snapshot (ETag, YYYY-MM-DD, or latest) instead of live data")
+	network        = transport.RegisterFlags(flag.CommandLine)
+	showHelp       = flag.Bool("help", false, "Show help message")
 )
 
 // Styles for table formatting
 var (
-	headerStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("86"))
-	nameStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
-	idStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
-	typeStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
-	costStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("228"))
-	contextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("81"))
-	capStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("120"))
-	borderStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
-	dividerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	nameStyle = lipgloss.NewStyle().Foreground(cli.Colors.Accent)
+	idStyle   = lipgloss.NewStyle().Foreground(cli.Colors.Muted)
+	typeStyle = lipgloss.NewStyle().Foreground(cli.Colors.Secondary)
 )
 
 func main() {
+	render.SetupConsole()
 	flag.Parse()
 
 	if *showHelp {
@@ -73,11 +79,15 @@ func main() {
 	}
 
 	// Create catwalk client
-	client := catwalk.New()
+	httpClient, err := network.Client()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	client := catwalk.NewWithHTTPClient(httpClient)
 	ctx := context.Background()
 
-	// Fetch providers
-	providers, err := client.GetProviders(ctx, "")
+	// Fetch providers (live, or from a pinned snapshot)
+	providers, err := snapshot.Fetch(ctx, client, *catalogVersion)
 	if err != nil {
 		if err == catwalk.ErrNotModified {
 			log.Println("Data not modified (cached)")
@@ -105,6 +115,12 @@ func main() {
 	// Sort models
 	sortModels(models, *sortBy)
 
+	// A template takes precedence over --format
+	if *tmplText != "" {
+		outputTemplate(provider, models, *tmplText)
+		return
+	}
+
 	// Output in requested format
 	switch strings.ToLower(*outputFormat) {
 	case "json":
@@ -143,17 +159,11 @@ func filterModels(models []catwalk.Model) []catwalk.Model {
 func sortModels(models []catwalk.Model, sortBy string) {
 	switch strings.ToLower(sortBy) {
 	case "cost":
-		sort.Slice(models, func(i, j int) bool {
-			return models[i].CostPer1MIn < models[j].CostPer1MIn
-		})
+		slices.SortFunc(models, func(a, b catwalk.Model) int { return cmp.Compare(a.CostPer1MIn, b.CostPer1MIn) })
 	case "context":
-		sort.Slice(models, func(i, j int) bool {
-			return models[i].ContextWindow > models[j].ContextWindow
-		})
+		slices.SortFunc(models, func(a, b catwalk.Model) int { return cmp.Compare(b.ContextWindow, a.ContextWindow) })
 	default: // name
-		sort.Slice(models, func(i, j int) bool {
-			return models[i].Name < models[j].Name
-		})
+		slices.SortFunc(models, func(a, b catwalk.Model) int { return cmp.Compare(a.Name, b.Name) })
 	}
 }
 
@@ -165,61 +175,31 @@ func outputTable(provider *catwalk.Provider, models []catwalk.Model) {
 	}
 
 	// Print header
-	fmt.Printf("%s: %s\n", headerStyle.Render("Provider"), nameStyle.Render(provider.Name))
-	fmt.Printf("%s: %s\n", headerStyle.Render("Type"), typeStyle.Render(string(provider.Type)))
-	fmt.Printf("%s: %d\n\n", headerStyle.Render("Models"), len(models))
-
-	// Print table header
-	fmt.Println(dividerStyle.Render("─┬──────────────────────────────────────────────┬──────────┬─────────┬────────┬────────┐"))
-	fmt.Printf("%s %-42s %s %8s %s %7s %s %6s %s %6s %s\n",
-		dividerStyle.Render("│"),
-		nameStyle.Render("Model Name"),
-		dividerStyle.Render("│"),
-		costStyle.Render("Cost/1M"),
-		dividerStyle.Render("│"),
-		contextStyle.Render("Context"),
-		dividerStyle.Render("│"),
-		capStyle.Render("Reas"),
-		dividerStyle.Render("│"),
-		capStyle.Render("Vis"),
-		dividerStyle.Render("│"))
-	fmt.Println(dividerStyle.Render("─┼──────────────────────────────────────────────┼──────────┼─────────┼────────┼────────┤"))
-
-	// Print each model
+	fmt.Printf("%s: %s\n", cli.HeaderStyle.Render("Provider"), nameStyle.Render(provider.Name))
+	fmt.Printf("%s: %s\n", cli.HeaderStyle.Render("Type"), typeStyle.Render(string(provider.Type)))
+	fmt.Printf("%s: %d\n\n", cli.HeaderStyle.Render("Models"), len(models))
+
+	// Print table, sized to the terminal
+	tbl := render.NewTable(
+		render.Column{Title: "Model Name", MinWidth: 16, Style: nameStyle},
+		render.Column{Title: "Cost/1M", Align: render.AlignRight, Style: cli.CostStyle},
+		render.Column{Title: "Context", Align: render.AlignRight, Style: cli.ContextStyle},
+		render.Column{Title: "Reas", Style: cli.CapabilityStyle},
+		render.Column{Title: "Vis", Style: cli.CapabilityStyle},
+	)
+	tbl.HeaderStyle = cli.HeaderStyle
+	tbl.BorderStyle = cli.MutedStyle
 	for _, m := range models {
-		name := m.Name
-		if len(name) > 40 {
-			name = name[:37] + "..."
-		}
-
-		reasoning := " "
-		if m.CanReason {
-			reasoning = "✓"
-		}
-
-		vision := " "
-		if m.SupportsImages {
-			vision = "✓"
-		}
-
-		fmt.Printf("%s %-42s %s %8.2f %s %7dK %s %6s %s %6s %s\n",
-			dividerStyle.Render("│"),
-			nameStyle.Render(name),
-			dividerStyle.Render("│"),
-			m.CostPer1MIn,
-			dividerStyle.Render("│"),
-			m.ContextWindow/1000,
-			dividerStyle.Render("│"),
-			capStyle.Render(reasoning),
-			dividerStyle.Render("│"),
-			capStyle.Render(vision),
-			dividerStyle.Render("│"))
+		tbl.AddRow(
+			m.Name,
+			fmt.Sprintf("%.2f", m.CostPer1MIn),
+			fmt.Sprintf("%dK", m.ContextWindow/1000),
+			render.Mark(m.CanReason),
+			render.Mark(m.SupportsImages))
 	}
-
-	fmt.Println(dividerStyle.Render("─┴──────────────────────────────────────────────┴──────────┴─────────┴────────┴────────┘"))
+	tbl.Print()
 }
 
-// outputJSON displays models in JSON format
 func outputJSON(provider *catwalk.Provider, models []catwalk.Model) {
 	type ProviderWithModels struct {
 		catwalk.Provider
@@ -266,6 +246,30 @@ func outputCSV(models []catwalk.Model) {
 	}
 }
 
+// templateData is the value each model is rendered with in --template mode.
+// Model fields are promoted, so {{.Name}} is the model name and
+// {{.Provider.Name}} is the provider name.
+type templateData struct {
+	catwalk.Model
+	Provider catwalk.Provider
+}
+
+// outputTemplate renders each model with a user-supplied Go template
+func outputTemplate(provider *catwalk.Provider, models []catwalk.Model, text string) {
+	text = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(text)
+	tmpl, err := template.New("output").Parse(text)
+	if err != nil {
+		log.Fatalf("Error parsing template: %v", err)
+	}
+
+	for _, m := range models {
+		if err := tmpl.Execute(os.Stdout, templateData{Model: m, Provider: *provider}); err != nil {
+			log.Fatalf("Error executing template: %v", err)
+		}
+		fmt.Println()
+	}
+}
+
 // printHelp displays usage information
 func printHelp() {
 	fmt.Println("list-models - List models from a specific provider")
@@ -285,13 +289,18 @@ func printHelp() {
 	fmt.Println()
 	fmt.Println("Output Options:")
 	fmt.Println("  --format <fmt>     Output format: table (default), json, csv")
+	fmt.Println("  --template <tmpl>  Go template applied to each model (overrides --format)")
+	fmt.Println("                     Fields: .ID .Name .CostPer1MIn .CostPer1MOut .ContextWindow")
+	fmt.Println("                     .CanReason .SupportsImages .Provider.Name ...")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  go run main.go --provider openai")
 	fmt.Println("  go run main.go --provider anthropic --reasoning --sort cost")
 	fmt.Println("  go run main.go --provider google --format json")
 	fmt.Println("  go run main.go --provider openai --vision --format csv")
+	fmt.Println("  go run main.go --provider openai --template '{{.ID}}\\t{{.CostPer1MIn}}'")
 	fmt.Println()
-	fmt.Println("Environment Variables:")
-	fmt.Println("  CATWALK_URL - URL of the catwalk service (default: http://localhost:8080)")
+	cli.PrintCatalogHelp()
+	cli.PrintNetworkHelp()
+	cli.PrintEnvHelp()
 }
@@ -1,32 +1,44 @@
 // Package main provides a CLI tool to list all available AI providers.
 //
 // This example demonstrates:
-// - Using the catwalk client to fetch providers
-// - Handling ETag support for efficient caching
-// - Formatting output in table and JSON formats
-// - Filtering providers by type
+//   - Using the catwalk client to fetch providers
+//   - Handling ETag support for efficient caching: --etag and --if-modified
+//     skip the download when the catalog has not changed, exiting with status 7
+//   - Formatting output in table and JSON formats
+//   - Filtering providers by type
+//   - Custom output shapes with Go templates
 //
 // Usage:
-//   go run main.go                    # List all providers in table format
-//   go run main.go --type openai       # List only OpenAI-compatible providers
-//   go run main.go --format json       # Output in JSON format
-//   go run main.go --help             # Show help message
+//
+//	go run main.go                    # List all providers in table format
+//	go run main.go --type openai       # List only OpenAI-compatible providers
+//	go run main.go --format json       # Output in JSON format
+//	go run main.go --template '{{.ID}}\t{{len .Models}}'
+//	go run main.go --if-modified etag.txt --format json > providers.json
+//	go run main.go --help             # Show help message
 //
 // Environment Variables:
-//   CATWALK_URL - URL of the catwalk service (default: http://localhost:8080)
+//
+//	CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"sort"
+	"slices"
 	"strings"
+	"text/template"
 
+	"charm.land/catwalk/internal/cli"
 	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/transport"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -34,20 +46,23 @@ var (
 	// Command-line flags
 	providerType = flag.String("type", "", "Filter by provider type (e.g., openai, anthropic, google)")
 	outputFormat = flag.String("format", "table", "Output format: table or json")
-	showHelp    = flag.Bool("help", false, "Show help message")
+	tmplText     = flag.String("template", "", "Go template applied to each provider (overrides --format)")
+	etag         = flag.String("etag", "", "ETag from a previous run; exit with status 7 if the catalog has not changed")
+	ifModified   = flag.String("if-modified", "", "File with the last ETag: fetch only if the catalog changed, then store the new ETag there")
+	network      = transport.RegisterFlags(flag.CommandLine)
+	showHelp     = flag.Bool("help", false, "Show help message")
 )
 
 // Styles for table formatting
 var (
-	headerStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("86"))
-	nameStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
-	idStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
-	typeStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("81"))
-	countStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("228"))
-	borderStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	nameStyle  = lipgloss.NewStyle().Foreground(cli.Colors.Accent)
+	idStyle    = lipgloss.NewStyle().Foreground(cli.Colors.Muted)
+	typeStyle  = lipgloss.NewStyle().Foreground(cli.Colors.Secondary)
+	countStyle = lipgloss.NewStyle().Foreground(cli.Colors.Highlight)
 )
 
 func main() {
+	render.SetupConsole()
 	flag.Parse()
 
 	if *showHelp {
@@ -56,15 +71,28 @@ func main() {
 	}
 
 	// Create catwalk client
-	client := catwalk.New()
+	httpClient, err := network.Client()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	client := catwalk.NewWithHTTPClient(httpClient)
 	ctx := context.Background()
 
-	// Fetch providers with ETag support
-	providers, err := client.GetProviders(ctx, "")
+	if *etag != "" && *ifModified != "" {
+		log.Fatal("Error: --etag and --if-modified cannot be used together")
+	}
+	previous := *etag
+	if *ifModified != "" {
+		previous = readETag(*ifModified)
+	}
+
+	// Fetch providers with ETag support: an unchanged catalog is not
+	// downloaded, and the distinct exit status lets cron jobs skip their work
+	providers, current, err := client.GetProvidersWithETag(ctx, previous)
 	if err != nil {
-		if err == catwalk.ErrNotModified {
-			log.Println("Data not modified (cached)")
-			return
+		if errors.Is(err, catwalk.ErrNotModified) {
+			fmt.Fprintln(os.Stderr, "Catalog not modified since ETag", previous)
+			os.Exit(catwalk.ExitCode(err))
 		}
 		log.Fatalf("Error fetching providers: %v", err)
 	}
@@ -81,19 +109,43 @@ func main() {
 	}
 
 	// Sort providers by name
-	sort.Slice(providers, func(i, j int) bool {
-		return providers[i].Name < providers[j].Name
-	})
+	slices.SortFunc(providers, func(a, b catwalk.Provider) int { return cmp.Compare(a.Name, b.Name) })
 
-	// Output in requested format
-	switch strings.ToLower(*outputFormat) {
-	case "json":
+	// Output in requested format; a template takes precedence over --format
+	switch format := strings.ToLower(*outputFormat); {
+	case *tmplText != "":
+		outputTemplate(providers, *tmplText)
+	case format == "json":
 		outputJSON(providers)
-	case "table":
+	case format == "table":
 		outputTable(providers)
 	default:
 		log.Fatalf("Unknown format: %s (use 'table' or 'json')", *outputFormat)
 	}
+
+	// Remember the ETag only once the output is written, so a failed run is
+	// retried in full next time
+	switch {
+	case current == "":
+	case *ifModified != "":
+		if err := os.WriteFile(*ifModified, []byte(current+"\n"), 0o644); err != nil { //nolint:gosec
+			log.Fatalf("Error saving ETag: %v", err)
+		}
+	case *etag != "":
+		fmt.Fprintln(os.Stderr, "ETag:", current)
+	}
+}
+
+// readETag returns the ETag stored in path, or "" if there is none yet
+func readETag(path string) string {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ""
+	}
+	if err != nil {
+		log.Fatalf("Error reading ETag: %v", err)
+	}
+	return strings.TrimSpace(string(data))
 }
 
 // outputTable displays providers in a formatted table
@@ -104,8 +156,8 @@ func outputTable(providers []catwalk.Provider) {
 	}
 
 	// Print header
-	fmt.Println(headerStyle.Render("Available AI Providers"))
-	fmt.Println(borderStyle.Render(strings.Repeat("─", 80)))
+	fmt.Println(cli.HeaderStyle.Render("Available AI Providers"))
+	fmt.Println(cli.BorderStyle.Render(render.Rule(80)))
 	fmt.Println()
 
 	// Print each provider
@@ -124,7 +176,7 @@ func outputTable(providers []catwalk.Provider) {
 		fmt.Println()
 	}
 
-	fmt.Printf(borderStyle.Render("Total: %d providers\n"), len(providers))
+	fmt.Printf(cli.BorderStyle.Render("Total: %d providers\n"), len(providers))
 }
 
 // outputJSON displays providers in JSON format
@@ -136,6 +188,22 @@ func outputJSON(providers []catwalk.Provider) {
 	}
 }
 
+// outputTemplate renders each provider with a user-supplied Go template
+func outputTemplate(providers []catwalk.Provider, text string) {
+	text = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(text)
+	tmpl, err := template.New("output").Parse(text)
+	if err != nil {
+		log.Fatalf("Error parsing template: %v", err)
+	}
+
+	for _, p := range providers {
+		if err := tmpl.Execute(os.Stdout, p); err != nil {
+			log.Fatalf("Error executing template: %v", err)
+		}
+		fmt.Println()
+	}
+}
+
 // printHelp displays usage information
 func printHelp() {
 	fmt.Println("list-providers - List all available AI providers")
@@ -150,7 +218,15 @@ func printHelp() {
 	fmt.Println("  go run main.go                           # List all providers")
 	fmt.Println("  go run main.go --type openai               # List OpenAI providers only")
 	fmt.Println("  go run main.go --format json               # Output as JSON")
+	fmt.Println("  go run main.go --template '{{.ID}}\\t{{.Type}}\\t{{len .Models}}'")
+	fmt.Println()
+	fmt.Println("Conditional Fetch:")
+	fmt.Println("  --etag <value>         Send the ETag from a previous run; the new ETag is printed")
+	fmt.Println("                         to stderr")
+	fmt.Println("  --if-modified <file>   Read the last ETag from file and store the new one there")
+	fmt.Println("  If the catalog has not changed, stdout is left empty and the exit status is 7:")
+	fmt.Println("    go run main.go --if-modified etag.txt --format json > new.json && mv new.json providers.json")
 	fmt.Println()
-	fmt.Println("Environment Variables:")
-	fmt.Println("  CATWALK_URL - URL of the catwalk service (default: http://localhost:8080)")
+	cli.PrintNetworkHelp()
+	cli.PrintEnvHelp()
 }
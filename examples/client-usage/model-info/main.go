@@ -6,15 +6,19 @@
 // - Showing pricing breakdown (cached/uncached)
 // - Displaying reasoning levels and default settings
 // - Exporting model configuration as JSON
+// - Custom output shapes with Go templates
 //
 // Usage:
-//   go run main.go --model "gpt-4o"                     # Show model info
-//   go run main.go --model "claude-3-opus" --provider anthropic  # Specify provider
-//   go run main.go --model "gpt-4o" --export              # Export as JSON
-//   go run main.go --help                                  # Show help message
+//
+//	go run main.go --model "gpt-4o"                     # Show model info
+//	go run main.go --model "claude-3-opus" --provider anthropic  # Specify provider
+//	go run main.go --model "gpt-4o" --export              # Export as JSON
+//	go run main.go --model "gpt-4o" --template '{{.Name}}\t{{.CostPer1MIn}}'
+//	go run main.go --help                                  # Show help message
 //
 // Environment Variables:
-//   CATWALK_URL - URL of the catwalk service (default: http://localhost:8080)
+//
+//	CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)
 package main
 
 import (
@@ -23,35 +27,40 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"maps"
 	"os"
+	"slices"
 	"strings"
+	"text/template"
 
+	"charm.land/catwalk/internal/cli"
 	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/snapshot"
+	"charm.land/catwalk/pkg/transport"
 	"github.com/charmbracelet/lipgloss"
 )
 
 var (
 	// Command-line flags
-	modelName   = flag.String("model", "", "Model name or ID (required)")
-	providerID  = flag.String("provider", "", "Provider ID (optional, if model ID is unique)")
-	exportJSON  = flag.Bool("export", false, "Export model configuration as JSON")
-	showHelp    = flag.Bool("help", false, "Show help message")
+	modelName      = flag.String("model", "", "Model name or ID (required)")
+	providerID     = flag.String("provider", "", "Provider ID (optional, if model ID is unique)")
+	exportJSON     = flag.Bool("export", false, "Export model configuration as JSON")
+	tmplText       = flag.String("template", "", "Go template applied to the model")
+	catalogVersion = flag.String("catalog-version", "", "Use a stored catalog snapshot (ETag, YYYY-MM-DD, or latest) instead of live data")
+	network        = transport.RegisterFlags(flag.CommandLine)
+	showHelp       = flag.Bool("help", false, "Show help message")
 )
 
 // Styles for formatting
 var (
-	headerStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("86"))
-	labelStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("245"))
-	valueStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("255"))
-	nameStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
-	costStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("228"))
-	contextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("81"))
-	capStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("120"))
-	borderStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
-	dividerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	labelStyle = lipgloss.NewStyle().Bold(true).Foreground(cli.Colors.Muted)
+	valueStyle = lipgloss.NewStyle().Foreground(cli.Colors.Text)
+	nameStyle  = lipgloss.NewStyle().Bold(true).Foreground(cli.Colors.Accent)
 )
 
 func main() {
+	render.SetupConsole()
 	flag.Parse()
 
 	if *showHelp {
@@ -65,11 +74,15 @@ func main() {
 	}
 
 	// Create catwalk client
-	client := catwalk.New()
+	httpClient, err := network.Client()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	client := catwalk.NewWithHTTPClient(httpClient)
 	ctx := context.Background()
 
-	// Fetch providers
-	providers, err := client.GetProviders(ctx, "")
+	// Fetch providers (live, or from a pinned snapshot)
+	providers, err := snapshot.Fetch(ctx, client, *catalogVersion)
 	if err != nil {
 		if err == catwalk.ErrNotModified {
 			log.Println("Data not modified (cached)")
@@ -108,6 +121,12 @@ func main() {
 		log.Fatalf("Model not found: %s", *modelName)
 	}
 
+	// Render with a template if requested
+	if *tmplText != "" {
+		outputTemplate(foundProvider, foundModel, *tmplText)
+		return
+	}
+
 	// Export as JSON if requested
 	if *exportJSON {
 		exportModelJSON(foundProvider, foundModel)
@@ -122,8 +141,8 @@ func main() {
 func displayModelInfo(provider *catwalk.Provider, model *catwalk.Model) {
 	// Print header
 	fmt.Println()
-	fmt.Println(headerStyle.Render("Model Information"))
-	fmt.Println(borderStyle.Render(strings.Repeat("═", 80)))
+	fmt.Println(cli.HeaderStyle.Render("Model Information"))
+	fmt.Println(cli.BorderStyle.Render(render.DoubleRule(80)))
 	fmt.Println()
 
 	// Basic information
@@ -134,22 +153,22 @@ func displayModelInfo(provider *catwalk.Provider, model *catwalk.Model) {
 	fmt.Println()
 
 	// Pricing
-	fmt.Println(headerStyle.Render("Pricing"))
-	fmt.Println(dividerStyle.Render(strings.Repeat("─", 40)))
+	fmt.Println(cli.HeaderStyle.Render("Pricing"))
+	fmt.Println(cli.MutedStyle.Render(render.Rule(40)))
 	fmt.Printf("%s $%.2f per 1M input tokens\n", labelStyle.Render("Input Cost:"), model.CostPer1MIn)
 	fmt.Printf("%s $%.2f per 1M output tokens\n", labelStyle.Render("Output Cost:"), model.CostPer1MOut)
 
 	if model.CostPer1MInCached > 0 || model.CostPer1MOutCached > 0 {
 		fmt.Println()
-		fmt.Println(costStyle.Render("Cached Pricing (with prompt caching):"))
+		fmt.Println(cli.CostStyle.Render("Cached Pricing (with prompt caching):"))
 		fmt.Printf("%s $%.2f per 1M cached input tokens\n", labelStyle.Render("Input:"), model.CostPer1MInCached)
 		fmt.Printf("%s $%.2f per 1M cached output tokens\n", labelStyle.Render("Output:"), model.CostPer1MOutCached)
 	}
 	fmt.Println()
 
 	// Capabilities
-	fmt.Println(headerStyle.Render("Capabilities"))
-	fmt.Println(dividerStyle.Render(strings.Repeat("─", 40)))
+	fmt.Println(cli.HeaderStyle.Render("Capabilities"))
+	fmt.Println(cli.MutedStyle.Render(render.Rule(40)))
 	fmt.Printf("%s %dK tokens\n", labelStyle.Render("Context Window:"), model.ContextWindow/1000)
 	fmt.Printf("%s %d tokens\n", labelStyle.Render("Default Max Tokens:"), model.DefaultMaxTokens)
 	fmt.Printf("%s %s\n", labelStyle.Render("Reasoning:"), capability(model.CanReason))
@@ -158,8 +177,8 @@ func displayModelInfo(provider *catwalk.Provider, model *catwalk.Model) {
 
 	// Reasoning levels (if applicable)
 	if model.CanReason {
-		fmt.Println(headerStyle.Render("Reasoning Configuration"))
-		fmt.Println(dividerStyle.Render(strings.Repeat("─", 40)))
+		fmt.Println(cli.HeaderStyle.Render("Reasoning Configuration"))
+		fmt.Println(cli.MutedStyle.Render(render.Rule(40)))
 		if model.DefaultReasoningEffort != "" {
 			fmt.Printf("%s %s\n", labelStyle.Render("Default Level:"), valueStyle.Render(model.DefaultReasoningEffort))
 		}
@@ -170,27 +189,27 @@ func displayModelInfo(provider *catwalk.Provider, model *catwalk.Model) {
 	}
 
 	// Example usage
-	fmt.Println(headerStyle.Render("Example Usage"))
-	fmt.Println(dividerStyle.Render(strings.Repeat("─", 40)))
+	fmt.Println(cli.HeaderStyle.Render("Example Usage"))
+	fmt.Println(cli.MutedStyle.Render(render.Rule(40)))
 	fmt.Printf("%s\n", labelStyle.Render("Provider Endpoint:"))
 	fmt.Printf("  %s\n\n", valueStyle.Render(provider.APIEndpoint))
 	fmt.Printf("%s\n", labelStyle.Render("API Key:"))
 	fmt.Printf("  %s\n\n", valueStyle.Render(provider.APIKey))
 	fmt.Printf("%s\n", labelStyle.Render("Default Headers:"))
-	for key, value := range provider.DefaultHeaders {
-		fmt.Printf("  %s: %s\n", key, value)
+	for _, key := range slices.Sorted(maps.Keys(provider.DefaultHeaders)) {
+		fmt.Printf("  %s: %s\n", key, provider.DefaultHeaders[key])
 	}
 	fmt.Println()
 
-	fmt.Println(borderStyle.Render(strings.Repeat("═", 80)))
+	fmt.Println(cli.BorderStyle.Render(render.DoubleRule(80)))
 }
 
 // capability returns a styled capability indicator
 func capability(enabled bool) string {
 	if enabled {
-		return capStyle.Render("✓ Supported")
+		return cli.CapabilityStyle.Render(render.Symbol("✓", "+") + " Supported")
 	}
-	return lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Render("✗ Not supported")
+	return cli.MutedStyle.Render(render.Symbol("✗", "-") + " Not supported")
 }
 
 // exportModelJSON exports the model configuration as JSON
@@ -202,9 +221,9 @@ func exportModelJSON(provider *catwalk.Provider, model *catwalk.Model) {
 	}
 
 	type ModelExport struct {
-		Model      catwalk.Model    `json:"model"`
-		Provider   catwalk.Provider `json:"provider"`
-		APIConfig  APIConfig        `json:"api_config"`
+		Model     catwalk.Model    `json:"model"`
+		Provider  catwalk.Provider `json:"provider"`
+		APIConfig APIConfig        `json:"api_config"`
 	}
 
 	export := ModelExport{
@@ -224,6 +243,28 @@ func exportModelJSON(provider *catwalk.Provider, model *catwalk.Model) {
 	}
 }
 
+// templateData is the value the model is rendered with in --template mode.
+// Model fields are promoted, so {{.Name}} is the model name and
+// {{.Provider.Name}} is the provider name.
+type templateData struct {
+	catwalk.Model
+	Provider catwalk.Provider
+}
+
+// outputTemplate renders the model with a user-supplied Go template
+func outputTemplate(provider *catwalk.Provider, model *catwalk.Model, text string) {
+	text = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(text)
+	tmpl, err := template.New("output").Parse(text)
+	if err != nil {
+		log.Fatalf("Error parsing template: %v", err)
+	}
+
+	if err := tmpl.Execute(os.Stdout, templateData{Model: *model, Provider: *provider}); err != nil {
+		log.Fatalf("Error executing template: %v", err)
+	}
+	fmt.Println()
+}
+
 // printHelp displays usage information
 func printHelp() {
 	fmt.Println("model-info - Display detailed information about a specific model")
@@ -237,12 +278,15 @@ func printHelp() {
 	fmt.Println("Optional Options:")
 	fmt.Println("  --provider <id>    Provider ID (optional, if model ID is unique)")
 	fmt.Println("  --export           Export model configuration as JSON")
+	fmt.Println("  --template <tmpl>  Go template applied to the model (e.g. '{{.ID}}\\t{{.ContextWindow}}')")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  go run main.go --model \"gpt-4o\"")
 	fmt.Println("  go run main.go --model \"claude-3-opus\" --provider anthropic")
 	fmt.Println("  go run main.go --model \"gpt-4o\" --export > model-config.json")
+	fmt.Println("  go run main.go --model \"gpt-4o\" --template '{{.Provider.ID}}/{{.ID}}'")
 	fmt.Println()
-	fmt.Println("Environment Variables:")
-	fmt.Println("  CATWALK_URL - URL of the catwalk service (default: http://localhost:8080)")
+	cli.PrintCatalogHelp()
+	cli.PrintNetworkHelp()
+	cli.PrintEnvHelp()
 }
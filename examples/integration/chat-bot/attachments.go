@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/secrets"
+	"charm.land/catwalk/pkg/tokenizer"
+)
+
+// Limits on attached files, so a stray glob or directory does not fill the
+// context window with generated or binary files.
+const (
+	maxAttachmentSize  = 256 << 10
+	maxAttachmentFiles = 100
+)
+
+// attachment is a file to be sent with the next message.
+type attachment struct {
+	path    string
+	content string // fenced, see fenceFile
+	tokens  int
+}
+
+// handleFile attaches files to the next message, lists the attached files,
+// or with "clear" drops them.
+func handleFile(session *chatSession, args []string) {
+	switch {
+	case len(args) == 0:
+		if len(session.attachments) == 0 {
+			fmt.Println(infoStyle.Render("No files attached. Use /file <path|dir|glob> to attach some to the next message."))
+		} else {
+			printAttachments(session)
+		}
+	case len(args) == 1 && strings.EqualFold(args[0], "clear"):
+		session.attachments = nil
+		fmt.Println(infoStyle.Render("Attachments dropped."))
+	default:
+		attachFiles(session, args)
+		return
+	}
+	fmt.Println()
+}
+
+// attachFiles reads the files named by patterns, which may be paths,
+// directories, or globs, and attaches them to the next message. Files that
+// are already attached are read again.
+func attachFiles(session *chatSession, patterns []string) {
+	paths, skipped, err := expandAttachments(patterns)
+	if err != nil {
+		fmt.Println(errorStyle.Render("Error: " + err.Error()))
+		fmt.Println()
+		return
+	}
+	for _, s := range skipped {
+		fmt.Println(warnStyle.Render(render.Symbol("⚠", "!") + " Skipped " + s))
+	}
+
+	var masked []secrets.Kind
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Println(errorStyle.Render("Error: " + err.Error()))
+			continue
+		}
+		if !isText(data) {
+			fmt.Println(warnStyle.Render(render.Symbol("⚠", "!") + " Skipped " + path + ": not a text file"))
+			continue
+		}
+		content, kinds, err := filterSecrets(session, path, string(data))
+		if err != nil {
+			fmt.Println(errorStyle.Render("Not attached: " + err.Error()))
+			continue
+		}
+		masked = append(masked, kinds...)
+
+		fenced := fenceFile(path, content)
+		a := attachment{path: path, content: fenced, tokens: tokenizer.Count(fenced)}
+		session.attachments = slices.DeleteFunc(session.attachments, func(b attachment) bool { return b.path == path })
+		session.attachments = append(session.attachments, a)
+	}
+	printMasked(masked)
+	if len(session.attachments) > 0 {
+		printAttachments(session)
+	}
+	fmt.Println()
+}
+
+// expandAttachments resolves patterns to the regular files they name,
+// walking directories and skipping hidden files and directories in them.
+// It also returns descriptions of the files skipped for their size.
+func expandAttachments(patterns []string) (paths, skipped []string, err error) {
+	seen := map[string]bool{}
+	add := func(path string, size int64) error {
+		path = filepath.Clean(path)
+		switch {
+		case seen[path]:
+		case size > maxAttachmentSize:
+			skipped = append(skipped, fmt.Sprintf("%s: %d KB is over the %d KB limit", path, (size+1023)>>10, maxAttachmentSize>>10))
+		case len(paths) == maxAttachmentFiles:
+			return fmt.Errorf("more than %d files; attach fewer or use a narrower glob", maxAttachmentFiles)
+		default:
+			seen[path] = true
+			paths = append(paths, path)
+		}
+		return nil
+	}
+
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, nil, fmt.Errorf("no files match %s", pattern)
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, nil, err //nolint:wrapcheck
+			}
+			if !info.IsDir() {
+				if err := add(match, info.Size()); err != nil {
+					return nil, nil, err
+				}
+				continue
+			}
+			err = filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if path != match && strings.HasPrefix(d.Name(), ".") {
+					if d.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if !d.Type().IsRegular() {
+					return nil
+				}
+				info, err := d.Info()
+				if err != nil {
+					return err //nolint:wrapcheck
+				}
+				return add(path, info.Size())
+			})
+			if err != nil {
+				return nil, nil, err //nolint:wrapcheck
+			}
+		}
+	}
+	return paths, skipped, nil
+}
+
+// isText reports whether data looks like text rather than a binary file.
+func isText(data []byte) bool {
+	return utf8.Valid(data) && !bytes.ContainsRune(data, 0)
+}
+
+// fenceFile wraps a file's content in a Markdown code block, headed by its
+// path and tagged with its extension. The fence is made longer than any run
+// of backticks in the content, so Markdown files stay intact.
+func fenceFile(path, content string) string {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	lang := strings.TrimPrefix(filepath.Ext(path), ".")
+	return filepath.ToSlash(path) + ":\n" + fence + lang + "\n" + content + fence
+}
+
+// withAttachments returns the message text with the attached files before
+// it.
+func withAttachments(attachments []attachment, text string) string {
+	if len(attachments) == 0 {
+		return text
+	}
+	parts := make([]string, 0, len(attachments)+1)
+	for _, a := range attachments {
+		parts = append(parts, a.content)
+	}
+	return strings.Join(append(parts, text), "\n\n")
+}
+
+func attachmentTokens(attachments []attachment) int {
+	n := 0
+	for _, a := range attachments {
+		n += a.tokens
+	}
+	return n
+}
+
+// plural returns "s" unless n is 1.
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// printAttachments lists the attached files with their token counts, and
+// warns if they would not fit in the context window alongside the
+// conversation and reply.
+func printAttachments(session *chatSession) {
+	n := len(session.attachments)
+	tokens := attachmentTokens(session.attachments)
+	fmt.Println(infoStyle.Render(fmt.Sprintf("Attached to the next message: %d file%s, ~%s tokens",
+		n, plural(n), formatCount(int64(tokens)))))
+	for _, a := range session.attachments {
+		fmt.Printf("  %-40s %s\n", a.path, infoStyle.Render("~"+formatCount(int64(a.tokens))+" tokens"))
+	}
+
+	window := session.model.ContextWindow
+	if window <= 0 {
+		return
+	}
+	needed := int64(session.chat.ContextTokens() + tokens + session.chat.ReplyTokens())
+	if needed > window {
+		fmt.Println(warnStyle.Render(fmt.Sprintf(
+			"%s With the conversation and reply, the next request needs ~%s tokens but the context window is %s. Use /file clear, or attach fewer files.",
+			render.Symbol("⚠", "!"), formatCount(needed), formatCount(window))))
+	} else if share := float64(tokens) / float64(window) * 100; share >= 50 {
+		fmt.Println(warnStyle.Render(fmt.Sprintf("%s The attachments take %.0f%% of the context window.",
+			render.Symbol("⚠", "!"), share)))
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/usage"
+)
+
+// journal is the conversation state autosaved after each turn, so a chat
+// cut short by a crash or a closed terminal can be resumed.
+type journal struct {
+	PID       int            `json:"pid"`
+	SessionID string         `json:"session_id"`
+	Provider  string         `json:"provider"`
+	Model     string         `json:"model"`
+	Preset    string         `json:"preset,omitempty"`
+	Cost      float64        `json:"cost"`
+	Saved     time.Time      `json:"saved"`
+	Messages  []chat.Message `json:"messages"`
+	// Requests are the input and output tokens of each request, for /whatif.
+	Requests [][2]int64 `json:"requests,omitempty"`
+}
+
+// journalMaxAge is how long an abandoned journal is kept.
+const journalMaxAge = 30 * 24 * time.Hour
+
+// journalDir returns the directory journals are kept in,
+// <user cache dir>/aimodels/chat-bot.
+func journalDir() (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine cache directory: %w", err)
+	}
+	return filepath.Join(cache, "aimodels", "chat-bot"), nil
+}
+
+// saveJournal writes the session's journal, or removes it while there is no
+// conversation to resume. The file is replaced by a rename, so a crash while
+// saving leaves the previous turn's journal.
+func (s *chatSession) saveJournal() {
+	if s.journal == "" {
+		return
+	}
+	messages := s.chat.Messages()
+	if !slices.ContainsFunc(messages, func(m chat.Message) bool { return m.Role != chat.RoleSystem }) {
+		s.discardJournal()
+		return
+	}
+
+	j := journal{
+		PID:       os.Getpid(),
+		SessionID: s.sessionID,
+		Provider:  string(s.provider.ID),
+		Model:     s.model.ID,
+		Preset:    s.preset,
+		Cost:      s.priorCost + s.chat.Usage().Cost,
+		Saved:     time.Now(),
+		Messages:  messages,
+	}
+	for _, r := range s.requests {
+		j.Requests = append(j.Requests, [2]int64{r.InputTokens, r.OutputTokens})
+	}
+	data, err := json.Marshal(j)
+	if err == nil {
+		tmp := s.journal + ".tmp"
+		if err = os.WriteFile(tmp, data, 0o600); err == nil {
+			err = os.Rename(tmp, s.journal)
+		}
+	}
+	if err != nil {
+		fmt.Println(warnStyle.Render(render.Symbol("⚠", "!") + " Autosave: " + err.Error()))
+	}
+}
+
+// discardJournal removes the session's journal once the chat has ended
+// normally.
+func (s *chatSession) discardJournal() {
+	if s.journal != "" {
+		_ = os.Remove(s.journal)
+	}
+}
+
+// offerResume looks for the latest journal of a chat that did not end
+// normally and asks whether to continue it. Journals of chats still running
+// are left alone; the one offered is removed whatever the answer, as are
+// journals older than journalMaxAge.
+func offerResume(session *chatSession) {
+	dir := filepath.Dir(session.journal)
+	entries, _ := os.ReadDir(dir)
+	var found *journal
+	var foundPath string
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		var j journal
+		data, err := os.ReadFile(path)
+		if err != nil || json.Unmarshal(data, &j) != nil || processAlive(j.PID) {
+			continue
+		}
+		if time.Since(j.Saved) > journalMaxAge {
+			_ = os.Remove(path)
+			continue
+		}
+		if found == nil || j.Saved.After(found.Saved) {
+			found, foundPath = &j, path
+		}
+	}
+	if found == nil {
+		return
+	}
+
+	fmt.Print(warnStyle.Render(fmt.Sprintf("Resume the unfinished session with %s/%s from %s (%d messages, $%.4f)? [Y/n] ",
+		found.Provider, found.Model, found.Saved.Format("Jan 2 15:04"), len(found.Messages), found.Cost)))
+	answer := strings.ToLower(readAnswer())
+	_ = os.Remove(foundPath)
+	if answer != "" && answer != "y" && answer != "yes" {
+		fmt.Println(infoStyle.Render("Discarded."))
+		fmt.Println()
+		return
+	}
+
+	// Keep the current system prompt unless the session had its own, as
+	// /import does
+	messages := found.Messages
+	if current := session.chat.Messages(); messages[0].Role != chat.RoleSystem &&
+		len(current) > 0 && current[0].Role == chat.RoleSystem {
+		messages = append([]chat.Message{current[0]}, messages...)
+	}
+	session.chat.SetMessages(messages)
+	if session.preset == "" {
+		session.preset = found.Preset
+	}
+	for _, r := range found.Requests {
+		session.requests = append(session.requests, usage.Record{Requests: 1, InputTokens: r[0], OutputTokens: r[1]})
+	}
+	// Continue under the old ID, so the transcript groups both parts
+	session.sessionID = found.SessionID
+	session.journal = filepath.Join(dir, found.SessionID+".json")
+	session.priorCost = found.Cost
+	session.saveJournal()
+
+	fmt.Println(infoStyle.Render(fmt.Sprintf("Resumed %d messages. Replies now come from %s; the earlier $%.4f is not counted in this session's cost or --budget.",
+		len(found.Messages), session.model.Name, found.Cost)))
+	printContextUsage(session)
+	fmt.Println()
+}
+
+// readAnswer reads a line from stdin a byte at a time, so nothing after it
+// is buffered away from the chat loop.
+func readAnswer() string {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := os.Stdin.Read(b)
+		if n == 0 || err != nil || b[0] == '\n' {
+			return strings.TrimSpace(string(line))
+		}
+		line = append(line, b[0])
+	}
+}
+
+// processAlive reports whether a process is running. On Windows, finding
+// the process is enough; elsewhere FindProcess always succeeds, so it is
+// sent the null signal.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil || pid <= 0 {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	return p.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,471 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/commands"
+	"charm.land/catwalk/pkg/policy"
+	"charm.land/catwalk/pkg/prompts"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/secrets"
+	"charm.land/catwalk/pkg/tokenizer"
+	"charm.land/catwalk/pkg/transcript"
+)
+
+func handleCommand(session *chatSession, cmd string) bool {
+	if fields := strings.Fields(cmd); strings.EqualFold(fields[0], "/set") {
+		handleSet(session, fields[1:])
+		return true
+	} else if strings.EqualFold(fields[0], "/preset") {
+		handlePreset(session, fields[1:])
+		return true
+	} else if strings.EqualFold(fields[0], "/import") {
+		handleImport(session, fields[1:])
+		return true
+	} else if strings.EqualFold(fields[0], "/file") {
+		handleFile(session, fields[1:])
+		return true
+	} else if strings.EqualFold(fields[0], "/whatif") {
+		handleWhatIf(session, fields[1:])
+		return true
+	} else if strings.EqualFold(fields[0], "/model") {
+		handleModel(session, fields[1:])
+		return true
+	} else if strings.EqualFold(fields[0], "/save-last") {
+		handleSaveLast(session, fields[1:])
+		return true
+	} else if strings.EqualFold(fields[0], "/good") || strings.EqualFold(fields[0], "/bad") {
+		handleRate(session, fields[0], strings.TrimSpace(cmd[len(fields[0]):]))
+		return true
+	}
+
+	switch strings.ToLower(cmd) {
+	case "/quit", "/exit", "/q":
+		fmt.Println()
+		printSessionSummary(session)
+		return false
+
+	case "/clear":
+		// Keeps the system message if present
+		session.chat.Clear()
+		session.warnedAt = 0
+		fmt.Println(infoStyle.Render("Conversation cleared."))
+		fmt.Println()
+		return true
+
+	case "/cost":
+		fmt.Println()
+		fmt.Println(infoStyle.Render("Session Statistics:"))
+		usage := session.chat.Usage()
+		fmt.Printf("  Messages: %d\n", len(session.chat.Messages()))
+		fmt.Printf("  Total tokens: %d\n", usage.InputTokens+usage.OutputTokens)
+		if usage.ReasoningTokens > 0 {
+			fmt.Printf("  Reasoning tokens: %d (billed as output)\n", usage.ReasoningTokens)
+		}
+		fmt.Printf("  Total cost: $%.6f\n", usage.Cost)
+		printUpstreamCost(session)
+		printRouting(session)
+		printVoiceUsage(session)
+		printQuota(session)
+		if session.model.ContextWindow > 0 {
+			fmt.Printf("  Context used: %s / %s tokens\n",
+				formatCount(int64(session.chat.ContextTokens())), formatCount(session.model.ContextWindow))
+		}
+		fmt.Println()
+		return true
+
+	case "/chart":
+		fmt.Println()
+		width := render.TerminalWidth()
+		if width == 0 {
+			width = 80
+		}
+		for _, line := range sessionChart(session, min(width, 100)) {
+			fmt.Println(line)
+		}
+		fmt.Println()
+		return true
+
+	case "/thinking":
+		session.showThinking = !session.showThinking
+		if !session.showThinking {
+			fmt.Println(infoStyle.Render("Reasoning is collapsed to a line from now on."))
+			fmt.Println()
+			return true
+		}
+		fmt.Println(infoStyle.Render("Reasoning streams before each reply from now on."))
+		if session.lastReasoning != "" {
+			fmt.Println()
+			fmt.Println(infoStyle.Render("Last reply's reasoning:"))
+			fmt.Println(infoStyle.Render(session.lastReasoning))
+		}
+		fmt.Println()
+		return true
+
+	case "/help":
+		fmt.Println()
+		fmt.Println(infoStyle.Render("Available commands:"))
+		fmt.Println("  /clear  - Clear conversation history")
+		fmt.Println("  /cost   - Show current session cost")
+		fmt.Println("  /chart  - Chart the tokens and cost so far, turn by turn, to spot a context that keeps growing")
+		fmt.Println("  /set    - Show sampling parameters; /set <name> <value|default> to change")
+		fmt.Println("  /preset - List system prompt presets; /preset <name|none> to switch")
+		fmt.Println("  /import - Continue a conversation from a ChatGPT or Claude export or a transcript")
+		fmt.Println("  /file   - Attach files, directories, or globs to the next message; /file to list, /file clear to drop")
+		fmt.Println("  /whatif - Show what this session would have cost on another model, e.g. /whatif gpt-4o-mini")
+		fmt.Println("  /model  - Show the model; /model <id> to switch, with Tab completing IDs as you type")
+		fmt.Println("  /retry  - Regenerate the last answer and show a word diff against it; /model first to compare models")
+		fmt.Println("  /good   - Rate the last answer good in the transcript; /bad [reason] to rate it bad")
+		fmt.Println("  /save-last - Write the last answer to a file; /save-last --code <file> for only its code blocks")
+		fmt.Println("  /thinking - Show or collapse the reasoning of models that send it")
+		fmt.Println("  /help   - Show this help")
+		fmt.Println("  /quit   - Exit the chat")
+		if names := session.plugins.Names(); len(names) > 0 {
+			fmt.Println()
+			fmt.Println(infoStyle.Render("From " + session.pluginsDir + ":"))
+			for _, name := range names {
+				c, _ := session.plugins.Lookup(name)
+				fmt.Printf("  /%-6s - %s\n", name, c.Summary())
+			}
+		}
+		fmt.Println()
+		return true
+
+	default:
+		fmt.Println(errorStyle.Render("Unknown command: " + cmd))
+		fmt.Println(infoStyle.Render("Type /help for available commands."))
+		fmt.Println()
+		return true
+	}
+}
+
+// builtinCommands are handled by handleCommand, so the commands directory
+// cannot replace them.
+var builtinCommands = []string{"quit", "exit", "q", "clear", "cost", "help", "set", "preset", "import", "file", "whatif", "model", "retry", "good", "bad", "thinking", "save-last", "chart"}
+
+// loadPlugins adds the executables in the commands directory as slash
+// commands, warning about any that cannot be used.
+func loadPlugins(session *chatSession) {
+	session.pluginsDir = *commandsDir
+	if session.pluginsDir == "" {
+		dir, err := commands.DefaultDir()
+		if err != nil {
+			return
+		}
+		session.pluginsDir = dir
+	}
+	if err := session.plugins.LoadDir(session.pluginsDir, builtinCommands...); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Println(warnStyle.Render(render.Symbol("⚠", "!") + " Skipped command " + line))
+		}
+	}
+}
+
+// runPlugin runs a command from the commands directory and shows its output.
+// It returns the message the command asks to send, if any, and false if cmd
+// is not such a command.
+func runPlugin(ctx context.Context, session *chatSession, cmd string) (string, bool) {
+	name, args, _ := strings.Cut(strings.TrimPrefix(cmd, "/"), " ")
+	c, ok := session.plugins.Lookup(name)
+	if !ok {
+		return "", false
+	}
+
+	res, err := c.Run(ctx, commands.Request{
+		Command:  strings.ToLower(name),
+		Args:     strings.TrimSpace(args),
+		Session:  session.sessionID,
+		Provider: string(session.provider.ID),
+		Model:    session.model.ID,
+		Messages: session.chat.Messages(),
+		Cost:     session.chat.Usage().Cost,
+	})
+	if err != nil {
+		fmt.Println(errorStyle.Render("Error: " + err.Error()))
+		fmt.Println()
+		return "", true
+	}
+	if res.Output != "" {
+		fmt.Println(res.Output)
+	}
+	if res.Prompt == "" {
+		fmt.Println()
+		return "", true
+	}
+	fmt.Println(infoStyle.Render(fmt.Sprintf("Sending the message from /%s (~%s tokens).",
+		strings.ToLower(name), formatCount(int64(tokenizer.CountMessage(chat.RoleUser, res.Prompt))))))
+	return res.Prompt, true
+}
+
+// handleRate records the user's verdict on the last reply, and the reason
+// given, on its transcript entry, where aimodels dashboard and dataset build
+// read it.
+func handleRate(session *chatSession, cmd, reason string) {
+	if session.transcript == nil {
+		fmt.Println(errorStyle.Render("Ratings are kept in the transcript; restart with --log-transcript <file> to record them."))
+		fmt.Println()
+		return
+	}
+	rating, verdict := transcript.RatingGood, "good"
+	if strings.EqualFold(cmd, "/bad") {
+		rating, verdict = transcript.RatingBad, "bad"
+	}
+	if err := session.transcript.Rate(rating, reason); err != nil {
+		fmt.Println(errorStyle.Render("Error: " + err.Error()))
+		fmt.Println()
+		return
+	}
+	fmt.Println(infoStyle.Render(fmt.Sprintf("Rated the last answer from %s %s.", session.model.ID, verdict)))
+	fmt.Println()
+}
+
+// handleModel shows the session's model, or switches to another of the
+// provider's models, keeping the conversation.
+func handleModel(session *chatSession, args []string) {
+	if len(args) == 0 {
+		fmt.Println(infoStyle.Render(fmt.Sprintf("Model: %s (%s)", session.model.Name, session.model.ID)))
+		fmt.Println(infoStyle.Render("Use /model <id> to switch; as you type, Tab completes the ID."))
+		fmt.Println()
+		return
+	}
+
+	model, note, err := findModel(session, args[0])
+	if note != "" {
+		fmt.Println(infoStyle.Render(note))
+	}
+	if err != nil {
+		fmt.Println(errorStyle.Render("Error: " + err.Error()))
+		fmt.Println()
+		return
+	}
+	if err := model.CheckMaxTokens(int64(*maxTokens)); err != nil {
+		if !*clampMax {
+			fmt.Println(errorStyle.Render("Error: " + err.Error()))
+			fmt.Println(infoStyle.Render("Restart with a lower --max-tokens, or with --clamp-max-tokens."))
+			fmt.Println()
+			return
+		}
+		*maxTokens = int(model.ClampMaxTokens(int64(*maxTokens)))
+		session.setSampling(session.sampling)
+		fmt.Println(warnStyle.Render(fmt.Sprintf("Clamping --max-tokens to %d, the most %s can reply with.", *maxTokens, model.ID)))
+	}
+
+	session.model = model
+	session.chat.SetModel(*model)
+	session.warnedAt = 0
+	fmt.Println(infoStyle.Render(fmt.Sprintf("Switched to %s (%s). The conversation so far is kept.", model.Name, model.ID)))
+	if session.router != nil && !session.router.off {
+		session.router.off = true
+		fmt.Println(infoStyle.Render("Smart routing is off for the rest of the session."))
+	}
+	printContextUsage(session)
+	fmt.Println()
+}
+
+// findModel finds one of the session's provider's models by ID, or by an
+// alias --rules maps to one, that the policy allows. The note says which
+// model an alias stood for.
+func findModel(session *chatSession, name string) (*catwalk.Model, string, error) {
+	var note string
+	if alias := session.rules.Alias(session.provider.ID, name); alias != name {
+		note = fmt.Sprintf("Using %s in place of %s, as --rules maps it", alias, name)
+		name = alias
+	}
+	model, err := session.provider.FindModel(name)
+	if err != nil {
+		return nil, note, err //nolint:wrapcheck
+	}
+	if err := session.policy.Check(*session.provider, *model); err != nil {
+		return nil, note, err //nolint:wrapcheck
+	}
+	return model, note, nil
+}
+
+// printAllowedModels lists the provider's models that the policy allows,
+// or says it allows none
+func printAllowedModels(p *policy.Policy, provider catwalk.Provider) {
+	allowed := p.Allowed([]catwalk.Provider{provider})
+	if len(allowed) == 0 {
+		fmt.Println(infoStyle.Render("\nThe policy allows no models of " + provider.Name + "."))
+		return
+	}
+	fmt.Println(infoStyle.Render("\nModels of " + provider.Name + " the policy allows:"))
+	for _, m := range allowed[0].Models {
+		fmt.Printf("  - %s (%s)\n", m.ID, m.Name)
+	}
+}
+
+// handleSet shows the sampling parameters, or changes one of them if it is
+// supported by the current provider.
+func handleSet(session *chatSession, args []string) {
+	if len(args) == 0 {
+		fmt.Println(infoStyle.Render("Sampling: " + session.sampling.String()))
+		fmt.Println()
+		return
+	}
+	if len(args) < 2 {
+		fmt.Println(errorStyle.Render("Usage: /set <temperature|top-p|frequency-penalty|seed|stop> <value|default>"))
+		fmt.Println()
+		return
+	}
+
+	updated := session.sampling
+	if err := updated.set(args[0], strings.Join(args[1:], " ")); err != nil {
+		fmt.Println(errorStyle.Render("Error: " + err.Error()))
+		fmt.Println()
+		return
+	}
+	if bad := updated.unsupported(session.provider.Type); len(bad) > 0 {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %s not supported by %s providers",
+			strings.Join(bad, ", "), session.provider.Type)))
+		fmt.Println()
+		return
+	}
+
+	session.setSampling(updated)
+	fmt.Println(infoStyle.Render("Sampling: " + session.sampling.String()))
+	fmt.Println()
+}
+
+// openPrompts opens the prompt library in the default directory, falling back
+// to the built-in presets if there is no config directory.
+func openPrompts() *prompts.Library {
+	lib, err := prompts.OpenDefault()
+	if err != nil {
+		return prompts.Open("")
+	}
+	return lib
+}
+
+// handlePreset lists the system prompt presets, or replaces the system prompt
+// with one of them.
+func handlePreset(session *chatSession, args []string) {
+	if len(args) == 0 {
+		list, err := session.prompts.List()
+		if err != nil {
+			fmt.Println(errorStyle.Render("Error: " + err.Error()))
+			fmt.Println()
+			return
+		}
+		fmt.Println()
+		fmt.Println(infoStyle.Render("Presets (" + session.prompts.Dir() + "):"))
+		for _, p := range list {
+			marker := "  "
+			if p.Name == session.preset {
+				marker = "* "
+			}
+			fmt.Printf("  %s%-12s %s\n", marker, p.Name, infoStyle.Render(p.Summary()))
+		}
+		fmt.Println()
+		return
+	}
+
+	if strings.EqualFold(args[0], "none") {
+		session.chat.SetSystem("")
+		session.preset = ""
+		fmt.Println(infoStyle.Render("System prompt removed."))
+		fmt.Println()
+		return
+	}
+
+	p, err := session.prompts.Get(args[0])
+	if err != nil {
+		fmt.Println(errorStyle.Render("Error: " + err.Error()))
+		fmt.Println()
+		return
+	}
+	// The conversation so far is kept; only the system prompt changes
+	session.chat.SetSystem(p.Content)
+	session.preset = p.Name
+	fmt.Println(infoStyle.Render("System prompt set to preset " + p.Name + "."))
+	fmt.Println()
+}
+
+// handleImport replaces the history with a conversation from a ChatGPT or
+// Claude export or a transcript, listing the conversations when the file has
+// several and none is chosen.
+func handleImport(session *chatSession, args []string) {
+	if len(args) == 0 {
+		fmt.Println(errorStyle.Render("Usage: /import <file> [number|title]"))
+		fmt.Println()
+		return
+	}
+	convs, err := transcript.ReadExport(args[0])
+	if err == nil && len(convs) == 0 {
+		err = errors.New("no conversations in " + args[0])
+	}
+	if err != nil {
+		fmt.Println(errorStyle.Render("Error: " + err.Error()))
+		fmt.Println()
+		return
+	}
+
+	conv := convs[0]
+	if key := strings.Join(args[1:], " "); key != "" {
+		if conv, err = transcript.FindConversation(convs, key); err != nil {
+			fmt.Println(errorStyle.Render("Error: " + err.Error()))
+			fmt.Println()
+			return
+		}
+	} else if len(convs) > 1 {
+		fmt.Println(infoStyle.Render(fmt.Sprintf("%s has %d conversations:", args[0], len(convs))))
+		for i, c := range convs {
+			fmt.Printf("  %3d  %s %s\n", i+1, conversationTitle(c), infoStyle.Render(fmt.Sprintf("(%d messages)", len(c.Messages))))
+		}
+		fmt.Println(infoStyle.Render("Use /import " + args[0] + " <number|title> to continue one."))
+		fmt.Println()
+		return
+	}
+
+	// The imported messages are sent with the next request, so they are
+	// filtered like typed ones
+	messages := slices.Clone(conv.Messages)
+	var masked []secrets.Kind
+	for i, m := range messages {
+		if m.Role != chat.RoleUser {
+			continue
+		}
+		content, kinds, err := filterSecrets(session, args[0], m.Content)
+		if err != nil {
+			fmt.Println(errorStyle.Render("Not imported: " + err.Error()))
+			fmt.Println()
+			return
+		}
+		messages[i].Content = content
+		masked = append(masked, kinds...)
+	}
+	printMasked(masked)
+
+	// Keep the current system prompt unless the conversation has its own
+	if current := session.chat.Messages(); messages[0].Role != chat.RoleSystem &&
+		len(current) > 0 && current[0].Role == chat.RoleSystem {
+		messages = append([]chat.Message{current[0]}, messages...)
+	}
+	session.chat.SetMessages(messages)
+	session.warnedAt = 0
+
+	from := conv.Source
+	if conv.Model != "" {
+		from += ", " + conv.Model
+	}
+	fmt.Println(infoStyle.Render(fmt.Sprintf("Imported %s: %d messages (from %s). Replies now come from %s.",
+		conversationTitle(conv), len(conv.Messages), from, session.model.Name)))
+	printContextUsage(session)
+	fmt.Println()
+}
+
+// conversationTitle names an imported conversation by its title, or its ID
+// when it has none.
+func conversationTitle(c transcript.Conversation) string {
+	if c.Title != "" {
+		return strconv.Quote(c.Title)
+	}
+	return c.ID
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/hooks"
+	"charm.land/catwalk/pkg/transcript"
+)
+
+// runPreHook passes the pending request through the --hook-pre command,
+// which may rewrite the conversation. An error means it must not be sent.
+func runPreHook(ctx context.Context, session *chatSession) error {
+	if *hookPre == "" {
+		return nil
+	}
+
+	turn := newTurn(session, hooks.Pre)
+	if err := (hooks.Hook{Command: *hookPre}).Run(ctx, &turn); err != nil {
+		return err
+	}
+	session.chat.SetMessages(turn.Messages)
+	return nil
+}
+
+// runPostHook passes the outcome of a request through the --hook-post
+// command, which may rewrite the reply kept in the history and transcript.
+// It runs even if the request was interrupted, so audits stay complete.
+func runPostHook(ctx context.Context, session *chatSession, response *chat.Response, sendErr error) error {
+	if *hookPost == "" {
+		return nil
+	}
+
+	turn := newTurn(session, hooks.Post)
+	if sendErr != nil {
+		turn.Error = sendErr.Error()
+	}
+	if response != nil {
+		turn.Response = &transcript.Message{Role: chat.RoleAssistant, Content: response.Content}
+		turn.Usage = &transcript.Usage{InputTokens: response.InputTokens, OutputTokens: response.OutputTokens}
+		turn.Cost = response.Cost
+	}
+	if err := (hooks.Hook{Command: *hookPost}).Run(context.WithoutCancel(ctx), &turn); err != nil {
+		return err
+	}
+	if response != nil && turn.Response != nil {
+		response.Content = turn.Response.Content
+	}
+	return nil
+}
+
+// newTurn describes the conversation for a hook.
+func newTurn(session *chatSession, event string) hooks.Turn {
+	return hooks.Turn{
+		Event:    event,
+		Session:  session.sessionID,
+		Provider: string(session.provider.ID),
+		Model:    session.model.ID,
+		Messages: session.chat.Messages(),
+	}
+}
@@ -8,67 +8,490 @@
 // - Interactive CLI chat interface
 // - Handling different provider types (openai, openai-compat, anthropic, etc.)
 // - Conversation history management
+// - Tracking context window usage with a local token estimate
+// - Logging every request/response pair to a JSONL transcript
+// - Sampling parameters validated against the provider type
+// - Streaming responses with Ctrl-C cancelling the in-flight request
+// - Live token and cost estimate of the message being typed
+// - OpenRouter routing preferences, with the upstream provider and billed cost recorded
+// - Pre/post hook commands for redacting, auditing, or logging each turn
+// - Masking or blocking API keys, JWTs, private keys, and emails before they are sent
+// - System prompt presets from a prompt library, switchable mid-chat
+// - Sending the API key the way each provider expects (bearer, header, query, AWS SigV4, or Google credentials)
+// - Sharing conversation and cost logic through pkg/chat
+// - Typed errors with suggestions for unknown providers and models, and a session budget
+// - Voice chat: speech-to-text input and spoken replies, with audio priced per minute
+// - Importing ChatGPT and Claude exports to continue old conversations with any model
+// - Attaching files and directories to a message, fenced and counted against the context window
+// - Pricing the session on another model with /whatif, with and without prompt caching
+// - Checking --max-tokens against the model's output limit, failing or clamping
+// - Autosaving the conversation after each turn and offering to resume it after a crash
+// - Rewriting requests from a central rules file: system prompt prefix, max tokens, stripped parameters, model aliases
+// - Enforcing an organization policy: allowed providers, banned models, price caps, and data residency
+// - Third-party slash commands from executables in a commands directory, such as /jira or /summarize-pr
+// - Decoding only the providers it uses from the catalog, so startup stays fast as the catalog grows
+// - Switching models mid-chat with /model, with IDs autocompleted from the catalog as you type
+// - Tracking the provider's request and token quota across sessions, with a warning before a request would exceed it
+// - Regenerating the last answer with /retry, with a colored word diff against the previous one
+// - Rating answers with /good and /bad [reason] in the transcript, for the dashboard and fine-tuning datasets
+// - Routing simple messages to the provider's small model and hard ones to the large model with --smart-routing
+// - Several concurrent conversations in a full-screen app with a session sidebar, with --sessions
+// - Reasoning traces from DeepSeek, Qwen, and other reasoning models, collapsed by default and shown with /thinking
+// - Defaulting the provider, model, and catalog URL to the settings written by aimodels init
+// - Failing over to other models with --fallback, recording the model used and why in the transcript
+// - Time-boxing each request with --timeout, keeping a partial reply marked as truncated
+// - Writing replies, or only their code blocks, to files with /save-last and --tee
+// - Charting the session's cumulative tokens and cost per turn with /chart, to spot runaway context growth
 //
 // Usage:
 //
-//	go run main.go --provider openai --model gpt-4o           # Start with specific model
-//	go run main.go --provider anthropic                       # Use default model
-//	go run main.go --provider openai --system "You are a helpful coding assistant"
-//	go run main.go --help                                     # Show help message
+//	go run .                                            # The provider and model chosen with aimodels init
+//	go run . --provider openai --model gpt-4o           # Start with specific model
+//	go run . --provider anthropic                       # Use default model
+//	go run . --provider anthropic --size small          # Use the provider's default small model
+//	go run . --provider huggingface --model openai/gpt-oss-120b:groq   # Org-prefixed ID, routed to Groq
+//	go run . --provider openai --system "You are a helpful coding assistant"
+//	go run . --provider openai --preset reviewer            # System prompt from the prompt library
+//	go run . --provider openai --context-warn 50,75,90  # Warn earlier about context usage
+//	go run . --provider openai --budget 0.50            # Refuse requests once $0.50 is spent
+//	go run . --provider openai --timeout 60s            # Give up on a reply after a minute
+//	go run . --provider openai --max-tokens 100000 --clamp-max-tokens   # Ask for the longest reply allowed
+//	go run . --provider openai --log-transcript chat.jsonl
+//	go run . --provider openai --log-transcript chat.jsonl --tag acme   # Spend by tag in aimodels dashboard
+//	go run . --provider groq --log-transcript chat.jsonl   # Count usage against the quota in overrides.yaml
+//	go run . --provider openai --temperature 0 --seed 42   # Reproducible experiments
+//	go run . --provider openrouter --model openai/gpt-4o --openrouter-sort price
+//	go run . --provider openai --hook-pre ./redact.sh --hook-post 'cat >> audit.jsonl'
+//	go run . --provider openai --redact-secrets mask,private_key=block --redact-log redactions.jsonl
+//	go run . --provider openai --voice                  # Talk instead of typing (needs sox or alsa-utils)
+//	go run . --provider openai --context 'pkg/chat/*.go'   # Attach files to the first message
+//	go run . --provider openai --autosave=false         # Keep no crash-recovery journal
+//	go run . --provider openai --rules rules.yaml       # Apply the organization's request rules
+//	go run . --provider azure --policy policy.yaml      # Refuse models the organization does not allow
+//	go run . --provider openai --commands-dir ./tools   # Slash commands from the executables in ./tools
+//	go run . --provider openai --sessions               # Sidebar of conversations to switch between
+//	go run . --provider openai --model gpt-4o --fallback gpt-4o-mini   # Keep answering through an outage
+//	go run . --provider openai --tee snippets.go --tee-code   # Collect the code of every reply
+//	go run . --help                                     # Show help message
 //
 // Environment Variables:
 //
-//	CATWALK_URL - URL of the catwalk service (default: http://localhost:8080)
+//	CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)
 package main
 
 import (
 	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"maps"
+	"math"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"charm.land/catwalk/internal/cli"
+	"charm.land/catwalk/pkg/auth"
+	quota "charm.land/catwalk/pkg/budget"
 	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/commands"
+	"charm.land/catwalk/pkg/config"
+	"charm.land/catwalk/pkg/openrouter"
+	"charm.land/catwalk/pkg/policy"
+	"charm.land/catwalk/pkg/prompts"
+	"charm.land/catwalk/pkg/registry"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/rules"
+	"charm.land/catwalk/pkg/secrets"
+	"charm.land/catwalk/pkg/selector"
+	"charm.land/catwalk/pkg/tokenizer"
+	"charm.land/catwalk/pkg/transcript"
+	"charm.land/catwalk/pkg/transport"
+	"charm.land/catwalk/pkg/usage"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
 	"github.com/sashabaranov/go-openai"
 )
 
 var (
-	providerID   = flag.String("provider", "", "Provider ID (e.g., openai, anthropic)")
+	providerID   = flag.String("provider", "", "Provider ID (e.g., openai, anthropic; default: the one set with aimodels init)")
 	modelName    = flag.String("model", "", "Model ID (overrides default)")
+	modelSize    = flag.String("size", "", "Use the provider's default small or large model")
+	fallback     = flag.String("fallback", "", "Comma-separated models of the provider to fail over to, in order, when the model is rate limited, erroring, or unreachable")
+	smartRouting = flag.Bool("smart-routing", false, "Send simple messages to the provider's default small model and hard ones to the large model")
 	systemPrompt = flag.String("system", "", "System prompt for the conversation")
+	preset       = flag.String("preset", "", "System prompt preset: coding, writing, sql, reviewer, or a file in the prompts directory")
 	maxTokens    = flag.Int("max-tokens", 0, "Max tokens for response (0 = model default)")
+	clampMax     = flag.Bool("clamp-max-tokens", false, "Lower --max-tokens to the model's output limit instead of failing")
+	budget       = flag.Float64("budget", 0, "Stop sending once the session has cost this many USD (0 = no limit)")
+	timeout      = flag.Duration("timeout", 0, "Cancel a request that takes longer than this, e.g. 60s, keeping any partial reply (0 = no limit)")
 	apiKey       = flag.String("api-key", "", "API key (overrides provider config)")
+	contextWarn  = flag.String("context-warn", "80,95", "Comma-separated context usage percentages that trigger a warning")
+	logFile      = flag.String("log-transcript", "", "Append every request/response pair to this JSONL file")
+	teeFile      = flag.String("tee", "", "Append each reply to this file as well, e.g. to collect generated code")
+	teeCode      = flag.Bool("tee-code", false, "With --tee, append only the fenced code blocks of each reply")
+	tags         = flag.String("tag", "", "Comma-separated tags recorded with each transcript entry, e.g. a project or client")
+	overrides    = flag.String("overrides", "", "Overrides file with provider quotas to track and API key variables, or none (default: the aimodels overrides.yaml, if present)")
+	hookPre      = flag.String("hook-pre", "", "Command run before each request; may rewrite or block it (JSON on stdin/stdout)")
+	hookPost     = flag.String("hook-post", "", "Command run after each response (JSON on stdin); may rewrite the stored reply")
+	redactPolicy = flag.String("redact-secrets", "", "Mask or block secrets in messages before sending: mask, block, or e.g. mask,private_key=block")
+	contextFiles = flag.String("context", "", "Comma-separated files, directories, or globs to attach to the first message")
+	redactLog    = flag.String("redact-log", "", "Append each redaction event (kind, action, fingerprint; never the secret) to this JSONL file")
+	rulesFile    = flag.String("rules", "", "YAML rules that rewrite each request: system prompt prefix, max tokens cap, stripped parameters, model aliases")
+	policyFile   = flag.String("policy", "", "Organization policy of allowed providers and models, or none (default: the aimodels policy.yaml, if present)")
+	commandsDir  = flag.String("commands-dir", "", "Directory of executables added as slash commands (default: aimodels/commands in the config directory)")
+	liveEstimate = flag.Bool("live-estimate", true, "Show a live token/cost estimate while typing (terminal only)")
+	autosave     = flag.Bool("autosave", true, "Save the conversation after each turn and offer to resume it after a crash")
+	sessionsMode = flag.Bool("sessions", false, "Full-screen app with a sidebar of concurrent conversations to create, rename, switch, and archive")
+	voice        = flag.Bool("voice", false, "Talk instead of typing: record the microphone, transcribe it, and speak replies")
+	voiceProv    = flag.String("voice-provider", "openai", "OpenAI-compatible provider used for speech-to-text and text-to-speech")
+	sttModel     = flag.String("stt-model", "whisper-1", "Speech-to-text model")
+	ttsModel     = flag.String("tts-model", "tts-1", "Text-to-speech model (empty = don't speak replies)")
+	ttsVoice     = flag.String("tts-voice", "alloy", "Text-to-speech voice")
+	recordCmd    = flag.String("record-cmd", "", "Command that records WAV to stdout until interrupted (default: rec or arecord)")
+	playCmd      = flag.String("play-cmd", "", "Command that plays WAV from stdin (default: play, aplay, or ffplay)")
+	sttCost      = flag.Float64("stt-cost", 0, "Speech-to-text price in USD per minute (default: catalog audio pricing)")
+	ttsCost      = flag.Float64("tts-cost", 0, "Text-to-speech price in USD per minute (default: catalog audio pricing)")
 	debug        = flag.Bool("debug", false, "Show debug information")
+	network      = transport.RegisterFlags(flag.CommandLine)
+	openRouter   = openrouter.RegisterFlags(flag.CommandLine)
 	showHelp     = flag.Bool("help", false, "Show help message")
 )
 
 // Styles for formatting
 var (
-	headerStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("86"))
-	userStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
-	aiStyle      = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("120"))
-	costStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("228"))
-	infoStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
-	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
-	borderStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
-	promptStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("255"))
+	userStyle   = lipgloss.NewStyle().Bold(true).Foreground(cli.Colors.Accent)
+	aiStyle     = lipgloss.NewStyle().Bold(true).Foreground(cli.Colors.OK)
+	infoStyle   = lipgloss.NewStyle().Foreground(cli.Colors.Muted)
+	errorStyle  = lipgloss.NewStyle().Foreground(cli.Colors.Error)
+	warnStyle   = lipgloss.NewStyle().Foreground(cli.Colors.Warn)
+	promptStyle = lipgloss.NewStyle().Bold(true).Foreground(cli.Colors.Text)
+
+	diffAddStyle = lipgloss.NewStyle().Foreground(cli.Colors.OK)
+	diffDelStyle = lipgloss.NewStyle().Foreground(cli.Colors.Error).Strikethrough(true)
 )
 
+// chatSession wraps the conversation with the state only the CLI needs.
 type chatSession struct {
-	client      *openai.Client
-	provider    *catwalk.Provider
-	model       *catwalk.Model
-	messages    []openai.ChatCompletionMessage
-	totalTokens int
-	totalCost   float64
+	chat     *chat.Session
+	client   *openai.Client
+	provider *catwalk.Provider
+	model    *catwalk.Model
+
+	// The whole catalog, decoded as /whatif needs it, and the token counts
+	// of each request so far, with InputTokens the full prompt, and their
+	// cost where known (not for the turns of a resumed session).
+	catalog  *catwalk.Catalog
+	requests []usage.Record
+
+	// Context usage percentages to warn at, in ascending order, and the
+	// highest one already warned about.
+	contextWarn []float64
+	warnedAt    float64
+
+	// Optional transcript log and the ID grouping this session's entries.
+	transcript *transcript.Writer
+	sessionID  string
+
+	// Usage against the provider's quota, if the overrides file sets one.
+	quotas *quota.QuotaTracker
+
+	sampling samplingParams
+
+	// Prompt library and the preset the system prompt came from, if any.
+	prompts *prompts.Library
+	preset  string
+
+	// Speech input and output, with --voice.
+	voice *voiceMode
+
+	// Request rules, with --rules.
+	rules *rules.Rules
+
+	// Organization policy of the models that may be used, with --policy.
+	policy *policy.Policy
+
+	// Per-message model choice, with --smart-routing.
+	router *smartRouter
+
+	// Models to fail over to, in order, with --fallback.
+	fallbacks []catwalk.Model
+
+	// File each reply is appended to, with --tee.
+	tee *replyTee
+
+	// Slash commands added from the commands directory.
+	plugins    commands.Registry
+	pluginsDir string
+
+	// Secret scanner for outgoing messages, with --redact-secrets, the
+	// kinds of secret the current filtering masked or blocked, and the
+	// --redact-log file.
+	secrets   *secrets.Scanner
+	redacted  []secrets.Kind
+	redactLog *json.Encoder
+
+	// Files attached with /file or --context, sent with the next message.
+	attachments []attachment
+
+	// Journal file autosaved after each turn, with --autosave, and the cost
+	// of a resumed session's earlier turns.
+	journal   string
+	priorCost float64
+
+	// Reasoning traces are collapsed to a line unless /thinking shows them.
+	// thinking streams them in the chat loop; lastReasoning is the last
+	// reply's, shown when /thinking is turned on.
+	showThinking  bool
+	thinking      *thinkingWriter
+	lastReasoning string
+}
+
+// setSampling applies sampling parameters to the session's requests.
+func (s *chatSession) setSampling(p samplingParams) {
+	s.sampling = p
+	config := chat.Config{MaxTokens: *maxTokens, Budget: *budget, Timeout: *timeout, Prepare: p.apply}
+	if len(s.fallbacks) > 0 {
+		// First, so the policy and rules see the model failed over to
+		config.Middleware = append(config.Middleware, chat.Failover(s.fallbacks...))
+	}
+	if s.policy != nil {
+		config.Middleware = append(config.Middleware, s.policy.Middleware())
+	}
+	if s.rules != nil {
+		config.Middleware = append(config.Middleware, s.rules.Middleware())
+	}
+	if s.thinking != nil {
+		config.Reasoning = s.thinking
+	}
+	s.chat.SetConfig(config)
+}
+
+// thinkingWriter prints the reasoning of a reply as it streams, dimmed,
+// while /thinking shows it.
+type thinkingWriter struct {
+	session *chatSession
+	open    bool // reasoning was printed and the reply has not started
+}
+
+func (w *thinkingWriter) Write(p []byte) (int, error) {
+	if !w.session.showThinking {
+		return len(p), nil
+	}
+	text := string(p)
+	if !w.open {
+		// Start on the reply's line
+		if text = strings.TrimLeft(text, " \r\n"); text == "" {
+			return len(p), nil
+		}
+		w.open = true
+	}
+	// Style each line on its own, so lipgloss does not pad them
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = infoStyle.Render(line)
+		}
+	}
+	fmt.Print(strings.Join(lines, "\n"))
+	return len(p), nil
+}
+
+// reply returns a writer for the reply that starts it below any reasoning
+// printed.
+func (w *thinkingWriter) reply(out io.Writer) io.Writer {
+	return replyWriter{thinking: w, out: out}
+}
+
+type replyWriter struct {
+	thinking *thinkingWriter
+	out      io.Writer
+}
+
+func (r replyWriter) Write(p []byte) (int, error) {
+	if r.thinking.open {
+		fmt.Fprint(r.out, "\n\n")
+		r.thinking.open = false
+	}
+	return r.out.Write(p) //nolint:wrapcheck
+}
+
+// thinkingNote collapses a reply's reasoning to a line, with how many
+// tokens it took.
+func thinkingNote(r *chat.Response) string {
+	return fmt.Sprintf("%s Thought for %s tokens; /thinking to show", render.Symbol("▸", ">"), formatCount(int64(r.ReasoningTokens)))
+}
+
+// samplingParams holds the optional sampling parameters sent with each
+// request. Nil values mean the provider default is used.
+type samplingParams struct {
+	temperature      *float64
+	topP             *float64
+	frequencyPenalty *float64
+	seed             *int
+	stop             []string
+}
+
+// sampling holds the values set on the command line.
+var sampling samplingParams
+
+func init() {
+	flag.Func("temperature", "Sampling temperature, 0-2 (default: provider default)", func(v string) error {
+		return sampling.set("temperature", v)
+	})
+	flag.Func("top-p", "Nucleus sampling probability, 0-1 (default: provider default)", func(v string) error {
+		return sampling.set("top-p", v)
+	})
+	flag.Func("frequency-penalty", "Frequency penalty, -2 to 2 (default: provider default)", func(v string) error {
+		return sampling.set("frequency-penalty", v)
+	})
+	flag.Func("seed", "Seed for deterministic sampling (where supported)", func(v string) error {
+		return sampling.set("seed", v)
+	})
+	flag.Func("stop", "Comma-separated stop sequences (up to 4)", func(v string) error {
+		return sampling.set("stop", v)
+	})
+}
+
+// unsupportedSampling lists the sampling parameters that each provider type
+// rejects. Types not listed accept all of them.
+var unsupportedSampling = map[catwalk.Type][]string{
+	catwalk.TypeAnthropic: {"seed", "frequency-penalty"},
+	catwalk.TypeBedrock:   {"seed", "frequency-penalty"},
+}
+
+// set parses and stores a single parameter. The value "default" clears it.
+func (p *samplingParams) set(name, value string) error {
+	value = strings.TrimSpace(value)
+	reset := strings.EqualFold(value, "default")
+
+	switch strings.ReplaceAll(strings.ToLower(name), "_", "-") {
+	case "temperature":
+		return setFloat(&p.temperature, value, reset, 0, 2)
+	case "top-p":
+		return setFloat(&p.topP, value, reset, 0, 1)
+	case "frequency-penalty":
+		return setFloat(&p.frequencyPenalty, value, reset, -2, 2)
+	case "seed":
+		if reset {
+			p.seed = nil
+			return nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("seed must be an integer")
+		}
+		p.seed = &n
+	case "stop":
+		if reset {
+			p.stop = nil
+			return nil
+		}
+		var stop []string
+		for _, s := range strings.Split(value, ",") {
+			if s != "" {
+				stop = append(stop, s)
+			}
+		}
+		if len(stop) > 4 {
+			return fmt.Errorf("at most 4 stop sequences are allowed")
+		}
+		p.stop = stop
+	default:
+		return fmt.Errorf("unknown parameter %q (use temperature, top-p, frequency-penalty, seed, or stop)", name)
+	}
+	return nil
+}
+
+func setFloat(dst **float64, value string, reset bool, lo, hi float64) error {
+	if reset {
+		*dst = nil
+		return nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < lo || f > hi {
+		return fmt.Errorf("value must be a number between %g and %g", lo, hi)
+	}
+	*dst = &f
+	return nil
+}
+
+// unsupported returns the parameters that are set but not accepted by the
+// given provider type.
+func (p samplingParams) unsupported(t catwalk.Type) []string {
+	isSet := map[string]bool{
+		"temperature":       p.temperature != nil,
+		"top-p":             p.topP != nil,
+		"frequency-penalty": p.frequencyPenalty != nil,
+		"seed":              p.seed != nil,
+		"stop":              len(p.stop) > 0,
+	}
+	var bad []string
+	for _, name := range unsupportedSampling[t] {
+		if isSet[name] {
+			bad = append(bad, name)
+		}
+	}
+	return bad
+}
+
+// apply copies the parameters onto a request. The client omits zero floats,
+// so an explicit 0 is sent as the smallest non-zero value instead.
+func (p samplingParams) apply(req *openai.ChatCompletionRequest) {
+	nonZero := func(f float64) float32 {
+		if f == 0 {
+			return math.SmallestNonzeroFloat32
+		}
+		return float32(f)
+	}
+	if p.temperature != nil {
+		req.Temperature = nonZero(*p.temperature)
+	}
+	if p.topP != nil {
+		req.TopP = nonZero(*p.topP)
+	}
+	if p.frequencyPenalty != nil {
+		req.FrequencyPenalty = float32(*p.frequencyPenalty)
+	}
+	req.Seed = p.seed
+	req.Stop = p.stop
+}
+
+// String describes the parameters for display.
+func (p samplingParams) String() string {
+	show := func(f *float64) string {
+		if f == nil {
+			return "default"
+		}
+		return strconv.FormatFloat(*f, 'g', -1, 64)
+	}
+	seed := "default"
+	if p.seed != nil {
+		seed = strconv.Itoa(*p.seed)
+	}
+	stop := "default"
+	if len(p.stop) > 0 {
+		stop = strconv.Quote(strings.Join(p.stop, ","))
+	}
+	return fmt.Sprintf("temperature=%s top-p=%s frequency-penalty=%s seed=%s stop=%s",
+		show(p.temperature), show(p.topP), show(p.frequencyPenalty), seed, stop)
 }
 
 func main() {
+	render.SetupConsole()
 	flag.Parse()
 
 	if *showHelp {
@@ -76,54 +499,130 @@ func main() {
 		return
 	}
 
+	// The settings from aimodels init fill in what the flags leave out
+	settings, err := config.Open("")
+	if err != nil {
+		log.Fatalf("Error: %v (fix it, or run: aimodels init --force)", err)
+	}
+	settings.ApplyCatalogURL()
 	if *providerID == "" {
-		log.Fatal("Error: --provider is required. Use --help for usage information.")
+		*providerID = settings.Provider
+		if *modelName == "" && *modelSize == "" {
+			*modelName = settings.Model
+		}
+	}
+	if *providerID == "" {
+		log.Fatal("Error: --provider is required, or choose a default with: aimodels init. Use --help for usage information.")
+	}
+	if *sessionsMode {
+		switch {
+		case *voice || *smartRouting:
+			log.Fatal("Error: --sessions cannot be combined with --voice or --smart-routing.")
+		case !term.IsTerminal(os.Stdin.Fd()) || !term.IsTerminal(os.Stdout.Fd()):
+			log.Fatal("Error: --sessions needs an interactive terminal.")
+		}
+	}
+
+	var size selector.Size
+	if *modelSize != "" {
+		if *modelName != "" {
+			log.Fatal("Error: --size and --model are mutually exclusive.")
+		}
+		var err error
+		if size, err = selector.ParseSize(*modelSize); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	}
+
+	thresholds, err := parseThresholds(*contextWarn)
+	if err != nil {
+		log.Fatalf("Error: invalid --context-warn: %v", err)
+	}
+
+	var requestRules *rules.Rules
+	if *rulesFile != "" {
+		if requestRules, err = rules.Load(*rulesFile); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	}
+	var orgPolicy *policy.Policy
+	if *policyFile != "none" {
+		if orgPolicy, err = policy.Open(*policyFile); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	}
+
+	// The overrides file sets quotas and API key variables
+	var localOverrides *registry.Overrides
+	if *overrides != "none" {
+		if localOverrides, err = registry.Open(*overrides); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
 	}
 
 	// Create catwalk client and fetch providers
-	catwalkClient := catwalk.New()
+	httpClient, err := network.Client()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	catwalkClient := catwalk.NewWithHTTPClient(httpClient)
 	ctx := context.Background()
 
-	providers, err := catwalkClient.GetProviders(ctx, "")
+	// Only the providers used are decoded, which keeps startup fast as the
+	// catalog grows
+	catalog, _, err := catwalkClient.GetCatalog(ctx, "")
 	if err != nil {
 		log.Fatalf("Error fetching providers: %v", err)
 	}
 
 	// Find provider
-	var provider *catwalk.Provider
-	for i := range providers {
-		if strings.EqualFold(string(providers[i].ID), *providerID) {
-			provider = &providers[i]
-			break
-		}
-	}
-
-	if provider == nil {
-		fmt.Println(errorStyle.Render("Provider not found: " + *providerID))
+	provider, err := catalog.Provider(*providerID)
+	if err != nil {
+		fmt.Println(errorStyle.Render("Error: " + err.Error()))
 		fmt.Println(infoStyle.Render("\nAvailable providers:"))
+		providers, _ := catalog.Providers()
 		for _, p := range providers {
 			fmt.Printf("  - %s (%s)\n", p.ID, p.Name)
 		}
-		os.Exit(1)
+		os.Exit(catwalk.ExitCode(err))
+	}
+
+	if bad := sampling.unsupported(provider.Type); len(bad) > 0 {
+		log.Fatalf("Error: %s not supported by %s providers", strings.Join(bad, ", "), provider.Type)
+	}
+
+	routing, err := openRouter.Request()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	isOpenRouter := provider.ID == catwalk.InferenceProviderOpenRouter || provider.Type == catwalk.TypeOpenRouter
+	if !isOpenRouter && !routing.IsZero() {
+		log.Fatalf("Error: --openrouter-* options require the openrouter provider, not %s", provider.ID)
 	}
 
 	// Find model
 	var model *catwalk.Model
 	if *modelName != "" {
-		for i := range provider.Models {
-			if strings.EqualFold(provider.Models[i].ID, *modelName) {
-				model = &provider.Models[i]
-				break
-			}
+		name := requestRules.Alias(provider.ID, *modelName)
+		if name != *modelName {
+			fmt.Println(infoStyle.Render(fmt.Sprintf("Using %s in place of %s, as --rules maps it", name, *modelName)))
 		}
-		if model == nil {
-			fmt.Println(errorStyle.Render("Model not found: " + *modelName))
+		model, err = provider.FindModel(name)
+		if err != nil {
+			fmt.Println(errorStyle.Render("Error: " + err.Error()))
 			fmt.Println(infoStyle.Render("\nAvailable models for " + provider.Name + ":"))
 			for _, m := range provider.Models {
 				fmt.Printf("  - %s (%s)\n", m.ID, m.Name)
 			}
-			os.Exit(1)
+			os.Exit(catwalk.ExitCode(err))
+		}
+	} else if size != "" {
+		// Use the provider's default model of the requested size
+		m, err := selector.DefaultFor(*provider, size)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
 		}
+		model = &m
 	} else {
 		// Use default model
 		modelID := provider.DefaultLargeModelID
@@ -142,131 +641,257 @@ func main() {
 		log.Fatal("No model found for provider.")
 	}
 
+	// Refuse models the organization policy does not allow before anything
+	// is sent
+	if err := orgPolicy.Check(*provider, *model); err != nil {
+		fmt.Println(errorStyle.Render("Error: " + err.Error()))
+		printAllowedModels(orgPolicy, *provider)
+		os.Exit(catwalk.ExitCode(err))
+	}
+
+	// Check --max-tokens against the catalog, since providers reject an
+	// oversized limit with an opaque 400
+	if err := model.CheckMaxTokens(int64(*maxTokens)); err != nil {
+		if !*clampMax {
+			fmt.Println(errorStyle.Render("Error: " + err.Error()))
+			fmt.Println(infoStyle.Render(fmt.Sprintf("\nUse --max-tokens %d or less, or --clamp-max-tokens.", model.OutputLimit())))
+			os.Exit(catwalk.ExitCode(err))
+		}
+		*maxTokens = int(model.ClampMaxTokens(int64(*maxTokens)))
+		fmt.Println(warnStyle.Render(fmt.Sprintf("Clamping --max-tokens to %d, the most %s can reply with.", *maxTokens, model.ID)))
+	}
+
+	// Resolve the models to fail over to, held to the same policy
+	var fallbacks []catwalk.Model
+	for _, id := range strings.Split(*fallback, ",") {
+		if id = strings.TrimSpace(id); id == "" {
+			continue
+		}
+		m, err := provider.FindModel(id)
+		if err != nil {
+			log.Fatalf("Error: --fallback: %v", err)
+		}
+		if err := orgPolicy.Check(*provider, *m); err != nil {
+			fmt.Println(errorStyle.Render("Error: --fallback: " + err.Error()))
+			printAllowedModels(orgPolicy, *provider)
+			os.Exit(catwalk.ExitCode(err))
+		}
+		fallbacks = append(fallbacks, *m)
+	}
+
+	// Route simple messages to the provider's small model, with the chosen
+	// model as the large one
+	var router *smartRouter
+	if *smartRouting {
+		if router, err = newSmartRouter(provider, model, orgPolicy); err != nil {
+			fmt.Println(errorStyle.Render("Error: " + err.Error()))
+			os.Exit(catwalk.ExitCode(err))
+		}
+	}
+
 	// Resolve API key (flag > env var > provider config)
-	resolvedAPIKey := resolveAPIKey(provider)
-	if resolvedAPIKey == "" {
-		fmt.Println(errorStyle.Render("No API key found!"))
+	resolvedAPIKey, keyErr := resolveAPIKey(provider, localOverrides)
+	scheme := auth.For(*provider)
+	var missing *catwalk.MissingAPIKeyError
+	if errors.As(keyErr, &missing) && scheme.NeedsKey() {
+		fmt.Println(errorStyle.Render("Error: " + missing.Error()))
 		fmt.Println(infoStyle.Render("\nProvide an API key via:"))
 		fmt.Println("  --api-key <key>")
-		fmt.Printf("  %s environment variable\n", getEnvKeyName(provider.ID))
-		os.Exit(1)
+		if missing.EnvVar != "" {
+			fmt.Printf("  %s environment variable\n", missing.EnvVar)
+		}
+		fmt.Printf("  api_key_env for %s in the overrides file, naming another variable\n", provider.ID)
+		os.Exit(catwalk.ExitCode(missing))
 	}
 
 	// Create OpenAI-compatible client
-	client := createClient(provider, resolvedAPIKey)
+	base, err := network.Transport()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	var rt http.RoundTripper = base
+	if isOpenRouter {
+		// Add routing preferences and usage accounting to every request
+		rt = routing.Transport(base)
+	}
+	client := chat.NewClient(*provider, resolvedAPIKey, rt)
 
 	// Debug info
 	if *debug {
 		fmt.Println(infoStyle.Render("\n[Debug Info]"))
 		fmt.Printf("  Endpoint: %s\n", provider.APIEndpoint)
-		fmt.Printf("  API Key: %s...%s\n", resolvedAPIKey[:4], resolvedAPIKey[len(resolvedAPIKey)-4:])
+		if len(resolvedAPIKey) > 8 {
+			fmt.Printf("  API Key: %s...%s\n", resolvedAPIKey[:4], resolvedAPIKey[len(resolvedAPIKey)-4:])
+		}
 		fmt.Printf("  Type: %s\n", provider.Type)
+		fmt.Printf("  Auth: %s\n", strings.TrimSpace(string(scheme.Kind)+" "+scheme.Name))
 		if len(provider.DefaultHeaders) > 0 {
 			fmt.Println("  Headers:")
-			for k, v := range provider.DefaultHeaders {
-				fmt.Printf("    %s: %s\n", k, v)
+			for _, k := range slices.Sorted(maps.Keys(provider.DefaultHeaders)) {
+				fmt.Printf("    %s: %s\n", k, provider.DefaultHeaders[k])
 			}
 		}
+		if isOpenRouter && !routing.IsZero() {
+			fields, _ := json.Marshal(routing)
+			fmt.Printf("  OpenRouter: %s\n", fields)
+		}
 		fmt.Println()
 	}
 
 	// Create chat session
 	session := &chatSession{
-		client:   client,
-		provider: provider,
-		model:    model,
-		messages: []openai.ChatCompletionMessage{},
+		chat:        chat.New(client, *provider, *model),
+		client:      client,
+		provider:    provider,
+		model:       model,
+		catalog:     catalog,
+		contextWarn: thresholds,
+		rules:       requestRules,
+		policy:      orgPolicy,
+		router:      router,
+		fallbacks:   fallbacks,
+	}
+	if !*sessionsMode {
+		session.thinking = &thinkingWriter{session: session}
+	}
+	session.setSampling(sampling)
+
+	// Set up speech input and output
+	if *voice {
+		v, err := newVoiceMode(catalog, provider, resolvedAPIKey, localOverrides, base)
+		if err != nil {
+			fmt.Println(errorStyle.Render("Error: " + err.Error()))
+			os.Exit(catwalk.ExitCode(err))
+		}
+		session.voice = v
+	}
+
+	// Open transcript log if requested
+	if *logFile != "" {
+		w, err := transcript.Open(*logFile)
+		if err != nil {
+			log.Fatalf("Error opening transcript: %v", err)
+		}
+		defer w.Close() //nolint:errcheck
+		session.transcript = w
+	}
+	session.sessionID = transcript.NewSessionID()
+
+	// Copy each reply to a file if requested
+	if *teeFile != "" {
+		t, err := openTee(*teeFile, *teeCode)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		defer t.file.Close() //nolint:errcheck
+		session.tee = t
+	} else if *teeCode {
+		log.Fatal("Error: --tee-code needs --tee <file>")
+	}
+	if err := setupQuotas(session, localOverrides); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if *autosave {
+		dir, err := journalDir()
+		if err == nil {
+			err = os.MkdirAll(dir, 0o700)
+		}
+		if err != nil {
+			fmt.Println(warnStyle.Render(render.Symbol("⚠", "!") + " Autosave disabled: " + err.Error()))
+		} else {
+			session.journal = filepath.Join(dir, session.sessionID+".json")
+		}
 	}
 
-	// Add system prompt if provided
-	if *systemPrompt != "" {
-		session.messages = append(session.messages, openai.ChatCompletionMessage{
-			Role:    openai.ChatMessageRoleSystem,
-			Content: *systemPrompt,
-		})
+	// Scan outgoing messages for secrets if requested
+	if *redactPolicy != "" {
+		closeLog, err := setupSecretScanner(session, resolvedAPIKey)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		defer closeLog() //nolint:errcheck
+	}
+
+	// Add system prompt if provided, either directly or from a preset
+	session.prompts = openPrompts()
+	loadPlugins(session)
+	switch {
+	case *systemPrompt != "" && *preset != "":
+		log.Fatal("Use either --system or --preset, not both.")
+	case *systemPrompt != "":
+		session.chat.Append(chat.RoleSystem, *systemPrompt)
+	case *preset != "":
+		p, err := session.prompts.Get(*preset)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		session.chat.Append(chat.RoleSystem, p.Content)
+		session.preset = p.Name
 	}
 
 	// Print header
 	printHeader(provider, model)
+	if router != nil {
+		fmt.Printf("%s %s for simple messages, %s for hard ones\n\n",
+			infoStyle.Render("Smart routing:"), router.small.ID, router.large.ID)
+	}
+	if len(fallbacks) > 0 {
+		ids := make([]string, len(fallbacks))
+		for i, m := range fallbacks {
+			ids[i] = m.ID
+		}
+		fmt.Printf("%s %s, in order, when the model fails\n\n", infoStyle.Render("Failover:"), strings.Join(ids, ", "))
+	}
+	if session.voice != nil {
+		session.voice.printHeader()
+	}
+	if session.journal != "" && term.IsTerminal(os.Stdin.Fd()) {
+		offerResume(session)
+	}
+	if *contextFiles != "" {
+		attachFiles(session, strings.Split(*contextFiles, ","))
+	}
 
-	// Start chat loop
-	runChatLoop(session)
-}
+	// The first Ctrl-C cancels ctx, which aborts any in-flight request and
+	// ends the chat loop cleanly. Restoring the default signal behavior
+	// afterwards makes a second Ctrl-C force-quit.
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
-func resolveAPIKey(provider *catwalk.Provider) string {
-	// Priority: flag > env var > provider config
-	if *apiKey != "" {
-		return *apiKey
-	}
-
-	// Check environment variable based on provider
-	envKey := getEnvKeyName(provider.ID)
-	if key := os.Getenv(envKey); key != "" {
-		return key
-	}
-
-	// Fall back to provider config
-	return provider.APIKey
-}
-
-func getEnvKeyName(providerID catwalk.InferenceProvider) string {
-	switch providerID {
-	case catwalk.InferenceProviderOpenAI:
-		return "OPENAI_API_KEY"
-	case catwalk.InferenceProviderAnthropic:
-		return "ANTHROPIC_API_KEY"
-	case catwalk.InferenceProviderGemini:
-		return "GEMINI_API_KEY"
-	case catwalk.InferenceProviderXAI:
-		return "XAI_API_KEY"
-	case catwalk.InferenceProviderGROQ:
-		return "GROQ_API_KEY"
-	case catwalk.InferenceProviderOpenRouter:
-		return "OPENROUTER_API_KEY"
-	case catwalk.InferenceProviderCerebras:
-		return "CEREBRAS_API_KEY"
-	case catwalk.InferenceProviderHuggingFace:
-		return "HUGGINGFACE_API_KEY"
-	case catwalk.InferenceProviderVercel:
-		return "VERCEL_API_KEY"
-	default:
-		return strings.ToUpper(string(providerID)) + "_API_KEY"
+	// Start chat loop; the journal is only kept if it does not end normally
+	if *sessionsMode {
+		runWorkspace(ctx, session)
+	} else {
+		runChatLoop(ctx, session)
 	}
+	session.discardJournal()
 }
 
-// headerTransport adds custom headers to all requests
-type headerTransport struct {
-	base    http.RoundTripper
-	headers map[string]string
-}
-
-func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	for k, v := range t.headers {
-		req.Header.Set(k, v)
+// resolveAPIKey returns the --api-key flag if set, else the provider's key
+func resolveAPIKey(provider *catwalk.Provider, o *registry.Overrides) (string, error) {
+	if *apiKey != "" {
+		return *apiKey, nil
 	}
-	return t.base.RoundTrip(req)
+	return providerKey(*provider, o)
 }
 
-func createClient(provider *catwalk.Provider, apiKey string) *openai.Client {
-	config := openai.DefaultConfig(apiKey)
-	config.BaseURL = provider.APIEndpoint
-
-	// Add custom headers if provider has them
-	if len(provider.DefaultHeaders) > 0 {
-		transport := &headerTransport{
-			base:    http.DefaultTransport,
-			headers: provider.DefaultHeaders,
-		}
-		config.HTTPClient = &http.Client{Transport: transport}
+// providerKey reads the provider's key from the environment variable the
+// catalog names, or the one the overrides file's api_key_env names instead
+func providerKey(provider catwalk.Provider, o *registry.Overrides) (string, error) {
+	if env, ok := o.APIKeyEnv(provider.ID); ok {
+		provider.APIKey = "$" + env
 	}
-
-	return openai.NewClientWithConfig(config)
+	return provider.ResolveAPIKey() //nolint:wrapcheck
 }
 
 func printHeader(provider *catwalk.Provider, model *catwalk.Model) {
 	fmt.Println()
-	fmt.Println(headerStyle.Render("AI Chat Bot"))
-	fmt.Println(borderStyle.Render(strings.Repeat("─", 60)))
+	fmt.Println(cli.HeaderStyle.Render("AI Chat Bot"))
+	fmt.Println(cli.BorderStyle.Render(render.Rule(60)))
 	fmt.Println()
 	fmt.Printf("%s %s\n", infoStyle.Render("Provider:"), provider.Name)
 	fmt.Printf("%s %s\n", infoStyle.Render("Model:"), model.Name)
@@ -276,24 +901,213 @@ func printHeader(provider *catwalk.Provider, model *catwalk.Model) {
 		model.CostPer1MOut)
 	fmt.Printf("%s %dK tokens\n", infoStyle.Render("Context:"), model.ContextWindow/1000)
 	fmt.Println()
-	fmt.Println(borderStyle.Render(strings.Repeat("─", 60)))
+	fmt.Println(cli.BorderStyle.Render(render.Rule(60)))
 	fmt.Println(infoStyle.Render("Type your message and press Enter. Commands:"))
 	fmt.Println(infoStyle.Render("  /clear  - Clear conversation history"))
 	fmt.Println(infoStyle.Render("  /cost   - Show current session cost"))
+	fmt.Println(infoStyle.Render("  /chart  - Chart tokens and cost per turn"))
+	fmt.Println(infoStyle.Render("  /set    - Show or change sampling parameters"))
+	fmt.Println(infoStyle.Render("  /preset - List or switch system prompt presets"))
+	fmt.Println(infoStyle.Render("  /import - Continue an exported conversation"))
+	fmt.Println(infoStyle.Render("  /file   - Attach files to the next message"))
+	fmt.Println(infoStyle.Render("  /whatif - Price this session on another model"))
+	fmt.Println(infoStyle.Render("  /model  - Switch to another of the provider's models"))
+	fmt.Println(infoStyle.Render("  /retry  - Ask for the last answer again and see what changed"))
+	fmt.Println(infoStyle.Render("  /good   - Rate the last answer good, or /bad [reason]"))
 	fmt.Println(infoStyle.Render("  /quit   - Exit the chat"))
-	fmt.Println(borderStyle.Render(strings.Repeat("─", 60)))
+	fmt.Println(cli.BorderStyle.Render(render.Rule(60)))
 	fmt.Println()
 }
 
-func runChatLoop(session *chatSession) {
-	reader := bufio.NewReader(os.Stdin)
+// inputLine is a line read from stdin, or the error that ended input.
+type inputLine struct {
+	text string
+	err  error
+}
+
+// readLines reads lines from r in the background so the chat loop can stop
+// waiting for input when it is interrupted.
+func readLines(r io.Reader) <-chan inputLine {
+	lines := make(chan inputLine)
+	go func() {
+		reader := bufio.NewReader(r)
+		for {
+			text, err := reader.ReadString('\n')
+			lines <- inputLine{text: text, err: err}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return lines
+}
 
-	for {
-		// Print prompt
-		fmt.Print(promptStyle.Render("You: "))
+// errInterrupted is returned in place of input when the user presses Ctrl-C
+// at the prompt.
+var errInterrupted = errors.New("interrupted")
+
+// composer is a single-line prompt that shows a live estimate of the pending
+// message's tokens and cost while the user types, or while typing /model, the
+// provider's models matching what has been typed.
+type composer struct {
+	input   textinput.Model
+	models  cli.Dropdown
+	session *chatSession
+	done    bool
+	err     error
+}
+
+func newComposer(session *chatSession) composer {
+	input := textinput.New()
+	input.Prompt = promptStyle.Render("You: ")
+	input.CharLimit = 0
+	input.Focus()
+	ids := make([]string, len(session.provider.Models))
+	for i, m := range session.provider.Models {
+		ids[i] = m.ID
+	}
+	return composer{input: input, models: cli.NewDropdown(ids, 5), session: session}
+}
+
+func (c composer) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (c composer) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.Type {
+		case tea.KeyEnter:
+			c.done = true
+			return c, tea.Quit
+		case tea.KeyCtrlC:
+			c.done, c.err = true, errInterrupted
+			return c, tea.Quit
+		case tea.KeyCtrlD:
+			// Like a terminal, Ctrl-D on an empty line ends input
+			if c.input.Value() == "" {
+				c.done, c.err = true, io.EOF
+				return c, tea.Quit
+			}
+		case tea.KeyUp, tea.KeyDown:
+			if msg.Type == tea.KeyUp {
+				c.models.Move(-1)
+			} else {
+				c.models.Move(1)
+			}
+			return c, nil
+		case tea.KeyTab:
+			if id, ok := c.models.Selected(); ok {
+				c.input.SetValue("/model " + id)
+				c.input.CursorEnd()
+				c.models.Filter("")
+			}
+			return c, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	c.input, cmd = c.input.Update(msg)
+	if _, ok := msg.(tea.KeyMsg); ok {
+		c.models.Filter(modelArg(c.input.Value()))
+	}
+	return c, cmd
+}
+
+func (c composer) View() string {
+	if c.done {
+		// Leave only the submitted line in the scrollback
+		return promptStyle.Render("You: ") + c.input.Value() + "\n"
+	}
+	if list := c.models.View(); list != "" {
+		return c.input.View() + "\n" + list + infoStyle.Render("  Up/Down to choose, Tab to complete")
+	}
+	return c.input.View() + "\n" + infoStyle.Render(pendingEstimate(c.session, c.input.Value()))
+}
+
+// modelArg returns what follows /model in a line being typed, or "" if the
+// line is not a /model command.
+func modelArg(line string) string {
+	const prefix = "/model "
+	line = strings.TrimLeft(line, " ")
+	if len(line) < len(prefix) || !strings.EqualFold(line[:len(prefix)], prefix) {
+		return ""
+	}
+	return strings.TrimSpace(line[len(prefix):])
+}
+
+// pendingEstimate describes what sending text would cost: the message's own
+// tokens, plus the input tokens, input cost, and context share of the whole
+// request it would produce.
+func pendingEstimate(session *chatSession, text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "/") {
+		return "  command (not sent to the model)"
+	}
+
+	message := 0
+	if text != "" {
+		message = tokenizer.CountMessage(chat.RoleUser, text)
+	}
+	files := ""
+	if n := len(session.attachments); n > 0 {
+		message += attachmentTokens(session.attachments)
+		files = fmt.Sprintf(" with %d file%s", n, plural(n))
+	}
+	total := session.chat.ContextTokens() + message
+	estimate := fmt.Sprintf("  message%s ~%s tokens | request ~%s tokens, $%.6f input",
+		files, formatCount(int64(message)), formatCount(int64(total)), chat.Cost(*session.model, total, 0))
+	if window := session.model.ContextWindow; window > 0 {
+		estimate += fmt.Sprintf(" | context %.1f%% of %s", float64(total)/float64(window)*100, formatCount(window))
+	}
+	return estimate
+}
+
+// compose reads a line with the live estimate shown below the prompt.
+func compose(ctx context.Context, session *chatSession) inputLine {
+	m, err := tea.NewProgram(newComposer(session), tea.WithContext(ctx)).Run()
+	if err != nil {
+		if ctx.Err() != nil {
+			return inputLine{err: errInterrupted}
+		}
+		return inputLine{err: err}
+	}
+	c := m.(composer)
+	return inputLine{text: c.input.Value(), err: c.err}
+}
+
+// liveInput reports whether the prompt can show a live estimate, which needs
+// an interactive terminal on both ends.
+func liveInput() bool {
+	return *liveEstimate && term.IsTerminal(os.Stdin.Fd()) && term.IsTerminal(os.Stdout.Fd())
+}
+
+func runChatLoop(ctx context.Context, session *chatSession) {
+	// Voice mode reads plain lines, so an empty line can start a recording
+	live := liveInput() && session.voice == nil
+	var lines <-chan inputLine
+	if !live {
+		lines = readLines(os.Stdin)
+	}
 
+	for {
 		// Read input
-		input, err := reader.ReadString('\n')
+		var line inputLine
+		if live {
+			line = compose(ctx, session)
+		} else {
+			fmt.Print(promptStyle.Render("You: "))
+			select {
+			case line = <-lines:
+			case <-ctx.Done():
+				line.err = errInterrupted
+			}
+		}
+		input, err := line.text, line.err
+		if errors.Is(err, errInterrupted) {
+			fmt.Println()
+			printSessionSummary(session)
+			return
+		}
 		if err != nil {
 			if err == io.EOF {
 				fmt.Println("\nGoodbye!")
@@ -304,189 +1118,659 @@ func runChatLoop(session *chatSession) {
 
 		input = strings.TrimSpace(input)
 		if input == "" {
-			continue
+			if session.voice == nil {
+				continue
+			}
+			input, err = session.voice.listen(ctx, lines)
+			if errors.Is(err, errInterrupted) {
+				fmt.Println()
+				printSessionSummary(session)
+				return
+			}
+			if err != nil {
+				fmt.Println(errorStyle.Render("Voice: " + err.Error()))
+				fmt.Println()
+				continue
+			}
+			if input == "" {
+				fmt.Println(infoStyle.Render("Nothing was heard."))
+				fmt.Println()
+				continue
+			}
+			fmt.Printf("%s %s\n", userStyle.Render("You (voice):"), input)
 		}
 
-		// Handle commands
-		if strings.HasPrefix(input, "/") {
-			if handleCommand(session, input) {
-				continue
-			} else {
+		// Handle commands; one from the commands directory may return a
+		// message to send. /retry sends the last message again instead
+		retry := strings.EqualFold(input, "/retry")
+		if strings.HasPrefix(input, "/") && !retry {
+			prompt, handled := runPlugin(ctx, session, input)
+			switch {
+			case !handled && !handleCommand(session, input):
 				return // /quit command
+			case prompt == "":
+				session.saveJournal()
+				continue
 			}
+			input = prompt
 		}
 
-		// Add user message
-		session.messages = append(session.messages, openai.ChatCompletionMessage{
-			Role:    openai.ChatMessageRoleUser,
-			Content: input,
-		})
+		history := session.chat.Messages()
+		var previous string
+		if retry {
+			// Drop the last reply, keeping it to compare with the new one
+			var ok bool
+			if previous, ok = lastReply(history); !ok {
+				fmt.Println(infoStyle.Render("Nothing to retry yet: send a message first."))
+				fmt.Println()
+				continue
+			}
+			session.chat.SetMessages(history[:len(history)-1])
+		} else {
+			// Mask or block secrets before the message reaches the history
+			var masked []secrets.Kind
+			input, masked, err = filterSecrets(session, "message", input)
+			if err != nil {
+				fmt.Println(errorStyle.Render("Not sent: " + err.Error()))
+				fmt.Println(infoStyle.Render("Remove it and try again, or change --redact-secrets."))
+				fmt.Println()
+				continue
+			}
+			printMasked(masked)
+
+			// Add user message, with any attached files before it
+			session.chat.Append(chat.RoleUser, withAttachments(session.attachments, input))
+		}
 
-		// Make API call
+		// Let the pre hook redact or block the request
+		if err := runPreHook(ctx, session); err != nil {
+			fmt.Println(errorStyle.Render("Not sent: " + err.Error()))
+			fmt.Println()
+			session.chat.SetMessages(history)
+			continue
+		}
+
+		// Pick this message's model with --smart-routing; a retry is routed
+		// by the message it repeats
+		if session.router != nil && !session.router.off {
+			text := input
+			if retry {
+				text = lastUserText(history)
+			}
+			session.router.route(session, text)
+		}
+
+		// Warn before sending a request that will not fit
+		if window := session.model.ContextWindow; window > 0 {
+			if needed := int64(session.chat.ContextTokens()) + int64(session.chat.ReplyTokens()); needed > window {
+				fmt.Println(warnStyle.Render(fmt.Sprintf(
+					"%s This request needs ~%s tokens but the context window is %s; it may fail or be truncated. Use /clear to start over.",
+					render.Symbol("⚠", "!"), formatCount(needed), formatCount(window))))
+			}
+		}
+
+		// Warn before a request that would exceed the provider's quota
+		if session.quotas != nil {
+			needed := int64(session.chat.ContextTokens()) + int64(session.chat.ReplyTokens())
+			if err := session.quotas.Check(session.provider.ID, needed); err != nil {
+				fmt.Println(warnStyle.Render(render.Symbol("⚠", "!") + " Quota: " + err.Error()))
+			}
+		}
+
+		// Make API call, streaming the response as it arrives
 		fmt.Print(aiStyle.Render("AI: "))
 
-		response, err := sendMessage(session)
-		if err != nil {
+		start := time.Now()
+		response, err := session.chat.Complete(ctx, session.thinking.reply(os.Stdout))
+		latency := time.Since(start)
+		if session.thinking.open {
+			// Only reasoning arrived
+			session.thinking.open = false
 			fmt.Println()
+		}
+		fmt.Println()
+		if response != nil && !response.Cached {
+			session.requests = append(session.requests, usage.Record{
+				Requests:     1,
+				InputTokens:  int64(response.InputTokens),
+				OutputTokens: int64(response.OutputTokens),
+				Cost:         response.Cost,
+				HasCost:      true,
+			})
+			if session.quotas != nil {
+				session.quotas.Record(transcript.Entry{
+					Time:     time.Now(),
+					Provider: string(session.provider.ID),
+					Usage:    transcript.Usage{InputTokens: response.InputTokens, OutputTokens: response.OutputTokens},
+				})
+			}
+		}
+		if hookErr := runPostHook(ctx, session, response, err); hookErr != nil {
+			fmt.Println(warnStyle.Render(render.Symbol("⚠", "!") + " " + hookErr.Error()))
+		}
+		logTurn(session, response, latency, err)
+		if ctx.Err() != nil {
+			// Interrupted: what was streamed so far is already in the totals
+			fmt.Println(warnStyle.Render("[interrupted]"))
+			printSessionSummary(session)
+			return
+		}
+		// A reply cut off by --timeout is kept, marked, with what it cost
+		truncated := timedOut(response, err)
+		if truncated {
+			fmt.Println(warnStyle.Render(fmt.Sprintf("[truncated: %s; the partial reply is kept]", err)))
+		}
+		if err != nil && !truncated {
 			fmt.Println(errorStyle.Render("Error: " + err.Error()))
+			if errors.Is(err, catwalk.ErrOverBudget) {
+				fmt.Println(infoStyle.Render("Use /quit and restart with a higher --budget to continue."))
+			}
 			// Remove the failed user message
-			session.messages = session.messages[:len(session.messages)-1]
+			session.chat.SetMessages(history)
 			continue
 		}
 
-		// Print response
-		fmt.Println(response.content)
+		// Add assistant message to history; the attachments have been sent
+		session.chat.Append(chat.RoleAssistant, response.Content)
+		session.attachments = nil
+		session.saveJournal()
+		if err := session.tee.write(response.Content); err != nil {
+			fmt.Println(warnStyle.Render(render.Symbol("⚠", "!") + " " + err.Error()))
+		}
 
-		// Add assistant message to history
-		session.messages = append(session.messages, openai.ChatCompletionMessage{
-			Role:    openai.ChatMessageRoleAssistant,
-			Content: response.content,
-		})
+		// Reasoning streamed above it when shown, and is collapsed otherwise
+		session.lastReasoning = response.Reasoning
+		if response.Reasoning != "" && !session.showThinking {
+			fmt.Println(infoStyle.Render(thinkingNote(response)))
+		}
 
-		// Update and show cost
-		session.totalTokens += response.inputTokens + response.outputTokens
-		session.totalCost += response.cost
+		// Show cost
+		fmt.Printf("%s tokens: %d (in: %d, out: %d) | cost: $%.6f | session: $%.6f%s\n",
+			cli.CostStyle.Render(render.Symbol("→", "->")),
+			response.InputTokens+response.OutputTokens,
+			response.InputTokens,
+			response.OutputTokens,
+			response.Cost,
+			session.chat.Usage().Cost,
+			routing(response, session.model.ID))
+		if session.router != nil && !session.router.off {
+			session.router.record(session.model, response)
+		}
+		printContextUsage(session)
+		if retry {
+			printReplyDiff(previous, response.Content)
+		}
 
-		fmt.Printf("%s tokens: %d (in: %d, out: %d) | cost: $%.6f | session: $%.6f\n",
-			costStyle.Render("→"),
-			response.inputTokens+response.outputTokens,
-			response.inputTokens,
-			response.outputTokens,
-			response.cost,
-			session.totalCost)
+		// Speak the reply; Ctrl-C stops playback and ends the chat
+		if session.voice != nil {
+			if err := session.voice.speak(ctx, response.Content); err != nil && ctx.Err() == nil {
+				fmt.Println(errorStyle.Render("Voice: " + err.Error()))
+			}
+		}
 		fmt.Println()
 	}
 }
 
-func handleCommand(session *chatSession, cmd string) bool {
-	switch strings.ToLower(cmd) {
-	case "/quit", "/exit", "/q":
-		fmt.Println()
-		fmt.Println(infoStyle.Render("Session Summary:"))
-		fmt.Printf("  Total tokens: %d\n", session.totalTokens)
-		fmt.Printf("  Total cost: $%.6f\n", session.totalCost)
-		fmt.Println()
-		fmt.Println("Goodbye!")
-		return false
-
-	case "/clear":
-		// Keep system message if present
-		var systemMsg []openai.ChatCompletionMessage
-		if len(session.messages) > 0 && session.messages[0].Role == openai.ChatMessageRoleSystem {
-			systemMsg = session.messages[:1]
+// setupQuotas tracks the provider's quota if the overrides file sets one,
+// counting the usage already logged to the --log-transcript file
+func setupQuotas(session *chatSession, o *registry.Overrides) error {
+	quotas := o.Quotas()
+	if _, ok := quotas[session.provider.ID]; !ok {
+		return nil
+	}
+	var ledger []transcript.Entry
+	var err error
+	if *logFile != "" {
+		if ledger, err = transcript.ReadFile(*logFile); err != nil {
+			return err //nolint:wrapcheck
 		}
-		session.messages = systemMsg
-		fmt.Println(infoStyle.Render("Conversation cleared."))
-		fmt.Println()
-		return true
+	}
+	session.quotas, err = quota.NewQuotas(quotas, ledger)
+	return err //nolint:wrapcheck
+}
 
-	case "/cost":
-		fmt.Println()
-		fmt.Println(infoStyle.Render("Session Statistics:"))
-		fmt.Printf("  Messages: %d\n", len(session.messages))
-		fmt.Printf("  Total tokens: %d\n", session.totalTokens)
-		fmt.Printf("  Total cost: $%.6f\n", session.totalCost)
-		fmt.Println()
-		return true
+// printQuota prints what is left of the provider's quota
+func printQuota(session *chatSession) {
+	if session.quotas == nil {
+		return
+	}
+	s, _ := session.quotas.StatusOf(session.provider.ID)
+	var left []string
+	if n, ok := s.RequestsLeft(); ok {
+		left = append(left, fmt.Sprintf("%s of %s requests", formatCount(int64(n)), formatCount(int64(s.Quota.Requests))))
+	}
+	if n, ok := s.TokensLeft(); ok {
+		left = append(left, fmt.Sprintf("%s of %s tokens", formatCount(n), formatCount(s.Quota.Tokens)))
+	}
+	fmt.Printf("  Quota left (%s): %s (resets %s)\n", s.Quota.Period, strings.Join(left, ", "), s.Resets.Format("Jan 2 15:04"))
+	if *logFile == "" {
+		fmt.Println(infoStyle.Render("    Counted from this session only; use --log-transcript to count earlier ones"))
+	}
+}
 
-	case "/help":
-		fmt.Println()
-		fmt.Println(infoStyle.Render("Available commands:"))
-		fmt.Println("  /clear  - Clear conversation history")
-		fmt.Println("  /cost   - Show current session cost")
-		fmt.Println("  /help   - Show this help")
-		fmt.Println("  /quit   - Exit the chat")
-		fmt.Println()
-		return true
+// printUpstreamCost prints the session cost per upstream provider, when the
+// provider reported them.
+func printUpstreamCost(session *chatSession) {
+	costs := session.chat.CostByUpstream()
+	if len(costs) == 0 {
+		return
+	}
+	fmt.Println("  By upstream provider:")
+	for _, name := range slices.Sorted(maps.Keys(costs)) {
+		fmt.Printf("    %-20s $%.6f\n", name, costs[name])
+	}
+}
 
-	default:
-		fmt.Println(errorStyle.Render("Unknown command: " + cmd))
-		fmt.Println(infoStyle.Render("Type /help for available commands."))
-		fmt.Println()
-		return true
+// setupSecretScanner sets up the --redact-secrets scanner, which also treats
+// the API key in use as a secret whatever its format, and opens the
+// --redact-log file. It returns a function that closes the log.
+func setupSecretScanner(session *chatSession, apiKey string) (func() error, error) {
+	policy, err := secrets.ParsePolicy(*redactPolicy)
+	if err != nil {
+		return nil, err
 	}
+
+	closeLog := func() error { return nil }
+	if *redactLog != "" {
+		f, err := os.OpenFile(*redactLog, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open redaction log: %w", err)
+		}
+		session.redactLog = json.NewEncoder(f)
+		closeLog = f.Close
+	}
+	session.secrets = newScanner(session, policy, []string{apiKey})
+	return closeLog, nil
 }
 
-type apiResponse struct {
-	content      string
-	inputTokens  int
-	outputTokens int
-	cost         float64
+// newScanner returns a scanner applying policy that records what it masks
+// or blocks on session and in the session's redaction log.
+func newScanner(session *chatSession, policy secrets.Policy, known []string) *secrets.Scanner {
+	return &secrets.Scanner{
+		Policy: policy,
+		Known:  known,
+		OnEvent: func(e secrets.Event) {
+			session.redacted = append(session.redacted, e.Kind)
+			if session.redactLog == nil {
+				return
+			}
+			entry := struct {
+				secrets.Event
+				Session  string `json:"session"`
+				Provider string `json:"provider"`
+				Model    string `json:"model"`
+			}{e, session.sessionID, string(session.provider.ID), session.model.ID}
+			if err := session.redactLog.Encode(entry); err != nil {
+				fmt.Println(warnStyle.Render(render.Symbol("⚠", "!") + " Redaction log: " + err.Error()))
+			}
+		},
+	}
 }
 
-func sendMessage(session *chatSession) (*apiResponse, error) {
-	ctx := context.Background()
+// filterSecrets applies --redact-secrets to text from source before it is
+// sent, returning the text with secrets masked and the kinds masked, or an
+// error if the text must not be sent.
+func filterSecrets(session *chatSession, source, text string) (string, []secrets.Kind, error) {
+	if session.secrets == nil {
+		return text, nil, nil
+	}
+	session.redacted = nil
+	filtered, err := session.secrets.Filter(source, text)
+	if err != nil {
+		return text, nil, err
+	}
+	return filtered, session.redacted, nil
+}
+
+// printMasked tells the user which secrets were masked, if any.
+func printMasked(masked []secrets.Kind) {
+	if len(masked) == 0 {
+		return
+	}
+	fmt.Println(warnStyle.Render(fmt.Sprintf("%s Masked %s before sending.",
+		render.Symbol("⚠", "!"), secrets.Summary(masked))))
+}
 
-	// Build request
-	req := openai.ChatCompletionRequest{
+// logTurn appends the request just sent and its outcome to the transcript.
+func logTurn(session *chatSession, response *chat.Response, latency time.Duration, sendErr error) {
+	if session.transcript == nil {
+		return
+	}
+
+	entry := transcript.Entry{
+		Session:  session.sessionID,
+		Provider: string(session.provider.ID),
 		Model:    session.model.ID,
-		Messages: session.messages,
+		Params: transcript.Params{
+			MaxTokens:        session.chat.ReplyTokens(),
+			Temperature:      session.sampling.temperature,
+			TopP:             session.sampling.topP,
+			FrequencyPenalty: session.sampling.frequencyPenalty,
+			Seed:             session.sampling.seed,
+			Stop:             session.sampling.stop,
+		},
+		LatencyMS: latency.Milliseconds(),
+		Request:   session.chat.Messages(),
+		Tags:      splitTags(*tags),
+	}
+	if sendErr != nil {
+		entry.Error = sendErr.Error()
+	}
+	if response != nil {
+		entry.Response = &transcript.Message{Role: chat.RoleAssistant, Content: response.Content}
+		entry.Usage = transcript.Usage{
+			InputTokens:     response.InputTokens,
+			OutputTokens:    response.OutputTokens,
+			ReasoningTokens: response.ReasoningTokens,
+			CacheReadTokens: response.CacheReadTokens,
+		}
+		entry.Reasoning = response.Reasoning
+		entry.Cost = response.Cost
+		entry.Billed = response.Billed
+		entry.Upstream = response.Upstream
+		if response.Model != session.model.ID {
+			entry.ServedModel = response.Model
+		}
+		entry.FailoverReason = response.FailoverReason
+		entry.Truncated = response.Truncated
 	}
 
-	// Set max tokens if specified
-	if *maxTokens > 0 {
-		req.MaxTokens = *maxTokens
-	} else if session.model.DefaultMaxTokens > 0 {
-		req.MaxTokens = int(session.model.DefaultMaxTokens)
+	if err := session.transcript.Write(entry); err != nil {
+		fmt.Println(errorStyle.Render("Transcript: " + err.Error()))
 	}
+}
 
-	// Make API call
-	resp, err := session.client.CreateChatCompletion(ctx, req)
-	if err != nil {
-		return nil, fmt.Errorf("API call failed: %w", err)
+// printContextUsage prints the context meter and warns the first time each
+// configured threshold is crossed.
+func printContextUsage(session *chatSession) {
+	window := session.model.ContextWindow
+	if window <= 0 {
+		return
+	}
+
+	used := int64(session.chat.ContextTokens())
+	pct := float64(used) / float64(window) * 100
+	fmt.Printf("%s context used: %s / %s tokens (%.0f%%)\n",
+		cli.CostStyle.Render(render.Symbol("→", "->")), formatCount(used), formatCount(window), pct)
+
+	var crossed float64
+	for _, t := range session.contextWarn {
+		if pct >= t {
+			crossed = t
+		}
+	}
+	if crossed > session.warnedAt {
+		session.warnedAt = crossed
+		fmt.Println(warnStyle.Render(fmt.Sprintf(
+			"%s Context is %.0f%% full. Older messages may be truncated or requests may fail; use /clear to start over.", render.Symbol("⚠", "!"), pct)))
+	}
+}
+
+// splitTags parses a comma-separated list of tags, dropping empty ones.
+func splitTags(list string) []string {
+	var out []string
+	for _, t := range strings.Split(list, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			out = append(out, t)
+		}
 	}
+	return out
+}
 
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from model")
+// parseThresholds parses a comma-separated list of percentages.
+func parseThresholds(s string) ([]float64, error) {
+	var thresholds []float64
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(part), "%"))
+		if part == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || v <= 0 || v > 100 {
+			return nil, fmt.Errorf("%q is not a percentage between 0 and 100", part)
+		}
+		thresholds = append(thresholds, v)
 	}
+	slices.Sort(thresholds)
+	return thresholds, nil
+}
 
-	// Calculate cost
-	inputTokens := resp.Usage.PromptTokens
-	outputTokens := resp.Usage.CompletionTokens
-	cost := calculateCost(session.model, inputTokens, outputTokens)
+// formatCount formats n with thousands separators (e.g. 200,000).
+func formatCount(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
 
-	return &apiResponse{
-		content:      resp.Choices[0].Message.Content,
-		inputTokens:  inputTokens,
-		outputTokens: outputTokens,
-		cost:         cost,
-	}, nil
+// printSessionSummary prints the session totals before exiting.
+func printSessionSummary(session *chatSession) {
+	usage := session.chat.Usage()
+	fmt.Println(infoStyle.Render("Session Summary:"))
+	fmt.Printf("  Total tokens: %d\n", usage.InputTokens+usage.OutputTokens)
+	fmt.Printf("  Total cost: $%.6f\n", usage.Cost)
+	if session.priorCost > 0 {
+		fmt.Printf("  Before resuming: $%.6f\n", session.priorCost)
+	}
+	printUpstreamCost(session)
+	printRouting(session)
+	printVoiceUsage(session)
+	fmt.Println()
+	fmt.Println("Goodbye!")
 }
 
-func calculateCost(model *catwalk.Model, inputTokens, outputTokens int) float64 {
-	inputCost := float64(inputTokens) * model.CostPer1MIn / 1_000_000
-	outputCost := float64(outputTokens) * model.CostPer1MOut / 1_000_000
-	return inputCost + outputCost
+// routing describes where a router such as OpenRouter sent the request,
+// for the cost line.
+func routing(r *chat.Response, requested string) string {
+	var parts []string
+	if r.Upstream != "" {
+		parts = append(parts, "via "+r.Upstream)
+	}
+	switch {
+	case r.FailoverReason != "":
+		parts = append(parts, fmt.Sprintf("failed over to %s (%s)", r.Model, r.FailoverReason))
+	case r.Model != "" && r.Model != requested:
+		parts = append(parts, "fallback "+r.Model)
+	}
+	if r.Billed {
+		parts = append(parts, "billed")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " | " + strings.Join(parts, ", ")
 }
 
 func printHelp() {
 	fmt.Println("chat-bot - Interactive CLI chat bot with catwalk integration")
 	fmt.Println()
 	fmt.Println("Usage:")
-	fmt.Println("  go run main.go --provider <id> [options]")
+	fmt.Println("  go run . --provider <id> [options]")
 	fmt.Println()
 	fmt.Println("Required:")
-	fmt.Println("  --provider <id>     Provider ID (e.g., openai, anthropic, google)")
+	fmt.Println("  --provider <id>     Provider ID (e.g., openai, anthropic, google), unless a default")
+	fmt.Println("                      is set with 'aimodels init', whose model and catalog URL are")
+	fmt.Println("                      then used too")
 	fmt.Println()
 	fmt.Println("Optional:")
 	fmt.Println("  --model <id>        Model ID (uses provider default if not specified)")
+	fmt.Println("  --size <size>       Use the provider's default small or large model instead of --model")
+	fmt.Println("  --smart-routing     Send each message to the provider's default small model if it")
+	fmt.Println("                      looks simple, or to the large model (--model, or the default")
+	fmt.Println("                      large one) if it is long, holds or attaches code, or asks for")
+	fmt.Println("                      reasoning; each turn shows its choice, /cost the savings")
+	fmt.Println("  --fallback <ids>    Comma-separated models of the provider to fail over to, in order,")
+	fmt.Println("                      when the model is rate limited, erroring, or unreachable; the")
+	fmt.Println("                      turn and transcript record the model used, its cost, and why")
 	fmt.Println("  --system <prompt>   System prompt for the conversation")
-	fmt.Println("  --max-tokens <n>    Max tokens for response (0 = model default)")
-	fmt.Println("  --api-key <key>     API key (overrides env var and provider config)")
+	fmt.Println("  --preset <name>     System prompt preset: coding, writing, sql, reviewer, or")
+	fmt.Println("                      <name>.md in ~/.config/aimodels/prompts (see 'aimodels prompts')")
+	fmt.Println("  --max-tokens <n>    Max tokens for response (0 = model default); more than the")
+	fmt.Println("                      model's output limit in the catalog is an error")
+	fmt.Println("  --clamp-max-tokens  Lower --max-tokens to the model's output limit instead")
+	fmt.Println("  --budget <usd>      Refuse requests once the session has cost this much (0 = no limit)")
+	fmt.Println("  --timeout <d>       Cancel a request that takes longer than this, e.g. 60s; a reply")
+	fmt.Println("                      cut off is kept, marked as truncated, and the transcript records")
+	fmt.Println("                      the timeout with what the streamed part cost (0 = no limit)")
+	fmt.Println("  --context-warn <p>  Context usage percentages that trigger a warning (default: 80,95)")
+	fmt.Println("  --log-transcript <file>  Append each request/response pair (with usage and cost) as JSONL")
+	fmt.Println("  --tee <file>        Append each reply to this file as well, after a blank line, so")
+	fmt.Println("                      generated code or text need not be copied from the terminal")
+	fmt.Println("  --tee-code          With --tee, append only the fenced code blocks of each reply")
+	fmt.Println("  --tag <tags>        Comma-separated tags logged with each transcript entry, so")
+	fmt.Println("                      'aimodels dashboard' can break spend down by project or client")
+	fmt.Println("  --overrides <file>  Overrides file whose provider quota is tracked, or none")
+	fmt.Println("                      (default: the aimodels overrides.yaml, if present). Usage is")
+	fmt.Println("                      counted from the --log-transcript file and this session; a")
+	fmt.Println("                      warning is shown before a request that would exceed it, e.g.")
+	fmt.Println("                      providers: {groq: {quota: {period: daily, requests: 14400}}}.")
+	fmt.Println("                      Its api_key_env names the variable holding a provider's key")
+	fmt.Println("                      in place of the catalog's, e.g. {huggingface: {api_key_env:")
+	fmt.Println("                      HUGGINGFACE_API_KEY}}")
+	fmt.Println("  --live-estimate     Show a live token/cost estimate while typing (default: true)")
+	fmt.Println("  --sessions          Run full screen with a sidebar of concurrent conversations")
+	fmt.Println("                      (see Sessions below)")
+	fmt.Println("  --autosave          Save the conversation after each turn; if a chat ends in a")
+	fmt.Println("                      crash or a closed terminal, the next start offers to resume")
+	fmt.Println("                      it (default: true; --autosave=false to turn off)")
+	fmt.Println("  --hook-pre <cmd>    Shell command run before each request")
+	fmt.Println("  --hook-post <cmd>   Shell command run after each response")
+	fmt.Println("  --context <globs>   Comma-separated files, directories, or globs to attach to the")
+	fmt.Println("                      first message (see /file)")
+	fmt.Println("  --redact-secrets <policy>  Scan typed and imported messages for API keys, JWTs,")
+	fmt.Println("                      private keys, and emails before sending: mask, block, or an")
+	fmt.Println("                      action plus per-kind overrides, e.g. mask,private_key=block")
+	fmt.Println("  --redact-log <file> Append each redaction event as JSONL (the secret's kind,")
+	fmt.Println("                      action, and a fingerprint; never the secret itself)")
+	fmt.Println("  --rules <file>      YAML rules applied to every request: a system prompt prefix,")
+	fmt.Println("                      a max tokens cap, parameters to strip, and model aliases")
+	fmt.Println("                      (see pkg/rules)")
+	fmt.Println("  --policy <file>     Organization policy of the providers and models that may be")
+	fmt.Println("                      used, or none (default: the aimodels policy.yaml, if")
+	fmt.Println("                      present). A model it does not allow is refused at startup")
+	fmt.Println("                      and by /model, with exit status 9 (see pkg/policy)")
+	fmt.Println("  --commands-dir <dir>  Directory of executables added as slash commands (default:")
+	fmt.Println("                      aimodels/commands in the config directory)")
+	fmt.Println("  --api-key <key>     API key (default: from the environment variable the catalog")
+	fmt.Println("                      names for the provider, such as OPENAI_API_KEY)")
 	fmt.Println("  --debug             Show debug information (endpoint, headers, etc.)")
 	fmt.Println()
+	fmt.Println("Sampling (validated against the provider type; default: provider default):")
+	fmt.Println("  --temperature <t>        Sampling temperature (0-2)")
+	fmt.Println("  --top-p <p>              Nucleus sampling probability (0-1)")
+	fmt.Println("  --frequency-penalty <f>  Frequency penalty (-2 to 2; not on anthropic/bedrock)")
+	fmt.Println("  --seed <n>               Seed for deterministic sampling (not on anthropic/bedrock)")
+	fmt.Println("  --stop <a,b>             Comma-separated stop sequences (up to 4)")
+	fmt.Println()
 	fmt.Println("Examples:")
-	fmt.Println("  go run main.go --provider openai --model gpt-4o")
-	fmt.Println("  go run main.go --provider anthropic")
-	fmt.Println("  go run main.go --provider openai --system \"You are a helpful coding assistant\"")
-	fmt.Println("  go run main.go --provider openai --api-key sk-xxx --debug")
+	fmt.Println("  go run . --provider openai --model gpt-4o")
+	fmt.Println("  go run . --provider anthropic")
+	fmt.Println("  go run . --provider anthropic --size small")
+	fmt.Println("  go run . --provider openai --system \"You are a helpful coding assistant\"")
+	fmt.Println("  go run . --provider openai --preset sql")
+	fmt.Println("  go run . --provider openai --api-key sk-xxx --debug")
+	fmt.Println("  go run . --provider huggingface --model openai/gpt-oss-120b:cheapest")
+	fmt.Println("  go run . --provider anthropic --voice --stt-cost 0.006 --tts-cost 0.015")
 	fmt.Println()
 	fmt.Println("In-chat commands:")
 	fmt.Println("  /clear   Clear conversation history")
-	fmt.Println("  /cost    Show current session cost")
+	fmt.Println("  /cost    Show current session cost, and what is left of the provider's quota")
+	fmt.Println("  /set     Show or change sampling parameters (e.g. /set temperature 0.2)")
+	fmt.Println("  /preset  List presets, or switch the system prompt (e.g. /preset reviewer)")
+	fmt.Println("  /import  Replace the history with a conversation from a ChatGPT or Claude")
+	fmt.Println("           export (zip or conversations.json) or a JSONL transcript, e.g.")
+	fmt.Println("           /import export.zip 3 or /import export.zip trip planning")
+	fmt.Println("  /file    Attach files, directories, or globs to the next message, each fenced")
+	fmt.Println("           with its path, e.g. /file main.go pkg/*.go; /file lists them with")
+	fmt.Println("           their tokens, /file clear drops them. Hidden, binary, and files over")
+	fmt.Println("           256 KB are skipped")
+	fmt.Println("  /whatif  Price the session so far on another model, as a model ID or")
+	fmt.Println("           provider/model, e.g. /whatif anthropic/claude-haiku-4-5: input,")
+	fmt.Println("           output, and the cost with prompt caching where the model offers it")
+	fmt.Println("  /model   Show the model, or switch to another of the provider's models,")
+	fmt.Println("           keeping the conversation, e.g. /model gpt-4o-mini. While typing,")
+	fmt.Println("           the matching IDs are listed below the prompt; Up/Down choose and")
+	fmt.Println("           Tab completes")
+	fmt.Println("  /retry   Ask for the last answer again in its place, then show a word diff")
+	fmt.Println("           against the previous one: deleted words in red, added ones in green.")
+	fmt.Println("           Run /model first to compare another model's answer")
+	fmt.Println("  /good    Rate the last answer good in the --log-transcript file; /bad")
+	fmt.Println("           [reason] rates it bad, e.g. /bad made up the API. aimodels")
+	fmt.Println("           dashboard shows ratings per model, and aimodels dataset build")
+	fmt.Println("           --min-rating keeps only the good answers")
+	fmt.Println("  /save-last Write the last answer to a file, replacing it, e.g. /save-last")
+	fmt.Println("           notes.md; /save-last --code main.go writes only the contents of its")
+	fmt.Println("           fenced code blocks, separated by blank lines")
+	fmt.Println("  /thinking Show the reasoning that DeepSeek, Qwen, and other reasoning models")
+	fmt.Println("           send apart from the reply, streamed before it, or collapse it to a")
+	fmt.Println("           line with its token count (the default). Reasoning tokens are billed")
+	fmt.Println("           as output, and --log-transcript records them")
 	fmt.Println("  /help    Show available commands")
 	fmt.Println("  /quit    Exit the chat")
 	fmt.Println()
+	fmt.Println("Responses are streamed. Ctrl-C cancels the in-flight request, prints the")
+	fmt.Println("partial response and session summary, and exits; a second Ctrl-C force-quits.")
+	fmt.Println()
+	fmt.Println("In a terminal, the line below the prompt estimates the message being typed:")
+	fmt.Println("its tokens, the input tokens and cost of the request it would send, and the")
+	fmt.Println("share of the context window used. Use --live-estimate=false for a plain prompt.")
+	fmt.Println()
+	fmt.Println("Sessions (with --sessions):")
+	fmt.Println("  The sidebar lists each conversation with its model and cost, and the total.")
+	fmt.Println("  Each has its own history, model, and --budget, and answers in the background")
+	fmt.Println("  while another is used: … marks one answering and • an unread reply.")
+	fmt.Println("  /new [model]      Start a session, on another of the provider's models if given (Ctrl-N)")
+	fmt.Println("  /rename <name>    Rename the session; until then it is named after its first message")
+	fmt.Println("  /archive          Move the session to the archived list; /unarchive brings it back")
+	fmt.Println("  /switch <name|n>  Show a session by name or sidebar number, archived ones included;")
+	fmt.Println("                    Tab and Shift-Tab cycle the open ones")
+	fmt.Println("  /model, /clear, /cost, /thinking, /save-last, /help, and /quit work on the")
+	fmt.Println("  selected session.")
+	fmt.Println("  Esc stops its reply, PgUp/PgDn scroll, and Ctrl-C exits with every session's")
+	fmt.Println("  totals. --voice, --smart-routing, and the other commands need the plain chat.")
+	fmt.Println()
+	fmt.Println("Hooks receive the turn as JSON on stdin ({event, session, provider, model,")
+	fmt.Println("messages, and for post hooks response, usage, cost, error}), with")
+	fmt.Println("CATWALK_HOOK_EVENT set to pre or post. Printing {\"messages\": [...]} from a")
+	fmt.Println("pre hook rewrites the conversation (e.g. redaction); exiting non-zero blocks the")
+	fmt.Println("request, with the last stderr line shown as the reason. Printing {\"response\":")
+	fmt.Println("{...}} from a post hook rewrites the reply kept in history and the transcript.")
+	fmt.Println()
+	fmt.Println("Each executable in the commands directory is a slash command named after the")
+	fmt.Println("file, so commands/jira runs on /jira ABC-123, with the words after the name as")
+	fmt.Println("arguments and the session as JSON on stdin ({command, args, session, provider,")
+	fmt.Println("model, messages, cost}). What it prints is shown; printing {\"prompt\": \"...\"}")
+	fmt.Println("sends that message to the model, with an optional \"output\" shown first.")
+	fmt.Println("Built-in commands cannot be replaced.")
+	fmt.Println()
+	fmt.Println("Voice (with --voice):")
+	fmt.Println("  --voice-provider <id>  OpenAI-compatible provider for audio (default: openai)")
+	fmt.Println("  --stt-model <id>       Speech-to-text model (default: whisper-1)")
+	fmt.Println("  --tts-model <id>       Text-to-speech model; empty to not speak replies (default: tts-1)")
+	fmt.Println("  --tts-voice <name>     Text-to-speech voice (default: alloy)")
+	fmt.Println("  --record-cmd <cmd>     Records WAV to stdout until interrupted (default: rec, then arecord)")
+	fmt.Println("  --play-cmd <cmd>       Plays WAV from stdin (default: play, aplay, then ffplay)")
+	fmt.Println("  --stt-cost <usd>       Speech-to-text price per minute (default: catalog audio pricing)")
+	fmt.Println("  --tts-cost <usd>       Text-to-speech price per minute (default: catalog audio pricing)")
+	fmt.Println("  Press Enter on an empty line to start recording and Enter again to stop; the")
+	fmt.Println("  transcript is sent like a typed message, and typed messages still work. Audio")
+	fmt.Println("  minutes and their cost are shown after each turn and included in /cost.")
+	fmt.Println()
+	fmt.Println("OpenRouter (with --provider openrouter):")
+	fmt.Println("  --openrouter-order <a,b>         Upstream providers to try first, in order")
+	fmt.Println("  --openrouter-only <a,b>          Only route to these upstream providers")
+	fmt.Println("  --openrouter-ignore <a,b>        Never route to these upstream providers")
+	fmt.Println("  --openrouter-no-fallbacks        Fail instead of falling back to other upstream providers")
+	fmt.Println("  --openrouter-sort <s>            Route by price, throughput, or latency")
+	fmt.Println("  --openrouter-data-collection <d> allow or deny providers that may store prompts")
+	fmt.Println("  --openrouter-transforms <t>      Prompt transforms (e.g. middle-out)")
+	fmt.Println("  --openrouter-fallbacks <m1,m2>   Fallback models if the requested model fails")
+	fmt.Println("  --openrouter-config <file>       JSON file with provider/transforms/models fields (flags override)")
+	fmt.Println("  The upstream provider, native token counts, and billed cost of each response are")
+	fmt.Println("  shown after it, totalled per upstream in /cost, and logged with --log-transcript.")
+	fmt.Println()
+	cli.PrintNetworkHelp()
 	fmt.Println("Environment Variables (checked if --api-key not provided):")
 	fmt.Println("  OPENAI_API_KEY      - for OpenAI provider")
 	fmt.Println("  ANTHROPIC_API_KEY   - for Anthropic provider")
@@ -495,5 +1779,5 @@ func printHelp() {
 	fmt.Println("  OPENROUTER_API_KEY  - for OpenRouter provider")
 	fmt.Println("  (or <PROVIDER>_API_KEY for others)")
 	fmt.Println()
-	fmt.Println("  CATWALK_URL - URL of the catwalk service (default: http://localhost:8080)")
+	fmt.Println("  CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)")
 }
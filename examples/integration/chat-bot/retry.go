@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/render"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// lastReply returns the last reply in history, if it ends with one that
+// answers a user message, so /retry can ask for it again.
+func lastReply(history []chat.Message) (string, bool) {
+	n := len(history)
+	if n < 2 || history[n-1].Role != chat.RoleAssistant || history[n-2].Role != chat.RoleUser {
+		return "", false
+	}
+	return history[n-1].Content, true
+}
+
+// printReplyDiff shows what changed from the previous reply to a retried
+// one, word by word: deleted words in red and struck through, added ones in
+// green, or marked [-like this-] and {+like this+} without color.
+func printReplyDiff(previous, reply string) {
+	ops, ok := render.WordDiff(render.DiffWords(previous), render.DiffWords(reply))
+	if !ok {
+		fmt.Println(infoStyle.Render("The replies are too long to compare word by word."))
+		return
+	}
+	similarity := render.DiffSimilarity(ops)
+	if similarity == 1 {
+		fmt.Println(infoStyle.Render("Same as the previous answer, word for word."))
+		return
+	}
+
+	fmt.Println()
+	fmt.Println(infoStyle.Render(fmt.Sprintf("Changes from the previous answer (%.0f%% of words the same):", 100*similarity)))
+	plain := lipgloss.ColorProfile() == termenv.Ascii
+	var out strings.Builder
+	for i, op := range ops {
+		if i > 0 && op.Word != "\n" && ops[i-1].Word != "\n" {
+			out.WriteByte(' ')
+		}
+		if plain && op.Kind != ' ' && (i == 0 || ops[i-1].Kind != op.Kind) {
+			out.WriteString(map[byte]string{'-': "[-", '+': "{+"}[op.Kind])
+		}
+		switch {
+		case op.Word == "\n":
+			out.WriteString(op.Word)
+		case op.Kind == '-' && !plain:
+			out.WriteString(diffDelStyle.Render(op.Word))
+		case op.Kind == '+' && !plain:
+			out.WriteString(diffAddStyle.Render(op.Word))
+		default:
+			out.WriteString(op.Word)
+		}
+		if plain && op.Kind != ' ' && (i == len(ops)-1 || ops[i+1].Kind != op.Kind) {
+			out.WriteString(map[byte]string{'-': "-]", '+': "+}"}[op.Kind])
+		}
+	}
+	fmt.Println(out.String())
+}
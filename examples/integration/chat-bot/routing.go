@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/policy"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/selector"
+)
+
+// smartRouter picks the model for each message with --smart-routing and
+// keeps count of what sending simple ones to the small model saved.
+type smartRouter struct {
+	small, large *catwalk.Model
+
+	// off is set once /model picks a model for the rest of the session.
+	off bool
+
+	turns, smallTurns int
+	saved             float64
+}
+
+// newSmartRouter routes between the provider's default small model and
+// large, which must both be allowed and accept --max-tokens.
+func newSmartRouter(provider *catwalk.Provider, large *catwalk.Model, orgPolicy *policy.Policy) (*smartRouter, error) {
+	if *modelSize != "" {
+		return nil, errors.New("--smart-routing picks the size for each message; drop --size")
+	}
+	m, err := selector.DefaultFor(*provider, selector.SizeSmall)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	small, err := provider.FindModel(m.ID)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if small.ID == large.ID {
+		return nil, fmt.Errorf("%s is already the small model; pick a larger one with --model to route between them", large.ID)
+	}
+	if err := orgPolicy.Check(*provider, *small); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if err := small.CheckMaxTokens(int64(*maxTokens)); err != nil {
+		return nil, fmt.Errorf("%w; use --max-tokens %d or less with --smart-routing", err, small.OutputLimit())
+	}
+	return &smartRouter{small: small, large: large}, nil
+}
+
+// route switches the session to the model for a message and shows why it
+// was picked.
+func (r *smartRouter) route(session *chatSession, text string) {
+	files := make([]string, len(session.attachments))
+	for i, a := range session.attachments {
+		files[i] = a.path
+	}
+	choice := selector.RouteTurn(selector.Turn{Text: text, Files: files})
+	model, reasons := r.large, choice.Reasons
+	if choice.Size == selector.SizeSmall {
+		model = r.small
+		// A conversation that has outgrown the small model stays large
+		needed := int64(session.chat.ContextTokens()) + int64(session.chat.ReplyTokens())
+		if window := r.small.ContextWindow; window > 0 && needed > window {
+			model, reasons = r.large, []string{"too long for " + r.small.ID}
+		}
+	}
+	if model != session.model {
+		session.model = model
+		session.chat.SetModel(*model)
+	}
+	fmt.Println(infoStyle.Render(fmt.Sprintf("%s %s (%s)", render.Symbol("⇢", "->"), model.ID, strings.Join(reasons, ", "))))
+}
+
+// record counts a reply, and for one from the small model, what the large
+// model would have charged for the same tokens.
+func (r *smartRouter) record(model *catwalk.Model, response *chat.Response) {
+	r.turns++
+	if model != r.small || response.Cached {
+		return
+	}
+	r.smallTurns++
+	saved := chat.Cost(*r.large, response.InputTokens, response.OutputTokens) - response.Cost
+	r.saved += saved
+	fmt.Println(infoStyle.Render(fmt.Sprintf("  ~$%.6f less than %s (session: ~$%.6f saved)", saved, r.large.ID, r.saved)))
+}
+
+// printRouting shows how many messages --smart-routing sent to the small
+// model and what that saved.
+func printRouting(session *chatSession) {
+	r := session.router
+	if r == nil || r.turns == 0 {
+		return
+	}
+	fmt.Printf("  Smart routing: %d of %d replies from %s, ~$%.6f saved vs %s\n",
+		r.smallTurns, r.turns, r.small.ID, r.saved, r.large.ID)
+}
+
+// lastUserText returns the last user message in history.
+func lastUserText(history []chat.Message) string {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == chat.RoleUser {
+			return history[i].Content
+		}
+	}
+	return ""
+}
@@ -0,0 +1,702 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"charm.land/catwalk/internal/cli"
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/secrets"
+	"charm.land/catwalk/pkg/transcript"
+	"charm.land/catwalk/pkg/usage"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// sidebarWidth is the width of the --sessions sidebar, its border included.
+const sidebarWidth = 30
+
+// Workspace styles, for --sessions
+var (
+	sidebarStyle  = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderRight(true).BorderForeground(cli.Colors.Border).Padding(0, 1)
+	selectedStyle = lipgloss.NewStyle().Bold(true).Foreground(cli.Colors.Accent)
+	archivedStyle = lipgloss.NewStyle().Foreground(cli.Colors.Border)
+)
+
+// workspace is the full-screen app of --sessions: a sidebar of concurrent
+// conversations, each with its own history, model, and cost, beside the
+// selected one. Each tab's requests run in the background, so another can
+// be used while one answers.
+type workspace struct {
+	tabs    []*tab
+	current int
+	input   textinput.Model
+	system  string // the --system or --preset prompt new sessions start with
+	created int    // sessions created, for naming new ones
+
+	ctx   context.Context
+	send  func(tea.Msg)
+	turns *sync.WaitGroup
+
+	width, height int
+	scroll        int // lines scrolled back from the end of the conversation
+}
+
+// tab is one conversation in the workspace. While a request is in flight
+// its session belongs to that request, so the sidebar shows the totals
+// copied when the last one finished.
+type tab struct {
+	session  *chatSession
+	name     string
+	named    bool // renamed with /rename, rather than titled by its first message
+	archived bool
+	unread   bool
+	log      []logEntry
+
+	busy   bool
+	cancel context.CancelFunc
+	reply  strings.Builder // streamed so far
+
+	cost          float64
+	tokens        int
+	contextTokens int
+}
+
+// logEntry is a line of a tab's conversation as shown.
+type logEntry struct {
+	label string
+	style lipgloss.Style
+	text  string
+}
+
+// Messages from the requests in flight
+type (
+	streamMsg struct {
+		tab  *tab
+		text string
+	}
+	turnMsg struct {
+		tab      *tab
+		history  []chat.Message // before the user message, to restore on failure
+		response *chat.Response
+		err      error
+		hookErr  error
+		blocked  bool // by the pre hook, so nothing was sent
+	}
+)
+
+// tabWriter streams a tab's reply to the workspace.
+type tabWriter struct {
+	w   *workspace
+	tab *tab
+}
+
+func (t tabWriter) Write(p []byte) (int, error) {
+	t.w.send(streamMsg{tab: t.tab, text: string(p)})
+	return len(p), nil
+}
+
+// runWorkspace runs the --sessions app, starting with session, and prints
+// the totals of every session once it ends.
+func runWorkspace(ctx context.Context, session *chatSession) {
+	input := textinput.New()
+	input.Prompt = promptStyle.Render("You: ")
+	input.CharLimit = 0
+	input.Focus()
+	w := &workspace{input: input, ctx: ctx, turns: &sync.WaitGroup{}, width: 80, height: 24}
+	if messages := session.chat.Messages(); len(messages) > 0 && messages[0].Role == chat.RoleSystem {
+		w.system = messages[0].Content
+	}
+	w.add(session)
+
+	p := tea.NewProgram(w, tea.WithAltScreen(), tea.WithContext(ctx))
+	w.send = p.Send
+	_, err := p.Run()
+
+	// Let interrupted requests log their turns before the totals
+	for _, t := range w.tabs {
+		if t.cancel != nil {
+			t.cancel()
+		}
+	}
+	w.turns.Wait()
+	if err != nil && ctx.Err() == nil {
+		fmt.Println(errorStyle.Render("Error: " + err.Error()))
+	}
+	printWorkspaceSummary(w.tabs)
+	for _, t := range w.tabs[1:] {
+		t.session.discardJournal()
+	}
+}
+
+// add opens a tab for session and selects it.
+func (w *workspace) add(session *chatSession) *tab {
+	w.created++
+	t := &tab{session: session, name: fmt.Sprintf("Session %d", w.created)}
+	t.snapshot()
+	w.tabs = append(w.tabs, t)
+	w.current, w.scroll = len(w.tabs)-1, 0
+	return t
+}
+
+// newSession returns a new conversation on model with the settings of
+// session and the workspace's system prompt, logged and autosaved under a
+// session ID of its own.
+func (w *workspace) newSession(session *chatSession, model *catwalk.Model) *chatSession {
+	s := &chatSession{
+		chat:        chat.New(session.client, *session.provider, *model),
+		client:      session.client,
+		provider:    session.provider,
+		model:       model,
+		catalog:     session.catalog,
+		contextWarn: session.contextWarn,
+		transcript:  session.transcript,
+		sessionID:   transcript.NewSessionID(),
+		quotas:      session.quotas,
+		prompts:     session.prompts,
+		preset:      session.preset,
+		rules:       session.rules,
+		policy:      session.policy,
+		fallbacks:   session.fallbacks,
+		tee:         session.tee,
+		redactLog:   session.redactLog,
+	}
+	s.setSampling(session.sampling)
+	if session.secrets != nil {
+		s.secrets = newScanner(s, session.secrets.Policy, session.secrets.Known)
+	}
+	if w.system != "" {
+		s.chat.Append(chat.RoleSystem, w.system)
+	}
+	if session.journal != "" {
+		s.journal = filepath.Join(filepath.Dir(session.journal), s.sessionID+".json")
+	}
+	return s
+}
+
+// snapshot copies the session's totals for display; see tab.
+func (t *tab) snapshot() {
+	u := t.session.chat.Usage()
+	t.cost, t.tokens = u.Cost, u.InputTokens+u.OutputTokens
+	t.contextTokens = t.session.chat.ContextTokens()
+}
+
+func (t *tab) add(label string, style lipgloss.Style, text string) {
+	t.log = append(t.log, logEntry{label: label, style: style, text: text})
+}
+
+func (t *tab) info(text string) { t.add("", infoStyle, text) }
+func (t *tab) warn(text string) { t.add("", warnStyle, render.Symbol("⚠", "!")+" "+text) }
+func (t *tab) fail(text string) { t.add("", errorStyle, text) }
+
+func (w *workspace) tab() *tab { return w.tabs[w.current] }
+
+// cycle selects the next open tab in direction dir, skipping archived ones.
+func (w *workspace) cycle(dir int) {
+	for i := 1; i < len(w.tabs); i++ {
+		next := (w.current + dir*i + len(w.tabs)) % len(w.tabs)
+		if !w.tabs[next].archived {
+			w.selectTab(next)
+			return
+		}
+	}
+}
+
+func (w *workspace) selectTab(i int) {
+	w.current, w.scroll = i, 0
+	w.tabs[i].unread = false
+}
+
+func (w *workspace) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (w *workspace) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		w.width, w.height = msg.Width, msg.Height
+		w.input.Width = max(w.width-sidebarWidth-len("You: ")-2, 10)
+		return w, nil
+
+	case streamMsg:
+		msg.tab.reply.WriteString(msg.text)
+		if msg.tab == w.tab() {
+			w.scroll = 0
+		}
+		return w, nil
+
+	case turnMsg:
+		w.finish(msg)
+		return w, nil
+
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC:
+			return w, tea.Quit
+		case tea.KeyEsc:
+			if t := w.tab(); t.busy {
+				t.cancel()
+			}
+			return w, nil
+		case tea.KeyTab:
+			w.cycle(1)
+			return w, nil
+		case tea.KeyShiftTab:
+			w.cycle(-1)
+			return w, nil
+		case tea.KeyCtrlN:
+			w.command("/new")
+			return w, nil
+		case tea.KeyPgUp:
+			w.scroll += max(w.height/2, 1)
+			return w, nil
+		case tea.KeyPgDown:
+			w.scroll = max(w.scroll-max(w.height/2, 1), 0)
+			return w, nil
+		case tea.KeyEnter:
+			line := strings.TrimSpace(w.input.Value())
+			w.input.Reset()
+			w.scroll = 0
+			switch {
+			case line == "":
+			case strings.HasPrefix(line, "/"):
+				if !w.command(line) {
+					return w, tea.Quit
+				}
+			default:
+				w.submit(line)
+			}
+			return w, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	w.input, cmd = w.input.Update(msg)
+	return w, cmd
+}
+
+// submit sends a message in the selected tab.
+func (w *workspace) submit(text string) {
+	t := w.tab()
+	s := t.session
+	if t.busy {
+		t.info("Still answering; wait for the reply, or press Esc to stop it.")
+		return
+	}
+
+	text, masked, err := filterSecrets(s, "message", text)
+	if err != nil {
+		t.fail("Not sent: " + err.Error())
+		return
+	}
+	if t.archived {
+		t.archived = false
+		t.info("Unarchived.")
+	}
+	if !t.named && !slices.ContainsFunc(s.chat.Messages(), func(m chat.Message) bool { return m.Role == chat.RoleUser }) {
+		t.name = sessionTitle(text)
+	}
+	t.add("You: ", userStyle, text)
+	if len(masked) > 0 {
+		t.warn(fmt.Sprintf("Masked %s before sending.", secrets.Summary(masked)))
+	}
+
+	history := s.chat.Messages()
+	s.chat.Append(chat.RoleUser, withAttachments(s.attachments, text))
+	needed := int64(s.chat.ContextTokens()) + int64(s.chat.ReplyTokens())
+	if window := s.model.ContextWindow; window > 0 && needed > window {
+		t.warn(fmt.Sprintf("This request needs ~%s tokens but the context window is %s; it may fail or be truncated. Use /clear to start over.",
+			formatCount(needed), formatCount(window)))
+	}
+	if s.quotas != nil {
+		if err := s.quotas.Check(s.provider.ID, needed); err != nil {
+			t.warn("Quota: " + err.Error())
+		}
+	}
+
+	ctx, cancel := context.WithCancel(w.ctx)
+	t.busy, t.cancel = true, cancel
+	t.reply.Reset()
+	w.turns.Add(1)
+	go func() {
+		defer w.turns.Done()
+		defer cancel()
+		w.send(sendTurn(ctx, t, history, tabWriter{w: w, tab: t}))
+	}()
+}
+
+// sendTurn sends t's conversation, streaming the reply to out, and records
+// the turn's usage, hooks, and transcript entry as the chat loop does.
+func sendTurn(ctx context.Context, t *tab, history []chat.Message, out io.Writer) turnMsg {
+	s := t.session
+	msg := turnMsg{tab: t, history: history}
+	if err := runPreHook(ctx, s); err != nil {
+		msg.err, msg.blocked = err, true
+		return msg
+	}
+
+	start := time.Now()
+	msg.response, msg.err = s.chat.Complete(ctx, out)
+	latency := time.Since(start)
+	if r := msg.response; r != nil && !r.Cached {
+		s.requests = append(s.requests, usage.Record{Requests: 1, InputTokens: int64(r.InputTokens), OutputTokens: int64(r.OutputTokens), Cost: r.Cost, HasCost: true})
+		if s.quotas != nil {
+			s.quotas.Record(transcript.Entry{
+				Time:     time.Now(),
+				Provider: string(s.provider.ID),
+				Usage:    transcript.Usage{InputTokens: r.InputTokens, OutputTokens: r.OutputTokens},
+			})
+		}
+	}
+	msg.hookErr = runPostHook(ctx, s, msg.response, msg.err)
+	logTurn(s, msg.response, latency, msg.err)
+	return msg
+}
+
+// timedOut reports whether a request failed by running past --timeout
+// after part of its reply arrived.
+func timedOut(response *chat.Response, err error) bool {
+	var timeout *chat.TimeoutError
+	return response != nil && response.Truncated && errors.As(err, &timeout)
+}
+
+// finish shows the outcome of a tab's request and keeps its reply.
+func (w *workspace) finish(msg turnMsg) {
+	t, s := msg.tab, msg.tab.session
+	t.busy, t.cancel = false, nil
+	streamed := t.reply.String()
+	t.reply.Reset()
+	if t != w.tab() {
+		t.unread = true
+	}
+
+	switch {
+	case msg.blocked:
+		t.fail("Not sent: " + msg.err.Error())
+		s.chat.SetMessages(msg.history)
+	case msg.err != nil && !timedOut(msg.response, msg.err):
+		if streamed != "" {
+			t.add("AI: ", aiStyle, streamed)
+		}
+		if errors.Is(msg.err, context.Canceled) {
+			t.warn("[stopped]")
+		} else {
+			t.fail("Error: " + msg.err.Error())
+		}
+		if errors.Is(msg.err, catwalk.ErrOverBudget) {
+			t.info("This session has reached --budget; use /new to start another.")
+		}
+		s.chat.SetMessages(msg.history)
+	default:
+		r := msg.response
+		s.chat.Append(chat.RoleAssistant, r.Content)
+		s.attachments = nil
+		s.saveJournal()
+		if err := s.tee.write(r.Content); err != nil {
+			t.warn(err.Error())
+		}
+		s.lastReasoning = r.Reasoning
+		switch {
+		case r.Reasoning != "" && s.showThinking:
+			t.info("Thinking: " + r.Reasoning)
+		case r.Reasoning != "":
+			t.info(thinkingNote(r))
+		}
+		t.add("AI: ", aiStyle, r.Content)
+		if r.Truncated {
+			t.warn(fmt.Sprintf("[truncated: %s; the partial reply is kept]", msg.err))
+		}
+		t.add("", cli.CostStyle, fmt.Sprintf("%s tokens: %d (in: %d, out: %d) | cost: $%.6f | session: $%.6f%s",
+			render.Symbol("→", "->"), r.InputTokens+r.OutputTokens, r.InputTokens, r.OutputTokens,
+			r.Cost, s.chat.Usage().Cost, routing(r, s.model.ID)))
+	}
+	if msg.hookErr != nil {
+		t.warn(msg.hookErr.Error())
+	}
+	t.snapshot()
+}
+
+// command runs a workspace command in the selected tab. It returns false
+// for /quit.
+func (w *workspace) command(line string) bool {
+	t := w.tab()
+	s := t.session
+	fields := strings.Fields(line)
+	name, args := strings.ToLower(fields[0]), fields[1:]
+	arg := strings.TrimSpace(line[len(fields[0]):])
+
+	// Commands that change the conversation wait for its reply
+	if t.busy && slices.Contains([]string{"/model", "/clear", "/archive"}, name) && (name != "/model" || arg != "") {
+		t.info("Still answering; wait for the reply, or press Esc to stop it.")
+		return true
+	}
+
+	switch name {
+	case "/quit", "/exit", "/q":
+		return false
+
+	case "/new":
+		model := s.model
+		if arg != "" {
+			var note string
+			var err error
+			model, note, err = findModel(s, arg)
+			if note != "" {
+				t.info(note)
+			}
+			if err != nil {
+				t.fail("Error: " + err.Error())
+				return true
+			}
+			if err := model.CheckMaxTokens(int64(*maxTokens)); err != nil {
+				t.fail("Error: " + err.Error())
+				return true
+			}
+		}
+		n := w.add(w.newSession(s, model))
+		n.info(fmt.Sprintf("New session with %s (%s).", model.Name, model.ID))
+
+	case "/rename":
+		if arg == "" {
+			t.fail("Usage: /rename <name>")
+			return true
+		}
+		t.name, t.named = arg, true
+
+	case "/archive":
+		if !slices.ContainsFunc(w.tabs, func(o *tab) bool { return o != t && !o.archived }) {
+			t.fail("This is the only open session; use /new to start another first.")
+			return true
+		}
+		t.archived = true
+		t.info("Archived; /switch " + t.name + " shows it again, and sending a message unarchives it.")
+		w.cycle(1)
+
+	case "/unarchive":
+		t.archived = false
+		t.info("Unarchived.")
+
+	case "/switch":
+		for i, o := range w.tabs {
+			if strings.EqualFold(o.name, arg) || arg == strconv.Itoa(i+1) {
+				w.selectTab(i)
+				return true
+			}
+		}
+		t.fail("No session named " + strconv.Quote(arg) + "; give its name or its number in the sidebar.")
+
+	case "/model":
+		if len(args) == 0 {
+			t.info(fmt.Sprintf("Model: %s (%s)", s.model.Name, s.model.ID))
+			return true
+		}
+		model, note, err := findModel(s, args[0])
+		if note != "" {
+			t.info(note)
+		}
+		if err == nil {
+			err = model.CheckMaxTokens(int64(*maxTokens))
+		}
+		if err != nil {
+			t.fail("Error: " + err.Error())
+			return true
+		}
+		s.model = model
+		s.chat.SetModel(*model)
+		t.info(fmt.Sprintf("Switched to %s (%s). The conversation so far is kept.", model.Name, model.ID))
+
+	case "/clear":
+		s.chat.Clear()
+		t.log = nil
+		t.info("Conversation cleared.")
+
+	case "/cost":
+		total := 0.0
+		for _, o := range w.tabs {
+			total += o.cost
+		}
+		t.info(fmt.Sprintf("This session: %d messages, %d tokens, $%.6f | all %d sessions: $%.6f",
+			len(s.chat.Messages()), t.tokens, t.cost, len(w.tabs), total))
+
+	case "/chart":
+		t.add("", lipgloss.NewStyle(), strings.Join(sessionChart(s, min(w.width-sidebarWidth-2, 100)), "\n"))
+
+	case "/save-last":
+		note, err := saveLast(s, args)
+		if err != nil {
+			t.fail("Error: " + err.Error())
+			return true
+		}
+		t.info(note)
+
+	case "/thinking":
+		s.showThinking = !s.showThinking
+		switch {
+		case !s.showThinking:
+			t.info("Reasoning is collapsed to a line in this session.")
+		case s.lastReasoning != "":
+			t.info("Last reply's reasoning: " + s.lastReasoning)
+		default:
+			t.info("Reasoning is shown before each reply in this session.")
+		}
+
+	case "/help":
+		t.info(strings.Join([]string{
+			"Sessions:",
+			"  /new [model]     Start a session, on another of the provider's models if given (Ctrl-N)",
+			"  /rename <name>   Rename this session",
+			"  /archive         Move this session to the archived list; /unarchive brings it back",
+			"  /switch <name|n> Show a session, archived ones included (Tab and Shift-Tab cycle the open ones)",
+			"  /model [id]      Show or switch this session's model",
+			"  /clear           Clear this session's history",
+			"  /cost            Show this session's cost and the total",
+			"  /chart           Chart this session's tokens and cost, turn by turn",
+			"  /thinking        Show or collapse the reasoning of models that send it",
+			"  /save-last       Write this session's last reply to a file: /save-last [--code] <file>",
+			"  /quit            Exit (Ctrl-C)",
+			"Esc stops this session's reply; PgUp and PgDn scroll. Other sessions keep answering in the background.",
+		}, "\n"))
+
+	default:
+		if slices.Contains(builtinCommands, strings.TrimPrefix(name, "/")) {
+			t.fail(name + " is not available with --sessions; run without it for the full set of commands.")
+		} else {
+			t.fail("Unknown command: " + fields[0] + "; type /help for the commands.")
+		}
+		return true
+	}
+	t.snapshot()
+	return true
+}
+
+// sessionTitle names a session after its first message.
+func sessionTitle(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	return ansi.Truncate(strings.Join(strings.Fields(line), " "), 22, "…")
+}
+
+func (w *workspace) View() string {
+	height := max(w.height, 4)
+	main := max(w.width-sidebarWidth, 20)
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		sidebarStyle.Height(height).Width(sidebarWidth-1).Render(w.sidebar()),
+		lipgloss.NewStyle().PaddingLeft(1).Render(w.conversation(main-1, height)))
+}
+
+// sidebar lists the open sessions with their model and cost, then the
+// archived ones.
+func (w *workspace) sidebar() string {
+	width := sidebarWidth - 4
+	var b strings.Builder
+	b.WriteString(promptStyle.Render("Sessions") + "\n")
+	total := 0.0
+	var archived []string
+	for i, t := range w.tabs {
+		total += t.cost
+		name := ansi.Truncate(fmt.Sprintf("%d %s", i+1, t.name), width-2, "…")
+		if t.archived {
+			if i == w.current {
+				name = selectedStyle.Render(name)
+			}
+			archived = append(archived, archivedStyle.Render("  ")+name)
+			continue
+		}
+		marker, style := "  ", promptStyle
+		if i == w.current {
+			marker, style = "▸ ", selectedStyle
+		}
+		switch {
+		case t.busy:
+			name += " " + warnStyle.Render("…")
+		case t.unread:
+			name += " " + aiStyle.Render("•")
+		}
+		b.WriteString(marker + style.Render(name) + "\n")
+		b.WriteString(infoStyle.Render(ansi.Truncate(fmt.Sprintf("  %s · $%.4f", t.session.model.ID, t.cost), width, "…")) + "\n")
+	}
+	if len(archived) > 0 {
+		b.WriteString("\n" + archivedStyle.Render("Archived") + "\n" + strings.Join(archived, "\n") + "\n")
+	}
+	b.WriteString("\n" + cli.CostStyle.Render(fmt.Sprintf("Total $%.6f", total)))
+	return b.String()
+}
+
+// conversation renders the selected session: a header with its model and
+// totals, as much of the conversation as fits, the prompt, and the keys.
+func (w *workspace) conversation(width, height int) string {
+	t := w.tab()
+	s := t.session
+	header := fmt.Sprintf("%s  %s", selectedStyle.Render(t.name), infoStyle.Render(fmt.Sprintf("%s | %s tokens | $%.6f",
+		s.model.ID, formatCount(int64(t.tokens)), t.cost)))
+	if window := s.model.ContextWindow; window > 0 {
+		header += infoStyle.Render(fmt.Sprintf(" | context %.0f%%", float64(t.contextTokens)/float64(window)*100))
+	}
+
+	wrap := lipgloss.NewStyle().Width(width)
+	var lines []string
+	entries := t.log
+	if t.busy {
+		entries = append(slices.Clip(entries), logEntry{label: "AI: ", style: aiStyle, text: t.reply.String() + "▌"})
+	}
+	for _, e := range entries {
+		text := e.text
+		if e.label != "" {
+			text = e.style.Render(e.label) + text
+		} else {
+			text = e.style.Render(text)
+		}
+		lines = append(lines, strings.Split(wrap.Render(text), "\n")...)
+		lines = append(lines, "")
+	}
+
+	// The newest lines, unless scrolled back
+	rows := max(height-4, 1)
+	w.scroll = min(w.scroll, max(len(lines)-rows, 0))
+	end := len(lines) - w.scroll
+	visible := lines[max(end-rows, 0):end]
+	for len(visible) < rows {
+		visible = append([]string{""}, visible...)
+	}
+
+	keys := "Enter send · Tab switch · Ctrl-N new · Esc stop · PgUp/PgDn scroll · /help · Ctrl-C quit"
+	if w.scroll > 0 {
+		keys = fmt.Sprintf("%d lines back · ", w.scroll) + keys
+	}
+	return strings.Join([]string{
+		ansi.Truncate(header, width, "…"),
+		strings.Join(visible, "\n"),
+		w.input.View(),
+		infoStyle.Render(ansi.Truncate(keys, width, "…")),
+	}, "\n")
+}
+
+// printWorkspaceSummary prints each session's totals and the overall cost
+// once the workspace closes.
+func printWorkspaceSummary(tabs []*tab) {
+	fmt.Println(infoStyle.Render("Sessions Summary:"))
+	total := 0.0
+	for _, t := range tabs {
+		t.snapshot()
+		total += t.cost
+		note := ""
+		if t.archived {
+			note = " (archived)"
+		}
+		fmt.Printf("  %-24s %-28s %8d tokens  $%.6f%s\n", t.name, t.session.model.ID, t.tokens, t.cost, note)
+	}
+	fmt.Printf("  Total cost: $%.6f\n", total)
+	fmt.Println()
+	fmt.Println("Goodbye!")
+}
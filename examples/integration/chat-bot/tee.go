@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// handleSaveLast writes the last reply, or with --code only its fenced code
+// blocks, to a file.
+func handleSaveLast(session *chatSession, args []string) {
+	note, err := saveLast(session, args)
+	if err != nil {
+		fmt.Println(errorStyle.Render("Error: " + err.Error()))
+	} else {
+		fmt.Println(infoStyle.Render(note))
+	}
+	fmt.Println()
+}
+
+// saveLast writes the last reply for /save-last [--code] <file>, replacing
+// the file, and returns a note of what it wrote.
+func saveLast(session *chatSession, args []string) (string, error) {
+	var paths []string
+	code := false
+	for _, arg := range args {
+		if arg == "--code" {
+			code = true
+		} else {
+			paths = append(paths, arg)
+		}
+	}
+	if len(paths) != 1 {
+		return "", errors.New("usage: /save-last [--code] <file>")
+	}
+	path := paths[0]
+	reply, ok := lastReply(session.chat.Messages())
+	if !ok {
+		return "", errors.New("there is no reply to save yet")
+	}
+
+	text, what := reply, "the last reply"
+	if code {
+		blocks := codeBlocks(reply)
+		if len(blocks) == 0 {
+			return "", errors.New("the last reply has no code blocks; use /save-last <file> to save all of it")
+		}
+		text = strings.Join(blocks, "\n")
+		what = fmt.Sprintf("%d code block%s of the last reply", len(blocks), plural(len(blocks)))
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil { //nolint:gosec
+		return "", err //nolint:wrapcheck
+	}
+	lines := strings.Count(text, "\n")
+	return fmt.Sprintf("Saved %s to %s (%d line%s).", what, path, lines, plural(lines)), nil
+}
+
+// codeBlocks returns the contents of the fenced code blocks in a Markdown
+// reply, each ending with a newline. A block left open, as in a reply cut
+// off by --timeout, runs to the end of the reply.
+func codeBlocks(text string) []string {
+	var blocks []string
+	var block strings.Builder
+	fence := ""
+	for _, line := range strings.SplitAfter(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence == "":
+			fence = openingFence(trimmed)
+			block.Reset()
+		case strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "":
+			blocks = append(blocks, block.String())
+			fence = ""
+		default:
+			block.WriteString(line)
+		}
+	}
+	if fence != "" && block.Len() > 0 {
+		if !strings.HasSuffix(block.String(), "\n") {
+			block.WriteString("\n")
+		}
+		blocks = append(blocks, block.String())
+	}
+	return blocks
+}
+
+// openingFence returns the fence a line opens a code block with, three or
+// more backticks or tildes followed by an optional language, or "".
+func openingFence(line string) string {
+	if !strings.HasPrefix(line, "```") && !strings.HasPrefix(line, "~~~") {
+		return ""
+	}
+	n := len(line) - len(strings.TrimLeft(line, line[:1]))
+	if line[0] == '`' && strings.Contains(line[n:], "`") {
+		// Inline code, such as ```x```
+		return ""
+	}
+	return line[:n]
+}
+
+// replyTee appends each reply, or with --tee-code only its code blocks, to
+// the --tee file. Sessions of the --sessions app share it.
+type replyTee struct {
+	file *os.File
+	code bool
+	// Whether the file has content, so the next reply is set off from it.
+	started bool
+}
+
+// openTee opens the --tee file for appending, creating it if need be.
+func openTee(path string, code bool) (*replyTee, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("opening --tee file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close() //nolint:errcheck
+		return nil, fmt.Errorf("opening --tee file: %w", err)
+	}
+	return &replyTee{file: f, code: code, started: info.Size() > 0}, nil
+}
+
+// write appends a reply after a blank line. With --tee-code, a reply
+// without code blocks adds nothing. It does nothing without --tee.
+func (t *replyTee) write(reply string) error {
+	if t == nil {
+		return nil
+	}
+	text := reply
+	if t.code {
+		text = strings.Join(codeBlocks(reply), "\n")
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	if t.started {
+		text = "\n" + text
+	}
+	if _, err := t.file.WriteString(text); err != nil {
+		return fmt.Errorf("--tee: %w", err)
+	}
+	t.started = true
+	return nil
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"charm.land/catwalk/internal/cli"
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/registry"
+	"charm.land/catwalk/pkg/render"
+	"github.com/sashabaranov/go-openai"
+)
+
+// voiceMode records and transcribes the user's speech, and speaks replies,
+// through a provider's OpenAI-compatible audio endpoints.
+type voiceMode struct {
+	client   *openai.Client
+	provider *catwalk.Provider
+
+	// Commands that record WAV to stdout and play WAV from stdin. play is
+	// empty when replies are not spoken.
+	record []string
+	play   []string
+
+	// Prices in USD per minute of audio; 0 when unknown.
+	sttPrice float64
+	ttsPrice float64
+
+	// Session totals.
+	minutesIn  float64
+	minutesOut float64
+	cost       float64
+	unpriced   bool
+}
+
+// Recorders and players tried in order when --record-cmd or --play-cmd is
+// not set.
+var (
+	defaultRecorders = [][]string{
+		{"rec", "-q", "-t", "wav", "-c", "1", "-r", "16000", "-"},
+		{"arecord", "-q", "-f", "S16_LE", "-c", "1", "-r", "16000", "-t", "wav"},
+	}
+	defaultPlayers = [][]string{
+		{"play", "-q", "-t", "wav", "-"},
+		{"aplay", "-q"},
+		{"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet", "-"},
+	}
+)
+
+// newVoiceMode sets up --voice. The chat provider's key is reused when it
+// also handles audio.
+func newVoiceMode(catalog *catwalk.Catalog, chatProvider *catwalk.Provider, chatKey string, o *registry.Overrides, rt http.RoundTripper) (*voiceMode, error) {
+	provider, err := catalog.Provider(*voiceProv)
+	if err != nil {
+		return nil, fmt.Errorf("--voice-provider: %w", err)
+	}
+	key := chatKey
+	if provider.ID != chatProvider.ID {
+		if key, err = providerKey(*provider, o); err != nil {
+			return nil, err
+		}
+	}
+
+	v := &voiceMode{
+		client:   chat.NewClient(*provider, key, rt),
+		provider: provider,
+		sttPrice: audioPrice(provider, *sttModel, *sttCost, true),
+		ttsPrice: audioPrice(provider, *ttsModel, *ttsCost, false),
+	}
+	if v.record, err = audioCommand(*recordCmd, defaultRecorders); err != nil {
+		return nil, fmt.Errorf("no recorder found (install sox or alsa-utils, or set --record-cmd)")
+	}
+	if *ttsModel != "" {
+		if v.play, err = audioCommand(*playCmd, defaultPlayers); err != nil {
+			return nil, fmt.Errorf("no audio player found (install sox, alsa-utils, or ffmpeg, or set --play-cmd)")
+		}
+	}
+	return v, nil
+}
+
+// audioCommand splits a command given on the command line, or returns the
+// first default that is installed.
+func audioCommand(command string, defaults [][]string) ([]string, error) {
+	if fields := strings.Fields(command); len(fields) > 0 {
+		return fields, nil
+	}
+	for _, c := range defaults {
+		if _, err := exec.LookPath(c[0]); err == nil {
+			return c, nil
+		}
+	}
+	return nil, exec.ErrNotFound
+}
+
+// audioPrice returns the per-minute price of a model's audio input or
+// output: the override if set, else the catalog's audio pricing, else 0.
+func audioPrice(provider *catwalk.Provider, modelID string, override float64, input bool) float64 {
+	if override > 0 {
+		return override
+	}
+	m, err := provider.FindModel(modelID)
+	if err != nil || m.AudioPricing == nil {
+		return 0
+	}
+	if input {
+		return m.AudioPricing.CostPerMinuteIn
+	}
+	return m.AudioPricing.CostPerMinuteOut
+}
+
+func (v *voiceMode) printHeader() {
+	describe := func(model string, price float64) string {
+		if price == 0 {
+			return model + " (price unknown)"
+		}
+		return fmt.Sprintf("%s ($%.4f/min)", model, price)
+	}
+	fmt.Printf("%s %s via %s", infoStyle.Render("Voice:"), describe(*sttModel, v.sttPrice), v.provider.Name)
+	if len(v.play) > 0 {
+		fmt.Printf(", replies spoken by %s in voice %s", describe(*ttsModel, v.ttsPrice), *ttsVoice)
+	}
+	fmt.Println()
+	fmt.Println(infoStyle.Render("Press Enter on an empty line to talk, and Enter again to send."))
+	fmt.Println()
+}
+
+// listen records until the user presses Enter and returns the transcript.
+func (v *voiceMode) listen(ctx context.Context, lines <-chan inputLine) (string, error) {
+	var audio, stderr bytes.Buffer
+	cmd := exec.Command(v.record[0], v.record[1:]...) //nolint:gosec
+	cmd.Stdout = &audio
+	cmd.Stderr = &stderr
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("failed to start recorder: %w", err)
+	}
+	fmt.Print(warnStyle.Render(render.Symbol("●", "*") + " Recording, press Enter to stop..."))
+
+	interrupted := false
+	select {
+	case <-lines:
+	case <-ctx.Done():
+		interrupted = true
+	}
+	stopRecorder(cmd.Process)
+	waitErr := cmd.Wait()
+	elapsed := time.Since(start)
+	if interrupted {
+		return "", errInterrupted
+	}
+
+	// Recorders usually exit non-zero when interrupted, so only fail if
+	// nothing was recorded
+	if audio.Len() <= 44 {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("recorder failed: %s", msg)
+		}
+		return "", fmt.Errorf("recorder failed: %v", waitErr)
+	}
+
+	duration, ok := wavDuration(audio.Bytes())
+	if !ok {
+		duration = elapsed
+	}
+	resp, err := v.client.CreateTranscription(ctx, openai.AudioRequest{
+		Model:    *sttModel,
+		FilePath: "speech.wav",
+		Reader:   &audio,
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", errInterrupted
+		}
+		return "", fmt.Errorf("transcription failed: %w", err)
+	}
+	v.minutesIn += duration.Minutes()
+	fmt.Printf("%s audio in: %s | %s\n", cli.CostStyle.Render(render.Symbol("→", "->")),
+		formatDuration(duration), v.charge(duration, v.sttPrice, "--stt-cost"))
+	return strings.TrimSpace(resp.Text), nil
+}
+
+// stopRecorder asks the recorder to finish, so it flushes what it has.
+// Windows has no interrupt signal, so it is killed there.
+func stopRecorder(p *os.Process) {
+	if runtime.GOOS == "windows" || p.Signal(os.Interrupt) != nil {
+		p.Kill() //nolint:errcheck
+	}
+}
+
+// ttsInputLimit is the longest text OpenAI's speech endpoint accepts.
+const ttsInputLimit = 4096
+
+// speak reads text aloud with the text-to-speech model, if one is set.
+func (v *voiceMode) speak(ctx context.Context, text string) error {
+	if len(v.play) == 0 || strings.TrimSpace(text) == "" {
+		return nil
+	}
+	if runes := []rune(text); len(runes) > ttsInputLimit {
+		text = string(runes[:ttsInputLimit])
+		fmt.Println(infoStyle.Render(fmt.Sprintf("Only the first %d characters will be spoken.", ttsInputLimit)))
+	}
+
+	resp, err := v.client.CreateSpeech(ctx, openai.CreateSpeechRequest{
+		Model:          openai.SpeechModel(*ttsModel),
+		Input:          text,
+		Voice:          openai.SpeechVoice(*ttsVoice),
+		ResponseFormat: openai.SpeechResponseFormatWav,
+	})
+	if err != nil {
+		return fmt.Errorf("speech failed: %w", err)
+	}
+	defer resp.Close() //nolint:errcheck
+	audio, err := io.ReadAll(resp)
+	if err != nil {
+		return fmt.Errorf("speech failed: %w", err)
+	}
+
+	if duration, ok := wavDuration(audio); ok {
+		v.minutesOut += duration.Minutes()
+		fmt.Printf("%s audio out: %s | %s\n", cli.CostStyle.Render(render.Symbol("→", "->")),
+			formatDuration(duration), v.charge(duration, v.ttsPrice, "--tts-cost"))
+	}
+
+	cmd := exec.CommandContext(ctx, v.play[0], v.play[1:]...) //nolint:gosec
+	cmd.Stdin = bytes.NewReader(audio)
+	if out, err := cmd.CombinedOutput(); err != nil && ctx.Err() == nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("player failed: %s", msg)
+		}
+		return fmt.Errorf("player failed: %w", err)
+	}
+	return nil
+}
+
+// charge adds the cost of some audio to the session and describes it for
+// the cost line.
+func (v *voiceMode) charge(d time.Duration, pricePerMinute float64, flagName string) string {
+	if pricePerMinute == 0 {
+		v.unpriced = true
+		return "cost: unknown (no per-minute price in the catalog; set " + flagName + ")"
+	}
+	cost := d.Minutes() * pricePerMinute
+	v.cost += cost
+	return fmt.Sprintf("cost: $%.6f | session audio: $%.6f", cost, v.cost)
+}
+
+// wavDuration returns the length of a WAV file from its byte rate and the
+// size of its data. Recorders writing to a pipe can't fill in the chunk
+// sizes, so the data is assumed to run to the end of the file.
+func wavDuration(data []byte) (time.Duration, bool) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return 0, false
+	}
+	var byteRate uint32
+	for pos := 12; pos+8 <= len(data); {
+		id, size := string(data[pos:pos+4]), binary.LittleEndian.Uint32(data[pos+4:pos+8])
+		body := pos + 8
+		switch id {
+		case "fmt ":
+			if body+12 <= len(data) {
+				byteRate = binary.LittleEndian.Uint32(data[body+8 : body+12])
+			}
+		case "data":
+			if byteRate == 0 {
+				return 0, false
+			}
+			seconds := float64(len(data)-body) / float64(byteRate)
+			return time.Duration(seconds * float64(time.Second)), true
+		}
+		pos = body + int(size) + int(size%2)
+	}
+	return 0, false
+}
+
+// formatDuration formats d as m:ss.
+func formatDuration(d time.Duration) string {
+	s := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// printVoiceUsage prints the audio totals and the session cost including
+// them, with --voice.
+func printVoiceUsage(session *chatSession) {
+	v := session.voice
+	if v == nil {
+		return
+	}
+	fmt.Printf("  Audio: %.1f min in, %.1f min out, $%.6f\n", v.minutesIn, v.minutesOut, v.cost)
+	note := ""
+	if v.unpriced {
+		note = " (excluding unpriced audio)"
+	}
+	fmt.Printf("  Total with audio: $%.6f%s\n", session.chat.Usage().Cost+v.cost, note)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"charm.land/catwalk/internal/cli"
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/usage"
+)
+
+// handleWhatIf prices the requests sent so far on another model, at catalog
+// prices, next to the current model, so users can decide whether to switch.
+// Token counts are the current model's; other tokenizers differ somewhat.
+func handleWhatIf(session *chatSession, args []string) {
+	if len(args) != 1 {
+		fmt.Println(errorStyle.Render("Usage: /whatif <model|provider/model>"))
+		fmt.Println()
+		return
+	}
+	if len(session.requests) == 0 {
+		fmt.Println(infoStyle.Render("Nothing has been sent yet."))
+		fmt.Println()
+		return
+	}
+	provider, model, err := findWhatIfModel(session, args[0])
+	if err != nil {
+		fmt.Println(errorStyle.Render("Error: " + err.Error()))
+		fmt.Println()
+		return
+	}
+
+	var total usage.Record
+	var largest int64
+	for _, r := range session.requests {
+		total.InputTokens += r.InputTokens
+		total.OutputTokens += r.OutputTokens
+		largest = max(largest, r.InputTokens+r.OutputTokens)
+	}
+	current := string(session.provider.ID) + "/" + session.model.ID
+	target := string(provider.ID) + "/" + model.ID
+
+	fmt.Println()
+	fmt.Println(infoStyle.Render(fmt.Sprintf("What if this session had used %s?", target)))
+	fmt.Printf("  %d request%s: %s input and %s output tokens\n\n", len(session.requests), plural(len(session.requests)),
+		formatCount(total.InputTokens), formatCount(total.OutputTokens))
+
+	width := max(len(current)+6, len(target)+8)
+	fmt.Printf("  %-*s %11s %11s %11s %11s\n", width, "", "Input", "Caching", "Output", "Total")
+	now := printWhatIfRows(session.requests, *session.model, current+" (now)", width)
+	then := printWhatIfRows(session.requests, *model, target, width)
+	fmt.Println()
+
+	switch {
+	case now == 0 && then == 0:
+		fmt.Println(infoStyle.Render("Both models are free at catalog prices."))
+	case then < now:
+		fmt.Println(cli.CostStyle.Render(fmt.Sprintf("%s %.0f%% cheaper: $%.6f less", render.Symbol("→", "->"), 100*(now-then)/now, now-then)))
+	case then > now:
+		more := fmt.Sprintf("$%.6f more", then-now)
+		if now > 0 {
+			more = fmt.Sprintf("%.0f%% more expensive: %s", 100*(then-now)/now, more)
+		}
+		fmt.Println(cli.CostStyle.Render(render.Symbol("→", "->") + " " + more))
+	default:
+		fmt.Println(cli.CostStyle.Render(render.Symbol("→", "->") + " The same cost."))
+	}
+	if billed := session.priorCost + session.chat.Usage().Cost; math.Abs(billed-now) > 1e-6 {
+		fmt.Println(infoStyle.Render(fmt.Sprintf("The session has actually cost $%.6f; the table uses catalog prices throughout.", billed)))
+	}
+	if window := model.ContextWindow; window > 0 && largest > window {
+		fmt.Println(warnStyle.Render(fmt.Sprintf("%s The largest request used %s tokens, more than %s's %s-token context window.",
+			render.Symbol("⚠", "!"), formatCount(largest), model.ID, formatCount(window))))
+	}
+	fmt.Println(infoStyle.Render("Token counts are " + session.model.ID + "'s; other models tokenize text somewhat differently."))
+	fmt.Println()
+}
+
+// sessionChart charts the session's cumulative tokens and cost turn by
+// turn, in lines at most width cells wide, and compares the last prompt
+// with the first: a context that keeps growing shows as a curve bending
+// upward. Turns without a known cost, from before a resume, are priced at
+// the current model's catalog prices.
+func sessionChart(session *chatSession, width int) []string {
+	n := len(session.requests)
+	if n == 0 {
+		return []string{infoStyle.Render("Nothing has been sent yet.")}
+	}
+	tokens := make([]float64, n)
+	costs := make([]float64, n)
+	var total, cost float64
+	for i, r := range session.requests {
+		total += float64(r.InputTokens + r.OutputTokens)
+		if r.HasCost {
+			cost += r.Cost
+		} else {
+			cost += usage.Cost(*session.model, r)
+		}
+		tokens[i], costs[i] = total, cost
+	}
+
+	width = max(width-2, 20)
+	lines := []string{infoStyle.Render(fmt.Sprintf("Tokens so far, over %d turn%s:", n, plural(n)))}
+	for _, line := range render.LineChart(tokens, width, 6, func(v float64) string { return formatCount(int64(v)) }) {
+		lines = append(lines, "  "+cli.ContextStyle.Render(line))
+	}
+	lines = append(lines, infoStyle.Render("Cost so far:"))
+	for _, line := range render.LineChart(costs, width, 6, func(v float64) string { return fmt.Sprintf("$%.4f", v) }) {
+		lines = append(lines, "  "+cli.CostStyle.Render(line))
+	}
+
+	first, last := session.requests[0].InputTokens, session.requests[n-1].InputTokens
+	summary := fmt.Sprintf("Last prompt: %s tokens", formatCount(last))
+	if n > 1 && first > 0 {
+		summary += fmt.Sprintf(", %.1fx the first", float64(last)/float64(first))
+	}
+	if window := session.model.ContextWindow; window > 0 {
+		summary += fmt.Sprintf(", %.0f%% of the context window", float64(last)/float64(window)*100)
+	}
+	return append(lines, infoStyle.Render(summary+"."))
+}
+
+// printWhatIfRows prints the cost of requests on model m, and again with
+// prompt caching if the model has cache prices. It returns the cost without
+// caching.
+func printWhatIfRows(requests []usage.Record, m catwalk.Model, label string, width int) float64 {
+	row := func(label string, requests []usage.Record) float64 {
+		var input, caching, output float64
+		for _, r := range requests {
+			input += float64(r.InputTokens) * m.CostPer1MIn / 1_000_000
+			caching += float64(r.CacheWriteTokens)*m.CostPer1MInCached/1_000_000 + float64(r.CacheReadTokens)*m.CostPer1MOutCached/1_000_000
+			output += float64(r.OutputTokens) * m.CostPer1MOut / 1_000_000
+		}
+		usd := func(v float64) string { return fmt.Sprintf("$%.6f", v) }
+		fmt.Printf("  %-*s %11s %11s %11s %11s\n", width, label, usd(input), usd(caching), usd(output), usd(input+caching+output))
+		return input + caching + output
+	}
+	cost := row(label, requests)
+	if m.CostPer1MInCached > 0 || m.CostPer1MOutCached > 0 {
+		row(label+", cached", usage.CachePrefix(m, requests))
+	}
+	return cost
+}
+
+// findWhatIfModel resolves a /whatif argument: a model ID of the current
+// provider, provider/model, or a model ID anywhere in the catalog. Model IDs
+// may contain slashes themselves (openai/gpt-oss-120b:groq on Hugging Face).
+func findWhatIfModel(session *chatSession, name string) (*catwalk.Provider, *catwalk.Model, error) {
+	if m, err := session.provider.FindModel(name); err == nil {
+		return session.provider, m, nil
+	}
+	var providerErr error
+	if providerID, modelID, ok := strings.Cut(name, "/"); ok {
+		if p, err := session.catalog.Provider(providerID); err == nil {
+			m, err := p.FindModel(modelID)
+			if err == nil {
+				return p, m, nil
+			}
+			providerErr = err
+		}
+	}
+
+	providers, err := session.catalog.Providers()
+	if err != nil {
+		return nil, nil, err //nolint:wrapcheck
+	}
+	var ids []string
+	for i := range providers {
+		p := &providers[i]
+		if m, err := p.FindModel(name); err == nil {
+			return p, m, nil
+		}
+		for _, m := range p.Models {
+			ids = append(ids, m.ID)
+		}
+	}
+	if providerErr != nil {
+		return nil, nil, providerErr //nolint:wrapcheck
+	}
+	return nil, nil, &catwalk.ModelNotFoundError{Model: name, Suggestions: catwalk.Suggest(name, ids, 3)}
+}
@@ -5,74 +5,165 @@
 // - Calculating costs for input/output token estimates
 // - Comparing costs across multiple models
 // - Accounting for prompt caching discounts
-// - Batch processing multiple scenarios
-// - Exporting cost comparisons as CSV/JSON
+// - Batch processing multiple scenarios concurrently, with per-label summary statistics
+// - Exporting cost comparisons as CSV/JSON, or as a standalone HTML report
+// - Sensitivity analysis across a range of token counts or cache ratios
+// - Simulating multi-turn conversations whose context grows every turn
+// - Budgeting image (per-image tiers) and audio (per-minute or per-token) usage
+// - Framing a comparison against a baseline model, with monthly savings at a call volume
 //
 // Usage:
-//   go run main.go --model "gpt-4o" --input 1000 --output 500           # Calculate cost
-//   go run main.go --compare "gpt-4o,claude-3-opus" --input 1000 --output 500  # Compare models
-//   go run main.go --batch scenarios.json --format csv                       # Batch calculation
-//   go run main.go --model "gpt-4o" --input 1000 --cached 0.5          # With caching
-//   go run main.go --help                                                     # Show help message
+//
+//	go run main.go --model "gpt-4o" --input 1000 --output 500           # Calculate cost
+//	go run main.go --compare "gpt-4o,claude-3-opus" --input 1000 --output 500  # Compare models
+//	go run main.go --compare "gpt-4o-mini,claude-3-haiku" --baseline gpt-4o --input 1000 --output 500 --calls-per-month 500000
+//	go run main.go --batch scenarios.json --format csv                       # Batch calculation
+//	go run main.go --compare "gpt-4o,claude-3-opus" --input 1000 --output 500 --format html > report.html
+//	go run main.go --model "gpt-4o" --input 1000 --cached 0.5          # With caching
+//	go run main.go --compare "gpt-4o,claude-3-opus" --output 500 --sweep input=500:5000:500
+//	go run main.go --compare "gpt-4o,claude-3-opus" --input 200 --output 400 --system 2000 --simulate 20 --cache-prefix
+//	go run main.go --model "gpt-4o" --input 1000 --output 500 --catalog-version 2025-06-01
+//	go run main.go --model "gpt-4o" --input 1000 --output 500 --images 20 --image-cost 0.002
+//	go run main.go --model "gpt-4o" --audio-in 10m --audio-out 2m --audio-cost in=0.006/min,out=0.024/min
+//	go run main.go --help                                                     # Show help message
 //
 // Environment Variables:
-//   CATWALK_URL - URL of the catwalk service (default: http://localhost:8080)
+//
+//	CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/csv"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
+	"math"
 	"os"
-	"sort"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"charm.land/catwalk/internal/cli"
 	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/report"
+	"charm.land/catwalk/pkg/selector"
+	"charm.land/catwalk/pkg/snapshot"
+	"charm.land/catwalk/pkg/transport"
+	"charm.land/catwalk/pkg/usage"
 	"github.com/charmbracelet/lipgloss"
 )
 
 var (
 	// Command-line flags
-	modelName  = flag.String("model", "", "Model name or ID")
-	compareList = flag.String("compare", "", "Comma-separated list of models to compare")
-	inputTokens = flag.Int64("input", 0, "Number of input tokens")
-	outputTokens = flag.Int64("output", 0, "Number of output tokens")
-	cachedRatio = flag.Float64("cached", 0, "Ratio of cached tokens (0-1)")
-	batchFile  = flag.String("batch", "", "JSON file with batch scenarios")
-	outputFormat = flag.String("format", "table", "Output format: table, json, or csv")
-	showHelp   = flag.Bool("help", false, "Show help message")
+	modelName      = flag.String("model", "", "Model name or ID")
+	compareList    = flag.String("compare", "", "Comma-separated list of models to compare")
+	baselineModel  = flag.String("baseline", "", "Model to compare the others against (with --compare)")
+	monthlyCalls   = flag.Int64("calls-per-month", 100_000, "Calls per month the --baseline savings are for")
+	inputTokens    = flag.Int64("input", 0, "Number of input tokens")
+	outputTokens   = flag.Int64("output", 0, "Number of output tokens")
+	cachedRatio    = flag.Float64("cached", 0, "Ratio of cached tokens (0-1)")
+	batchFile      = flag.String("batch", "", "JSON file with batch scenarios")
+	images         = flag.Int64("images", 0, "Number of images")
+	imageTier      = flag.String("image-tier", "", "Image pricing tier name (default: the model's first tier)")
+	imageCost      = flag.Float64("image-cost", 0, "Price per image in USD (overrides catalog pricing)")
+	audioIn        = flag.String("audio-in", "", "Audio input as a duration (e.g. 10m) or a token count")
+	audioOut       = flag.String("audio-out", "", "Audio output as a duration (e.g. 2m) or a token count")
+	audioCost      = flag.String("audio-cost", "", "Audio prices overriding the catalog, e.g. in=0.006/min,out=40/1M")
+	parallel       = flag.Int("parallel", runtime.NumCPU(), "Number of batch scenarios to calculate concurrently")
+	sweepSpec      = flag.String("sweep", "", "Vary input, output, or cached over start:end:step (e.g. input=500:5000:500)")
+	simulateTurns  = flag.Int("simulate", 0, "Simulate a conversation of N turns, with --input and --output per turn")
+	systemTokens   = flag.Int64("system", 0, "System prompt tokens resent every turn (with --simulate)")
+	cachePrefix    = flag.Bool("cache-prefix", false, "Cache the conversation prefix between turns (with --simulate)")
+	outputFormat   = flag.String("format", "table", "Output format: table, json, csv, or html")
+	catalogVersion = flag.String("catalog-version", "", "Use a stored catalog snapshot (ETag, YYYY-MM-DD, or latest) instead of live data")
+	network        = transport.RegisterFlags(flag.CommandLine)
+	showHelp       = flag.Bool("help", false, "Show help message")
 )
 
 // Styles for formatting
 var (
-	headerStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("86"))
-	modelStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
-	costStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("228"))
-	providerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("120"))
-	borderStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
-	dividerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	modelStyle    = lipgloss.NewStyle().Bold(true).Foreground(cli.Colors.Accent)
+	providerStyle = lipgloss.NewStyle().Foreground(cli.Colors.OK)
 )
 
 type costResult struct {
-	Model    string  `json:"model"`
-	Provider string  `json:"provider"`
-	InputCost float64 `json:"input_cost"`
+	Label      string  `json:"label,omitempty"`
+	Model      string  `json:"model"`
+	Provider   string  `json:"provider"`
+	Repeat     int     `json:"repeat,omitempty"`
+	InputCost  float64 `json:"input_cost"`
 	OutputCost float64 `json:"output_cost"`
-	TotalCost float64 `json:"total_cost"`
+	ImageCost  float64 `json:"image_cost,omitempty"`
+	AudioCost  float64 `json:"audio_cost,omitempty"`
+	TotalCost  float64 `json:"total_cost"`
+
+	// With --baseline: the total as a percentage of the baseline's, and
+	// what using this model instead of the baseline saves per month
+	PctOfBaseline  *float64 `json:"pct_of_baseline,omitempty"`
+	MonthlySavings *float64 `json:"monthly_savings,omitempty"`
+
+	catalog catwalk.Model // for the HTML capability matrix
+}
+
+// media describes the image and audio part of a workload. Audio amounts are
+// durations (e.g. "90s", "10m") or plain audio token counts.
+type media struct {
+	Images    int64  `json:"images,omitempty"`
+	ImageTier string `json:"image_tier,omitempty"`
+	AudioIn   string `json:"audio_in,omitempty"`
+	AudioOut  string `json:"audio_out,omitempty"`
+}
+
+// empty reports whether the workload has no image or audio usage
+func (m media) empty() bool {
+	return m.Images == 0 && m.AudioIn == "" && m.AudioOut == ""
+}
+
+// audioOverride holds the parsed --audio-cost prices
+var audioOverride *catwalk.AudioPricing
+
+// flagMedia returns the media usage given on the command line
+func flagMedia() media {
+	return media{Images: *images, ImageTier: *imageTier, AudioIn: *audioIn, AudioOut: *audioOut}
 }
 
 type scenario struct {
-	Model       string  `json:"model"`
-	InputTokens int64   `json:"input_tokens"`
-	OutputTokens int64  `json:"output_tokens"`
-	CachedRatio float64 `json:"cached_ratio"`
+	Label        string  `json:"label"`
+	Model        string  `json:"model"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CachedRatio  float64 `json:"cached_ratio"`
+	Repeat       int     `json:"repeat"` // times the scenario runs (default 1)
+	media
+}
+
+// batchSummary holds statistics over the runs of one label/model pair.
+// Costs are per run; Total covers all repeats.
+type batchSummary struct {
+	Label string  `json:"label"`
+	Model string  `json:"model"`
+	Runs  int     `json:"runs"`
+	Total float64 `json:"total_cost"`
+	Mean  float64 `json:"mean_cost"`
+	P95   float64 `json:"p95_cost"`
+}
+
+// batchReport is the full output of a batch run.
+type batchReport struct {
+	Results    []costResult   `json:"results"`
+	Summary    []batchSummary `json:"summary"`
+	GrandTotal float64        `json:"grand_total"`
 }
 
 func main() {
+	render.SetupConsole()
 	flag.Parse()
 
 	if *showHelp {
@@ -80,12 +171,40 @@ func main() {
 		return
 	}
 
+	// Validate multimodal flags before fetching anything
+	if *audioCost != "" {
+		pricing, err := parseAudioCost(*audioCost)
+		if err != nil {
+			log.Fatalf("Error: invalid --audio-cost: %v", err)
+		}
+		audioOverride = &pricing
+	}
+	for _, amount := range []string{*audioIn, *audioOut} {
+		if amount == "" {
+			continue
+		}
+		if _, _, err := parseAudioAmount(amount); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	}
+
+	if *baselineModel != "" && (*compareList == "" || *batchFile != "" || *sweepSpec != "" || *simulateTurns != 0) {
+		log.Fatal("Error: --baseline works with --compare only.")
+	}
+	if *monthlyCalls < 0 {
+		log.Fatal("Error: --calls-per-month must not be negative.")
+	}
+
 	// Create catwalk client
-	client := catwalk.New()
+	httpClient, err := network.Client()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	client := catwalk.NewWithHTTPClient(httpClient)
 	ctx := context.Background()
 
-	// Fetch providers
-	providers, err := client.GetProviders(ctx, "")
+	// Fetch providers (live, or from a pinned snapshot)
+	providers, err := snapshot.Fetch(ctx, client, *catalogVersion)
 	if err != nil {
 		if err == catwalk.ErrNotModified {
 			log.Println("Data not modified (cached)")
@@ -100,6 +219,26 @@ func main() {
 		return
 	}
 
+	// Handle simulation mode
+	if *simulateTurns != 0 {
+		names := strings.Split(*compareList, ",")
+		if *compareList == "" {
+			names = []string{*modelName}
+		}
+		runSimulation(providers, names)
+		return
+	}
+
+	// Handle sweep mode
+	if *sweepSpec != "" {
+		names := strings.Split(*compareList, ",")
+		if *compareList == "" {
+			names = []string{*modelName}
+		}
+		runSweep(providers, names, *sweepSpec)
+		return
+	}
+
 	// Handle compare mode
 	if *compareList != "" {
 		compareModels(providers, strings.Split(*compareList, ","))
@@ -111,11 +250,11 @@ func main() {
 		log.Fatal("Error: --model is required. Use --help for usage information.")
 	}
 
-	if *inputTokens == 0 || *outputTokens == 0 {
+	if (*inputTokens == 0 || *outputTokens == 0) && flagMedia().empty() {
 		log.Fatal("Error: --input and --output are required.")
 	}
 
-	result := calculateCost(providers, *modelName, *inputTokens, *outputTokens, *cachedRatio)
+	result := calculateCost(providers, *modelName, *inputTokens, *outputTokens, *cachedRatio, flagMedia())
 	if result == nil {
 		log.Fatalf("Model not found: %s", *modelName)
 	}
@@ -123,45 +262,183 @@ func main() {
 	displayCostResult([]costResult{*result})
 }
 
-// calculateCost calculates cost for a single model
-func calculateCost(providers []catwalk.Provider, modelName string, inputTokens, outputTokens int64, cachedRatio float64) *costResult {
-	var model *catwalk.Model
-	var provider *catwalk.Provider
-
-	// Find model
+// findModel finds a model by ID, or by a substring of its name
+func findModel(providers []catwalk.Provider, modelName string) (*catwalk.Model, *catwalk.Provider) {
 	for i := range providers {
 		for j := range providers[i].Models {
 			if strings.EqualFold(providers[i].Models[j].ID, modelName) ||
 				strings.Contains(strings.ToLower(providers[i].Models[j].Name), strings.ToLower(modelName)) {
-				model = &providers[i].Models[j]
-				provider = &providers[i]
-				break
+				return &providers[i].Models[j], &providers[i]
 			}
 		}
-		if model != nil {
-			break
-		}
 	}
+	return nil, nil
+}
 
+// calculateCost calculates cost for a single model
+func calculateCost(providers []catwalk.Provider, modelName string, inputTokens, outputTokens int64, cachedRatio float64, usage media) *costResult {
+	model, provider := findModel(providers, modelName)
 	if model == nil {
 		return nil
 	}
 
-	// Calculate costs
-	cachedInputTokens := float64(inputTokens) * cachedRatio
-	uncachedInputTokens := float64(inputTokens) * (1 - cachedRatio)
-
-	inputCost := (uncachedInputTokens * model.CostPer1MIn / 1_000_000) +
-		(cachedInputTokens * model.CostPer1MInCached / 1_000_000)
+	// Calculate costs the way find-models --workload ranks them
+	w := selector.Workload{InputTokens: inputTokens, OutputTokens: outputTokens, Cached: cachedRatio}
+	inputCost := w.InputCost(*model)
+	outputCost := w.OutputCost(*model)
 
-	outputCost := float64(outputTokens) * model.CostPer1MOut / 1_000_000
+	imgCost, err := calculateImageCost(model, usage)
+	if err != nil {
+		warnOnce("%s: %v; image cost not included", model.Name, err)
+	}
+	audCost, err := calculateAudioCost(model, usage)
+	if err != nil {
+		warnOnce("%s: %v; audio cost not included", model.Name, err)
+	}
 
 	return &costResult{
-		Model:     model.Name,
-		Provider:  provider.Name,
+		Model:      model.Name,
+		Provider:   provider.Name,
 		InputCost:  inputCost,
 		OutputCost: outputCost,
-		TotalCost: inputCost + outputCost,
+		ImageCost:  imgCost,
+		AudioCost:  audCost,
+		TotalCost:  inputCost + outputCost + imgCost + audCost,
+		catalog:    *model,
+	}
+}
+
+// calculateImageCost prices images with --image-cost, or the model's tier
+// named by the workload (the first tier by default)
+func calculateImageCost(model *catwalk.Model, usage media) (float64, error) {
+	if usage.Images == 0 {
+		return 0, nil
+	}
+	if *imageCost > 0 {
+		return float64(usage.Images) * *imageCost, nil
+	}
+	if len(model.ImagePricing) == 0 {
+		return 0, fmt.Errorf("no per-image pricing in the catalog (set --image-cost)")
+	}
+
+	tier := model.ImagePricing[0]
+	if usage.ImageTier != "" {
+		found := false
+		for _, t := range model.ImagePricing {
+			if strings.EqualFold(t.Name, usage.ImageTier) {
+				tier, found = t, true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown image tier %q", usage.ImageTier)
+		}
+	}
+	return float64(usage.Images) * tier.Cost, nil
+}
+
+// calculateAudioCost prices audio input and output, per minute for
+// durations and per 1M tokens for token counts
+func calculateAudioCost(model *catwalk.Model, usage media) (float64, error) {
+	if usage.AudioIn == "" && usage.AudioOut == "" {
+		return 0, nil
+	}
+
+	pricing := catwalk.AudioPricing{}
+	if audioOverride != nil {
+		pricing = *audioOverride
+	} else if model.AudioPricing != nil {
+		pricing = *model.AudioPricing
+	}
+
+	var total float64
+	for _, part := range []struct {
+		amount           string
+		perMinute, per1M float64
+	}{
+		{usage.AudioIn, pricing.CostPerMinuteIn, pricing.CostPer1MIn},
+		{usage.AudioOut, pricing.CostPerMinuteOut, pricing.CostPer1MOut},
+	} {
+		if part.amount == "" {
+			continue
+		}
+		minutes, tokens, err := parseAudioAmount(part.amount)
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case minutes > 0 && part.perMinute > 0:
+			total += minutes * part.perMinute
+		case tokens > 0 && part.per1M > 0:
+			total += float64(tokens) * part.per1M / 1_000_000
+		case minutes > 0:
+			return 0, fmt.Errorf("no per-minute audio pricing (set --audio-cost or give a token count)")
+		default:
+			return 0, fmt.Errorf("no per-token audio pricing (set --audio-cost or give a duration)")
+		}
+	}
+	return total, nil
+}
+
+// parseAudioAmount parses a duration ("90s", "10m") into minutes or a plain
+// number into audio tokens
+func parseAudioAmount(s string) (minutes float64, tokens int64, err error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return d.Minutes(), 0, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, 0, fmt.Errorf("invalid audio amount %q (use a duration like 10m or a token count)", s)
+	}
+	return 0, n, nil
+}
+
+// parseAudioCost parses prices like "in=0.006/min,out=40/1M"
+func parseAudioCost(spec string) (catwalk.AudioPricing, error) {
+	var pricing catwalk.AudioPricing
+	for _, part := range strings.Split(spec, ",") {
+		dir, price, ok := strings.Cut(strings.TrimSpace(part), "=")
+		value, unit, ok2 := strings.Cut(price, "/")
+		if !ok || !ok2 {
+			return pricing, fmt.Errorf("%q is not <in|out>=<price>/<min|1M>", part)
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return pricing, fmt.Errorf("invalid price %q", value)
+		}
+
+		var dst *float64
+		switch strings.ToLower(dir) + "/" + strings.ToLower(unit) {
+		case "in/min":
+			dst = &pricing.CostPerMinuteIn
+		case "out/min":
+			dst = &pricing.CostPerMinuteOut
+		case "in/1m":
+			dst = &pricing.CostPer1MIn
+		case "out/1m":
+			dst = &pricing.CostPer1MOut
+		default:
+			return pricing, fmt.Errorf("%q is not <in|out>=<price>/<min|1M>", part)
+		}
+		*dst = v
+	}
+	return pricing, nil
+}
+
+var (
+	warnMu   sync.Mutex
+	warnSeen = map[string]bool{}
+)
+
+// warnOnce logs a warning the first time it occurs, so sweeps and batches
+// do not repeat it for every row
+func warnOnce(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	warnMu.Lock()
+	defer warnMu.Unlock()
+	if !warnSeen[msg] {
+		warnSeen[msg] = true
+		log.Printf("Warning: %s", msg)
 	}
 }
 
@@ -171,7 +448,7 @@ func compareModels(providers []catwalk.Provider, modelNames []string) {
 
 	for _, name := range modelNames {
 		name = strings.TrimSpace(name)
-		result := calculateCost(providers, name, *inputTokens, *outputTokens, *cachedRatio)
+		result := calculateCost(providers, name, *inputTokens, *outputTokens, *cachedRatio, flagMedia())
 		if result != nil {
 			results = append(results, *result)
 		}
@@ -182,14 +459,60 @@ func compareModels(providers []catwalk.Provider, modelNames []string) {
 		return
 	}
 
+	if *baselineModel != "" {
+		base := calculateCost(providers, strings.TrimSpace(*baselineModel), *inputTokens, *outputTokens, *cachedRatio, flagMedia())
+		if base == nil {
+			log.Fatalf("Baseline model not found: %s", *baselineModel)
+		}
+		// The baseline is listed too, so its row shows what the others are measured against
+		if !slices.ContainsFunc(results, func(r costResult) bool { return r.Model == base.Model && r.Provider == base.Provider }) {
+			results = append(results, *base)
+		}
+		compareToBaseline(results, *base, *monthlyCalls)
+	}
+
 	// Sort by total cost
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].TotalCost < results[j].TotalCost
-	})
+	slices.SortFunc(results, func(a, b costResult) int { return cmp.Compare(a.TotalCost, b.TotalCost) })
 
 	displayCostResult(results)
 }
 
+// compareToBaseline sets each result's share of the baseline's cost and its
+// monthly savings over the baseline at calls per month. A free baseline has
+// no meaningful share, so only the savings are set.
+func compareToBaseline(results []costResult, base costResult, calls int64) {
+	for i := range results {
+		r := &results[i]
+		savings := (base.TotalCost - r.TotalCost) * float64(calls)
+		r.MonthlySavings = &savings
+		if base.TotalCost > 0 {
+			pct := r.TotalCost / base.TotalCost * 100
+			r.PctOfBaseline = &pct
+		}
+	}
+}
+
+// baselineNote describes what the --baseline columns are measured against
+func baselineNote() string {
+	return fmt.Sprintf("Baseline: %s; savings are per month at %d calls", *baselineModel, *monthlyCalls)
+}
+
+// formatPct formats a share of the baseline, or "-" if there is none
+func formatPct(pct *float64) string {
+	if pct == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", *pct)
+}
+
+// formatSavings formats monthly savings, with extra cost shown as negative
+func formatSavings(v float64) string {
+	if v < 0 {
+		return fmt.Sprintf("-$%.2f", -v)
+	}
+	return fmt.Sprintf("$%.2f", v)
+}
+
 // processBatch processes multiple scenarios from a file
 func processBatch(providers []catwalk.Provider, batchFile string) {
 	// Read batch file
@@ -203,12 +526,38 @@ func processBatch(providers []catwalk.Provider, batchFile string) {
 		log.Fatalf("Error parsing batch file: %v", err)
 	}
 
+	// Calculate scenarios concurrently, keeping results in file order
+	calculated := make([]*costResult, len(scenarios))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(*parallel, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				s := scenarios[i]
+				result := calculateCost(providers, s.Model, s.InputTokens, s.OutputTokens, s.CachedRatio, s.media)
+				if result != nil {
+					result.Label = s.Label
+					result.Repeat = max(s.Repeat, 1)
+				}
+				calculated[i] = result
+			}
+		}()
+	}
+	for i := range scenarios {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
 	var results []costResult
-	for _, s := range scenarios {
-		result := calculateCost(providers, s.Model, s.InputTokens, s.OutputTokens, s.CachedRatio)
-		if result != nil {
-			results = append(results, *result)
+	for i, result := range calculated {
+		if result == nil {
+			log.Printf("Skipping scenario %d: model not found: %s", i+1, scenarios[i].Model)
+			continue
 		}
+		results = append(results, *result)
 	}
 
 	if len(results) == 0 {
@@ -216,7 +565,577 @@ func processBatch(providers []catwalk.Provider, batchFile string) {
 		return
 	}
 
-	displayCostResult(results)
+	report := batchReport{Results: results, Summary: summarizeBatch(results)}
+	for _, sum := range report.Summary {
+		report.GrandTotal += sum.Total
+	}
+
+	switch strings.ToLower(*outputFormat) {
+	case "json":
+		outputJSON(report)
+	case "csv":
+		outputBatchCSV(report)
+	case "html":
+		outputBatchHTML(report)
+	case "table":
+		outputTable(results)
+		outputSummaryTable(report)
+	default:
+		log.Fatalf("Unknown format: %s (use 'table', 'json', 'csv', or 'html')", *outputFormat)
+	}
+}
+
+// summarizeBatch computes total, mean, and p95 cost per label/model pair,
+// in order of first appearance
+func summarizeBatch(results []costResult) []batchSummary {
+	type key struct{ label, model string }
+	groups := map[key][]costResult{}
+	var order []key
+	for _, r := range results {
+		k := key{r.Label, r.Model}
+		if _, ok := groups[k]; !ok {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], r)
+	}
+
+	var summary []batchSummary
+	for _, k := range order {
+		runs := groups[k]
+		slices.SortFunc(runs, func(a, b costResult) int { return cmp.Compare(a.TotalCost, b.TotalCost) })
+
+		sum := batchSummary{Label: k.label, Model: k.model}
+		for _, r := range runs {
+			sum.Runs += r.Repeat
+			sum.Total += r.TotalCost * float64(r.Repeat)
+		}
+		sum.Mean = sum.Total / float64(sum.Runs)
+
+		// Nearest-rank p95, weighting each scenario by its repeat count
+		rank := int(math.Ceil(0.95 * float64(sum.Runs)))
+		seen := 0
+		for _, r := range runs {
+			seen += r.Repeat
+			if seen >= rank {
+				sum.P95 = r.TotalCost
+				break
+			}
+		}
+		summary = append(summary, sum)
+	}
+	return summary
+}
+
+// outputSummaryTable displays batch statistics and the grand total
+func outputSummaryTable(report batchReport) {
+	fmt.Println()
+	fmt.Println(cli.HeaderStyle.Render("Batch Summary"))
+	tbl := render.NewTable(
+		render.Column{Title: "Label"},
+		render.Column{Title: "Model", MinWidth: 16, Style: modelStyle},
+		render.Column{Title: "Runs", Align: render.AlignRight},
+		render.Column{Title: "Total", Align: render.AlignRight, Style: cli.CostStyle},
+		render.Column{Title: "Mean", Align: render.AlignRight},
+		render.Column{Title: "P95", Align: render.AlignRight},
+	)
+	tbl.HeaderStyle = cli.HeaderStyle
+	tbl.BorderStyle = cli.MutedStyle
+	for _, sum := range report.Summary {
+		label := sum.Label
+		if label == "" {
+			label = "-"
+		}
+		tbl.AddRow(label, sum.Model, strconv.Itoa(sum.Runs),
+			fmt.Sprintf("$%.4f", sum.Total),
+			fmt.Sprintf("$%.4f", sum.Mean),
+			fmt.Sprintf("$%.4f", sum.P95))
+	}
+	tbl.AddSeparator()
+	tbl.AddRow("Grand Total", "", "", fmt.Sprintf("$%.4f", report.GrandTotal))
+	tbl.Print()
+}
+
+// outputBatchCSV writes the per-scenario results, then the summary rows and
+// a grand-total row
+func outputBatchCSV(report batchReport) {
+	writer := csv.NewWriter(os.Stdout)
+	defer writer.Flush()
+
+	rows := [][]string{{"Label", "Model", "Provider", "Repeat", "InputCost", "OutputCost", "ImageCost", "AudioCost", "TotalCost"}}
+	for _, r := range report.Results {
+		rows = append(rows, []string{
+			r.Label,
+			r.Model,
+			r.Provider,
+			strconv.Itoa(r.Repeat),
+			strconv.FormatFloat(r.InputCost, 'f', 4, 64),
+			strconv.FormatFloat(r.OutputCost, 'f', 4, 64),
+			strconv.FormatFloat(r.ImageCost, 'f', 4, 64),
+			strconv.FormatFloat(r.AudioCost, 'f', 4, 64),
+			strconv.FormatFloat(r.TotalCost, 'f', 4, 64),
+		})
+	}
+
+	rows = append(rows, nil, []string{"Label", "Model", "Runs", "TotalCost", "MeanCost", "P95Cost"})
+	for _, sum := range report.Summary {
+		rows = append(rows, []string{
+			sum.Label,
+			sum.Model,
+			strconv.Itoa(sum.Runs),
+			strconv.FormatFloat(sum.Total, 'f', 4, 64),
+			strconv.FormatFloat(sum.Mean, 'f', 4, 64),
+			strconv.FormatFloat(sum.P95, 'f', 4, 64),
+		})
+	}
+	rows = append(rows, []string{"Grand Total", "", "", strconv.FormatFloat(report.GrandTotal, 'f', 4, 64)})
+
+	for _, row := range rows {
+		if row == nil {
+			writer.Flush()
+			fmt.Println()
+			continue
+		}
+		if err := writer.Write(row); err != nil {
+			log.Fatalf("Error writing CSV row: %v", err)
+		}
+	}
+}
+
+// sweep describes a parameter varied over a range
+type sweep struct {
+	param            string
+	start, end, step float64
+}
+
+// sweepRow holds the cost of every model at one sweep value
+type sweepRow struct {
+	Value float64            `json:"value"`
+	Costs map[string]float64 `json:"costs"`
+}
+
+// parseSweep parses a spec like "input=500:5000:500"
+func parseSweep(spec string) (sweep, error) {
+	param, rng, ok := strings.Cut(spec, "=")
+	if !ok {
+		return sweep{}, fmt.Errorf("expected <param>=<start>:<end>:<step>")
+	}
+	param = strings.ToLower(strings.TrimSpace(param))
+	if param != "input" && param != "output" && param != "cached" {
+		return sweep{}, fmt.Errorf("unknown parameter %q (use input, output, or cached)", param)
+	}
+
+	parts := strings.Split(rng, ":")
+	if len(parts) != 3 {
+		return sweep{}, fmt.Errorf("expected <start>:<end>:<step>")
+	}
+	var nums [3]float64
+	for i, part := range parts {
+		n, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return sweep{}, fmt.Errorf("invalid number %q", part)
+		}
+		nums[i] = n
+	}
+
+	sw := sweep{param: param, start: nums[0], end: nums[1], step: nums[2]}
+	if sw.step <= 0 || sw.end < sw.start {
+		return sweep{}, fmt.Errorf("step must be positive and end must not be less than start")
+	}
+	if param == "cached" && (sw.start < 0 || sw.end > 1) {
+		return sweep{}, fmt.Errorf("cached ratio must be between 0 and 1")
+	}
+	return sw, nil
+}
+
+// runSweep computes costs for each model across the sweep range
+func runSweep(providers []catwalk.Provider, modelNames []string, spec string) {
+	sw, err := parseSweep(spec)
+	if err != nil {
+		log.Fatalf("Error: invalid --sweep: %v", err)
+	}
+
+	var models []string
+	var entries []costResult
+	var rows []sweepRow
+	for i := 0; ; i++ {
+		// Multiply rather than accumulate to avoid float drift
+		value := sw.start + float64(i)*sw.step
+		if value > sw.end+sw.step/1e6 {
+			break
+		}
+
+		in, out, cached := *inputTokens, *outputTokens, *cachedRatio
+		switch sw.param {
+		case "input":
+			in = int64(value)
+		case "output":
+			out = int64(value)
+		case "cached":
+			cached = value
+		}
+
+		row := sweepRow{Value: value, Costs: map[string]float64{}}
+		for _, name := range modelNames {
+			result := calculateCost(providers, strings.TrimSpace(name), in, out, cached, flagMedia())
+			if result == nil {
+				continue
+			}
+			if i == 0 {
+				models = append(models, result.Model)
+				entries = append(entries, *result)
+			}
+			row.Costs[result.Model] = result.TotalCost
+		}
+		rows = append(rows, row)
+	}
+
+	if len(models) == 0 {
+		fmt.Println("No models found.")
+		return
+	}
+
+	switch strings.ToLower(*outputFormat) {
+	case "json":
+		outputJSON(rows)
+	case "csv":
+		outputSweepCSV(sw, models, rows)
+	case "html":
+		outputSweepHTML(sw, entries, rows)
+	case "table":
+		outputSweepTable(sw, models, rows)
+	default:
+		log.Fatalf("Unknown format: %s (use 'table', 'json', 'csv', or 'html')", *outputFormat)
+	}
+}
+
+// cheapestModel returns the cheapest model in a sweep row
+func cheapestModel(models []string, row sweepRow) string {
+	best := models[0]
+	for _, m := range models[1:] {
+		if row.Costs[m] < row.Costs[best] {
+			best = m
+		}
+	}
+	return best
+}
+
+// formatSweepValue formats a sweep value for display
+func formatSweepValue(sw sweep, v float64) string {
+	if sw.param == "cached" {
+		return fmt.Sprintf("%.0f%%", v*100)
+	}
+	return strconv.FormatInt(int64(v), 10)
+}
+
+// outputSweepTable displays the sweep as a matrix with crossover points
+func outputSweepTable(sw sweep, models []string, rows []sweepRow) {
+	const colWidth = 14
+
+	fmt.Println()
+	fmt.Println(cli.HeaderStyle.Render(fmt.Sprintf("Cost Sensitivity: %s", sw.param)))
+	fmt.Println(cli.BorderStyle.Render(render.DoubleRule(80)))
+	fmt.Println()
+
+	fmt.Printf("%-10s", sw.param)
+	for _, m := range models {
+		name := m
+		if len(name) > colWidth-1 {
+			name = name[:colWidth-4] + "..."
+		}
+		fmt.Printf(" %s", modelStyle.Render(fmt.Sprintf("%*s", colWidth-1, name)))
+	}
+	fmt.Println()
+	fmt.Println(cli.MutedStyle.Render(render.Rule(10 + len(models)*colWidth)))
+
+	for _, row := range rows {
+		cheapest := cheapestModel(models, row)
+		fmt.Printf("%-10s", formatSweepValue(sw, row.Value))
+		for _, m := range models {
+			cell := fmt.Sprintf("%*s", colWidth-1, fmt.Sprintf("$%.6f", row.Costs[m]))
+			if m == cheapest && len(models) > 1 {
+				cell = cli.CostStyle.Render(cell)
+			}
+			fmt.Printf(" %s", cell)
+		}
+		fmt.Println()
+	}
+
+	// Report where the cheapest model changes
+	if len(models) < 2 {
+		return
+	}
+	fmt.Println()
+	fmt.Println(cli.HeaderStyle.Render("Crossover Points"))
+	crossovers := 0
+	prev := cheapestModel(models, rows[0])
+	for _, row := range rows[1:] {
+		if cheapest := cheapestModel(models, row); cheapest != prev {
+			fmt.Printf("  At %s=%s, %s becomes cheaper than %s\n",
+				sw.param, formatSweepValue(sw, row.Value),
+				modelStyle.Render(cheapest), modelStyle.Render(prev))
+			prev = cheapest
+			crossovers++
+		}
+	}
+	if crossovers == 0 {
+		fmt.Printf("  None: %s is cheapest across the whole range\n", modelStyle.Render(prev))
+	}
+}
+
+// outputSweepCSV displays the sweep matrix in CSV format
+func outputSweepCSV(sw sweep, models []string, rows []sweepRow) {
+	writer := csv.NewWriter(os.Stdout)
+	defer writer.Flush()
+
+	if err := writer.Write(append([]string{sw.param}, models...)); err != nil {
+		log.Fatalf("Error writing CSV header: %v", err)
+	}
+	for _, row := range rows {
+		record := []string{strconv.FormatFloat(row.Value, 'f', -1, 64)}
+		for _, m := range models {
+			record = append(record, strconv.FormatFloat(row.Costs[m], 'f', 6, 64))
+		}
+		if err := writer.Write(record); err != nil {
+			log.Fatalf("Error writing CSV row: %v", err)
+		}
+	}
+}
+
+// simResult is the outcome of simulating a conversation on one model
+type simResult struct {
+	Model        string    `json:"model"`
+	Provider     string    `json:"provider"`
+	NaiveCost    float64   `json:"naive_cost"`
+	TotalCost    float64   `json:"total_cost"`
+	Multiplier   float64   `json:"multiplier"`
+	FinalContext int64     `json:"final_context"`
+	OverflowTurn int       `json:"overflow_turn,omitempty"` // first turn over the context window
+	Turns        []simTurn `json:"turns"`
+
+	catalog catwalk.Model // for the HTML capability matrix
+}
+
+type simTurn struct {
+	Turn       int     `json:"turn"`
+	Context    int64   `json:"context_tokens"`
+	Input      int64   `json:"input_tokens"`
+	CacheRead  int64   `json:"cache_read_tokens"`
+	CacheWrite int64   `json:"cache_write_tokens"`
+	Output     int64   `json:"output_tokens"`
+	Cost       float64 `json:"cost"`
+	Cumulative float64 `json:"cumulative_cost"`
+}
+
+// conversation returns the conversation given on the command line
+func conversation() usage.Conversation {
+	return usage.Conversation{
+		Turns:        *simulateTurns,
+		SystemTokens: *systemTokens,
+		UserTokens:   *inputTokens,
+		ReplyTokens:  *outputTokens,
+		CachePrefix:  *cachePrefix,
+	}
+}
+
+// runSimulation prices an N-turn conversation on each model, resending the
+// growing history every turn, and compares it with single-call math
+func runSimulation(providers []catwalk.Provider, modelNames []string) {
+	conv := conversation()
+	if conv.Turns < 1 {
+		log.Fatal("Error: --simulate must be at least 1")
+	}
+	if conv.UserTokens <= 0 && conv.SystemTokens <= 0 {
+		log.Fatal("Error: --input (tokens per turn) or --system is required with --simulate")
+	}
+	if *cachedRatio > 0 || !flagMedia().empty() {
+		warnOnce("--simulate ignores --cached and image/audio options; use --cache-prefix for caching")
+	}
+
+	var results []simResult
+	for _, name := range modelNames {
+		model, provider := findModel(providers, strings.TrimSpace(name))
+		if model == nil {
+			warnOnce("Model not found: %s", strings.TrimSpace(name))
+			continue
+		}
+
+		r := simResult{
+			Model:     model.Name,
+			Provider:  provider.Name,
+			NaiveCost: conv.NaiveCost(*model),
+			catalog:   *model,
+		}
+		for i, t := range usage.Simulate(*model, conv) {
+			r.Turns = append(r.Turns, simTurn{
+				Turn:       i + 1,
+				Context:    t.Context,
+				Input:      t.InputTokens,
+				CacheRead:  t.CacheReadTokens,
+				CacheWrite: t.CacheWriteTokens,
+				Output:     t.OutputTokens,
+				Cost:       t.Cost,
+				Cumulative: t.Cumulative,
+			})
+			if r.OverflowTurn == 0 && model.ContextWindow > 0 && t.Context+t.OutputTokens > model.ContextWindow {
+				r.OverflowTurn = i + 1
+			}
+		}
+		last := r.Turns[len(r.Turns)-1]
+		r.TotalCost = last.Cumulative
+		r.FinalContext = last.Context
+		if r.NaiveCost > 0 {
+			r.Multiplier = r.TotalCost / r.NaiveCost
+		}
+		results = append(results, r)
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No models found.")
+		return
+	}
+
+	switch strings.ToLower(*outputFormat) {
+	case "json":
+		outputJSON(results)
+	case "csv":
+		outputSimulationCSV(results)
+	case "html":
+		outputSimulationHTML(results)
+	case "table":
+		outputSimulationTable(results)
+	default:
+		log.Fatalf("Unknown format: %s (use 'table', 'json', 'csv', or 'html')", *outputFormat)
+	}
+}
+
+// conversationNote describes the simulated conversation
+func conversationNote() string {
+	conv := conversation()
+	note := fmt.Sprintf("Conversation: %d turns of %d input and %d output tokens, %d-token system prompt",
+		conv.Turns, conv.UserTokens, conv.ReplyTokens, conv.SystemTokens)
+	if conv.CachePrefix {
+		note += ", prefix cached"
+	}
+	return note
+}
+
+// outputSimulationTable displays the simulated and naive cost per model, and
+// the turn-by-turn breakdown for a single model
+func outputSimulationTable(results []simResult) {
+	fmt.Println()
+	fmt.Println(cli.HeaderStyle.Render("Conversation Cost Simulation"))
+	fmt.Println(cli.BorderStyle.Render(render.DoubleRule(80)))
+	fmt.Println(cli.MutedStyle.Render(conversationNote()))
+	fmt.Println()
+
+	fmt.Printf("%-25s %-12s %12s %12s %8s %12s\n",
+		"Model", "Provider", "Naive", "Simulated", "Factor", "Final ctx")
+	fmt.Println(cli.MutedStyle.Render(render.Rule(86)))
+	for _, r := range results {
+		name := r.Model
+		if len(name) > 25 {
+			name = name[:22] + "..."
+		}
+		fmt.Printf("%s %s %12s %s %7.1fx %12d\n",
+			modelStyle.Render(fmt.Sprintf("%-25s", name)),
+			providerStyle.Render(fmt.Sprintf("%-12s", r.Provider)),
+			fmt.Sprintf("$%.4f", r.NaiveCost),
+			cli.CostStyle.Render(fmt.Sprintf("%12s", fmt.Sprintf("$%.4f", r.TotalCost))),
+			r.Multiplier, r.FinalContext)
+	}
+
+	for _, r := range results {
+		if r.OverflowTurn > 0 {
+			fmt.Printf("\n%s %s exceeds its %d-token context window at turn %d\n",
+				render.Symbol("⚠", "!"), modelStyle.Render(r.Model), r.catalog.ContextWindow, r.OverflowTurn)
+		}
+	}
+
+	if len(results) == 1 {
+		r := results[0]
+		fmt.Println()
+		fmt.Println(cli.HeaderStyle.Render("Turns"))
+		fmt.Printf("%5s %10s %10s %11s %11s %12s %12s\n",
+			"Turn", "Context", "Input", "Cache read", "Cache write", "Cost", "Cumulative")
+		fmt.Println(cli.MutedStyle.Render(render.Rule(79)))
+		for _, t := range r.Turns {
+			fmt.Printf("%5d %10d %10d %11d %11d %12s %s\n",
+				t.Turn, t.Context, t.Input, t.CacheRead, t.CacheWrite,
+				fmt.Sprintf("$%.6f", t.Cost),
+				cli.CostStyle.Render(fmt.Sprintf("%12s", fmt.Sprintf("$%.6f", t.Cumulative))))
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(cli.MutedStyle.Render("Naive: the turn count times one call without history. Simulated: every turn resends the history."))
+}
+
+// outputSimulationCSV displays the turn-by-turn costs in CSV format
+func outputSimulationCSV(results []simResult) {
+	writer := csv.NewWriter(os.Stdout)
+	defer writer.Flush()
+
+	header := []string{"model", "provider", "turn", "context_tokens", "input_tokens",
+		"cache_read_tokens", "cache_write_tokens", "output_tokens", "cost", "cumulative_cost"}
+	if err := writer.Write(header); err != nil {
+		log.Fatalf("Error writing CSV header: %v", err)
+	}
+	for _, r := range results {
+		for _, t := range r.Turns {
+			record := []string{
+				r.Model,
+				r.Provider,
+				strconv.Itoa(t.Turn),
+				strconv.FormatInt(t.Context, 10),
+				strconv.FormatInt(t.Input, 10),
+				strconv.FormatInt(t.CacheRead, 10),
+				strconv.FormatInt(t.CacheWrite, 10),
+				strconv.FormatInt(t.Output, 10),
+				strconv.FormatFloat(t.Cost, 'f', 6, 64),
+				strconv.FormatFloat(t.Cumulative, 'f', 6, 64),
+			}
+			if err := writer.Write(record); err != nil {
+				log.Fatalf("Error writing CSV row: %v", err)
+			}
+		}
+	}
+}
+
+// outputSimulationHTML displays the simulation as a standalone HTML report
+func outputSimulationHTML(results []simResult) {
+	summary := report.Table{
+		Title:   "Cost per Conversation",
+		Columns: []string{"Model", "Provider", "Naive", "Simulated", "Multiplier", "Final context"},
+	}
+	chart := report.Chart{Title: "Simulated Cost per Conversation", Format: "$%.4f"}
+	tables := []report.Table{}
+	var entries []costResult
+	for _, r := range results {
+		summary.AddRow(report.Text(r.Model), report.Text(r.Provider),
+			report.USD(r.NaiveCost), report.USD(r.TotalCost),
+			report.Number("%.1fx", r.Multiplier), report.Int(r.FinalContext))
+		chart.Bars = append(chart.Bars, report.Bar{Label: r.Model, Value: r.TotalCost})
+		entries = append(entries, costResult{Model: r.Model, catalog: r.catalog})
+
+		turns := report.Table{
+			Title:   "Turns: " + r.Model,
+			Columns: []string{"Turn", "Context", "Input", "Cache read", "Cache write", "Cost", "Cumulative"},
+		}
+		for _, t := range r.Turns {
+			turns.AddRow(report.Int(int64(t.Turn)), report.Int(t.Context), report.Int(t.Input),
+				report.Int(t.CacheRead), report.Int(t.CacheWrite),
+				report.Number("$%.6f", t.Cost), report.Number("$%.6f", t.Cumulative))
+		}
+		tables = append(tables, turns)
+	}
+
+	writeHTML(report.Report{
+		Title:        "Conversation Cost Simulation",
+		Notes:        []string{conversationNote(), catalogNote()},
+		Tables:       append([]report.Table{summary}, tables...),
+		Charts:       []report.Chart{chart},
+		Capabilities: capabilityMatrix(entries),
+	})
 }
 
 // displayCostResult displays cost results
@@ -226,10 +1145,12 @@ func displayCostResult(results []costResult) {
 		outputJSON(results)
 	case "csv":
 		outputCSV(results)
+	case "html":
+		outputHTML(results)
 	case "table":
 		outputTable(results)
 	default:
-		log.Fatalf("Unknown format: %s (use 'table', 'json', or 'csv')", *outputFormat)
+		log.Fatalf("Unknown format: %s (use 'table', 'json', 'csv', or 'html')", *outputFormat)
 	}
 }
 
@@ -241,53 +1162,66 @@ func outputTable(results []costResult) {
 	}
 
 	fmt.Println()
-	fmt.Println(headerStyle.Render("Cost Calculation Results"))
-	fmt.Println(borderStyle.Render(strings.Repeat("═", 80)))
+	fmt.Println(cli.HeaderStyle.Render("Cost Calculation Results"))
+	fmt.Println(cli.BorderStyle.Render(render.DoubleRule(80)))
 	fmt.Println()
 
-	fmt.Println(dividerStyle.Render("─┬──────────────────────────────────────────────┬──────────┬─────────┬────────┐"))
-	fmt.Printf("%s %-42s %s %8s %s %7s %s %6s %s\n",
-		dividerStyle.Render("│"),
-		modelStyle.Render("Model"),
-		dividerStyle.Render("│"),
-		costStyle.Render("Input"),
-		dividerStyle.Render("│"),
-		costStyle.Render("Output"),
-		dividerStyle.Render("│"),
-		costStyle.Render("Total"),
-		dividerStyle.Render("│"))
-	fmt.Println(dividerStyle.Render("─┼──────────────────────────────────────────────┼──────────┼─────────┼────────┤"))
-
+	tbl := render.NewTable(
+		render.Column{Title: "Model", MinWidth: 16, Style: modelStyle},
+		render.Column{Title: "Input", Align: render.AlignRight, Style: cli.CostStyle},
+		render.Column{Title: "Output", Align: render.AlignRight, Style: cli.CostStyle},
+		render.Column{Title: "Total", Align: render.AlignRight, Style: cli.CostStyle},
+	)
+	baseline := results[0].MonthlySavings != nil
+	if baseline {
+		tbl.Columns = append(tbl.Columns,
+			render.Column{Title: "% of Baseline", Align: render.AlignRight},
+			render.Column{Title: "Savings/Month", Align: render.AlignRight, Style: cli.CostStyle})
+	}
+	tbl.HeaderStyle = cli.HeaderStyle
+	tbl.BorderStyle = cli.MutedStyle
 	for _, r := range results {
-		name := r.Model
-		if len(name) > 40 {
-			name = name[:37] + "..."
+		row := []string{r.Model,
+			fmt.Sprintf("$%.4f", r.InputCost),
+			fmt.Sprintf("$%.4f", r.OutputCost),
+			fmt.Sprintf("$%.4f", r.TotalCost)}
+		if baseline {
+			row = append(row, formatPct(r.PctOfBaseline), formatSavings(*r.MonthlySavings))
 		}
-
-		fmt.Printf("%s %-42s %s $%7.4f %s $%7.4f %s $%6.4f %s\n",
-			dividerStyle.Render("│"),
-			name,
-			dividerStyle.Render("│"),
-			r.InputCost,
-			dividerStyle.Render("│"),
-			r.OutputCost,
-			dividerStyle.Render("│"),
-			r.TotalCost,
-			dividerStyle.Render("│"))
+		tbl.AddRow(row...)
+	}
+	tbl.Print()
+	if baseline {
+		fmt.Println(cli.MutedStyle.Render(baselineNote()))
 	}
 
-	fmt.Println(dividerStyle.Render("─┴──────────────────────────────────────────────┴──────────┴─────────┴────────┘"))
+	// Show image and audio costs, which are included in the totals above
+	var multimodal []costResult
+	for _, r := range results {
+		if r.ImageCost > 0 || r.AudioCost > 0 {
+			multimodal = append(multimodal, r)
+		}
+	}
+	if len(multimodal) > 0 {
+		fmt.Println()
+		fmt.Println(cli.HeaderStyle.Render("Multimodal Costs (included in Total)"))
+		for _, r := range multimodal {
+			fmt.Printf("%s: images %s, audio %s\n", modelStyle.Render(r.Model),
+				cli.CostStyle.Render(fmt.Sprintf("$%.4f", r.ImageCost)),
+				cli.CostStyle.Render(fmt.Sprintf("$%.4f", r.AudioCost)))
+		}
+	}
 
 	// Show provider information
 	fmt.Println()
-	fmt.Println(headerStyle.Render("Provider Information"))
+	fmt.Println(cli.HeaderStyle.Render("Provider Information"))
 	for _, r := range results {
 		fmt.Printf("%s: %s\n", modelStyle.Render(r.Model), providerStyle.Render(r.Provider))
 	}
 }
 
 // outputJSON displays results in JSON format
-func outputJSON(results []costResult) {
+func outputJSON(results any) {
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
 	if err := encoder.Encode(results); err != nil {
@@ -301,7 +1235,11 @@ func outputCSV(results []costResult) {
 	defer writer.Flush()
 
 	// Write header
-	header := []string{"Model", "Provider", "InputCost", "OutputCost", "TotalCost"}
+	header := []string{"Model", "Provider", "InputCost", "OutputCost", "ImageCost", "AudioCost", "TotalCost"}
+	baseline := len(results) > 0 && results[0].MonthlySavings != nil
+	if baseline {
+		header = append(header, "PctOfBaseline", "MonthlySavings")
+	}
 	if err := writer.Write(header); err != nil {
 		log.Fatalf("Error writing CSV header: %v", err)
 	}
@@ -313,14 +1251,174 @@ func outputCSV(results []costResult) {
 			r.Provider,
 			strconv.FormatFloat(r.InputCost, 'f', 4, 64),
 			strconv.FormatFloat(r.OutputCost, 'f', 4, 64),
+			strconv.FormatFloat(r.ImageCost, 'f', 4, 64),
+			strconv.FormatFloat(r.AudioCost, 'f', 4, 64),
 			strconv.FormatFloat(r.TotalCost, 'f', 4, 64),
 		}
+		if baseline {
+			pct := ""
+			if r.PctOfBaseline != nil {
+				pct = strconv.FormatFloat(*r.PctOfBaseline, 'f', 2, 64)
+			}
+			row = append(row, pct, strconv.FormatFloat(*r.MonthlySavings, 'f', 2, 64))
+		}
 		if err := writer.Write(row); err != nil {
 			log.Fatalf("Error writing CSV row: %v", err)
 		}
 	}
 }
 
+// workloadNote describes the workload given on the command line
+func workloadNote() string {
+	note := fmt.Sprintf("Workload: %d input tokens (%.0f%% cached), %d output tokens",
+		*inputTokens, *cachedRatio*100, *outputTokens)
+	if m := flagMedia(); !m.empty() {
+		note += fmt.Sprintf(", %d images, audio in %q, audio out %q", m.Images, m.AudioIn, m.AudioOut)
+	}
+	return note
+}
+
+// catalogNote describes the catalog the prices came from
+func catalogNote() string {
+	if *catalogVersion != "" {
+		return "Catalog snapshot: " + *catalogVersion
+	}
+	return "Catalog: live"
+}
+
+// capabilityMatrix lists the capabilities of each distinct model in results
+func capabilityMatrix(results []costResult) *report.Matrix {
+	var labels []string
+	var models []catwalk.Model
+	seen := map[string]bool{}
+	for _, r := range results {
+		if !seen[r.Model] {
+			seen[r.Model] = true
+			labels = append(labels, r.Model)
+			models = append(models, r.catalog)
+		}
+	}
+	return report.Capabilities(labels, models)
+}
+
+// resultTable tabulates the cost breakdown of each result
+func resultTable(title string, results []costResult) report.Table {
+	tbl := report.Table{
+		Title:   title,
+		Columns: []string{"Model", "Provider", "Input", "Output", "Image", "Audio", "Total"},
+	}
+	for _, r := range results {
+		tbl.AddRow(report.Text(r.Model), report.Text(r.Provider),
+			report.USD(r.InputCost), report.USD(r.OutputCost),
+			report.USD(r.ImageCost), report.USD(r.AudioCost), report.USD(r.TotalCost))
+	}
+	return tbl
+}
+
+// writeHTML writes a report to stdout
+func writeHTML(r report.Report) {
+	if err := r.WriteHTML(os.Stdout); err != nil {
+		log.Fatalf("Error writing HTML: %v", err)
+	}
+}
+
+// outputHTML displays results as a standalone HTML report
+func outputHTML(results []costResult) {
+	chart := report.Chart{Title: "Total Cost per Model", Format: "$%.4f"}
+	for _, r := range results {
+		chart.Bars = append(chart.Bars, report.Bar{Label: r.Model, Value: r.TotalCost})
+	}
+
+	costs := resultTable("Costs", results)
+	notes := []string{workloadNote(), catalogNote()}
+	if results[0].MonthlySavings != nil {
+		costs.Columns = append(costs.Columns, "% of Baseline", "Savings/Month")
+		for i, r := range results {
+			pct := report.Text("-")
+			if r.PctOfBaseline != nil {
+				pct = report.Number("%.1f%%", *r.PctOfBaseline)
+			}
+			costs.Rows[i] = append(costs.Rows[i], pct, report.USD(*r.MonthlySavings))
+		}
+		notes = append(notes, baselineNote())
+	}
+
+	writeHTML(report.Report{
+		Title:        "Cost Calculation Results",
+		Notes:        notes,
+		Tables:       []report.Table{costs},
+		Charts:       []report.Chart{chart},
+		Capabilities: capabilityMatrix(results),
+	})
+}
+
+// outputBatchHTML displays a batch run as a standalone HTML report
+func outputBatchHTML(batch batchReport) {
+	scenarios := resultTable("Scenarios", batch.Results)
+	scenarios.Columns = append([]string{"Label"}, scenarios.Columns...)
+	scenarios.Columns = append(scenarios.Columns, "Repeat")
+	for i, r := range batch.Results {
+		scenarios.Rows[i] = append([]report.Cell{report.Text(r.Label)}, scenarios.Rows[i]...)
+		scenarios.Rows[i] = append(scenarios.Rows[i], report.Int(int64(r.Repeat)))
+	}
+
+	summary := report.Table{
+		Title:   "Summary",
+		Columns: []string{"Label", "Model", "Runs", "Total", "Mean", "P95"},
+	}
+	chart := report.Chart{Title: "Total Cost per Label and Model", Format: "$%.4f"}
+	for _, sum := range batch.Summary {
+		summary.AddRow(report.Text(sum.Label), report.Text(sum.Model), report.Int(int64(sum.Runs)),
+			report.USD(sum.Total), report.USD(sum.Mean), report.USD(sum.P95))
+		label := sum.Model
+		if sum.Label != "" {
+			label = sum.Label + " / " + sum.Model
+		}
+		chart.Bars = append(chart.Bars, report.Bar{Label: label, Value: sum.Total})
+	}
+
+	writeHTML(report.Report{
+		Title:        "Batch Cost Report",
+		Notes:        []string{fmt.Sprintf("Grand total: $%.4f", batch.GrandTotal), catalogNote()},
+		Tables:       []report.Table{summary, scenarios},
+		Charts:       []report.Chart{chart},
+		Capabilities: capabilityMatrix(batch.Results),
+	})
+}
+
+// outputSweepHTML displays the sweep matrix as a standalone HTML report, with
+// a chart of the costs at the end of the range
+func outputSweepHTML(sw sweep, entries []costResult, rows []sweepRow) {
+	tbl := report.Table{Columns: []string{sw.param}}
+	for _, e := range entries {
+		tbl.Columns = append(tbl.Columns, e.Model)
+	}
+	for _, row := range rows {
+		cells := []report.Cell{{Text: formatSweepValue(sw, row.Value), Value: row.Value, Numeric: true}}
+		for _, e := range entries {
+			cells = append(cells, report.Number("$%.6f", row.Costs[e.Model]))
+		}
+		tbl.AddRow(cells...)
+	}
+
+	last := rows[len(rows)-1]
+	chart := report.Chart{
+		Title:  fmt.Sprintf("Cost at %s=%s", sw.param, formatSweepValue(sw, last.Value)),
+		Format: "$%.6f",
+	}
+	for _, e := range entries {
+		chart.Bars = append(chart.Bars, report.Bar{Label: e.Model, Value: last.Costs[e.Model]})
+	}
+
+	writeHTML(report.Report{
+		Title:        "Cost Sensitivity: " + sw.param,
+		Notes:        []string{"Sweep: " + *sweepSpec + " (overrides the workload below)", workloadNote(), catalogNote()},
+		Tables:       []report.Table{tbl},
+		Charts:       []report.Chart{chart},
+		Capabilities: capabilityMatrix(entries),
+	})
+}
+
 // printHelp displays usage information
 func printHelp() {
 	fmt.Println("cost-calculator - Estimate AI API costs for different models")
@@ -334,28 +1432,66 @@ func printHelp() {
 	fmt.Println("  --output <tokens>   Number of output tokens")
 	fmt.Println()
 	fmt.Println("Optional Options:")
-	fmt.Println("  --cached <ratio>    Ratio of cached tokens (0-1, default: 0)")
+	fmt.Println("  --cached <ratio>    Ratio of input tokens read from the prompt cache, priced")
+	fmt.Println("                      at the cache read price (0-1, default: 0)")
 	fmt.Println("  --compare <models>  Comma-separated list of models to compare")
+	fmt.Println("  --baseline <model>  With --compare, add each model's cost as a % of this model's")
+	fmt.Println("                      and its monthly savings over it (listed if not compared)")
+	fmt.Println("  --calls-per-month <n>  Calls per month the savings are for (default: 100000)")
 	fmt.Println("  --batch <file>      JSON file with batch scenarios")
-	fmt.Println("  --format <fmt>      Output format: table (default), json, csv")
+	fmt.Println("  --parallel <n>      Batch scenarios calculated concurrently (default: CPU count)")
+	fmt.Println("  --sweep <spec>      Vary input, output, or cached over a range and show the")
+	fmt.Println("                      cost matrix and crossover points, e.g. input=500:5000:500")
+	fmt.Println("                      or cached=0:1:0.25 (uses --model or --compare)")
+	fmt.Println("  --format <fmt>      Output format: table (default), json, csv, html")
+	fmt.Println()
+	fmt.Println("Simulation Options:")
+	fmt.Println("  --simulate <turns>  Price an N-turn conversation that resends its history every")
+	fmt.Println("                      turn, with --input and --output tokens per turn, against")
+	fmt.Println("                      the naive per-call estimate (uses --model or --compare)")
+	fmt.Println("  --system <tokens>   System prompt and tool tokens sent every turn")
+	fmt.Println("  --cache-prefix      Cache each prompt so the next turn reads it from the cache")
+	fmt.Println()
+	fmt.Println("Multimodal Options:")
+	fmt.Println("  --images <n>        Number of images")
+	fmt.Println("  --image-tier <name>  Catalog image pricing tier (default: the model's first tier)")
+	fmt.Println("  --image-cost <usd>  Price per image, overriding catalog pricing")
+	fmt.Println("  --audio-in <amt>    Audio input: a duration (90s, 10m) or an audio token count")
+	fmt.Println("  --audio-out <amt>   Audio output: a duration or an audio token count")
+	fmt.Println("  --audio-cost <spec>  Audio prices overriding the catalog: in/out per minute or")
+	fmt.Println("                      per 1M tokens, e.g. in=0.006/min,out=0.024/min or in=40/1M")
 	fmt.Println()
 	fmt.Println("Batch File Format (JSON):")
 	fmt.Println("  [")
 	fmt.Println("    {")
+	fmt.Println("      \"label\": \"summarize\",")
 	fmt.Println("      \"model\": \"gpt-4o\",")
 	fmt.Println("      \"input_tokens\": 1000,")
 	fmt.Println("      \"output_tokens\": 500,")
-	fmt.Println("      \"cached_ratio\": 0.5")
+	fmt.Println("      \"cached_ratio\": 0.5,")
+	fmt.Println("      \"repeat\": 100,")
+	fmt.Println("      \"images\": 2,")
+	fmt.Println("      \"audio_in\": \"30s\"")
 	fmt.Println("    },")
 	fmt.Println("    ...")
 	fmt.Println("  ]")
 	fmt.Println()
+	fmt.Println("  label, repeat, images, image_tier, audio_in, and audio_out are optional.")
+	fmt.Println("  Batch output adds total, mean, and p95 cost per label/model (counting")
+	fmt.Println("  each repeat as a run) and a grand total.")
+	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  go run main.go --model \"gpt-4o\" --input 1000 --output 500")
 	fmt.Println("  go run main.go --compare \"gpt-4o,claude-3-opus\" --input 1000 --output 500")
+	fmt.Println("  go run main.go --compare \"gpt-4o-mini,claude-3-haiku\" --baseline gpt-4o --input 1000 --output 500 --calls-per-month 500000")
 	fmt.Println("  go run main.go --model \"gpt-4o\" --input 1000 --output 500 --cached 0.5")
 	fmt.Println("  go run main.go --batch scenarios.json --format csv")
+	fmt.Println("  go run main.go --model \"gpt-4o\" --input 1000 --output 500 --images 20 --image-cost 0.002")
+	fmt.Println("  go run main.go --model \"gpt-4o\" --audio-in 10m --audio-out 2m --audio-cost in=0.006/min,out=0.024/min")
+	fmt.Println("  go run main.go --compare \"gpt-4o,claude-3-opus\" --output 500 --sweep input=500:5000:500")
+	fmt.Println("  go run main.go --model \"gpt-4o\" --input 200 --output 400 --system 2000 --simulate 20 --cache-prefix")
 	fmt.Println()
-	fmt.Println("Environment Variables:")
-	fmt.Println("  CATWALK_URL - URL of the catwalk service (default: http://localhost:8080)")
+	cli.PrintCatalogHelp()
+	cli.PrintNetworkHelp()
+	cli.PrintEnvHelp()
 }
@@ -9,53 +9,60 @@
 // - Configuration export
 //
 // Usage:
-//   go run main.go                          # Start interactive wizard
-//   go run main.go --help                     # Show help message
+//
+//	go run main.go                          # Start interactive wizard
+//	go run main.go --help                     # Show help message
 //
 // Environment Variables:
-//   CATWALK_URL - URL of the catwalk service (default: http://localhost:8080)
+//
+//	CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)
 package main
 
 import (
+	"cmp"
 	"context"
 	"flag"
 	"fmt"
 	"log"
-	"sort"
+	"slices"
 	"strings"
 
+	"charm.land/catwalk/internal/cli"
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/render"
+	"charm.land/catwalk/pkg/transport"
+	bubblesList "github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	bubblesList "github.com/charmbracelet/bubbles/list"
-	"charm.land/catwalk/pkg/catwalk"
 )
 
 var (
+	network  = transport.RegisterFlags(flag.CommandLine)
 	showHelp = flag.Bool("help", false, "Show help message")
 )
 
 // Styles for formatting
 var (
-	headerStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("86"))
-	titleStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
-	subtitleStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
-	optionStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("255"))
-	selectedStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("228"))
-	borderStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	titleStyle    = lipgloss.NewStyle().Bold(true).Foreground(cli.Colors.Accent)
+	subtitleStyle = lipgloss.NewStyle().Foreground(cli.Colors.Muted)
+	optionStyle   = lipgloss.NewStyle().Foreground(cli.Colors.Text)
+	selectedStyle = lipgloss.NewStyle().Bold(true).Foreground(cli.Colors.Highlight)
 )
 
 type requirements struct {
-	budget       float64
-	contextSize  int64
-	reasoning    bool
-	vision       bool
+	budget      float64
+	contextSize int64
+	reasoning   bool
+	vision      bool
 }
 
+// modelScore points into the fetched catalog, so collecting and sorting
+// every model copies no model or provider data
 type modelScore struct {
-	model    catwalk.Model
-	provider catwalk.Provider
+	model    *catwalk.Model
+	provider *catwalk.Provider
 	score    float64
-	reasons   []string
+	reasons  []string
 }
 
 type step int
@@ -87,6 +94,7 @@ type model struct {
 }
 
 func main() {
+	render.SetupConsole()
 	flag.Parse()
 
 	if *showHelp {
@@ -95,7 +103,11 @@ func main() {
 	}
 
 	// Create catwalk client
-	client := catwalk.New()
+	httpClient, err := network.Client()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	client := catwalk.NewWithHTTPClient(httpClient)
 	ctx := context.Background()
 
 	// Fetch providers
@@ -110,10 +122,11 @@ func main() {
 
 	// Collect all models
 	var allModels []modelScore
-	for _, p := range providers {
-		for _, m := range p.Models {
+	for i := range providers {
+		p := &providers[i]
+		for j := range p.Models {
 			allModels = append(allModels, modelScore{
-				model:    m,
+				model:    &p.Models[j],
 				provider: p,
 			})
 		}
@@ -308,9 +321,7 @@ func (m *model) calculateScores() {
 	}
 
 	// Sort by score (descending)
-	sort.Slice(m.allModels, func(i, j int) bool {
-		return m.allModels[i].score > m.allModels[j].score
-	})
+	slices.SortFunc(m.allModels, func(a, b modelScore) int { return cmp.Compare(b.score, a.score) })
 }
 
 func (m *model) setupResultsList() {
@@ -332,7 +343,7 @@ func (m *model) setupResultsList() {
 func (m model) View() string {
 	var s strings.Builder
 
-	s.WriteString(headerStyle.Render("AI Model Selector"))
+	s.WriteString(cli.HeaderStyle.Render("AI Model Selector"))
 	s.WriteString("\n\n")
 	s.WriteString(subtitleStyle.Render("Answer a few questions to find the best model for your needs"))
 	s.WriteString("\n\n")
@@ -360,7 +371,7 @@ func (m model) viewResults() string {
 			mm.model.CostPer1MIn, mm.model.CostPer1MOut))
 		s.WriteString(fmt.Sprintf("  Context: %dK tokens\n", mm.model.ContextWindow/1000))
 		s.WriteString(fmt.Sprintf("  Reasoning: %s | Vision: %s\n",
-			boolToStr(mm.model.CanReason), boolToStr(mm.model.SupportsImages)))
+			cli.YesNo(mm.model.CanReason), cli.YesNo(mm.model.SupportsImages)))
 
 		if len(mm.reasons) > 0 {
 			s.WriteString("  Reasons: ")
@@ -376,7 +387,7 @@ func (m model) viewResults() string {
 		s.WriteString("\n")
 	}
 
-	s.WriteString(borderStyle.Render(strings.Repeat("─", 60)))
+	s.WriteString(cli.BorderStyle.Render(render.Rule(60)))
 	s.WriteString("\n")
 	s.WriteString("Press Enter to exit or select a model to see details")
 
@@ -419,20 +430,6 @@ func parseContext(s string) (int64, error) {
 	}
 }
 
-func boolToStr(b bool) string {
-	if b {
-		return "Yes"
-	}
-	return "No"
-}
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
 func printHelp() {
 	fmt.Println("model-selector - Interactive wizard to select the best model")
 	fmt.Println()
@@ -448,6 +445,6 @@ func printHelp() {
 	fmt.Println("  - Reasoning capabilities")
 	fmt.Println("  - Vision/multimodal support")
 	fmt.Println()
-	fmt.Println("Environment Variables:")
-	fmt.Println("  CATWALK_URL - URL of the catwalk service (default: http://localhost:8080)")
+	cli.PrintNetworkHelp()
+	cli.PrintEnvHelp()
 }
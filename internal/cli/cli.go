@@ -0,0 +1,62 @@
+// Package cli holds what the example programs share: the color palette,
+// small formatting helpers, and the help sections for flags every example
+// registers, so their output and documentation stay consistent.
+package cli
+
+import (
+	"fmt"
+
+	"charm.land/catwalk/pkg/theme"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Colors is the aimodels theme: AIMODELS_THEME or the settings file's
+// theme, else dark or light to suit the terminal. Programs color their own
+// styles from it.
+var Colors = theme.Current()
+
+// Styles shared by the examples. Programs define their own for anything
+// else, such as chat-bot's user and assistant labels.
+var (
+	HeaderStyle     = lipgloss.NewStyle().Bold(true).Foreground(Colors.Header)
+	CostStyle       = lipgloss.NewStyle().Foreground(Colors.Highlight)
+	ContextStyle    = lipgloss.NewStyle().Foreground(Colors.Secondary)
+	CapabilityStyle = lipgloss.NewStyle().Foreground(Colors.OK)
+	MutedStyle      = lipgloss.NewStyle().Foreground(Colors.Muted)
+	BorderStyle     = lipgloss.NewStyle().Foreground(Colors.Border)
+)
+
+// YesNo returns "Yes" or "No".
+func YesNo(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return "No"
+}
+
+// PrintCatalogHelp prints the help section for --catalog-version.
+func PrintCatalogHelp() {
+	fmt.Println("Catalog Options:")
+	fmt.Println("  --catalog-version <v>  Use a stored snapshot instead of live data: an ETag,")
+	fmt.Println("                         a date (YYYY-MM-DD, latest snapshot on or before it),")
+	fmt.Println("                         or \"latest\". Live fetches are snapshotted automatically;")
+	fmt.Println("                         run 'aimodels snapshots' to list them.")
+	fmt.Println()
+}
+
+// PrintNetworkHelp prints the help section for the flags registered by
+// transport.RegisterFlags.
+func PrintNetworkHelp() {
+	fmt.Println("Network Options:")
+	fmt.Println("  --proxy <url>           Proxy URL (default: HTTPS_PROXY/HTTP_PROXY from the environment)")
+	fmt.Println("  --ca-cert <pem>         PEM file with additional CA certificates to trust")
+	fmt.Println("  --insecure-skip-verify  Skip TLS certificate verification (unsafe)")
+	fmt.Println()
+}
+
+// PrintEnvHelp prints the help section for CATWALK_URL.
+func PrintEnvHelp() {
+	fmt.Println("Environment Variables:")
+	fmt.Println("  CATWALK_URL - URL of the catwalk service, then any mirrors, comma-separated (default: http://localhost:8080)")
+	fmt.Println("  AIMODELS_THEME - Output theme (auto, dark, light, high-contrast, plain) or theme file")
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"charm.land/catwalk/pkg/transport"
+)
+
+// capture returns what f prints to stdout.
+func capture(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	f()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestNetworkHelpCoversFlags(t *testing.T) {
+	help := capture(t, PrintNetworkHelp)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	transport.RegisterFlags(fs)
+	fs.VisitAll(func(f *flag.Flag) {
+		if !strings.Contains(help, "--"+f.Name) {
+			t.Errorf("network help does not document --%s", f.Name)
+		}
+	})
+}
+
+func TestDropdown(t *testing.T) {
+	d := NewDropdown([]string{"gpt-4o-mini", "claude-3-5-sonnet-20241022", "gpt-4o", "claude-3-5-haiku-20241022"}, 3)
+	if d.View() != "" {
+		t.Error("dropdown shows before anything is typed")
+	}
+
+	d.Filter("c35s")
+	if got, ok := d.Selected(); !ok || got != "claude-3-5-sonnet-20241022" {
+		t.Errorf("selected %q, %v", got, ok)
+	}
+
+	d.Filter("gpt")
+	d.Move(1)
+	if got, _ := d.Selected(); got != "gpt-4o-mini" {
+		t.Errorf("selected %q after moving down", got)
+	}
+	d.Move(1)
+	if got, _ := d.Selected(); got != "gpt-4o" {
+		t.Errorf("selected %q after wrapping", got)
+	}
+	if lines := strings.Count(d.View(), "\n"); lines != 2 {
+		t.Errorf("view has %d lines; want 2", lines)
+	}
+
+	d.Filter("zzz")
+	if _, ok := d.Selected(); ok || d.View() != "" {
+		t.Error("dropdown shows without matches")
+	}
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/render"
+)
+
+// Dropdown lists the candidates completing a partly typed ID, such as a
+// model ID, for showing under an input line with one of them selected.
+type Dropdown struct {
+	candidates []string
+	size       int
+	matches    []string
+	selected   int
+}
+
+// NewDropdown returns a dropdown over candidates that shows at most size
+// matches.
+func NewDropdown(candidates []string, size int) Dropdown {
+	return Dropdown{candidates: candidates, size: size}
+}
+
+// Filter matches the candidates against input, by prefix and then fuzzily
+// as [catwalk.Complete] ranks them, and selects the best. An empty input
+// matches nothing, so the dropdown stays hidden until the user types.
+func (d *Dropdown) Filter(input string) {
+	d.matches, d.selected = nil, 0
+	if input = strings.TrimSpace(input); input != "" {
+		d.matches = catwalk.Complete(input, d.candidates, d.size)
+	}
+}
+
+// Move moves the selection by delta, wrapping around.
+func (d *Dropdown) Move(delta int) {
+	if n := len(d.matches); n > 0 {
+		d.selected = ((d.selected+delta)%n + n) % n
+	}
+}
+
+// Selected returns the selected match, if there are any.
+func (d Dropdown) Selected() (string, bool) {
+	if len(d.matches) == 0 {
+		return "", false
+	}
+	return d.matches[d.selected], true
+}
+
+// View renders the matches one per line, marking the selected one, or
+// returns "" if there are none.
+func (d Dropdown) View() string {
+	var b strings.Builder
+	for i, m := range d.matches {
+		if i == d.selected {
+			b.WriteString(CapabilityStyle.Render(render.Symbol("›", ">") + " " + m))
+		} else {
+			b.WriteString(MutedStyle.Render("  " + m))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
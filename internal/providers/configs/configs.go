@@ -0,0 +1,40 @@
+// Package configs holds the provider configurations the catwalk service
+// serves, so clients built with the embedcatalog tag can compile them in
+// without importing the providers package, which imports theirs.
+package configs
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+)
+
+//go:embed *.json
+var files embed.FS
+
+// Catalog returns the configurations as the JSON array of providers the
+// service's /v2/providers endpoint returns, in file name order.
+func Catalog() ([]byte, error) {
+	names, err := fs.Glob(files, "*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list provider configs: %w", err)
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, name := range names {
+		data, err := files.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := json.Compact(&buf, data); err != nil {
+			return nil, fmt.Errorf("invalid provider config %s: %w", name, err)
+		}
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
@@ -1,8 +1,12 @@
 package providers
 
 import (
+	"encoding/json"
 	"slices"
 	"testing"
+
+	"charm.land/catwalk/internal/providers/configs"
+	"charm.land/catwalk/pkg/catwalk"
 )
 
 func TestValidDefaultModels(t *testing.T) {
@@ -21,3 +25,28 @@ func TestValidDefaultModels(t *testing.T) {
 		})
 	}
 }
+
+// TestEmbeddedCatalog checks that the catalog compiled in with the
+// embedcatalog tag has every provider the service serves.
+func TestEmbeddedCatalog(t *testing.T) {
+	data, err := configs.Catalog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var embedded []catwalk.Provider
+	if err := json.Unmarshal(data, &embedded); err != nil {
+		t.Fatal(err)
+	}
+	var served, got []string
+	for _, p := range GetAll() {
+		served = append(served, string(p.ID))
+	}
+	for _, p := range embedded {
+		got = append(got, string(p.ID))
+	}
+	slices.Sort(served)
+	slices.Sort(got)
+	if !slices.Equal(got, served) {
+		t.Errorf("embedded catalog has %v; the service serves %v", got, served)
+	}
+}
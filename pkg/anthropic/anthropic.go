@@ -0,0 +1,83 @@
+// Package anthropic converts OpenAI-style chat requests to Anthropic's
+// Messages API, for the backends that take only that: Anthropic's Message
+// Batches API in pkg/batch and Claude models on Vertex AI in pkg/vertex.
+//
+// Only text is converted. Requests with tools, images, or tool results are
+// refused, since the OpenAI and Anthropic forms of them differ in more than
+// shape.
+package anthropic
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// DefaultMaxTokens is sent when neither the request nor the caller sets a
+// limit, which the Messages API requires.
+const DefaultMaxTokens = 4096
+
+// Params is a Messages API request. Vertex AI takes the model in the path
+// rather than as Model, and AnthropicVersion in the body rather than as a
+// header; both are left out when empty.
+type Params struct {
+	Model            string    `json:"model,omitempty"`
+	AnthropicVersion string    `json:"anthropic_version,omitempty"`
+	MaxTokens        int       `json:"max_tokens"`
+	System           string    `json:"system,omitempty"`
+	Messages         []Message `json:"messages"`
+	Stream           bool      `json:"stream,omitempty"`
+	Temperature      *float32  `json:"temperature,omitempty"`
+	TopP             *float32  `json:"top_p,omitempty"`
+	StopSequences    []string  `json:"stop_sequences,omitempty"`
+}
+
+// Message is one turn of a conversation, as text.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request converts an OpenAI-style request. System and developer messages
+// become the system prompt, and consecutive messages of one role are
+// merged, as the Messages API requires turns to alternate; sampling
+// parameters it lacks are dropped. maxTokens is sent when the request sets
+// no limit, or [DefaultMaxTokens] if it is zero too.
+func Request(r openai.ChatCompletionRequest, maxTokens int) (Params, error) {
+	if len(r.Tools) > 0 || len(r.Functions) > 0 {
+		return Params{}, errors.New("tools are not supported")
+	}
+	p := Params{
+		Model:         r.Model,
+		MaxTokens:     cmp.Or(r.MaxTokens, r.MaxCompletionTokens, maxTokens, DefaultMaxTokens),
+		Stream:        r.Stream,
+		StopSequences: r.Stop,
+	}
+	if r.Temperature != 0 {
+		p.Temperature = &r.Temperature
+	}
+	if r.TopP != 0 {
+		p.TopP = &r.TopP
+	}
+
+	var system []string
+	for _, m := range r.Messages {
+		switch {
+		case len(m.MultiContent) > 0 || len(m.ToolCalls) > 0:
+			return Params{}, errors.New("only text messages are supported")
+		case m.Role == openai.ChatMessageRoleSystem || m.Role == openai.ChatMessageRoleDeveloper:
+			system = append(system, m.Content)
+		case m.Role != openai.ChatMessageRoleUser && m.Role != openai.ChatMessageRoleAssistant:
+			return Params{}, fmt.Errorf("%s messages are not supported", m.Role)
+		case len(p.Messages) > 0 && p.Messages[len(p.Messages)-1].Role == m.Role:
+			p.Messages[len(p.Messages)-1].Content += "\n\n" + m.Content
+		default:
+			p.Messages = append(p.Messages, Message{Role: m.Role, Content: m.Content})
+		}
+	}
+	p.System = strings.Join(system, "\n\n")
+	return p, nil
+}
@@ -0,0 +1,94 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestRequest(t *testing.T) {
+	r := openai.ChatCompletionRequest{
+		Model:       "claude-sonnet-4",
+		Temperature: 0.5,
+		Stop:        []string{"END"},
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "Be brief."},
+			{Role: openai.ChatMessageRoleDeveloper, Content: "Answer in English."},
+			{Role: openai.ChatMessageRoleUser, Content: "Hi."},
+			{Role: openai.ChatMessageRoleUser, Content: "Are you there?"},
+			{Role: openai.ChatMessageRoleAssistant, Content: "Yes."},
+			{Role: openai.ChatMessageRoleUser, Content: "Good."},
+		},
+	}
+	p, err := Request(r, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Model != "claude-sonnet-4" || p.System != "Be brief.\n\nAnswer in English." || p.MaxTokens != DefaultMaxTokens {
+		t.Errorf("Model, System, MaxTokens = %q, %q, %d", p.Model, p.System, p.MaxTokens)
+	}
+	if p.Temperature == nil || *p.Temperature != 0.5 || p.TopP != nil || len(p.StopSequences) != 1 {
+		t.Errorf("Temperature, TopP, StopSequences = %v, %v, %q", p.Temperature, p.TopP, p.StopSequences)
+	}
+	want := []Message{{"user", "Hi.\n\nAre you there?"}, {"assistant", "Yes."}, {"user", "Good."}}
+	if len(p.Messages) != len(want) {
+		t.Fatalf("Messages = %+v, want %+v", p.Messages, want)
+	}
+	for i := range want {
+		if p.Messages[i] != want[i] {
+			t.Errorf("Messages[%d] = %+v, want %+v", i, p.Messages[i], want[i])
+		}
+	}
+
+	// Vertex AI's form leaves out the model
+	p.Model, p.AnthropicVersion = "", "vertex-2023-10-16"
+	data, _ := json.Marshal(p)
+	if s := string(data); strings.Contains(s, `"model"`) || !strings.Contains(s, `"anthropic_version":"vertex-2023-10-16"`) {
+		t.Errorf("encoded as %s", s)
+	}
+}
+
+func TestRequestMaxTokens(t *testing.T) {
+	tests := []struct {
+		maxTokens, maxCompletionTokens, fallback, want int
+	}{
+		{1000, 2000, 3000, 1000},
+		{0, 2000, 3000, 2000},
+		{0, 0, 3000, 3000},
+		{0, 0, 0, DefaultMaxTokens},
+	}
+	for _, tt := range tests {
+		r := openai.ChatCompletionRequest{MaxTokens: tt.maxTokens, MaxCompletionTokens: tt.maxCompletionTokens}
+		if p, err := Request(r, tt.fallback); err != nil || p.MaxTokens != tt.want {
+			t.Errorf("Request(%d, %d, %d) MaxTokens = %d, %v, want %d", tt.maxTokens, tt.maxCompletionTokens, tt.fallback, p.MaxTokens, err, tt.want)
+		}
+	}
+}
+
+func TestRequestUnsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		r    openai.ChatCompletionRequest
+		want string
+	}{
+		{"tools", openai.ChatCompletionRequest{Tools: []openai.Tool{{Type: openai.ToolTypeFunction}}}, "tools are not supported"},
+		{"images", openai.ChatCompletionRequest{Messages: []openai.ChatCompletionMessage{{
+			Role:         openai.ChatMessageRoleUser,
+			MultiContent: []openai.ChatMessagePart{{Type: openai.ChatMessagePartTypeImageURL}},
+		}}}, "only text messages"},
+		{"tool calls", openai.ChatCompletionRequest{Messages: []openai.ChatCompletionMessage{{
+			Role:      openai.ChatMessageRoleAssistant,
+			ToolCalls: []openai.ToolCall{{ID: "1"}},
+		}}}, "only text messages"},
+		{"tool results", openai.ChatCompletionRequest{Messages: []openai.ChatCompletionMessage{{
+			Role: openai.ChatMessageRoleTool, Content: "42",
+		}}}, "tool messages are not supported"},
+	}
+	for _, tt := range tests {
+		if _, err := Request(tt.r, 0); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
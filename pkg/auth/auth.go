@@ -0,0 +1,177 @@
+// Package auth attaches credentials to provider API requests. Providers
+// differ in how they expect an API key: OpenAI-style bearer tokens, a named
+// header such as Anthropic's x-api-key or Azure's api-key, a query
+// parameter, AWS Signature Version 4 for Bedrock, or a Google OAuth token for
+// Vertex AI. [For] looks up the
+// scheme for a provider so clients don't have to hard-code one.
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// Kind is the way a credential is sent.
+type Kind string
+
+// Credential kinds.
+const (
+	// Bearer sends "Authorization: Bearer <key>".
+	Bearer Kind = "bearer"
+	// Header sends the key as the value of the header Scheme.Name.
+	Header Kind = "header"
+	// Query sends the key as the query parameter Scheme.Name.
+	Query Kind = "query"
+	// SigV4 signs the request with AWS credentials from the environment;
+	// the API key is not used.
+	SigV4 Kind = "sigv4"
+	// Google sends "Authorization: Bearer <token>" with an OAuth access
+	// token from Google application default credentials. An API key, if
+	// set, is sent as the token instead, as from gcloud auth
+	// print-access-token.
+	Google Kind = "google"
+)
+
+// Scheme describes how a provider authenticates requests.
+type Scheme struct {
+	Kind Kind
+
+	// Name is the header or query parameter carrying the key, for Header
+	// and Query.
+	Name string
+
+	// Headers are extra headers the API requires alongside the key, such as
+	// Anthropic's API version.
+	Headers map[string]string
+
+	// Service and Region scope SigV4 signatures. An empty Region is read
+	// from AWS_REGION or AWS_DEFAULT_REGION.
+	Service string
+	Region  string
+}
+
+// NeedsKey reports whether the scheme sends an API key, as opposed to
+// credentials found in the environment.
+func (s Scheme) NeedsKey() bool {
+	return s.Kind != SigV4 && s.Kind != Google
+}
+
+// Schemes used by the provider types in the catalog. Types not listed use
+// bearer tokens.
+var typeSchemes = map[catwalk.Type]Scheme{
+	catwalk.TypeAnthropic: {Kind: Header, Name: "x-api-key", Headers: map[string]string{"anthropic-version": "2023-06-01"}},
+	catwalk.TypeGoogle:    {Kind: Header, Name: "x-goog-api-key"},
+	catwalk.TypeAzure:     {Kind: Header, Name: "api-key"},
+	catwalk.TypeBedrock:   {Kind: SigV4, Service: "bedrock"},
+	catwalk.TypeVertexAI:  {Kind: Google},
+}
+
+var (
+	mu        sync.RWMutex
+	overrides = map[catwalk.InferenceProvider]Scheme{}
+)
+
+// Register sets the scheme for a provider, taking precedence over the one
+// for its type. Use it for providers whose API differs from their type's.
+func Register(id catwalk.InferenceProvider, s Scheme) {
+	mu.Lock()
+	defer mu.Unlock()
+	overrides[id] = s
+}
+
+// For returns the scheme for provider p.
+func For(p catwalk.Provider) Scheme {
+	mu.RLock()
+	s, ok := overrides[p.ID]
+	mu.RUnlock()
+	if ok {
+		return s
+	}
+	if s, ok := typeSchemes[p.Type]; ok {
+		return s
+	}
+	return Scheme{Kind: Bearer}
+}
+
+// Apply adds the credential to req, which the caller must own.
+func (s Scheme) Apply(req *http.Request, key string) error {
+	return s.apply(req, key, http.DefaultTransport)
+}
+
+// apply is Apply, fetching any token the credential needs through rt.
+func (s Scheme) apply(req *http.Request, key string, rt http.RoundTripper) error {
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+
+	switch s.Kind {
+	case Bearer, "":
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+	case Header:
+		req.Header.Del("Authorization")
+		req.Header.Set(s.Name, key)
+	case Query:
+		req.Header.Del("Authorization")
+		q := req.URL.Query()
+		q.Set(s.Name, key)
+		req.URL.RawQuery = q.Encode()
+	case SigV4:
+		req.Header.Del("Authorization")
+		creds, err := credentialsFromEnv()
+		if err != nil {
+			return err
+		}
+		region := s.Region
+		if region == "" {
+			region = envRegion()
+		}
+		if region == "" {
+			return fmt.Errorf("no AWS region set (AWS_REGION or AWS_DEFAULT_REGION)")
+		}
+		return sign(req, creds, region, s.Service, now())
+	case Google:
+		if key == "" {
+			token, err := googleAccessToken(req.Context(), rt)
+			if err != nil {
+				return err
+			}
+			key = token
+		}
+		req.Header.Set("Authorization", "Bearer "+key)
+	default:
+		return fmt.Errorf("unknown auth scheme %q", s.Kind)
+	}
+	return nil
+}
+
+// Transport returns a RoundTripper that authenticates every request sent
+// through base with key.
+func (s Scheme) Transport(key string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, scheme: s, key: key}
+}
+
+type transport struct {
+	base   http.RoundTripper
+	scheme Scheme
+	key    string
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the caller's request.
+	req = req.Clone(req.Context())
+	if err := t.scheme.apply(req, t.key, t.base); err != nil {
+		if req.Body != nil {
+			req.Body.Close() //nolint:errcheck
+		}
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
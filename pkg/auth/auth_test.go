@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+func TestFor(t *testing.T) {
+	tests := []struct {
+		provider catwalk.Provider
+		want     Kind
+		name     string
+	}{
+		{catwalk.Provider{ID: "openai", Type: catwalk.TypeOpenAI}, Bearer, ""},
+		{catwalk.Provider{ID: "anthropic", Type: catwalk.TypeAnthropic}, Header, "x-api-key"},
+		{catwalk.Provider{ID: "azure", Type: catwalk.TypeAzure}, Header, "api-key"},
+		{catwalk.Provider{ID: "bedrock", Type: catwalk.TypeBedrock}, SigV4, ""},
+		{catwalk.Provider{ID: "vertexai", Type: catwalk.TypeVertexAI}, Google, ""},
+	}
+	for _, tt := range tests {
+		if got := For(tt.provider); got.Kind != tt.want || got.Name != tt.name {
+			t.Errorf("For(%s) = %+v", tt.provider.ID, got)
+		}
+	}
+
+	Register("custom", Scheme{Kind: Query, Name: "key"})
+	if got := For(catwalk.Provider{ID: "custom", Type: catwalk.TypeOpenAICompat}); got.Kind != Query {
+		t.Errorf("registered scheme not used: %+v", got)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		scheme Scheme
+		check  func(r *http.Request) bool
+	}{
+		{Scheme{Kind: Bearer}, func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer k" }},
+		{For(catwalk.Provider{Type: catwalk.TypeAnthropic}), func(r *http.Request) bool {
+			return r.Header.Get("X-Api-Key") == "k" && r.Header.Get("Anthropic-Version") != "" && r.Header.Get("Authorization") == ""
+		}},
+		{Scheme{Kind: Query, Name: "key"}, func(r *http.Request) bool {
+			return r.URL.Query().Get("key") == "k" && r.URL.Query().Get("a") == "1"
+		}},
+	}
+	for _, tt := range tests {
+		client := &http.Client{Transport: tt.scheme.Transport("k", nil)}
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/models?a=1", nil)
+		req.Header.Set("Authorization", "Bearer stale")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close() //nolint:errcheck
+		if !tt.check(got) {
+			t.Errorf("%s: unexpected request headers %v, query %q", tt.scheme.Kind, got.Header, got.URL.RawQuery)
+		}
+		if req.Header.Get("Authorization") != "Bearer stale" {
+			t.Errorf("%s: caller's request was modified", tt.scheme.Kind)
+		}
+	}
+}
+
+// TestSignV4 checks the example request from the AWS Signature Version 4
+// documentation.
+func TestSignV4(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	creds := awsCredentials{accessKey: "AKIDEXAMPLE", secretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	if err := sign(req, creds, "us-east-1", "iam", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-date, " +
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSigV4RequiresCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	req, _ := http.NewRequest(http.MethodPost, "https://bedrock-runtime.us-east-1.amazonaws.com/model/x/converse", strings.NewReader("{}"))
+	if err := (Scheme{Kind: SigV4, Service: "bedrock"}).Apply(req, ""); err == nil {
+		t.Error("expected missing credentials error")
+	}
+}
+
+func TestCanonicalPath(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "https://bedrock-runtime.us-east-1.amazonaws.com/model/anthropic.claude-v2:1/invoke", nil)
+	if got, want := canonicalPath(req.URL), "/model/anthropic.claude-v2%253A1/invoke"; got != want {
+		t.Errorf("canonicalPath = %q; want %q", got, want)
+	}
+}
+
+func TestGoogleServiceAccount(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+
+	exchanges := 0
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/token" {
+			got = r
+			return
+		}
+		exchanges++
+		_ = r.ParseForm()
+		parts := strings.Split(r.PostForm.Get("assertion"), ".")
+		sig, _ := base64.RawURLEncoding.DecodeString(parts[len(parts)-1])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if len(parts) != 3 || rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig) != nil {
+			http.Error(w, "bad assertion", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"ya29.t","expires_in":3600}`))
+	}))
+	defer srv.Close()
+
+	creds, _ := json.Marshal(googleCredentials{
+		Type: "service_account", ClientEmail: "sa@p.iam.gserviceaccount.com",
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		TokenURI:   srv.URL + "/token",
+	})
+	path := filepath.Join(t.TempDir(), "sa.json")
+	if err := os.WriteFile(path, creds, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", path)
+	googleTokens.token = ""
+
+	client := &http.Client{Transport: For(catwalk.Provider{Type: catwalk.TypeVertexAI}).Transport("", nil)}
+	for range 2 {
+		resp, err := client.Get(srv.URL + "/v1/models")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close() //nolint:errcheck
+		if got.Header.Get("Authorization") != "Bearer ya29.t" {
+			t.Errorf("Authorization = %q", got.Header.Get("Authorization"))
+		}
+	}
+	if exchanges != 1 {
+		t.Errorf("token requested %d times, want it cached", exchanges)
+	}
+
+	// An access token given as the key is used as is
+	resp, err := (&http.Client{Transport: Scheme{Kind: Google}.Transport("given", nil)}).Get(srv.URL + "/v1/models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close() //nolint:errcheck
+	if got.Header.Get("Authorization") != "Bearer given" {
+		t.Errorf("Authorization = %q", got.Header.Get("Authorization"))
+	}
+}
@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// googleScope is the OAuth scope tokens are requested for; Vertex AI
+// accepts nothing narrower.
+const googleScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// googleTokenURL exchanges refresh tokens, for credentials that name no
+// token URI.
+const googleTokenURL = "https://oauth2.googleapis.com/token"
+
+// googleMetadataTimeout bounds the request to the metadata server, which
+// only answers on Google Cloud.
+const googleMetadataTimeout = 3 * time.Second
+
+// googleCredentials is an application default credentials file, as written
+// by "gcloud auth application-default login" or downloaded for a service
+// account.
+type googleCredentials struct {
+	Type string `json:"type"`
+
+	// authorized_user
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+
+	// service_account
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// googleTokens caches the access token for the process's credentials, which
+// are found once.
+var googleTokens struct {
+	sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// googleAccessToken returns an OAuth access token from Google application
+// default credentials, sending token requests through rt. It looks, in
+// order, at the file named by GOOGLE_APPLICATION_CREDENTIALS, the file
+// gcloud writes, and the metadata server of a Google Cloud machine.
+func googleAccessToken(ctx context.Context, rt http.RoundTripper) (string, error) {
+	googleTokens.Lock()
+	defer googleTokens.Unlock()
+	// Renew a minute early so a token does not expire in flight
+	if googleTokens.token != "" && now().Add(time.Minute).Before(googleTokens.expiry) {
+		return googleTokens.token, nil
+	}
+
+	client := &http.Client{Transport: rt}
+	var (
+		token   string
+		expires time.Duration
+		err     error
+	)
+	if path := googleCredentialsFile(); path != "" {
+		token, expires, err = googleFileToken(ctx, client, path)
+	} else {
+		token, expires, err = googleMetadataToken(ctx, client)
+		if err != nil {
+			err = fmt.Errorf("no Google credentials found (set GOOGLE_APPLICATION_CREDENTIALS or run gcloud auth application-default login): %w", err)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	googleTokens.token, googleTokens.expiry = token, now().Add(expires)
+	return token, nil
+}
+
+// googleCredentialsFile returns the credentials file to use, or "" if there
+// is none.
+func googleCredentialsFile() string {
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		return path
+	}
+	dir := os.Getenv("CLOUDSDK_CONFIG")
+	if dir == "" {
+		if runtime.GOOS == "windows" {
+			dir = filepath.Join(os.Getenv("APPDATA"), "gcloud")
+		} else if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, ".config", "gcloud")
+		}
+	}
+	path := filepath.Join(dir, "application_default_credentials.json")
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// googleFileToken gets a token for the credentials in a file.
+func googleFileToken(ctx context.Context, client *http.Client, path string) (string, time.Duration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read Google credentials: %w", err)
+	}
+	var c googleCredentials
+	if err := json.Unmarshal(data, &c); err != nil {
+		return "", 0, fmt.Errorf("%s: invalid Google credentials: %w", path, err)
+	}
+
+	form := url.Values{}
+	tokenURL := c.TokenURI
+	if tokenURL == "" {
+		tokenURL = googleTokenURL
+	}
+	switch c.Type {
+	case "authorized_user":
+		form.Set("grant_type", "refresh_token")
+		form.Set("client_id", c.ClientID)
+		form.Set("client_secret", c.ClientSecret)
+		form.Set("refresh_token", c.RefreshToken)
+	case "service_account":
+		assertion, err := c.assertion(tokenURL)
+		if err != nil {
+			return "", 0, fmt.Errorf("%s: %w", path, err)
+		}
+		form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+		form.Set("assertion", assertion)
+	default:
+		return "", 0, fmt.Errorf("%s: unsupported Google credentials type %q (use a service account key or gcloud auth application-default login)", path, c.Type)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return googleToken(client, req)
+}
+
+// assertion returns the signed JWT a service account exchanges for a token.
+func (c googleCredentials) assertion(audience string) (string, error) {
+	block, _ := pem.Decode([]byte(c.PrivateKey))
+	if block == nil {
+		return "", errors.New("service account has no PEM private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return "", fmt.Errorf("invalid service account private key: %w", err)
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", errors.New("service account private key is not RSA")
+	}
+
+	issued := now().Unix()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": c.PrivateKeyID})
+	claims, _ := json.Marshal(map[string]any{
+		"iss": c.ClientEmail, "scope": googleScope, "aud": audience,
+		"iat": issued, "exp": issued + 3600,
+	})
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token request: %w", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// googleMetadataToken gets a token for the service account of the Google
+// Cloud machine the process runs on. GCE_METADATA_HOST overrides the
+// server's address.
+func googleMetadataToken(ctx context.Context, client *http.Client) (string, time.Duration, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+	ctx, cancel := context.WithTimeout(ctx, googleMetadataTimeout)
+	defer cancel()
+	endpoint := "http://" + host + "/computeMetadata/v1/instance/service-accounts/default/token?scopes=" + url.QueryEscape(googleScope)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	return googleToken(client, req)
+}
+
+// googleToken sends a token request and reads the token and its lifetime.
+func googleToken(client *http.Client, req *http.Request) (string, time.Duration, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read token: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("token request failed: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var t struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &t); err != nil || t.AccessToken == "" {
+		return "", 0, fmt.Errorf("invalid token response: %s", strings.TrimSpace(string(body)))
+	}
+	return t.AccessToken, time.Duration(t.ExpiresIn) * time.Second, nil
+}
@@ -0,0 +1,230 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"charm.land/catwalk/pkg/anthropic"
+	"charm.land/catwalk/pkg/auth"
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/sashabaranov/go-openai"
+)
+
+// anthropicBackend uses Anthropic's Message Batches API, which takes the
+// requests inline and serves the results as JSONL from a results URL.
+type anthropicBackend struct {
+	endpoint  string
+	headers   map[string]string
+	client    *http.Client
+	maxTokens int
+}
+
+func newAnthropic(provider catwalk.Provider, model catwalk.Model, apiKey string, base http.RoundTripper) *anthropicBackend {
+	return &anthropicBackend{
+		endpoint:  provider.APIEndpoint,
+		headers:   provider.DefaultHeaders,
+		client:    &http.Client{Transport: auth.For(provider).Transport(apiKey, base)},
+		maxTokens: int(max(model.DefaultMaxTokens, 0)),
+	}
+}
+
+// anthropicBatch is a batch as the API describes it.
+type anthropicBatch struct {
+	ID               string `json:"id"`
+	ProcessingStatus string `json:"processing_status"`
+	RequestCounts    struct {
+		Processing int `json:"processing"`
+		Succeeded  int `json:"succeeded"`
+		Errored    int `json:"errored"`
+		Canceled   int `json:"canceled"`
+		Expired    int `json:"expired"`
+	} `json:"request_counts"`
+	ResultsURL string `json:"results_url"`
+}
+
+func (b anthropicBatch) status() (Status, bool) {
+	c := b.RequestCounts
+	failed := c.Errored + c.Canceled + c.Expired
+	return Status{
+		ID:        b.ID,
+		State:     b.ProcessingStatus,
+		Total:     c.Processing + c.Succeeded + failed,
+		Completed: c.Succeeded + failed,
+		Failed:    failed,
+	}, b.ProcessingStatus == "ended"
+}
+
+func (b *anthropicBackend) submit(ctx context.Context, reqs []openai.ChatCompletionRequest) (Status, error) {
+	type item struct {
+		CustomID string           `json:"custom_id"`
+		Params   anthropic.Params `json:"params"`
+	}
+	var body struct {
+		Requests []item `json:"requests"`
+	}
+	for i, r := range reqs {
+		params, err := anthropic.Request(r, b.maxTokens)
+		if err != nil {
+			return Status{}, fmt.Errorf("%s: %w", customID(i), err)
+		}
+		body.Requests = append(body.Requests, item{CustomID: customID(i), Params: params})
+	}
+
+	var batch anthropicBatch
+	if err := b.do(ctx, http.MethodPost, b.endpoint+"/messages/batches", body, &batch); err != nil {
+		return Status{}, err
+	}
+	s, _ := batch.status()
+	return s, nil
+}
+
+func (b *anthropicBackend) status(ctx context.Context, id string) (Status, bool, error) {
+	var batch anthropicBatch
+	if err := b.do(ctx, http.MethodGet, b.endpoint+"/messages/batches/"+id, nil, &batch); err != nil {
+		return Status{}, false, err
+	}
+	s, done := batch.status()
+	return s, done, nil
+}
+
+func (b *anthropicBackend) cancel(ctx context.Context, id string) error {
+	return b.do(ctx, http.MethodPost, b.endpoint+"/messages/batches/"+id+"/cancel", nil, nil)
+}
+
+// anthropicResultLine is one line of a batch's results.
+type anthropicResultLine struct {
+	CustomID string `json:"custom_id"`
+	Result   struct {
+		Type    string `json:"type"`
+		Message struct {
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+			Usage struct {
+				InputTokens  int `json:"input_tokens"`
+				OutputTokens int `json:"output_tokens"`
+			} `json:"usage"`
+		} `json:"message"`
+		Error struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"error"`
+	} `json:"result"`
+}
+
+func (b *anthropicBackend) results(ctx context.Context, id string) (map[string]Result, error) {
+	var batch anthropicBatch
+	if err := b.do(ctx, http.MethodGet, b.endpoint+"/messages/batches/"+id, nil, &batch); err != nil {
+		return nil, err
+	}
+	if batch.ResultsURL == "" {
+		return nil, fmt.Errorf("batch %s has no results (%s)", id, batch.ProcessingStatus)
+	}
+
+	resp, err := b.send(ctx, http.MethodGet, batch.ResultsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	results := map[string]Result{}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var l anthropicResultLine
+		if err := json.Unmarshal(line, &l); err != nil {
+			return nil, fmt.Errorf("invalid result line: %w", err)
+		}
+
+		var r Result
+		switch l.Result.Type {
+		case "succeeded":
+			var text strings.Builder
+			for _, c := range l.Result.Message.Content {
+				if c.Type == "text" {
+					text.WriteString(c.Text)
+				}
+			}
+			r.Content = text.String()
+			r.InputTokens = l.Result.Message.Usage.InputTokens
+			r.OutputTokens = l.Result.Message.Usage.OutputTokens
+		case "errored":
+			r.Error = l.Result.Error.Error.Message
+			if r.Error == "" {
+				r.Error = "request failed"
+			}
+		default:
+			r.Error = "request " + l.Result.Type
+		}
+		results[l.CustomID] = r
+	}
+	return results, scanner.Err() //nolint:wrapcheck
+}
+
+// do sends a JSON request and decodes the JSON reply into out, if not nil.
+func (b *anthropicBackend) do(ctx context.Context, method, url string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	resp, err := b.send(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// send makes a request, returning an error for a non-2xx status.
+func (b *anthropicBackend) send(ctx context.Context, method, url string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range b.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close() //nolint:errcheck
+		var e struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		msg := resp.Status
+		if data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16)); json.Unmarshal(data, &e) == nil && e.Error.Message != "" {
+			msg += ": " + e.Error.Message
+		}
+		return nil, fmt.Errorf("%s %s: %s", method, req.URL.Path, msg)
+	}
+	return resp, nil
+}
@@ -0,0 +1,201 @@
+// Package batch sends many chat requests at once through a provider's
+// asynchronous Batch API. Batches trade latency for price: results arrive
+// within 24 hours, usually much sooner, and cost half the synchronous rate.
+//
+// OpenAI's Batch API and Anthropic's Message Batches API are supported; use
+// [Supports] to check a provider and send requests synchronously otherwise.
+// Requests to Anthropic are converted by pkg/anthropic, which takes text
+// only.
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Discount is the fraction of catalog prices that batched requests cost.
+const Discount = 0.5
+
+// DefaultPollInterval is how often a batch's status is checked when
+// Client.PollInterval is zero.
+const DefaultPollInterval = 30 * time.Second
+
+// ErrUnsupported is returned for providers without a batch API.
+var ErrUnsupported = errors.New("provider has no batch API")
+
+// Default endpoints for providers whose catalog endpoint is an unset
+// environment variable.
+var defaultEndpoints = map[catwalk.InferenceProvider]string{
+	catwalk.InferenceProviderOpenAI:    "https://api.openai.com/v1",
+	catwalk.InferenceProviderAnthropic: "https://api.anthropic.com/v1",
+}
+
+// Supports reports whether requests to provider p can be batched. Only the
+// first-party OpenAI and Anthropic APIs offer batches; providers that merely
+// share their API type do not.
+func Supports(p catwalk.Provider) bool {
+	_, ok := defaultEndpoints[p.ID]
+	return ok
+}
+
+// Result is the outcome of one batched request.
+type Result struct {
+	Content      string
+	InputTokens  int
+	OutputTokens int
+
+	// Cost is at catalog prices with [Discount] applied.
+	Cost float64
+
+	// Error is set instead of Content if the request failed, expired, or
+	// was cancelled.
+	Error string
+}
+
+// Status is the progress of a batch.
+type Status struct {
+	ID string
+
+	// State is the provider's name for the batch's state, such as
+	// "in_progress".
+	State string
+
+	Total     int
+	Completed int // including Failed
+	Failed    int
+}
+
+// Client runs batches of requests on one model.
+type Client struct {
+	// PollInterval is how often the batch's status is checked. Zero means
+	// DefaultPollInterval.
+	PollInterval time.Duration
+
+	// OnStatus, if set, is called with the status after submission and
+	// each poll.
+	OnStatus func(Status)
+
+	model   catwalk.Model
+	backend backend
+}
+
+// backend is one provider's batch API. Requests are identified by their
+// position, as a custom ID both APIs accept.
+type backend interface {
+	submit(ctx context.Context, reqs []openai.ChatCompletionRequest) (Status, error)
+	status(ctx context.Context, id string) (Status, bool, error)
+	results(ctx context.Context, id string) (map[string]Result, error)
+	cancel(ctx context.Context, id string) error
+}
+
+// NewClient returns a client batching requests to model on provider, with
+// the key sent the way the provider expects. It returns [ErrUnsupported] if
+// the provider has no batch API.
+func NewClient(provider catwalk.Provider, model catwalk.Model, apiKey string, base http.RoundTripper) (*Client, error) {
+	if !Supports(provider) {
+		return nil, fmt.Errorf("%s: %w", provider.Name, ErrUnsupported)
+	}
+	provider.APIEndpoint = endpoint(provider)
+
+	c := &Client{model: model}
+	switch provider.ID {
+	case catwalk.InferenceProviderAnthropic:
+		c.backend = newAnthropic(provider, model, apiKey, base)
+	default:
+		c.backend = &openAIBackend{client: chat.NewClient(provider, apiKey, base)}
+	}
+	return c, nil
+}
+
+// endpoint returns the provider's API endpoint, expanding an environment
+// variable reference and falling back to the provider's default.
+func endpoint(p catwalk.Provider) string {
+	e := p.APIEndpoint
+	if name, ok := strings.CutPrefix(e, "$"); ok {
+		e = os.Getenv(name)
+	}
+	if e == "" {
+		e = defaultEndpoints[p.ID]
+	}
+	return strings.TrimSuffix(e, "/")
+}
+
+// customID names the request at index i in a batch.
+func customID(i int) string {
+	return fmt.Sprintf("request-%d", i)
+}
+
+// Run submits reqs as one batch, waits for it to finish, and returns a
+// result for each request, in order. The model of each request is set to the
+// client's. If ctx is cancelled while waiting, the batch is cancelled too.
+func (c *Client) Run(ctx context.Context, reqs []openai.ChatCompletionRequest) ([]Result, error) {
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+	reqs = append([]openai.ChatCompletionRequest(nil), reqs...)
+	for i := range reqs {
+		reqs[i].Model = c.model.ID
+		reqs[i].Stream = false
+		reqs[i].StreamOptions = nil
+	}
+
+	status, err := c.backend.submit(ctx, reqs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit batch: %w", err)
+	}
+	c.report(status)
+
+	interval := c.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	for done := false; !done; {
+		select {
+		case <-ctx.Done():
+			// The provider would otherwise keep running, and billing, it
+			cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+			defer cancel()
+			if err := c.backend.cancel(cancelCtx, status.ID); err != nil {
+				return nil, fmt.Errorf("batch %s interrupted, and cancelling it failed: %w", status.ID, err)
+			}
+			return nil, fmt.Errorf("batch %s cancelled: %w", status.ID, ctx.Err())
+		case <-time.After(interval):
+		}
+		if status, done, err = c.backend.status(ctx, status.ID); err != nil {
+			return nil, fmt.Errorf("failed to check batch: %w", err)
+		}
+		c.report(status)
+	}
+
+	byID, err := c.backend.results(ctx, status.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download batch results: %w", err)
+	}
+	results := make([]Result, len(reqs))
+	for i := range results {
+		r, ok := byID[customID(i)]
+		if !ok {
+			r.Error = "no result in batch " + status.ID + " (" + status.State + ")"
+		}
+		if r.Error == "" {
+			r.Cost = chat.Cost(c.model, r.InputTokens, r.OutputTokens) * Discount
+		}
+		results[i] = r
+	}
+	return results, nil
+}
+
+func (c *Client) report(s Status) {
+	if c.OnStatus != nil {
+		c.OnStatus(s)
+	}
+}
@@ -0,0 +1,242 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"charm.land/catwalk/pkg/anthropic"
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/sashabaranov/go-openai"
+)
+
+var model = catwalk.Model{ID: "m", CostPer1MIn: 2, CostPer1MOut: 8}
+
+func requests(prompts ...string) []openai.ChatCompletionRequest {
+	reqs := make([]openai.ChatCompletionRequest, len(prompts))
+	for i, p := range prompts {
+		reqs[i] = openai.ChatCompletionRequest{Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "Be brief."},
+			{Role: openai.ChatMessageRoleUser, Content: p},
+		}}
+	}
+	return reqs
+}
+
+func TestSupports(t *testing.T) {
+	for _, tt := range []struct {
+		provider catwalk.Provider
+		want     bool
+	}{
+		{catwalk.Provider{ID: "openai", Type: catwalk.TypeOpenAI}, true},
+		{catwalk.Provider{ID: "anthropic", Type: catwalk.TypeAnthropic}, true},
+		{catwalk.Provider{ID: "minimax", Type: catwalk.TypeAnthropic}, false},
+		{catwalk.Provider{ID: "groq", Type: catwalk.TypeOpenAICompat}, false},
+	} {
+		if got := Supports(tt.provider); got != tt.want {
+			t.Errorf("Supports(%s) = %v, want %v", tt.provider.ID, got, tt.want)
+		}
+	}
+	if _, err := NewClient(catwalk.Provider{ID: "groq"}, model, "k", nil); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("NewClient(groq) error = %v", err)
+	}
+}
+
+// fakeOpenAI serves the files and batches endpoints of OpenAI's API. The
+// batch completes on the second status check.
+func fakeOpenAI(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	var input []string
+	checks := 0
+	batch := func(status string) map[string]any {
+		b := map[string]any{"id": "batch_1", "status": status, "request_counts": map[string]int{"total": len(input), "completed": 0}}
+		if status == "completed" {
+			b["output_file_id"] = "file-out"
+			b["error_file_id"] = "file-err"
+			b["request_counts"] = map[string]int{"total": len(input), "completed": len(input) - 1, "failed": 1}
+		}
+		return b
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, `{"error":{"message":"bad key"}}`, http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/files":
+			f, _, err := r.FormFile("file")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			data, _ := io.ReadAll(f)
+			input = strings.Split(strings.TrimSpace(string(data)), "\n")
+			json.NewEncoder(w).Encode(map[string]any{"id": "file-in", "purpose": "batch"}) //nolint:errcheck
+		case r.Method == http.MethodPost && r.URL.Path == "/batches":
+			json.NewEncoder(w).Encode(batch("validating")) //nolint:errcheck
+		case r.URL.Path == "/batches/batch_1":
+			checks++
+			status := "in_progress"
+			if checks > 1 {
+				status = "completed"
+			}
+			json.NewEncoder(w).Encode(batch(status)) //nolint:errcheck
+		case r.URL.Path == "/files/file-out/content":
+			// Every request succeeds but the last, echoing its prompt
+			for _, line := range input[:len(input)-1] {
+				var in struct {
+					CustomID string                       `json:"custom_id"`
+					Body     openai.ChatCompletionRequest `json:"body"`
+				}
+				if err := json.Unmarshal([]byte(line), &in); err != nil {
+					t.Error(err)
+				}
+				body := map[string]any{
+					"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": in.Body.Model + ": " + in.Body.Messages[1].Content}}},
+					"usage":   map[string]int{"prompt_tokens": 1000, "completion_tokens": 500},
+				}
+				json.NewEncoder(w).Encode(map[string]any{"custom_id": in.CustomID, "response": map[string]any{"status_code": 200, "body": body}}) //nolint:errcheck
+			}
+		case r.URL.Path == "/files/file-err/content":
+			fmt.Fprintf(w, `{"custom_id":"request-%d","response":{"status_code":400,"body":{"error":{"message":"too long"}}},"error":null}`+"\n", len(input)-1)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestRunOpenAI(t *testing.T) {
+	srv := fakeOpenAI(t)
+	defer srv.Close()
+
+	c, err := NewClient(catwalk.Provider{ID: "openai", APIEndpoint: srv.URL}, model, "key", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.PollInterval = time.Millisecond
+	var statuses []string
+	c.OnStatus = func(s Status) { statuses = append(statuses, s.State) }
+
+	results, err := c.Run(context.Background(), requests("one", "two", "three"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(statuses, ","); got != "validating,in_progress,completed" {
+		t.Errorf("statuses = %s", got)
+	}
+	if len(results) != 3 || results[0].Content != "m: one" || results[1].Content != "m: two" {
+		t.Fatalf("results = %+v", results)
+	}
+	// 1000 in at $2/M and 500 out at $8/M is $0.006, halved
+	if math.Abs(results[0].Cost-0.003) > 1e-9 || results[0].InputTokens != 1000 || results[0].OutputTokens != 500 {
+		t.Errorf("result = %+v", results[0])
+	}
+	if results[2].Error != "status 400: too long" || results[2].Cost != 0 {
+		t.Errorf("failed result = %+v", results[2])
+	}
+}
+
+// fakeAnthropic serves Anthropic's Message Batches API. The batch ends on the
+// first status check; cancelled batches end immediately.
+func fakeAnthropic(t *testing.T, results func(custom []string) string) (*httptest.Server, *[]string) {
+	var mu sync.Mutex
+	var custom []string
+	var calls []string
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		if r.Header.Get("x-api-key") != "key" || r.Header.Get("anthropic-version") == "" {
+			http.Error(w, `{"error":{"message":"bad key"}}`, http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/messages/batches":
+			var body struct {
+				Requests []struct {
+					CustomID string           `json:"custom_id"`
+					Params   anthropic.Params `json:"params"`
+				} `json:"requests"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Error(err)
+			}
+			for _, req := range body.Requests {
+				p := req.Params
+				if p.Model != "m" || p.System != "Be brief." || p.MaxTokens != 4096 || len(p.Messages) != 1 {
+					t.Errorf("params = %+v", p)
+				}
+				custom = append(custom, req.CustomID)
+			}
+			fmt.Fprintf(w, `{"id":"msgbatch_1","processing_status":"in_progress","request_counts":{"processing":%d}}`, len(custom))
+		case r.URL.Path == "/v1/messages/batches/msgbatch_1":
+			fmt.Fprintf(w, `{"id":"msgbatch_1","processing_status":"ended","request_counts":{"succeeded":%d},"results_url":"%s/results"}`, len(custom), srv.URL)
+		case r.URL.Path == "/v1/messages/batches/msgbatch_1/cancel":
+			fmt.Fprint(w, `{"id":"msgbatch_1","processing_status":"canceling"}`)
+		case r.URL.Path == "/results":
+			fmt.Fprint(w, results(custom))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return srv, &calls
+}
+
+func TestRunAnthropic(t *testing.T) {
+	srv, _ := fakeAnthropic(t, func(custom []string) string {
+		return fmt.Sprintf(`{"custom_id":%q,"result":{"type":"errored","error":{"type":"error","error":{"type":"invalid_request_error","message":"bad prompt"}}}}
+{"custom_id":%q,"result":{"type":"succeeded","message":{"content":[{"type":"text","text":"hel"},{"type":"text","text":"lo"}],"usage":{"input_tokens":2000,"output_tokens":1000}}}}
+`, custom[1], custom[0])
+	})
+	defer srv.Close()
+
+	provider := catwalk.Provider{ID: "anthropic", Type: catwalk.TypeAnthropic, APIEndpoint: srv.URL + "/v1/"}
+	c, err := NewClient(provider, model, "key", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.PollInterval = time.Millisecond
+
+	results, err := c.Run(context.Background(), requests("a", "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Content != "hello" || math.Abs(results[0].Cost-0.006) > 1e-9 {
+		t.Errorf("result = %+v", results[0])
+	}
+	if results[1].Error != "bad prompt" {
+		t.Errorf("failed result = %+v", results[1])
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	srv, calls := fakeAnthropic(t, func([]string) string { return "" })
+	defer srv.Close()
+
+	provider := catwalk.Provider{ID: "anthropic", Type: catwalk.TypeAnthropic, APIEndpoint: srv.URL + "/v1"}
+	c, err := NewClient(provider, model, "key", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.OnStatus = func(Status) { cancel() }
+
+	if _, err := c.Run(ctx, requests("a")); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run error = %v", err)
+	}
+	if got := (*calls)[len(*calls)-1]; got != "POST /v1/messages/batches/msgbatch_1/cancel" {
+		t.Errorf("last call = %s", got)
+	}
+}
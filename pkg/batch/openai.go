@@ -0,0 +1,159 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// openAIBackend uses OpenAI's Batch API: the requests are uploaded as a
+// JSONL file, and the results downloaded as output and error files.
+type openAIBackend struct {
+	client *openai.Client
+}
+
+func (b *openAIBackend) submit(ctx context.Context, reqs []openai.ChatCompletionRequest) (Status, error) {
+	var upload openai.CreateBatchWithUploadFileRequest
+	upload.Endpoint = openai.BatchEndpointChatCompletions
+	upload.FileName = "batch.jsonl"
+	for i, r := range reqs {
+		upload.AddChatCompletion(customID(i), r)
+	}
+	resp, err := b.client.CreateBatchWithUploadFile(ctx, upload)
+	if err != nil {
+		return Status{}, err //nolint:wrapcheck
+	}
+	s, _ := openAIStatus(resp.Batch)
+	return s, nil
+}
+
+func (b *openAIBackend) status(ctx context.Context, id string) (Status, bool, error) {
+	resp, err := b.client.RetrieveBatch(ctx, id)
+	if err != nil {
+		return Status{}, false, err //nolint:wrapcheck
+	}
+	s, done := openAIStatus(resp.Batch)
+	if resp.Status == "failed" {
+		return s, true, fmt.Errorf("batch %s failed: %s", id, openAIBatchErrors(resp.Batch))
+	}
+	return s, done, nil
+}
+
+func openAIStatus(b openai.Batch) (Status, bool) {
+	s := Status{
+		ID:        b.ID,
+		State:     b.Status,
+		Total:     b.RequestCounts.Total,
+		Completed: b.RequestCounts.Completed + b.RequestCounts.Failed,
+		Failed:    b.RequestCounts.Failed,
+	}
+	switch b.Status {
+	case "completed", "failed", "expired", "cancelled":
+		return s, true
+	}
+	return s, false
+}
+
+func openAIBatchErrors(b openai.Batch) string {
+	if b.Errors == nil || len(b.Errors.Data) == 0 {
+		return "no reason given"
+	}
+	msgs := make([]string, len(b.Errors.Data))
+	for i, e := range b.Errors.Data {
+		msgs[i] = e.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (b *openAIBackend) results(ctx context.Context, id string) (map[string]Result, error) {
+	resp, err := b.client.RetrieveBatch(ctx, id)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	results := map[string]Result{}
+	for _, file := range []*string{resp.OutputFileID, resp.ErrorFileID} {
+		if file == nil || *file == "" {
+			continue
+		}
+		if err := b.download(ctx, *file, results); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// openAIResultLine is one line of a batch output or error file.
+type openAIResultLine struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// download reads a result file into results.
+func (b *openAIBackend) download(ctx context.Context, file string, results map[string]Result) error {
+	content, err := b.client.GetFileContent(ctx, file)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	defer content.Close() //nolint:errcheck
+
+	scanner := bufio.NewScanner(content)
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var l openAIResultLine
+		if err := json.Unmarshal(line, &l); err != nil {
+			return fmt.Errorf("invalid result line: %w", err)
+		}
+		results[l.CustomID] = openAIResult(l)
+	}
+	return scanner.Err() //nolint:wrapcheck
+}
+
+func openAIResult(l openAIResultLine) Result {
+	switch {
+	case l.Error != nil:
+		return Result{Error: l.Error.Message}
+	case l.Response == nil:
+		return Result{Error: "empty response"}
+	case l.Response.StatusCode != 200:
+		var body struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		msg := fmt.Sprintf("status %d", l.Response.StatusCode)
+		if json.Unmarshal(l.Response.Body, &body) == nil && body.Error.Message != "" {
+			msg += ": " + body.Error.Message
+		}
+		return Result{Error: msg}
+	}
+
+	var body openai.ChatCompletionResponse
+	if err := json.Unmarshal(l.Response.Body, &body); err != nil {
+		return Result{Error: "invalid response: " + err.Error()}
+	}
+	r := Result{InputTokens: body.Usage.PromptTokens, OutputTokens: body.Usage.CompletionTokens}
+	if len(body.Choices) > 0 {
+		r.Content = body.Choices[0].Message.Content
+	}
+	return r
+}
+
+func (b *openAIBackend) cancel(ctx context.Context, id string) error {
+	_, err := b.client.CancelBatch(ctx, id)
+	return err //nolint:wrapcheck
+}
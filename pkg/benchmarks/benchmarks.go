@@ -0,0 +1,174 @@
+// Package benchmarks loads third-party benchmark scores for models so they
+// can be merged with catwalk catalog data.
+package benchmarks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/selector"
+)
+
+// Scores holds benchmark results for a single model, as percentages in the
+// range 0-100. A nil score means the benchmark has not been reported.
+// LatencyTier, if set, is the model's measured latency tier.
+type Scores struct {
+	MMLU     *float64 `json:"mmlu,omitempty"`
+	GPQA     *float64 `json:"gpqa,omitempty"`
+	SWEBench *float64 `json:"swe_bench,omitempty"`
+
+	LatencyTier selector.LatencyTier `json:"latency_tier,omitempty"`
+}
+
+// Benchmarks names the benchmarks Scores holds, in order.
+var Benchmarks = []string{"MMLU", "GPQA", "SWE-bench"}
+
+// list returns the scores in the order of [Benchmarks].
+func (s Scores) list() []*float64 {
+	return []*float64{s.MMLU, s.GPQA, s.SWEBench}
+}
+
+// Quality is a model's standing on the benchmarks it reports.
+type Quality struct {
+	// Score is the mean of the model's percentile ranks, 0-100, among the
+	// dataset's models on each benchmark it reports.
+	Score float64
+	// Benchmarks is how many benchmarks Score rests on, out of
+	// len([Benchmarks]).
+	Benchmarks int
+}
+
+// String formats q as "72.5 (2/3)": the score and how many benchmarks it
+// rests on.
+func (q Quality) String() string {
+	return fmt.Sprintf("%.1f (%d/%d)", q.Score, q.Benchmarks, len(Benchmarks))
+}
+
+// Dataset maps model IDs to their benchmark scores.
+type Dataset map[string]Scores
+
+// Load reads a dataset from a local file or an http(s) URL.
+func Load(ctx context.Context, source string) (Dataset, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, fmt.Errorf("could not create request: %w", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch benchmarks: %w", err)
+		}
+		defer resp.Body.Close() //nolint:errcheck
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+		return Parse(resp.Body)
+	}
+
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open benchmarks: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+	return Parse(f)
+}
+
+// Parse decodes a dataset from JSON of the form
+// {"gpt-4o": {"mmlu": 88.7, "gpqa": 53.6, "latency_tier": "standard"}, ...}.
+func Parse(r io.Reader) (Dataset, error) {
+	var raw map[string]Scores
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode benchmarks: %w", err)
+	}
+	d := make(Dataset, len(raw))
+	for id, s := range raw {
+		if s.LatencyTier != "" {
+			t, err := selector.ParseLatencyTier(string(s.LatencyTier))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", id, err)
+			}
+			s.LatencyTier = t
+		}
+		d[normalizeID(id)] = s
+	}
+	return d, nil
+}
+
+// Quality returns a model's quality, or false if it has no scores.
+//
+// Benchmarks differ in difficulty: 60 on GPQA is a better result than 80
+// on MMLU. So each score is ranked among the dataset's models that report
+// the same benchmark, from 0 for the lowest to 100 for the highest, and
+// the ranks are averaged. A mean of raw scores would rate models that
+// report only the easier benchmarks above those that report them all.
+func (d Dataset) Quality(modelID string) (Quality, bool) {
+	s, ok := d.Lookup(modelID)
+	if !ok {
+		return Quality{}, false
+	}
+	var q Quality
+	for i, v := range s.list() {
+		if v == nil {
+			continue
+		}
+		var below, equal, n int
+		for _, other := range d {
+			o := other.list()[i]
+			switch {
+			case o == nil:
+				continue
+			case *o < *v:
+				below++
+			case *o == *v:
+				equal++
+			}
+			n++
+		}
+		rank := 50.0
+		if n > 1 {
+			// Ties share the middle of the ranks they span
+			rank = (float64(below) + float64(equal-1)/2) / float64(n-1) * 100
+		}
+		q.Score += rank
+		q.Benchmarks++
+	}
+	if q.Benchmarks == 0 {
+		return Quality{}, false
+	}
+	q.Score /= float64(q.Benchmarks)
+	return q, true
+}
+
+// Lookup returns the scores for a model ID. IDs are matched
+// case-insensitively, and an aggregator prefix such as "openai/" is ignored
+// when the full ID has no entry.
+func (d Dataset) Lookup(modelID string) (Scores, bool) {
+	id := normalizeID(modelID)
+	if s, ok := d[id]; ok {
+		return s, true
+	}
+	if _, rest, ok := strings.Cut(id, "/"); ok {
+		s, ok := d[rest]
+		return s, ok
+	}
+	return Scores{}, false
+}
+
+// CostPerQualityPoint returns the blended price per 1M tokens (the mean of
+// input and output prices) divided by the quality score.
+func CostPerQualityPoint(m catwalk.Model, quality float64) float64 {
+	if quality <= 0 {
+		return 0
+	}
+	return (m.CostPer1MIn + m.CostPer1MOut) / 2 / quality
+}
+
+func normalizeID(id string) string {
+	return strings.ToLower(strings.TrimSpace(id))
+}
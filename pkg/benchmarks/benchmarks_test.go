@@ -0,0 +1,82 @@
+package benchmarks
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"charm.land/catwalk/pkg/selector"
+)
+
+func TestLookup(t *testing.T) {
+	d, err := Parse(strings.NewReader(`{
+		"GPT-4o": {"mmlu": 88, "gpqa": 52},
+		"claude-sonnet-4": {"swe_bench": 72, "latency_tier": "Standard"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"gpt-4o", "openai/gpt-4o", "anthropic/claude-sonnet-4"} {
+		if _, ok := d.Lookup(id); !ok {
+			t.Errorf("expected scores for %q", id)
+		}
+	}
+	if _, ok := d.Lookup("gpt-5"); ok {
+		t.Error("expected no scores for gpt-5")
+	}
+
+	if s, _ := d.Lookup("claude-sonnet-4"); s.LatencyTier != selector.LatencyStandard {
+		t.Errorf("expected latency tier standard, got %q", s.LatencyTier)
+	}
+
+	if _, err := Parse(strings.NewReader(`{"m": {"latency_tier": "slow"}}`)); err == nil {
+		t.Error("expected an error for an unknown latency tier")
+	}
+}
+
+func TestQuality(t *testing.T) {
+	// mmlu-only has the best raw mean but the lowest MMLU score of the
+	// models that report it
+	d, err := Parse(strings.NewReader(`{
+		"all-three": {"mmlu": 90, "gpqa": 60, "swe_bench": 50},
+		"two": {"mmlu": 88, "gpqa": 50},
+		"mmlu-only": {"mmlu": 86},
+		"tied": {"mmlu": 88, "swe_bench": 40},
+		"swe-only": {"swe_bench": 70},
+		"no-scores": {"latency_tier": "fast"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id   string
+		want Quality
+	}{
+		// MMLU ranks 86 < 88 = 88 < 90 as 0, 50, 50, 100; GPQA 50 < 60 as
+		// 0, 100; SWE-bench 40 < 50 < 70 as 0, 50, 100
+		{"all-three", Quality{Score: (100 + 100 + 50) / 3.0, Benchmarks: 3}},
+		{"two", Quality{Score: (50 + 0) / 2.0, Benchmarks: 2}},
+		{"mmlu-only", Quality{Score: 0, Benchmarks: 1}},
+		{"tied", Quality{Score: (50 + 0) / 2.0, Benchmarks: 2}},
+		{"openrouter/swe-only", Quality{Score: 100, Benchmarks: 1}},
+	}
+	for _, tt := range tests {
+		got, ok := d.Quality(tt.id)
+		if !ok || math.Abs(got.Score-tt.want.Score) > 1e-9 || got.Benchmarks != tt.want.Benchmarks {
+			t.Errorf("Quality(%s) = %+v, %v, want %+v", tt.id, got, ok, tt.want)
+		}
+	}
+	for _, id := range []string{"no-scores", "gpt-5"} {
+		if q, ok := d.Quality(id); ok {
+			t.Errorf("Quality(%s) = %+v, want none", id, q)
+		}
+	}
+
+	// A benchmark only one model reports puts it in the middle
+	one, _ := Parse(strings.NewReader(`{"m": {"gpqa": 10}}`))
+	if q, _ := one.Quality("m"); q.Score != 50 || q.String() != "50.0 (1/3)" {
+		t.Errorf("Quality of the only model = %v", q)
+	}
+}
@@ -0,0 +1,336 @@
+// Package budget enforces rolling spend caps, such as $20 a day for one
+// project's tag or $500 a month overall. Spend is computed from a ledger of
+// transcript entries, so caps survive restarts when the ledger is the
+// transcript file requests are logged to. A tracker can be put in front of
+// sessions as chat middleware, and serves an admin handler to inspect and
+// reset its budgets over HTTP.
+//
+// A [QuotaTracker] counts requests and tokens per provider the same way,
+// against quotas such as a free tier's daily caps.
+package budget
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/tokenizer"
+	"charm.land/catwalk/pkg/transcript"
+)
+
+// Period is how often a budget starts over.
+type Period string
+
+// Budget periods. Days and months begin at local midnight.
+const (
+	Daily   Period = "daily"
+	Monthly Period = "monthly"
+)
+
+// Start returns the beginning of the period that contains t.
+func (p Period) Start(t time.Time) time.Time {
+	y, m, d := t.Date()
+	if p == Monthly {
+		d = 1
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// Rule caps spend over a period.
+type Rule struct {
+	// Name identifies the rule in errors and the admin handler.
+	Name string `json:"name" yaml:"name"`
+
+	// Tag limits the rule to requests with this tag; empty applies it to
+	// every request.
+	Tag string `json:"tag,omitempty" yaml:"tag,omitempty"`
+
+	Period Period `json:"period" yaml:"period"`
+
+	// Limit is the most the rule's requests may spend per period, in USD.
+	Limit float64 `json:"limit" yaml:"limit"`
+}
+
+// matches reports whether the rule applies to a request with tags.
+func (r Rule) matches(tags []string) bool {
+	return r.Tag == "" || slices.Contains(tags, r.Tag)
+}
+
+// Status is a rule's spend in its current period. Reserved is the
+// estimated cost of requests in flight, already taken off Remaining.
+type Status struct {
+	Rule
+	Spent     float64   `json:"spent"`
+	Reserved  float64   `json:"reserved,omitempty"`
+	Remaining float64   `json:"remaining"`
+	Since     time.Time `json:"since"`
+}
+
+// spend is the part of a ledger entry the tracker needs.
+type spend struct {
+	at   time.Time
+	cost float64
+	tags []string
+}
+
+// Tracker checks requests against rules. It is safe for concurrent use.
+type Tracker struct {
+	rules []Rule
+
+	mu     sync.Mutex
+	spends []spend
+	// held holds the estimated costs of requests in flight; see
+	// [Tracker.Reserve].
+	held map[*Reservation]spend
+	// resets holds when each rule was last reset; spend before it is not
+	// counted until the period starts over.
+	resets map[string]time.Time
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// New returns a tracker for rules that counts the spend already in ledger.
+// Rule names must be unique, periods daily or monthly, and limits positive.
+func New(rules []Rule, ledger []transcript.Entry) (*Tracker, error) {
+	return newTracker(rules, ledger, time.Now)
+}
+
+func newTracker(rules []Rule, ledger []transcript.Entry, now func() time.Time) (*Tracker, error) {
+	seen := map[string]bool{}
+	for _, r := range rules {
+		switch {
+		case r.Name == "":
+			return nil, errors.New("budget rule without a name")
+		case seen[r.Name]:
+			return nil, fmt.Errorf("budget rule %s is defined twice", r.Name)
+		case r.Period != Daily && r.Period != Monthly:
+			return nil, fmt.Errorf("budget rule %s: unknown period %q (use daily or monthly)", r.Name, r.Period)
+		case r.Limit <= 0:
+			return nil, fmt.Errorf("budget rule %s: limit must be positive", r.Name)
+		}
+		seen[r.Name] = true
+	}
+
+	t := &Tracker{rules: slices.Clone(rules), held: map[*Reservation]spend{}, resets: map[string]time.Time{}, now: now}
+	for _, e := range ledger {
+		t.add(spend{at: e.Time, cost: e.Cost, tags: e.Tags})
+	}
+	return t, nil
+}
+
+// Record adds a request's cost to the tracker.
+func (t *Tracker) Record(e transcript.Entry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.add(spend{at: e.Time, cost: e.Cost, tags: e.Tags})
+}
+
+// add keeps a spend made this month, the longest period. The caller holds
+// t.mu, or has the only reference to t.
+func (t *Tracker) add(s spend) {
+	if s.cost <= 0 || s.at.Before(Monthly.Start(t.now())) {
+		return
+	}
+	t.spends = append(t.spends, s)
+}
+
+// Check returns a *catwalk.OverBudgetError for the first rule that applies
+// to a request with tags and whose budget is spent, counting the costs
+// reserved for requests in flight, or nil.
+func (t *Tracker) Check(tags []string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.check(tags, t.now())
+}
+
+// check is Check with t.mu held.
+func (t *Tracker) check(tags []string, now time.Time) error {
+	for _, r := range t.rules {
+		if !r.matches(tags) {
+			continue
+		}
+		if spent, reserved, _ := t.spent(r, now); spent+reserved >= r.Limit {
+			return &catwalk.OverBudgetError{Spent: spent + reserved, Budget: r.Limit, Rule: r.Name, Period: string(r.Period)}
+		}
+	}
+	return nil
+}
+
+// Reservation is the estimated cost of a request in flight, counted
+// against the budgets that apply to it until the request is recorded.
+type Reservation struct {
+	t *Tracker
+}
+
+// Reserve checks a request with tags as [Tracker.Check] does and, if it
+// may go ahead, holds estimate against its budgets until
+// [Reservation.Record]. Checking and reserving under one lock keeps
+// concurrent requests from all passing a check that only one of them
+// should: each sees what the others are expected to spend.
+//
+// Budgets are soft caps. A request is refused only once its budgets are
+// spent, so the last one let through may overshoot a limit by what it costs
+// beyond its estimate, or by its whole cost if it has no estimate.
+func (t *Tracker) Reserve(tags []string, estimate float64) (*Reservation, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	if err := t.check(tags, now); err != nil {
+		return nil, err
+	}
+	res := &Reservation{t: t}
+	t.held[res] = spend{at: now, cost: estimate, tags: tags}
+	return res, nil
+}
+
+// Record replaces the reservation with the request's actual cost, in e.
+// A request that failed without consuming tokens is recorded with a zero
+// cost, which releases the reservation. Recording a reservation again
+// does nothing.
+func (r *Reservation) Record(e transcript.Entry) {
+	r.t.mu.Lock()
+	defer r.t.mu.Unlock()
+	if _, ok := r.t.held[r]; !ok {
+		return
+	}
+	delete(r.t.held, r)
+	r.t.add(spend{at: e.Time, cost: e.Cost, tags: e.Tags})
+}
+
+// spent returns what r's requests spent in the current period and what is
+// reserved for those in flight, and since when. The caller holds t.mu.
+func (t *Tracker) spent(r Rule, now time.Time) (spent, reserved float64, since time.Time) {
+	since = r.Period.Start(now)
+	if reset := t.resets[r.Name]; reset.After(since) {
+		since = reset
+	}
+	for _, s := range t.spends {
+		if !s.at.Before(since) && r.matches(s.tags) {
+			spent += s.cost
+		}
+	}
+	for _, s := range t.held {
+		if !s.at.Before(since) && r.matches(s.tags) {
+			reserved += s.cost
+		}
+	}
+	return spent, reserved, since
+}
+
+// Status returns every rule's spend in its current period, in rule order.
+func (t *Tracker) Status() []Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	out := make([]Status, 0, len(t.rules))
+	for _, r := range t.rules {
+		spent, reserved, since := t.spent(r, now)
+		out = append(out, Status{Rule: r, Spent: spent, Reserved: reserved, Remaining: max(r.Limit-spent-reserved, 0), Since: since})
+	}
+	return out
+}
+
+// Reset starts a rule's budget over now, until its period starts over
+// anyway. The ledger is not changed.
+func (t *Tracker) Reset(name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, r := range t.rules {
+		if r.Name == name {
+			t.resets[name] = t.now()
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown budget rule: %s", name)
+}
+
+// Middleware returns chat middleware that fails requests over a budget
+// that applies to tags and records the cost of the others, including
+// failed requests that consumed tokens. Each request reserves its
+// estimated cost while in flight, as [Tracker.Reserve] describes.
+func (t *Tracker) Middleware(tags ...string) chat.Middleware {
+	return func(next chat.Handler) chat.Handler {
+		return chat.HandlerFunc(func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			res, err := t.Reserve(tags, estimate(req))
+			if err != nil {
+				return nil, err
+			}
+			resp, err := next.Complete(ctx, req)
+			e := transcript.Entry{Time: t.now(), Tags: tags}
+			if resp != nil {
+				e.Cost = resp.Cost
+			}
+			res.Record(e)
+			return resp, err
+		})
+	}
+}
+
+// estimate returns what req costs at most at the model's catalog prices:
+// its messages as input, and as output the most tokens it allows.
+func estimate(req *chat.Request) float64 {
+	in := tokenizer.ReplyOverhead
+	for _, m := range req.Params.Messages {
+		in += tokenizer.CountMessage(m.Role, m.Content)
+	}
+	out := cmp.Or(req.Params.MaxCompletionTokens, req.Params.MaxTokens, int(req.Model.DefaultMaxTokens))
+	return chat.Cost(req.Model, in, out)
+}
+
+// Handler returns the admin handler: GET lists every rule's status as
+// JSON, and POST with a rule parameter, as in "?rule=daily-ci", resets that
+// rule. It does not authenticate requests, and anyone who can reach it can
+// lift a budget, so mount it behind the server's admin authentication.
+func (t *Tracker) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			if err := t.Reset(r.FormValue("rule")); err != nil {
+				writeJSON(w, http.StatusNotFound, errorBody("not_found", err.Error()))
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeJSON(w, http.StatusMethodNotAllowed, errorBody("method_not_allowed", "use GET or POST"))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"budgets": t.Status()})
+	})
+}
+
+// WriteError answers a request refused by [Tracker.Check] with status 402
+// Payment Required and a JSON body in the OpenAI error format, so clients
+// of an OpenAI-compatible server show the message. Other errors are
+// answered with status 500.
+func WriteError(w http.ResponseWriter, err error) {
+	var over *catwalk.OverBudgetError
+	if !errors.As(err, &over) {
+		writeJSON(w, http.StatusInternalServerError, errorBody("internal_error", err.Error()))
+		return
+	}
+	body := errorBody(string(catwalk.CodeOverBudget), over.Error())
+	e := body["error"].(map[string]any)
+	e["rule"], e["period"], e["spent"], e["limit"] = over.Rule, over.Period, over.Spent, over.Budget
+	writeJSON(w, http.StatusPaymentRequired, body)
+}
+
+// errorBody returns an error in the OpenAI format.
+func errorBody(code, message string) map[string]any {
+	return map[string]any{"error": map[string]any{"type": code, "code": code, "message": message}}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
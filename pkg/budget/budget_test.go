@@ -0,0 +1,287 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/transcript"
+)
+
+func TestTracker(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.Local)
+	ledger := []transcript.Entry{
+		{Time: now.Add(-time.Hour), Cost: 4, Tags: []string{"ci"}},
+		{Time: now.AddDate(0, 0, -2), Cost: 10, Tags: []string{"ci"}},
+		{Time: now.AddDate(0, -1, 0), Cost: 100},
+		{Time: now.Add(-2 * time.Hour), Cost: 1},
+	}
+	tr, err := newTracker([]Rule{
+		{Name: "ci-daily", Tag: "ci", Period: Daily, Limit: 5},
+		{Name: "monthly", Period: Monthly, Limit: 20},
+	}, ledger, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status := tr.Status()
+	if status[0].Spent != 4 || status[0].Remaining != 1 || status[1].Spent != 15 {
+		t.Fatalf("status = %+v", status)
+	}
+	if err := tr.Check([]string{"ci"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tr.Record(transcript.Entry{Time: now, Cost: 1.5, Tags: []string{"ci"}})
+	err = tr.Check([]string{"ci"})
+	var over *catwalk.OverBudgetError
+	if !errors.As(err, &over) || over.Rule != "ci-daily" || over.Spent != 5.5 {
+		t.Fatalf("Check(ci) = %v", err)
+	}
+	if want := "over the daily budget ci-daily: spent $5.5 of $5"; err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+	if err := tr.Check(nil); err != nil {
+		t.Errorf("untagged request: %v", err)
+	}
+
+	if err := tr.Reset("ci-daily"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Check([]string{"ci"}); err != nil {
+		t.Errorf("after reset: %v", err)
+	}
+	if err := tr.Reset("nope"); err == nil {
+		t.Error("reset of an unknown rule succeeded")
+	}
+}
+
+func TestReserve(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.Local)
+	tr, err := newTracker([]Rule{{Name: "daily", Period: Daily, Limit: 1}}, nil, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := tr.Reserve(nil, 0.6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := tr.Reserve(nil, 0.6)
+	if err != nil {
+		t.Fatalf("second request, with $0.60 of $1 reserved: %v", err)
+	}
+	if s := tr.Status()[0]; s.Spent != 0 || s.Reserved != 1.2 || s.Remaining != 0 {
+		t.Errorf("status with two requests in flight = %+v", s)
+	}
+	var over *catwalk.OverBudgetError
+	if _, err := tr.Reserve(nil, 0.1); !errors.As(err, &over) || over.Spent != 1.2 {
+		t.Fatalf("third request, with $1.20 reserved: %v", err)
+	}
+
+	// Recording replaces an estimate with the cost, and a failed request
+	// releases its reservation
+	first.Record(transcript.Entry{Time: now, Cost: 0.1})
+	first.Record(transcript.Entry{Time: now, Cost: 0.1})
+	if s := tr.Status()[0]; s.Spent != 0.1 || s.Reserved != 0.6 {
+		t.Errorf("status after recording one = %+v", s)
+	}
+	second.Record(transcript.Entry{Time: now})
+	if s := tr.Status()[0]; s.Spent != 0.1 || s.Reserved != 0 || s.Remaining != 0.9 {
+		t.Errorf("status after releasing the other = %+v", s)
+	}
+	if err := tr.Check(nil); err != nil {
+		t.Errorf("Check after both finished: %v", err)
+	}
+}
+
+func TestNewRejectsBadRules(t *testing.T) {
+	for _, rules := range [][]Rule{
+		{{Period: Daily, Limit: 1}},
+		{{Name: "a", Period: "weekly", Limit: 1}},
+		{{Name: "a", Period: Daily}},
+		{{Name: "a", Period: Daily, Limit: 1}, {Name: "a", Period: Monthly, Limit: 1}},
+	} {
+		if _, err := New(rules, nil); err == nil {
+			t.Errorf("New(%+v) succeeded", rules)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	tr, err := New([]Rule{{Name: "all", Period: Daily, Limit: 1}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	h := chat.Chain(chat.HandlerFunc(func(context.Context, *chat.Request) (*chat.Response, error) {
+		calls++
+		return &chat.Response{Cost: 0.6}, nil
+	}), tr.Middleware("team-a"))
+
+	for range 3 {
+		_, err = h.Complete(context.Background(), &chat.Request{})
+	}
+	if calls != 2 || !errors.Is(err, catwalk.ErrOverBudget) {
+		t.Errorf("calls = %d, err = %v", calls, err)
+	}
+}
+
+func TestMiddlewareConcurrent(t *testing.T) {
+	tr, err := New([]Rule{{Name: "all", Period: Daily, Limit: 3}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls atomic.Int32
+	release := make(chan struct{})
+	h := chat.Chain(chat.HandlerFunc(func(context.Context, *chat.Request) (*chat.Response, error) {
+		calls.Add(1)
+		<-release
+		return &chat.Response{Cost: 1}, nil
+	}), tr.Middleware())
+
+	// Each request may cost $1: a one-token reply at $1 a token
+	req := &chat.Request{Model: catwalk.Model{CostPer1MOut: 1_000_000}}
+	req.Params.MaxTokens = 1
+	const requests = 10
+	refused := make(chan error, requests)
+	var wg sync.WaitGroup
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := h.Complete(context.Background(), req); err != nil {
+				refused <- err
+			}
+		}()
+	}
+	for range requests - 3 {
+		select {
+		case err := <-refused:
+			if !errors.Is(err, catwalk.ErrOverBudget) {
+				t.Errorf("refused with %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%d requests in flight against a $3 budget", calls.Load())
+		}
+	}
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 3 {
+		t.Errorf("%d requests sent, want 3", n)
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	tr, err := New([]Rule{{Name: "all", Period: Monthly, Limit: 1}}, []transcript.Entry{{Time: time.Now(), Cost: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	WriteError(rec, tr.Check(nil))
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+			Rule string `json:"rule"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusPaymentRequired || body.Error.Code != "over_budget" || body.Error.Rule != "all" {
+		t.Errorf("WriteError = %d %+v", rec.Code, body)
+	}
+
+	srv := httptest.NewServer(tr.Handler())
+	defer srv.Close()
+	resp, err := http.Post(srv.URL+"?rule=all", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var status struct{ Budgets []Status }
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || len(status.Budgets) != 1 || status.Budgets[0].Spent != 0 {
+		t.Errorf("reset = %d %+v", resp.StatusCode, status)
+	}
+
+	resp, err = http.Post(srv.URL+"?rule=none", "", strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown rule = %d", resp.StatusCode)
+	}
+}
+
+func TestQuotas(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.Local)
+	usage := func(in, out int) transcript.Usage { return transcript.Usage{InputTokens: in, OutputTokens: out} }
+	ledger := []transcript.Entry{
+		{Time: now.Add(-time.Hour), Provider: "groq", Usage: usage(300, 100)},
+		{Time: now.Add(-2 * time.Hour), Provider: "groq", Usage: usage(400, 100), Error: "stream cut"},
+		{Time: now.AddDate(0, 0, -1), Provider: "groq", Usage: usage(5000, 0)},
+		{Time: now.Add(-time.Hour), Provider: "openai", Usage: usage(9000, 1000)},
+		{Time: now.AddDate(0, 0, -3), Provider: "openai", Usage: usage(9000, 1000)},
+	}
+	tr, err := newQuotaTracker(map[catwalk.InferenceProvider]Quota{
+		"groq":   {Period: Daily, Requests: 3, Tokens: 1000},
+		"openai": {Period: Monthly, Tokens: 100_000},
+	}, ledger, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status := tr.Status()
+	if len(status) != 2 || status[0].Provider != "groq" || status[0].Requests != 2 || status[0].Tokens != 900 || status[1].Tokens != 20_000 {
+		t.Fatalf("status = %+v", status)
+	}
+	if left, ok := status[0].TokensLeft(); !ok || left != 100 {
+		t.Errorf("groq tokens left = %d, %v", left, ok)
+	}
+	if _, ok := status[1].RequestsLeft(); ok {
+		t.Error("openai requests should be unlimited")
+	}
+	if !status[0].Resets.Equal(time.Date(2026, 3, 16, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("groq resets %v", status[0].Resets)
+	}
+
+	if err := tr.Check("groq", 100); err != nil {
+		t.Errorf("Check(groq, 100) = %v", err)
+	}
+	err = tr.Check("groq", 200)
+	var quotaErr *QuotaError
+	if !errors.As(err, &quotaErr) || quotaErr.Unit != "tokens" || quotaErr.Used != 900 {
+		t.Fatalf("Check(groq, 200) = %v", err)
+	}
+	if want := "groq daily quota of 1000 tokens: 900 used, this request needs ~200 more (resets Mar 16 00:00)"; err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+
+	tr.Record(transcript.Entry{Time: now, Provider: "groq"})
+	if err := tr.Check("groq", 0); !errors.As(err, &quotaErr) || quotaErr.Unit != "requests" {
+		t.Errorf("after a third request: %v", err)
+	}
+	if err := tr.Check("anthropic", 1_000_000); err != nil || tr.Has("anthropic") {
+		t.Errorf("provider without a quota: %v", err)
+	}
+
+	for _, q := range []Quota{{Period: "weekly", Requests: 1}, {Period: Daily}, {Period: Daily, Tokens: -1}} {
+		if _, err := NewQuotas(map[catwalk.InferenceProvider]Quota{"x": q}, nil); err == nil {
+			t.Errorf("NewQuotas(%+v) succeeded", q)
+		}
+	}
+}
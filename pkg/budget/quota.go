@@ -0,0 +1,201 @@
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/transcript"
+)
+
+// Quota caps a provider's requests and tokens per period, such as a free
+// tier's daily limits. A zero limit is unlimited.
+type Quota struct {
+	Period   Period `json:"period" yaml:"period"`
+	Requests int    `json:"requests,omitempty" yaml:"requests,omitempty"`
+	// Tokens counts input and output tokens together.
+	Tokens int64 `json:"tokens,omitempty" yaml:"tokens,omitempty"`
+}
+
+// Validate checks the period and that at least one limit is set.
+func (q Quota) Validate() error {
+	switch {
+	case q.Period != Daily && q.Period != Monthly:
+		return fmt.Errorf("quota: unknown period %q (use daily or monthly)", q.Period)
+	case q.Requests < 0 || q.Tokens < 0:
+		return errors.New("quota: limits cannot be negative")
+	case q.Requests == 0 && q.Tokens == 0:
+		return errors.New("quota: set requests, tokens, or both")
+	}
+	return nil
+}
+
+// End returns the beginning of the period after the one that contains t.
+func (p Period) End(t time.Time) time.Time {
+	start := p.Start(t)
+	if p == Monthly {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// QuotaStatus is a provider's use of its quota in the current period.
+type QuotaStatus struct {
+	Provider catwalk.InferenceProvider `json:"provider"`
+	Quota    Quota                     `json:"quota"`
+
+	Requests int       `json:"requests"`
+	Tokens   int64     `json:"tokens"`
+	Since    time.Time `json:"since"`
+	Resets   time.Time `json:"resets"`
+}
+
+// RequestsLeft returns how many requests remain, or false if requests are
+// unlimited.
+func (s QuotaStatus) RequestsLeft() (int, bool) {
+	return max(s.Quota.Requests-s.Requests, 0), s.Quota.Requests > 0
+}
+
+// TokensLeft returns how many tokens remain, or false if tokens are
+// unlimited.
+func (s QuotaStatus) TokensLeft() (int64, bool) {
+	return max(s.Quota.Tokens-s.Tokens, 0), s.Quota.Tokens > 0
+}
+
+// QuotaError reports a request that would exceed a provider's quota.
+type QuotaError struct {
+	Provider catwalk.InferenceProvider
+	Period   Period
+	// Unit is "requests" or "tokens".
+	Unit string
+	// Used is what the period has used so far, Need what the request would
+	// add, and Limit the quota.
+	Used, Need, Limit int64
+	Resets            time.Time
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s %s quota of %d %s: %d used, this request needs ~%d more (resets %s)",
+		e.Provider, e.Period, e.Limit, e.Unit, e.Used, e.Need, e.Resets.Format("Jan 2 15:04"))
+}
+
+// use is the part of a ledger entry the quota tracker needs.
+type use struct {
+	at       time.Time
+	provider catwalk.InferenceProvider
+	tokens   int64
+}
+
+// QuotaTracker counts each provider's requests and tokens against its
+// quota. Usage is computed from a ledger of transcript entries, as for
+// [Tracker]. It is safe for concurrent use.
+type QuotaTracker struct {
+	quotas map[catwalk.InferenceProvider]Quota
+
+	mu   sync.Mutex
+	uses []use
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// NewQuotas returns a tracker for quotas that counts the usage already in
+// ledger. Every logged request counts, failed ones included, since
+// providers usually count them too.
+func NewQuotas(quotas map[catwalk.InferenceProvider]Quota, ledger []transcript.Entry) (*QuotaTracker, error) {
+	return newQuotaTracker(quotas, ledger, time.Now)
+}
+
+func newQuotaTracker(quotas map[catwalk.InferenceProvider]Quota, ledger []transcript.Entry, now func() time.Time) (*QuotaTracker, error) {
+	for id, q := range quotas {
+		if err := q.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", id, err)
+		}
+	}
+	t := &QuotaTracker{quotas: maps.Clone(quotas), now: now}
+	for _, e := range ledger {
+		t.add(e)
+	}
+	return t, nil
+}
+
+// Record adds a request to the tracker.
+func (t *QuotaTracker) Record(e transcript.Entry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.add(e)
+}
+
+// add keeps a request made this month to a provider with a quota. The
+// caller holds t.mu, or has the only reference to t.
+func (t *QuotaTracker) add(e transcript.Entry) {
+	id := catwalk.InferenceProvider(e.Provider)
+	if _, ok := t.quotas[id]; !ok || e.Time.Before(Monthly.Start(t.now())) {
+		return
+	}
+	t.uses = append(t.uses, use{at: e.Time, provider: id, tokens: int64(e.Usage.InputTokens + e.Usage.OutputTokens)})
+}
+
+// Has reports whether provider has a quota.
+func (t *QuotaTracker) Has(provider catwalk.InferenceProvider) bool {
+	_, ok := t.quotas[provider]
+	return ok
+}
+
+// StatusOf returns a provider's use of its quota, or false if it has none.
+func (t *QuotaTracker) StatusOf(provider catwalk.InferenceProvider) (QuotaStatus, bool) {
+	q, ok := t.quotas[provider]
+	if !ok {
+		return QuotaStatus{}, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status(provider, q, t.now()), true
+}
+
+// status totals provider's use in the current period. The caller holds
+// t.mu.
+func (t *QuotaTracker) status(provider catwalk.InferenceProvider, q Quota, now time.Time) QuotaStatus {
+	s := QuotaStatus{Provider: provider, Quota: q, Since: q.Period.Start(now), Resets: q.Period.End(now)}
+	for _, u := range t.uses {
+		if u.provider == provider && !u.at.Before(s.Since) {
+			s.Requests++
+			s.Tokens += u.tokens
+		}
+	}
+	return s
+}
+
+// Status returns every provider's use of its quota, by provider ID.
+func (t *QuotaTracker) Status() []QuotaStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	out := make([]QuotaStatus, 0, len(t.quotas))
+	for _, id := range slices.Sorted(maps.Keys(t.quotas)) {
+		out = append(out, t.status(id, t.quotas[id], now))
+	}
+	return out
+}
+
+// Check returns a *QuotaError if one more request of about tokens input
+// and output tokens would exceed provider's quota, or nil. Quotas are not
+// enforced: callers decide whether to warn or refuse.
+func (t *QuotaTracker) Check(provider catwalk.InferenceProvider, tokens int64) error {
+	s, ok := t.StatusOf(provider)
+	if !ok {
+		return nil
+	}
+	q := s.Quota
+	if q.Requests > 0 && s.Requests+1 > q.Requests {
+		return &QuotaError{Provider: provider, Period: q.Period, Unit: "requests", Used: int64(s.Requests), Need: 1, Limit: int64(q.Requests), Resets: s.Resets}
+	}
+	if q.Tokens > 0 && s.Tokens+tokens > q.Tokens {
+		return &QuotaError{Provider: provider, Period: q.Period, Unit: "tokens", Used: s.Tokens, Need: tokens, Limit: q.Tokens, Resets: s.Resets}
+	}
+	return nil
+}
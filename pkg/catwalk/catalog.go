@@ -0,0 +1,100 @@
+package catwalk
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Catalog is a provider list that is decoded lazily: each provider's JSON is
+// kept until the provider is first asked for, so a program that uses one
+// provider does not pay to decode every model in the catalog. It is safe for
+// concurrent use.
+type Catalog struct {
+	ids []InferenceProvider
+	raw []json.RawMessage
+
+	mu        sync.Mutex
+	providers []Provider
+	// decoded[i] is set once providers[i] has been decoded from raw[i].
+	decoded []bool
+}
+
+// ParseCatalog splits a JSON provider list, as served by the catwalk
+// service, into providers without decoding their models.
+func ParseCatalog(data []byte) (*Catalog, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode catalog: %w", err)
+	}
+	c := &Catalog{
+		ids:       make([]InferenceProvider, len(raw)),
+		raw:       raw,
+		providers: make([]Provider, len(raw)),
+		decoded:   make([]bool, len(raw)),
+	}
+	for i, r := range raw {
+		var head struct {
+			ID InferenceProvider `json:"id"`
+		}
+		if err := json.Unmarshal(r, &head); err != nil {
+			return nil, fmt.Errorf("failed to decode provider %d: %w", i+1, err)
+		}
+		c.ids[i] = head.ID
+	}
+	return c, nil
+}
+
+// Len returns the number of providers.
+func (c *Catalog) Len() int { return len(c.ids) }
+
+// IDs returns the provider IDs, in catalog order, without decoding any
+// provider.
+func (c *Catalog) IDs() []InferenceProvider { return c.ids }
+
+// Provider returns the provider with the given ID, ignoring case, decoding
+// it on first use. If there is none, the error is a *ProviderNotFoundError
+// suggesting similar IDs, as from [FindProvider].
+func (c *Catalog) Provider(id string) (*Provider, error) {
+	ids := make([]string, len(c.ids))
+	for i, pid := range c.ids {
+		if strings.EqualFold(string(pid), id) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if err := c.decode(i); err != nil {
+				return nil, err
+			}
+			return &c.providers[i], nil
+		}
+		ids[i] = string(pid)
+	}
+	return nil, &ProviderNotFoundError{Provider: id, Suggestions: Suggest(id, ids, maxSuggestions)}
+}
+
+// Providers decodes every provider and returns them in catalog order. The
+// slice is shared with the catalog, so later calls return the same
+// providers.
+func (c *Catalog) Providers() ([]Provider, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.raw {
+		if err := c.decode(i); err != nil {
+			return nil, err
+		}
+	}
+	return c.providers, nil
+}
+
+// decode decodes provider i if it has not been yet, dropping its JSON. The
+// caller holds c.mu.
+func (c *Catalog) decode(i int) error {
+	if c.decoded[i] {
+		return nil
+	}
+	if err := json.Unmarshal(c.raw[i], &c.providers[i]); err != nil {
+		return fmt.Errorf("failed to decode provider %s: %w", c.ids[i], err)
+	}
+	c.decoded[i], c.raw[i] = true, nil
+	return nil
+}
@@ -0,0 +1,141 @@
+package catwalk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	xetag "github.com/charmbracelet/x/etag"
+)
+
+func TestCatalog(t *testing.T) {
+	data := []byte(`[
+		{"id": "openai", "name": "OpenAI", "models": [{"id": "gpt-4o", "cost_per_1m_in": 2.5}]},
+		{"id": "anthropic", "name": "Anthropic", "models": [{"id": "claude-sonnet-4-5", "context_window": "big"}]}
+	]`)
+	c, err := ParseCatalog(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 2 || !slices.Equal(c.IDs(), []InferenceProvider{"openai", "anthropic"}) {
+		t.Fatalf("IDs = %v", c.IDs())
+	}
+
+	p, err := c.Provider("OpenAI")
+	if err != nil || p.Name != "OpenAI" || p.Models[0].CostPer1MIn != 2.5 {
+		t.Fatalf("Provider(OpenAI) = %+v, %v", p, err)
+	}
+	if again, _ := c.Provider("openai"); again != p {
+		t.Error("provider decoded twice")
+	}
+
+	// A malformed provider only fails when it is used
+	if _, err := c.Provider("anthropic"); err == nil {
+		t.Error("expected an error for a malformed provider")
+	}
+	if _, err := c.Providers(); err == nil {
+		t.Error("expected an error for a malformed catalog")
+	}
+	var perr *ProviderNotFoundError
+	if _, err := c.Provider("opneai"); !errors.As(err, &perr) || !slices.Equal(perr.Suggestions, []string{"openai"}) {
+		t.Errorf("Provider(opneai) error = %v", err)
+	}
+
+	if _, err := ParseCatalog([]byte(`{"id": "openai"}`)); err == nil {
+		t.Error("expected an error for a catalog that is not a list")
+	}
+}
+
+func TestGetCatalog(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		xetag.Response(w, "v1")
+		if xetag.Matches(r, "v1") {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"id": "openai", "models": [{"id": "gpt-4o"}]}]`))
+	}))
+	defer srv.Close()
+	client := NewWithURL(srv.URL)
+
+	c, etag, err := client.GetCatalog(context.Background(), "")
+	if err != nil || etag != `"v1"` {
+		t.Fatalf("GetCatalog = %v, %q", err, etag)
+	}
+	if providers, err := c.Providers(); err != nil || len(providers) != 1 || providers[0].Models[0].ID != "gpt-4o" {
+		t.Errorf("Providers = %+v, %v", providers, err)
+	}
+	// The ETag returned is passed back as is
+	if _, etag, err := client.GetCatalog(context.Background(), etag); err != ErrNotModified || etag != `"v1"` {
+		t.Errorf("unchanged catalog = %v, %q", err, etag)
+	}
+}
+
+// largeCatalog returns a catalog of 40 providers with 250 models each, in
+// the JSON the catwalk service serves.
+func largeCatalog(b *testing.B) []byte {
+	b.Helper()
+	providers := make([]Provider, 40)
+	for i := range providers {
+		p := &providers[i]
+		p.ID = InferenceProvider(fmt.Sprintf("provider-%d", i))
+		p.Name = fmt.Sprintf("Provider %d", i)
+		p.APIEndpoint = "https://api.example.com/v1"
+		for j := range 250 {
+			p.Models = append(p.Models, Model{
+				ID:              fmt.Sprintf("model-%d-%d", i, j),
+				Name:            fmt.Sprintf("Model %d %d", i, j),
+				CostPer1MIn:     float64(j) / 10,
+				CostPer1MOut:    float64(j) / 2,
+				ContextWindow:   128000,
+				CanReason:       j%2 == 0,
+				ReasoningLevels: []string{"low", "medium", "high"},
+				ImagePricing:    []ImageTier{{Name: "standard", Cost: 0.04}},
+			})
+		}
+	}
+	data, err := json.Marshal(providers)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return data
+}
+
+// BenchmarkDecodeCatalog measures what GetProviders does at startup before
+// a program can look up its provider.
+func BenchmarkDecodeCatalog(b *testing.B) {
+	data := largeCatalog(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		var providers []Provider
+		if err := json.Unmarshal(data, &providers); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := FindProvider(providers, "provider-20"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParseCatalog is BenchmarkDecodeCatalog with a lazily decoded
+// catalog, as GetCatalog returns.
+func BenchmarkParseCatalog(b *testing.B) {
+	data := largeCatalog(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		c, err := ParseCatalog(data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := c.Provider("provider-20"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -4,9 +4,14 @@ import (
 	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	xetag "github.com/charmbracelet/x/etag"
@@ -14,26 +19,65 @@ import (
 
 const defaultURL = "http://localhost:8080"
 
+// downFor is how long a URL that failed is tried only after the others.
+const downFor = time.Minute
+
 // Client represents a client for the catwalk service.
 type Client struct {
-	baseURL    string
+	// urls are the service's base URLs: the primary, then its mirrors.
+	urls       []string
 	httpClient *http.Client
+
+	mu sync.Mutex
+	// down holds when each failed URL is next tried first again.
+	down map[string]time.Time
+	// source is the URL that served the last catalog.
+	source string
+	// warned is set once the embedded catalog's age has been reported.
+	warned bool
 }
 
 // New creates a new client instance
 // Uses CATWALK_URL environment variable or falls back to localhost:8080.
+// CATWALK_URL may list mirrors after the primary URL, separated by commas.
 func New() *Client {
-	return NewWithURL(cmp.Or(os.Getenv("CATWALK_URL"), defaultURL))
+	return NewWithURLs(strings.Split(cmp.Or(os.Getenv("CATWALK_URL"), defaultURL), ",")...)
 }
 
 // NewWithURL creates a new client with a specific URL.
 func NewWithURL(url string) *Client {
-	return &Client{
-		baseURL: url,
+	return NewWithURLs(url)
+}
+
+// NewWithURLs creates a client for a primary URL and its mirrors. Each
+// request goes to the first URL that has not failed recently; when one
+// fails, it is marked down for a minute and the next is tried, so tools keep
+// working while the primary is out.
+func NewWithURLs(urls ...string) *Client {
+	c := &Client{
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		down: map[string]time.Time{},
 	}
+	for _, u := range urls {
+		if u = strings.TrimRight(strings.TrimSpace(u), "/"); u != "" {
+			c.urls = append(c.urls, u)
+		}
+	}
+	if len(c.urls) == 0 {
+		c.urls = []string{defaultURL}
+	}
+	return c
+}
+
+// NewWithHTTPClient creates a new client that sends requests with httpClient,
+// e.g. one configured for a proxy or custom CA.
+// Uses CATWALK_URL environment variable or falls back to localhost:8080.
+func NewWithHTTPClient(httpClient *http.Client) *Client {
+	c := New()
+	c.httpClient = httpClient
+	return c
 }
 
 // ErrNotModified happens when the given ETag matches the server, so no update
@@ -43,37 +87,176 @@ var ErrNotModified = fmt.Errorf("not modified")
 // Etag returns the ETag for the given data.
 func Etag(data []byte) string { return xetag.Of(data) }
 
-// GetProviders retrieves all available providers from the service.
+// GetProviders retrieves all available providers from the service. If etag
+// is set and still matches the catalog, it returns ErrNotModified.
 func (c *Client) GetProviders(ctx context.Context, etag string) ([]Provider, error) {
+	providers, _, err := c.GetProvidersWithETag(ctx, etag)
+	return providers, err
+}
+
+// GetProvidersWithETag is like [Client.GetProviders] but also returns the
+// catalog's current ETag, to pass as etag on the next call so an unchanged
+// catalog is not downloaded again.
+func (c *Client) GetProvidersWithETag(ctx context.Context, etag string) ([]Provider, string, error) {
+	resp, err := c.get(ctx, etag)
+	if err == ErrNotModified {
+		return nil, etag, err
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	var providers []Provider
+	if err := json.NewDecoder(resp.Body).Decode(&providers); err != nil {
+		return nil, "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return providers, resp.Header.Get("ETag"), nil
+}
+
+// GetCatalog is like [Client.GetProvidersWithETag] but decodes each
+// provider only when it is first used, which makes startup faster and
+// lighter for programs that need one provider.
+func (c *Client) GetCatalog(ctx context.Context, etag string) (*Catalog, string, error) {
+	resp, err := c.get(ctx, etag)
+	if err == ErrNotModified {
+		return nil, etag, err
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read response: %w", err)
+	}
+	catalog, err := ParseCatalog(data)
+	if err != nil {
+		return nil, "", err
+	}
+	return catalog, resp.Header.Get("ETag"), nil
+}
+
+// URLs returns the client's base URLs, primary first.
+func (c *Client) URLs() []string {
+	return append([]string(nil), c.urls...)
+}
+
+// Source returns the URL that served the last catalog, [EmbeddedURL] if it
+// was the embedded one, or "" before the first request.
+func (c *Client) Source() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.source
+}
+
+// Down returns the URLs marked down after failing, in the client's order.
+func (c *Client) Down() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	var down []string
+	for _, u := range c.urls {
+		if now.Before(c.down[u]) {
+			down = append(down, u)
+		}
+	}
+	return down
+}
+
+// get requests the provider list from the first URL that answers, trying
+// URLs marked down last, then the embedded catalog if the binary has one.
+// It returns ErrNotModified if etag still matches, and an error for any
+// status but 200 OK from every URL.
+func (c *Client) get(ctx context.Context, etag string) (*http.Response, error) {
+	var errs []error
+	for _, url := range c.order() {
+		resp, err := c.getFrom(ctx, url, etag)
+		if err == nil || err == ErrNotModified {
+			c.mark(url, true)
+			return resp, err
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		c.mark(url, false)
+		errs = append(errs, fmt.Errorf("%s: %w", url, err))
+	}
+	err := fmt.Errorf("all %d catwalk URLs failed: %w", len(errs), errors.Join(errs...))
+	if len(errs) == 1 {
+		err = errors.Unwrap(errs[0])
+	}
+	if _, ok := EmbeddedCatalog(); ok && !slices.Contains(c.urls, EmbeddedURL) {
+		resp, embeddedErr := c.getEmbedded(etag, err)
+		if embeddedErr == nil || embeddedErr == ErrNotModified {
+			c.mark(EmbeddedURL, true)
+		}
+		return resp, embeddedErr
+	}
+	return nil, err
+}
+
+// order returns the URLs to try: those not marked down, then the others.
+func (c *Client) order() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	var up, down []string
+	for _, u := range c.urls {
+		if now.Before(c.down[u]) {
+			down = append(down, u)
+		} else {
+			up = append(up, u)
+		}
+	}
+	return append(up, down...)
+}
+
+// mark records whether a request to url succeeded.
+func (c *Client) mark(url string, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ok {
+		delete(c.down, url)
+		c.source = url
+	} else {
+		c.down[url] = time.Now().Add(downFor)
+	}
+}
+
+// getFrom requests the provider list from one base URL.
+func (c *Client) getFrom(ctx context.Context, baseURL, etag string) (*http.Response, error) {
+	if baseURL == EmbeddedURL {
+		return c.getEmbedded(etag, nil)
+	}
 	req, err := http.NewRequestWithContext(
 		ctx,
 		http.MethodGet,
-		fmt.Sprintf("%s/v2/providers", c.baseURL),
+		fmt.Sprintf("%s/v2/providers", baseURL),
 		nil,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("could not create request: %w", err)
 	}
-	xetag.Request(req, etag)
+	// ETags are returned quoted, as the service sends them, and quoted
+	// again by xetag.Request
+	xetag.Request(req, strings.Trim(etag, `"`))
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
-	defer resp.Body.Close() //nolint:errcheck
 
-	if resp.StatusCode == http.StatusNotModified {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusNotModified:
+		resp.Body.Close() //nolint:errcheck
 		return nil, ErrNotModified
-	}
-
-	if resp.StatusCode != http.StatusOK {
+	default:
+		resp.Body.Close() //nolint:errcheck
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
-
-	var providers []Provider
-	if err := json.NewDecoder(resp.Body).Decode(&providers); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return providers, nil
 }
@@ -0,0 +1,89 @@
+package catwalk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	xetag "github.com/charmbracelet/x/etag"
+)
+
+func TestETagRoundTrip(t *testing.T) {
+	data := []byte(`[{"id": "openai", "models": [{"id": "gpt-4o"}]}]`)
+	tag := Etag(data)
+	var downloads int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// As the catwalk service answers
+		xetag.Response(w, tag)
+		if xetag.Matches(r, tag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		_, _ = w.Write(data)
+	}))
+	defer srv.Close()
+	client := NewWithURL(srv.URL)
+	ctx := context.Background()
+
+	_, etag, err := client.GetProvidersWithETag(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := client.GetProvidersWithETag(ctx, etag); err != ErrNotModified {
+		t.Errorf("GetProvidersWithETag(%q) = %v, want ErrNotModified", etag, err)
+	}
+	if _, _, err := client.GetCatalog(ctx, etag); err != ErrNotModified {
+		t.Errorf("GetCatalog(%q) = %v, want ErrNotModified", etag, err)
+	}
+	// An unquoted ETag, as computed with Etag, matches too
+	if _, err := client.GetProviders(ctx, tag); err != ErrNotModified {
+		t.Errorf("GetProviders(%q) = %v, want ErrNotModified", tag, err)
+	}
+	if downloads != 1 {
+		t.Errorf("%d downloads, want 1", downloads)
+	}
+}
+
+func TestClientFailover(t *testing.T) {
+	// Without the embedded catalog of -tags embedcatalog to fall back to
+	saved := embedded
+	t.Cleanup(func() { embedded = saved })
+	embedded.data = nil
+
+	var primaryHits int
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryHits++
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]Provider{{ID: "openai"}})
+	}))
+	defer mirror.Close()
+
+	c := NewWithURLs(primary.URL, mirror.URL+"/")
+	for range 2 {
+		providers, err := c.GetProviders(context.Background(), "")
+		if err != nil || len(providers) != 1 {
+			t.Fatalf("GetProviders = %v, %v", providers, err)
+		}
+	}
+	// The primary is marked down after failing, so the second call skips it
+	if primaryHits != 1 {
+		t.Errorf("primary was tried %d times, want 1", primaryHits)
+	}
+	if down := c.Down(); !slices.Equal(down, []string{primary.URL}) {
+		t.Errorf("Down() = %v", down)
+	}
+
+	mirror.Close()
+	_, err := c.GetProviders(context.Background(), "")
+	if err == nil || !strings.Contains(err.Error(), "all 2 catwalk URLs failed") {
+		t.Errorf("expected every URL to fail, got %v", err)
+	}
+}
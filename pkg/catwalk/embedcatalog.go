@@ -0,0 +1,46 @@
+//go:build embedcatalog
+
+package catwalk
+
+import (
+	"runtime/debug"
+	"time"
+
+	"charm.land/catwalk/internal/providers/configs"
+	xetag "github.com/charmbracelet/x/etag"
+)
+
+// embeddedDate overrides when the embedded catalog was captured, as
+// YYYY-MM-DD, for builds without version control information:
+//
+//	go build -tags embedcatalog -ldflags "-X charm.land/catwalk/pkg/catwalk.embeddedDate=2025-06-01"
+var embeddedDate string
+
+func init() {
+	data, err := configs.Catalog()
+	if err != nil {
+		panic(err)
+	}
+	embedded.data = data
+	embedded.etag = xetag.Of(data)
+	embedded.captured = captureTime()
+}
+
+// captureTime returns embeddedDate, or else the time of the commit the
+// binary was built from, which is when its provider configs were current.
+func captureTime() time.Time {
+	if t, err := time.Parse(time.DateOnly, embeddedDate); err == nil {
+		return t
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return time.Time{}
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.time" {
+			t, _ := time.Parse(time.RFC3339, s.Value)
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,89 @@
+package catwalk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// EmbeddedURL is the catalog URL, for CATWALK_URL or [NewWithURLs], of the
+// point-in-time catalog compiled into binaries built with
+// -tags embedcatalog. Those binaries also fall back to it when every other
+// URL fails, so they work in air-gapped environments.
+const EmbeddedURL = "embedded"
+
+// ErrNoEmbeddedCatalog is returned for [EmbeddedURL] by binaries built
+// without the embedcatalog tag.
+var ErrNoEmbeddedCatalog = errors.New("this binary has no embedded catalog; build it with -tags embedcatalog")
+
+// Warnings receives the notice that a client is using the embedded catalog
+// and how old it is, written once per client. Set it to [io.Discard] to
+// silence it.
+var Warnings io.Writer = os.Stderr
+
+// embedded is the compiled-in catalog, set by the embedcatalog build.
+var embedded struct {
+	data     []byte
+	etag     string
+	captured time.Time
+}
+
+// EmbeddedCatalog reports whether the binary has an embedded catalog and
+// when it was captured, which is zero if unknown.
+func EmbeddedCatalog() (captured time.Time, ok bool) {
+	return embedded.captured, embedded.data != nil
+}
+
+// embeddedAge describes when the embedded catalog was captured, such as
+// "captured 2025-06-01 (137 days ago)".
+func embeddedAge(now time.Time) string {
+	if embedded.captured.IsZero() {
+		return "of unknown age"
+	}
+	days := int(now.Sub(embedded.captured).Hours() / 24)
+	switch days {
+	case 0:
+		return fmt.Sprintf("captured %s (today)", embedded.captured.Format(time.DateOnly))
+	case 1:
+		return fmt.Sprintf("captured %s (1 day ago)", embedded.captured.Format(time.DateOnly))
+	}
+	return fmt.Sprintf("captured %s (%d days ago)", embedded.captured.Format(time.DateOnly), days)
+}
+
+// getEmbedded serves the embedded catalog as a response from the service,
+// or returns ErrNotModified if etag matches it. cause is why it is used
+// instead of the service, if it is a fallback.
+func (c *Client) getEmbedded(etag string, cause error) (*http.Response, error) {
+	if embedded.data == nil {
+		return nil, ErrNoEmbeddedCatalog
+	}
+	c.mu.Lock()
+	warn := !c.warned
+	c.warned = true
+	c.mu.Unlock()
+	if warn {
+		reason := ""
+		if cause != nil {
+			reason = fmt.Sprintf(", because the catwalk service is unreachable (%v)", cause)
+		}
+		fmt.Fprintf(Warnings, "Warning: using the catalog embedded in this binary, %s%s; prices, limits, and models may have changed since.\n", //nolint:errcheck
+			embeddedAge(time.Now()), reason)
+	}
+
+	tag := strings.Trim(embedded.etag, `"`)
+	if strings.Trim(etag, `"`) == tag {
+		return nil, ErrNotModified
+	}
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       io.NopCloser(bytes.NewReader(embedded.data)),
+	}
+	resp.Header.Set("ETag", `"`+tag+`"`)
+	return resp, nil
+}
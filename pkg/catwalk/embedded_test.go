@@ -0,0 +1,57 @@
+package catwalk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmbeddedCatalog(t *testing.T) {
+	saved, warnings := embedded, Warnings
+	t.Cleanup(func() { embedded, Warnings = saved, warnings })
+	var warned bytes.Buffer
+	Warnings = &warned
+
+	embedded.data = nil
+	if _, err := NewWithURL(EmbeddedURL).GetProviders(context.Background(), ""); !errors.Is(err, ErrNoEmbeddedCatalog) {
+		t.Errorf("expected ErrNoEmbeddedCatalog, got %v", err)
+	}
+
+	embedded.data = []byte(`[{"id":"openai","models":[{"id":"gpt-4o"}]}]`)
+	embedded.etag = "c0ffee"
+	embedded.captured = time.Now().AddDate(0, 0, -40)
+
+	// A service that is down falls back to the embedded catalog, with a warning
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+	c := NewWithURL(down.URL)
+	for range 2 {
+		providers, etag, err := c.GetProvidersWithETag(context.Background(), "")
+		if err != nil || len(providers) != 1 || etag != `"c0ffee"` {
+			t.Fatalf("GetProvidersWithETag = %v, %q, %v", providers, etag, err)
+		}
+	}
+	if c.Source() != EmbeddedURL {
+		t.Errorf("Source() = %q", c.Source())
+	}
+	want := "(40 days ago), because the catwalk service is unreachable (unexpected status code: 502)"
+	if got := warned.String(); !strings.Contains(got, want) || strings.Count(got, "Warning:") != 1 {
+		t.Errorf("warnings = %q; want one containing %q", got, want)
+	}
+
+	// Listed explicitly, it is used without trying the service
+	c = NewWithURL(EmbeddedURL)
+	if _, _, err := c.GetCatalog(context.Background(), embedded.etag); err != ErrNotModified {
+		t.Errorf("expected ErrNotModified, got %v", err)
+	}
+	if catalog, _, err := c.GetCatalog(context.Background(), ""); err != nil || catalog.Len() != 1 {
+		t.Errorf("GetCatalog = %v, %v", catalog, err)
+	}
+}
@@ -0,0 +1,255 @@
+package catwalk
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrorCode identifies a kind of error, so command-line tools can map it to
+// an exit status and scripts can branch on it.
+type ErrorCode string
+
+// Error codes.
+const (
+	CodeProviderNotFound   ErrorCode = "provider_not_found"
+	CodeModelNotFound      ErrorCode = "model_not_found"
+	CodeMissingAPIKey      ErrorCode = "missing_api_key"
+	CodeOverBudget         ErrorCode = "over_budget"
+	CodeMaxTokens          ErrorCode = "max_tokens_exceeded"
+	CodePolicyViolation    ErrorCode = "policy_violation"
+	CodeUnsupportedFeature ErrorCode = "unsupported_feature"
+)
+
+// Sentinel errors matched with errors.Is; the typed errors below wrap them.
+var (
+	ErrProviderNotFound   = errors.New("provider not found")
+	ErrModelNotFound      = errors.New("model not found")
+	ErrMissingAPIKey      = errors.New("missing API key")
+	ErrOverBudget         = errors.New("over budget")
+	ErrMaxTokens          = errors.New("max tokens exceeded")
+	ErrPolicyViolation    = errors.New("policy violation")
+	ErrUnsupportedFeature = errors.New("unsupported feature")
+)
+
+// ProviderNotFoundError is returned for an unknown provider ID.
+type ProviderNotFoundError struct {
+	Provider string
+	// Suggestions are similar provider IDs, closest first.
+	Suggestions []string
+}
+
+func (e *ProviderNotFoundError) Error() string {
+	return fmt.Sprintf("provider not found: %s%s", e.Provider, didYouMean(e.Suggestions))
+}
+
+// Unwrap returns ErrProviderNotFound.
+func (e *ProviderNotFoundError) Unwrap() error { return ErrProviderNotFound }
+
+// Code returns CodeProviderNotFound.
+func (e *ProviderNotFoundError) Code() ErrorCode { return CodeProviderNotFound }
+
+// ModelNotFoundError is returned for a model ID that a provider, or the
+// whole catalog when Provider is empty, does not list.
+type ModelNotFoundError struct {
+	Model    string
+	Provider InferenceProvider
+	// Suggestions are similar model IDs, closest first.
+	Suggestions []string
+}
+
+func (e *ModelNotFoundError) Error() string {
+	where := ""
+	if e.Provider != "" {
+		where = " in " + string(e.Provider)
+	}
+	return fmt.Sprintf("model not found%s: %s%s", where, e.Model, didYouMean(e.Suggestions))
+}
+
+// Unwrap returns ErrModelNotFound.
+func (e *ModelNotFoundError) Unwrap() error { return ErrModelNotFound }
+
+// Code returns CodeModelNotFound.
+func (e *ModelNotFoundError) Code() ErrorCode { return CodeModelNotFound }
+
+// MissingAPIKeyError is returned when a provider's API key is not set.
+type MissingAPIKeyError struct {
+	Provider InferenceProvider
+	// EnvVar is the environment variable the key is read from, if known.
+	EnvVar string
+}
+
+func (e *MissingAPIKeyError) Error() string {
+	if e.EnvVar == "" {
+		return fmt.Sprintf("no API key for %s", e.Provider)
+	}
+	return fmt.Sprintf("no API key for %s: set %s", e.Provider, e.EnvVar)
+}
+
+// Unwrap returns ErrMissingAPIKey.
+func (e *MissingAPIKeyError) Unwrap() error { return ErrMissingAPIKey }
+
+// Code returns CodeMissingAPIKey.
+func (e *MissingAPIKeyError) Code() ErrorCode { return CodeMissingAPIKey }
+
+// OverBudgetError is returned when spending reaches a cost limit.
+type OverBudgetError struct {
+	// Spent and Budget are in USD.
+	Spent  float64
+	Budget float64
+
+	// Rule and Period name the budget that was reached when it is one of
+	// several rolling ones, such as a "daily" cap on a tag.
+	Rule   string
+	Period string
+}
+
+func (e *OverBudgetError) Error() string {
+	if e.Rule != "" {
+		return fmt.Sprintf("over the %s budget %s: spent $%s of $%s", cmp.Or(e.Period, "total"), e.Rule, usd(e.Spent), usd(e.Budget))
+	}
+	return fmt.Sprintf("over budget: spent $%s of $%s", usd(e.Spent), usd(e.Budget))
+}
+
+// Unwrap returns ErrOverBudget.
+func (e *OverBudgetError) Unwrap() error { return ErrOverBudget }
+
+// Code returns CodeOverBudget.
+func (e *OverBudgetError) Code() ErrorCode { return CodeOverBudget }
+
+// MaxTokensError is returned when a request asks for a longer reply than a
+// model can produce; see [Model.CheckMaxTokens].
+type MaxTokensError struct {
+	Model     string
+	Requested int64
+	Limit     int64
+	// ContextWindow is set when Limit is the model's context window because
+	// the catalog lists no separate output limit.
+	ContextWindow bool
+}
+
+func (e *MaxTokensError) Error() string {
+	what := "output limit"
+	if e.ContextWindow {
+		what = "context window"
+	}
+	return fmt.Sprintf("max tokens %d exceed the %d-token %s of %s", e.Requested, e.Limit, what, e.Model)
+}
+
+// Unwrap returns ErrMaxTokens.
+func (e *MaxTokensError) Unwrap() error { return ErrMaxTokens }
+
+// Code returns CodeMaxTokens.
+func (e *MaxTokensError) Code() ErrorCode { return CodeMaxTokens }
+
+// PolicyViolationError is returned when an organization policy does not
+// allow a model.
+type PolicyViolationError struct {
+	Provider InferenceProvider
+	Model    string
+	// Rule is the policy setting that was violated, such as
+	// "banned_models", and Reason says how.
+	Rule   string
+	Reason string
+	// Policy is the path of the policy file, if known.
+	Policy string
+}
+
+func (e *PolicyViolationError) Error() string {
+	from := ""
+	if e.Policy != "" {
+		from = " by " + e.Policy
+	}
+	return fmt.Sprintf("%s/%s is not allowed%s (%s): %s", e.Provider, e.Model, from, e.Rule, e.Reason)
+}
+
+// Unwrap returns ErrPolicyViolation.
+func (e *PolicyViolationError) Unwrap() error { return ErrPolicyViolation }
+
+// Code returns CodePolicyViolation.
+func (e *PolicyViolationError) Code() ErrorCode { return CodePolicyViolation }
+
+// UnsupportedFeatureError is returned before sending a request that uses a
+// feature the model does not support, which providers reject with an opaque
+// 400.
+type UnsupportedFeatureError struct {
+	Provider InferenceProvider
+	Model    string
+	Feature  Feature
+	// Reason says how the model falls short, such as "the catalog lists no
+	// image input".
+	Reason string
+	// Suggestions are the provider's models that support every feature the
+	// request uses, closest in price first.
+	Suggestions []string
+}
+
+func (e *UnsupportedFeatureError) Error() string {
+	try := ""
+	if len(e.Suggestions) > 0 {
+		try = " (try " + strings.Join(e.Suggestions, ", ") + ")"
+	}
+	return fmt.Sprintf("%s/%s does not support %s: %s%s", e.Provider, e.Model, strings.ReplaceAll(string(e.Feature), "_", " "), e.Reason, try)
+}
+
+// Unwrap returns ErrUnsupportedFeature.
+func (e *UnsupportedFeatureError) Unwrap() error { return ErrUnsupportedFeature }
+
+// Code returns CodeUnsupportedFeature.
+func (e *UnsupportedFeatureError) Code() ErrorCode { return CodeUnsupportedFeature }
+
+// CodeOf returns the code of the first error in err's chain that has one, or
+// "" if none does.
+func CodeOf(err error) ErrorCode {
+	var coded interface{ Code() ErrorCode }
+	if errors.As(err, &coded) {
+		return coded.Code()
+	}
+	return ""
+}
+
+// ExitCode returns the process exit status for err: 0 for nil, 3 to 6 and 8
+// to 10 for the error codes above, 7 for ErrNotModified, and 1 otherwise.
+// Status 2 is left for usage errors.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if errors.Is(err, ErrNotModified) {
+		return 7
+	}
+	switch CodeOf(err) {
+	case CodeProviderNotFound:
+		return 3
+	case CodeModelNotFound:
+		return 4
+	case CodeMissingAPIKey:
+		return 5
+	case CodeOverBudget:
+		return 6
+	case CodeMaxTokens:
+		return 8
+	case CodePolicyViolation:
+		return 9
+	case CodeUnsupportedFeature:
+		return 10
+	default:
+		return 1
+	}
+}
+
+// usd formats a dollar amount to the nearest millionth, without trailing
+// zeros.
+func usd(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e6)/1e6, 'f', -1, 64)
+}
+
+func didYouMean(suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	return " (did you mean " + strings.Join(suggestions, ", ") + "?)"
+}
@@ -0,0 +1,30 @@
+package catwalk
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, 0},
+		{errors.New("boom"), 1},
+		{&ProviderNotFoundError{Provider: "x"}, 3},
+		{fmt.Errorf("lookup: %w", &ModelNotFoundError{Model: "x"}), 4},
+		{&MissingAPIKeyError{Provider: "x"}, 5},
+		{&OverBudgetError{Spent: 2, Budget: 1}, 6},
+		{fmt.Errorf("fetch: %w", ErrNotModified), 7},
+		{&MaxTokensError{Model: "x", Requested: 2, Limit: 1}, 8},
+		{&PolicyViolationError{Provider: "x", Model: "y", Rule: "banned_models"}, 9},
+		{&UnsupportedFeatureError{Provider: "x", Model: "y", Feature: FeatureImages}, 10},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package catwalk
+
+import "slices"
+
+// Feature is a request feature that only some models support.
+type Feature string
+
+// Features a request may use.
+const (
+	FeatureImages          Feature = "images"
+	FeatureTools           Feature = "tools"
+	FeatureJSONMode        Feature = "json_mode"
+	FeatureReasoningEffort Feature = "reasoning_effort"
+)
+
+// Supports reports whether the catalog says m supports f, and whether it
+// records f at all: it does for images and reasoning, but not for tools or
+// JSON mode.
+func (m Model) Supports(f Feature) (supported, known bool) {
+	switch f {
+	case FeatureImages:
+		return m.SupportsImages, true
+	case FeatureReasoningEffort:
+		return m.CanReason, true
+	default:
+		return false, false
+	}
+}
+
+// SupportsEffort reports whether m takes a reasoning effort of level: it
+// must reason and, if the catalog lists its levels, have that one.
+func (m Model) SupportsEffort(level string) bool {
+	return m.CanReason && (len(m.ReasoningLevels) == 0 || slices.Contains(m.ReasoningLevels, level))
+}
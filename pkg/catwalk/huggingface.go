@@ -0,0 +1,104 @@
+package catwalk
+
+import (
+	"net/url"
+	"strings"
+)
+
+// HuggingFaceRouter is the OpenAI-compatible endpoint of Hugging Face
+// Inference Providers.
+const HuggingFaceRouter = "https://router.huggingface.co/v1"
+
+// Provider-selection policies of the Hugging Face router, appended to a
+// model ID in place of a provider name, as in "openai/gpt-oss-120b:cheapest".
+// Without a suffix the router picks the fastest provider.
+const (
+	HuggingFaceFastest  = "fastest"
+	HuggingFaceCheapest = "cheapest"
+)
+
+// IsHuggingFace reports whether p routes through Hugging Face Inference
+// Providers. Like OpenRouter, it is recognized by type or by ID, since the
+// catalog lists it as an OpenAI-compatible provider.
+func (p Provider) IsHuggingFace() bool {
+	return p.Type == TypeHuggingFace || p.ID == InferenceProviderHuggingFace
+}
+
+// SplitHuggingFaceModel splits a router model ID such as
+// "openai/gpt-oss-120b:groq" into the model repository, with its
+// organization prefix, and the provider or policy suffix, which is empty if
+// there is none.
+func SplitHuggingFaceModel(id string) (repo, suffix string) {
+	if i := strings.LastIndex(id, ":"); i > strings.LastIndex(id, "/") {
+		return id[:i], id[i+1:]
+	}
+	return id, ""
+}
+
+// HuggingFaceEndpoint returns the router endpoint to send OpenAI-style
+// requests to, given a provider's configured one: empty endpoints, the bare
+// router host, the retired api-inference host, and URLs ending in
+// /chat/completions all map to the router's /v1 base. Other paths, such as
+// a provider-scoped route, are kept.
+func HuggingFaceEndpoint(endpoint string) string {
+	endpoint = strings.TrimSuffix(strings.TrimSuffix(endpoint, "/"), "/chat/completions")
+	u, err := url.Parse(endpoint)
+	if endpoint == "" || err != nil {
+		return HuggingFaceRouter
+	}
+	switch {
+	case u.Host == "api-inference.huggingface.co":
+		return HuggingFaceRouter
+	case u.Host == "router.huggingface.co" && strings.Trim(u.Path, "/") == "":
+		return strings.TrimSuffix(endpoint, "/") + "/v1"
+	}
+	return endpoint
+}
+
+// findHuggingFaceModel resolves a model ID the router accepts that the
+// catalog does not list as is: a repository alone, or with a policy suffix.
+// The catalog lists one model per repository and provider, and the result
+// is a copy of one of them with the requested ID. Since the router chooses
+// the provider, prices are the cheapest variant's for "cheapest" and
+// otherwise the most expensive one's, so cost estimates are not low; the
+// context window and max tokens are the smallest of any variant.
+func (p *Provider) findHuggingFaceModel(id string) (*Model, bool) {
+	repo, suffix := SplitHuggingFaceModel(id)
+	suffix = strings.ToLower(suffix)
+	if suffix != "" && suffix != HuggingFaceFastest && suffix != HuggingFaceCheapest {
+		return nil, false
+	}
+
+	var found *Model
+	var window, maxTokens int64
+	for i := range p.Models {
+		m := &p.Models[i]
+		r, _ := SplitHuggingFaceModel(m.ID)
+		if !strings.EqualFold(r, repo) {
+			continue
+		}
+		if found == nil {
+			// The catalog's spelling of the repository is used
+			found, window, maxTokens, repo = m, m.ContextWindow, m.DefaultMaxTokens, r
+			continue
+		}
+		window, maxTokens = min(window, m.ContextWindow), min(maxTokens, m.DefaultMaxTokens)
+		price, best := m.CostPer1MIn+m.CostPer1MOut, found.CostPer1MIn+found.CostPer1MOut
+		if (suffix == HuggingFaceCheapest && price < best) || (suffix != HuggingFaceCheapest && price > best) {
+			found = m
+		}
+	}
+	if found == nil {
+		return nil, false
+	}
+
+	m := *found
+	m.ID, m.Name = repo, repo+" (auto)"
+	if suffix != "" {
+		m.ID, m.Name = repo+":"+suffix, repo+" ("+suffix+")"
+	}
+	if suffix != HuggingFaceCheapest {
+		m.ContextWindow, m.DefaultMaxTokens = window, maxTokens
+	}
+	return &m, true
+}
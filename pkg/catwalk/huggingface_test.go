@@ -0,0 +1,71 @@
+package catwalk
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHuggingFace(t *testing.T) {
+	p := &Provider{ID: "huggingface", Type: TypeOpenAICompat, Models: []Model{
+		{ID: "openai/gpt-oss-120b:cerebras", CostPer1MIn: 0.25, CostPer1MOut: 0.69, ContextWindow: 131072, DefaultMaxTokens: 8192},
+		{ID: "openai/gpt-oss-120b:groq", CostPer1MIn: 0.15, CostPer1MOut: 0.75, ContextWindow: 65536, DefaultMaxTokens: 8192},
+		{ID: "openai/gpt-oss-120b:fireworks-ai", CostPer1MIn: 0.15, CostPer1MOut: 0.6, ContextWindow: 131072, DefaultMaxTokens: 4096},
+		{ID: "Qwen/Qwen3-32B:groq", CostPer1MIn: 0.29, CostPer1MOut: 0.59, ContextWindow: 131072},
+	}}
+
+	if repo, suffix := SplitHuggingFaceModel("openai/gpt-oss-120b:groq"); repo != "openai/gpt-oss-120b" || suffix != "groq" {
+		t.Errorf("SplitHuggingFaceModel = %q, %q", repo, suffix)
+	}
+	if repo, suffix := SplitHuggingFaceModel("org/model"); repo != "org/model" || suffix != "" {
+		t.Errorf("SplitHuggingFaceModel without suffix = %q, %q", repo, suffix)
+	}
+
+	for _, tt := range []struct {
+		id, want       string
+		in, out        float64
+		window, tokens int64
+	}{
+		{"OpenAI/gpt-oss-120b:Groq", "openai/gpt-oss-120b:groq", 0.15, 0.75, 65536, 8192},
+		// The router picks the provider: the highest price, the smallest limits
+		{"openai/gpt-oss-120b", "openai/gpt-oss-120b", 0.25, 0.69, 65536, 4096},
+		{"openai/gpt-oss-120b:fastest", "openai/gpt-oss-120b:fastest", 0.25, 0.69, 65536, 4096},
+		{"openai/gpt-oss-120b:cheapest", "openai/gpt-oss-120b:cheapest", 0.15, 0.6, 131072, 4096},
+		{"qwen/qwen3-32b", "Qwen/Qwen3-32B", 0.29, 0.59, 131072, 0},
+	} {
+		m, err := p.FindModel(tt.id)
+		if err != nil {
+			t.Errorf("FindModel(%s): %v", tt.id, err)
+			continue
+		}
+		if m.ID != tt.want || m.CostPer1MIn != tt.in || m.CostPer1MOut != tt.out || m.ContextWindow != tt.window || m.DefaultMaxTokens != tt.tokens {
+			t.Errorf("FindModel(%s) = %+v", tt.id, m)
+		}
+	}
+	if p.Models[2].ID != "openai/gpt-oss-120b:fireworks-ai" {
+		t.Errorf("catalog modified: %+v", p.Models[2])
+	}
+
+	for _, id := range []string{"openai/gpt-oss-120b:together", "openai/gpt-oss-20b"} {
+		if _, err := p.FindModel(id); !errors.Is(err, ErrModelNotFound) {
+			t.Errorf("FindModel(%s) error = %v", id, err)
+		}
+	}
+	other := &Provider{ID: "groq", Models: p.Models}
+	if _, err := other.FindModel("openai/gpt-oss-120b"); err == nil {
+		t.Error("repository lookup outside Hugging Face succeeded")
+	}
+
+	for in, want := range map[string]string{
+		"":                                     HuggingFaceRouter,
+		"https://router.huggingface.co":        HuggingFaceRouter,
+		"https://router.huggingface.co/v1/":    HuggingFaceRouter,
+		"https://api-inference.huggingface.co": HuggingFaceRouter,
+		"https://router.huggingface.co/v1/chat/completions": HuggingFaceRouter,
+		"https://router.huggingface.co/together/v1":         "https://router.huggingface.co/together/v1",
+		"http://localhost:8081/v1":                          "http://localhost:8081/v1",
+	} {
+		if got := HuggingFaceEndpoint(in); got != want {
+			t.Errorf("HuggingFaceEndpoint(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
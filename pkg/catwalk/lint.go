@@ -0,0 +1,146 @@
+package catwalk
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity grades a catalog [Issue].
+type Severity string
+
+// Issue severities. Errors are data that is certainly wrong and breaks
+// clients, such as a default model that does not exist; warnings are
+// suspicious values worth checking upstream.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Catalog checks, as reported in Issue.Check.
+const (
+	CheckNoModels       = "no-models"
+	CheckDefaultModel   = "default-model"
+	CheckDuplicateModel = "duplicate-model"
+	CheckContextWindow  = "context-window"
+	CheckMaxTokens      = "max-tokens"
+	CheckZeroPricing    = "zero-pricing"
+	CheckNegativePrice  = "negative-price"
+)
+
+// Checks lists every catalog check.
+var Checks = []string{
+	CheckNoModels, CheckDefaultModel, CheckDuplicateModel, CheckContextWindow,
+	CheckMaxTokens, CheckZeroPricing, CheckNegativePrice,
+}
+
+// Issue is an anomaly in catalog data.
+type Issue struct {
+	Severity Severity          `json:"severity"`
+	Check    string            `json:"check"`
+	Provider InferenceProvider `json:"provider"`
+	Model    string            `json:"model,omitempty"`
+	Message  string            `json:"message"`
+}
+
+// Lint checks providers for data that is missing, inconsistent, or
+// implausible, and returns the issues found in catalog order:
+//
+//   - a provider without models, or whose default models are unset or not
+//     among its models
+//   - model IDs listed more than once by one provider
+//   - a context window of 0, or a default max tokens above it or above the
+//     model's output limit
+//   - negative prices
+//   - models without token prices at a provider that prices its other
+//     models, unless they are marked free in their ID or name
+func Lint(providers []Provider) []Issue {
+	var issues []Issue
+	for _, p := range providers {
+		issues = append(issues, lintProvider(p)...)
+	}
+	return issues
+}
+
+func lintProvider(p Provider) []Issue {
+	var issues []Issue
+	add := func(s Severity, check, model, format string, args ...any) {
+		issues = append(issues, Issue{Severity: s, Check: check, Provider: p.ID, Model: model, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(p.Models) == 0 {
+		add(SeverityError, CheckNoModels, "", "provider lists no models")
+		return issues
+	}
+
+	ids := map[string]int{}
+	priced := false
+	for _, m := range p.Models {
+		ids[m.ID]++
+		if m.CostPer1MIn > 0 || m.CostPer1MOut > 0 {
+			priced = true
+		}
+	}
+	for _, d := range []struct{ name, id string }{
+		{"large", p.DefaultLargeModelID},
+		{"small", p.DefaultSmallModelID},
+	} {
+		switch {
+		case d.id == "":
+			add(SeverityError, CheckDefaultModel, "", "default %s model is not set", d.name)
+		case ids[d.id] == 0 && !routable(p, d.id):
+			add(SeverityError, CheckDefaultModel, d.id, "default %s model %q is not among the provider's models", d.name, d.id)
+		}
+	}
+
+	reported := map[string]bool{}
+	for _, m := range p.Models {
+		if n := ids[m.ID]; n > 1 && !reported[m.ID] {
+			reported[m.ID] = true
+			add(SeverityError, CheckDuplicateModel, m.ID, "model is listed %d times", n)
+		}
+
+		switch {
+		case m.ContextWindow <= 0:
+			add(SeverityError, CheckContextWindow, m.ID, "context window is %d", m.ContextWindow)
+		case m.DefaultMaxTokens > m.ContextWindow:
+			add(SeverityWarning, CheckMaxTokens, m.ID, "default max tokens %d exceed the context window of %d", m.DefaultMaxTokens, m.ContextWindow)
+		case m.DefaultMaxTokens > m.OutputLimit():
+			add(SeverityWarning, CheckMaxTokens, m.ID, "default max tokens %d exceed the output limit of %d", m.DefaultMaxTokens, m.MaxOutputTokens)
+		}
+
+		for _, price := range []struct {
+			name  string
+			value float64
+		}{
+			{"input", m.CostPer1MIn},
+			{"output", m.CostPer1MOut},
+			{"cached input", m.CostPer1MInCached},
+			{"cached output", m.CostPer1MOutCached},
+		} {
+			if price.value < 0 {
+				add(SeverityError, CheckNegativePrice, m.ID, "%s price is %g per 1M tokens", price.name, price.value)
+			}
+		}
+
+		if priced && m.CostPer1MIn == 0 && m.CostPer1MOut == 0 && !markedFree(m) {
+			add(SeverityWarning, CheckZeroPricing, m.ID, "model has no token prices, but the provider's other models do")
+		}
+	}
+	return issues
+}
+
+// routable reports whether an unlisted model ID is still one the Hugging
+// Face router accepts, such as a repository without a provider suffix.
+func routable(p Provider, id string) bool {
+	if !p.IsHuggingFace() {
+		return false
+	}
+	_, ok := p.findHuggingFaceModel(id)
+	return ok
+}
+
+// markedFree reports whether a model is free by name, like OpenRouter's
+// ":free" variants.
+func markedFree(m Model) bool {
+	return strings.Contains(strings.ToLower(m.ID), "free") || strings.Contains(strings.ToLower(m.Name), "free")
+}
@@ -0,0 +1,53 @@
+package catwalk
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	providers := []Provider{
+		{
+			ID:                  "good",
+			DefaultLargeModelID: "big",
+			DefaultSmallModelID: "small",
+			Models: []Model{
+				{ID: "big", ContextWindow: 200000, DefaultMaxTokens: 8000, CostPer1MIn: 3, CostPer1MOut: 15},
+				{ID: "small", ContextWindow: 128000, CostPer1MIn: 0.1, CostPer1MOut: 0.4},
+				{ID: "llama:free", ContextWindow: 8000},
+			},
+		},
+		{ID: "empty", DefaultLargeModelID: "x", DefaultSmallModelID: "x"},
+		{
+			ID:                  "bad",
+			DefaultLargeModelID: "gone",
+			Models: []Model{
+				{ID: "a", ContextWindow: 1000, DefaultMaxTokens: 4000, CostPer1MIn: 1, CostPer1MOut: 2},
+				{ID: "a", ContextWindow: 1000, CostPer1MIn: 1, CostPer1MOut: 2},
+				{ID: "b", CostPer1MIn: 1, CostPer1MOut: -2},
+				{ID: "c", ContextWindow: 1000},
+				{ID: "d", ContextWindow: 1000, DefaultMaxTokens: 800, MaxOutputTokens: 500, CostPer1MIn: 1, CostPer1MOut: 2},
+			},
+		},
+	}
+
+	var got []string
+	for _, i := range Lint(providers) {
+		got = append(got, fmt.Sprintf("%s %s %s/%s", i.Severity, i.Check, i.Provider, i.Model))
+	}
+	want := []string{
+		"error no-models empty/",
+		"error default-model bad/gone",
+		"error default-model bad/",
+		"error duplicate-model bad/a",
+		"warning max-tokens bad/a",
+		"error context-window bad/b",
+		"error negative-price bad/b",
+		"warning zero-pricing bad/c",
+		"warning max-tokens bad/d",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Lint =\n%v\nwant\n%v", got, want)
+	}
+}
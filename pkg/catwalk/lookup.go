@@ -0,0 +1,254 @@
+package catwalk
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the number of similar IDs offered in not-found errors.
+const maxSuggestions = 3
+
+// FindProvider returns the provider with the given ID, ignoring case. If
+// there is none, the error is a *ProviderNotFoundError suggesting similar
+// IDs.
+func FindProvider(providers []Provider, id string) (*Provider, error) {
+	ids := make([]string, len(providers))
+	for i := range providers {
+		if strings.EqualFold(string(providers[i].ID), id) {
+			return &providers[i], nil
+		}
+		ids[i] = string(providers[i].ID)
+	}
+	return nil, &ProviderNotFoundError{Provider: id, Suggestions: Suggest(id, ids, maxSuggestions)}
+}
+
+// FindModel returns the model with the given name and the provider offering
+// it. The name is a model ID, found in the first provider listing it, or
+// "provider/model" for a particular provider's. Since model IDs such as
+// "openrouter/openai/gpt-4o" or "openai/gpt-oss-120b:groq" contain slashes
+// too, a name whose prefix is not a provider, or whose provider does not
+// list the rest, is looked up as a whole. If there is no such model, the
+// error is the provider's *ModelNotFoundError when the prefix named one,
+// else a *ModelNotFoundError suggesting similar IDs from every provider.
+// The results point into providers.
+func FindModel(providers []Provider, name string) (*Provider, *Model, error) {
+	// A miss falls through, since the prefix may be an organization instead
+	var providerErr error
+	if id, modelID, ok := strings.Cut(name, "/"); ok {
+		if p, err := FindProvider(providers, id); err == nil {
+			m, err := p.FindModel(modelID)
+			if err == nil {
+				return p, m, nil
+			}
+			providerErr = err
+		}
+	}
+
+	var ids []string
+	for i := range providers {
+		p := &providers[i]
+		if m, err := p.FindModel(name); err == nil {
+			return p, m, nil
+		}
+		for _, m := range p.Models {
+			ids = append(ids, m.ID)
+		}
+	}
+	if providerErr != nil {
+		return nil, nil, providerErr
+	}
+	return nil, nil, &ModelNotFoundError{Model: name, Suggestions: Suggest(name, ids, maxSuggestions)}
+}
+
+// FindModel returns the provider's model with the given ID, ignoring case.
+// For Hugging Face, a model repository without a provider suffix, or with a
+// policy suffix such as ":cheapest", is also found, as a copy of one of the
+// listed variants. If there is none, the error is a *ModelNotFoundError
+// suggesting similar IDs.
+func (p *Provider) FindModel(id string) (*Model, error) {
+	ids := make([]string, len(p.Models))
+	for i := range p.Models {
+		if strings.EqualFold(p.Models[i].ID, id) {
+			return &p.Models[i], nil
+		}
+		ids[i] = p.Models[i].ID
+	}
+	if p.IsHuggingFace() {
+		if m, ok := p.findHuggingFaceModel(id); ok {
+			return m, nil
+		}
+	}
+	return nil, &ModelNotFoundError{Model: id, Provider: p.ID, Suggestions: Suggest(id, ids, maxSuggestions)}
+}
+
+// APIKeyEnv returns the environment variable named by the provider's API
+// key, which the catalog writes as "$NAME", or "" if the key is a literal.
+func (p Provider) APIKeyEnv() string {
+	name, _ := strings.CutPrefix(p.APIKey, "$")
+	if name == p.APIKey {
+		return ""
+	}
+	return name
+}
+
+// ResolveAPIKey returns the provider's API key, reading it from the
+// environment when the catalog names a variable. If the key is empty, the
+// error is a *MissingAPIKeyError.
+func (p Provider) ResolveAPIKey() (string, error) {
+	key := p.APIKey
+	env := p.APIKeyEnv()
+	if env != "" {
+		key = os.Getenv(env)
+	}
+	if key == "" {
+		return "", &MissingAPIKeyError{Provider: p.ID, EnvVar: env}
+	}
+	return key, nil
+}
+
+// Suggest returns up to n candidates similar to name, closest first: those
+// containing it or contained in it, and those within a small edit distance.
+// Case is ignored and duplicates are dropped.
+func Suggest(name string, candidates []string, n int) []string {
+	type scored struct {
+		id       string
+		distance int
+	}
+
+	target := strings.ToLower(name)
+	limit := max(2, len(target)/3)
+	seen := map[string]bool{}
+	var matches []scored
+	for _, c := range candidates {
+		lc := strings.ToLower(c)
+		if seen[lc] || target == "" {
+			continue
+		}
+		seen[lc] = true
+
+		d := editDistance(target, lc)
+		if d <= limit || strings.Contains(lc, target) || strings.Contains(target, lc) {
+			matches = append(matches, scored{c, d})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].id < matches[j].id
+	})
+	var out []string
+	for _, m := range matches[:min(n, len(matches))] {
+		out = append(out, m.id)
+	}
+	return out
+}
+
+// Complete returns up to n candidates for a partly typed input, best first,
+// for autocompletion: those starting with it, then those with a word
+// starting with it (after "/", "-", ":", "." or "_"), then those containing
+// its characters in order, most compactly first. Shorter candidates come
+// first within each group, an empty input keeps candidate order, case is
+// ignored, and duplicates are dropped.
+func Complete(input string, candidates []string, n int) []string {
+	type scored struct {
+		id         string
+		rank, span int
+	}
+
+	target := strings.ToLower(input)
+	seen := map[string]bool{}
+	var matches []scored
+	for _, c := range candidates {
+		lc := strings.ToLower(c)
+		if seen[lc] {
+			continue
+		}
+		seen[lc] = true
+
+		switch {
+		case target == "":
+			matches = append(matches, scored{c, 0, 0})
+		case strings.HasPrefix(lc, target):
+			matches = append(matches, scored{c, 0, len(lc)})
+		case wordPrefix(lc, target):
+			matches = append(matches, scored{c, 1, len(lc)})
+		default:
+			if span, ok := subsequenceSpan(lc, target); ok {
+				matches = append(matches, scored{c, 2, span})
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].rank != matches[j].rank {
+			return matches[i].rank < matches[j].rank
+		}
+		return matches[i].span < matches[j].span
+	})
+	var out []string
+	for _, m := range matches[:min(n, len(matches))] {
+		out = append(out, m.id)
+	}
+	return out
+}
+
+// wordPrefix reports whether a word of s, after a separator, starts with
+// prefix.
+func wordPrefix(s, prefix string) bool {
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte("/-:._", s[i]) >= 0 && strings.HasPrefix(s[i+1:], prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// subsequenceSpan returns the length of the shortest part of s that
+// contains the bytes of sub in order, and whether there is one.
+func subsequenceSpan(s, sub string) (int, bool) {
+	best := -1
+	for start := 0; start < len(s); start++ {
+		if s[start] != sub[0] {
+			continue
+		}
+		j := 1
+		end := start + 1
+		for ; end < len(s) && j < len(sub); end++ {
+			if s[end] == sub[j] {
+				j++
+			}
+		}
+		if j < len(sub) {
+			// No later start can match either
+			break
+		}
+		if span := end - start; best < 0 || span < best {
+			best = span
+		}
+	}
+	return best, best >= 0
+}
+
+// editDistance returns the Levenshtein distance between a and b, in bytes.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
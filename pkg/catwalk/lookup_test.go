@@ -0,0 +1,108 @@
+package catwalk
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestFindProviderAndModel(t *testing.T) {
+	providers := []Provider{
+		{ID: "openai", Models: []Model{{ID: "gpt-4o"}, {ID: "gpt-4o-mini"}, {ID: "o3"}}},
+		{ID: "openrouter"},
+		{ID: "anthropic"},
+	}
+
+	p, err := FindProvider(providers, "OpenAI")
+	if err != nil || p.ID != "openai" {
+		t.Fatalf("FindProvider(OpenAI) = %v, %v", p, err)
+	}
+
+	_, err = FindProvider(providers, "opneai")
+	var perr *ProviderNotFoundError
+	if !errors.As(err, &perr) || !errors.Is(err, ErrProviderNotFound) {
+		t.Fatalf("expected a ProviderNotFoundError, got %v", err)
+	}
+	if !slices.Equal(perr.Suggestions, []string{"openai"}) {
+		t.Errorf("suggestions = %v, want [openai]", perr.Suggestions)
+	}
+
+	if m, err := p.FindModel("GPT-4O"); err != nil || m.ID != "gpt-4o" {
+		t.Fatalf("FindModel(GPT-4O) = %v, %v", m, err)
+	}
+	_, err = p.FindModel("gpt-4")
+	var merr *ModelNotFoundError
+	if !errors.As(err, &merr) || merr.Provider != "openai" {
+		t.Fatalf("expected a ModelNotFoundError, got %v", err)
+	}
+	if !slices.Equal(merr.Suggestions, []string{"gpt-4o", "gpt-4o-mini"}) {
+		t.Errorf("suggestions = %v, want [gpt-4o gpt-4o-mini]", merr.Suggestions)
+	}
+}
+
+func TestFindModelInCatalog(t *testing.T) {
+	providers := []Provider{
+		{ID: "openai", Models: []Model{{ID: "gpt-4o"}}},
+		{ID: "groq", Models: []Model{{ID: "meta-llama/llama-3.3-70b"}}},
+		{ID: "openrouter", Models: []Model{{ID: "openai/gpt-4o"}, {ID: "meta-llama/llama-3.3-70b"}}},
+	}
+	for _, tt := range []struct{ name, provider, model string }{
+		{"GPT-4o", "openai", "gpt-4o"},
+		{"openrouter/openai/gpt-4o", "openrouter", "openai/gpt-4o"},
+		// An organization prefix is part of the model ID
+		{"meta-llama/llama-3.3-70b", "groq", "meta-llama/llama-3.3-70b"},
+		{"openai/gpt-4o", "openai", "gpt-4o"},
+	} {
+		p, m, err := FindModel(providers, tt.name)
+		if err != nil || string(p.ID) != tt.provider || m.ID != tt.model {
+			t.Errorf("FindModel(%q) = %v, %v, %v; want %s/%s", tt.name, p, m, err, tt.provider, tt.model)
+		}
+	}
+
+	var merr *ModelNotFoundError
+	if _, _, err := FindModel(providers, "openai/gpt-5"); !errors.As(err, &merr) || merr.Provider != "openai" {
+		t.Errorf("FindModel(openai/gpt-5) = %v, want not found in openai", err)
+	}
+	if _, _, err := FindModel(providers, "gpt-4"); !errors.As(err, &merr) || merr.Provider != "" || !slices.Contains(merr.Suggestions, "gpt-4o") {
+		t.Errorf("FindModel(gpt-4) = %v, want not found suggesting gpt-4o", err)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	ids := []string{
+		"claude-3-5-sonnet-20241022", "claude-3-5-haiku-20241022", "claude-sonnet-4-5",
+		"gpt-4o", "gpt-4o-mini", "openai/gpt-4o", "GPT-4O", "o3",
+	}
+	for _, tt := range []struct {
+		input string
+		n     int
+		want  []string
+	}{
+		{"gpt-4o", 5, []string{"gpt-4o", "gpt-4o-mini", "openai/gpt-4o"}},
+		{"sonnet", 5, []string{"claude-sonnet-4-5", "claude-3-5-sonnet-20241022"}},
+		{"c35s", 5, []string{"claude-3-5-sonnet-20241022"}},
+		{"4O", 2, []string{"gpt-4o", "gpt-4o-mini"}},
+		{"", 2, []string{"claude-3-5-sonnet-20241022", "claude-3-5-haiku-20241022"}},
+		{"zzz", 5, nil},
+	} {
+		if got := Complete(tt.input, ids, tt.n); !slices.Equal(got, tt.want) {
+			t.Errorf("Complete(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestResolveAPIKey(t *testing.T) {
+	t.Setenv("TEST_CATWALK_KEY", "")
+	p := Provider{ID: "test", APIKey: "$TEST_CATWALK_KEY"}
+
+	_, err := p.ResolveAPIKey()
+	var kerr *MissingAPIKeyError
+	if !errors.As(err, &kerr) || kerr.EnvVar != "TEST_CATWALK_KEY" {
+		t.Fatalf("expected a MissingAPIKeyError naming the variable, got %v", err)
+	}
+
+	t.Setenv("TEST_CATWALK_KEY", "secret")
+	if key, err := p.ResolveAPIKey(); err != nil || key != "secret" {
+		t.Errorf("ResolveAPIKey() = %q, %v", key, err)
+	}
+}
@@ -14,6 +14,7 @@ const (
 	TypeAzure        Type = "azure"
 	TypeBedrock      Type = "bedrock"
 	TypeVertexAI     Type = "google-vertex"
+	TypeHuggingFace  Type = "huggingface"
 )
 
 // InferenceProvider represents the inference provider identifier.
@@ -66,21 +67,41 @@ type ModelOptions struct {
 	ProviderOptions  map[string]any `json:"provider_options,omitempty"`
 }
 
+// ImageTier is a flat per-image price, for models that bill images by size
+// or quality rather than by tokens.
+type ImageTier struct {
+	Name      string  `json:"name"`
+	MaxPixels int64   `json:"max_pixels,omitempty"`
+	Cost      float64 `json:"cost"`
+}
+
+// AudioPricing stores audio input/output prices, per minute or per 1M
+// audio tokens depending on how the provider bills.
+type AudioPricing struct {
+	CostPerMinuteIn  float64 `json:"cost_per_minute_in,omitempty"`
+	CostPerMinuteOut float64 `json:"cost_per_minute_out,omitempty"`
+	CostPer1MIn      float64 `json:"cost_per_1m_in,omitempty"`
+	CostPer1MOut     float64 `json:"cost_per_1m_out,omitempty"`
+}
+
 // Model represents an AI model configuration.
 type Model struct {
-	ID                     string       `json:"id"`
-	Name                   string       `json:"name"`
-	CostPer1MIn            float64      `json:"cost_per_1m_in"`
-	CostPer1MOut           float64      `json:"cost_per_1m_out"`
-	CostPer1MInCached      float64      `json:"cost_per_1m_in_cached"`
-	CostPer1MOutCached     float64      `json:"cost_per_1m_out_cached"`
-	ContextWindow          int64        `json:"context_window"`
-	DefaultMaxTokens       int64        `json:"default_max_tokens"`
-	CanReason              bool         `json:"can_reason"`
-	ReasoningLevels        []string     `json:"reasoning_levels,omitempty"`
-	DefaultReasoningEffort string       `json:"default_reasoning_effort,omitempty"`
-	SupportsImages         bool         `json:"supports_attachments"`
-	Options                ModelOptions `json:"options"`
+	ID                     string        `json:"id"`
+	Name                   string        `json:"name"`
+	CostPer1MIn            float64       `json:"cost_per_1m_in"`
+	CostPer1MOut           float64       `json:"cost_per_1m_out"`
+	CostPer1MInCached      float64       `json:"cost_per_1m_in_cached"`
+	CostPer1MOutCached     float64       `json:"cost_per_1m_out_cached"`
+	ContextWindow          int64         `json:"context_window"`
+	DefaultMaxTokens       int64         `json:"default_max_tokens"`
+	MaxOutputTokens        int64         `json:"max_output_tokens,omitempty"`
+	CanReason              bool          `json:"can_reason"`
+	ReasoningLevels        []string      `json:"reasoning_levels,omitempty"`
+	DefaultReasoningEffort string        `json:"default_reasoning_effort,omitempty"`
+	SupportsImages         bool          `json:"supports_attachments"`
+	Options                ModelOptions  `json:"options"`
+	ImagePricing           []ImageTier   `json:"image_pricing,omitempty"`
+	AudioPricing           *AudioPricing `json:"audio_pricing,omitempty"`
 }
 
 // KnownProviders returns all the known inference providers.
@@ -121,5 +142,6 @@ func KnownProviderTypes() []Type {
 		TypeAzure,
 		TypeBedrock,
 		TypeVertexAI,
+		TypeHuggingFace,
 	}
 }
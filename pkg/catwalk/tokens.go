@@ -0,0 +1,32 @@
+package catwalk
+
+// OutputLimit returns the most tokens a reply from m can have: its
+// MaxOutputTokens if the catalog lists one, else its context window, which
+// no reply can exceed. It returns 0 if neither is known.
+func (m Model) OutputLimit() int64 {
+	if m.MaxOutputTokens > 0 {
+		return m.MaxOutputTokens
+	}
+	return max(m.ContextWindow, 0)
+}
+
+// CheckMaxTokens returns a *MaxTokensError if a reply limit of n tokens is
+// more than m can produce, which providers reject with an opaque 400. A
+// non-positive n, meaning the model's default, is always valid, as is any n
+// for a model without known limits.
+func (m Model) CheckMaxTokens(n int64) error {
+	limit := m.OutputLimit()
+	if n <= 0 || limit == 0 || n <= limit {
+		return nil
+	}
+	return &MaxTokensError{Model: m.ID, Requested: n, Limit: limit, ContextWindow: m.MaxOutputTokens <= 0}
+}
+
+// ClampMaxTokens returns n lowered to m's output limit if it exceeds it, and
+// n otherwise.
+func (m Model) ClampMaxTokens(n int64) int64 {
+	if m.CheckMaxTokens(n) != nil {
+		return m.OutputLimit()
+	}
+	return n
+}
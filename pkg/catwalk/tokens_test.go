@@ -0,0 +1,33 @@
+package catwalk
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMaxTokens(t *testing.T) {
+	capped := Model{ID: "gpt-4o", ContextWindow: 128000, MaxOutputTokens: 16384}
+	window := Model{ID: "llama", ContextWindow: 8000}
+	for _, tt := range []struct {
+		m       Model
+		n, want int64
+		err     string
+	}{
+		{capped, 0, 0, ""},
+		{capped, 16384, 16384, ""},
+		{capped, 20000, 16384, "max tokens 20000 exceed the 16384-token output limit of gpt-4o"},
+		{window, 9000, 8000, "max tokens 9000 exceed the 8000-token context window of llama"},
+		{Model{ID: "unknown"}, 9000, 9000, ""},
+	} {
+		err := tt.m.CheckMaxTokens(tt.n)
+		if (err == nil && tt.err != "") || (err != nil && err.Error() != tt.err) {
+			t.Errorf("%s.CheckMaxTokens(%d) = %v, want %q", tt.m.ID, tt.n, err, tt.err)
+		}
+		if err != nil && !errors.Is(err, ErrMaxTokens) {
+			t.Errorf("%v does not wrap ErrMaxTokens", err)
+		}
+		if got := tt.m.ClampMaxTokens(tt.n); got != tt.want {
+			t.Errorf("%s.ClampMaxTokens(%d) = %d, want %d", tt.m.ID, tt.n, got, tt.want)
+		}
+	}
+}
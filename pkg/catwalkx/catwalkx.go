@@ -0,0 +1,157 @@
+// Package catwalkx offers high-level helpers over the catwalk client, so
+// programs embedding the catalog need not repeat the loops every example
+// writes: finding a model by name in any provider, listing the providers of
+// a type, and filtering models. The catalog is fetched once and reused for a
+// TTL, after which it is checked again with its ETag, so an unchanged
+// catalog is not downloaded twice.
+//
+//	c := catwalkx.New(nil)
+//	p, m, err := c.FindModel(ctx, "gpt-4o")
+//	cheap, err := c.ModelsWhere(ctx, func(p catwalk.Provider, m catwalk.Model) bool {
+//		return m.CostPer1MIn < 1
+//	})
+package catwalkx
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// DefaultTTL is how long a fetched catalog is used before it is checked
+// again, unless [Client.TTL] is set.
+const DefaultTTL = 5 * time.Minute
+
+// Match is a model together with the provider offering it.
+type Match struct {
+	Provider catwalk.Provider
+	Model    catwalk.Model
+}
+
+// Client answers questions about the catalog, fetching it through a
+// catwalk client and caching it.
+//
+// A Client is safe for concurrent use. Concurrent calls that find the cache
+// expired wait for a single fetch.
+type Client struct {
+	// TTL is how long a fetched catalog is used before it is checked again;
+	// zero means DefaultTTL, and a negative TTL checks on every call.
+	TTL time.Duration
+
+	client *catwalk.Client
+
+	mu        sync.Mutex
+	providers []catwalk.Provider
+	etag      string
+	fetched   time.Time
+	now       func() time.Time
+}
+
+// New returns a Client fetching the catalog with client, or with
+// [catwalk.New] if client is nil.
+func New(client *catwalk.Client) *Client {
+	if client == nil {
+		client = catwalk.New()
+	}
+	return &Client{client: client, now: time.Now}
+}
+
+// Providers returns the catalog's providers, fetching them if the cache is
+// empty or older than the TTL. A failed fetch returns its error and leaves
+// the cache as it was, to be tried again on the next call. The providers
+// are shared with other callers and must not be modified.
+func (c *Client) Providers(ctx context.Context) ([]catwalk.Provider, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ttl := c.TTL
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	if c.providers != nil && c.now().Sub(c.fetched) < ttl {
+		return c.providers, nil
+	}
+
+	providers, etag, err := c.client.GetProvidersWithETag(ctx, c.etag)
+	switch {
+	case err == catwalk.ErrNotModified && c.providers != nil:
+		// The cached catalog is still current
+	case err != nil:
+		return nil, err //nolint:wrapcheck
+	default:
+		c.providers = providers
+	}
+	c.etag = etag
+	c.fetched = c.now()
+	return c.providers, nil
+}
+
+// Invalidate empties the cache, so the next call fetches the catalog in
+// full.
+func (c *Client) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.providers = nil
+	c.etag = ""
+}
+
+// Provider returns the provider with the given ID, ignoring case. If there
+// is none, the error is a *catwalk.ProviderNotFoundError suggesting similar
+// IDs.
+func (c *Client) Provider(ctx context.Context, id string) (*catwalk.Provider, error) {
+	providers, err := c.Providers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return catwalk.FindProvider(providers, id) //nolint:wrapcheck
+}
+
+// FindModel returns the model with the given name, a model ID or
+// "provider/model", and the provider offering it; see [catwalk.FindModel].
+// The results point into the cache and must not be modified.
+func (c *Client) FindModel(ctx context.Context, name string) (*catwalk.Provider, *catwalk.Model, error) {
+	providers, err := c.Providers(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return catwalk.FindModel(providers, name) //nolint:wrapcheck
+}
+
+// ProvidersByType returns the providers whose API is of type t, such as
+// catwalk.TypeOpenAICompat, in catalog order.
+func (c *Client) ProvidersByType(ctx context.Context, t catwalk.Type) ([]catwalk.Provider, error) {
+	providers, err := c.Providers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []catwalk.Provider
+	for _, p := range providers {
+		if p.Type == t {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// ModelsWhere returns every model for which pred returns true, with its
+// provider, in catalog order. The Satisfies method of pkg/selector's
+// Requirements makes a predicate too:
+//
+//	c.ModelsWhere(ctx, selector.Requirements{Vision: true}.Satisfies)
+func (c *Client) ModelsWhere(ctx context.Context, pred func(catwalk.Provider, catwalk.Model) bool) ([]Match, error) {
+	providers, err := c.Providers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []Match
+	for _, p := range providers {
+		for _, m := range p.Models {
+			if pred(p, m) {
+				out = append(out, Match{Provider: p, Model: m})
+			}
+		}
+	}
+	return out, nil
+}
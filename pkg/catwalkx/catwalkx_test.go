@@ -0,0 +1,177 @@
+package catwalkx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+	xetag "github.com/charmbracelet/x/etag"
+)
+
+var testProviders = []catwalk.Provider{
+	{ID: "openai", Type: catwalk.TypeOpenAI, Models: []catwalk.Model{
+		{ID: "gpt-4o", CostPer1MIn: 2.5},
+		{ID: "gpt-4o-mini", CostPer1MIn: 0.15},
+	}},
+	{ID: "groq", Type: catwalk.TypeOpenAICompat, Models: []catwalk.Model{
+		{ID: "meta-llama/llama-3.3-70b", CostPer1MIn: 0.59},
+	}},
+	{ID: "openrouter", Type: catwalk.TypeOpenRouter, Models: []catwalk.Model{
+		{ID: "openai/gpt-4o", CostPer1MIn: 2.5},
+		{ID: "meta-llama/llama-3.3-70b", CostPer1MIn: 0.1},
+	}},
+	{ID: "deepseek", Type: catwalk.TypeOpenAICompat, Models: []catwalk.Model{
+		{ID: "deepseek-chat", CostPer1MIn: 0.27},
+	}},
+}
+
+// catalogServer serves testProviders with an ETag, counting the requests
+// that download the catalog and those answered 304 Not Modified.
+func catalogServer(t *testing.T) (srv *httptest.Server, full, notModified *int) {
+	t.Helper()
+	data, err := json.Marshal(testProviders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag := catwalk.Etag(data)
+	full, notModified = new(int), new(int)
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		xetag.Response(w, etag)
+		if xetag.Matches(r, etag) {
+			*notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		*full++
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, full, notModified
+}
+
+func TestFindModel(t *testing.T) {
+	srv, _, _ := catalogServer(t)
+	c := New(catwalk.NewWithURL(srv.URL))
+	ctx := context.Background()
+
+	tests := []struct {
+		name, provider, model string
+	}{
+		{"gpt-4o", "openai", "gpt-4o"},
+		{"GPT-4o-Mini", "openai", "gpt-4o-mini"},
+		{"openrouter/openai/gpt-4o", "openrouter", "openai/gpt-4o"},
+		{"openrouter/meta-llama/llama-3.3-70b", "openrouter", "meta-llama/llama-3.3-70b"},
+		// An organization prefix is part of the model ID
+		{"meta-llama/llama-3.3-70b", "groq", "meta-llama/llama-3.3-70b"},
+		{"openai/gpt-4o", "openai", "gpt-4o"},
+	}
+	for _, tt := range tests {
+		p, m, err := c.FindModel(ctx, tt.name)
+		if err != nil {
+			t.Errorf("FindModel(%q): %v", tt.name, err)
+			continue
+		}
+		if string(p.ID) != tt.provider || m.ID != tt.model {
+			t.Errorf("FindModel(%q) = %s/%s, want %s/%s", tt.name, p.ID, m.ID, tt.provider, tt.model)
+		}
+	}
+
+	_, _, err := c.FindModel(ctx, "gpt-4")
+	var notFound *catwalk.ModelNotFoundError
+	if !errors.As(err, &notFound) || !slices.Contains(notFound.Suggestions, "gpt-4o") {
+		t.Errorf("FindModel(gpt-4) = %v, want a not found error suggesting gpt-4o", err)
+	}
+	_, _, err = c.FindModel(ctx, "openai/gpt-5")
+	if !errors.As(err, &notFound) || notFound.Provider != "openai" {
+		t.Errorf("FindModel(openai/gpt-5) = %v, want a not found error in openai", err)
+	}
+	if _, err := c.Provider(ctx, "DeepSeek"); err != nil {
+		t.Errorf("Provider(DeepSeek): %v", err)
+	}
+	if _, err := c.Provider(ctx, "groc"); !errors.Is(err, catwalk.ErrProviderNotFound) {
+		t.Errorf("Provider(groc) = %v, want not found", err)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	srv, _, _ := catalogServer(t)
+	c := New(catwalk.NewWithURL(srv.URL))
+	ctx := context.Background()
+
+	compat, err := c.ProvidersByType(ctx, catwalk.TypeOpenAICompat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []catwalk.InferenceProvider
+	for _, p := range compat {
+		ids = append(ids, p.ID)
+	}
+	if want := []catwalk.InferenceProvider{"groq", "deepseek"}; !slices.Equal(ids, want) {
+		t.Errorf("ProvidersByType(openai-compat) = %v, want %v", ids, want)
+	}
+
+	cheap, err := c.ModelsWhere(ctx, func(_ catwalk.Provider, m catwalk.Model) bool { return m.CostPer1MIn < 0.5 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range cheap {
+		got = append(got, string(m.Provider.ID)+"/"+m.Model.ID)
+	}
+	if want := []string{"openai/gpt-4o-mini", "openrouter/meta-llama/llama-3.3-70b", "deepseek/deepseek-chat"}; !slices.Equal(got, want) {
+		t.Errorf("ModelsWhere(cheap) = %v, want %v", got, want)
+	}
+}
+
+func TestCache(t *testing.T) {
+	srv, full, notModified := catalogServer(t)
+	c := New(catwalk.NewWithURL(srv.URL))
+	c.TTL = time.Minute
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for range 3 {
+		if _, _, err := c.FindModel(ctx, "gpt-4o"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if *full != 1 || *notModified != 0 {
+		t.Fatalf("within the TTL: %d downloads, %d checks; want 1, 0", *full, *notModified)
+	}
+
+	// Once expired, the unchanged catalog is checked, not downloaded
+	now = now.Add(time.Minute)
+	providers, err := c.Providers(ctx)
+	if err != nil || len(providers) != len(testProviders) {
+		t.Fatalf("Providers after the TTL = %d providers, %v", len(providers), err)
+	}
+	if *full != 1 || *notModified != 1 {
+		t.Fatalf("after the TTL: %d downloads, %d checks; want 1, 1", *full, *notModified)
+	}
+
+	c.Invalidate()
+	if _, err := c.Providers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if *full != 2 {
+		t.Errorf("after Invalidate: %d downloads, want 2", *full)
+	}
+
+	// A failed fetch is reported, and the cache kept for the next call
+	srv.Close()
+	now = now.Add(time.Hour)
+	if _, err := c.Providers(ctx); err == nil {
+		t.Error("Providers with the server down succeeded")
+	}
+	c.TTL = 2 * time.Hour
+	if providers, err := c.Providers(ctx); err != nil || len(providers) != len(testProviders) {
+		t.Errorf("Providers from the cache = %d providers, %v", len(providers), err)
+	}
+}
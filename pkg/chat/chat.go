@@ -0,0 +1,645 @@
+// Package chat manages a conversation with a model through any catwalk
+// provider's OpenAI-compatible API, tracking token usage and cost.
+//
+// A [Session] is safe for concurrent use: requests are serialized so turns
+// never interleave, while history and totals can be read at any time. This
+// lets servers, fan-out tools, and bots share the same conversation and cost
+// logic.
+//
+// Tool calls in a streamed reply arrive as fragments spread over many
+// chunks; [ToolCallAssembler] merges them into whole calls, and
+// [ParseArguments] decodes their JSON arguments even before they are
+// complete.
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"charm.land/catwalk/pkg/auth"
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/openrouter"
+	"charm.land/catwalk/pkg/tokenizer"
+	"charm.land/catwalk/pkg/transcript"
+	"charm.land/catwalk/pkg/vertex"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Message roles.
+const (
+	RoleSystem    = openai.ChatMessageRoleSystem
+	RoleUser      = openai.ChatMessageRoleUser
+	RoleAssistant = openai.ChatMessageRoleAssistant
+)
+
+// Message is a chat message. It is the transcript type, so conversations can
+// be logged as is.
+type Message = transcript.Message
+
+// Response is the outcome of one request.
+type Response struct {
+	Content   string
+	ToolCalls []ToolCall
+	// Reasoning is the model's account of its reasoning, from providers
+	// that return one: a summary from the Responses API, DeepSeek's
+	// reasoning content, OpenRouter's reasoning, or a <think> block at the
+	// start of the reply. It is not part of Content or the history.
+	Reasoning    string
+	InputTokens  int
+	OutputTokens int
+	Cost         float64
+
+	// ReasoningTokens are the part of OutputTokens spent reasoning, as
+	// reported by the provider or else estimated from Reasoning. They are
+	// billed as output, so Cost includes them.
+	ReasoningTokens int
+
+	// CacheReadTokens are the part of InputTokens the provider reported
+	// reading from its prompt cache; zero when it reports none.
+	CacheReadTokens int
+
+	// Estimated is set when the provider did not report usage and the token
+	// counts come from the local tokenizer.
+	Estimated bool
+
+	// Billed is set when Cost is the amount the provider billed (OpenRouter
+	// usage accounting) rather than computed from catalog prices.
+	Billed bool
+
+	// Upstream and Model are the provider and model a router such as
+	// OpenRouter, or [Failover], actually used.
+	Upstream string
+	Model    string
+
+	// FailoverReason is why Model answered instead of the requested model
+	// when [Failover] fell back to it, such as "gpt-4o: HTTP 503".
+	FailoverReason string
+
+	// Cached is set when the reply came from a [Cache] rather than the
+	// provider; it has no usage or cost.
+	Cached bool
+
+	// Truncated is set when the request was cancelled or timed out
+	// mid-reply, so Content is only what arrived before. Its usage is
+	// estimated for the part that was streamed.
+	Truncated bool
+}
+
+// TimeoutError is returned when a request takes longer than
+// [Config.Timeout]. It wraps context.DeadlineExceeded.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("request timed out after %s", e.Timeout)
+}
+
+// Unwrap returns context.DeadlineExceeded.
+func (e *TimeoutError) Unwrap() error { return context.DeadlineExceeded }
+
+// Usage is the running total over a session's requests.
+type Usage struct {
+	Requests        int
+	InputTokens     int
+	OutputTokens    int
+	ReasoningTokens int
+	Cost            float64
+}
+
+// Config holds per-request settings.
+type Config struct {
+	// MaxTokens limits the reply. Zero uses the model's default.
+	MaxTokens int
+
+	// Budget is the most the session may spend, in USD. Once its cost
+	// reaches Budget, requests fail with a *catwalk.OverBudgetError. Zero
+	// means no limit.
+	Budget float64
+
+	// Prepare, if set, adjusts each request before it is sent, for example
+	// to apply sampling parameters.
+	Prepare func(*openai.ChatCompletionRequest)
+
+	// ToolCalls receives the tool calls of each reply as they stream. Tools
+	// are offered by setting them in Prepare; the calls are also returned in
+	// [Response.ToolCalls], but the history keeps only the reply's text.
+	ToolCalls ToolCallHandler
+
+	// Reasoning, if set, receives the reasoning of each reply as it
+	// streams, before the reply itself; see [Response.Reasoning].
+	Reasoning io.Writer
+
+	// Middleware wraps each request after Prepare, first outermost; see
+	// [Chain]. The budget is checked before the middleware runs, and the
+	// response it returns is what the session records.
+	Middleware []Middleware
+
+	// Timeout limits each request, including any retries by middleware.
+	// When it expires, the request is cancelled and fails with a
+	// *TimeoutError; a reply cut off mid-stream is returned along with it,
+	// marked Truncated. Zero means no limit.
+	Timeout time.Duration
+}
+
+// Session is a conversation with one model.
+type Session struct {
+	client   *openai.Client
+	provider catwalk.Provider
+	model    catwalk.Model
+
+	// turn serializes requests; mu guards the fields below.
+	turn     sync.Mutex
+	mu       sync.Mutex
+	config   Config
+	messages []Message
+	usage    Usage
+	upstream map[string]float64
+}
+
+// New returns a session talking to model through client.
+func New(client *openai.Client, provider catwalk.Provider, model catwalk.Model) *Session {
+	return &Session{client: client, provider: provider, model: model}
+}
+
+// NewClient returns an OpenAI-compatible client for provider, sending its
+// default headers on every request through base. The API key is sent the way
+// the provider expects; see [auth.For]. Hugging Face endpoints are mapped to
+// the router's OpenAI-compatible base; see [catwalk.HuggingFaceEndpoint].
+// Vertex AI requests are sent to the model's publisher; see pkg/vertex.
+// Chat requests to models only served by the OpenAI Responses API are sent
+// there and the replies converted back; see [UsesResponses].
+func NewClient(provider catwalk.Provider, apiKey string, base http.RoundTripper) *openai.Client {
+	// The key is added by the auth transport rather than as a bearer token
+	config := openai.DefaultConfig("")
+	config.BaseURL = provider.APIEndpoint
+	switch {
+	case provider.IsHuggingFace():
+		config.BaseURL = catwalk.HuggingFaceEndpoint(provider.APIEndpoint)
+	case provider.Type == catwalk.TypeVertexAI:
+		config.BaseURL = vertex.BaseURL(provider)
+	}
+
+	base = auth.For(provider).Transport(apiKey, base)
+	if len(provider.DefaultHeaders) > 0 {
+		base = &headerTransport{base: base, headers: provider.DefaultHeaders}
+	}
+	if provider.Type == catwalk.TypeVertexAI {
+		base = vertex.Transport(base)
+	}
+	if t := newResponsesTransport(provider, base); t != nil {
+		base = t
+	}
+	config.HTTPClient = &http.Client{Transport: base}
+
+	return openai.NewClientWithConfig(config)
+}
+
+// NewClientFor is like [NewClient] but resolves the provider's API key
+// itself. A missing key is an error, a *catwalk.MissingAPIKeyError, unless
+// the provider authenticates without one; see [auth.Scheme.NeedsKey].
+func NewClientFor(provider catwalk.Provider, base http.RoundTripper) (*openai.Client, error) {
+	key, err := provider.ResolveAPIKey()
+	if err != nil && auth.For(provider).NeedsKey() {
+		return nil, err //nolint:wrapcheck
+	}
+	return NewClient(provider, key, base), nil
+}
+
+// Target is a model found in the catalog, with a client for its provider.
+type Target struct {
+	Client   *openai.Client
+	Provider catwalk.Provider
+	Model    catwalk.Model
+}
+
+// Resolve finds a model by name, a model ID or "provider/model" (see
+// [catwalk.FindModel]), and creates a client for its provider with
+// [NewClientFor] that sends requests through base.
+func Resolve(providers []catwalk.Provider, name string, base http.RoundTripper) (Target, error) {
+	provider, model, err := catwalk.FindModel(providers, name)
+	if err != nil {
+		return Target{}, err //nolint:wrapcheck
+	}
+	client, err := NewClientFor(*provider, base)
+	if err != nil {
+		return Target{}, err
+	}
+	return Target{Client: client, Provider: *provider, Model: *model}, nil
+}
+
+// headerTransport adds custom headers to all requests.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// Provider returns the session's provider.
+func (s *Session) Provider() catwalk.Provider {
+	return s.provider
+}
+
+// Model returns the session's model.
+func (s *Session) Model() catwalk.Model {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.model
+}
+
+// SetModel switches to another of the provider's models from the next
+// request on, keeping the history and usage so far.
+func (s *Session) SetModel(m catwalk.Model) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.model = m
+}
+
+// Config returns the request settings.
+func (s *Session) Config() Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config
+}
+
+// SetConfig replaces the request settings used from the next request on.
+func (s *Session) SetConfig(c Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = c
+}
+
+// Append adds a message to the history.
+func (s *Session) Append(role, content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, Message{Role: role, Content: content})
+}
+
+// Messages returns a copy of the history.
+func (s *Session) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// SetMessages replaces the history.
+func (s *Session) SetMessages(messages []Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append([]Message(nil), messages...)
+}
+
+// SetSystem replaces the system prompt, adding it at the start of the
+// history if there is none. An empty prompt removes it.
+func (s *Session) SetSystem(content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hasSystem := len(s.messages) > 0 && s.messages[0].Role == RoleSystem
+	switch {
+	case content == "" && hasSystem:
+		s.messages = s.messages[1:]
+	case content == "":
+	case hasSystem:
+		s.messages[0].Content = content
+	default:
+		s.messages = append([]Message{{Role: RoleSystem, Content: content}}, s.messages...)
+	}
+}
+
+// Clear removes the history, keeping the system prompt if there is one.
+func (s *Session) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.messages) > 0 && s.messages[0].Role == RoleSystem {
+		s.messages = s.messages[:1]
+	} else {
+		s.messages = nil
+	}
+}
+
+// ContextTokens returns the estimated number of tokens the history takes up
+// on the next request.
+func (s *Session) ContextTokens() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return contextTokens(s.messages)
+}
+
+func contextTokens(messages []Message) int {
+	used := tokenizer.ReplyOverhead
+	for _, m := range messages {
+		used += tokenizer.CountMessage(m.Role, m.Content)
+	}
+	return used
+}
+
+// ReplyTokens returns the number of tokens reserved for the reply.
+func (s *Session) ReplyTokens() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.replyTokens()
+}
+
+func (s *Session) replyTokens() int {
+	if s.config.MaxTokens > 0 {
+		return s.config.MaxTokens
+	}
+	return int(s.model.DefaultMaxTokens)
+}
+
+// Usage returns the totals over all requests so far, including failed or
+// interrupted ones that consumed tokens.
+func (s *Session) Usage() Usage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage
+}
+
+// CostByUpstream returns the session cost per upstream provider, for
+// providers such as OpenRouter that report one.
+func (s *Session) CostByUpstream() map[string]float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	costs := make(map[string]float64, len(s.upstream))
+	for k, v := range s.upstream {
+		costs[k] = v
+	}
+	return costs
+}
+
+// Cost returns the cost of a request with the given token counts at the
+// model's catalog prices.
+func Cost(model catwalk.Model, inputTokens, outputTokens int) float64 {
+	inputCost := float64(inputTokens) * model.CostPer1MIn / 1_000_000
+	outputCost := float64(outputTokens) * model.CostPer1MOut / 1_000_000
+	return inputCost + outputCost
+}
+
+// Send adds a user message and returns the reply, which is added to the
+// history. If the request fails, the user message is removed again.
+func (s *Session) Send(ctx context.Context, content string) (*Response, error) {
+	return s.Stream(ctx, content, io.Discard)
+}
+
+// Stream is like [Session.Send] but writes the reply to w as it arrives. If
+// ctx is cancelled or [Config.Timeout] expires mid-reply, the partial
+// response is returned along with the error.
+func (s *Session) Stream(ctx context.Context, content string, w io.Writer) (*Response, error) {
+	s.turn.Lock()
+	defer s.turn.Unlock()
+
+	sent := Message{Role: RoleUser, Content: content}
+	s.mu.Lock()
+	i := len(s.messages)
+	s.messages = append(s.messages, sent)
+	s.mu.Unlock()
+
+	resp, err := s.complete(ctx, w)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		// The history may have been cleared or replaced meanwhile, in which
+		// case the message is already gone
+		if i < len(s.messages) && s.messages[i] == sent {
+			s.messages = slices.Delete(s.messages, i, i+1)
+		}
+		return resp, err
+	}
+	s.messages = append(s.messages, Message{Role: RoleAssistant, Content: resp.Content})
+	return resp, nil
+}
+
+// Complete sends the history as it is and streams the reply to w, without
+// adding it to the history. Its usage is added to the session totals.
+func (s *Session) Complete(ctx context.Context, w io.Writer) (*Response, error) {
+	s.turn.Lock()
+	defer s.turn.Unlock()
+	return s.complete(ctx, w)
+}
+
+func (s *Session) complete(ctx context.Context, w io.Writer) (*Response, error) {
+	s.mu.Lock()
+	messages := append([]Message(nil), s.messages...)
+	config := s.config
+	model := s.model
+	maxTokens := s.replyTokens()
+	spent := s.usage.Cost
+	s.mu.Unlock()
+
+	if config.Budget > 0 && spent >= config.Budget {
+		return nil, &catwalk.OverBudgetError{Spent: spent, Budget: config.Budget}
+	}
+
+	req := openai.ChatCompletionRequest{
+		Model:         model.ID,
+		Stream:        true,
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+		MaxTokens:     maxTokens,
+	}
+	if UsesResponses(s.provider, model) {
+		// go-openai refuses max_tokens for reasoning models, and the
+		// Responses API takes the limit under another name anyway
+		req.MaxTokens, req.MaxCompletionTokens = 0, maxTokens
+	}
+	for _, m := range messages {
+		req.Messages = append(req.Messages, openai.ChatCompletionMessage{Role: m.Role, Content: m.Content})
+	}
+	if config.Prepare != nil {
+		config.Prepare(&req)
+	}
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, config.Timeout, &TimeoutError{Timeout: config.Timeout})
+		defer cancel()
+	}
+
+	h := Chain(HandlerFunc(s.stream), config.Middleware...)
+	resp, err := h.Complete(ctx, &Request{Provider: s.provider, Model: model, Params: req, Output: w, Reasoning: config.Reasoning, ToolCalls: config.ToolCalls})
+	if resp != nil {
+		s.record(resp)
+	}
+	var timeout *TimeoutError
+	if err != nil && errors.As(context.Cause(ctx), &timeout) {
+		err = timeout
+	}
+	return resp, err
+}
+
+// stream runs req and collects the reply, estimating usage locally when the
+// provider does not report it. It is the last handler of every chain.
+func (s *Session) stream(ctx context.Context, req *Request) (*Response, error) {
+	stream, err := s.client.CreateChatCompletionStream(ctx, req.Params)
+	if err != nil {
+		return nil, fmt.Errorf("API call failed: %w", err)
+	}
+	defer stream.Close() //nolint:errcheck
+
+	isOpenRouter := s.provider.ID == catwalk.InferenceProviderOpenRouter || s.provider.Type == catwalk.TypeOpenRouter
+	w := req.Output
+	tools := ToolCallAssembler{Handler: req.ToolCalls}
+	var content, reasoning []byte
+	var think thinkSplitter
+	estimate := func(content string) *Response {
+		messages := make([]Message, len(req.Params.Messages))
+		for i, m := range req.Params.Messages {
+			messages[i] = Message{Role: m.Role, Content: m.Content}
+		}
+		thought := strings.TrimSpace(string(reasoning))
+		in, out := contextTokens(messages), tokenizer.Count(content)+tokenizer.Count(thought)
+		for _, c := range tools.Calls() {
+			out += tokenizer.Count(c.Name + c.Arguments)
+		}
+		return &Response{
+			Content: content, ToolCalls: tools.Calls(), Reasoning: thought, ReasoningTokens: tokenizer.Count(thought),
+			InputTokens: in, OutputTokens: out, Cost: Cost(req.Model, in, out), Estimated: true,
+		}
+	}
+	// emit passes on a delta of the reply and of its reasoning
+	emit := func(delta, thought string) error {
+		reasoning = append(reasoning, thought...)
+		if req.Reasoning != nil && thought != "" {
+			if _, err := io.WriteString(req.Reasoning, thought); err != nil {
+				return fmt.Errorf("failed to write reasoning: %w", err)
+			}
+		}
+		content = append(content, delta...)
+		if _, err := io.WriteString(w, delta); err != nil {
+			return fmt.Errorf("failed to write reply: %w", err)
+		}
+		return nil
+	}
+
+	var usage *openai.Usage
+	var meta openrouter.Metadata
+	for {
+		raw, err := stream.RecvRaw()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if ctx.Err() == nil || (len(content) == 0 && len(reasoning) == 0 && len(tools.Calls()) == 0) {
+				return nil, fmt.Errorf("API call failed: %w", err)
+			}
+			// Interrupted mid-stream: report what arrived so far, which
+			// was billed
+			resp := estimate(string(content))
+			resp.Upstream = meta.Provider
+			resp.Truncated = true
+			return resp, context.Cause(ctx)
+		}
+
+		var chunk openai.ChatCompletionStreamResponse
+		if err := json.Unmarshal(raw, &chunk); err != nil {
+			return nil, fmt.Errorf("invalid response chunk: %w", err)
+		}
+		if isOpenRouter {
+			if err := meta.Merge(raw); err != nil {
+				return nil, err
+			}
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if len(chunk.Choices) > 0 {
+			tools.Add(chunk.Choices[0].Delta.ToolCalls)
+			thought := chunk.Choices[0].Delta.ReasoningContent
+			if thought == "" {
+				thought = reasoningDelta(raw)
+			}
+			delta, inline := think.split(chunk.Choices[0].Delta.Content)
+			if err := emit(delta, thought+inline); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := emit(think.flush()); err != nil {
+		return nil, err
+	}
+
+	calls := tools.Finish()
+	thought := strings.TrimSpace(string(reasoning))
+	if len(content) == 0 && len(calls) == 0 {
+		return nil, errors.New("no response from model")
+	}
+	switch {
+	case meta.Usage != nil:
+		// OpenRouter reports native token counts and the billed cost
+		return &Response{
+			Content:         string(content),
+			ToolCalls:       calls,
+			Reasoning:       thought,
+			InputTokens:     meta.Usage.PromptTokens,
+			OutputTokens:    meta.Usage.CompletionTokens,
+			ReasoningTokens: reasoningTokens(meta.Usage.CompletionTokensDetails.ReasoningTokens, thought),
+			CacheReadTokens: meta.Usage.PromptTokensDetails.CachedTokens,
+			Cost:            meta.Usage.Cost,
+			Billed:          true,
+			Upstream:        meta.Provider,
+			Model:           meta.Model,
+		}, nil
+	case usage != nil:
+		reported, cached := 0, 0
+		if usage.CompletionTokensDetails != nil {
+			reported = usage.CompletionTokensDetails.ReasoningTokens
+		}
+		if usage.PromptTokensDetails != nil {
+			cached = usage.PromptTokensDetails.CachedTokens
+		}
+		return &Response{
+			Content:         string(content),
+			ToolCalls:       calls,
+			Reasoning:       thought,
+			InputTokens:     usage.PromptTokens,
+			OutputTokens:    usage.CompletionTokens,
+			ReasoningTokens: reasoningTokens(reported, thought),
+			CacheReadTokens: cached,
+			Cost:            Cost(req.Model, usage.PromptTokens, usage.CompletionTokens),
+		}, nil
+	default:
+		// Some OpenAI-compatible providers ignore stream_options
+		return estimate(string(content)), nil
+	}
+}
+
+// reasoningTokens returns the reasoning tokens a provider reported, or an
+// estimate from the reasoning it sent. Reported output tokens include
+// reasoning either way, so the estimate does not change the cost.
+func reasoningTokens(reported int, reasoning string) int {
+	if reported > 0 {
+		return reported
+	}
+	return tokenizer.Count(reasoning)
+}
+
+// record adds a response's usage to the session totals.
+func (s *Session) record(resp *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usage.Requests++
+	s.usage.InputTokens += resp.InputTokens
+	s.usage.OutputTokens += resp.OutputTokens
+	s.usage.ReasoningTokens += resp.ReasoningTokens
+	s.usage.Cost += resp.Cost
+	if resp.Upstream != "" {
+		if s.upstream == nil {
+			s.upstream = map[string]float64{}
+		}
+		s.upstream[resp.Upstream] += resp.Cost
+	}
+}
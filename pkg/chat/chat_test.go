@@ -0,0 +1,866 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/transport"
+	"github.com/sashabaranov/go-openai"
+)
+
+// newServer returns a chat completions server that streams reply in two
+// chunks followed by usage, or fails when the last message is "fail".
+func newServer(t *testing.T, reply string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		if req.Messages[len(req.Messages)-1].Content == "fail" {
+			http.Error(w, `{"error":{"message":"boom"}}`, http.StatusInternalServerError)
+			return
+		}
+		if r.Header.Get("X-Title") != "test" {
+			t.Errorf("missing provider default header")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		half := len(reply) / 2
+		for _, part := range []string{reply[:half], reply[half:]} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", part)
+		}
+		fmt.Fprintf(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":%d,\"completion_tokens\":10}}\n\n", 100*len(req.Messages))
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newSession(t *testing.T, reply string) *Session {
+	srv := newServer(t, reply)
+	provider := catwalk.Provider{ID: "test", APIEndpoint: srv.URL, DefaultHeaders: map[string]string{"X-Title": "test"}}
+	model := catwalk.Model{ID: "m", CostPer1MIn: 1, CostPer1MOut: 2, DefaultMaxTokens: 50}
+	return New(NewClient(provider, "key", nil), provider, model)
+}
+
+func TestSessionStream(t *testing.T) {
+	s := newSession(t, "Hello there")
+	s.Append(RoleSystem, "Be brief.")
+
+	var out strings.Builder
+	resp, err := s.Stream(context.Background(), "Hi", &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "Hello there" || resp.Content != "Hello there" {
+		t.Errorf("streamed %q, response %q", out.String(), resp.Content)
+	}
+	if resp.InputTokens != 200 || resp.OutputTokens != 10 || resp.Estimated {
+		t.Errorf("usage = %+v", resp)
+	}
+	if want := (200*1.0 + 10*2.0) / 1_000_000; resp.Cost != want {
+		t.Errorf("cost = %g; want %g", resp.Cost, want)
+	}
+	if got := s.Messages(); len(got) != 3 || got[2] != (Message{Role: RoleAssistant, Content: "Hello there"}) {
+		t.Errorf("history = %+v", got)
+	}
+
+	// A failed request leaves the history as it was but is not counted.
+	if _, err := s.Send(context.Background(), "fail"); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := len(s.Messages()); got != 3 {
+		t.Errorf("history has %d messages after failure; want 3", got)
+	}
+	if u := s.Usage(); u.Requests != 1 || u.InputTokens != 200 {
+		t.Errorf("usage = %+v", u)
+	}
+
+	s.Clear()
+	if got := s.Messages(); len(got) != 1 || got[0].Role != RoleSystem {
+		t.Errorf("history after Clear = %+v", got)
+	}
+}
+
+func TestStreamFailureKeepsNewHistory(t *testing.T) {
+	var s *Session
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s.SetMessages([]Message{{Role: RoleUser, Content: "restored"}})
+		http.Error(w, `{"error":{"message":"boom"}}`, http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+	provider := catwalk.Provider{ID: "test", APIEndpoint: srv.URL}
+	s = New(NewClient(provider, "key", nil), provider, catwalk.Model{ID: "m"})
+
+	// The history replaced during the request is left alone.
+	if _, err := s.Send(context.Background(), "Hi"); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := s.Messages(); len(got) != 1 || got[0].Content != "restored" {
+		t.Errorf("history = %+v; want the restored message", got)
+	}
+}
+
+func TestSessionConcurrent(t *testing.T) {
+	s := newSession(t, "ok")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Send(context.Background(), "hi"); err != nil {
+				t.Error(err)
+			}
+			_ = s.Usage()
+			_ = s.ContextTokens()
+		}()
+	}
+	wg.Wait()
+
+	// Turns are serialized, so every reply directly follows its message.
+	messages := s.Messages()
+	if len(messages) != 16 {
+		t.Fatalf("history has %d messages; want 16", len(messages))
+	}
+	for i, m := range messages {
+		want := RoleUser
+		if i%2 == 1 {
+			want = RoleAssistant
+		}
+		if m.Role != want {
+			t.Fatalf("message %d has role %s; want %s", i, m.Role, want)
+		}
+	}
+	if u := s.Usage(); u.Requests != 8 {
+		t.Errorf("requests = %d; want 8", u.Requests)
+	}
+}
+
+func TestCompleteDoesNotAppend(t *testing.T) {
+	s := newSession(t, "reply")
+	s.Append(RoleUser, "Hi")
+	if _, err := s.Complete(context.Background(), io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(s.Messages()); got != 1 {
+		t.Errorf("history has %d messages; want 1", got)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	s := newSession(t, "reply")
+	s.SetConfig(Config{Budget: 0.0003})
+
+	// Requests are checked before they are sent: $0.00012 spent after the
+	// first, $0.00044 after the second, so the third is refused.
+	for _, msg := range []string{"Hi", "Again"} {
+		if _, err := s.Send(context.Background(), msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := s.Send(context.Background(), "Once more")
+	var over *catwalk.OverBudgetError
+	if !errors.As(err, &over) || over.Budget != 0.0003 {
+		t.Fatalf("expected an OverBudgetError, got %v", err)
+	}
+	if got := len(s.Messages()); got != 4 {
+		t.Errorf("history has %d messages; want 4", got)
+	}
+}
+
+func TestSetSystem(t *testing.T) {
+	s := New(nil, catwalk.Provider{}, catwalk.Model{})
+	s.Append(RoleUser, "Hi")
+	s.SetSystem("Be brief.")
+	s.SetSystem("Be terse.")
+	if got := s.Messages(); len(got) != 2 || got[0] != (Message{Role: RoleSystem, Content: "Be terse."}) {
+		t.Errorf("history = %+v", got)
+	}
+	s.SetSystem("")
+	if got := s.Messages(); len(got) != 1 || got[0].Role != RoleUser {
+		t.Errorf("history = %+v", got)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	s := newSession(t, "reply")
+	s.Append(RoleSystem, "My key is sk-123.")
+
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return HandlerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+				order = append(order, name+":"+req.Params.Messages[0].Content)
+				return next.Complete(ctx, req)
+			})
+		}
+	}
+	meter := &Meter{}
+	cache := NewCache(10)
+	s.SetConfig(Config{Middleware: []Middleware{
+		meter.Middleware(),
+		cache.Middleware(),
+		trace("outer"),
+		Redact(regexp.MustCompile(`sk-[0-9]+`)),
+		trace("inner"),
+	}})
+
+	if _, err := s.Send(context.Background(), "Hi"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"outer:My key is sk-123.", "inner:My key is [REDACTED]."}; !slices.Equal(order, want) {
+		t.Errorf("calls = %q; want %q", order, want)
+	}
+	if got := s.Messages()[0].Content; got != "My key is sk-123." {
+		t.Errorf("history was redacted: %q", got)
+	}
+
+	// The same request again is answered from the cache without a call.
+	s.SetMessages(s.Messages()[:1])
+	order = nil
+	var out strings.Builder
+	resp, err := s.Stream(context.Background(), "Hi", &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Cached || resp.Cost != 0 || out.String() != "reply" || len(order) != 0 {
+		t.Errorf("response = %+v, output %q, calls %q", resp, out.String(), order)
+	}
+	if u := meter.Usage(); u.Requests != 2 || u.InputTokens != 200 {
+		t.Errorf("meter usage = %+v", u)
+	}
+
+	// The meter's budget applies across sessions.
+	meter.Budget = 0.0001
+	other := newSession(t, "reply")
+	other.SetConfig(Config{Middleware: []Middleware{meter.Middleware()}})
+	var over *catwalk.OverBudgetError
+	if _, err := other.Send(context.Background(), "Hi"); !errors.As(err, &over) {
+		t.Errorf("expected an OverBudgetError, got %v", err)
+	}
+}
+
+func TestCheckFeatures(t *testing.T) {
+	provider := catwalk.Provider{ID: "p", Models: []catwalk.Model{
+		{ID: "text", CostPer1MIn: 1},
+		{ID: "vision", CostPer1MIn: 2, SupportsImages: true, CanReason: true, ReasoningLevels: []string{"low", "high"}},
+		{ID: "vision-pro", CostPer1MIn: 9, SupportsImages: true},
+		{ID: "vision-mini", CostPer1MIn: 0.5, SupportsImages: true, CanReason: true},
+	}}
+	image := openai.ChatCompletionRequest{Messages: []openai.ChatCompletionMessage{{
+		Role:         RoleUser,
+		MultiContent: []openai.ChatMessagePart{{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{URL: "data:"}}},
+	}}}
+
+	var unsupported *catwalk.UnsupportedFeatureError
+	err := CheckFeatures(provider, provider.Models[0], image, nil)
+	if !errors.As(err, &unsupported) || unsupported.Feature != catwalk.FeatureImages {
+		t.Fatalf("err = %v", err)
+	}
+	if want := []string{"vision-mini", "vision", "vision-pro"}; !slices.Equal(unsupported.Suggestions, want) {
+		t.Errorf("suggestions = %q; want %q", unsupported.Suggestions, want)
+	}
+	if err := CheckFeatures(provider, provider.Models[1], image, nil); err != nil {
+		t.Errorf("vision: %v", err)
+	}
+
+	// Levels are checked when listed, and suggestions fit every feature.
+	image.ReasoningEffort = "medium"
+	err = CheckFeatures(provider, provider.Models[1], image, nil)
+	if !errors.As(err, &unsupported) || unsupported.Feature != catwalk.FeatureReasoningEffort || !slices.Equal(unsupported.Suggestions, []string{"vision-mini"}) {
+		t.Errorf("err = %v", err)
+	}
+
+	// Tools are only refused when a probe found them failing.
+	tools := openai.ChatCompletionRequest{Tools: []openai.Tool{{Type: openai.ToolTypeFunction}}}
+	if err := CheckFeatures(provider, provider.Models[0], tools, nil); err != nil {
+		t.Errorf("unprobed tools: %v", err)
+	}
+	probed := func(_ catwalk.InferenceProvider, model string, f catwalk.Feature) (bool, bool) {
+		return model != "text", f == catwalk.FeatureTools
+	}
+	h := Preflight(probed)(HandlerFunc(func(context.Context, *Request) (*Response, error) {
+		t.Error("request sent")
+		return &Response{}, nil
+	}))
+	_, err = h.Complete(context.Background(), &Request{Provider: provider, Model: provider.Models[0], Params: tools})
+	if !errors.Is(err, catwalk.ErrUnsupportedFeature) || catwalk.ExitCode(err) != 10 {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	calls := 0
+	flaky := HandlerFunc(func(_ context.Context, req *Request) (*Response, error) {
+		calls++
+		switch {
+		case calls < 3:
+			return nil, &openai.APIError{HTTPStatusCode: http.StatusServiceUnavailable}
+		case req.Params.Model == "bad":
+			return nil, &openai.APIError{HTTPStatusCode: http.StatusBadRequest}
+		}
+		io.WriteString(req.Output, "ok") //nolint:errcheck
+		return &Response{Content: "ok"}, nil
+	})
+	h := Chain(flaky, Retry(3, time.Millisecond))
+
+	var out strings.Builder
+	resp, err := h.Complete(context.Background(), &Request{Output: &out})
+	if err != nil || resp.Content != "ok" || calls != 3 || out.String() != "ok" {
+		t.Fatalf("calls = %d, response %+v, err %v", calls, resp, err)
+	}
+
+	// Client errors are not retried.
+	calls = 3
+	if _, err := h.Complete(context.Background(), &Request{Params: openai.ChatCompletionRequest{Model: "bad"}, Output: io.Discard}); err == nil || calls != 4 {
+		t.Errorf("calls = %d, err %v", calls, err)
+	}
+
+	// Nor are requests refused by an open circuit, even through url.Error.
+	open := &url.Error{Op: "Post", URL: "https://example.com", Err: &transport.CircuitOpenError{Provider: "p"}}
+	if Retryable(open) {
+		t.Error("an open circuit must not be retried")
+	}
+}
+
+func TestFailover(t *testing.T) {
+	var models []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		models = append(models, req.Model)
+		switch {
+		case req.Messages[len(req.Messages)-1].Content == "bad":
+			http.Error(w, `{"error":{"message":"bad"}}`, http.StatusBadRequest)
+			return
+		case req.Model == "m":
+			http.Error(w, `{"error":{"message":"overloaded"}}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"ok\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":1000,\"completion_tokens\":1000}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	provider := catwalk.Provider{ID: "test", APIEndpoint: srv.URL}
+	model := catwalk.Model{ID: "m", CostPer1MIn: 10, CostPer1MOut: 20}
+	fallback := catwalk.Model{ID: "b", CostPer1MIn: 1, CostPer1MOut: 2, DefaultMaxTokens: 100}
+	s := New(NewClient(provider, "key", nil), provider, model)
+	s.SetConfig(Config{MaxTokens: 500, Middleware: []Middleware{Failover(model, fallback)}})
+
+	resp, err := s.Send(context.Background(), "Hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Model != "b" || resp.FailoverReason != "m: HTTP 503" || resp.Content != "ok" {
+		t.Errorf("response %+v", resp)
+	}
+	// Priced at the model that answered, not the one asked for
+	if want := 0.003; math.Abs(resp.Cost-want) > 1e-12 {
+		t.Errorf("cost = %v; want %v", resp.Cost, want)
+	}
+	if !slices.Equal(models, []string{"m", "b"}) {
+		t.Errorf("requested models %v", models)
+	}
+
+	// Client errors are not failed over.
+	models = nil
+	if _, err := s.Send(context.Background(), "bad"); err == nil || len(models) != 1 {
+		t.Errorf("requested models %v, err %v", models, err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		if req.Messages[len(req.Messages)-1].Content != "silent" {
+			fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"The answer is\"}}]}\n\n")
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	provider := catwalk.Provider{ID: "test", APIEndpoint: srv.URL}
+	s := New(NewClient(provider, "key", nil), provider, catwalk.Model{ID: "m", CostPer1MIn: 1, CostPer1MOut: 2})
+	s.SetConfig(Config{Timeout: 50 * time.Millisecond})
+
+	// A reply cut off mid-stream is returned, truncated, and counted.
+	var out strings.Builder
+	resp, err := s.Stream(context.Background(), "Hi", &out)
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) || !errors.Is(err, context.DeadlineExceeded) || timeout.Timeout != 50*time.Millisecond {
+		t.Fatalf("expected a TimeoutError, got %v", err)
+	}
+	if resp == nil || !resp.Truncated || resp.Content != "The answer is" || out.String() != resp.Content || resp.Cost == 0 {
+		t.Fatalf("response %+v", resp)
+	}
+	if got := s.Usage(); got.Requests != 1 || got.Cost != resp.Cost {
+		t.Errorf("usage %+v", got)
+	}
+
+	// With nothing streamed, there is only the error.
+	resp, err = s.Send(context.Background(), "silent")
+	if !errors.As(err, &timeout) || resp != nil {
+		t.Errorf("response %+v, err %v", resp, err)
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewScheduler()
+	s.now = func() time.Time { return start }
+	requests := scheduleKey{"test", "requests"}
+	tokens := scheduleKey{"test", "tokens"}
+	s.SetLimit(requests.provider, requests.model, Limit{Requests: 2, Per: time.Minute})
+	s.SetLimit(tokens.provider, tokens.model, Limit{Tokens: 100, Per: time.Minute})
+
+	// Requests wait for a slot in the window; one larger than the token
+	// limit waits for the window to empty.
+	for _, tt := range []struct {
+		key    scheduleKey
+		tokens int
+		want   time.Duration
+	}{
+		{requests, 10, 0},
+		{requests, 10, 0},
+		{requests, 10, time.Minute},
+		{tokens, 60, 0},
+		{tokens, 30, 0},
+		{tokens, 60, time.Minute},
+		{tokens, 500, 2 * time.Minute},
+	} {
+		if got := s.reserve(tt.key, tt.tokens).at.Sub(start); got != tt.want {
+			t.Errorf("%s: %d tokens start after %s, want %s", tt.key.model, tt.tokens, got, tt.want)
+		}
+	}
+
+	// A 429 teaches the limit from the headers and pauses the model.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.Header().Set("X-Ratelimit-Limit-Requests", "3")
+		w.Header().Set("X-Ratelimit-Limit-Tokens", "1000")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	var learned Limit
+	s.OnLimit = func(_ catwalk.InferenceProvider, _ string, l Limit) { learned = l }
+	paced := scheduleKey{"test", "paced"}
+	req, _ := http.NewRequestWithContext(context.WithValue(context.Background(), modelContextKey{}, paced), http.MethodPost, srv.URL, nil)
+	resp, err := s.Transport(nil).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close() //nolint:errcheck
+	if want := (Limit{Requests: 3, Tokens: 1000, Per: time.Minute}); learned != want {
+		t.Errorf("learned %v, want %v", learned, want)
+	}
+	if got := s.reserve(paced, 10).at.Sub(start); got != 30*time.Second {
+		t.Errorf("after a 429, the next request starts after %s, want 30s", got)
+	}
+
+	// Through a session, a grant's estimate is corrected to the usage.
+	sched := NewScheduler()
+	session := newSession(t, "Hello")
+	session.SetConfig(Config{Middleware: []Middleware{sched.Middleware()}})
+	if _, err := session.Send(context.Background(), "Hi"); err != nil {
+		t.Fatal(err)
+	}
+	if g := sched.models[scheduleKey{"test", "m"}].grants; len(g) != 1 || g[0].tokens != 110 {
+		t.Errorf("grants %+v, want one of 110 tokens", g)
+	}
+}
+
+func TestToolCallAssembler(t *testing.T) {
+	index := func(i int) *int { return &i }
+	delta := func(i *int, id, name, args string) openai.ToolCall {
+		return openai.ToolCall{Index: i, ID: id, Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: name, Arguments: args}}
+	}
+
+	var events []string
+	a := ToolCallAssembler{Handler: ToolCallHandler{
+		Start: func(i int, c ToolCall) { events = append(events, fmt.Sprintf("start %d %s", i, c.Name)) },
+		Arguments: func(i int, c ToolCall, frag string) {
+			events = append(events, fmt.Sprintf("args %d %s", i, c.Arguments))
+		},
+		Done: func(i int, c ToolCall) { events = append(events, fmt.Sprintf("done %d %s", i, c.ID)) },
+	}}
+	chunks := [][]openai.ToolCall{
+		// OpenAI: ID and name first, then argument fragments
+		{delta(index(0), "a", "get_weather", "")},
+		{delta(index(0), "", "", `{"city":`)},
+		{delta(index(0), "", "", `"Oslo"}`)},
+		// A parallel call reusing index 0 with a new ID, repeating its name
+		{delta(index(0), "b", "get_time", `{"tz":`)},
+		{delta(index(0), "", "get_time", `"CET"}`)},
+		// A whole call without an index
+		{delta(nil, "c", "ping", "{}")},
+	}
+	for _, c := range chunks {
+		a.Add(c)
+	}
+	calls := a.Finish()
+	a.Finish()
+
+	want := []ToolCall{
+		{ID: "a", Name: "get_weather", Arguments: `{"city":"Oslo"}`},
+		{ID: "b", Name: "get_time", Arguments: `{"tz":"CET"}`},
+		{ID: "c", Name: "ping", Arguments: "{}"},
+	}
+	if !slices.Equal(calls, want) {
+		t.Errorf("calls = %+v", calls)
+	}
+	wantEvents := []string{
+		"start 0 get_weather", `args 0 {"city":`, `args 0 {"city":"Oslo"}`,
+		"start 1 get_time", `args 1 {"tz":`, `args 1 {"tz":"CET"}`,
+		"start 2 ping", "args 2 {}",
+		"done 0 a", "done 1 b", "done 2 c",
+	}
+	if !slices.Equal(events, wantEvents) {
+		t.Errorf("events = %q", events)
+	}
+}
+
+func TestParseArguments(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{``, `{}`},
+		{`{"city": "Oslo", "days": 3}`, `{"city":"Oslo","days":3}`},
+		{`{"city": "Os`, `{"city":"Os"}`},
+		{`{"city": "Oslo", "da`, `{"city":"Oslo"}`},
+		{`{"city": "Oslo",`, `{"city":"Oslo"}`},
+		{`{"city": "Oslo", "days":`, `{"city":"Oslo"}`},
+		{`{"tags": ["a", "b`, `{"tags":["a","b"]}`},
+		{`{"ok": tr`, `{}`},
+		{`{"n": 1.`, `{"n":1}`},
+		{`{"q": "say \"hi\`, `{"q":"say \"hi"}`},
+		{`{"a": {"b": [1, {"c": "d`, `{"a":{"b":[1,{"c":"d"}]}}`},
+	}
+	for _, tt := range tests {
+		var v any
+		if err := ParseArguments(tt.in, &v); err != nil {
+			t.Errorf("ParseArguments(%q): %v", tt.in, err)
+			continue
+		}
+		if got, _ := json.Marshal(v); string(got) != tt.want {
+			t.Errorf("ParseArguments(%q) = %s; want %s", tt.in, got, tt.want)
+		}
+	}
+
+	var v any
+	if err := ParseArguments(`{"a": }}`, &v); err == nil {
+		t.Error("expected an error for invalid arguments")
+	}
+}
+
+func TestSessionToolCalls(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Tools) != 1 {
+			t.Errorf("request tools = %+v, %v", req.Tools, err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"add","arguments":""}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"a\":1,"}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"b\":2}"}}]},"finish_reason":"tool_calls"}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":20,"completion_tokens":8}}`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	provider := catwalk.Provider{ID: "test", APIEndpoint: srv.URL}
+	s := New(NewClient(provider, "key", nil), provider, catwalk.Model{ID: "m"})
+	var partial []map[string]int
+	s.SetConfig(Config{
+		Prepare: func(req *openai.ChatCompletionRequest) {
+			req.Tools = []openai.Tool{{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{Name: "add"}}}
+		},
+		ToolCalls: ToolCallHandler{Arguments: func(_ int, c ToolCall, _ string) {
+			var args map[string]int
+			if err := ParseArguments(c.Arguments, &args); err != nil {
+				t.Error(err)
+			}
+			partial = append(partial, args)
+		}},
+	})
+
+	resp, err := s.Send(context.Background(), "1+2?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []ToolCall{{ID: "call_1", Name: "add", Arguments: `{"a":1,"b":2}`}}; !slices.Equal(resp.ToolCalls, want) || resp.OutputTokens != 8 {
+		t.Errorf("response = %+v", resp)
+	}
+	if len(partial) != 2 || partial[0]["a"] != 1 || partial[1]["b"] != 2 {
+		t.Errorf("partial arguments = %v", partial)
+	}
+}
+
+func TestHuggingFaceClient(t *testing.T) {
+	var path, model string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req) //nolint:errcheck
+		path, model = r.URL.Path, req.Model
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hi\"}}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	// A full chat completions URL is cut back to the router's base
+	provider := catwalk.Provider{ID: "huggingface", APIEndpoint: srv.URL + "/v1/chat/completions", Models: []catwalk.Model{
+		{ID: "openai/gpt-oss-120b:groq", CostPer1MIn: 0.15, CostPer1MOut: 0.75},
+		{ID: "openai/gpt-oss-120b:cerebras", CostPer1MIn: 0.25, CostPer1MOut: 0.69},
+	}}
+	m, err := provider.FindModel("openai/gpt-oss-120b:cheapest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(NewClient(provider, "key", nil), provider, *m)
+	if _, err := s.Send(context.Background(), "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v1/chat/completions" || model != "openai/gpt-oss-120b:cheapest" {
+		t.Errorf("request to %s for %s", path, model)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	t.Setenv("TEST_CHAT_KEY", "")
+	providers := []catwalk.Provider{
+		{ID: "openai", APIKey: "$TEST_CHAT_KEY", Models: []catwalk.Model{{ID: "gpt-4o"}}},
+		{ID: "bedrock", Type: catwalk.TypeBedrock, APIKey: "$TEST_CHAT_KEY", Models: []catwalk.Model{{ID: "claude"}}},
+	}
+
+	if _, err := Resolve(providers, "gpt-4o", nil); !errors.Is(err, catwalk.ErrMissingAPIKey) {
+		t.Errorf("Resolve without a key = %v, want a missing key error", err)
+	}
+	// Bedrock signs requests with AWS credentials instead
+	if target, err := Resolve(providers, "bedrock/claude", nil); err != nil || target.Client == nil || target.Model.ID != "claude" {
+		t.Errorf("Resolve(bedrock/claude) = %+v, %v", target, err)
+	}
+	if _, err := Resolve(providers, "gpt-5", nil); !errors.Is(err, catwalk.ErrModelNotFound) {
+		t.Errorf("Resolve(gpt-5) = %v, want model not found", err)
+	}
+
+	t.Setenv("TEST_CHAT_KEY", "key")
+	target, err := Resolve(providers, "openai/gpt-4o", nil)
+	if err != nil || target.Client == nil || target.Provider.ID != "openai" || target.Model.ID != "gpt-4o" {
+		t.Errorf("Resolve(openai/gpt-4o) = %+v, %v", target, err)
+	}
+}
+
+func TestSetModel(t *testing.T) {
+	s := newSession(t, "reply")
+	if _, err := s.Send(context.Background(), "Hi"); err != nil {
+		t.Fatal(err)
+	}
+	s.SetModel(catwalk.Model{ID: "big", CostPer1MIn: 10, CostPer1MOut: 20})
+
+	var got string
+	s.SetConfig(Config{Middleware: []Middleware{func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+			got = req.Params.Model
+			return next.Complete(ctx, req)
+		})
+	}}})
+	resp, err := s.Send(context.Background(), "Again")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "big" || s.Model().ID != "big" {
+		t.Errorf("requested %q", got)
+	}
+	if want := (300*10.0 + 10*20.0) / 1_000_000; resp.Cost != want {
+		t.Errorf("cost = %g; want %g", resp.Cost, want)
+	}
+	if got := len(s.Messages()); got != 4 {
+		t.Errorf("history has %d messages; want 4", got)
+	}
+}
+
+func TestResponses(t *testing.T) {
+	var paths []string
+	var params responsesParams
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/v1/chat/completions" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hi\"}}]}\n\ndata: [DONE]\n\n")
+			return
+		}
+		params = responsesParams{}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Error(err)
+		}
+		if !params.Stream {
+			fmt.Fprint(w, `{"id":"r2","output":[{"type":"function_call","call_id":"c1","name":"add","arguments":"{\"a\":1}"}],"usage":{"input_tokens":5,"output_tokens":3}}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range []string{
+			`{"type":"response.created","response":{"id":"r1"}}`,
+			`{"type":"response.reasoning_summary_text.delta","output_index":0,"summary_index":0,"delta":"Think"}`,
+			`{"type":"response.reasoning_summary_text.delta","output_index":0,"summary_index":1,"delta":"Again"}`,
+			`{"type":"response.output_text.delta","output_index":1,"delta":"Hel"}`,
+			`{"type":"response.output_text.delta","output_index":1,"delta":"lo"}`,
+			`{"type":"response.completed","response":{"id":"r1","usage":{"input_tokens":40,"output_tokens":12,"output_tokens_details":{"reasoning_tokens":8}}}}`,
+		} {
+			fmt.Fprintf(w, "event: x\ndata: %s\n\n", event)
+		}
+	}))
+	defer srv.Close()
+
+	provider := catwalk.Provider{ID: "openai", Type: catwalk.TypeOpenAI, APIEndpoint: srv.URL + "/v1", Models: []catwalk.Model{
+		{ID: "o3-pro", CanReason: true, CostPer1MIn: 1, CostPer1MOut: 1},
+		{ID: "gpt-4o"},
+	}}
+	client := NewClient(provider, "key", nil)
+	s := New(client, provider, provider.Models[0])
+	s.SetConfig(Config{MaxTokens: 100, Prepare: func(req *openai.ChatCompletionRequest) { req.ReasoningEffort = "high" }})
+	s.SetSystem("Be brief.")
+	resp, err := s.Send(context.Background(), "Hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "Hello" || resp.Reasoning != "Think\n\nAgain" || resp.InputTokens != 40 || resp.OutputTokens != 12 || resp.ReasoningTokens != 8 || resp.Estimated {
+		t.Errorf("response = %+v", resp)
+	}
+	if params.MaxOutputTokens != 100 || params.Reasoning == nil || params.Reasoning.Effort != "high" || params.Reasoning.Summary != "auto" ||
+		len(params.Input) != 2 || params.Input[0].Role != RoleSystem || params.Input[1].Content[0].Type != "input_text" {
+		t.Errorf("request = %+v", params)
+	}
+
+	// Tool calls and outputs, without streaming
+	reply, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model: "o3-pro",
+		Messages: []openai.ChatCompletionMessage{
+			{Role: RoleUser, Content: "1+1?"},
+			{Role: RoleAssistant, ToolCalls: []openai.ToolCall{{ID: "c0", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "add", Arguments: "{}"}}}},
+			{Role: openai.ChatMessageRoleTool, ToolCallID: "c0", Content: "2"},
+		},
+		Tools: []openai.Tool{{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{Name: "add"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls := reply.Choices[0].Message.ToolCalls; len(calls) != 1 || calls[0].ID != "c1" || reply.Choices[0].FinishReason != openai.FinishReasonToolCalls || reply.Usage.CompletionTokens != 3 {
+		t.Errorf("reply = %+v", reply)
+	}
+	if in := params.Input; len(in) != 3 || in[1].Type != "function_call" || in[2].Type != "function_call_output" || *in[2].Output != "2" || len(params.Tools) != 1 {
+		t.Errorf("request = %+v", params)
+	}
+
+	// Other models use chat completions
+	s.SetModel(provider.Models[1])
+	if _, err := s.Send(context.Background(), "Hi"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/v1/responses", "/v1/responses", "/v1/chat/completions"}; !slices.Equal(paths, want) {
+		t.Errorf("paths = %q", paths)
+	}
+}
+
+func TestReasoning(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		deltas []string // of the first choice
+		usage  string
+		tokens int
+	}{
+		{
+			name:   "reasoning_content",
+			deltas: []string{`{"reasoning_content":"Add "}`, `{"reasoning_content":"them."}`, `{"content":"4"}`},
+			usage:  `{"prompt_tokens":10,"completion_tokens":30,"completion_tokens_details":{"reasoning_tokens":29}}`,
+			tokens: 29,
+		},
+		{
+			name:   "reasoning",
+			deltas: []string{`{"reasoning":"Add them."}`, `{"content":"4"}`},
+			usage:  `{"prompt_tokens":10,"completion_tokens":30}`,
+			tokens: 3,
+		},
+		{
+			name:   "think tags",
+			deltas: []string{`{"content":"\n<thi"}`, `{"content":"nk>\nAdd them."}`, `{"content":"</th"}`, `{"content":"ink>\n\n"}`, `{"content":"4"}`},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				for _, d := range tt.deltas {
+					fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":%s}]}\n\n", d)
+				}
+				if tt.usage != "" {
+					fmt.Fprintf(w, "data: {\"choices\":[],\"usage\":%s}\n\n", tt.usage)
+				}
+				fmt.Fprint(w, "data: [DONE]\n\n")
+			}))
+			defer srv.Close()
+
+			provider := catwalk.Provider{ID: "deepseek", APIEndpoint: srv.URL}
+			s := New(NewClient(provider, "key", nil), provider, catwalk.Model{ID: "r1", CostPer1MOut: 1})
+			var thought strings.Builder
+			s.SetConfig(Config{Reasoning: &thought})
+			var out strings.Builder
+			resp, err := s.Stream(context.Background(), "2+2?", &out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != "4" || resp.Content != "4" || resp.Reasoning != "Add them." || !strings.Contains(thought.String(), "Add them.") {
+				t.Errorf("streamed %q and %q, response %+v", out.String(), thought.String(), resp)
+			}
+			if got := s.Messages(); got[len(got)-1].Content != "4" {
+				t.Errorf("history = %+v", got)
+			}
+			if tt.usage == "" {
+				// Estimated output includes the reasoning
+				if !resp.Estimated || resp.ReasoningTokens == 0 || resp.OutputTokens <= resp.ReasoningTokens {
+					t.Errorf("estimated usage = %+v", resp)
+				}
+				return
+			}
+			if resp.ReasoningTokens != tt.tokens || resp.OutputTokens != 30 || s.Usage().ReasoningTokens != tt.tokens {
+				t.Errorf("reasoning tokens = %d, usage %+v; want %d", resp.ReasoningTokens, s.Usage(), tt.tokens)
+			}
+		})
+	}
+
+	// Text that only looks like the start of a tag is the reply
+	var split thinkSplitter
+	content, _ := split.split("<th")
+	rest, _ := split.split("ey said")
+	if content+rest != "<they said" {
+		t.Errorf("split = %q", content+rest)
+	}
+}
@@ -0,0 +1,379 @@
+package chat
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/transport"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Request is one chat call on its way through a [Handler] chain.
+type Request struct {
+	Provider catwalk.Provider
+	Model    catwalk.Model
+
+	// Params is the API request, built from the session history and
+	// [Config]. Middleware may change it, for example to redact messages,
+	// without changing the history.
+	Params openai.ChatCompletionRequest
+
+	// Output receives the reply as it streams, Reasoning (if set) the
+	// reasoning before it, and ToolCalls the calls in it.
+	Output    io.Writer
+	Reasoning io.Writer
+	ToolCalls ToolCallHandler
+}
+
+// Handler sends a chat request and returns the reply. It is the chat
+// counterpart of [http.RoundTripper]: a session's requests go through a
+// chain of handlers, the last of which calls the API.
+type Handler interface {
+	Complete(ctx context.Context, req *Request) (*Response, error)
+}
+
+// HandlerFunc adapts a function to a [Handler].
+type HandlerFunc func(ctx context.Context, req *Request) (*Response, error)
+
+// Complete calls f(ctx, req).
+func (f HandlerFunc) Complete(ctx context.Context, req *Request) (*Response, error) {
+	return f(ctx, req)
+}
+
+// Middleware wraps a handler to add behavior around each request.
+type Middleware func(next Handler) Handler
+
+// Chain returns h wrapped in middleware. The first middleware is the
+// outermost, so it sees each request first and each response last.
+func Chain(h Handler, middleware ...Middleware) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+// Logging logs each request's model, token usage, cost, and duration, or its
+// error, to logger.
+func Logging(logger *log.Logger) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+			start := time.Now()
+			resp, err := next.Complete(ctx, req)
+			elapsed := time.Since(start).Round(time.Millisecond)
+			switch {
+			case err != nil:
+				logger.Printf("%s/%s: %d messages: failed after %s: %v", req.Provider.ID, req.Params.Model, len(req.Params.Messages), elapsed, err)
+			default:
+				logger.Printf("%s/%s: %d messages: %d in, %d out, $%.6f in %s", req.Provider.ID, req.Params.Model, len(req.Params.Messages), resp.InputTokens, resp.OutputTokens, resp.Cost, elapsed)
+			}
+			return resp, err
+		})
+	}
+}
+
+// Retry retries requests that fail with a transient error (a network error,
+// or an HTTP 408, 429, or 5xx status) up to attempts times in all, waiting
+// backoff before the first retry and twice as long before each one after.
+// Requests whose reply has started streaming are not retried, as the output
+// cannot be taken back.
+func Retry(attempts int, backoff time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+			wait := backoff
+			for attempt := 1; ; attempt++ {
+				w := &countingWriter{w: req.Output}
+				try := *req
+				try.Output = w
+				resp, err := next.Complete(ctx, &try)
+				if err == nil || attempt >= attempts || w.n > 0 || !Retryable(err) {
+					return resp, err
+				}
+
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return nil, ctx.Err() //nolint:wrapcheck
+				case <-timer.C:
+				}
+				wait *= 2
+			}
+		})
+	}
+}
+
+// Retryable reports whether err is a transient failure worth retrying: a
+// network error, or an API error with an HTTP 408, 429, or 5xx status. A
+// provider whose circuit is open (see transport.Breaker) is not retried, so
+// retries stop once failures trip the breaker.
+func Retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, transport.ErrCircuitOpen) {
+		return false
+	}
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return retryableStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return retryableStatus(reqErr.HTTPStatusCode)
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500
+}
+
+// Failover falls back to models in turn when a request fails with a
+// transient error (see [Retryable]) or the provider's circuit is open, as
+// long as none of the reply has streamed. The response of a fallback names
+// the model that answered in [Response.Model] and why in
+// [Response.FailoverReason], and is priced at that model's rates. Put
+// Failover before [Retry] in a chain, so each model is retried before the
+// next one is tried.
+func Failover(models ...catwalk.Model) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+			fallbacks := slices.DeleteFunc(slices.Clone(models), func(m catwalk.Model) bool { return m.ID == req.Model.ID })
+			try := *req
+			var reasons []string
+			for i := 0; ; i++ {
+				w := &countingWriter{w: req.Output}
+				try.Output = w
+				resp, err := next.Complete(ctx, &try)
+				if resp != nil && len(reasons) > 0 {
+					resp.Model, resp.FailoverReason = try.Model.ID, strings.Join(reasons, "; ")
+				}
+				if err == nil || i == len(fallbacks) || w.n > 0 || ctx.Err() != nil || !(Retryable(err) || errors.Is(err, transport.ErrCircuitOpen)) {
+					return resp, err
+				}
+				reasons = append(reasons, failoverReason(try.Model.ID, err))
+				try.Model = fallbacks[i]
+				try.Params.Model = fallbacks[i].ID
+				// Keep the reply within the fallback's output limit
+				if limit := int(try.Model.OutputLimit()); limit > 0 {
+					try.Params.MaxTokens = min(try.Params.MaxTokens, limit)
+					try.Params.MaxCompletionTokens = min(try.Params.MaxCompletionTokens, limit)
+				}
+			}
+		})
+	}
+}
+
+// failoverReason describes how model failed, such as "gpt-4o: HTTP 503".
+func failoverReason(model string, err error) string {
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, transport.ErrCircuitOpen):
+		return model + ": circuit open"
+	case errors.As(err, &apiErr):
+		return fmt.Sprintf("%s: HTTP %d", model, apiErr.HTTPStatusCode)
+	case errors.As(err, &reqErr):
+		return fmt.Sprintf("%s: HTTP %d", model, reqErr.HTTPStatusCode)
+	case errors.As(err, &netErr):
+		return model + ": network error"
+	}
+	return model + ": " + err.Error()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err //nolint:wrapcheck
+}
+
+// RateLimit spaces requests so that at most n start in any period, waiting
+// as needed. The limit is shared by every handler the middleware wraps, so
+// one RateLimit can throttle several sessions against the same provider.
+func RateLimit(n int, per time.Duration) Middleware {
+	interval := per / time.Duration(max(n, 1))
+	var mu sync.Mutex
+	var next time.Time
+	return func(h Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+			mu.Lock()
+			now := time.Now()
+			start := next
+			if start.Before(now) {
+				start = now
+			}
+			next = start.Add(interval)
+			mu.Unlock()
+
+			if wait := start.Sub(now); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return nil, ctx.Err() //nolint:wrapcheck
+				case <-timer.C:
+				}
+			}
+			return h.Complete(ctx, req)
+		})
+	}
+}
+
+// Meter totals usage and cost across every session whose requests go
+// through its middleware, and can enforce a budget over all of them.
+type Meter struct {
+	// Budget is the most all requests together may spend, in USD. Once the
+	// total reaches it, requests fail with a *catwalk.OverBudgetError. Zero
+	// means no limit.
+	Budget float64
+
+	mu    sync.Mutex
+	usage Usage
+}
+
+// Middleware returns middleware that records each response's usage in m,
+// including those of failed or interrupted requests that consumed tokens.
+func (m *Meter) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+			if spent := m.Usage().Cost; m.Budget > 0 && spent >= m.Budget {
+				return nil, &catwalk.OverBudgetError{Spent: spent, Budget: m.Budget}
+			}
+			resp, err := next.Complete(ctx, req)
+			if resp != nil {
+				m.mu.Lock()
+				m.usage.Requests++
+				m.usage.InputTokens += resp.InputTokens
+				m.usage.OutputTokens += resp.OutputTokens
+				m.usage.Cost += resp.Cost
+				m.mu.Unlock()
+			}
+			return resp, err
+		})
+	}
+}
+
+// Usage returns the totals so far.
+func (m *Meter) Usage() Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.usage
+}
+
+// Redacted replaces text removed by [Redact].
+const Redacted = "[REDACTED]"
+
+// Redact replaces every match of the patterns in the outgoing messages with
+// [Redacted], so secrets in the history are never sent to the provider. The
+// session history keeps the original text.
+func Redact(patterns ...*regexp.Regexp) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+			redacted := *req
+			redacted.Params.Messages = make([]openai.ChatCompletionMessage, len(req.Params.Messages))
+			for i, m := range req.Params.Messages {
+				for _, p := range patterns {
+					m.Content = p.ReplaceAllString(m.Content, Redacted)
+				}
+				redacted.Params.Messages[i] = m
+			}
+			return next.Complete(ctx, &redacted)
+		})
+	}
+}
+
+// Cache remembers replies by request, so repeating an identical request
+// (same provider, model, messages, and parameters) is answered without
+// calling the API. Cached replies are marked [Response.Cached] and cost
+// nothing. A Cache is safe for concurrent use and may be shared by several
+// sessions.
+type Cache struct {
+	size int
+
+	mu      sync.Mutex
+	entries map[string]Response
+	order   []string
+}
+
+// NewCache returns a cache holding up to size replies, dropping the oldest
+// when full. A size of zero or less means no limit.
+func NewCache(size int) *Cache {
+	return &Cache{size: size, entries: map[string]Response{}}
+}
+
+// Middleware returns middleware that answers from the cache when it can and
+// stores successful replies.
+func (c *Cache) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+			key, err := cacheKey(req)
+			if err != nil {
+				return nil, err
+			}
+			if resp, ok := c.get(key); ok {
+				if _, err := io.WriteString(req.Output, resp.Content); err != nil {
+					return nil, fmt.Errorf("failed to write reply: %w", err)
+				}
+				return &resp, nil
+			}
+
+			resp, err := next.Complete(ctx, req)
+			if err == nil {
+				c.put(key, *resp)
+			}
+			return resp, err
+		})
+	}
+}
+
+func (c *Cache) get(key string) (Response, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resp, ok := c.entries[key]
+	if !ok {
+		return Response{}, false
+	}
+	return Response{Content: resp.Content, Cached: true, Upstream: resp.Upstream, Model: resp.Model}, true
+}
+
+func (c *Cache) put(key string, resp Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	if c.size > 0 && len(c.order) >= c.size {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.entries[key] = resp
+	c.order = append(c.order, key)
+}
+
+// cacheKey identifies a request by its provider and API parameters.
+func cacheKey(req *Request) (string, error) {
+	params, err := json.Marshal(req.Params)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	sum := sha256.Sum256(append([]byte(string(req.Provider.ID)+"\n"), params...))
+	return hex.EncodeToString(sum[:]), nil
+}
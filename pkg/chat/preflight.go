@@ -0,0 +1,136 @@
+package chat
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Probed reports whether a feature was found working on a model by trying
+// it, and whether it was tried at all; see registry.Overrides.Probed.
+type Probed func(provider catwalk.InferenceProvider, model string, f catwalk.Feature) (works, known bool)
+
+// maxSuggestions is how many compatible models an
+// [catwalk.UnsupportedFeatureError] suggests.
+const maxSuggestions = 3
+
+// Features returns the features req uses that only some models support:
+// images in a message, tools, a JSON response format, and a reasoning
+// effort.
+func Features(req openai.ChatCompletionRequest) []catwalk.Feature {
+	var features []catwalk.Feature
+	for _, m := range req.Messages {
+		if slices.ContainsFunc(m.MultiContent, func(p openai.ChatMessagePart) bool {
+			return p.Type == openai.ChatMessagePartTypeImageURL
+		}) {
+			features = append(features, catwalk.FeatureImages)
+			break
+		}
+	}
+	if len(req.Tools) > 0 || len(req.Functions) > 0 {
+		features = append(features, catwalk.FeatureTools)
+	}
+	if f := req.ResponseFormat; f != nil && (f.Type == openai.ChatCompletionResponseFormatTypeJSONObject || f.Type == openai.ChatCompletionResponseFormatTypeJSONSchema) {
+		features = append(features, catwalk.FeatureJSONMode)
+	}
+	if req.ReasoningEffort != "" {
+		features = append(features, catwalk.FeatureReasoningEffort)
+	}
+	return features
+}
+
+// CheckFeatures returns a *catwalk.UnsupportedFeatureError if req uses a
+// feature that model, one of provider's, does not support, suggesting the
+// provider's models that support them all. Probe results, if probed is not
+// nil, take precedence over the catalog; a feature neither records, such as
+// tools on an unprobed model, is assumed to work.
+func CheckFeatures(provider catwalk.Provider, model catwalk.Model, req openai.ChatCompletionRequest, probed Probed) error {
+	features := Features(req)
+	for _, f := range features {
+		reason := unsupported(provider.ID, model, f, req.ReasoningEffort, probed)
+		if reason == "" {
+			continue
+		}
+		return &catwalk.UnsupportedFeatureError{
+			Provider:    provider.ID,
+			Model:       model.ID,
+			Feature:     f,
+			Reason:      reason,
+			Suggestions: compatible(provider, model, features, req.ReasoningEffort, probed),
+		}
+	}
+	return nil
+}
+
+// unsupported says why m does not support f, or returns "" if it does or
+// may.
+func unsupported(provider catwalk.InferenceProvider, m catwalk.Model, f catwalk.Feature, effort string, probed Probed) string {
+	if probed != nil {
+		if works, known := probed(provider, m.ID, f); known {
+			if works {
+				return ""
+			}
+			return "a capability probe found it failing"
+		}
+	}
+	switch supported, known := m.Supports(f); {
+	case !known:
+		return ""
+	case !supported && f == catwalk.FeatureImages:
+		return "the catalog lists no image input"
+	case !supported:
+		return "the catalog lists it as not reasoning"
+	case f == catwalk.FeatureReasoningEffort && !m.SupportsEffort(effort):
+		return fmt.Sprintf("no effort %q (levels: %s)", effort, strings.Join(m.ReasoningLevels, ", "))
+	default:
+		return ""
+	}
+}
+
+// compatible returns up to maxSuggestions of provider's models other than m
+// that support every feature, closest to m in price first.
+func compatible(provider catwalk.Provider, m catwalk.Model, features []catwalk.Feature, effort string, probed Probed) []string {
+	price := func(m catwalk.Model) float64 { return m.CostPer1MIn + m.CostPer1MOut }
+	var models []catwalk.Model
+	for _, candidate := range provider.Models {
+		if candidate.ID == m.ID {
+			continue
+		}
+		if !slices.ContainsFunc(features, func(f catwalk.Feature) bool {
+			return unsupported(provider.ID, candidate, f, effort, probed) != ""
+		}) {
+			models = append(models, candidate)
+		}
+	}
+	slices.SortFunc(models, func(a, b catwalk.Model) int {
+		return cmp.Or(
+			cmp.Compare(math.Abs(price(a)-price(m)), math.Abs(price(b)-price(m))),
+			strings.Compare(a.ID, b.ID),
+		)
+	})
+	var ids []string
+	for _, c := range models[:min(len(models), maxSuggestions)] {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+// Preflight returns middleware that fails a request with a
+// *catwalk.UnsupportedFeatureError, without sending it, when it uses a
+// feature the model does not support; see [CheckFeatures].
+func Preflight(probed Probed) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+			if err := CheckFeatures(req.Provider, req.Model, req.Params, probed); err != nil {
+				return nil, err
+			}
+			return next.Complete(ctx, req)
+		})
+	}
+}
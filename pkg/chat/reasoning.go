@@ -0,0 +1,113 @@
+package chat
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Tags around the reasoning that DeepSeek-R1, QwQ, and Qwen3 put at the
+// start of their reply on hosts that do not split it into its own field.
+const (
+	thinkOpen  = "<think>"
+	thinkClose = "</think>"
+)
+
+// reasoningChunk is the reasoning field OpenRouter, and vLLM with a
+// reasoning parser, stream instead of DeepSeek's reasoning_content.
+type reasoningChunk struct {
+	Choices []struct {
+		Delta struct {
+			Reasoning string `json:"reasoning"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+// reasoningDelta returns the reasoning field of a streamed chunk, if any.
+func reasoningDelta(raw []byte) string {
+	var chunk reasoningChunk
+	if json.Unmarshal(raw, &chunk) != nil || len(chunk.Choices) == 0 {
+		return ""
+	}
+	return chunk.Choices[0].Delta.Reasoning
+}
+
+// thinkSplitter separates a <think> block at the start of a streamed reply
+// from the reply itself. Text that might still turn out to be a tag is held
+// back until the next delta or [thinkSplitter.flush].
+type thinkSplitter struct {
+	state   thinkState
+	pending string
+}
+
+type thinkState int
+
+const (
+	thinkStart thinkState = iota // before the first non-space text
+	thinking                     // inside the block
+	thinkAfter                   // after the block, skipping blank lines
+	thinkDone                    // in the reply
+)
+
+// split adds a delta of the reply and returns the content and reasoning
+// it completes.
+func (t *thinkSplitter) split(delta string) (content, reasoning string) {
+	t.pending += delta
+	for {
+		switch t.state {
+		case thinkStart:
+			trimmed := strings.TrimLeft(t.pending, " \t\r\n")
+			switch {
+			case strings.HasPrefix(trimmed, thinkOpen):
+				t.pending, t.state = trimmed[len(thinkOpen):], thinking
+				continue
+			case strings.HasPrefix(thinkOpen, trimmed):
+				return "", "" // maybe the start of the tag
+			}
+			t.state = thinkDone
+		case thinking:
+			if i := strings.Index(t.pending, thinkClose); i >= 0 {
+				reasoning, t.pending, t.state = t.pending[:i], t.pending[i+len(thinkClose):], thinkAfter
+				content, _ = t.split("")
+				return content, reasoning
+			}
+			// Hold back what may be the start of the closing tag
+			n := len(t.pending) - partialSuffix(t.pending, thinkClose)
+			reasoning, t.pending = t.pending[:n], t.pending[n:]
+			return "", reasoning
+		case thinkAfter:
+			t.pending = strings.TrimLeft(t.pending, " \t\r\n")
+			if t.pending == "" {
+				return "", ""
+			}
+			t.state = thinkDone
+		default:
+			content, t.pending = t.pending, ""
+			return content, ""
+		}
+	}
+}
+
+// flush returns what split held back at the end of the reply. An unclosed
+// block is all reasoning.
+func (t *thinkSplitter) flush() (content, reasoning string) {
+	pending := t.pending
+	t.pending = ""
+	switch t.state {
+	case thinking:
+		return "", pending
+	case thinkAfter:
+		return "", ""
+	}
+	return pending, ""
+}
+
+// partialSuffix returns the length of the longest end of s that is a
+// proper prefix of tag.
+func partialSuffix(s, tag string) int {
+	for n := min(len(s), len(tag)-1); n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
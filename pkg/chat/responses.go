@@ -0,0 +1,533 @@
+package chat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/sashabaranov/go-openai"
+)
+
+// responsesOnly matches the OpenAI models served only by the Responses API,
+// which the catalog does not mark: the pro and deep research models, the
+// Codex models, and computer use.
+var responsesOnly = regexp.MustCompile(`^(o1-pro|o3-pro|o3-deep-research|o4-mini-deep-research|gpt-5(\.\d+)?-pro|gpt-5(\.\d+)?-codex|codex-mini|computer-use-preview)`)
+
+// UsesResponses reports whether requests to model m go to the OpenAI
+// Responses API rather than chat completions. Only OpenAI and Azure serve
+// it; there, a model whose catalog options set provider_options.api to
+// "responses" or "chat" gets that API, and others the Responses API if they
+// are only served by it, such as o3-pro and gpt-5-codex.
+func UsesResponses(p catwalk.Provider, m catwalk.Model) bool {
+	if p.Type != catwalk.TypeOpenAI && p.Type != catwalk.TypeAzure {
+		return false
+	}
+	if api, ok := m.Options.ProviderOptions["api"].(string); ok {
+		return api == "responses"
+	}
+	return responsesOnly.MatchString(m.ID)
+}
+
+// responsesTransport sends chat completion requests for its models to the
+// Responses API and converts the replies back, streamed or not, so sessions
+// and anything else using the client need not know which API a model takes.
+// Reasoning summaries arrive as reasoning content, and function calls as
+// tool calls.
+type responsesTransport struct {
+	base http.RoundTripper
+	// models are the models to convert requests for, by ID.
+	models map[string]catwalk.Model
+}
+
+// newResponsesTransport returns a transport for provider's models that use
+// the Responses API, or nil if none do.
+func newResponsesTransport(provider catwalk.Provider, base http.RoundTripper) http.RoundTripper {
+	models := map[string]catwalk.Model{}
+	for _, m := range provider.Models {
+		if UsesResponses(provider, m) {
+			models[m.ID] = m
+		}
+	}
+	if len(models) == 0 {
+		return nil
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &responsesTransport{base: base, models: models}
+}
+
+func (t *responsesTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	prefix, ok := strings.CutSuffix(req.URL.Path, "/chat/completions")
+	if !ok || req.Method != http.MethodPost || req.Body == nil {
+		return t.base.RoundTrip(req)
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close() //nolint:errcheck
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request: %w", err)
+	}
+	var r openai.ChatCompletionRequest
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("invalid chat request: %w", err)
+	}
+	m, ok := t.models[r.Model]
+	if !ok {
+		return t.base.RoundTrip(withBody(req, req.URL.Path, body))
+	}
+
+	params, err := responsesRequest(r, m.CanReason)
+	if err != nil {
+		return nil, err
+	}
+	if body, err = json.Marshal(params); err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	resp, err := t.base.RoundTrip(withBody(req, prefix+"/responses", body))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		// Errors are {"error": {...}} objects, which go-openai reads as is
+		return resp, err
+	}
+
+	if r.Stream {
+		includeUsage := r.StreamOptions != nil && r.StreamOptions.IncludeUsage
+		events := resp.Body
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(convertResponsesStream(events, pw, r.Model, includeUsage))
+			events.Close() //nolint:errcheck
+		}()
+		resp.Body = pr
+		resp.ContentLength = -1
+		resp.Header.Del("Content-Length")
+		return resp, nil
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close() //nolint:errcheck
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	out, err := convertResponse(data, r.Model)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(out))
+	resp.ContentLength = int64(len(out))
+	resp.Header.Set("Content-Length", fmt.Sprint(len(out)))
+	resp.Header.Set("Content-Type", "application/json")
+	return resp, nil
+}
+
+// withBody returns a copy of req sent to path with body.
+func withBody(req *http.Request, path string, body []byte) *http.Request {
+	req = req.Clone(req.Context())
+	req.URL.Path = path
+	req.URL.RawPath = ""
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	return req
+}
+
+// responsesParams is a Responses API request. Nothing is stored at the
+// provider, as the whole conversation is sent each time.
+type responsesParams struct {
+	Model           string              `json:"model"`
+	Input           []responsesItem     `json:"input"`
+	Stream          bool                `json:"stream,omitempty"`
+	MaxOutputTokens int                 `json:"max_output_tokens,omitempty"`
+	Temperature     *float32            `json:"temperature,omitempty"`
+	TopP            *float32            `json:"top_p,omitempty"`
+	Reasoning       *responsesReasoning `json:"reasoning,omitempty"`
+	Tools           []responsesTool     `json:"tools,omitempty"`
+	ToolChoice      any                 `json:"tool_choice,omitempty"`
+	Text            *responsesText      `json:"text,omitempty"`
+	User            string              `json:"user,omitempty"`
+	Store           bool                `json:"store"`
+}
+
+// responsesItem is an input item: a message, a function call the model
+// made, or a call's output.
+type responsesItem struct {
+	Type      string             `json:"type"`
+	Role      string             `json:"role,omitempty"`
+	Content   []responsesContent `json:"content,omitempty"`
+	CallID    string             `json:"call_id,omitempty"`
+	Name      string             `json:"name,omitempty"`
+	Arguments string             `json:"arguments,omitempty"`
+	Output    *string            `json:"output,omitempty"`
+}
+
+type responsesContent struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	ImageURL string `json:"image_url,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+type responsesReasoning struct {
+	Effort  string `json:"effort,omitempty"`
+	Summary string `json:"summary,omitempty"`
+}
+
+type responsesTool struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+	Strict      bool   `json:"strict,omitempty"`
+}
+
+type responsesText struct {
+	Format responsesFormat `json:"format"`
+}
+
+type responsesFormat struct {
+	Type   string          `json:"type"`
+	Name   string          `json:"name,omitempty"`
+	Schema json.RawMessage `json:"schema,omitempty"`
+	Strict bool            `json:"strict,omitempty"`
+}
+
+// responsesRequest converts a chat completion request. Assistant tool calls
+// and tool messages become function call and output items; reasoning
+// summaries are asked for if the model reasons.
+func responsesRequest(r openai.ChatCompletionRequest, reasons bool) (responsesParams, error) {
+	p := responsesParams{
+		Model:           r.Model,
+		Stream:          r.Stream,
+		MaxOutputTokens: r.MaxCompletionTokens,
+		ToolChoice:      r.ToolChoice,
+		User:            r.User,
+	}
+	if p.MaxOutputTokens == 0 {
+		p.MaxOutputTokens = r.MaxTokens
+	}
+	if r.Temperature != 0 {
+		p.Temperature = &r.Temperature
+	}
+	if r.TopP != 0 {
+		p.TopP = &r.TopP
+	}
+	if reasons {
+		p.Reasoning = &responsesReasoning{Effort: r.ReasoningEffort, Summary: "auto"}
+	}
+
+	// A specific function is {"type": "function", "name": ...} rather than
+	// nested under "function"
+	if choice, ok := r.ToolChoice.(map[string]any); ok {
+		if f, ok := choice["function"].(map[string]any); ok {
+			p.ToolChoice = map[string]any{"type": "function", "name": f["name"]}
+		}
+	}
+	for _, tool := range r.Tools {
+		if tool.Type != openai.ToolTypeFunction || tool.Function == nil {
+			return responsesParams{}, fmt.Errorf("responses: unsupported tool type %q", tool.Type)
+		}
+		p.Tools = append(p.Tools, responsesTool{
+			Type:        "function",
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			Parameters:  tool.Function.Parameters,
+			Strict:      tool.Function.Strict,
+		})
+	}
+	if len(r.Functions) > 0 {
+		return responsesParams{}, errors.New("responses: functions are not supported; use tools")
+	}
+
+	if f := r.ResponseFormat; f != nil {
+		switch f.Type {
+		case openai.ChatCompletionResponseFormatTypeJSONObject:
+			p.Text = &responsesText{Format: responsesFormat{Type: "json_object"}}
+		case openai.ChatCompletionResponseFormatTypeJSONSchema:
+			if f.JSONSchema == nil {
+				return responsesParams{}, errors.New("responses: json_schema format without a schema")
+			}
+			schema, err := json.Marshal(f.JSONSchema.Schema)
+			if err != nil {
+				return responsesParams{}, fmt.Errorf("responses: invalid schema: %w", err)
+			}
+			p.Text = &responsesText{Format: responsesFormat{Type: "json_schema", Name: f.JSONSchema.Name, Schema: schema, Strict: f.JSONSchema.Strict}}
+		}
+	}
+
+	for _, m := range r.Messages {
+		switch {
+		case m.Role == openai.ChatMessageRoleTool:
+			output := m.Content
+			p.Input = append(p.Input, responsesItem{Type: "function_call_output", CallID: m.ToolCallID, Output: &output})
+			continue
+		case m.Role == openai.ChatMessageRoleFunction:
+			return responsesParams{}, errors.New("responses: function messages are not supported; use tool messages")
+		}
+
+		// Assistant turns are output text; everything else is input
+		text := "input_text"
+		if m.Role == openai.ChatMessageRoleAssistant {
+			text = "output_text"
+		}
+		var content []responsesContent
+		if m.Content != "" {
+			content = append(content, responsesContent{Type: text, Text: m.Content})
+		}
+		for _, part := range m.MultiContent {
+			switch {
+			case part.Type == openai.ChatMessagePartTypeText:
+				content = append(content, responsesContent{Type: text, Text: part.Text})
+			case part.Type == openai.ChatMessagePartTypeImageURL && part.ImageURL != nil:
+				content = append(content, responsesContent{Type: "input_image", ImageURL: part.ImageURL.URL, Detail: string(part.ImageURL.Detail)})
+			}
+		}
+		if len(content) > 0 {
+			p.Input = append(p.Input, responsesItem{Type: "message", Role: m.Role, Content: content})
+		}
+		for _, call := range m.ToolCalls {
+			p.Input = append(p.Input, responsesItem{Type: "function_call", CallID: call.ID, Name: call.Function.Name, Arguments: call.Function.Arguments})
+		}
+	}
+	return p, nil
+}
+
+// responsesResponse is a Responses API reply, or the one carried by a
+// stream's closing event.
+type responsesResponse struct {
+	ID                string            `json:"id"`
+	Output            []responsesOutput `json:"output"`
+	Usage             *responsesUsage   `json:"usage"`
+	IncompleteDetails *struct {
+		Reason string `json:"reason"`
+	} `json:"incomplete_details"`
+	Error json.RawMessage `json:"error"`
+}
+
+// responsesOutput is an output item; each type fills in some of the
+// fields.
+type responsesOutput struct {
+	Type      string          `json:"type"`
+	Content   []responsesPart `json:"content"`
+	Summary   []responsesPart `json:"summary"`
+	CallID    string          `json:"call_id"`
+	Name      string          `json:"name"`
+	Arguments string          `json:"arguments"`
+}
+
+// responsesPart is a message's text or refusal, or a reasoning summary.
+type responsesPart struct {
+	Type    string `json:"type"`
+	Text    string `json:"text"`
+	Refusal string `json:"refusal"`
+}
+
+type responsesUsage struct {
+	InputTokens        int `json:"input_tokens"`
+	OutputTokens       int `json:"output_tokens"`
+	InputTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"input_tokens_details"`
+	OutputTokensDetails struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"output_tokens_details"`
+}
+
+// chat returns the usage as chat completions report it. Output tokens
+// include the reasoning tokens in both.
+func (u responsesUsage) chat() *openai.Usage {
+	return &openai.Usage{
+		PromptTokens:            u.InputTokens,
+		CompletionTokens:        u.OutputTokens,
+		TotalTokens:             u.InputTokens + u.OutputTokens,
+		PromptTokensDetails:     &openai.PromptTokensDetails{CachedTokens: u.InputTokensDetails.CachedTokens},
+		CompletionTokensDetails: &openai.CompletionTokensDetails{ReasoningTokens: u.OutputTokensDetails.ReasoningTokens},
+	}
+}
+
+// finishReason returns the chat finish reason of a finished response.
+func (r responsesResponse) finishReason(calls bool) openai.FinishReason {
+	switch {
+	case r.IncompleteDetails != nil && r.IncompleteDetails.Reason == "max_output_tokens":
+		return openai.FinishReasonLength
+	case r.IncompleteDetails != nil && r.IncompleteDetails.Reason == "content_filter":
+		return openai.FinishReasonContentFilter
+	case calls:
+		return openai.FinishReasonToolCalls
+	default:
+		return openai.FinishReasonStop
+	}
+}
+
+// convertResponse converts a Responses API reply to a chat completion.
+// Refusals are passed on as text.
+func convertResponse(data []byte, model string) ([]byte, error) {
+	var r responsesResponse
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	msg := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+	var text, summaries []string
+	for _, item := range r.Output {
+		switch item.Type {
+		case "message":
+			for _, c := range item.Content {
+				text = append(text, c.Text+c.Refusal)
+			}
+		case "reasoning":
+			for _, s := range item.Summary {
+				summaries = append(summaries, s.Text)
+			}
+		case "function_call":
+			msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{
+				ID:       item.CallID,
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: item.Name, Arguments: item.Arguments},
+			})
+		}
+	}
+	msg.Content = strings.Join(text, "")
+	msg.ReasoningContent = strings.Join(summaries, "\n\n")
+
+	out := openai.ChatCompletionResponse{
+		ID:      r.ID,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []openai.ChatCompletionChoice{{Message: msg, FinishReason: r.finishReason(len(msg.ToolCalls) > 0)}},
+	}
+	if r.Usage != nil {
+		out.Usage = *r.Usage.chat()
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode response: %w", err)
+	}
+	return data, nil
+}
+
+// responsesEvent is a Responses API stream event; each type fills in some
+// of the fields.
+type responsesEvent struct {
+	Type         string            `json:"type"`
+	Delta        string            `json:"delta"`
+	OutputIndex  int               `json:"output_index"`
+	SummaryIndex int               `json:"summary_index"`
+	Item         responsesOutput   `json:"item"`
+	Response     responsesResponse `json:"response"`
+
+	// An "error" event's fields
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// convertResponsesStream rewrites a Responses API event stream from r as
+// chat completion chunks on w, ending with a usage chunk if includeUsage is
+// set. Reasoning summaries are sent as reasoning content, separate summaries
+// a blank line apart, and function calls as tool calls. A failed response
+// is passed on as an error chunk, which go-openai reports.
+func convertResponsesStream(r io.Reader, w io.Writer, model string, includeUsage bool) error {
+	var (
+		id      string
+		created = time.Now().Unix()
+		// Tool call indexes by output item, as calls are numbered apart
+		// from the other output
+		calls = map[int]int{}
+		// The last summary part streamed, to separate the next one
+		summary = [2]int{-1, -1}
+	)
+	send := func(delta openai.ChatCompletionStreamChoiceDelta, finish openai.FinishReason, u *openai.Usage) error {
+		choices := []openai.ChatCompletionStreamChoice{{Delta: delta, FinishReason: finish}}
+		if u != nil {
+			choices = []openai.ChatCompletionStreamChoice{}
+		}
+		data, err := json.Marshal(openai.ChatCompletionStreamResponse{
+			ID: id, Object: "chat.completion.chunk", Created: created, Model: model,
+			Choices: choices, Usage: u,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode chunk: %w", err)
+		}
+		_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+		return err //nolint:wrapcheck
+	}
+	sendError := func(e json.RawMessage) error {
+		_, err := fmt.Fprintf(w, "data: {\"error\": %s}\n\n", e)
+		return err //nolint:wrapcheck
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		var e responsesEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &e); err != nil {
+			return fmt.Errorf("invalid stream event: %w", err)
+		}
+
+		var err error
+		switch e.Type {
+		case "response.created":
+			id = e.Response.ID
+			err = send(openai.ChatCompletionStreamChoiceDelta{Role: openai.ChatMessageRoleAssistant}, "", nil)
+		case "response.output_text.delta", "response.refusal.delta":
+			err = send(openai.ChatCompletionStreamChoiceDelta{Content: e.Delta}, "", nil)
+		case "response.reasoning_summary_text.delta":
+			delta := e.Delta
+			if part := [2]int{e.OutputIndex, e.SummaryIndex}; part != summary {
+				if summary[0] >= 0 {
+					delta = "\n\n" + delta
+				}
+				summary = part
+			}
+			err = send(openai.ChatCompletionStreamChoiceDelta{ReasoningContent: delta}, "", nil)
+		case "response.output_item.added":
+			if e.Item.Type != "function_call" {
+				continue
+			}
+			index := len(calls)
+			calls[e.OutputIndex] = index
+			err = send(openai.ChatCompletionStreamChoiceDelta{ToolCalls: []openai.ToolCall{{
+				Index:    &index,
+				ID:       e.Item.CallID,
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: e.Item.Name, Arguments: e.Item.Arguments},
+			}}}, "", nil)
+		case "response.function_call_arguments.delta":
+			index, ok := calls[e.OutputIndex]
+			if !ok {
+				continue
+			}
+			err = send(openai.ChatCompletionStreamChoiceDelta{ToolCalls: []openai.ToolCall{{
+				Index:    &index,
+				Function: openai.FunctionCall{Arguments: e.Delta},
+			}}}, "", nil)
+		case "response.completed", "response.incomplete":
+			err = send(openai.ChatCompletionStreamChoiceDelta{}, e.Response.finishReason(len(calls) > 0), nil)
+			if err == nil && includeUsage && e.Response.Usage != nil {
+				err = send(openai.ChatCompletionStreamChoiceDelta{}, "", e.Response.Usage.chat())
+			}
+			if err == nil {
+				_, err = io.WriteString(w, "data: [DONE]\n\n")
+			}
+		case "response.failed":
+			err = sendError(e.Response.Error)
+		case "error":
+			body, _ := json.Marshal(map[string]string{"code": e.Code, "message": e.Message})
+			err = sendError(body)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err() //nolint:wrapcheck
+}
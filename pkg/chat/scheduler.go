@@ -0,0 +1,351 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/tokenizer"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Limit is a model's rate limit: at most Requests requests and Tokens
+// tokens, prompts and replies together, in any Per. A zero Requests or
+// Tokens leaves that count unlimited.
+type Limit struct {
+	Requests int
+	Tokens   int
+	Per      time.Duration
+}
+
+// String returns the limit as, e.g., "60 requests, 30000 tokens per 1m0s".
+func (l Limit) String() string {
+	var parts []string
+	if l.Requests > 0 {
+		parts = append(parts, fmt.Sprintf("%d requests", l.Requests))
+	}
+	if l.Tokens > 0 {
+		parts = append(parts, fmt.Sprintf("%d tokens", l.Tokens))
+	}
+	return strings.Join(parts, ", ") + " per " + l.Per.String()
+}
+
+// Scheduler paces requests to stay under each model's rate limits, rather
+// than relying on retries after the provider refuses them. Limits are set
+// per model with [Scheduler.SetLimit], from an overrides file say, and
+// learned from the rate-limit headers of the provider's responses seen by
+// [Scheduler.Transport]. A 429 response, or headers reporting nothing left,
+// also hold the model's requests until the provider's reset time.
+//
+// A Scheduler is safe for concurrent use; share one among every session and
+// goroutine sending to the same models. A nil *Scheduler paces nothing.
+type Scheduler struct {
+	// OnLimit, if set, is called when response headers report a model's
+	// limit for the first time or a changed one.
+	OnLimit func(provider catwalk.InferenceProvider, model string, l Limit)
+
+	mu     sync.Mutex
+	models map[scheduleKey]*schedule
+	now    func() time.Time
+}
+
+// scheduleKey identifies a model.
+type scheduleKey struct {
+	provider catwalk.InferenceProvider
+	model    string
+}
+
+// modelContextKey is the context key of the model a request is for, which
+// tells the transport whose limits a response reports.
+type modelContextKey struct{}
+
+// schedule is one model's limits and the requests granted within them,
+// oldest first.
+type schedule struct {
+	configured, learned Limit
+	grants              []*grant
+	paused              time.Time
+}
+
+// grant is a request allowed to start at a time, with the tokens it counts
+// against the limit: estimated until its reply reports them.
+type grant struct {
+	at     time.Time
+	tokens int
+}
+
+// NewScheduler returns a Scheduler with no limits set.
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// SetLimit sets a model's limit. Limits learned from headers are enforced
+// as well, so the stricter of the two applies.
+func (s *Scheduler) SetLimit(provider catwalk.InferenceProvider, model string, l Limit) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schedule(scheduleKey{provider, model}).configured = l
+}
+
+// Middleware returns middleware that holds each request until its model's
+// limits allow it. A request's tokens are estimated from its messages and
+// reply limit, then corrected to the usage its reply reports. Put it after
+// [Retry] in a chain, so retries are paced too.
+func (s *Scheduler) Middleware() Middleware {
+	return func(next Handler) Handler {
+		if s == nil {
+			return next
+		}
+		return HandlerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+			key := scheduleKey{req.Provider.ID, req.Model.ID}
+			g := s.reserve(key, requestTokens(req.Params.Messages, max(req.Params.MaxTokens, req.Params.MaxCompletionTokens)))
+			if wait := g.at.Sub(s.clock()); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					s.release(key, g)
+					return nil, ctx.Err() //nolint:wrapcheck
+				case <-timer.C:
+				}
+			}
+
+			resp, err := next.Complete(context.WithValue(ctx, modelContextKey{}, key), req)
+			if resp != nil && resp.InputTokens+resp.OutputTokens > 0 {
+				s.mu.Lock()
+				g.tokens = resp.InputTokens + resp.OutputTokens
+				s.mu.Unlock()
+			}
+			return resp, err
+		})
+	}
+}
+
+// Transport returns a RoundTripper that sends requests through base
+// (http.DefaultTransport if nil) and learns limits from the responses to
+// requests made through [Scheduler.Middleware]. It reads the
+// x-ratelimit-limit-requests and -tokens headers of OpenAI-compatible
+// providers and Anthropic's anthropic-ratelimit-requests-limit and
+// -tokens-limit, taking them as per-minute limits, and waits out
+// Retry-After or the reset headers after a 429 or when none are left. A
+// nil *Scheduler returns base.
+func (s *Scheduler) Transport(base http.RoundTripper) http.RoundTripper {
+	if s == nil {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := base.RoundTrip(req)
+		if key, ok := req.Context().Value(modelContextKey{}).(scheduleKey); ok && err == nil {
+			s.observe(key, resp.StatusCode, resp.Header)
+		}
+		return resp, err //nolint:wrapcheck
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// reserve grants a request of tokens the earliest start that keeps every
+// limit of the model, after any pause and the requests granted before it.
+// A request larger than the token limit starts once the window is empty.
+func (s *Scheduler) reserve(key scheduleKey, tokens int) *grant {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sc := s.schedule(key)
+	now := s.clock()
+	limits := sc.limits()
+
+	// Grants older than the longest window count against nothing
+	var longest time.Duration
+	for _, l := range limits {
+		longest = max(longest, l.Per)
+	}
+	sc.grants = slices.DeleteFunc(sc.grants, func(g *grant) bool { return !g.at.After(now.Add(-longest)) })
+
+	at := now
+	if sc.paused.After(at) {
+		at = sc.paused
+	}
+	if n := len(sc.grants); n > 0 && sc.grants[n-1].at.After(at) {
+		at = sc.grants[n-1].at
+	}
+	for {
+		next := at
+		for _, l := range limits {
+			var requests, used int
+			oldest := -1
+			for i, g := range sc.grants {
+				if g.at.After(at.Add(-l.Per)) {
+					if oldest < 0 {
+						oldest = i
+					}
+					requests++
+					used += g.tokens
+				}
+			}
+			full := (l.Requests > 0 && requests >= l.Requests) || (l.Tokens > 0 && requests > 0 && used+tokens > l.Tokens)
+			if full {
+				// Wait for the oldest request in the window to leave it
+				if t := sc.grants[oldest].at.Add(l.Per); t.After(next) {
+					next = t
+				}
+			}
+		}
+		if next.Equal(at) {
+			break
+		}
+		at = next
+	}
+
+	g := &grant{at: at, tokens: tokens}
+	sc.grants = append(sc.grants, g)
+	return g
+}
+
+// release drops a grant whose request was cancelled before it started.
+func (s *Scheduler) release(key scheduleKey, g *grant) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sc := s.schedule(key)
+	sc.grants = slices.DeleteFunc(sc.grants, func(x *grant) bool { return x == g })
+}
+
+// observe learns a model's limits from a response's headers, and pauses
+// its requests after a 429 or when the headers report none left.
+func (s *Scheduler) observe(key scheduleKey, status int, h http.Header) {
+	learned := Limit{
+		Requests: headerInt(h, "x-ratelimit-limit-requests", "anthropic-ratelimit-requests-limit"),
+		Tokens:   headerInt(h, "x-ratelimit-limit-tokens", "anthropic-ratelimit-tokens-limit"),
+		Per:      time.Minute,
+	}
+	now := s.clock()
+	var wait time.Duration
+	switch {
+	case status == http.StatusTooManyRequests:
+		wait = retryAfter(h, now)
+		if wait == 0 {
+			wait = max(resetAfter(h, now, "requests"), resetAfter(h, now, "tokens"))
+		}
+	case headerRemaining(h, "requests") == 0:
+		wait = resetAfter(h, now, "requests")
+	case headerRemaining(h, "tokens") == 0:
+		wait = resetAfter(h, now, "tokens")
+	}
+
+	s.mu.Lock()
+	sc := s.schedule(key)
+	if wait > 0 && now.Add(wait).After(sc.paused) {
+		sc.paused = now.Add(wait)
+	}
+	changed := (learned.Requests > 0 || learned.Tokens > 0) && learned != sc.learned
+	if changed {
+		sc.learned = learned
+	}
+	s.mu.Unlock()
+
+	if changed && s.OnLimit != nil {
+		s.OnLimit(key.provider, key.model, learned)
+	}
+}
+
+// limits returns the limits to keep: the configured one and the learned
+// one, if set.
+func (sc *schedule) limits() []Limit {
+	var limits []Limit
+	for _, l := range []Limit{sc.configured, sc.learned} {
+		if l.Per > 0 && (l.Requests > 0 || l.Tokens > 0) {
+			limits = append(limits, l)
+		}
+	}
+	return limits
+}
+
+func (s *Scheduler) schedule(key scheduleKey) *schedule {
+	if s.models == nil {
+		s.models = map[scheduleKey]*schedule{}
+	}
+	sc, ok := s.models[key]
+	if !ok {
+		sc = &schedule{}
+		s.models[key] = sc
+	}
+	return sc
+}
+
+func (s *Scheduler) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+// requestTokens estimates the tokens a request counts against a token
+// limit: its prompt, and the reply limit, which providers reserve up front.
+func requestTokens(messages []openai.ChatCompletionMessage, maxTokens int) int {
+	n := tokenizer.ReplyOverhead + maxTokens
+	for _, m := range messages {
+		n += tokenizer.CountMessage(m.Role, m.Content)
+	}
+	return n
+}
+
+// headerInt returns the first of the headers that holds a positive
+// integer, or 0.
+func headerInt(h http.Header, names ...string) int {
+	for _, name := range names {
+		if n, err := strconv.Atoi(h.Get(name)); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// headerRemaining returns the requests or tokens the headers report left,
+// or -1 if they do not say.
+func headerRemaining(h http.Header, kind string) int {
+	for _, name := range []string{"x-ratelimit-remaining-" + kind, "anthropic-ratelimit-" + kind + "-remaining"} {
+		if n, err := strconv.Atoi(h.Get(name)); err == nil {
+			return n
+		}
+	}
+	return -1
+}
+
+// retryAfter returns how long Retry-After, in seconds or as a date, or
+// retry-after-ms asks to wait, or 0.
+func retryAfter(h http.Header, now time.Time) time.Duration {
+	if ms, err := strconv.ParseFloat(h.Get("retry-after-ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	v := h.Get("Retry-After")
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// resetAfter returns how long until the requests or tokens limit resets:
+// a duration such as "6m0s" in x-ratelimit-reset-*, or a time in
+// anthropic-ratelimit-*-reset. It returns 0 if the headers do not say.
+func resetAfter(h http.Header, now time.Time, kind string) time.Duration {
+	if d, err := time.ParseDuration(h.Get("x-ratelimit-reset-" + kind)); err == nil && d > 0 {
+		return d
+	}
+	if t, err := time.Parse(time.RFC3339, h.Get("anthropic-ratelimit-"+kind+"-reset")); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
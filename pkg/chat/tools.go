@@ -0,0 +1,216 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ToolCall is a function call requested by the model.
+type ToolCall struct {
+	ID   string
+	Name string
+
+	// Arguments is the JSON the model wrote for the call, which is partial
+	// until the call is done; see [ParseArguments].
+	Arguments string
+}
+
+// ToolCallHandler receives tool calls as they stream. Any field may be nil.
+type ToolCallHandler struct {
+	// Start is called when a call's name is first known.
+	Start func(index int, call ToolCall)
+
+	// Arguments is called with each fragment of a call's arguments; call
+	// holds the arguments received so far.
+	Arguments func(index int, call ToolCall, fragment string)
+
+	// Done is called for each call, in order, once the reply is complete.
+	Done func(index int, call ToolCall)
+}
+
+// ToolCallAssembler merges the tool call deltas of a streamed reply into
+// complete calls, reporting progress to its Handler. Providers differ in how
+// they stream calls: OpenAI sends the ID and name once and then argument
+// fragments under the same index, while others send whole calls in one
+// delta, reuse index 0 for parallel calls with new IDs, omit the index, or
+// repeat the name. All of these are handled.
+//
+// The zero value is ready to use.
+type ToolCallAssembler struct {
+	Handler ToolCallHandler
+
+	calls   []ToolCall
+	indexes map[int]int // delta index to position in calls
+	started []bool
+	done    bool
+}
+
+// Add merges the tool call deltas of one stream chunk.
+func (a *ToolCallAssembler) Add(deltas []openai.ToolCall) {
+	for _, d := range deltas {
+		i := a.position(d)
+		c := &a.calls[i]
+		if d.ID != "" {
+			c.ID = d.ID
+		}
+		// Most providers send the name once; some repeat it in every delta
+		// and a few split it, so only new text is appended
+		if name := d.Function.Name; name != "" && name != c.Name {
+			c.Name += name
+		}
+		if c.Name != "" && !a.started[i] {
+			a.started[i] = true
+			if a.Handler.Start != nil {
+				a.Handler.Start(i, *c)
+			}
+		}
+		if frag := d.Function.Arguments; frag != "" {
+			c.Arguments += frag
+			if a.Handler.Arguments != nil {
+				a.Handler.Arguments(i, *c, frag)
+			}
+		}
+	}
+}
+
+// position returns the index in calls that delta d belongs to, adding a call
+// if it starts a new one.
+func (a *ToolCallAssembler) position(d openai.ToolCall) int {
+	if a.indexes == nil {
+		a.indexes = map[int]int{}
+	}
+	add := func() int {
+		a.calls = append(a.calls, ToolCall{})
+		a.started = append(a.started, false)
+		return len(a.calls) - 1
+	}
+
+	if d.Index == nil {
+		// Without an index, a new ID starts a new call and anything else
+		// continues the last one
+		if len(a.calls) == 0 || (d.ID != "" && d.ID != a.calls[len(a.calls)-1].ID) {
+			return add()
+		}
+		return len(a.calls) - 1
+	}
+
+	i, ok := a.indexes[*d.Index]
+	if !ok || (d.ID != "" && a.calls[i].ID != "" && d.ID != a.calls[i].ID) {
+		i = add()
+		a.indexes[*d.Index] = i
+	}
+	return i
+}
+
+// Calls returns the calls assembled so far.
+func (a *ToolCallAssembler) Calls() []ToolCall {
+	return append([]ToolCall(nil), a.calls...)
+}
+
+// Finish reports every call to Handler.Done, once, and returns them. Call it
+// when the stream has ended.
+func (a *ToolCallAssembler) Finish() []ToolCall {
+	if !a.done && a.Handler.Done != nil {
+		for i, c := range a.calls {
+			a.Handler.Done(i, c)
+		}
+	}
+	a.done = true
+	return a.Calls()
+}
+
+// ParseArguments decodes tool call arguments into v. Arguments that end
+// early because they are still streaming are closed first: an unfinished
+// string, array, or object is terminated, and a trailing key, literal, or
+// number that cannot be completed is dropped, so a partial call can be shown
+// as it arrives. Empty arguments decode as an empty object.
+func ParseArguments(arguments string, v any) error {
+	s := strings.TrimSpace(arguments)
+	if s == "" {
+		s = "{}"
+	}
+	if truncated(s) {
+		s = closeJSON(s)
+	}
+	if err := json.Unmarshal([]byte(s), v); err != nil {
+		return fmt.Errorf("invalid tool call arguments: %w", err)
+	}
+	return nil
+}
+
+// truncated reports whether s is valid JSON cut short, as opposed to
+// complete or invalid.
+func truncated(s string) bool {
+	dec := json.NewDecoder(strings.NewReader(s))
+	for {
+		_, err := dec.Token()
+		switch {
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return true
+		case errors.Is(err, io.EOF):
+			// Complete, unless containers are left open
+			return dec.InputOffset() == int64(len(s)) && !json.Valid([]byte(s))
+		case err != nil:
+			return false
+		}
+	}
+}
+
+// maxJSONTrim bounds how far closeJSON backs up to find a prefix that can be
+// closed, so arguments that are simply invalid fail quickly.
+const maxJSONTrim = 256
+
+// closeJSON returns the longest prefix of s, within maxJSONTrim bytes of the
+// end, that is valid JSON once its open strings and containers are closed.
+// It returns s unchanged if there is none.
+func closeJSON(s string) string {
+	for end := len(s); end >= max(len(s)-maxJSONTrim, 1); end-- {
+		if closed := terminate(s[:end]); json.Valid([]byte(closed)) {
+			return closed
+		}
+	}
+	return s
+}
+
+// terminate closes the string and containers left open at the end of s.
+func terminate(s string) string {
+	var open []byte
+	inString, escaped := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			open = append(open, '}')
+		case c == '[':
+			open = append(open, ']')
+		case (c == '}' || c == ']') && len(open) > 0:
+			open = open[:len(open)-1]
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(s)
+	if escaped {
+		// A dangling backslash cannot start a valid escape
+		return ""
+	}
+	if inString {
+		sb.WriteByte('"')
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		sb.WriteByte(open[i])
+	}
+	return sb.String()
+}
@@ -0,0 +1,275 @@
+// Package commands adds slash commands to a chat program without changing
+// it: Go code registers a [Command] with a [Registry], and executables in a
+// commands directory become commands named after the file, so
+// ~/.config/aimodels/commands/jira answers /jira.
+//
+// An executable receives the session as a JSON [Request] on stdin and the
+// command's arguments as its own, with CATWALK_COMMAND set to the command
+// name. What it prints is shown to the user, unless it prints a JSON
+// [Result], whose prompt is sent to the model as the user's next message:
+//
+//	{"output": "Fetched PR #42.", "prompt": "Summarize this diff: ..."}
+//
+// A non-zero exit status fails the command with the last line of its
+// standard error. A comment on the line after a script's #! line, such as
+// "# Summarize a pull request", is the command's summary in help.
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"charm.land/catwalk/pkg/transcript"
+)
+
+// DefaultTimeout limits how long an executable may run when
+// Executable.Timeout is zero.
+const DefaultTimeout = time.Minute
+
+// Request is what a command is run with.
+type Request struct {
+	Command string `json:"command"`
+	// Args is the rest of the line after the command name.
+	Args string `json:"args,omitempty"`
+
+	Session  string               `json:"session,omitempty"`
+	Provider string               `json:"provider"`
+	Model    string               `json:"model"`
+	Messages []transcript.Message `json:"messages"`
+	// Cost is what the session has spent so far, in USD.
+	Cost float64 `json:"cost"`
+}
+
+// Result is what a command returns.
+type Result struct {
+	// Output is shown to the user.
+	Output string `json:"output,omitempty"`
+
+	// Prompt, if set, is sent to the model as the user's next message.
+	Prompt string `json:"prompt,omitempty"`
+}
+
+// Command is a slash command.
+type Command interface {
+	// Summary is a one-line description for help.
+	Summary() string
+
+	Run(ctx context.Context, req Request) (Result, error)
+}
+
+// Func adapts a function to a [Command].
+type Func struct {
+	Description string
+	Fn          func(ctx context.Context, req Request) (Result, error)
+}
+
+// Summary returns f.Description.
+func (f Func) Summary() string { return f.Description }
+
+// Run calls f.Fn.
+func (f Func) Run(ctx context.Context, req Request) (Result, error) { return f.Fn(ctx, req) }
+
+// validName matches command names: a letter, then letters, digits, dashes,
+// and underscores.
+var validName = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// Registry holds commands by name, without the leading slash. The zero
+// value is empty and ready to use.
+type Registry struct {
+	commands map[string]Command
+}
+
+// Register adds c as name. Names are lowercase, and each may be registered
+// once.
+func (r *Registry) Register(name string, c Command) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid command name %q: use lowercase letters, digits, dashes, and underscores", name)
+	}
+	if _, ok := r.commands[name]; ok {
+		return fmt.Errorf("command /%s is registered twice", name)
+	}
+	if r.commands == nil {
+		r.commands = map[string]Command{}
+	}
+	r.commands[name] = c
+	return nil
+}
+
+// Lookup returns the command registered as name, ignoring case.
+func (r *Registry) Lookup(name string) (Command, bool) {
+	c, ok := r.commands[strings.ToLower(name)]
+	return c, ok
+}
+
+// Names returns the registered names, sorted.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.commands))
+	for name := range r.commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// DefaultDir returns the default commands directory, aimodels/commands in
+// the user's config directory.
+func DefaultDir() (string, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine config directory: %w", err)
+	}
+	return filepath.Join(config, "aimodels", "commands"), nil
+}
+
+// LoadDir registers each executable in dir as an [Executable] named after
+// the file, without its extension. A missing directory adds nothing. Files
+// whose names are taken, by earlier registrations or by reserved names such
+// as a program's built-in commands, are skipped and reported in the error
+// after the others are loaded.
+func (r *Registry) LoadDir(dir string, reserved ...string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read commands: %w", err)
+	}
+
+	var errs []error
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if !executable(path) {
+			continue
+		}
+		name := strings.ToLower(strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())))
+		if slices.Contains(reserved, name) {
+			errs = append(errs, fmt.Errorf("%s: /%s is built in", path, name))
+			continue
+		}
+		if err := r.Register(name, Executable{Path: path}); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// executable reports whether path is a regular file the user can run: one
+// with an execute bit, or on Windows one with an extension in PATHEXT.
+func executable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS != "windows" {
+		return info.Mode().Perm()&0o111 != 0
+	}
+	exts := strings.ToLower(os.Getenv("PATHEXT"))
+	if exts == "" {
+		exts = ".com;.exe;.bat;.cmd"
+	}
+	return slices.Contains(strings.Split(exts, ";"), strings.ToLower(filepath.Ext(path)))
+}
+
+// Executable is a command run as an external program.
+type Executable struct {
+	Path string
+
+	// Timeout limits each run. Zero means DefaultTimeout.
+	Timeout time.Duration
+
+	// Stderr receives the program's standard error, if set.
+	Stderr io.Writer
+}
+
+// Summary returns the comment after a script's #! line, or the path.
+func (e Executable) Summary() string {
+	f, err := os.Open(e.Path)
+	if err != nil {
+		return e.Path
+	}
+	defer f.Close() //nolint:errcheck
+
+	sc := bufio.NewScanner(io.LimitReader(f, 4096))
+	if !sc.Scan() || !strings.HasPrefix(sc.Text(), "#!") || !sc.Scan() {
+		return e.Path
+	}
+	if comment, ok := strings.CutPrefix(sc.Text(), "#"); ok && strings.TrimSpace(comment) != "" {
+		return strings.TrimSpace(comment)
+	}
+	return e.Path
+}
+
+// Run runs the program with req on stdin and the words of req.Args as
+// arguments.
+func (e Executable) Run(ctx context.Context, req Request) (Result, error) {
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	input, err := json.Marshal(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to encode command input: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.Path, strings.Fields(req.Args)...)
+	cmd.Env = append(os.Environ(), "CATWALK_COMMAND="+req.Command)
+	// Don't wait for children that outlive a killed command
+	cmd.WaitDelay = time.Second
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if e.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(&stderr, e.Stderr)
+	}
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return Result{}, fmt.Errorf("/%s timed out after %s", req.Command, timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return Result{}, fmt.Errorf("/%s failed: %s", req.Command, lastLine(msg))
+		}
+		return Result{}, fmt.Errorf("/%s failed: %w", req.Command, err)
+	}
+	return parseOutput(stdout.Bytes()), nil
+}
+
+// parseOutput reads a program's output as a JSON Result, or else as text to
+// show.
+func parseOutput(output []byte) Result {
+	trimmed := bytes.TrimSpace(output)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var r Result
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		dec.DisallowUnknownFields()
+		if dec.Decode(&r) == nil && !dec.More() && (r.Output != "" || r.Prompt != "") {
+			return r
+		}
+	}
+	return Result{Output: strings.TrimRight(string(output), "\r\n")}
+}
+
+// lastLine returns the last line of s, which is usually the error message.
+func lastLine(s string) string {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	var r Registry
+	echo := Func{Description: "Echo the arguments", Fn: func(_ context.Context, req Request) (Result, error) {
+		return Result{Output: req.Args}, nil
+	}}
+	if err := r.Register("echo", echo); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("echo", echo); err == nil {
+		t.Error("registered a name twice")
+	}
+	for _, name := range []string{"", "Jira", "/jira", "1st", "a b"} {
+		if err := r.Register(name, echo); err == nil {
+			t.Errorf("registered invalid name %q", name)
+		}
+	}
+
+	c, ok := r.Lookup("ECHO")
+	if !ok || c.Summary() != "Echo the arguments" {
+		t.Fatalf("lookup = %v, %v", c, ok)
+	}
+	if res, _ := c.Run(context.Background(), Request{Args: "hi"}); res.Output != "hi" {
+		t.Errorf("output = %q", res.Output)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands in tests are shell scripts")
+	}
+	dir := t.TempDir()
+	write := func(name, content string, mode os.FileMode) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("jira.sh", "#!/bin/sh\n# Look up a Jira issue\necho \"$CATWALK_COMMAND $1 $2\"\n", 0o755)
+	write("pr", "#!/bin/sh\ngrep -q '\"model\":\"gpt-4o\"' && echo '{\"output\": \"Fetched.\", \"prompt\": \"Summarize\"}'\n", 0o755)
+	write("fail", "#!/bin/sh\necho starting >&2\necho 'no token set' >&2\nexit 1\n", 0o755)
+	write("clear", "#!/bin/sh\necho never\n", 0o755)
+	write("notes.txt", "not a command", 0o644)
+
+	var r Registry
+	err := r.LoadDir(dir, "clear", "quit")
+	if err == nil || !strings.Contains(err.Error(), "/clear is built in") {
+		t.Errorf("err = %v", err)
+	}
+	if got := strings.Join(r.Names(), ","); got != "fail,jira,pr" {
+		t.Fatalf("names = %s", got)
+	}
+
+	ctx := context.Background()
+	jira, _ := r.Lookup("jira")
+	if got := jira.Summary(); got != "Look up a Jira issue" {
+		t.Errorf("summary = %q", got)
+	}
+	res, err := jira.Run(ctx, Request{Command: "jira", Args: " ABC-1  ABC-2 "})
+	if err != nil || res.Output != "jira ABC-1 ABC-2" || res.Prompt != "" {
+		t.Errorf("jira = %+v, %v", res, err)
+	}
+
+	pr, _ := r.Lookup("pr")
+	res, err = pr.Run(ctx, Request{Command: "pr", Model: "gpt-4o"})
+	if err != nil || res.Output != "Fetched." || res.Prompt != "Summarize" {
+		t.Errorf("pr = %+v, %v", res, err)
+	}
+	if got := pr.Summary(); !strings.HasSuffix(got, "pr") {
+		t.Errorf("summary without a comment = %q", got)
+	}
+
+	fail, _ := r.Lookup("fail")
+	if _, err := fail.Run(ctx, Request{Command: "fail"}); err == nil || err.Error() != "/fail failed: no token set" {
+		t.Errorf("err = %v", err)
+	}
+
+	if err := new(Registry).LoadDir(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("missing directory: %v", err)
+	}
+}
+
+func TestParseOutput(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want Result
+	}{
+		{"plain text\n", Result{Output: "plain text"}},
+		{`{"prompt": "go"}`, Result{Prompt: "go"}},
+		{`{"issue": "ABC-1"}`, Result{Output: `{"issue": "ABC-1"}`}},
+		{"", Result{}},
+	} {
+		if got := parseOutput([]byte(tt.in)); got != tt.want {
+			t.Errorf("parseOutput(%q) = %+v", tt.in, got)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Package config is the aimodels settings file, by default
+// ~/.config/aimodels/config.yaml, which aimodels init writes: the catalog
+// URL, the default provider and model, and the output theme. Tools read it
+// for defaults; flags and environment variables still take precedence.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v2"
+)
+
+// Theme is a color scheme for terminal output.
+type Theme string
+
+// Themes. The zero value is ThemeAuto; see pkg/theme for their colors.
+const (
+	// ThemeAuto is ThemeDark or ThemeLight, whichever suits the terminal's
+	// background.
+	ThemeAuto Theme = "auto"
+	// ThemeDark is bright colors, for dark terminal backgrounds.
+	ThemeDark Theme = "dark"
+	// ThemeLight is deeper colors, for light terminal backgrounds.
+	ThemeLight Theme = "light"
+	// ThemeHighContrast is the strongest colors the terminal's background
+	// allows, for low vision or bright rooms.
+	ThemeHighContrast Theme = "high-contrast"
+	// ThemePlain is no color and ASCII symbols, as with NO_COLOR.
+	ThemePlain Theme = "plain"
+)
+
+// Themes lists the themes, the default first.
+var Themes = []Theme{ThemeAuto, ThemeDark, ThemeLight, ThemeHighContrast, ThemePlain}
+
+// Config is the settings file.
+type Config struct {
+	// CatalogURL is the catwalk service, used when CATWALK_URL is unset.
+	// Like CATWALK_URL, it may list mirrors after it, comma-separated.
+	CatalogURL string `yaml:"catalog_url,omitempty"`
+
+	// Provider and Model are the defaults of tools that talk to a model,
+	// such as chat-bot, and of aimodels commands that price one.
+	Provider string `yaml:"provider,omitempty"`
+	Model    string `yaml:"model,omitempty"`
+
+	Theme Theme `yaml:"theme,omitempty"`
+}
+
+// header starts every saved file.
+const header = "# aimodels settings, written by aimodels init. Flags and environment\n# variables such as CATWALK_URL take precedence over these values.\n"
+
+// DefaultPath returns the default settings file,
+// <user config dir>/aimodels/config.yaml.
+func DefaultPath() (string, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine config directory: %w", err)
+	}
+	return filepath.Join(config, "aimodels", "config.yaml"), nil
+}
+
+// Parse decodes and validates a settings file.
+func Parse(data []byte) (*Config, error) {
+	var c Config
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid settings: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Validate checks that the theme is known and that a model comes with
+// its provider.
+func (c *Config) Validate() error {
+	if c.Theme != "" && !c.Theme.Valid() {
+		return fmt.Errorf("unknown theme %q (use %s)", c.Theme, joinThemes())
+	}
+	if c.Model != "" && c.Provider == "" {
+		return errors.New("model is set without a provider")
+	}
+	return nil
+}
+
+// Valid reports whether t is one of [Themes].
+func (t Theme) Valid() bool {
+	for _, known := range Themes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+func joinThemes() string {
+	names := make([]string, len(Themes))
+	for i, t := range Themes {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Load reads and validates a settings file.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings: %w", err)
+	}
+	c, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Open loads the settings at path, or at [DefaultPath] if path is empty.
+// A missing default file is not an error: it returns empty settings, as
+// before aimodels init has run. The path "none" also means no settings.
+func Open(path string) (*Config, error) {
+	switch path {
+	case "none":
+		return &Config{}, nil
+	case "":
+		var err error
+		if path, err = DefaultPath(); err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return &Config{}, nil
+		}
+	}
+	return Load(path)
+}
+
+// Save writes the settings to path as YAML, creating its directory if
+// needed.
+func (c *Config) Save(path string) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode settings: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create settings directory: %w", err)
+	}
+	if err := os.WriteFile(path, append([]byte(header), data...), 0o600); err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	return nil
+}
+
+// ApplyCatalogURL sets CATWALK_URL to the catalog URL, unless it is
+// already set or there is none, so catwalk clients created afterwards use
+// it.
+func (c *Config) ApplyCatalogURL() {
+	if c.CatalogURL != "" && os.Getenv("CATWALK_URL") == "" {
+		_ = os.Setenv("CATWALK_URL", c.CatalogURL)
+	}
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aimodels", "config.yaml")
+	want := Config{CatalogURL: "http://catalog:8080", Provider: "openai", Model: "gpt-4o", Theme: ThemeLight}
+	if err := want.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "# aimodels settings") || !strings.Contains(string(data), "theme: light") {
+		t.Errorf("file = %s", data)
+	}
+	got, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != want {
+		t.Errorf("loaded %+v; want %+v", *got, want)
+	}
+
+	if c, err := Open("none"); err != nil || *c != (Config{}) {
+		t.Errorf("Open(none) = %+v, %v", c, err)
+	}
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing file that was asked for")
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, tt := range []struct {
+		data, err string
+	}{
+		{data: "provider: groq\nmodel: llama-3.3-70b\n"},
+		{data: "theme: solarized\n", err: `unknown theme "solarized" (use auto, dark, light, high-contrast, plain)`},
+		{data: "model: gpt-4o\n", err: "model is set without a provider"},
+		{data: "colour: red\n", err: "field colour not found"},
+	} {
+		_, err := Parse([]byte(tt.data))
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("Parse(%q) = %v", tt.data, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("Parse(%q) = %v; want %q", tt.data, err, tt.err)
+		}
+	}
+}
+
+func TestApplyCatalogURL(t *testing.T) {
+	t.Setenv("CATWALK_URL", "")
+	c := Config{CatalogURL: "http://mirror:8080"}
+	c.ApplyCatalogURL()
+	if got := os.Getenv("CATWALK_URL"); got != "http://mirror:8080" {
+		t.Errorf("CATWALK_URL = %q", got)
+	}
+
+	// The environment wins
+	t.Setenv("CATWALK_URL", "http://env:8080")
+	c.ApplyCatalogURL()
+	if got := os.Getenv("CATWALK_URL"); got != "http://env:8080" {
+		t.Errorf("CATWALK_URL = %q", got)
+	}
+}
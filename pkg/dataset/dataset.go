@@ -0,0 +1,231 @@
+// Package dataset builds fine-tuning datasets from chat transcripts: it
+// filters the logged replies, turns each into a training example, drops
+// duplicates, and writes the JSONL format a provider's tuning jobs accept.
+package dataset
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"charm.land/catwalk/pkg/tokenizer"
+	"charm.land/catwalk/pkg/transcript"
+)
+
+// Format is the JSONL layout of a fine-tuning dataset.
+type Format string
+
+// Supported formats.
+const (
+	// OpenAI is the chat format of OpenAI fine-tuning, with system prompts
+	// as messages.
+	OpenAI Format = "openai"
+	// Anthropic is the format of Claude fine-tuning on Amazon Bedrock, with
+	// the system prompt beside the messages.
+	Anthropic Format = "anthropic"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{OpenAI, Anthropic}
+
+// ParseFormat returns the format named s.
+func ParseFormat(s string) (Format, error) {
+	f := Format(strings.ToLower(s))
+	if !slices.Contains(Formats, f) {
+		return "", fmt.Errorf("unknown dataset format %q (use openai or anthropic)", s)
+	}
+	return f, nil
+}
+
+// Filter selects the transcript entries that become examples. Zero values
+// select everything.
+type Filter struct {
+	// Tags keeps entries with at least one of these tags.
+	Tags []string
+	// MinRating keeps entries rated at least this; unrated entries are
+	// dropped when it is set.
+	MinRating int
+	// MinTokens and MaxTokens bound an example's estimated tokens.
+	MinTokens int
+	MaxTokens int
+	// StripSystem leaves system prompts out of the examples.
+	StripSystem bool
+}
+
+// Example is one training conversation: user and assistant turns, starting
+// with the user and ending with the reply being trained on.
+type Example struct {
+	System   string
+	Messages []transcript.Message
+	Tokens   int
+}
+
+// Stats counts what happened to the entries a dataset was built from.
+type Stats struct {
+	Entries    int
+	Examples   int
+	Failed     int // requests that errored or have no reply
+	Filtered   int // left out by the tag, rating, or length filters
+	Malformed  int // not a user turn followed by a reply
+	Duplicates int // repeated, or contained in a longer conversation
+}
+
+// Build turns transcript entries into examples, in the order of the
+// entries. Identical examples are kept once, and an example whose turns all
+// open a longer example of the same conversation is dropped, since training
+// on the longer one covers it.
+func Build(entries []transcript.Entry, f Filter) ([]Example, Stats) {
+	stats := Stats{Entries: len(entries)}
+	var candidates []Example
+	for _, e := range entries {
+		if e.Error != "" || e.Response == nil || strings.TrimSpace(e.Response.Content) == "" {
+			stats.Failed++
+			continue
+		}
+		if !f.keeps(e) {
+			stats.Filtered++
+			continue
+		}
+		ex, ok := newExample(e, f.StripSystem)
+		if !ok {
+			stats.Malformed++
+			continue
+		}
+		if (f.MinTokens > 0 && ex.Tokens < f.MinTokens) || (f.MaxTokens > 0 && ex.Tokens > f.MaxTokens) {
+			stats.Filtered++
+			continue
+		}
+		candidates = append(candidates, ex)
+	}
+
+	// Every shorter conversation an example continues, by its key
+	prefixes := map[string]bool{}
+	for _, ex := range candidates {
+		for i := 1; i < len(ex.Messages)-1; i++ {
+			if ex.Messages[i].Role == "assistant" {
+				prefixes[key(ex.System, ex.Messages[:i+1])] = true
+			}
+		}
+	}
+	seen := map[string]bool{}
+	var examples []Example
+	for _, ex := range candidates {
+		k := key(ex.System, ex.Messages)
+		if seen[k] || prefixes[k] {
+			stats.Duplicates++
+			continue
+		}
+		seen[k] = true
+		examples = append(examples, ex)
+	}
+	stats.Examples = len(examples)
+	return examples, stats
+}
+
+// keeps reports whether the filter's tags and rating select e.
+func (f Filter) keeps(e transcript.Entry) bool {
+	if f.MinRating > 0 && e.Rating < f.MinRating {
+		return false
+	}
+	if len(f.Tags) == 0 {
+		return true
+	}
+	for _, tag := range e.Tags {
+		if slices.Contains(f.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// newExample turns an entry into an example, merging consecutive turns of
+// the same role, which providers reject. It reports false if the
+// conversation does not start with the user.
+func newExample(e transcript.Entry, stripSystem bool) (Example, bool) {
+	var ex Example
+	var system []string
+	for _, m := range append(slices.Clone(e.Request), *e.Response) {
+		content := strings.TrimSpace(m.Content)
+		switch {
+		case content == "":
+			continue
+		case m.Role == "system":
+			if !stripSystem {
+				system = append(system, content)
+			}
+			continue
+		case m.Role != "user" && m.Role != "assistant":
+			// Tool results and the like have no place in either format
+			continue
+		}
+		if n := len(ex.Messages); n > 0 && ex.Messages[n-1].Role == m.Role {
+			ex.Messages[n-1].Content += "\n\n" + content
+			continue
+		}
+		ex.Messages = append(ex.Messages, transcript.Message{Role: m.Role, Content: content})
+	}
+	if len(ex.Messages) < 2 || ex.Messages[0].Role != "user" || ex.Messages[len(ex.Messages)-1].Role != "assistant" {
+		return Example{}, false
+	}
+
+	ex.System = strings.Join(system, "\n\n")
+	if ex.System != "" {
+		ex.Tokens = tokenizer.CountMessage("system", ex.System)
+	}
+	for _, m := range ex.Messages {
+		ex.Tokens += tokenizer.CountMessage(m.Role, m.Content)
+	}
+	return ex, true
+}
+
+// key identifies a conversation for deduplication.
+func key(system string, messages []transcript.Message) string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, system)
+	for _, m := range messages {
+		_, _ = fmt.Fprintf(h, "\x00%s\x00%s", m.Role, m.Content)
+	}
+	return string(h.Sum(nil))
+}
+
+// Write writes examples to w as JSONL in format.
+func Write(w io.Writer, examples []Example, format Format) error {
+	enc := json.NewEncoder(w)
+	for _, ex := range examples {
+		var line any
+		switch format {
+		case OpenAI:
+			messages := ex.Messages
+			if ex.System != "" {
+				messages = append([]transcript.Message{{Role: "system", Content: ex.System}}, messages...)
+			}
+			line = struct {
+				Messages []transcript.Message `json:"messages"`
+			}{messages}
+		case Anthropic:
+			line = struct {
+				System   string               `json:"system,omitempty"`
+				Messages []transcript.Message `json:"messages"`
+			}{ex.System, ex.Messages}
+		default:
+			return fmt.Errorf("unknown dataset format %q", format)
+		}
+		if err := enc.Encode(line); err != nil {
+			return fmt.Errorf("failed to write dataset: %w", err)
+		}
+	}
+	return nil
+}
+
+// Tokens returns the estimated tokens of all the examples, which is what one
+// epoch of a tuning job trains on.
+func Tokens(examples []Example) int64 {
+	var total int64
+	for _, ex := range examples {
+		total += int64(ex.Tokens)
+	}
+	return total
+}
@@ -0,0 +1,59 @@
+package dataset
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"charm.land/catwalk/pkg/transcript"
+)
+
+func TestBuild(t *testing.T) {
+	msg := func(role, content string) transcript.Message { return transcript.Message{Role: role, Content: content} }
+	sys := msg("system", "Be brief.")
+	entries := []transcript.Entry{
+		// The first turn of a session is covered by the second
+		{Request: []transcript.Message{sys, msg("user", "Hi")}, Response: &transcript.Message{Role: "assistant", Content: "Hello"}, Tags: []string{"support"}, Rating: 5},
+		{Request: []transcript.Message{sys, msg("user", "Hi"), msg("assistant", "Hello"), msg("user", "Bye"), msg("user", "now")}, Response: &transcript.Message{Role: "assistant", Content: "Bye"}, Tags: []string{"support"}, Rating: 4},
+		{Request: []transcript.Message{sys, msg("user", "Hi")}, Response: &transcript.Message{Role: "assistant", Content: "Hey"}, Tags: []string{"support"}, Rating: 4},
+		{Request: []transcript.Message{sys, msg("user", "Hi")}, Response: &transcript.Message{Role: "assistant", Content: "Hey"}, Tags: []string{"support"}, Rating: 4},
+		{Request: []transcript.Message{msg("user", "Hi")}, Response: &transcript.Message{Role: "assistant", Content: "Meh"}, Tags: []string{"support"}, Rating: 2},
+		{Request: []transcript.Message{msg("user", "Hi")}, Response: &transcript.Message{Role: "assistant", Content: "Hi"}, Tags: []string{"sales"}, Rating: 5},
+		{Request: []transcript.Message{msg("user", "Hi")}, Error: "timeout"},
+		{Request: []transcript.Message{msg("assistant", "Welcome")}, Response: &transcript.Message{Role: "assistant", Content: "Hi"}, Tags: []string{"support"}, Rating: 5},
+	}
+
+	examples, stats := Build(entries, Filter{Tags: []string{"support"}, MinRating: 4})
+	want := Stats{Entries: 8, Examples: 2, Failed: 1, Filtered: 2, Malformed: 1, Duplicates: 2}
+	if stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+	if len(examples) != 2 || len(examples[0].Messages) != 4 || examples[0].Messages[2].Content != "Bye\n\nnow" {
+		t.Fatalf("examples = %+v", examples)
+	}
+	if examples[0].System != "Be brief." || examples[0].Tokens == 0 {
+		t.Errorf("example = %+v", examples[0])
+	}
+
+	var openai, anthropic bytes.Buffer
+	if err := Write(&openai, examples[1:], OpenAI); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(openai.String()); got != `{"messages":[{"role":"system","content":"Be brief."},{"role":"user","content":"Hi"},{"role":"assistant","content":"Hey"}]}` {
+		t.Errorf("openai = %s", got)
+	}
+	if err := Write(&anthropic, examples[1:], Anthropic); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(anthropic.String()); got != `{"system":"Be brief.","messages":[{"role":"user","content":"Hi"},{"role":"assistant","content":"Hey"}]}` {
+		t.Errorf("anthropic = %s", got)
+	}
+
+	stripped, _ := Build(entries[2:3], Filter{StripSystem: true, MaxTokens: 1000})
+	if len(stripped) != 1 || stripped[0].System != "" {
+		t.Errorf("stripped = %+v", stripped)
+	}
+	if long, stats := Build(entries[2:3], Filter{MinTokens: 1000}); len(long) != 0 || stats.Filtered != 1 {
+		t.Errorf("MinTokens kept %+v", long)
+	}
+}
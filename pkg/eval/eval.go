@@ -0,0 +1,261 @@
+// Package eval runs suites of golden prompts against models and checks the
+// replies with assertions: regular expressions, JSON content, or a grading
+// model acting as judge. Suites are YAML files:
+//
+//	name: support
+//	system: You are a support agent for Acme.
+//	max_tokens: 300
+//	cases:
+//	  - name: refund-policy
+//	    prompt: How many days do I have to return an item?
+//	    expect:
+//	      - regex: '\b30\b'
+//	      - judge: The reply is polite and mentions the receipt requirement.
+//	  - name: order-json
+//	    prompt: 'Reply with JSON only: {"order": <the order number in "Where is order 1234?">}'
+//	    expect:
+//	      - json: {order: 1234}
+package eval
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v2"
+)
+
+// Suite is a set of cases run against each model.
+type Suite struct {
+	Name string `yaml:"name"`
+
+	// System is the system prompt for every case unless the case sets one.
+	System string `yaml:"system"`
+
+	// MaxTokens limits each reply. Zero uses the model's default, and a
+	// limit above a model's output limit is lowered to it.
+	MaxTokens int `yaml:"max_tokens"`
+
+	Cases []Case `yaml:"cases"`
+}
+
+// Case is one prompt and the assertions its reply must pass.
+type Case struct {
+	Name   string      `yaml:"name"`
+	System string      `yaml:"system"`
+	Prompt string      `yaml:"prompt"`
+	Expect []Assertion `yaml:"expect"`
+}
+
+// Assertion is a check on a reply. Exactly one field is set.
+type Assertion struct {
+	// Regex must match the reply; NotRegex must not.
+	Regex    string `yaml:"regex"`
+	NotRegex string `yaml:"not_regex"`
+
+	// JSON requires the reply, optionally in a Markdown code fence, to be
+	// JSON containing this value. Objects match if they have the expected
+	// fields (extra fields are allowed); other values must be equal.
+	JSON any `yaml:"json"`
+
+	// Judge is a criterion the grading model checks the reply against.
+	Judge string `yaml:"judge"`
+
+	re *regexp.Regexp
+}
+
+// Kind returns the assertion type: regex, not_regex, json, or judge.
+func (a Assertion) Kind() string {
+	switch {
+	case a.Regex != "":
+		return "regex"
+	case a.NotRegex != "":
+		return "not_regex"
+	case a.JSON != nil:
+		return "json"
+	default:
+		return "judge"
+	}
+}
+
+// Load reads a suite from a YAML file.
+func Load(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suite: %w", err)
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Parse decodes and validates a suite. Unknown fields are errors, so typos
+// in assertion names don't silently pass.
+func Parse(data []byte) (*Suite, error) {
+	var s Suite
+	if err := yaml.UnmarshalStrict(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid suite: %w", err)
+	}
+	if len(s.Cases) == 0 {
+		return nil, errors.New("suite has no cases")
+	}
+
+	seen := map[string]bool{}
+	for i := range s.Cases {
+		c := &s.Cases[i]
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("case-%d", i+1)
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("duplicate case name %q", c.Name)
+		}
+		seen[c.Name] = true
+		if strings.TrimSpace(c.Prompt) == "" {
+			return nil, fmt.Errorf("case %s: prompt is empty", c.Name)
+		}
+		if len(c.Expect) == 0 {
+			return nil, fmt.Errorf("case %s: no assertions", c.Name)
+		}
+		for j := range c.Expect {
+			if err := c.Expect[j].compile(); err != nil {
+				return nil, fmt.Errorf("case %s, assertion %d: %w", c.Name, j+1, err)
+			}
+		}
+	}
+	return &s, nil
+}
+
+// NeedsJudge reports whether any case has a judge assertion.
+func (s *Suite) NeedsJudge() bool {
+	for _, c := range s.Cases {
+		for _, a := range c.Expect {
+			if a.Kind() == "judge" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *Assertion) compile() error {
+	set := 0
+	for _, ok := range []bool{a.Regex != "", a.NotRegex != "", a.JSON != nil, a.Judge != ""} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("set exactly one of regex, not_regex, json, or judge")
+	}
+
+	var err error
+	switch {
+	case a.Regex != "":
+		a.re, err = regexp.Compile(a.Regex)
+	case a.NotRegex != "":
+		a.re, err = regexp.Compile(a.NotRegex)
+	case a.JSON != nil:
+		a.JSON, err = normalize(a.JSON)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", a.Kind(), err)
+	}
+	return nil
+}
+
+// check runs a regex or JSON assertion against reply, returning why it
+// failed or "" if it passed.
+func (a Assertion) check(reply string) string {
+	switch a.Kind() {
+	case "regex":
+		if !a.re.MatchString(reply) {
+			return fmt.Sprintf("regex %q did not match", a.Regex)
+		}
+	case "not_regex":
+		if a.re.MatchString(reply) {
+			return fmt.Sprintf("not_regex %q matched", a.NotRegex)
+		}
+	case "json":
+		var got any
+		if err := json.Unmarshal([]byte(unfence(reply)), &got); err != nil {
+			return "reply is not valid JSON"
+		}
+		if !containsJSON(got, a.JSON) {
+			want, _ := json.Marshal(a.JSON)
+			return fmt.Sprintf("JSON does not contain %s", want)
+		}
+	}
+	return ""
+}
+
+// unfence returns the content of a Markdown code fence around s, or s.
+func unfence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	_, body, ok := strings.Cut(s, "\n")
+	if !ok {
+		return s
+	}
+	body, _, _ = strings.Cut(body, "```")
+	return strings.TrimSpace(body)
+}
+
+// normalize converts a YAML value to the types encoding/json decodes into,
+// so it can be compared with a decoded reply.
+func normalize(v any) (any, error) {
+	data, err := json.Marshal(stringKeys(v))
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return out, nil
+}
+
+// stringKeys converts the map[any]any values YAML decodes into maps with
+// string keys.
+func stringKeys(v any) any {
+	switch v := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = stringKeys(val)
+		}
+		return m
+	case []any:
+		for i := range v {
+			v[i] = stringKeys(v[i])
+		}
+	}
+	return v
+}
+
+// containsJSON reports whether got contains want: objects recursively by
+// field, everything else by equality.
+func containsJSON(got, want any) bool {
+	wantObj, ok := want.(map[string]any)
+	if !ok {
+		return reflect.DeepEqual(got, want)
+	}
+	gotObj, ok := got.(map[string]any)
+	if !ok {
+		return false
+	}
+	for k, w := range wantObj {
+		g, ok := gotObj[k]
+		if !ok || !containsJSON(g, w) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"github.com/sashabaranov/go-openai"
+)
+
+const suiteYAML = `name: test
+system: Be brief.
+cases:
+  - name: capital
+    prompt: What is the capital of France?
+    expect:
+      - regex: (?i)paris
+      - not_regex: (?i)london
+  - name: order
+    prompt: Reply with JSON for order 1234.
+    expect:
+      - json: {order: 1234, items: [a]}
+  - name: tone
+    prompt: Say goodbye.
+    expect:
+      - judge: The reply is polite.
+      - judge: The reply is in French.
+`
+
+// newTarget returns a target whose replies are canned per prompt. As judge,
+// it passes every criterion except the French one.
+func newTarget(t *testing.T) Target {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		prompt := req.Messages[len(req.Messages)-1].Content
+
+		var reply string
+		switch {
+		case strings.Contains(prompt, "in French"):
+			reply = "FAIL\nThe reply is in English."
+		case strings.Contains(prompt, "Criterion:"):
+			reply = "PASS\nIt is."
+		case strings.Contains(prompt, "capital"):
+			reply = "Paris."
+		case strings.Contains(prompt, "JSON"):
+			reply = "```json\n{\"order\": 1234, \"items\": [\"a\"], \"status\": \"shipped\"}\n```"
+		default:
+			reply = "Goodbye, and thank you!"
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", reply)
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":100,\"completion_tokens\":10}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(srv.Close)
+
+	provider := catwalk.Provider{ID: "test", APIEndpoint: srv.URL}
+	model := catwalk.Model{ID: "m", CostPer1MIn: 1, CostPer1MOut: 2}
+	return Target{Client: chat.NewClient(provider, "key", nil), Provider: provider, Model: model}
+}
+
+func TestRun(t *testing.T) {
+	suite, err := Parse([]byte(suiteYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !suite.NeedsJudge() {
+		t.Error("expected the suite to need a judge")
+	}
+
+	target := newTarget(t)
+	results := suite.Run(context.Background(), target, Options{Judge: &target, Parallel: 2})
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	for _, r := range results[:2] {
+		if !r.Passed {
+			t.Errorf("%s failed: %v %s", r.Case, r.Failures, r.Error)
+		}
+	}
+	tone := results[2]
+	if tone.Passed || len(tone.Failures) != 1 || !strings.Contains(tone.Failures[0], "in English") {
+		t.Errorf("tone: want one judge failure, got %v", tone.Failures)
+	}
+	if tone.JudgeCost == 0 {
+		t.Error("judge cost not recorded")
+	}
+
+	sum := Summarize(results)
+	if sum.Passed != 2 || sum.Cases != 3 || sum.Errors != 0 {
+		t.Errorf("summary = %+v", sum)
+	}
+	if want := 3 * (100*1.0 + 10*2.0) / 1_000_000; sum.Cost != want {
+		t.Errorf("cost = %g, want %g", sum.Cost, want)
+	}
+}
+
+func TestResume(t *testing.T) {
+	suite, err := Parse([]byte(suiteYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := newTarget(t)
+
+	// An interrupted run left the capital case done, the order case
+	// failed, and a line cut short
+	var file bytes.Buffer
+	enc := json.NewEncoder(&file)
+	for _, r := range []Result{
+		{Case: "capital", Reply: "Paris.", Passed: true, Latency: 1500 * time.Millisecond, Cost: 1},
+		{Case: "order", Error: "context canceled"},
+	} {
+		if err := enc.Encode(NewRecord(target, r)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file.WriteString(`{"provider": "test", "model": "m", "case": "tone", "pas`)
+	records, err := ReadRecords(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := Completed(records, target)
+	if len(records) != 2 || len(done) != 1 || done["capital"].Latency != 1500*time.Millisecond {
+		t.Fatalf("records = %+v, done = %+v", records, done)
+	}
+
+	var reported []string
+	results := suite.Run(context.Background(), target, Options{
+		Judge:    &target,
+		Parallel: 2,
+		Done:     done,
+		OnResult: func(r Result) { reported = append(reported, r.Case) },
+	})
+	sort.Strings(reported)
+	if strings.Join(reported, ",") != "order,tone" {
+		t.Errorf("reported %v, want order and tone", reported)
+	}
+	if results[0].Cost != 1 || !results[1].Passed {
+		t.Errorf("results = %+v", results)
+	}
+
+	if _, err := ReadRecords(strings.NewReader("{}\nnot json\n{}\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("ReadRecords error = %v", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"no cases":        "name: x\n",
+		"unknown field":   "cases: [{prompt: hi, expect: [{regexp: a}]}]\n",
+		"two kinds":       "cases: [{prompt: hi, expect: [{regex: a, judge: b}]}]\n",
+		"bad regex":       "cases: [{prompt: hi, expect: [{regex: '('}]}]\n",
+		"no assertions":   "cases: [{prompt: hi}]\n",
+		"duplicate names": "cases: [{name: a, prompt: hi, expect: [{regex: a}]}, {name: a, prompt: hi, expect: [{regex: a}]}]\n",
+	}
+	for name, data := range tests {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package eval
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// Record is one case's result on one model, as a line of a JSONL results
+// file. Writing records from Options.OnResult keeps every finished case
+// when a run is interrupted, and [Completed] reads them back so the run can
+// be resumed.
+type Record struct {
+	Provider     string   `json:"provider"`
+	Model        string   `json:"model"`
+	Case         string   `json:"case"`
+	Passed       bool     `json:"passed"`
+	Failures     []string `json:"failures,omitempty"`
+	Error        string   `json:"error,omitempty"`
+	Reply        string   `json:"reply"`
+	LatencyMS    float64  `json:"latency_ms"`
+	Batched      bool     `json:"batched,omitempty"`
+	InputTokens  int      `json:"input_tokens"`
+	OutputTokens int      `json:"output_tokens"`
+	Cost         float64  `json:"cost"`
+	JudgeCost    float64  `json:"judge_cost,omitempty"`
+}
+
+// NewRecord returns the record of a result on target.
+func NewRecord(target Target, r Result) Record {
+	return Record{
+		Provider:     string(target.Provider.ID),
+		Model:        target.Model.ID,
+		Case:         r.Case,
+		Passed:       r.Passed,
+		Failures:     r.Failures,
+		Error:        r.Error,
+		Reply:        r.Reply,
+		LatencyMS:    float64(r.Latency) / float64(time.Millisecond),
+		Batched:      r.Batched,
+		InputTokens:  r.InputTokens,
+		OutputTokens: r.OutputTokens,
+		Cost:         r.Cost,
+		JudgeCost:    r.JudgeCost,
+	}
+}
+
+// Result returns the result the record was made from.
+func (rec Record) Result() Result {
+	return Result{
+		Case:         rec.Case,
+		Reply:        rec.Reply,
+		Passed:       rec.Passed,
+		Failures:     rec.Failures,
+		Error:        rec.Error,
+		Latency:      time.Duration(rec.LatencyMS * float64(time.Millisecond)),
+		Batched:      rec.Batched,
+		InputTokens:  rec.InputTokens,
+		OutputTokens: rec.OutputTokens,
+		Cost:         rec.Cost,
+		JudgeCost:    rec.JudgeCost,
+	}
+}
+
+// ReadRecords reads a JSONL results file. A last line cut short, as by an
+// interrupted write, is ignored.
+func ReadRecords(r io.Reader) ([]Record, error) {
+	var records []Record
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read results: %w", err)
+		}
+		last := err != nil
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var rec Record
+			if jsonErr := json.Unmarshal(line, &rec); jsonErr != nil {
+				if last {
+					break
+				}
+				return nil, fmt.Errorf("results line %d: %w", n, jsonErr)
+			}
+			records = append(records, rec)
+		}
+		if last {
+			break
+		}
+	}
+	return records, nil
+}
+
+// Completed returns the results in records of the cases that ran on target
+// without a request error, by case name, for Options.Done. Cases whose
+// request failed are left out, so they are run again.
+func Completed(records []Record, target Target) map[string]Result {
+	done := map[string]Result{}
+	for _, rec := range records {
+		if catwalk.InferenceProvider(rec.Provider) == target.Provider.ID && rec.Model == target.Model.ID && rec.Error == "" {
+			done[rec.Case] = rec.Result()
+		}
+	}
+	return done
+}
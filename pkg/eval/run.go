@@ -0,0 +1,321 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"charm.land/catwalk/pkg/batch"
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Target is a model to send prompts to.
+type Target struct {
+	Client   *openai.Client
+	Provider catwalk.Provider
+	Model    catwalk.Model
+
+	// Batch, if set, sends the suite's prompts as one discounted batch
+	// instead of one request per case. Judge calls are still made one by
+	// one.
+	Batch *batch.Client
+
+	// Middleware wraps each request made to the model, the judge's
+	// included, such as a [chat.Scheduler]'s pacing.
+	Middleware []chat.Middleware
+}
+
+// Result is the outcome of one case on one model.
+type Result struct {
+	Case   string
+	Reply  string
+	Passed bool
+
+	// Failures explain the failed assertions. Error is set instead when the
+	// request itself failed.
+	Failures []string
+	Error    string
+
+	// Latency is not measured for batched cases, whose replies all arrive
+	// together.
+	Latency      time.Duration
+	Batched      bool
+	InputTokens  int
+	OutputTokens int
+
+	// Cost is the model's cost for the case; JudgeCost the grading model's.
+	Cost      float64
+	JudgeCost float64
+}
+
+// judgePrompt asks the grading model for a verdict on one criterion.
+const judgePrompt = `You are grading a reply from an AI model against one criterion.
+
+Criterion: %s
+
+Prompt given to the model:
+<prompt>
+%s
+</prompt>
+
+Reply to grade:
+<reply>
+%s
+</reply>
+
+Answer PASS if the reply meets the criterion and FAIL otherwise, on the first
+line, followed by one sentence explaining why.`
+
+// Options control how a suite is run.
+type Options struct {
+	// Judge grades judge assertions. It may be nil if the suite has none.
+	Judge *Target
+
+	// Parallel is the number of cases run at once (default 1).
+	Parallel int
+
+	// Timeout limits each case, including its judge calls. Zero means no
+	// limit.
+	Timeout time.Duration
+
+	// Done holds the results of cases already run, by case name, such as
+	// those read back with [Completed] after an interruption. These cases
+	// are not run again; their results are returned as they are.
+	Done map[string]Result
+
+	// OnResult, if set, is called with each case's result as soon as it is
+	// complete, one call at a time. Cases in Done are not reported.
+	OnResult func(Result)
+}
+
+// Run runs every case of the suite on target and returns the results in
+// case order.
+func (s *Suite) Run(ctx context.Context, target Target, opts Options) []Result {
+	results := make([]Result, len(s.Cases))
+	var pending []int
+	for i, c := range s.Cases {
+		if r, ok := opts.Done[c.Name]; ok {
+			results[i] = r
+		} else {
+			pending = append(pending, i)
+		}
+	}
+
+	var replies []Result
+	if target.Batch != nil && len(pending) > 0 {
+		replies = s.runBatch(ctx, target, pending)
+	}
+
+	var mu sync.Mutex
+	sem := make(chan struct{}, max(1, opts.Parallel))
+	var wg sync.WaitGroup
+	for j, i := range pending {
+		c := s.Cases[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			ctx := ctx
+			if opts.Timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+				defer cancel()
+			}
+			if replies != nil {
+				results[i] = s.check(ctx, c, replies[j], opts.Judge)
+			} else {
+				results[i] = s.runCase(ctx, c, target, opts.Judge)
+			}
+			if opts.OnResult != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				opts.OnResult(results[i])
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func (s *Suite) runCase(ctx context.Context, c Case, target Target, judge *Target) Result {
+	r := Result{Case: c.Name}
+
+	session := chat.New(target.Client, target.Provider, target.Model)
+	session.SetConfig(chat.Config{MaxTokens: s.replyTokens(target.Model), Middleware: target.Middleware})
+	if system := cmpOr(c.System, s.System); system != "" {
+		session.SetSystem(system)
+	}
+
+	start := time.Now()
+	resp, err := session.Send(ctx, c.Prompt)
+	r.Latency = time.Since(start)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	r.Reply = resp.Content
+	r.InputTokens, r.OutputTokens, r.Cost = resp.InputTokens, resp.OutputTokens, resp.Cost
+	return s.check(ctx, c, r, judge)
+}
+
+// replyTokens returns the suite's reply limit for m, lowered to m's output
+// limit so one suite can run against models with smaller limits.
+func (s *Suite) replyTokens(m catwalk.Model) int {
+	return int(m.ClampMaxTokens(int64(s.MaxTokens)))
+}
+
+// runBatch sends the prompts of the cases at indexes to target as one batch
+// and returns the replies, unchecked, in the same order. If the batch fails,
+// every case fails with its error.
+func (s *Suite) runBatch(ctx context.Context, target Target, indexes []int) []Result {
+	reqs := make([]openai.ChatCompletionRequest, len(indexes))
+	for j, i := range indexes {
+		c := s.Cases[i]
+		req := openai.ChatCompletionRequest{Model: target.Model.ID, MaxTokens: s.replyTokens(target.Model)}
+		if req.MaxTokens == 0 {
+			req.MaxTokens = int(target.Model.DefaultMaxTokens)
+		}
+		if system := cmpOr(c.System, s.System); system != "" {
+			req.Messages = append(req.Messages, openai.ChatCompletionMessage{Role: chat.RoleSystem, Content: system})
+		}
+		req.Messages = append(req.Messages, openai.ChatCompletionMessage{Role: chat.RoleUser, Content: c.Prompt})
+		reqs[j] = req
+	}
+
+	replies, err := target.Batch.Run(ctx, reqs)
+	results := make([]Result, len(indexes))
+	for j, i := range indexes {
+		r := Result{Case: s.Cases[i].Name, Batched: true}
+		switch {
+		case err != nil:
+			r.Error = err.Error()
+		case replies[j].Error != "":
+			r.Error = replies[j].Error
+		default:
+			r.Reply = replies[j].Content
+			r.InputTokens, r.OutputTokens, r.Cost = replies[j].InputTokens, replies[j].OutputTokens, replies[j].Cost
+		}
+		results[j] = r
+	}
+	return results
+}
+
+// check runs the case's assertions on a reply, unless the request failed.
+func (s *Suite) check(ctx context.Context, c Case, r Result, judge *Target) Result {
+	if r.Error != "" {
+		return r
+	}
+	for _, a := range c.Expect {
+		if a.Kind() != "judge" {
+			if failure := a.check(r.Reply); failure != "" {
+				r.Failures = append(r.Failures, failure)
+			}
+			continue
+		}
+		if judge == nil {
+			r.Failures = append(r.Failures, "judge assertion but no judge model")
+			continue
+		}
+		verdict, cost, err := grade(ctx, *judge, c.Prompt, r.Reply, a.Judge)
+		r.JudgeCost += cost
+		switch {
+		case err != nil:
+			r.Failures = append(r.Failures, "judge failed: "+err.Error())
+		case verdict != "":
+			r.Failures = append(r.Failures, "judge: "+verdict)
+		}
+	}
+	r.Passed = len(r.Failures) == 0
+	return r
+}
+
+// grade asks the judge whether reply meets criterion, returning its
+// explanation if it does not.
+func grade(ctx context.Context, judge Target, prompt, reply, criterion string) (string, float64, error) {
+	session := chat.New(judge.Client, judge.Provider, judge.Model)
+	session.SetConfig(chat.Config{Middleware: judge.Middleware})
+	resp, err := session.Send(ctx, fmt.Sprintf(judgePrompt, criterion, prompt, reply))
+	if err != nil {
+		return "", 0, err //nolint:wrapcheck
+	}
+
+	verdict, reason, _ := strings.Cut(strings.TrimSpace(resp.Content), "\n")
+	verdict = strings.ToUpper(strings.Trim(verdict, " *.:"))
+	switch {
+	case strings.HasPrefix(verdict, "PASS"):
+		return "", resp.Cost, nil
+	case strings.HasPrefix(verdict, "FAIL"):
+		if reason = strings.TrimSpace(reason); reason == "" {
+			reason = "criterion not met"
+		}
+		return fmt.Sprintf("%s (%s)", reason, criterion), resp.Cost, nil
+	default:
+		if len(verdict) > 40 {
+			verdict = verdict[:40] + "..."
+		}
+		return "", resp.Cost, fmt.Errorf("unexpected verdict %q", verdict)
+	}
+}
+
+func cmpOr(a, b string) string {
+	if a != "" {
+		return a
+	}
+	return b
+}
+
+// Summary aggregates the results of a suite on one model.
+type Summary struct {
+	Cases  int
+	Passed int
+	Errors int
+
+	Cost      float64
+	JudgeCost float64
+
+	MeanLatency time.Duration
+	P95Latency  time.Duration
+}
+
+// PassRate returns the fraction of cases that passed.
+func (s Summary) PassRate() float64 {
+	if s.Cases == 0 {
+		return 0
+	}
+	return float64(s.Passed) / float64(s.Cases)
+}
+
+// Summarize aggregates results. Latency covers the cases whose request
+// succeeded and was not batched.
+func Summarize(results []Result) Summary {
+	s := Summary{Cases: len(results)}
+	var latencies []time.Duration
+	var total time.Duration
+	for _, r := range results {
+		if r.Passed {
+			s.Passed++
+		}
+		switch {
+		case r.Error != "":
+			s.Errors++
+		case r.Batched:
+		default:
+			latencies = append(latencies, r.Latency)
+			total += r.Latency
+		}
+		s.Cost += r.Cost
+		s.JudgeCost += r.JudgeCost
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		s.MeanLatency = total / time.Duration(len(latencies))
+		s.P95Latency = latencies[(len(latencies)*95+99)/100-1]
+	}
+	return s
+}
@@ -0,0 +1,174 @@
+// Package extract pulls structured data out of a document with a chat model.
+// The model is asked for JSON matching a [Schema], its reply is checked
+// against the schema, and what is wrong with an invalid reply is sent back
+// for another attempt, until the reply is valid or the retries run out.
+//
+// Where the provider supports it, the schema is also sent as the request's
+// response format, so the model is constrained to it; [Supports] reports
+// which models are known to honor that.
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/registry"
+	"github.com/sashabaranov/go-openai"
+)
+
+// DefaultRetries is how many times an invalid reply is sent back when
+// Options.Retries is zero.
+const DefaultRetries = 2
+
+// Target is the model to extract with.
+type Target struct {
+	Client   *openai.Client
+	Provider catwalk.Provider
+	Model    catwalk.Model
+}
+
+// Options control an extraction.
+type Options struct {
+	// Instructions are added to the prompt, to say what to extract when
+	// the schema alone does not.
+	Instructions string
+
+	// Retries is how many times an invalid reply is sent back with its
+	// problems. Zero means DefaultRetries; a negative value, none.
+	Retries int
+
+	// PromptOnly puts the schema in the prompt only, for providers that
+	// reject a JSON schema response format.
+	PromptOnly bool
+
+	// MaxTokens limits each reply. Zero uses the model's default.
+	MaxTokens int
+
+	// Overrides, if set, holds probe results: a model that cmd/probe found
+	// rejecting a JSON schema response format fails before any request is
+	// sent, with a *catwalk.UnsupportedFeatureError suggesting others.
+	Overrides *registry.Overrides
+}
+
+// Result is the outcome of an extraction.
+type Result struct {
+	// Data is the last reply, with any Markdown code fence removed.
+	Data string
+	// Problems lists what is wrong with Data; it is empty if Data matches
+	// the schema.
+	Problems []string
+	Attempts int
+
+	InputTokens  int
+	OutputTokens int
+	Cost         float64
+}
+
+// Valid reports whether the result matches the schema.
+func (r Result) Valid() bool { return r.Attempts > 0 && len(r.Problems) == 0 }
+
+// ErrInvalid is returned, wrapped, when no attempt produced a reply that
+// matches the schema.
+var ErrInvalid = errors.New("reply does not match the schema")
+
+// systemPrompt asks for JSON only.
+const systemPrompt = `You extract structured data from documents. Reply with a single JSON value
+that matches this JSON Schema, and nothing else: no prose and no code fences.
+Use null, or leave out optional properties, for information the document does
+not contain; do not invent it.
+
+Schema:
+%s`
+
+// retryPrompt sends an invalid reply's problems back to the model.
+const retryPrompt = `Your reply does not match the schema:
+- %s
+
+Reply again with corrected JSON only.`
+
+// Extract extracts data matching schema from document. If every attempt
+// fails validation, the last reply is returned with its problems and an
+// error wrapping [ErrInvalid]; other errors are the request's.
+func Extract(ctx context.Context, t Target, schema *Schema, document string, opts Options) (Result, error) {
+	retries := opts.Retries
+	if retries == 0 {
+		retries = DefaultRetries
+	}
+
+	session := chat.New(t.Client, t.Provider, t.Model)
+	session.SetSystem(fmt.Sprintf(systemPrompt, schema.JSON()))
+	config := chat.Config{MaxTokens: opts.MaxTokens, Middleware: []chat.Middleware{chat.Preflight(opts.Overrides.Probed)}}
+	if !opts.PromptOnly {
+		config.Prepare = func(req *openai.ChatCompletionRequest) {
+			req.ResponseFormat = &openai.ChatCompletionResponseFormat{
+				Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+				// Not strict, since strict mode rejects schemas with
+				// optional properties; replies are validated instead
+				JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+					Name:   schema.Name(),
+					Schema: schema.JSON(),
+				},
+			}
+		}
+	}
+	session.SetConfig(config)
+
+	var r Result
+	message := document
+	if opts.Instructions != "" {
+		message = opts.Instructions + "\n\n" + document
+	}
+	for {
+		resp, err := session.Send(ctx, message)
+		if resp != nil {
+			r.InputTokens += resp.InputTokens
+			r.OutputTokens += resp.OutputTokens
+			r.Cost += resp.Cost
+		}
+		if err != nil {
+			return r, err //nolint:wrapcheck
+		}
+		r.Attempts++
+		r.Data = unfence(resp.Content)
+		r.Problems = schema.Validate([]byte(r.Data))
+		if len(r.Problems) == 0 {
+			return r, nil
+		}
+		if r.Attempts > retries {
+			return r, fmt.Errorf("%w: %s", ErrInvalid, r.Problems[0])
+		}
+		message = fmt.Sprintf(retryPrompt, strings.Join(r.Problems, "\n- "))
+	}
+}
+
+// unfence returns the content of a Markdown code fence around s, or s, since
+// models without a response format often fence their JSON anyway.
+func unfence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	_, body, ok := strings.Cut(s, "\n")
+	if !ok {
+		return s
+	}
+	body, _, _ = strings.Cut(body, "```")
+	return strings.TrimSpace(body)
+}
+
+// Supports reports whether a model is known to honor a JSON schema response
+// format: cmd/probe found it working, as recorded in overrides, or it is
+// served by OpenAI or Azure, whose chat APIs take one for every current
+// model. Other models may support it too; probe them to find out.
+func Supports(overrides *registry.Overrides, p catwalk.Provider, m catwalk.Model) bool {
+	if overrides != nil {
+		if mo, ok := overrides.Providers[p.ID].Models[m.ID]; ok && mo.Probe != nil && mo.Probe.JSONSchema != nil {
+			return *mo.Probe.JSONSchema
+		}
+	}
+	return p.Type == catwalk.TypeOpenAI || p.Type == catwalk.TypeAzure
+}
@@ -0,0 +1,142 @@
+package extract
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"github.com/sashabaranov/go-openai"
+)
+
+const invoiceSchema = `{
+	"title": "Invoice",
+	"type": "object",
+	"properties": {
+		"number": {"type": "string", "pattern": "^INV-[0-9]+$"},
+		"currency": {"enum": ["USD", "EUR"]},
+		"total": {"type": "number", "minimum": 0},
+		"items": {
+			"type": "array",
+			"minItems": 1,
+			"items": {
+				"type": "object",
+				"properties": {"name": {"type": "string"}, "qty": {"type": "integer"}},
+				"required": ["name", "qty"],
+				"additionalProperties": false
+			}
+		},
+		"note": {"anyOf": [{"type": "string", "maxLength": 10}, {"type": "null"}]}
+	},
+	"required": ["number", "total", "items"]
+}`
+
+func TestValidate(t *testing.T) {
+	s, err := ParseSchema([]byte(invoiceSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name() != "Invoice" {
+		t.Errorf("name = %q", s.Name())
+	}
+
+	for _, tt := range []struct {
+		doc  string
+		want []string
+	}{
+		{`{"number": "INV-1", "total": 12.5, "items": [{"name": "pen", "qty": 2}], "note": null}`, nil},
+		{`{"number": "INV-1", "total": 3, "items": [{"name": "pen", "qty": 2.0}]}`, nil},
+		{`{"number": "1", "total": -1, "currency": "GBP", "items": []}`, []string{
+			`$.currency: "GBP" is not one of the allowed values`,
+			`$.items: want at least 1 items, got 0`,
+			`$.number: "1" does not match ^INV-[0-9]+$`,
+			`$.total: -1 is below the minimum 0`,
+		}},
+		{`{"number": "INV-1", "items": [{"name": "pen", "qty": 1.5, "sku": "x"}], "note": "far too long"}`, []string{
+			`$: missing required property "total"`,
+			`$.items[0].qty: want integer, got number`,
+			`$.items[0]: unexpected property "sku"`,
+			`$.note: matches none of anyOf`,
+		}},
+		{`[1, 2]`, []string{`$: want object, got array`}},
+		{`{"number": "INV-1"} trailing`, []string{`not JSON: more than one value`}},
+	} {
+		if got := s.Validate([]byte(tt.doc)); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("Validate(%s) =\n%s\nwant\n%s", tt.doc, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+
+	for _, bad := range []string{`{"type": "text"}`, `{"pattern": "("}`, `{"properties": {"a": {"type": 1}}}`, `[`} {
+		if _, err := ParseSchema([]byte(bad)); err == nil {
+			t.Errorf("ParseSchema(%s) succeeded", bad)
+		}
+	}
+}
+
+// newTarget returns a target whose server streams the replies in turn,
+// recording each request.
+func newTarget(t *testing.T, replies []string, requests *[]openai.ChatCompletionRequest) Target {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		reply := replies[len(*requests)]
+		*requests = append(*requests, req)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", reply)
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":1000,\"completion_tokens\":100}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(srv.Close)
+	provider := catwalk.Provider{ID: "test", Type: catwalk.TypeOpenAI, APIEndpoint: srv.URL}
+	model := catwalk.Model{ID: "m", CostPer1MIn: 1, CostPer1MOut: 2}
+	return Target{Client: chat.NewClient(provider, "key", nil), Provider: provider, Model: model}
+}
+
+func TestExtract(t *testing.T) {
+	s, err := ParseSchema([]byte(invoiceSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := `{"number": "INV-7", "total": 5, "items": [{"name": "pen", "qty": 1}]}`
+
+	var requests []openai.ChatCompletionRequest
+	target := newTarget(t, []string{`{"number": "7", "total": 5, "items": []}`, "```json\n" + valid + "\n```"}, &requests)
+	r, err := Extract(context.Background(), target, s, "Invoice INV-7: one pen, $5.", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Valid() || r.Data != valid || r.Attempts != 2 {
+		t.Errorf("result = %+v", r)
+	}
+	if want := 2 * (1000*1.0 + 100*2.0) / 1_000_000; r.InputTokens != 2000 || math.Abs(r.Cost-want) > 1e-12 {
+		t.Errorf("usage = %d tokens, $%g; want $%g", r.InputTokens, r.Cost, want)
+	}
+	if f := requests[0].ResponseFormat; f == nil || f.JSONSchema == nil || f.JSONSchema.Name != "Invoice" {
+		t.Errorf("response format = %+v", f)
+	}
+	retry := requests[1].Messages[len(requests[1].Messages)-1].Content
+	if !strings.Contains(retry, "$.items: want at least 1 items") {
+		t.Errorf("retry prompt = %q", retry)
+	}
+
+	// Without retries, an invalid reply fails
+	requests = nil
+	target = newTarget(t, []string{`{}`}, &requests)
+	r, err = Extract(context.Background(), target, s, "nothing", Options{Retries: -1, PromptOnly: true})
+	if !errors.Is(err, ErrInvalid) || r.Valid() || len(r.Problems) != 3 {
+		t.Errorf("result = %+v, %v", r, err)
+	}
+	if requests[0].ResponseFormat != nil {
+		t.Error("PromptOnly sent a response format")
+	}
+}
@@ -0,0 +1,338 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a JSON Schema. The whole schema is sent to the model, but only
+// the keywords extraction schemas commonly use are checked by
+// [Schema.Validate]: type, enum, const, properties, required,
+// additionalProperties, items, minItems, maxItems, minLength, maxLength,
+// pattern, minimum, maximum, and anyOf.
+type Schema struct {
+	raw  json.RawMessage
+	root *node
+}
+
+// node is one (sub)schema.
+type node struct {
+	Title                string           `json:"title"`
+	Type                 typeList         `json:"type"`
+	Enum                 []any            `json:"enum"`
+	Const                *json.RawMessage `json:"const"`
+	Properties           map[string]*node `json:"properties"`
+	Required             []string         `json:"required"`
+	AdditionalProperties json.RawMessage  `json:"additionalProperties"`
+	Items                *node            `json:"items"`
+	MinItems             *int             `json:"minItems"`
+	MaxItems             *int             `json:"maxItems"`
+	MinLength            *int             `json:"minLength"`
+	MaxLength            *int             `json:"maxLength"`
+	Pattern              string           `json:"pattern"`
+	Minimum              *float64         `json:"minimum"`
+	Maximum              *float64         `json:"maximum"`
+	AnyOf                []*node          `json:"anyOf"`
+
+	pattern    *regexp.Regexp
+	additional *node // additionalProperties as a schema
+	closed     bool  // additionalProperties: false
+}
+
+// typeList is a schema's type, which may be one type name or several.
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = typeList{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("type must be a string or a list of strings")
+	}
+	*t = many
+	return nil
+}
+
+// types lists the type names Validate knows.
+var types = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+
+// LoadSchema reads a JSON Schema file.
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	s, err := ParseSchema(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// ParseSchema parses a JSON Schema.
+func ParseSchema(data []byte) (*Schema, error) {
+	var root node
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := root.compile("$"); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &Schema{raw: compact.Bytes(), root: &root}, nil
+}
+
+// compile checks n and its subschemas and prepares them for validation.
+func (n *node) compile(path string) error {
+	for _, t := range n.Type {
+		if !slices.Contains(types, t) {
+			return fmt.Errorf("%s: unknown type %q", path, t)
+		}
+	}
+	if n.Pattern != "" {
+		re, err := regexp.Compile(n.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: bad pattern: %w", path, err)
+		}
+		n.pattern = re
+	}
+	switch a := bytes.TrimSpace(n.AdditionalProperties); {
+	case len(a) == 0, string(a) == "true":
+	case string(a) == "false":
+		n.closed = true
+	default:
+		n.additional = new(node)
+		if err := json.Unmarshal(a, n.additional); err != nil {
+			return fmt.Errorf("%s: additionalProperties: %w", path, err)
+		}
+		if err := n.additional.compile(path + ".*"); err != nil {
+			return err
+		}
+	}
+	for name, p := range n.Properties {
+		if p == nil {
+			return fmt.Errorf("%s.%s: empty schema", path, name)
+		}
+		if err := p.compile(path + "." + name); err != nil {
+			return err
+		}
+	}
+	if n.Items != nil {
+		if err := n.Items.compile(path + "[]"); err != nil {
+			return err
+		}
+	}
+	for i, alt := range n.AnyOf {
+		if alt == nil {
+			return fmt.Errorf("%s: anyOf %d is empty", path, i+1)
+		}
+		if err := alt.compile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JSON returns the schema as given, compacted.
+func (s *Schema) JSON() json.RawMessage { return s.raw }
+
+// Name returns a name for the schema for the response format: its title,
+// reduced to the characters providers accept, or "extraction".
+func (s *Schema) Name() string {
+	name := strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		if r == ' ' {
+			return '_'
+		}
+		return -1
+	}, s.root.Title)
+	if name == "" {
+		return "extraction"
+	}
+	return name[:min(len(name), 64)]
+}
+
+// Validate checks a JSON document against the schema and returns what is
+// wrong with it, one problem per string with the path to the value, such as
+// "$.items[2].price: want number, got string". It returns nil if the
+// document is valid.
+func (s *Schema) Validate(data []byte) []string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return []string{"not JSON: " + err.Error()}
+	}
+	if dec.More() {
+		return []string{"not JSON: more than one value"}
+	}
+	var errs []string
+	s.root.validate("$", v, &errs)
+	return errs
+}
+
+func (n *node) validate(path string, v any, errs *[]string) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if len(n.AnyOf) > 0 {
+		matched := false
+		for _, alt := range n.AnyOf {
+			var altErrs []string
+			alt.validate(path, v, &altErrs)
+			if len(altErrs) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("matches none of anyOf")
+		}
+	}
+
+	got := typeOf(v)
+	if len(n.Type) > 0 && !slices.Contains(n.Type, got) && (got != "integer" || !slices.Contains(n.Type, "number")) {
+		fail("want %s, got %s", strings.Join(n.Type, " or "), got)
+		return
+	}
+	if n.Enum != nil && !slices.ContainsFunc(n.Enum, func(e any) bool { return equal(e, v) }) {
+		fail("%s is not one of the allowed values", short(v))
+	}
+	if n.Const != nil {
+		var c any
+		if json.Unmarshal(*n.Const, &c) == nil && !equal(c, v) {
+			fail("must be %s", string(*n.Const))
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range n.Required {
+			if _, ok := v[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			switch p := n.Properties[name]; {
+			case p != nil:
+				p.validate(path+"."+name, v[name], errs)
+			case n.closed:
+				fail("unexpected property %q", name)
+			case n.additional != nil:
+				n.additional.validate(path+"."+name, v[name], errs)
+			}
+		}
+	case []any:
+		if n.MinItems != nil && len(v) < *n.MinItems {
+			fail("want at least %d items, got %d", *n.MinItems, len(v))
+		}
+		if n.MaxItems != nil && len(v) > *n.MaxItems {
+			fail("want at most %d items, got %d", *n.MaxItems, len(v))
+		}
+		if n.Items != nil {
+			for i, item := range v {
+				n.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if n.MinLength != nil && length < *n.MinLength {
+			fail("want at least %d characters, got %d", *n.MinLength, length)
+		}
+		if n.MaxLength != nil && length > *n.MaxLength {
+			fail("want at most %d characters, got %d", *n.MaxLength, length)
+		}
+		if n.pattern != nil && !n.pattern.MatchString(v) {
+			fail("%s does not match %s", short(v), n.Pattern)
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if n.Minimum != nil && f < *n.Minimum {
+			fail("%s is below the minimum %g", v, *n.Minimum)
+		}
+		if n.Maximum != nil && f > *n.Maximum {
+			fail("%s is above the maximum %g", v, *n.Maximum)
+		}
+	}
+}
+
+// typeOf returns the JSON Schema type of a decoded value. Whole numbers,
+// including ones written as 2.0, are integers, which are numbers too.
+func typeOf(v any) string {
+	switch v := v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if f, err := v.Float64(); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	}
+	return "null"
+}
+
+// equal compares decoded JSON values, treating numbers by value.
+func equal(a, b any) bool {
+	return reflect.DeepEqual(canonical(a), canonical(b))
+}
+
+// canonical converts numbers to float64, so 1 and 1.0 are equal.
+func canonical(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = canonical(e)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = canonical(e)
+		}
+		return out
+	}
+	return v
+}
+
+// short renders a value for an error message, truncated.
+func short(v any) string {
+	data, _ := json.Marshal(v)
+	s := string(data)
+	if len(s) > 40 {
+		s = s[:37] + "..."
+	}
+	return s
+}
@@ -0,0 +1,145 @@
+// Package hooks runs external commands around chat turns, so requests and
+// responses can be redacted, audited, or logged without changing the program
+// that sends them.
+//
+// A hook is a shell command. It receives the turn as a JSON [Turn] on stdin,
+// with CATWALK_HOOK_EVENT set to "pre" or "post". It may print a JSON object
+// with "messages" and/or "response" fields to replace those of the turn; empty
+// output leaves the turn unchanged. A non-zero exit status fails the hook,
+// which for a pre hook means the request is not sent.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"charm.land/catwalk/pkg/transcript"
+)
+
+// Hook events.
+const (
+	Pre  = "pre"
+	Post = "post"
+)
+
+// DefaultTimeout limits how long a hook may run when Hook.Timeout is zero.
+const DefaultTimeout = 30 * time.Second
+
+// Turn is the document passed to a hook. Pre hooks see the request about to
+// be sent; post hooks also see the response, usage, and cost, or the error.
+type Turn struct {
+	Event    string               `json:"event"`
+	Session  string               `json:"session,omitempty"`
+	Provider string               `json:"provider"`
+	Model    string               `json:"model"`
+	Messages []transcript.Message `json:"messages"`
+	Response *transcript.Message  `json:"response,omitempty"`
+	Usage    *transcript.Usage    `json:"usage,omitempty"`
+	Cost     float64              `json:"cost,omitempty"`
+	Error    string               `json:"error,omitempty"`
+}
+
+// Hook is a command run on each turn.
+type Hook struct {
+	// Command is run with the system shell.
+	Command string
+
+	// Timeout limits each run. Zero means DefaultTimeout.
+	Timeout time.Duration
+
+	// Stderr receives the command's standard error, if set.
+	Stderr io.Writer
+}
+
+// Run runs the hook for turn, applying any replacement it prints. The turn is
+// left unchanged if the hook fails.
+func (h Hook) Run(ctx context.Context, turn *Turn) error {
+	if h.Command == "" {
+		return nil
+	}
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	input, err := json.Marshal(turn)
+	if err != nil {
+		return fmt.Errorf("failed to encode hook input: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := shell(ctx, h.Command)
+	cmd.Env = append(os.Environ(), "CATWALK_HOOK_EVENT="+turn.Event)
+	// Don't wait for children of the shell that outlive a killed hook.
+	cmd.WaitDelay = time.Second
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if h.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(&stderr, h.Stderr)
+	}
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("%s hook timed out after %s", turn.Event, timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s hook failed: %s", turn.Event, lastLine(msg))
+		}
+		return fmt.Errorf("%s hook failed: %w", turn.Event, err)
+	}
+
+	return apply(turn, stdout.Bytes())
+}
+
+// apply replaces the fields of turn set in a hook's output.
+func apply(turn *Turn, output []byte) error {
+	if len(bytes.TrimSpace(output)) == 0 {
+		return nil
+	}
+
+	var update struct {
+		Messages *[]transcript.Message `json:"messages"`
+		Response *transcript.Message   `json:"response"`
+	}
+	if err := json.Unmarshal(output, &update); err != nil {
+		return fmt.Errorf("%s hook printed invalid JSON: %w", turn.Event, err)
+	}
+	if update.Messages != nil {
+		if len(*update.Messages) == 0 {
+			return errors.New(turn.Event + " hook returned no messages")
+		}
+		turn.Messages = *update.Messages
+	}
+	if update.Response != nil {
+		turn.Response = update.Response
+	}
+	return nil
+}
+
+// shell returns a command running s with the system shell.
+func shell(ctx context.Context, s string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", s)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", s)
+}
+
+// lastLine returns the last line of s, which is usually the error message.
+func lastLine(s string) string {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
@@ -0,0 +1,67 @@
+package hooks
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"charm.land/catwalk/pkg/transcript"
+)
+
+func newTurn() *Turn {
+	return &Turn{
+		Event:    Pre,
+		Provider: "openai",
+		Model:    "gpt-4o",
+		Messages: []transcript.Message{{Role: "user", Content: "my key is sk-123"}},
+	}
+}
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks in tests use sh")
+	}
+	ctx := context.Background()
+
+	// Empty output leaves the turn unchanged, and the event is in the environment.
+	turn := newTurn()
+	var stderr strings.Builder
+	if err := (Hook{Command: `cat >/dev/null; echo "$CATWALK_HOOK_EVENT" >&2`, Stderr: &stderr}).Run(ctx, turn); err != nil {
+		t.Fatal(err)
+	}
+	if turn.Messages[0].Content != "my key is sk-123" || strings.TrimSpace(stderr.String()) != "pre" {
+		t.Errorf("turn = %+v, stderr = %q", turn, stderr.String())
+	}
+
+	// Printed messages replace the request.
+	redact := `sed 's/sk-[0-9]*/[REDACTED]/g'`
+	if err := (Hook{Command: redact}).Run(ctx, turn); err != nil {
+		t.Fatal(err)
+	}
+	if got := turn.Messages[0].Content; got != "my key is [REDACTED]" {
+		t.Errorf("redacted content = %q", got)
+	}
+
+	// A non-zero exit fails with the last line of stderr and keeps the turn.
+	turn = newTurn()
+	err := (Hook{Command: `echo '{"messages":[]}'; echo "checking" >&2; echo "blocked: contains a secret" >&2; exit 1`}).Run(ctx, turn)
+	if err == nil || err.Error() != "pre hook failed: blocked: contains a secret" {
+		t.Errorf("err = %v", err)
+	}
+	if turn.Messages[0].Content != "my key is sk-123" {
+		t.Errorf("failed hook changed the turn: %+v", turn)
+	}
+
+	if err := (Hook{Command: `echo not json`}).Run(ctx, turn); err == nil {
+		t.Error("expected error for invalid output")
+	}
+	if err := (Hook{Command: `echo '{"messages":[]}'`}).Run(ctx, turn); err == nil {
+		t.Error("expected error for empty messages")
+	}
+	if err := (Hook{Command: `exec sleep 5`, Timeout: 50 * time.Millisecond}).Run(ctx, turn); err == nil ||
+		!strings.Contains(err.Error(), "timed out") {
+		t.Errorf("err = %v; want timeout", err)
+	}
+}
@@ -0,0 +1,288 @@
+// Package openrouter supports OpenRouter's extensions to the OpenAI chat
+// completions API: provider routing preferences, transforms, and model
+// fallbacks on requests, and the upstream provider, native token counts, and
+// billed cost reported on responses.
+package openrouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ProviderPreferences controls which upstream providers OpenRouter routes a
+// request to. See https://openrouter.ai/docs/features/provider-routing.
+type ProviderPreferences struct {
+	// Order lists providers to try first, in order.
+	Order []string `json:"order,omitempty"`
+
+	// Only restricts routing to these providers.
+	Only []string `json:"only,omitempty"`
+
+	// Ignore excludes these providers.
+	Ignore []string `json:"ignore,omitempty"`
+
+	// AllowFallbacks, when false, fails the request instead of falling back
+	// to providers outside Order.
+	AllowFallbacks *bool `json:"allow_fallbacks,omitempty"`
+
+	// RequireParameters only routes to providers that support every
+	// parameter in the request.
+	RequireParameters bool `json:"require_parameters,omitempty"`
+
+	// DataCollection is "allow" or "deny"; "deny" excludes providers that
+	// may store or train on prompts.
+	DataCollection string `json:"data_collection,omitempty"`
+
+	// Sort is "price", "throughput", or "latency", overriding OpenRouter's
+	// default load balancing.
+	Sort string `json:"sort,omitempty"`
+}
+
+// Request holds the OpenRouter-specific fields added to a chat completion
+// request.
+type Request struct {
+	Provider *ProviderPreferences `json:"provider,omitempty"`
+
+	// Transforms are prompt transforms such as "middle-out", which
+	// compresses prompts that exceed the context window.
+	Transforms []string `json:"transforms,omitempty"`
+
+	// Models are fallback models tried in order if the requested model is
+	// unavailable or errors.
+	Models []string `json:"models,omitempty"`
+}
+
+// Validate checks the values OpenRouter accepts from a fixed set.
+func (r Request) Validate() error {
+	if p := r.Provider; p != nil {
+		if p.Sort != "" && !slices.Contains([]string{"price", "throughput", "latency"}, p.Sort) {
+			return fmt.Errorf("invalid provider sort %q (use price, throughput, or latency)", p.Sort)
+		}
+		if p.DataCollection != "" && p.DataCollection != "allow" && p.DataCollection != "deny" {
+			return fmt.Errorf("invalid data collection %q (use allow or deny)", p.DataCollection)
+		}
+	}
+	return nil
+}
+
+// IsZero reports whether r adds nothing to a request.
+func (r Request) IsZero() bool {
+	return (r.Provider == nil || r.Provider.isZero()) &&
+		len(r.Transforms) == 0 && len(r.Models) == 0
+}
+
+func (p ProviderPreferences) isZero() bool {
+	return len(p.Order) == 0 && len(p.Only) == 0 && len(p.Ignore) == 0 &&
+		p.AllowFallbacks == nil && !p.RequireParameters &&
+		p.DataCollection == "" && p.Sort == ""
+}
+
+// Extend returns the JSON request body with r's fields added, along with
+// usage accounting so the response reports the billed cost. Fields already
+// present in body are left untouched.
+func (r Request) Extend(body []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode request body: %w", err)
+	}
+
+	extra, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request fields: %w", err)
+	}
+	extraFields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(extra, &extraFields); err != nil {
+		return nil, fmt.Errorf("failed to encode request fields: %w", err)
+	}
+	extraFields["usage"] = json.RawMessage(`{"include":true}`)
+	for k, v := range extraFields {
+		if _, ok := fields[k]; !ok {
+			fields[k] = v
+		}
+	}
+
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request body: %w", err)
+	}
+	return out, nil
+}
+
+// Transport returns a RoundTripper that adds r's fields to every chat
+// completion request sent through base.
+func (r Request) Transport(base http.RoundTripper) http.RoundTripper {
+	return &requestTransport{base: base, req: r}
+}
+
+type requestTransport struct {
+	base http.RoundTripper
+	req  Request
+}
+
+func (t *requestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/chat/completions") || req.Body == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close() //nolint:errcheck
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	body, err = t.req.Extend(body)
+	if err != nil {
+		return nil, err
+	}
+
+	// RoundTrippers must not modify the caller's request.
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return t.base.RoundTrip(req)
+}
+
+// Usage is the usage accounting OpenRouter reports on the final chunk of a
+// response. Token counts come from the upstream model's own tokenizer.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+
+	// Cost is the amount billed for the request, in USD.
+	Cost float64 `json:"cost"`
+
+	PromptTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+	CompletionTokensDetails struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details"`
+}
+
+// Metadata is the OpenRouter-specific part of a completion response or
+// streamed chunk.
+type Metadata struct {
+	// Provider is the upstream provider that served the request.
+	Provider string `json:"provider"`
+
+	// Model is the model that answered, which differs from the requested one
+	// when a fallback was used.
+	Model string `json:"model"`
+
+	Usage *Usage `json:"usage"`
+}
+
+// Merge updates m with the fields set in a response chunk.
+func (m *Metadata) Merge(chunk []byte) error {
+	var c Metadata
+	if err := json.Unmarshal(chunk, &c); err != nil {
+		return fmt.Errorf("failed to decode response metadata: %w", err)
+	}
+	if c.Provider != "" {
+		m.Provider = c.Provider
+	}
+	if c.Model != "" {
+		m.Model = c.Model
+	}
+	if c.Usage != nil {
+		m.Usage = c.Usage
+	}
+	return nil
+}
+
+// Options are the command-line settings that build a [Request].
+type Options struct {
+	Config         string
+	Order          string
+	Only           string
+	Ignore         string
+	NoFallbacks    bool
+	Sort           string
+	DataCollection string
+	Transforms     string
+	Fallbacks      string
+}
+
+// RegisterFlags registers the --openrouter-* flags on fs and returns the
+// Options they populate.
+func RegisterFlags(fs *flag.FlagSet) *Options {
+	o := &Options{}
+	fs.StringVar(&o.Config, "openrouter-config", "", "JSON file with OpenRouter request fields (provider, transforms, models)")
+	fs.StringVar(&o.Order, "openrouter-order", "", "Comma-separated upstream providers to try first, in order")
+	fs.StringVar(&o.Only, "openrouter-only", "", "Comma-separated upstream providers to restrict routing to")
+	fs.StringVar(&o.Ignore, "openrouter-ignore", "", "Comma-separated upstream providers to exclude")
+	fs.BoolVar(&o.NoFallbacks, "openrouter-no-fallbacks", false, "Fail instead of falling back to other upstream providers")
+	fs.StringVar(&o.Sort, "openrouter-sort", "", "Route by price, throughput, or latency")
+	fs.StringVar(&o.DataCollection, "openrouter-data-collection", "", "allow or deny upstream providers that may store prompts")
+	fs.StringVar(&o.Transforms, "openrouter-transforms", "", "Comma-separated prompt transforms (e.g. middle-out)")
+	fs.StringVar(&o.Fallbacks, "openrouter-fallbacks", "", "Comma-separated fallback model IDs")
+	return o
+}
+
+// Request builds the request fields from the config file, if any, with the
+// individual flags taking precedence.
+func (o Options) Request() (Request, error) {
+	var r Request
+	if o.Config != "" {
+		data, err := os.ReadFile(o.Config)
+		if err != nil {
+			return Request{}, fmt.Errorf("failed to read OpenRouter config: %w", err)
+		}
+		if err := json.Unmarshal(data, &r); err != nil {
+			return Request{}, fmt.Errorf("failed to parse OpenRouter config: %w", err)
+		}
+	}
+
+	p := ProviderPreferences{}
+	if r.Provider != nil {
+		p = *r.Provider
+	}
+	setList(&p.Order, o.Order)
+	setList(&p.Only, o.Only)
+	setList(&p.Ignore, o.Ignore)
+	if o.NoFallbacks {
+		allow := false
+		p.AllowFallbacks = &allow
+	}
+	if o.Sort != "" {
+		p.Sort = o.Sort
+	}
+	if o.DataCollection != "" {
+		p.DataCollection = o.DataCollection
+	}
+	r.Provider = nil
+	if !p.isZero() {
+		r.Provider = &p
+	}
+	setList(&r.Transforms, o.Transforms)
+	setList(&r.Models, o.Fallbacks)
+
+	if err := r.Validate(); err != nil {
+		return Request{}, err
+	}
+	return r, nil
+}
+
+// setList replaces *dst with the comma-separated values in s, if any.
+func setList(dst *[]string, s string) {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	if len(list) > 0 {
+		*dst = list
+	}
+}
@@ -0,0 +1,85 @@
+package openrouter
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOptionsRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "openrouter.json")
+	config := `{"provider": {"order": ["Together"], "data_collection": "deny"}, "transforms": ["middle-out"]}`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Options{Config: path, Order: "Azure, OpenAI", NoFallbacks: true, Fallbacks: "openai/gpt-4o-mini"}.Request()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := json.Marshal(r)
+	want := `{"provider":{"order":["Azure","OpenAI"],"allow_fallbacks":false,"data_collection":"deny"},"transforms":["middle-out"],"models":["openai/gpt-4o-mini"]}`
+	if string(got) != want {
+		t.Errorf("request = %s\nwant %s", got, want)
+	}
+
+	if r, err := (Options{}).Request(); err != nil || !r.IsZero() {
+		t.Errorf("empty options = %+v, %v; want zero request", r, err)
+	}
+	if _, err := (Options{Sort: "cheapest"}).Request(); err == nil {
+		t.Error("expected error for invalid sort")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: Request{Transforms: []string{"middle-out"}}.Transport(http.DefaultTransport)}
+	resp, err := client.Post(srv.URL+"/api/v1/chat/completions", "application/json",
+		strings.NewReader(`{"model":"openai/gpt-4o","transforms":[]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close() //nolint:errcheck
+
+	if body["model"] != "openai/gpt-4o" {
+		t.Errorf("model = %v; want it preserved", body["model"])
+	}
+	if transforms, _ := body["transforms"].([]any); len(transforms) != 0 {
+		t.Errorf("transforms = %v; want the request's own value kept", transforms)
+	}
+	if usage, _ := body["usage"].(map[string]any); usage["include"] != true {
+		t.Errorf("usage = %v; want include: true", body["usage"])
+	}
+}
+
+func TestMetadataMerge(t *testing.T) {
+	var m Metadata
+	chunks := []string{
+		`{"id":"gen-1","provider":"Together","model":"meta-llama/llama-3.3-70b-instruct","choices":[{"delta":{"content":"Hi"}}]}`,
+		`{"id":"gen-1","choices":[],"usage":{"prompt_tokens":12,"completion_tokens":3,"cost":0.0000042}}`,
+	}
+	for _, c := range chunks {
+		if err := m.Merge([]byte(c)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.Provider != "Together" || m.Model != "meta-llama/llama-3.3-70b-instruct" {
+		t.Errorf("metadata = %+v", m)
+	}
+	if m.Usage == nil || m.Usage.PromptTokens != 12 || m.Usage.CompletionTokens != 3 || m.Usage.Cost != 0.0000042 {
+		t.Errorf("usage = %+v", m.Usage)
+	}
+}
@@ -0,0 +1,246 @@
+// Package policy enforces an organization's rules on which models its tools
+// may use, read from a YAML file:
+//
+//	allowed_providers: [openai, azure, anthropic]
+//	banned_models: ["gpt-3.5*", "openrouter/*:free"]
+//	max_cost_per_1m: {input: 10, output: 40}
+//	data_residency:
+//	  required: [eu]
+//	  providers:
+//	    azure: [eu, us]
+//	    anthropic: [us]
+//
+// A model must pass every setting that is present: its provider must be
+// allowed, its ID, or provider/ID, must not match a banned pattern, its catalog
+// prices must not exceed the caps, and when regions are required its
+// provider must be listed as serving from one of them. Unlike pkg/rules,
+// which rewrites requests, a policy only allows or refuses them; refusals
+// are *catwalk.PolicyViolationError values.
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"go.yaml.in/yaml/v2"
+)
+
+// Policy is a parsed policy file. A nil *Policy allows every model.
+type Policy struct {
+	// AllowedProviders lists the providers that may be used; empty allows
+	// all.
+	AllowedProviders []catwalk.InferenceProvider `yaml:"allowed_providers,omitempty"`
+
+	// BannedModels are patterns, such as "gpt-3.5*", matched against model
+	// IDs and against provider/ID, ignoring case. "*" matches any text,
+	// slashes included, and "?" any one character.
+	BannedModels []string `yaml:"banned_models,omitempty"`
+
+	MaxCostPer1M Cost `yaml:"max_cost_per_1m,omitempty"`
+
+	DataResidency Residency `yaml:"data_residency,omitempty"`
+
+	// Path is the file the policy was loaded from, for error messages.
+	Path string `yaml:"-"`
+
+	// banned holds BannedModels compiled, in the same order.
+	banned []*regexp.Regexp
+}
+
+// Cost caps catalog prices in USD per 1M tokens. Zero is no cap.
+type Cost struct {
+	Input  float64 `yaml:"input,omitempty"`
+	Output float64 `yaml:"output,omitempty"`
+}
+
+// Residency requires providers to keep data in certain regions.
+type Residency struct {
+	// Required lists the acceptable regions, such as "eu"; empty requires
+	// none.
+	Required []string `yaml:"required,omitempty"`
+
+	// Providers lists where each provider processes data, as the
+	// organization's contracts and deployments say. The catalog has no
+	// region data, so unlisted providers fail a requirement.
+	Providers map[catwalk.InferenceProvider][]string `yaml:"providers,omitempty"`
+}
+
+// DefaultPath returns the policy file used when none is given:
+// policy.yaml in the aimodels config directory.
+func DefaultPath() (string, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine config directory: %w", err)
+	}
+	return filepath.Join(config, "aimodels", "policy.yaml"), nil
+}
+
+// Load reads and validates a policy file.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p.Path = path
+	return p, nil
+}
+
+// Open loads the policy file at path, or at DefaultPath if path is empty. A
+// missing default file is not an error: Open returns nil.
+func Open(path string) (*Policy, error) {
+	if path != "" {
+		return Load(path)
+	}
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return Load(path)
+}
+
+// Parse parses and validates a policy in YAML. Unknown fields are errors,
+// so a misspelled setting does not silently allow everything.
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.UnmarshalStrict(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	for _, glob := range p.BannedModels {
+		if strings.TrimSpace(glob) == "" {
+			return nil, errors.New("invalid policy: empty banned model pattern")
+		}
+		p.banned = append(p.banned, compile(glob))
+	}
+	if p.MaxCostPer1M.Input < 0 || p.MaxCostPer1M.Output < 0 {
+		return nil, errors.New("invalid policy: max_cost_per_1m must not be negative")
+	}
+	for i, r := range p.DataResidency.Required {
+		p.DataResidency.Required[i] = strings.ToLower(strings.TrimSpace(r))
+	}
+	for id, regions := range p.DataResidency.Providers {
+		if len(regions) == 0 {
+			return nil, fmt.Errorf("invalid policy: data_residency: no regions for %s", id)
+		}
+		for i, r := range regions {
+			regions[i] = strings.ToLower(strings.TrimSpace(r))
+		}
+	}
+	return &p, nil
+}
+
+// Check returns a *catwalk.PolicyViolationError if the policy does not
+// allow model m at provider p, for the first setting it fails, or nil.
+func (p *Policy) Check(provider catwalk.Provider, m catwalk.Model) error {
+	if p == nil {
+		return nil
+	}
+	violation := func(rule, reason string, args ...any) error {
+		return &catwalk.PolicyViolationError{
+			Provider: provider.ID, Model: m.ID, Policy: p.Path,
+			Rule: rule, Reason: fmt.Sprintf(reason, args...),
+		}
+	}
+
+	if len(p.AllowedProviders) > 0 && !slices.Contains(p.AllowedProviders, provider.ID) {
+		return violation("allowed_providers", "%s is not an allowed provider (allowed: %s)", provider.ID, join(p.AllowedProviders))
+	}
+	for i, re := range p.banned {
+		if re.MatchString(m.ID) || re.MatchString(string(provider.ID)+"/"+m.ID) {
+			return violation("banned_models", "the model matches %q", p.BannedModels[i])
+		}
+	}
+	if limit := p.MaxCostPer1M.Input; limit > 0 && m.CostPer1MIn > limit {
+		return violation("max_cost_per_1m", "input costs $%.2f per 1M tokens, over the $%.2f cap", m.CostPer1MIn, limit)
+	}
+	if limit := p.MaxCostPer1M.Output; limit > 0 && m.CostPer1MOut > limit {
+		return violation("max_cost_per_1m", "output costs $%.2f per 1M tokens, over the $%.2f cap", m.CostPer1MOut, limit)
+	}
+	if required := p.DataResidency.Required; len(required) > 0 {
+		regions, ok := p.DataResidency.Providers[provider.ID]
+		if !ok {
+			return violation("data_residency", "no region is recorded for %s, and %s is required", provider.ID, strings.Join(required, " or "))
+		}
+		if !slices.ContainsFunc(regions, func(r string) bool { return slices.Contains(required, r) }) {
+			return violation("data_residency", "%s processes data in %s, not %s", provider.ID, strings.Join(regions, ", "), strings.Join(required, " or "))
+		}
+	}
+	return nil
+}
+
+// Allowed returns the providers with only the models the policy allows,
+// leaving out providers with none.
+func (p *Policy) Allowed(providers []catwalk.Provider) []catwalk.Provider {
+	if p == nil {
+		return providers
+	}
+	var out []catwalk.Provider
+	for _, provider := range providers {
+		var models []catwalk.Model
+		for _, m := range provider.Models {
+			if p.Check(provider, m) == nil {
+				models = append(models, m)
+			}
+		}
+		if len(models) > 0 {
+			provider.Models = models
+			out = append(out, provider)
+		}
+	}
+	return out
+}
+
+// Middleware returns chat middleware that refuses requests to models the
+// policy does not allow, as a last line of defense behind the checks a
+// tool makes when a model is chosen.
+func (p *Policy) Middleware() chat.Middleware {
+	return func(next chat.Handler) chat.Handler {
+		return chat.HandlerFunc(func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			if err := p.Check(req.Provider, req.Model); err != nil {
+				return nil, err
+			}
+			return next.Complete(ctx, req)
+		})
+	}
+}
+
+// compile turns a banned model pattern into a regular expression matching
+// whole IDs.
+func compile(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(?i)^")
+	for _, r := range strings.TrimSpace(glob) {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func join(ids []catwalk.InferenceProvider) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = string(id)
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,106 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+)
+
+const testPolicy = `
+allowed_providers: [openai, azure, openrouter]
+banned_models: ["gpt-3.5*", "openrouter/*:free"]
+max_cost_per_1m: {input: 10, output: 40}
+data_residency:
+  required: [EU]
+  providers:
+    azure: [eu, us]
+    openai: [us]
+`
+
+func TestCheck(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Path = "policy.yaml"
+
+	azure := catwalk.Provider{ID: "azure"}
+	for _, tt := range []struct {
+		provider catwalk.Provider
+		model    catwalk.Model
+		rule     string
+	}{
+		{azure, catwalk.Model{ID: "gpt-4o", CostPer1MIn: 2.5, CostPer1MOut: 10}, ""},
+		{catwalk.Provider{ID: "anthropic"}, catwalk.Model{ID: "claude-sonnet-4-5"}, "allowed_providers"},
+		{azure, catwalk.Model{ID: "GPT-3.5-turbo"}, "banned_models"},
+		{catwalk.Provider{ID: "openrouter"}, catwalk.Model{ID: "meta/llama:free"}, "banned_models"},
+		{azure, catwalk.Model{ID: "o1-pro", CostPer1MIn: 150, CostPer1MOut: 600}, "max_cost_per_1m"},
+		{azure, catwalk.Model{ID: "gpt-4.5", CostPer1MIn: 5, CostPer1MOut: 150}, "max_cost_per_1m"},
+		{catwalk.Provider{ID: "openai"}, catwalk.Model{ID: "gpt-4o"}, "data_residency"},
+		{catwalk.Provider{ID: "openrouter"}, catwalk.Model{ID: "openai/gpt-4o"}, "data_residency"},
+	} {
+		err := p.Check(tt.provider, tt.model)
+		var violation *catwalk.PolicyViolationError
+		switch {
+		case tt.rule == "" && err != nil:
+			t.Errorf("%s/%s: unexpected %v", tt.provider.ID, tt.model.ID, err)
+		case tt.rule != "" && (!errors.As(err, &violation) || violation.Rule != tt.rule):
+			t.Errorf("%s/%s: got %v, want a %s violation", tt.provider.ID, tt.model.ID, err, tt.rule)
+		}
+	}
+
+	err = p.Check(catwalk.Provider{ID: "openai"}, catwalk.Model{ID: "gpt-4o"})
+	if want := "openai/gpt-4o is not allowed by policy.yaml (data_residency): openai processes data in us, not eu"; err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+
+	if allowed := p.Allowed([]catwalk.Provider{
+		{ID: "azure", Models: []catwalk.Model{{ID: "gpt-4o"}, {ID: "gpt-35-turbo"}, {ID: "gpt-3.5-turbo"}}},
+		{ID: "openai", Models: []catwalk.Model{{ID: "gpt-4o"}}},
+	}); len(allowed) != 1 || len(allowed[0].Models) != 2 {
+		t.Errorf("Allowed = %+v", allowed)
+	}
+
+	var none *Policy
+	if err := none.Check(catwalk.Provider{ID: "x"}, catwalk.Model{ID: "y"}); err != nil {
+		t.Errorf("a nil policy refused a model: %v", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, data := range []string{
+		"banned_model: [gpt-4]",
+		"banned_models: ['']",
+		"max_cost_per_1m: {input: -1}",
+		"data_residency: {providers: {azure: []}}",
+	} {
+		if _, err := Parse([]byte(data)); err == nil || !strings.Contains(err.Error(), "invalid policy") {
+			t.Errorf("Parse(%q) = %v, want an invalid policy error", data, err)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	p, err := Parse([]byte("banned_models: [gpt-4]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	called := false
+	h := p.Middleware()(chat.HandlerFunc(func(context.Context, *chat.Request) (*chat.Response, error) {
+		called = true
+		return &chat.Response{}, nil
+	}))
+
+	req := &chat.Request{Provider: catwalk.Provider{ID: "openai"}, Model: catwalk.Model{ID: "gpt-4"}}
+	if _, err := h.Complete(context.Background(), req); !errors.Is(err, catwalk.ErrPolicyViolation) || called {
+		t.Errorf("banned model: err %v, called %v", err, called)
+	}
+	req.Model.ID = "gpt-4o"
+	if _, err := h.Complete(context.Background(), req); err != nil || !called {
+		t.Errorf("allowed model: err %v, called %v", err, called)
+	}
+}
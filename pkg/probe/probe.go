@@ -0,0 +1,323 @@
+// Package probe checks empirically which capabilities a model has on a
+// provider's endpoint, rather than trusting the catalog: it sends an image,
+// offers a tool, asks for output matching a JSON schema, and fills most of
+// the context window, and reports which of those worked. Providers often
+// serve a model without a feature its maker advertises, such as images
+// through an OpenAI-compatible proxy.
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/tokenizer"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Capabilities that can be probed.
+const (
+	Images      = "images"
+	Tools       = "tools"
+	JSONSchema  = "json_schema"
+	LongContext = "long_context"
+)
+
+// All lists every capability, in the order they are probed.
+var All = []string{Images, Tools, JSONSchema, LongContext}
+
+// Default lists the capabilities probed unless others are asked for. The
+// long-context probe is left out since it costs most of a context window of
+// input tokens.
+var Default = []string{Images, Tools, JSONSchema}
+
+// Target is the model to probe.
+type Target struct {
+	Client   *openai.Client
+	Provider catwalk.Provider
+	Model    catwalk.Model
+}
+
+// Result is the outcome of one probe.
+type Result struct {
+	Capability string
+
+	// Advertised is whether the catalog claims the capability, or nil if
+	// the catalog does not record it.
+	Advertised *bool
+
+	Works bool
+	// Detail says what the model did, or why the request failed.
+	Detail string
+	// Inconclusive is set when the request failed for a reason that says
+	// nothing about the capability, such as a bad API key, a rate limit, or
+	// a server error.
+	Inconclusive bool
+
+	// PromptTokens is the size of the prompt sent, for the long-context
+	// probe.
+	PromptTokens int64
+
+	InputTokens  int
+	OutputTokens int
+	Cost         float64
+}
+
+// Mismatch reports whether the result contradicts the catalog.
+func (r Result) Mismatch() bool {
+	return !r.Inconclusive && r.Advertised != nil && *r.Advertised != r.Works
+}
+
+// Options control the probes.
+type Options struct {
+	// ContextFraction is the share of the context window the long-context
+	// probe fills (default 0.9).
+	ContextFraction float64
+}
+
+// LongContextTokens returns the prompt size in tokens the long-context probe
+// sends to m, or 0 if m's context window is unknown.
+func (o Options) LongContextTokens(m catwalk.Model) int64 {
+	fraction := o.ContextFraction
+	if fraction <= 0 || fraction > 1 {
+		fraction = 0.9
+	}
+	return int64(float64(m.ContextWindow) * fraction)
+}
+
+// Run probes the capabilities in order and returns a result for each.
+// Unknown capability names are reported as not working.
+func Run(ctx context.Context, t Target, capabilities []string, opts Options) []Result {
+	results := make([]Result, 0, len(capabilities))
+	for _, c := range capabilities {
+		var r Result
+		switch c {
+		case Images:
+			r = probeImages(ctx, t)
+		case Tools:
+			r = probeTools(ctx, t)
+		case JSONSchema:
+			r = probeJSONSchema(ctx, t)
+		case LongContext:
+			r = probeLongContext(ctx, t, opts.LongContextTokens(t.Model))
+		default:
+			r = Result{Detail: "unknown capability"}
+		}
+		r.Capability = c
+		results = append(results, r)
+	}
+	return results
+}
+
+// send makes one non-streaming request and records its usage in r. It
+// returns nil, with r.Detail set, if the request failed.
+func send(ctx context.Context, t Target, req openai.ChatCompletionRequest, r *Result) *openai.ChatCompletionMessage {
+	req.Model = t.Model.ID
+	req.MaxTokens = 200
+	if t.Model.CanReason {
+		// Reasoning models spend tokens thinking before they answer
+		req.MaxTokens = 4000
+	}
+	resp, err := t.Client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		r.Detail = "request failed: " + err.Error()
+		r.Inconclusive = !rejected(err)
+		return nil
+	}
+	r.InputTokens, r.OutputTokens = resp.Usage.PromptTokens, resp.Usage.CompletionTokens
+	r.Cost = chat.Cost(t.Model, r.InputTokens, r.OutputTokens)
+	if len(resp.Choices) == 0 {
+		r.Detail = "no reply"
+		return nil
+	}
+	return &resp.Choices[0].Message
+}
+
+// rejected reports whether the provider refused the request itself, with a
+// client error other than authentication, timeouts, and rate limits, which
+// is how endpoints answer features they do not support.
+func rejected(err error) bool {
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	status := 0
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	}
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return status >= 400 && status < 500
+}
+
+// probeImages sends a solid red square and asks for its color.
+func probeImages(ctx context.Context, t Target) Result {
+	r := Result{Advertised: &t.Model.SupportsImages}
+	msg := send(ctx, t, openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{{
+			Role: chat.RoleUser,
+			MultiContent: []openai.ChatMessagePart{
+				{Type: openai.ChatMessagePartTypeText, Text: "What color is this image? Answer with one word."},
+				{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{URL: redSquare}},
+			},
+		}},
+	}, &r)
+	if msg == nil {
+		return r
+	}
+	r.Works = strings.Contains(strings.ToLower(msg.Content), "red")
+	r.Detail = fmt.Sprintf("replied %q", truncate(msg.Content))
+	return r
+}
+
+// redSquare is a data URL of a 16×16 red PNG.
+var redSquare = func() string {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	// Pix is in RGBA order
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+3] = 255, 255
+	}
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}()
+
+// probeTools offers a weather tool and expects a call to it with a city.
+func probeTools(ctx context.Context, t Target) Result {
+	var r Result
+	msg := send(ctx, t, openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{{
+			Role:    chat.RoleUser,
+			Content: "What is the weather in Paris right now? Use the get_weather tool.",
+		}},
+		Tools: []openai.Tool{{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "get_weather",
+				Description: "Get the current weather in a city.",
+				Parameters:  json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}`),
+			},
+		}},
+	}, &r)
+	if msg == nil {
+		return r
+	}
+	for _, call := range msg.ToolCalls {
+		var args struct {
+			City string `json:"city"`
+		}
+		if call.Function.Name == "get_weather" && json.Unmarshal([]byte(call.Function.Arguments), &args) == nil && args.City != "" {
+			r.Works = true
+			r.Detail = fmt.Sprintf("called get_weather(%s)", call.Function.Arguments)
+			return r
+		}
+	}
+	if len(msg.ToolCalls) > 0 {
+		r.Detail = fmt.Sprintf("called %s(%s)", msg.ToolCalls[0].Function.Name, msg.ToolCalls[0].Function.Arguments)
+	} else {
+		r.Detail = fmt.Sprintf("replied without a tool call: %q", truncate(msg.Content))
+	}
+	return r
+}
+
+// probeJSONSchema asks for a person matching a strict schema and checks the
+// reply against it.
+func probeJSONSchema(ctx context.Context, t Target) Result {
+	var r Result
+	msg := send(ctx, t, openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{{
+			Role:    chat.RoleUser,
+			Content: "Make up a person.",
+		}},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   "person",
+				Strict: true,
+				Schema: json.RawMessage(`{"type":"object","properties":{"name":{"type":"string"},"age":{"type":"integer"}},"required":["name","age"],"additionalProperties":false}`),
+			},
+		},
+	}, &r)
+	if msg == nil {
+		return r
+	}
+	var person map[string]any
+	if err := json.Unmarshal([]byte(msg.Content), &person); err != nil {
+		r.Detail = fmt.Sprintf("reply is not JSON: %q", truncate(msg.Content))
+		return r
+	}
+	name, okName := person["name"].(string)
+	age, okAge := person["age"].(float64)
+	if !okName || !okAge || age != float64(int64(age)) || len(person) != 2 {
+		r.Detail = fmt.Sprintf("reply does not match the schema: %s", truncate(msg.Content))
+		return r
+	}
+	r.Works = true
+	r.Detail = fmt.Sprintf("returned {name: %q, age: %d}", name, int64(age))
+	return r
+}
+
+// longContextFiller is repeated to fill the context window.
+const longContextFiller = "The quick brown fox jumps over the lazy dog while the committee reviews the quarterly figures. "
+
+// probeLongContext fills the prompt to size tokens, with a passphrase at the
+// start, and asks for the passphrase at the end, so a provider that silently
+// truncates the prompt fails as well as one that rejects it.
+func probeLongContext(ctx context.Context, t Target, size int64) Result {
+	claim := t.Model.ContextWindow > 0
+	r := Result{Advertised: &claim}
+	if size <= 0 {
+		r.Detail = "the catalog lists no context window"
+		return r
+	}
+
+	const passphrase = "violet-harbor-1987"
+	head := "Remember this passphrase: " + passphrase + ". Several pages of unrelated text follow.\n\n"
+	tail := "\n\nWhat was the passphrase given at the start? Reply with the passphrase only."
+	budget := size - int64(tokenizer.CountMessage(chat.RoleUser, head+tail))
+	per := int64(tokenizer.Count(longContextFiller))
+	var b strings.Builder
+	b.WriteString(head)
+	for budget >= per {
+		b.WriteString(longContextFiller)
+		budget -= per
+	}
+	b.WriteString(tail)
+	prompt := b.String()
+	r.PromptTokens = int64(tokenizer.CountMessage(chat.RoleUser, prompt))
+
+	msg := send(ctx, t, openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{{Role: chat.RoleUser, Content: prompt}},
+	}, &r)
+	if msg == nil {
+		return r
+	}
+	r.Works = strings.Contains(msg.Content, passphrase)
+	if r.Works {
+		r.Detail = fmt.Sprintf("recalled the passphrase from a ~%d-token prompt", r.PromptTokens)
+	} else {
+		r.Detail = fmt.Sprintf("did not recall the passphrase from a ~%d-token prompt: %q", r.PromptTokens, truncate(msg.Content))
+	}
+	return r
+}
+
+// truncate shortens a reply for display.
+func truncate(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > 60 {
+		return string(r[:60]) + "…"
+	}
+	return s
+}
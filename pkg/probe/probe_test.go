@@ -0,0 +1,108 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"github.com/sashabaranov/go-openai"
+)
+
+// newTarget returns a target served by a fake provider that handles tools
+// and long prompts but rejects images and ignores response formats.
+func newTarget(t *testing.T, model catwalk.Model) Target {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		msg := req.Messages[0]
+		reply := openai.ChatCompletionMessage{Role: chat.RoleAssistant}
+		switch {
+		case len(msg.MultiContent) > 0:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error": {"message": "image input is not supported"}}`))
+			return
+		case len(req.Tools) > 0:
+			reply.ToolCalls = []openai.ToolCall{{ID: "1", Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: "get_weather", Arguments: `{"city": "Paris"}`}}}
+		case strings.Contains(msg.Content, "passphrase"):
+			if len(msg.Content) < 1000 {
+				t.Errorf("long-context prompt has %d bytes", len(msg.Content))
+			}
+			reply.Content = "violet-harbor-1987"
+		default:
+			reply.Content = "Sure! Here is a person: Ada, 36."
+		}
+		_ = json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: reply}},
+			Usage:   openai.Usage{PromptTokens: 100, CompletionTokens: 10},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	config := openai.DefaultConfig("key")
+	config.BaseURL = srv.URL
+	return Target{Client: openai.NewClientWithConfig(config), Model: model}
+}
+
+func TestRun(t *testing.T) {
+	model := catwalk.Model{ID: "m", ContextWindow: 2000, SupportsImages: true, CostPer1MIn: 1, CostPer1MOut: 2}
+	results := Run(context.Background(), newTarget(t, model), All, Options{})
+	if len(results) != len(All) {
+		t.Fatalf("got %d results", len(results))
+	}
+
+	for _, tt := range []struct {
+		works, mismatch bool
+		detail          string
+	}{
+		{false, true, "image input is not supported"},
+		{true, false, `get_weather({"city": "Paris"})`},
+		{false, false, "not JSON"},
+		{true, false, "recalled the passphrase"},
+	} {
+		r := results[0]
+		results = results[1:]
+		if r.Works != tt.works || r.Mismatch() != tt.mismatch || !strings.Contains(r.Detail, tt.detail) {
+			t.Errorf("%s = works %v, mismatch %v, %q", r.Capability, r.Works, r.Mismatch(), r.Detail)
+		}
+		if r.Inconclusive {
+			t.Errorf("%s is inconclusive", r.Capability)
+		}
+		if r.Capability != Images && r.Cost != 0.00012 {
+			t.Errorf("%s cost = %g", r.Capability, r.Cost)
+		}
+	}
+}
+
+func TestInconclusive(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": {"message": "rate limited"}}`, http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	config := openai.DefaultConfig("key")
+	config.BaseURL = srv.URL
+	target := Target{Client: openai.NewClientWithConfig(config), Model: catwalk.Model{ID: "m", SupportsImages: true}}
+
+	r := Run(context.Background(), target, []string{Images}, Options{})[0]
+	if !r.Inconclusive || r.Works || r.Mismatch() {
+		t.Errorf("rate-limited probe = %+v", r)
+	}
+}
+
+func TestLongContextTokens(t *testing.T) {
+	m := catwalk.Model{ContextWindow: 100000}
+	if got := (Options{}).LongContextTokens(m); got != 90000 {
+		t.Errorf("default = %d", got)
+	}
+	if got := (Options{ContextFraction: 0.5}).LongContextTokens(m); got != 50000 {
+		t.Errorf("half = %d", got)
+	}
+}
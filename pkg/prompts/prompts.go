@@ -0,0 +1,215 @@
+// Package prompts is a library of system prompts. A few presets are built in;
+// more are Markdown files in a prompts directory, by default
+// ~/.config/aimodels/prompts, where the file name (without .md) is the
+// preset name and the file content is the prompt. Files override built-in
+// presets of the same name.
+package prompts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrNotFound is returned for a preset that is neither built in nor in the
+// prompts directory.
+var ErrNotFound = errors.New("preset not found")
+
+// ErrExists is returned by [Library.Add] when the preset file already exists.
+var ErrExists = errors.New("preset already exists")
+
+// Builtin are the presets available without a prompts directory.
+var Builtin = map[string]string{
+	"coding": `You are an expert software engineer. Give correct, idiomatic, and minimal code.
+Explain briefly why, not what. Point out bugs, edge cases, and security issues
+you notice. Ask when requirements are ambiguous instead of guessing.`,
+
+	"writing": `You are a careful editor. Help write clear, concise prose for the reader the
+user describes. Prefer plain words and short sentences, keep the author's voice,
+and explain significant changes.`,
+
+	"sql": `You are a database expert. Write correct, readable SQL for the dialect the user
+names (ask if unclear). Prefer explicit joins and column lists, mention the
+indexes a query needs, and warn about queries that modify or lock data.`,
+
+	"reviewer": `You are a senior code reviewer. Review the code or diff you are given for
+correctness, security, performance, and maintainability, in that order. Be
+specific: quote the line, explain the problem, and suggest a fix. Say so when
+the code is fine.`,
+}
+
+// Prompt is a preset.
+type Prompt struct {
+	Name string
+
+	// Path is the file the preset was read from; empty for built-in ones.
+	Path string
+
+	Content string
+}
+
+// IsBuiltin reports whether the preset is built in.
+func (p Prompt) IsBuiltin() bool {
+	return p.Path == ""
+}
+
+// Summary returns the first sentence of the prompt's first line, without
+// Markdown heading marks, for listings.
+func (p Prompt) Summary() string {
+	for _, line := range strings.Split(p.Content, "\n") {
+		if line = strings.TrimSpace(strings.TrimLeft(line, "#")); line != "" {
+			if i := strings.Index(line, ". "); i >= 0 {
+				line = line[:i+1]
+			}
+			return line
+		}
+	}
+	return ""
+}
+
+// DefaultDir returns the default prompts directory.
+func DefaultDir() (string, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine config directory: %w", err)
+	}
+	return filepath.Join(config, "aimodels", "prompts"), nil
+}
+
+// Library is the built-in presets plus the files in a prompts directory.
+type Library struct {
+	dir string
+}
+
+// Open returns the library for dir. The directory need not exist; an empty
+// dir means built-in presets only.
+func Open(dir string) *Library {
+	return &Library{dir: dir}
+}
+
+// OpenDefault returns the library for the default directory.
+func OpenDefault() (*Library, error) {
+	dir, err := DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return Open(dir), nil
+}
+
+// Dir returns the prompts directory.
+func (l *Library) Dir() string {
+	return l.dir
+}
+
+// List returns all presets sorted by name.
+func (l *Library) List() ([]Prompt, error) {
+	byName := map[string]Prompt{}
+	for name, content := range Builtin {
+		byName[name] = Prompt{Name: name, Content: content}
+	}
+
+	var files []string
+	if l.dir != "" {
+		var err error
+		files, err = filepath.Glob(filepath.Join(l.dir, "*.md"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list prompts: %w", err)
+		}
+	}
+	for _, path := range files {
+		p, err := read(path)
+		if err != nil {
+			return nil, err
+		}
+		byName[p.Name] = p
+	}
+
+	list := make([]Prompt, 0, len(byName))
+	for _, p := range byName {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Get returns the named preset.
+func (l *Library) Get(name string) (Prompt, error) {
+	if err := validName(name); err != nil {
+		return Prompt{}, err
+	}
+	if l.dir != "" {
+		p, err := read(filepath.Join(l.dir, name+".md"))
+		if err == nil {
+			return p, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return Prompt{}, err
+		}
+	}
+	if content, ok := Builtin[name]; ok {
+		return Prompt{Name: name, Content: content}, nil
+	}
+	return Prompt{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// Add saves content as the named preset, creating the prompts directory if
+// needed. An existing file is only replaced if overwrite is set.
+func (l *Library) Add(name, content string, overwrite bool) (Prompt, error) {
+	if err := validName(name); err != nil {
+		return Prompt{}, err
+	}
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return Prompt{}, errors.New("prompt is empty")
+	}
+	if l.dir == "" {
+		return Prompt{}, errors.New("no prompts directory")
+	}
+	if err := os.MkdirAll(l.dir, 0o755); err != nil {
+		return Prompt{}, fmt.Errorf("failed to create prompts directory: %w", err)
+	}
+
+	path := filepath.Join(l.dir, name+".md")
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return Prompt{}, fmt.Errorf("%w: %s", ErrExists, path)
+	}
+	if err != nil {
+		return Prompt{}, fmt.Errorf("failed to save prompt: %w", err)
+	}
+	if _, err := f.WriteString(content + "\n"); err != nil {
+		f.Close() //nolint:errcheck
+		return Prompt{}, fmt.Errorf("failed to save prompt: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return Prompt{}, fmt.Errorf("failed to save prompt: %w", err)
+	}
+	return Prompt{Name: name, Path: path, Content: content}, nil
+}
+
+func read(path string) (Prompt, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Prompt{}, fmt.Errorf("failed to read prompt: %w", err)
+	}
+	name := strings.TrimSuffix(filepath.Base(path), ".md")
+	return Prompt{Name: name, Path: path, Content: strings.TrimSpace(string(data))}, nil
+}
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// validName rejects names that are not safe file names.
+func validName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid preset name %q (use letters, digits, - and _)", name)
+	}
+	return nil
+}
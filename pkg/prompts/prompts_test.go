@@ -0,0 +1,60 @@
+package prompts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLibrary(t *testing.T) {
+	lib := Open(filepath.Join(t.TempDir(), "prompts"))
+
+	p, err := lib.Get("coding")
+	if err != nil || !p.IsBuiltin() || p.Summary() != "You are an expert software engineer." {
+		t.Fatalf("Get(coding) = %+v, %v", p, err)
+	}
+	if _, err := lib.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(missing) error = %v", err)
+	}
+	if _, err := lib.Get("../etc/passwd"); err == nil {
+		t.Error("expected invalid name error")
+	}
+
+	// A file overrides the built-in preset and adds new ones
+	if _, err := lib.Add("coding", "# Go\nWrite Go.", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lib.Add("coding", "again", false); !errors.Is(err, ErrExists) {
+		t.Errorf("Add existing error = %v", err)
+	}
+	if _, err := lib.Add("haiku", "Answer in haiku.", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(lib.Dir(), "notes.txt"), []byte("ignored"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err = lib.Get("coding")
+	if err != nil || p.IsBuiltin() || p.Content != "# Go\nWrite Go." || p.Summary() != "Go" {
+		t.Errorf("Get(coding) = %+v, %v", p, err)
+	}
+
+	list, err := lib.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range list {
+		names = append(names, p.Name)
+	}
+	want := []string{"coding", "haiku", "reviewer", "sql", "writing"}
+	if len(names) != len(want) {
+		t.Fatalf("List = %v; want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("List = %v; want %v", names, want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokCompare
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of query"
+	}
+	return strconv.Quote(t.text)
+}
+
+// lex splits src into tokens, ending with a tokEOF token.
+func lex(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+
+		case isIdentStart(c):
+			j := i
+			for j < len(src) && (isIdentStart(src[j]) || isDigit(src[j])) {
+				j++
+			}
+			toks = append(toks, token{kind: tokIdent, text: strings.ToLower(src[i:j]), pos: i})
+			i = j
+
+		case isDigit(c) || c == '.':
+			j := i
+			for j < len(src) && (isDigit(src[j]) || src[j] == '.' || src[j] == '_') {
+				j++
+			}
+			v, err := strconv.ParseFloat(src[i:j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", src[i:j], i+1)
+			}
+			toks = append(toks, token{kind: tokNumber, text: src[i:j], num: v, pos: i})
+			i = j
+
+		case c == '"' || c == '\'':
+			j := strings.IndexByte(src[i+1:], c)
+			if j < 0 {
+				return nil, fmt.Errorf("unterminated string at position %d", i+1)
+			}
+			toks = append(toks, token{kind: tokString, text: src[i+1 : i+1+j], pos: i})
+			i += j + 2
+
+		default:
+			t, n := lexOperator(src[i:])
+			if n == 0 {
+				return nil, fmt.Errorf("unexpected %q at position %d", c, i+1)
+			}
+			t.pos = i
+			toks = append(toks, t)
+			i += n
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// lexOperator returns the operator at the start of s and its length, or a
+// zero length if there is none.
+func lexOperator(s string) (token, int) {
+	for _, op := range []string{"<=", ">=", "==", "!=", "&&", "||"} {
+		if strings.HasPrefix(s, op) {
+			kind := tokCompare
+			switch op {
+			case "&&":
+				kind = tokAnd
+			case "||":
+				kind = tokOr
+			}
+			return token{kind: kind, text: op}, 2
+		}
+	}
+	switch s[0] {
+	case '<', '>', '~':
+		return token{kind: tokCompare, text: s[:1]}, 1
+	case '!':
+		return token{kind: tokNot, text: "!"}, 1
+	case '(':
+		return token{kind: tokLParen, text: "("}, 1
+	case ')':
+		return token{kind: tokRParen, text: ")"}, 1
+	}
+	return token{}, 0
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
@@ -0,0 +1,320 @@
+// Package query implements a small expression language for filtering
+// catalog models, such as:
+//
+//	cost_in < 1 && context >= 128000 && (reason || vision)
+//
+// Expressions combine comparisons (<, <=, >, >=, ==, !=, and ~ for
+// case-insensitive substring match) with &&, ||, !, and parentheses.
+// Operands are model fields (see [Fields]), numbers, quoted strings, and
+// true/false. Expressions are type-checked when parsed, so evaluation
+// cannot fail.
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// Expr is a parsed query.
+type Expr struct {
+	src  string
+	eval func(catwalk.Provider, catwalk.Model) bool
+}
+
+// Parse parses and type-checks a query expression.
+func Parse(src string) (*Expr, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	op, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "unexpected %s", t)
+	}
+	if op.kind != kindBool {
+		return nil, fmt.Errorf("expression is a %s, not true/false", op.kind)
+	}
+	return &Expr{src: src, eval: op.b}, nil
+}
+
+// Match reports whether model m of provider p satisfies the expression.
+func (e *Expr) Match(p catwalk.Provider, m catwalk.Model) bool {
+	return e.eval(p, m)
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string { return e.src }
+
+type kind int
+
+const (
+	kindNumber kind = iota
+	kindString
+	kindBool
+)
+
+func (k kind) String() string {
+	switch k {
+	case kindNumber:
+		return "number"
+	case kindString:
+		return "string"
+	default:
+		return "boolean"
+	}
+}
+
+// operand is a typed expression; only the function matching kind is set.
+type operand struct {
+	kind kind
+	num  func(catwalk.Provider, catwalk.Model) float64
+	str  func(catwalk.Provider, catwalk.Model) string
+	b    func(catwalk.Provider, catwalk.Model) bool
+}
+
+// field describes a model attribute available in queries.
+type field struct {
+	operand
+	help string
+}
+
+func numField(help string, f func(catwalk.Provider, catwalk.Model) float64) field {
+	return field{operand{kind: kindNumber, num: f}, help}
+}
+
+func strField(help string, f func(catwalk.Provider, catwalk.Model) string) field {
+	return field{operand{kind: kindString, str: f}, help}
+}
+
+func boolField(help string, f func(catwalk.Provider, catwalk.Model) bool) field {
+	return field{operand{kind: kindBool, b: f}, help}
+}
+
+var fields = map[string]field{
+	"id":       strField("Model ID", func(_ catwalk.Provider, m catwalk.Model) string { return m.ID }),
+	"name":     strField("Model name", func(_ catwalk.Provider, m catwalk.Model) string { return m.Name }),
+	"provider": strField("Provider ID", func(p catwalk.Provider, _ catwalk.Model) string { return string(p.ID) }),
+	"type":     strField("Provider type (openai, anthropic, ...)", func(p catwalk.Provider, _ catwalk.Model) string { return string(p.Type) }),
+
+	"cost_in":         numField("Cost per 1M input tokens", func(_ catwalk.Provider, m catwalk.Model) float64 { return m.CostPer1MIn }),
+	"cost_out":        numField("Cost per 1M output tokens", func(_ catwalk.Provider, m catwalk.Model) float64 { return m.CostPer1MOut }),
+	"cost_in_cached":  numField("Cost per 1M cached input tokens", func(_ catwalk.Provider, m catwalk.Model) float64 { return m.CostPer1MInCached }),
+	"cost_out_cached": numField("Cost per 1M cached output tokens", func(_ catwalk.Provider, m catwalk.Model) float64 { return m.CostPer1MOutCached }),
+	"context":         numField("Context window in tokens", func(_ catwalk.Provider, m catwalk.Model) float64 { return float64(m.ContextWindow) }),
+	"max_tokens":      numField("Default max output tokens", func(_ catwalk.Provider, m catwalk.Model) float64 { return float64(m.DefaultMaxTokens) }),
+
+	"reason": boolField("Supports reasoning", func(_ catwalk.Provider, m catwalk.Model) bool { return m.CanReason }),
+	"vision": boolField("Supports image attachments", func(_ catwalk.Provider, m catwalk.Model) bool { return m.SupportsImages }),
+}
+
+// Fields returns the names and descriptions of the fields usable in
+// queries, sorted by name.
+func Fields() [][2]string {
+	out := make([][2]string, 0, len(fields))
+	for name, f := range fields {
+		out = append(out, [2]string{name, f.help})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i][0] < out[j][0] })
+	return out
+}
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return fmt.Errorf("%s at position %d", fmt.Sprintf(format, args...), t.pos+1)
+}
+
+// parseOr parses: and ('||' and)*
+func (p *parser) parseOr() (operand, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return left, err
+	}
+	for p.peek().kind == tokOr {
+		t := p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return right, err
+		}
+		if left.kind != kindBool || right.kind != kindBool {
+			return left, p.errorf(t, "|| needs boolean operands")
+		}
+		l, r := left.b, right.b
+		left = operand{kind: kindBool, b: func(pr catwalk.Provider, m catwalk.Model) bool { return l(pr, m) || r(pr, m) }}
+	}
+	return left, nil
+}
+
+// parseAnd parses: unary ('&&' unary)*
+func (p *parser) parseAnd() (operand, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return left, err
+	}
+	for p.peek().kind == tokAnd {
+		t := p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return right, err
+		}
+		if left.kind != kindBool || right.kind != kindBool {
+			return left, p.errorf(t, "&& needs boolean operands")
+		}
+		l, r := left.b, right.b
+		left = operand{kind: kindBool, b: func(pr catwalk.Provider, m catwalk.Model) bool { return l(pr, m) && r(pr, m) }}
+	}
+	return left, nil
+}
+
+// parseUnary parses: '!' unary | comparison
+func (p *parser) parseUnary() (operand, error) {
+	if p.peek().kind != tokNot {
+		return p.parseComparison()
+	}
+	t := p.next()
+	x, err := p.parseUnary()
+	if err != nil {
+		return x, err
+	}
+	if x.kind != kindBool {
+		return x, p.errorf(t, "! needs a boolean operand")
+	}
+	f := x.b
+	return operand{kind: kindBool, b: func(pr catwalk.Provider, m catwalk.Model) bool { return !f(pr, m) }}, nil
+}
+
+// parseComparison parses: primary (op primary)?
+func (p *parser) parseComparison() (operand, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return left, err
+	}
+	t := p.peek()
+	if t.kind != tokCompare {
+		return left, nil
+	}
+	p.next()
+	right, err := p.parsePrimary()
+	if err != nil {
+		return right, err
+	}
+	if left.kind != right.kind {
+		return left, p.errorf(t, "cannot compare %s with %s", left.kind, right.kind)
+	}
+	return compare(p, t, left, right)
+}
+
+func compare(p *parser, t token, left, right operand) (operand, error) {
+	op := t.text
+	switch left.kind {
+	case kindNumber:
+		if op == "~" {
+			return left, p.errorf(t, "~ needs string operands")
+		}
+		l, r := left.num, right.num
+		return operand{kind: kindBool, b: func(pr catwalk.Provider, m catwalk.Model) bool {
+			a, b := l(pr, m), r(pr, m)
+			switch op {
+			case "<":
+				return a < b
+			case "<=":
+				return a <= b
+			case ">":
+				return a > b
+			case ">=":
+				return a >= b
+			case "==":
+				return a == b
+			default:
+				return a != b
+			}
+		}}, nil
+
+	case kindString:
+		l, r := left.str, right.str
+		switch op {
+		case "==", "!=", "~":
+		default:
+			return left, p.errorf(t, "%s cannot compare strings; use ==, != or ~", op)
+		}
+		return operand{kind: kindBool, b: func(pr catwalk.Provider, m catwalk.Model) bool {
+			a, b := strings.ToLower(l(pr, m)), strings.ToLower(r(pr, m))
+			switch op {
+			case "==":
+				return a == b
+			case "!=":
+				return a != b
+			default:
+				return strings.Contains(a, b)
+			}
+		}}, nil
+
+	default:
+		if op != "==" && op != "!=" {
+			return left, p.errorf(t, "%s cannot compare booleans; use == or !=", op)
+		}
+		l, r := left.b, right.b
+		return operand{kind: kindBool, b: func(pr catwalk.Provider, m catwalk.Model) bool {
+			return (l(pr, m) == r(pr, m)) == (op == "==")
+		}}, nil
+	}
+}
+
+// parsePrimary parses: '(' or ')' | number | string | true | false | field
+func (p *parser) parsePrimary() (operand, error) {
+	t := p.next()
+	switch t.kind {
+	case tokLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return x, err
+		}
+		if c := p.next(); c.kind != tokRParen {
+			return x, p.errorf(c, "expected ) but found %s", c)
+		}
+		return x, nil
+
+	case tokNumber:
+		v := t.num
+		return operand{kind: kindNumber, num: func(catwalk.Provider, catwalk.Model) float64 { return v }}, nil
+
+	case tokString:
+		v := t.text
+		return operand{kind: kindString, str: func(catwalk.Provider, catwalk.Model) string { return v }}, nil
+
+	case tokIdent:
+		switch t.text {
+		case "true", "false":
+			v := t.text == "true"
+			return operand{kind: kindBool, b: func(catwalk.Provider, catwalk.Model) bool { return v }}, nil
+		}
+		f, ok := fields[t.text]
+		if !ok {
+			return operand{}, p.errorf(t, "unknown field %q", t.text)
+		}
+		return f.operand, nil
+
+	default:
+		return operand{}, p.errorf(t, "unexpected %s", t)
+	}
+}
@@ -0,0 +1,59 @@
+package query
+
+import (
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+func TestMatch(t *testing.T) {
+	p := catwalk.Provider{ID: "openai", Type: catwalk.TypeOpenAI}
+	m := catwalk.Model{
+		ID:             "gpt-4o",
+		Name:           "GPT-4o",
+		CostPer1MIn:    2.5,
+		CostPer1MOut:   10,
+		ContextWindow:  128_000,
+		SupportsImages: true,
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"cost_in < 1 && context >= 128000 && (reason || vision)", false},
+		{"cost_in < 3 && context >= 128_000 && (reason || vision)", true},
+		{"!reason && vision", true},
+		{"reason == false", true},
+		{"provider == 'OpenAI' && id ~ \"4o\"", true},
+		{"name != 'gpt-4o'", false},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.query)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.query, err)
+		}
+		if got := e.Match(p, m); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, q := range []string{
+		"",
+		"cost_in",
+		"cost_in < 'a'",
+		"id < 'a'",
+		"reason && 1",
+		"(reason",
+		"unknown > 1",
+		"id == 'open",
+		"reason vision",
+		"cost_out / 2",
+	} {
+		if _, err := Parse(q); err == nil {
+			t.Errorf("Parse(%q): expected error", q)
+		}
+	}
+}
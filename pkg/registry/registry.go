@@ -0,0 +1,470 @@
+// Package registry merges local overrides over catalog data, so cost
+// reports reflect what a company actually pays rather than list prices.
+// Overrides are YAML files:
+//
+//	providers:
+//	  openai:
+//	    discount: 0.15                  # 15% off every list price
+//	    rate_limit: {requests: 500, per: 1m}
+//	    models:
+//	      gpt-4o:
+//	        cost_per_1m_in: 2.00        # negotiated; not discounted again
+//	        cost_per_1m_out: 8.00
+//	        rate_limit: {requests: 60, tokens: 150000, per: 1m}
+//	        latency_tier: fast          # realtime, fast, standard, or batch
+//	      gpt-4-turbo:
+//	        disabled: true
+//	  venice:
+//	    disabled: true
+//	  groq:
+//	    quota: {period: daily, requests: 14400, tokens: 500000}
+//	  huggingface:
+//	    api_key_env: HUGGINGFACE_API_KEY  # instead of the catalog's HF_TOKEN
+//
+// By default the file is read from <user config dir>/aimodels/overrides.yaml.
+// cmd/probe records what it finds under a model's probe key; see [Probe].
+package registry
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"time"
+
+	"charm.land/catwalk/pkg/budget"
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/selector"
+	"go.yaml.in/yaml/v2"
+)
+
+// Overrides replaces catalog data for some providers and models. A nil
+// *Overrides leaves the catalog unchanged.
+type Overrides struct {
+	Providers map[catwalk.InferenceProvider]ProviderOverride `yaml:"providers,omitempty"`
+
+	// Path is the file the overrides were loaded from.
+	Path string `yaml:"-"`
+}
+
+// ProviderOverride adjusts one provider and its models.
+type ProviderOverride struct {
+	// Disabled removes the provider from the catalog.
+	Disabled bool `yaml:"disabled,omitempty"`
+
+	// Discount is the fraction taken off every list price of the
+	// provider's models, such as 0.15 for 15% off. Model prices set in
+	// Models are used as given.
+	Discount float64 `yaml:"discount,omitempty"`
+
+	// RateLimit applies to models without their own.
+	RateLimit *Limit `yaml:"rate_limit,omitempty"`
+
+	// Quota caps the provider's requests and tokens per day or month, as
+	// tracked by a [budget.QuotaTracker].
+	Quota *budget.Quota `yaml:"quota,omitempty"`
+
+	// APIKeyEnv is the environment variable holding the provider's API
+	// key, in place of the one the catalog names.
+	APIKeyEnv string `yaml:"api_key_env,omitempty"`
+
+	Models map[string]ModelOverride `yaml:"models,omitempty"`
+}
+
+// ModelOverride adjusts one model. Unset fields keep the catalog's values.
+type ModelOverride struct {
+	// Disabled removes the model from the catalog.
+	Disabled bool `yaml:"disabled,omitempty"`
+
+	CostPer1MIn        *float64 `yaml:"cost_per_1m_in,omitempty"`
+	CostPer1MOut       *float64 `yaml:"cost_per_1m_out,omitempty"`
+	CostPer1MInCached  *float64 `yaml:"cost_per_1m_in_cached,omitempty"`
+	CostPer1MOutCached *float64 `yaml:"cost_per_1m_out_cached,omitempty"`
+	ContextWindow      *int64   `yaml:"context_window,omitempty"`
+	DefaultMaxTokens   *int64   `yaml:"default_max_tokens,omitempty"`
+
+	RateLimit *Limit `yaml:"rate_limit,omitempty"`
+
+	// LatencyTier records how quickly the model answers in practice,
+	// which the catalog does not; see [Overrides.LatencyTier].
+	LatencyTier selector.LatencyTier `yaml:"latency_tier,omitempty"`
+
+	Probe *Probe `yaml:"probe,omitempty"`
+}
+
+// Probe records which capabilities cmd/probe found working on the
+// provider's endpoint. Nil fields were not probed. Images replaces the
+// catalog's image support; the others are kept for reference, since the
+// catalog does not record them.
+type Probe struct {
+	At          time.Time `yaml:"at"`
+	Images      *bool     `yaml:"images,omitempty"`
+	Tools       *bool     `yaml:"tools,omitempty"`
+	JSONSchema  *bool     `yaml:"json_schema,omitempty"`
+	LongContext *bool     `yaml:"long_context,omitempty"`
+
+	// ContextTokens is the prompt size the long-context probe sent.
+	ContextTokens int64 `yaml:"context_tokens,omitempty"`
+}
+
+// Limit is a rate limit: at most Requests requests start in any Per, and
+// at most Tokens tokens, prompts and replies together, are used in it.
+// Either may be zero for no limit on that count.
+type Limit struct {
+	Requests int           `yaml:"requests,omitempty"`
+	Per      time.Duration `yaml:"per"`
+	Tokens   int           `yaml:"tokens,omitempty"`
+}
+
+// Middleware returns chat middleware that enforces the request limit. To
+// keep the token limit too, set [Limit.Chat] on a [chat.Scheduler].
+func (l Limit) Middleware() chat.Middleware {
+	if l.Requests <= 0 {
+		return func(next chat.Handler) chat.Handler { return next }
+	}
+	return chat.RateLimit(l.Requests, l.Per)
+}
+
+// Chat returns the limit for a [chat.Scheduler].
+func (l Limit) Chat() chat.Limit {
+	return chat.Limit{Requests: l.Requests, Tokens: l.Tokens, Per: l.Per}
+}
+
+// String returns the limit as, e.g., "60/1m0s", or with a token limit
+// "60 requests, 150000 tokens/1m0s".
+func (l Limit) String() string {
+	if l.Tokens > 0 {
+		return fmt.Sprintf("%d requests, %d tokens/%s", l.Requests, l.Tokens, l.Per)
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Per)
+}
+
+// DefaultPath returns the default overrides file,
+// <user config dir>/aimodels/overrides.yaml.
+func DefaultPath() (string, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine config directory: %w", err)
+	}
+	return filepath.Join(config, "aimodels", "overrides.yaml"), nil
+}
+
+// Load reads and validates an overrides file.
+func Load(path string) (*Overrides, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read overrides: %w", err)
+	}
+	o, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	o.Path = path
+	return o, nil
+}
+
+// Open loads the overrides file at path, or at DefaultPath if path is
+// empty. A missing default file is not an error: Open returns nil.
+func Open(path string) (*Overrides, error) {
+	if path != "" {
+		return Load(path)
+	}
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return Load(path)
+}
+
+// Save writes the overrides to path as YAML, creating its directory if
+// needed. Comments in an existing file are not kept.
+func (o *Overrides) Save(path string) error {
+	data, err := yaml.Marshal(o)
+	if err != nil {
+		return fmt.Errorf("failed to encode overrides: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create overrides directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write overrides: %w", err)
+	}
+	o.Path = path
+	return nil
+}
+
+// SetProbe records probe results for a model, keeping earlier results for
+// capabilities p did not probe.
+func (o *Overrides) SetProbe(provider catwalk.InferenceProvider, model string, p Probe) {
+	if o.Providers == nil {
+		o.Providers = map[catwalk.InferenceProvider]ProviderOverride{}
+	}
+	po := o.Providers[provider]
+	if po.Models == nil {
+		po.Models = map[string]ModelOverride{}
+	}
+	mo := po.Models[model]
+	if old := mo.Probe; old != nil {
+		p.Images = cmp.Or(p.Images, old.Images)
+		p.Tools = cmp.Or(p.Tools, old.Tools)
+		p.JSONSchema = cmp.Or(p.JSONSchema, old.JSONSchema)
+		if p.LongContext == nil {
+			p.LongContext, p.ContextTokens = old.LongContext, old.ContextTokens
+		}
+	}
+	mo.Probe = &p
+	po.Models[model] = mo
+	o.Providers[provider] = po
+}
+
+// Parse parses and validates overrides in YAML. Unknown fields are errors,
+// so a misspelled price is not silently ignored.
+func Parse(data []byte) (*Overrides, error) {
+	var o Overrides
+	if err := yaml.UnmarshalStrict(data, &o); err != nil {
+		return nil, fmt.Errorf("invalid overrides: %w", err)
+	}
+	for id, p := range o.Providers {
+		if p.Discount < 0 || p.Discount >= 1 {
+			return nil, fmt.Errorf("%s: discount must be at least 0 and below 1, got %g", id, p.Discount)
+		}
+		if err := p.RateLimit.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", id, err)
+		}
+		if p.Quota != nil {
+			if err := p.Quota.Validate(); err != nil {
+				return nil, fmt.Errorf("%s: %w", id, err)
+			}
+		}
+		if p.APIKeyEnv != "" && !envName.MatchString(p.APIKeyEnv) {
+			return nil, fmt.Errorf("%s: api_key_env must be an environment variable name, got %q", id, p.APIKeyEnv)
+		}
+		for model, m := range p.Models {
+			if err := m.validate(); err != nil {
+				return nil, fmt.Errorf("%s/%s: %w", id, model, err)
+			}
+		}
+	}
+	return &o, nil
+}
+
+// envName matches environment variable names.
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (l *Limit) validate() error {
+	if l != nil && (l.Requests < 0 || l.Tokens < 0 || l.Requests+l.Tokens == 0 || l.Per <= 0) {
+		return fmt.Errorf("rate_limit needs positive requests, tokens, or both, and a positive per, got %d requests and %d tokens per %s", l.Requests, l.Tokens, l.Per)
+	}
+	return nil
+}
+
+func (m ModelOverride) validate() error {
+	for _, price := range []*float64{m.CostPer1MIn, m.CostPer1MOut, m.CostPer1MInCached, m.CostPer1MOutCached} {
+		if price != nil && *price < 0 {
+			return fmt.Errorf("prices cannot be negative, got %g", *price)
+		}
+	}
+	if m.ContextWindow != nil && *m.ContextWindow <= 0 {
+		return fmt.Errorf("context_window must be positive, got %d", *m.ContextWindow)
+	}
+	if m.DefaultMaxTokens != nil && *m.DefaultMaxTokens <= 0 {
+		return fmt.Errorf("default_max_tokens must be positive, got %d", *m.DefaultMaxTokens)
+	}
+	if m.LatencyTier != "" && !slices.Contains(selector.LatencyTiers, m.LatencyTier) {
+		return fmt.Errorf("latency_tier must be realtime, fast, standard, or batch, got %q", m.LatencyTier)
+	}
+	return m.RateLimit.validate()
+}
+
+// Apply returns a copy of providers with the overrides merged in: disabled
+// providers and models are removed, discounts applied, API keys read from
+// the overridden variables, and overridden fields replaced. The input is not modified. A provider's default model
+// IDs are kept even if that model is disabled.
+func (o *Overrides) Apply(providers []catwalk.Provider) []catwalk.Provider {
+	if o == nil {
+		return providers
+	}
+	out := make([]catwalk.Provider, 0, len(providers))
+	for _, p := range providers {
+		po, ok := o.Providers[p.ID]
+		if !ok {
+			out = append(out, p)
+			continue
+		}
+		if po.Disabled {
+			continue
+		}
+		models := make([]catwalk.Model, 0, len(p.Models))
+		for _, m := range p.Models {
+			mo := po.Models[m.ID]
+			if mo.Disabled {
+				continue
+			}
+			models = append(models, mo.apply(discount(m, po.Discount)))
+		}
+		p.Models = models
+		if po.APIKeyEnv != "" {
+			p.APIKey = "$" + po.APIKeyEnv
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// discount takes fraction d off all of m's prices.
+func discount(m catwalk.Model, d float64) catwalk.Model {
+	if d == 0 {
+		return m
+	}
+	f := 1 - d
+	m.CostPer1MIn *= f
+	m.CostPer1MOut *= f
+	m.CostPer1MInCached *= f
+	m.CostPer1MOutCached *= f
+	if m.ImagePricing != nil {
+		m.ImagePricing = slices.Clone(m.ImagePricing)
+		for i := range m.ImagePricing {
+			m.ImagePricing[i].Cost *= f
+		}
+	}
+	if m.AudioPricing != nil {
+		a := *m.AudioPricing
+		a.CostPerMinuteIn *= f
+		a.CostPerMinuteOut *= f
+		a.CostPer1MIn *= f
+		a.CostPer1MOut *= f
+		m.AudioPricing = &a
+	}
+	return m
+}
+
+func (mo ModelOverride) apply(m catwalk.Model) catwalk.Model {
+	set := func(dst *float64, v *float64) {
+		if v != nil {
+			*dst = *v
+		}
+	}
+	set(&m.CostPer1MIn, mo.CostPer1MIn)
+	set(&m.CostPer1MOut, mo.CostPer1MOut)
+	set(&m.CostPer1MInCached, mo.CostPer1MInCached)
+	set(&m.CostPer1MOutCached, mo.CostPer1MOutCached)
+	if mo.ContextWindow != nil {
+		m.ContextWindow = *mo.ContextWindow
+	}
+	if mo.DefaultMaxTokens != nil {
+		m.DefaultMaxTokens = *mo.DefaultMaxTokens
+	}
+	if mo.Probe != nil && mo.Probe.Images != nil {
+		m.SupportsImages = *mo.Probe.Images
+	}
+	return m
+}
+
+// Probed reports whether cmd/probe found feature f working on a model, and
+// whether it probed f at all. JSON mode counts as working if a JSON schema
+// response format did. Its signature fits [chat.Probed].
+func (o *Overrides) Probed(provider catwalk.InferenceProvider, model string, f catwalk.Feature) (works, known bool) {
+	if o == nil {
+		return false, false
+	}
+	p := o.Providers[provider].Models[model].Probe
+	if p == nil {
+		return false, false
+	}
+	var result *bool
+	switch f {
+	case catwalk.FeatureImages:
+		result = p.Images
+	case catwalk.FeatureTools:
+		result = p.Tools
+	case catwalk.FeatureJSONMode:
+		result = p.JSONSchema
+	}
+	if result == nil {
+		return false, false
+	}
+	return *result, true
+}
+
+// Limit returns the rate limit for a model: its own, else its provider's.
+func (o *Overrides) Limit(provider catwalk.InferenceProvider, model string) (Limit, bool) {
+	if o == nil {
+		return Limit{}, false
+	}
+	po := o.Providers[provider]
+	if l := po.Models[model].RateLimit; l != nil {
+		return *l, true
+	}
+	if po.RateLimit != nil {
+		return *po.RateLimit, true
+	}
+	return Limit{}, false
+}
+
+// Quotas returns the providers' quotas, for [budget.NewQuotas].
+func (o *Overrides) Quotas() map[catwalk.InferenceProvider]budget.Quota {
+	if o == nil {
+		return nil
+	}
+	quotas := map[catwalk.InferenceProvider]budget.Quota{}
+	for id, po := range o.Providers {
+		if po.Quota != nil {
+			quotas[id] = *po.Quota
+		}
+	}
+	return quotas
+}
+
+// APIKeyEnv returns the environment variable the overrides name for the
+// provider's API key, if any. Apply already uses it; this is for programs
+// that take only some of the overrides.
+func (o *Overrides) APIKeyEnv(provider catwalk.InferenceProvider) (string, bool) {
+	if o == nil {
+		return "", false
+	}
+	env := o.Providers[provider].APIKeyEnv
+	return env, env != ""
+}
+
+// LatencyTier returns the latency tier recorded for a model, if any.
+func (o *Overrides) LatencyTier(provider catwalk.InferenceProvider, model string) (selector.LatencyTier, bool) {
+	if o == nil {
+		return "", false
+	}
+	t := o.Providers[provider].Models[model].LatencyTier
+	return t, t != ""
+}
+
+// Unmatched returns the overridden providers and models, as "provider" or
+// "provider/model", that are not in providers, sorted. IDs match exactly, as
+// in Apply; unmatched entries usually are typos or models the catalog has
+// since dropped.
+func (o *Overrides) Unmatched(providers []catwalk.Provider) []string {
+	if o == nil {
+		return nil
+	}
+	var missing []string
+	for id, po := range o.Providers {
+		i := slices.IndexFunc(providers, func(p catwalk.Provider) bool { return p.ID == id })
+		if i < 0 {
+			missing = append(missing, string(id))
+			continue
+		}
+		p := providers[i]
+		for model := range po.Models {
+			if !slices.ContainsFunc(p.Models, func(m catwalk.Model) bool { return m.ID == model }) {
+				missing = append(missing, string(id)+"/"+model)
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
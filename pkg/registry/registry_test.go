@@ -0,0 +1,221 @@
+package registry
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"charm.land/catwalk/pkg/budget"
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/selector"
+)
+
+const sample = `
+providers:
+  openai:
+    discount: 0.2
+    api_key_env: WORK_OPENAI_KEY
+    rate_limit: {requests: 500, per: 1m}
+    models:
+      gpt-4o:
+        cost_per_1m_in: 2
+        context_window: 64000
+        rate_limit: {requests: 60, tokens: 150000, per: 1m}
+        latency_tier: fast
+      gpt-4-turbo:
+        disabled: true
+      gpt-9:
+        cost_per_1m_in: 1
+  venice:
+    disabled: true
+  nowhere:
+    quota: {period: daily, requests: 100}
+`
+
+func catalog() []catwalk.Provider {
+	return []catwalk.Provider{
+		{ID: "openai", APIKey: "$OPENAI_API_KEY", Models: []catwalk.Model{
+			{ID: "gpt-4o", CostPer1MIn: 2.5, CostPer1MOut: 10, ContextWindow: 128000, SupportsImages: true},
+			{ID: "gpt-4-turbo", CostPer1MIn: 10, CostPer1MOut: 30},
+			{ID: "gpt-4o-mini", CostPer1MIn: 0.15, CostPer1MOut: 0.6, AudioPricing: &catwalk.AudioPricing{CostPerMinuteIn: 0.1}},
+		}},
+		{ID: "venice", Models: []catwalk.Model{{ID: "v"}}},
+		{ID: "groq", Models: []catwalk.Model{{ID: "llama", CostPer1MIn: 0.05}}},
+	}
+}
+
+func TestApply(t *testing.T) {
+	o, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := catalog()
+	out := o.Apply(in)
+
+	if len(out) != 2 || out[0].ID != "openai" || out[1].ID != "groq" {
+		t.Fatalf("providers = %+v", out)
+	}
+	models := out[0].Models
+	if len(models) != 2 || models[0].ID != "gpt-4o" || models[1].ID != "gpt-4o-mini" {
+		t.Fatalf("models = %+v", models)
+	}
+	// Overridden prices are used as given; the rest are discounted
+	if m := models[0]; m.CostPer1MIn != 2 || m.CostPer1MOut != 8 || m.ContextWindow != 64000 {
+		t.Errorf("gpt-4o = %+v", m)
+	}
+	if m := models[1]; math.Abs(m.CostPer1MIn-0.12) > 1e-9 || math.Abs(m.AudioPricing.CostPerMinuteIn-0.08) > 1e-9 {
+		t.Errorf("gpt-4o-mini = %+v", m)
+	}
+	// The key is read from the overridden variable
+	if env := out[0].APIKeyEnv(); env != "WORK_OPENAI_KEY" || in[0].APIKeyEnv() != "OPENAI_API_KEY" {
+		t.Errorf("key variable = %s, input %s", env, in[0].APIKeyEnv())
+	}
+	if env, ok := o.APIKeyEnv("groq"); ok {
+		t.Errorf("APIKeyEnv(groq) = %s", env)
+	}
+	if out[1].Models[0].CostPer1MIn != 0.05 {
+		t.Errorf("groq = %+v", out[1])
+	}
+	// The input is left alone
+	if in[0].Models[0].CostPer1MIn != 2.5 || in[0].Models[2].AudioPricing.CostPerMinuteIn != 0.1 || len(in[0].Models) != 3 {
+		t.Errorf("input modified: %+v", in[0])
+	}
+
+	if got := strings.Join(o.Unmatched(in), ","); got != "nowhere,openai/gpt-9" {
+		t.Errorf("Unmatched = %s", got)
+	}
+
+	var none *Overrides
+	if got := none.Apply(in); len(got) != 3 {
+		t.Errorf("nil Apply = %+v", got)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	o, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		provider catwalk.InferenceProvider
+		model    string
+		want     Limit
+		ok       bool
+	}{
+		{"openai", "gpt-4o", Limit{Requests: 60, Tokens: 150000, Per: time.Minute}, true},
+		{"openai", "gpt-4o-mini", Limit{Requests: 500, Per: time.Minute}, true},
+		{"groq", "llama", Limit{}, false},
+	} {
+		got, ok := o.Limit(tt.provider, tt.model)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Limit(%s, %s) = %v, %v", tt.provider, tt.model, got, ok)
+		}
+	}
+
+	if tier, ok := o.LatencyTier("openai", "gpt-4o"); !ok || tier != selector.LatencyFast {
+		t.Errorf("LatencyTier(openai, gpt-4o) = %q, %v", tier, ok)
+	}
+	if _, ok := o.LatencyTier("openai", "gpt-4o-mini"); ok {
+		t.Error("expected no latency tier for gpt-4o-mini")
+	}
+
+	if q := o.Quotas(); len(q) != 1 || q["nowhere"].Requests != 100 || q["nowhere"].Period != budget.Daily {
+		t.Errorf("Quotas = %+v", q)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tt := range []struct {
+		yaml, want string
+	}{
+		{"providers: {openai: {models: {m: {cost_per_1m: 1}}}}", "cost_per_1m"},
+		{"providers: {openai: {discount: 1.5}}", "discount"},
+		{"providers: {openai: {models: {m: {cost_per_1m_out: -1}}}}", "openai/m: prices cannot be negative"},
+		{"providers: {openai: {rate_limit: {requests: 10}}}", "rate_limit"},
+		{"providers: {openai: {rate_limit: {requests: 10, per: soon}}}", "soon"},
+		{"providers: {openai: {rate_limit: {per: 1m}}}", "requests, tokens, or both"},
+		{"providers: {openai: {rate_limit: {tokens: -5, per: 1m}}}", "rate_limit"},
+		{"providers: {openai: {models: {m: {latency_tier: slow}}}}", "latency_tier"},
+		{"providers: {groq: {quota: {period: hourly, requests: 1}}}", "groq: quota: unknown period"},
+		{"providers: {groq: {quota: {period: daily}}}", "set requests, tokens, or both"},
+		{"providers: {groq: {api_key_env: $GROQ_KEY}}", "groq: api_key_env"},
+	} {
+		if _, err := Parse([]byte(tt.yaml)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%s) error = %v, want %q", tt.yaml, err, tt.want)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	if o, err := Open(""); o != nil || err != nil {
+		t.Fatalf("Open without a default file = %v, %v", o, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "overrides.yaml")
+	if err := os.WriteFile(path, []byte(sample), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	o, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Path != path || !o.Providers["venice"].Disabled {
+		t.Errorf("Open = %+v", o)
+	}
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Open of a missing file succeeded")
+	}
+}
+
+func TestSaveProbe(t *testing.T) {
+	o, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	yes, no := true, false
+	o.SetProbe("openai", "gpt-4o", Probe{Images: &no, Tools: &yes})
+	o.SetProbe("openai", "gpt-4o", Probe{JSONSchema: &yes})
+	o.SetProbe("groq", "llama", Probe{Images: &yes})
+
+	path := filepath.Join(t.TempDir(), "sub", "overrides.yaml")
+	if err := o.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "null") || !strings.Contains(string(data), "per: 1m0s") {
+		t.Errorf("saved:\n%s", data)
+	}
+	o, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := o.Providers["openai"].Models["gpt-4o"].Probe
+	if p == nil || *p.Images || !*p.Tools || !*p.JSONSchema || p.LongContext != nil {
+		t.Errorf("gpt-4o probe = %+v", p)
+	}
+	if o.Providers["openai"].Models["gpt-4o"].CostPer1MIn == nil {
+		t.Error("gpt-4o price lost")
+	}
+	out := o.Apply(catalog())
+	if out[0].Models[0].SupportsImages {
+		t.Error("gpt-4o still supports images")
+	}
+	if !out[1].Models[0].SupportsImages {
+		t.Error("llama does not support images")
+	}
+	if works, known := o.Probed("openai", "gpt-4o", catwalk.FeatureJSONMode); !works || !known {
+		t.Errorf("gpt-4o JSON mode probed = %v, %v", works, known)
+	}
+	if _, known := o.Probed("groq", "llama", catwalk.FeatureTools); known {
+		t.Error("llama tools probed")
+	}
+}
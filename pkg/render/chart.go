@@ -0,0 +1,193 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Sparkline heights, lowest first. Zero is drawn as a space so days with
+// no activity read as gaps.
+var (
+	sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+	sparkASCII  = []rune("._-~=+*#")
+)
+
+// Sparkline draws values as a row of bars scaled to the largest, one cell
+// per value. Negative values count as zero.
+func Sparkline(values []float64) string {
+	levels := sparkBlocks
+	if Plain() {
+		levels = sparkASCII
+	}
+	top := 0.0
+	for _, v := range values {
+		top = max(top, v)
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		if v <= 0 || top == 0 {
+			sb.WriteByte(' ')
+			continue
+		}
+		i := int(math.Ceil(v/top*float64(len(levels)))) - 1
+		sb.WriteRune(levels[min(max(i, 0), len(levels)-1)])
+	}
+	return sb.String()
+}
+
+// Bar draws a progress bar width cells wide, filled to fraction (clamped to
+// 0–1).
+func Bar(fraction float64, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	filled := int(math.Round(min(max(fraction, 0), 1) * float64(width)))
+	return strings.Repeat(Symbol("█", "#"), filled) + strings.Repeat(Symbol("░", "-"), width-filled)
+}
+
+// brailleDots are the bits of a braille cell's dots, by column and then row
+// from the top: each cell is 2 dots wide and 4 tall.
+var brailleDots = [2][4]rune{{0x01, 0x02, 0x04, 0x40}, {0x08, 0x10, 0x20, 0x80}}
+
+// Plot draws values as a line in a grid width cells wide and height rows
+// tall, scaled from zero at the bottom to the largest value at the top,
+// with the values spread evenly across the width and each joined to the
+// next. Braille dots give each cell 2 by 4 points; plain terminals get one
+// * per cell. Negative values count as zero.
+func Plot(values []float64, width, height int) []string {
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+	dx, dy := 2, 4
+	if Plain() {
+		dx, dy = 1, 1
+	}
+	cols, rows := width*dx, height*dy
+	dots := make([][]bool, rows)
+	for i := range dots {
+		dots[i] = make([]bool, cols)
+	}
+
+	top := 0.0
+	for _, v := range values {
+		top = max(top, v)
+	}
+	level := func(v float64) int {
+		if v <= 0 || top == 0 {
+			return 0
+		}
+		return int(math.Round(v / top * float64(rows-1)))
+	}
+	column := func(i int) int {
+		if len(values) == 1 {
+			return 0
+		}
+		return int(math.Round(float64(i) * float64(cols-1) / float64(len(values)-1)))
+	}
+
+	// Each dot column between two points takes the interpolated level, and
+	// fills toward the previous column's so steep rises stay joined
+	var prev int
+	for i, v := range values {
+		x, y := column(i), level(v)
+		if i == 0 {
+			prev = y
+		}
+		x0, y0 := x, prev
+		if i > 0 {
+			x0, y0 = column(i-1), level(values[i-1])
+		}
+		if x == x0 {
+			// The first value, or more values than columns
+			for r := min(prev, y); r <= max(prev, y); r++ {
+				dots[rows-1-r][x] = true
+			}
+			prev = y
+			continue
+		}
+		for c := x0 + 1; c <= x; c++ {
+			yc := y0 + int(math.Round(float64(y-y0)*float64(c-x0)/float64(x-x0)))
+			from := prev
+			switch {
+			case yc > prev:
+				from = prev + 1
+			case yc < prev:
+				from = prev - 1
+			}
+			for r := min(from, yc); r <= max(from, yc); r++ {
+				dots[rows-1-r][c] = true
+			}
+			prev = yc
+		}
+	}
+
+	lines := make([]string, height)
+	for row := range lines {
+		var sb strings.Builder
+		for cell := 0; cell < width; cell++ {
+			if dx == 1 {
+				if dots[row][cell] {
+					sb.WriteByte('*')
+				} else {
+					sb.WriteByte(' ')
+				}
+				continue
+			}
+			var bits rune
+			for c := 0; c < dx; c++ {
+				for r := 0; r < dy; r++ {
+					if dots[row*dy+r][cell*dx+c] {
+						bits |= brailleDots[c][r]
+					}
+				}
+			}
+			if bits == 0 {
+				sb.WriteByte(' ')
+			} else {
+				sb.WriteRune(0x2800 + bits)
+			}
+		}
+		lines[row] = sb.String()
+	}
+	return lines
+}
+
+// LineChart draws values as a [Plot] fitting width cells, with a y axis
+// marked by label at zero and at the largest value, and an x axis numbered
+// from 1 to len(values).
+func LineChart(values []float64, width, height int, label func(float64) string) []string {
+	top := 0.0
+	for _, v := range values {
+		top = max(top, v)
+	}
+	high, low := label(top), label(0)
+	pad := max(len(high), len(low))
+	plotWidth := max(width-pad-2, 1)
+	plot := Plot(values, plotWidth, height)
+	if len(plot) == 0 {
+		return nil
+	}
+
+	lines := make([]string, 0, len(plot)+2)
+	for i, row := range plot {
+		mark, axis := "", Symbol("│", "|")
+		switch i {
+		case 0:
+			mark, axis = high, Symbol("┤", "+")
+		case len(plot) - 1:
+			mark, axis = low, Symbol("┤", "+")
+		}
+		lines = append(lines, fmt.Sprintf("%*s %s%s", pad, mark, axis, row))
+	}
+	lines = append(lines, fmt.Sprintf("%*s %s%s", pad, "", Symbol("└", "+"), strings.Repeat(Symbol("─", "-"), plotWidth)))
+	last := strconv.Itoa(len(values))
+	axis := "1"
+	if len(values) > 1 {
+		axis += fmt.Sprintf("%*s", max(plotWidth-1, len(last)+1), last)
+	}
+	lines = append(lines, fmt.Sprintf("%*s  %s", pad, "", axis))
+	return lines
+}
@@ -0,0 +1,43 @@
+package render
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// legacyConsole is set by SetupConsole when the console cannot interpret
+// ANSI escape sequences.
+var legacyConsole atomic.Bool
+
+// SetupConsole prepares the console for styled output and should be called
+// at the start of main, before anything is printed. On Windows it enables
+// ANSI escape processing; classic consoles that don't support it get plain
+// output instead, with no color and ASCII borders and symbols. Elsewhere it
+// does nothing.
+func SetupConsole() {
+	if !enableVirtualTerminal() {
+		legacyConsole.Store(true)
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
+
+// Symbol returns s, or ascii on plain terminals.
+func Symbol(s, ascii string) string {
+	if Plain() {
+		return ascii
+	}
+	return s
+}
+
+// Rule returns a horizontal line n cells wide.
+func Rule(n int) string {
+	return strings.Repeat(Symbol("─", "-"), n)
+}
+
+// DoubleRule returns a double horizontal line n cells wide, for headings.
+func DoubleRule(n int) string {
+	return strings.Repeat(Symbol("═", "="), n)
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package render
+
+// enableVirtualTerminal reports that the terminal handles ANSI escapes,
+// which every supported non-Windows terminal does.
+func enableVirtualTerminal() bool {
+	return true
+}
@@ -0,0 +1,86 @@
+package render
+
+import "strings"
+
+// MaxDiffCells bounds the word-by-word table of a diff, so very long texts
+// are not compared. The table takes four bytes a cell.
+const MaxDiffCells = 4_000_000
+
+// DiffWords splits text into words for [WordDiff], with "\n" words for line
+// breaks so paragraphs survive the diff.
+func DiffWords(s string) []string {
+	var words []string
+	for i, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		if i > 0 {
+			words = append(words, "\n")
+		}
+		words = append(words, strings.Fields(line)...)
+	}
+	return words
+}
+
+// DiffOp is a word of a diff, with its kind: ' ' kept, '-' deleted, or '+'
+// added.
+type DiffOp struct {
+	Kind byte
+	Word string
+}
+
+// WordDiff turns a into b along their longest common subsequence, deleting
+// before adding where words were replaced. It returns false, and no
+// operations, if a and b are too long to compare, beyond [MaxDiffCells].
+func WordDiff(a, b []string) ([]DiffOp, bool) {
+	if len(a)*len(b) > MaxDiffCells {
+		return nil, false
+	}
+
+	// lcs[i][j] is the common length of a[i:] and b[j:]
+	cols := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*cols)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*cols+j] = lcs[(i+1)*cols+j+1] + 1
+			} else {
+				lcs[i*cols+j] = max(lcs[(i+1)*cols+j], lcs[i*cols+j+1])
+			}
+		}
+	}
+
+	ops := make([]DiffOp, 0, len(a)+len(b)-int(lcs[0]))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, DiffOp{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[(i+1)*cols+j] >= lcs[i*cols+j+1]):
+			ops = append(ops, DiffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, DiffOp{'+', b[j]})
+			j++
+		}
+	}
+	return ops, true
+}
+
+// DiffSimilarity returns how alike the two sides of a diff are: twice the
+// words kept over the words on both sides, from 0 (nothing shared) to 1
+// (the same, or both empty).
+func DiffSimilarity(ops []DiffOp) float64 {
+	kept, total := 0, 0
+	for _, op := range ops {
+		if op.Kind == ' ' {
+			kept++
+			total += 2
+		} else {
+			total++
+		}
+	}
+	if total == 0 {
+		return 1
+	}
+	return 2 * float64(kept) / float64(total)
+}
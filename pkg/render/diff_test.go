@@ -0,0 +1,46 @@
+package render
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWordDiff(t *testing.T) {
+	tests := []struct {
+		name, a, b string
+		want       string
+		similarity float64
+	}{
+		{"same", "the quick fox", "the quick fox", " the  quick  fox", 1},
+		{"replaced", "the quick fox", "the slow fox", " the -quick +slow  fox", 2.0 / 3},
+		{"added", "a b", "a b c", " a  b +c", 0.8},
+		{"deleted", "a b c", "a c", " a -b  c", 0.8},
+		{"paragraphs", "a\nb", "a\n\nb", " a  \n +\n  b", 6.0 / 7},
+		{"empty", "", "", "", 1},
+		{"new", "", "a b", "+a +b", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, ok := WordDiff(DiffWords(tt.a), DiffWords(tt.b))
+			if !ok {
+				t.Fatal("WordDiff refused to compare")
+			}
+			var got []string
+			for _, op := range ops {
+				got = append(got, string(op.Kind)+op.Word)
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("WordDiff(%q, %q) = %q, want %q", tt.a, tt.b, strings.Join(got, " "), tt.want)
+			}
+			if s := DiffSimilarity(ops); s != tt.similarity {
+				t.Errorf("DiffSimilarity = %v, want %v", s, tt.similarity)
+			}
+		})
+	}
+
+	long := slices.Repeat([]string{"word"}, 3000)
+	if _, ok := WordDiff(long, long); ok {
+		t.Error("WordDiff compared texts beyond MaxDiffCells")
+	}
+}
@@ -0,0 +1,224 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Align is the horizontal alignment of a column.
+type Align int
+
+// Column alignments.
+const (
+	AlignLeft Align = iota
+	AlignRight
+)
+
+// Column describes one table column.
+type Column struct {
+	Title string
+	Align Align
+
+	// MinWidth is the narrowest the column may be truncated to. Zero means
+	// the title width (at least 6 cells).
+	MinWidth int
+
+	// Style is applied to cells after padding, so it never affects
+	// alignment.
+	Style lipgloss.Style
+}
+
+// Table is a bordered table that fits within a maximum width.
+type Table struct {
+	Columns []Column
+	Rows    [][]string
+
+	// Width is the maximum rendered width; 0 means no limit.
+	// NewTable sets it to TerminalWidth.
+	Width int
+
+	// ASCII draws borders with +, -, and | instead of box characters.
+	// NewTable sets it on plain terminals.
+	ASCII bool
+
+	HeaderStyle lipgloss.Style
+	BorderStyle lipgloss.Style
+}
+
+// BorderColor is the color of the borders of tables made by [NewTable].
+// pkg/theme sets it to the theme's border color.
+var BorderColor lipgloss.TerminalColor = lipgloss.Color("240")
+
+// NewTable returns a table with the given columns sized for the current
+// terminal.
+func NewTable(columns ...Column) *Table {
+	return &Table{
+		Columns:     columns,
+		Width:       TerminalWidth(),
+		ASCII:       Plain(),
+		HeaderStyle: lipgloss.NewStyle().Bold(true),
+		BorderStyle: lipgloss.NewStyle().Foreground(BorderColor),
+	}
+}
+
+// AddRow appends a row. Missing cells are left blank and extra cells are
+// ignored.
+func (t *Table) AddRow(cells ...string) {
+	t.Rows = append(t.Rows, cells)
+}
+
+// AddSeparator appends a horizontal rule, e.g. before a totals row.
+func (t *Table) AddSeparator() {
+	t.Rows = append(t.Rows, nil)
+}
+
+// Print writes the table to stdout.
+func (t *Table) Print() {
+	_, _ = io.WriteString(os.Stdout, t.Render())
+}
+
+// Render returns the table as a string ending in a newline.
+func (t *Table) Render() string {
+	widths := t.columnWidths()
+	b := t.box()
+
+	var sb strings.Builder
+	t.rule(&sb, widths, b.top)
+
+	titles := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		titles[i] = c.Title
+	}
+	t.line(&sb, widths, titles, true)
+	t.rule(&sb, widths, b.middle)
+
+	for _, row := range t.Rows {
+		if row == nil {
+			t.rule(&sb, widths, b.middle)
+			continue
+		}
+		t.line(&sb, widths, row, false)
+	}
+	t.rule(&sb, widths, b.bottom)
+	return sb.String()
+}
+
+// columnWidths returns the natural width of each column, shrinking the
+// widest columns first until the table fits in t.Width.
+func (t *Table) columnWidths() []int {
+	widths := make([]int, len(t.Columns))
+	mins := make([]int, len(t.Columns))
+	for i, c := range t.Columns {
+		widths[i] = ansi.StringWidth(c.Title)
+		mins[i] = c.MinWidth
+		if mins[i] == 0 {
+			mins[i] = max(widths[i], 6)
+		}
+	}
+	for _, row := range t.Rows {
+		for i := range t.Columns {
+			if i < len(row) {
+				widths[i] = max(widths[i], ansi.StringWidth(row[i]))
+			}
+		}
+	}
+
+	if t.Width <= 0 {
+		return widths
+	}
+
+	// Each column has one space of padding on either side plus a border.
+	total := 1
+	for _, w := range widths {
+		total += w + 3
+	}
+	for total > t.Width {
+		widest := -1
+		for i, w := range widths {
+			if w > mins[i] && (widest < 0 || w > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			break // cannot shrink further; let the terminal wrap
+		}
+		widths[widest]--
+		total--
+	}
+	return widths
+}
+
+func (t *Table) line(sb *strings.Builder, widths []int, cells []string, header bool) {
+	v := t.BorderStyle.Render(t.box().vertical)
+	sb.WriteString(v)
+	for i, c := range t.Columns {
+		cell := ""
+		if i < len(cells) {
+			cell = cells[i]
+		}
+		cell = ansi.Truncate(cell, widths[i], t.ellipsis())
+		pad := strings.Repeat(" ", widths[i]-ansi.StringWidth(cell))
+		if c.Align == AlignRight {
+			cell = pad + cell
+		} else {
+			cell += pad
+		}
+
+		if header {
+			cell = t.HeaderStyle.Render(cell)
+		} else {
+			cell = c.Style.Render(cell)
+		}
+		fmt.Fprintf(sb, " %s %s", cell, v)
+	}
+	sb.WriteString("\n")
+}
+
+func (t *Table) rule(sb *strings.Builder, widths []int, parts [3]string) {
+	h := t.box().horizontal
+	segments := make([]string, len(widths))
+	for i, w := range widths {
+		segments[i] = strings.Repeat(h, w+2)
+	}
+	sb.WriteString(t.BorderStyle.Render(parts[0] + strings.Join(segments, parts[1]) + parts[2]))
+	sb.WriteString("\n")
+}
+
+func (t *Table) ellipsis() string {
+	if t.ASCII {
+		return "..."
+	}
+	return "…"
+}
+
+type boxChars struct {
+	horizontal, vertical string
+	top, middle, bottom  [3]string // left, junction, right
+}
+
+var (
+	unicodeBox = boxChars{
+		horizontal: "─", vertical: "│",
+		top:    [3]string{"┌", "┬", "┐"},
+		middle: [3]string{"├", "┼", "┤"},
+		bottom: [3]string{"└", "┴", "┘"},
+	}
+	asciiBox = boxChars{
+		horizontal: "-", vertical: "|",
+		top:    [3]string{"+", "+", "+"},
+		middle: [3]string{"+", "+", "+"},
+		bottom: [3]string{"+", "+", "+"},
+	}
+)
+
+func (t *Table) box() boxChars {
+	if t.ASCII {
+		return asciiBox
+	}
+	return unicodeBox
+}
@@ -0,0 +1,106 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestTableFitsWidth(t *testing.T) {
+	tbl := &Table{
+		Columns: []Column{
+			{Title: "Model"},
+			{Title: "Cost", Align: AlignRight},
+		},
+		Width: 30,
+	}
+	tbl.AddRow("Claude 3.5 Sonnet (October 2024 snapshot)", "$3.00")
+	tbl.AddRow("通义千问 Qwen Max", "$1.60")
+	tbl.AddSeparator()
+	tbl.AddRow("Total", "$4.60")
+
+	lines := strings.Split(strings.TrimSuffix(tbl.Render(), "\n"), "\n")
+	if len(lines) != 8 {
+		t.Fatalf("got %d lines, want 8:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	for _, line := range lines {
+		if w := ansi.StringWidth(line); w != 30 {
+			t.Errorf("line width = %d, want 30: %q", w, line)
+		}
+	}
+	if !strings.Contains(lines[3], "…") {
+		t.Errorf("expected long name to be truncated: %q", lines[3])
+	}
+	if !strings.HasSuffix(lines[4], "$1.60 │") {
+		t.Errorf("expected right-aligned cost: %q", lines[4])
+	}
+}
+
+func TestTableASCII(t *testing.T) {
+	tbl := &Table{Columns: []Column{{Title: "ID"}}, ASCII: true}
+	tbl.AddRow("gpt-4o")
+	want := "+--------+\n| ID     |\n+--------+\n| gpt-4o |\n+--------+\n"
+	if got := tbl.Render(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRulePlain(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm")
+	if got := Rule(3); got != "───" {
+		t.Errorf("Rule = %q", got)
+	}
+
+	legacyConsole.Store(true)
+	defer legacyConsole.Store(false)
+	if got := Rule(3) + DoubleRule(2) + Symbol("→", "->"); got != "---==->" {
+		t.Errorf("plain rules = %q", got)
+	}
+}
+
+func TestSparklineAndBar(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm")
+	if got := Sparkline([]float64{0, 1, 4, 8, -2}); got != " ▁▄█ " {
+		t.Errorf("Sparkline = %q", got)
+	}
+	if got := Sparkline([]float64{0, 0}); got != "  " {
+		t.Errorf("Sparkline of zeros = %q", got)
+	}
+	if got := Bar(0.5, 4) + "|" + Bar(2, 2); got != "██░░|██" {
+		t.Errorf("Bar = %q", got)
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if got := Sparkline([]float64{1, 8}) + Bar(0.25, 4); got != ".##---" {
+		t.Errorf("plain = %q", got)
+	}
+}
+
+func TestPlot(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm")
+	// A rise from 0 to the top within one cell: the left dot column has
+	// its bottom dot, the right the three above it
+	if got := Plot([]float64{0, 4}, 1, 1); len(got) != 1 || got[0] != "⡸" {
+		t.Errorf("Plot = %q", got)
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if got := strings.Join(Plot([]float64{0, 1, 2}, 3, 3), "|"); got != "  *| * |*  " {
+		t.Errorf("plain Plot = %q", got)
+	}
+	chart := LineChart([]float64{1, 2, 3}, 10, 2, func(v float64) string { return fmt.Sprintf("$%.0f", v) })
+	want := []string{
+		"$3 +  ****",
+		"$0 +**    ",
+		"   +------",
+		"    1    3",
+	}
+	if strings.Join(chart, "\n") != strings.Join(want, "\n") {
+		t.Errorf("LineChart =\n%s\nwant\n%s", strings.Join(chart, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,52 @@
+// Package render draws terminal output that adapts to the terminal: tables
+// fit the terminal width, truncate gracefully, stay aligned with wide
+// Unicode text, and fall back to plain ASCII on dumb terminals and classic
+// Windows consoles.
+//
+// Colors come from lipgloss styles, which already drop color when NO_COLOR
+// is set or output is not a terminal.
+package render
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// TerminalWidth returns the width available for output: $COLUMNS if set,
+// otherwise the width of stdout when it is a terminal. It returns 0 (no
+// limit) when output is redirected, so piped tables are never truncated.
+func TerminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	if !term.IsTerminal(os.Stdout.Fd()) {
+		return 0
+	}
+	w, _, err := term.GetSize(os.Stdout.Fd())
+	if err != nil || w <= 0 {
+		return 0
+	}
+	return w
+}
+
+// Plain reports whether output should avoid color and Unicode decoration:
+// NO_COLOR is set, the terminal is dumb, or [SetupConsole] found a classic
+// Windows console without ANSI support.
+func Plain() bool {
+	return legacyConsole.Load() || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb"
+}
+
+// Mark returns a check mark for true and an empty string for false, using
+// ASCII on plain terminals.
+func Mark(ok bool) string {
+	switch {
+	case !ok:
+		return ""
+	case Plain():
+		return "yes"
+	default:
+		return "✓"
+	}
+}
@@ -0,0 +1,262 @@
+// Package report renders model comparisons as standalone HTML documents: a
+// sortable table, bar charts, and a capability matrix, with all styles and
+// scripts inlined so the file can be attached to a planning doc as is.
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// Report is a standalone HTML report.
+type Report struct {
+	Title string
+
+	// Notes are short lines shown under the title, such as the workload
+	// being priced or the catalog version used.
+	Notes []string
+
+	// Generated is the time shown in the footer. Zero means now.
+	Generated time.Time
+
+	Tables       []Table
+	Charts       []Chart
+	Capabilities *Matrix
+}
+
+// Table is a table whose columns can be sorted by clicking their headers.
+type Table struct {
+	Title   string
+	Columns []string
+	Rows    [][]Cell
+}
+
+// AddRow appends a row.
+func (t *Table) AddRow(cells ...Cell) {
+	t.Rows = append(t.Rows, cells)
+}
+
+// Cell is a table cell. Numeric cells are right-aligned and sort by Value
+// rather than by their text.
+type Cell struct {
+	Text    string
+	Value   float64
+	Numeric bool
+}
+
+// Text returns a text cell.
+func Text(s string) Cell {
+	return Cell{Text: s}
+}
+
+// Number returns a numeric cell displaying v with the given fmt format.
+func Number(format string, v float64) Cell {
+	return Cell{Text: fmt.Sprintf(format, v), Value: v, Numeric: true}
+}
+
+// Int returns a numeric cell displaying n.
+func Int(n int64) Cell {
+	return Cell{Text: strconv.FormatInt(n, 10), Value: float64(n), Numeric: true}
+}
+
+// USD returns a numeric cell displaying a dollar amount.
+func USD(v float64) Cell {
+	return Number("$%.4f", v)
+}
+
+// Chart is a horizontal bar chart.
+type Chart struct {
+	Title string
+
+	// Format formats bar values with fmt, e.g. "$%.4f". Empty means "%g".
+	Format string
+
+	Bars []Bar
+}
+
+// Bar is a labelled value in a [Chart].
+type Bar struct {
+	Label string
+	Value float64
+}
+
+// Matrix is a grid of yes/no values, such as which capabilities each model
+// supports.
+type Matrix struct {
+	Columns []string
+	Rows    []MatrixRow
+}
+
+// MatrixRow is a row of a [Matrix].
+type MatrixRow struct {
+	Label  string
+	Values []bool
+}
+
+// CapabilityColumns are the columns of the matrix built by [Capabilities].
+var CapabilityColumns = []string{"Reasoning", "Vision", "Prompt caching", "Image pricing", "Audio pricing"}
+
+// Capabilities builds a capability matrix with one row per model, labelled
+// by the corresponding entry of labels.
+func Capabilities(labels []string, models []catwalk.Model) *Matrix {
+	m := &Matrix{Columns: CapabilityColumns}
+	for i, model := range models {
+		m.Rows = append(m.Rows, MatrixRow{
+			Label: labels[i],
+			Values: []bool{
+				model.CanReason,
+				model.SupportsImages,
+				model.CostPer1MInCached > 0 || model.CostPer1MOutCached > 0,
+				len(model.ImagePricing) > 0,
+				model.AudioPricing != nil,
+			},
+		})
+	}
+	return m
+}
+
+// bar is a [Bar] prepared for rendering.
+type bar struct {
+	Label string
+	Text  string
+	Width float64 // percent of the largest bar
+}
+
+type chartView struct {
+	Title string
+	Bars  []bar
+}
+
+// view scales the bars to the largest value.
+func (c Chart) view() chartView {
+	format := c.Format
+	if format == "" {
+		format = "%g"
+	}
+	var largest float64
+	for _, b := range c.Bars {
+		largest = max(largest, b.Value)
+	}
+
+	v := chartView{Title: c.Title}
+	for _, b := range c.Bars {
+		width := 0.0
+		if largest > 0 {
+			width = b.Value / largest * 100
+		}
+		v.Bars = append(v.Bars, bar{Label: b.Label, Text: fmt.Sprintf(format, b.Value), Width: width})
+	}
+	return v
+}
+
+// WriteHTML writes the report as a standalone HTML document.
+func (r Report) WriteHTML(w io.Writer) error {
+	generated := r.Generated
+	if generated.IsZero() {
+		generated = time.Now()
+	}
+
+	charts := make([]chartView, len(r.Charts))
+	for i, c := range r.Charts {
+		charts[i] = c.view()
+	}
+
+	data := struct {
+		Report
+		ChartViews []chartView
+		Timestamp  string
+	}{r, charts, generated.UTC().Format("2006-01-02 15:04 MST")}
+
+	if err := page.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	return nil
+}
+
+var page = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem auto; max-width: 1100px; padding: 0 1rem; color: #1f2328; }
+h1 { margin-bottom: 0.25rem; }
+h2 { margin-top: 2rem; border-bottom: 1px solid #d0d7de; padding-bottom: 0.25rem; }
+.notes { color: #59636e; margin: 0.15rem 0; }
+table { border-collapse: collapse; width: 100%; font-size: 0.9rem; }
+th, td { padding: 0.4rem 0.6rem; border-bottom: 1px solid #d0d7de; text-align: left; }
+th { background: #f6f8fa; cursor: pointer; user-select: none; white-space: nowrap; }
+th[data-dir="asc"]::after { content: " ▲"; }
+th[data-dir="desc"]::after { content: " ▼"; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+tr:hover td { background: #f6f8fa; }
+.chart { display: grid; grid-template-columns: minmax(8rem, max-content) 1fr; gap: 0.3rem 0.75rem; align-items: center; font-size: 0.9rem; }
+.track { display: flex; align-items: center; gap: 0.5rem; }
+.bar { background: #8250df; height: 1.1rem; border-radius: 2px; min-width: 1px; }
+.value { font-variant-numeric: tabular-nums; color: #59636e; white-space: nowrap; }
+.matrix td, .matrix th { text-align: center; cursor: default; }
+.matrix td:first-child, .matrix th:first-child { text-align: left; }
+.yes { color: #1a7f37; font-weight: bold; }
+.no { color: #d0d7de; }
+footer { margin-top: 2rem; color: #59636e; font-size: 0.8rem; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Notes}}<p class="notes">{{.}}</p>
+{{end}}
+{{range .Tables}}
+{{if .Title}}<h2>{{.Title}}</h2>{{end}}
+<table class="sortable">
+<thead><tr>{{range .Columns}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{range .Rows}}<tr>{{range .}}{{if .Numeric}}<td class="num" data-value="{{.Value}}">{{.Text}}</td>{{else}}<td>{{.Text}}</td>{{end}}{{end}}</tr>
+{{end}}</tbody>
+</table>
+{{end}}
+{{range .ChartViews}}
+<h2>{{.Title}}</h2>
+<div class="chart">
+{{range .Bars}}<div>{{.Label}}</div><div class="track"><div class="bar" style="width: {{printf "%.2f" .Width}}%"></div><span class="value">{{.Text}}</span></div>
+{{end}}</div>
+{{end}}
+{{with .Capabilities}}
+<h2>Capabilities</h2>
+<table class="matrix">
+<thead><tr><th>Model</th>{{range .Columns}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{range .Rows}}<tr><td>{{.Label}}</td>{{range .Values}}{{if .}}<td class="yes">✓</td>{{else}}<td class="no">–</td>{{end}}{{end}}</tr>
+{{end}}</tbody>
+</table>
+{{end}}
+<footer>Generated {{.Timestamp}} from the catwalk catalog.</footer>
+<script>
+document.querySelectorAll("table.sortable").forEach(function (table) {
+  table.querySelectorAll("th").forEach(function (th, col) {
+    th.addEventListener("click", function () {
+      var dir = th.dataset.dir === "asc" ? "desc" : "asc";
+      table.querySelectorAll("th").forEach(function (h) { delete h.dataset.dir; });
+      th.dataset.dir = dir;
+      var body = table.tBodies[0];
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function (a, b) {
+        var x = a.cells[col], y = b.cells[col];
+        var cmp = "value" in x.dataset && "value" in y.dataset
+          ? parseFloat(x.dataset.value) - parseFloat(y.dataset.value)
+          : x.textContent.localeCompare(y.textContent);
+        return dir === "asc" ? cmp : -cmp;
+      });
+      rows.forEach(function (row) { body.appendChild(row); });
+    });
+  });
+});
+</script>
+</body>
+</html>
+`))
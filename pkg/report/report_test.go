@@ -0,0 +1,51 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+func TestWriteHTML(t *testing.T) {
+	tbl := Table{Columns: []string{"Model", "Total"}}
+	tbl.AddRow(Text("<b>GPT-4o</b>"), USD(0.0125))
+
+	r := Report{
+		Title:     "Cost comparison",
+		Notes:     []string{"1,000 input / 500 output tokens"},
+		Generated: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		Tables:    []Table{tbl},
+		Charts: []Chart{{
+			Title:  "Total cost",
+			Format: "$%.4f",
+			Bars:   []Bar{{Label: "GPT-4o", Value: 0.0125}, {Label: "Haiku", Value: 0.003125}},
+		}},
+		Capabilities: Capabilities([]string{"GPT-4o"}, []catwalk.Model{{CanReason: false, SupportsImages: true}}),
+	}
+
+	var b strings.Builder
+	if err := r.WriteHTML(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"<title>Cost comparison</title>",
+		"&lt;b&gt;GPT-4o&lt;/b&gt;",
+		`<td class="num" data-value="0.0125">$0.0125</td>`,
+		`style="width: 100.00%"`,
+		`style="width: 25.00%"`,
+		"$0.0031",
+		`<td class="no">–</td><td class="yes">✓</td>`,
+		"Generated 2025-06-01 12:00 UTC",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+	if strings.Contains(out, "ZgotmplZ") {
+		t.Error("report contains values rejected by html/template")
+	}
+}
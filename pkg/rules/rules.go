@@ -0,0 +1,213 @@
+// Package rules rewrites chat requests according to a YAML file, so one
+// policy can govern how every tool uses models: prepend a system prompt,
+// cap reply length, drop parameters a provider rejects, and map retired
+// model names to their replacements.
+//
+//	aliases:
+//	  gpt-4: gpt-4o                       # at any provider
+//	  anthropic/claude-2.1: claude-sonnet-4-5
+//	rules:
+//	  - match: {provider: openai, model: "gpt-4o*"}
+//	    system_prefix: Follow the Acme acceptable-use policy.
+//	    max_tokens: 4096
+//	  - match: {type: anthropic}
+//	    strip: [frequency_penalty, seed]
+//
+// Every rule whose match fits a request applies, in file order; an empty
+// match fits every request. Aliases are resolved with [Rules.Alias] before
+// the model is looked up, and the other rules by [Rules.Middleware] on each
+// request.
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"github.com/sashabaranov/go-openai"
+	"go.yaml.in/yaml/v2"
+)
+
+// Rules is a parsed rules file. A nil *Rules changes nothing.
+type Rules struct {
+	// Aliases maps a model ID, or provider/model, to the model to use
+	// instead.
+	Aliases map[string]string `yaml:"aliases,omitempty"`
+
+	Rules []Rule `yaml:"rules,omitempty"`
+}
+
+// Rule rewrites the requests it matches.
+type Rule struct {
+	Match Match `yaml:"match,omitempty"`
+
+	// SystemPrefix is put before the system prompt, or sent as the system
+	// prompt if there is none.
+	SystemPrefix string `yaml:"system_prefix,omitempty"`
+
+	// MaxTokens caps the reply length. Requests without a limit get this
+	// one, since the provider default may be higher.
+	MaxTokens int `yaml:"max_tokens,omitempty"`
+
+	// Strip lists request parameters to remove; see [Params].
+	Strip []string `yaml:"strip,omitempty"`
+}
+
+// Match selects requests by provider ID, provider type, and model ID.
+// Empty fields match anything; Model may be a glob such as "gpt-4o*".
+type Match struct {
+	Provider catwalk.InferenceProvider `yaml:"provider,omitempty"`
+	Type     catwalk.Type              `yaml:"type,omitempty"`
+	Model    string                    `yaml:"model,omitempty"`
+}
+
+// matches reports whether m fits a request for model at provider.
+func (m Match) matches(provider catwalk.Provider, model string) bool {
+	if m.Provider != "" && m.Provider != provider.ID {
+		return false
+	}
+	if m.Type != "" && m.Type != provider.Type {
+		return false
+	}
+	if m.Model != "" {
+		ok, _ := path.Match(m.Model, model)
+		return ok
+	}
+	return true
+}
+
+// Params lists the request parameters a rule can strip, with what removing
+// each one does to an OpenAI-style request.
+var Params = map[string]func(*openai.ChatCompletionRequest){
+	"temperature":       func(r *openai.ChatCompletionRequest) { r.Temperature = 0 },
+	"top_p":             func(r *openai.ChatCompletionRequest) { r.TopP = 0 },
+	"frequency_penalty": func(r *openai.ChatCompletionRequest) { r.FrequencyPenalty = 0 },
+	"presence_penalty":  func(r *openai.ChatCompletionRequest) { r.PresencePenalty = 0 },
+	"seed":              func(r *openai.ChatCompletionRequest) { r.Seed = nil },
+	"stop":              func(r *openai.ChatCompletionRequest) { r.Stop = nil },
+	"logit_bias":        func(r *openai.ChatCompletionRequest) { r.LogitBias = nil },
+	"logprobs":          func(r *openai.ChatCompletionRequest) { r.LogProbs, r.TopLogProbs = false, 0 },
+	"reasoning_effort":  func(r *openai.ChatCompletionRequest) { r.ReasoningEffort = "" },
+	"response_format":   func(r *openai.ChatCompletionRequest) { r.ResponseFormat = nil },
+	"tools":             func(r *openai.ChatCompletionRequest) { r.Tools, r.ToolChoice = nil, nil },
+	"user":              func(r *openai.ChatCompletionRequest) { r.User = "" },
+}
+
+// Load reads and validates a rules file.
+func Load(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+	r, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Parse parses and validates rules in YAML. Unknown fields and parameters
+// are errors, so a misspelled rule is not silently ignored.
+func Parse(data []byte) (*Rules, error) {
+	var r Rules
+	if err := yaml.UnmarshalStrict(data, &r); err != nil {
+		return nil, fmt.Errorf("invalid rules: %w", err)
+	}
+	for from, to := range r.Aliases {
+		if from == "" || to == "" {
+			return nil, errors.New("invalid rules: aliases need a model on both sides")
+		}
+	}
+	for i, rule := range r.Rules {
+		if rule.MaxTokens < 0 {
+			return nil, fmt.Errorf("invalid rules: rule %d: max_tokens must not be negative", i+1)
+		}
+		if _, err := path.Match(rule.Match.Model, ""); err != nil {
+			return nil, fmt.Errorf("invalid rules: rule %d: bad model pattern %q", i+1, rule.Match.Model)
+		}
+		for _, p := range rule.Strip {
+			if Params[p] == nil {
+				return nil, fmt.Errorf("invalid rules: rule %d: unknown parameter %q to strip", i+1, p)
+			}
+		}
+	}
+	return &r, nil
+}
+
+// Alias returns the model to use in place of model at provider: the alias
+// for provider/model, else the alias for model, else model itself. Aliases
+// are not followed further, so a cycle cannot loop.
+func (r *Rules) Alias(provider catwalk.InferenceProvider, model string) string {
+	if r == nil {
+		return model
+	}
+	if to, ok := r.Aliases[string(provider)+"/"+model]; ok {
+		return to
+	}
+	if to, ok := r.Aliases[model]; ok {
+		return to
+	}
+	return model
+}
+
+// Apply rewrites req, a request for model at provider, with every matching
+// rule.
+func (r *Rules) Apply(provider catwalk.Provider, model string, req *openai.ChatCompletionRequest) {
+	if r == nil {
+		return
+	}
+	for _, rule := range r.Rules {
+		if !rule.Match.matches(provider, model) {
+			continue
+		}
+		if rule.SystemPrefix != "" {
+			prefixSystem(req, rule.SystemPrefix)
+		}
+		if rule.MaxTokens > 0 {
+			capTokens(req, rule.MaxTokens)
+		}
+		for _, p := range rule.Strip {
+			Params[p](req)
+		}
+	}
+}
+
+// prefixSystem puts prefix before the first system message, or adds it as
+// one.
+func prefixSystem(req *openai.ChatCompletionRequest, prefix string) {
+	if len(req.Messages) > 0 && req.Messages[0].Role == chat.RoleSystem {
+		// The history is shared with the session, so the message is copied
+		req.Messages = slices.Clone(req.Messages)
+		req.Messages[0].Content = prefix + "\n\n" + req.Messages[0].Content
+		return
+	}
+	req.Messages = append([]openai.ChatCompletionMessage{{Role: chat.RoleSystem, Content: prefix}}, req.Messages...)
+}
+
+// capTokens lowers the request's reply limit to n, or sets it if there is
+// none.
+func capTokens(req *openai.ChatCompletionRequest, n int) {
+	limit := &req.MaxTokens
+	if req.MaxCompletionTokens > 0 {
+		limit = &req.MaxCompletionTokens
+	}
+	if *limit == 0 || *limit > n {
+		*limit = n
+	}
+}
+
+// Middleware returns chat middleware that applies the rules to each
+// request.
+func (r *Rules) Middleware() chat.Middleware {
+	return func(next chat.Handler) chat.Handler {
+		return chat.HandlerFunc(func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			r.Apply(req.Provider, req.Model.ID, &req.Params)
+			return next.Complete(ctx, req)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package rules
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"github.com/sashabaranov/go-openai"
+)
+
+const testRules = `
+aliases:
+  gpt-4: gpt-4o
+  anthropic/claude-2.1: claude-sonnet-4-5
+rules:
+  - match: {provider: openai, model: "gpt-4o*"}
+    system_prefix: Follow the policy.
+    max_tokens: 100
+  - match: {type: anthropic}
+    strip: [frequency_penalty, seed]
+`
+
+func TestAlias(t *testing.T) {
+	r, err := Parse([]byte(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		provider    catwalk.InferenceProvider
+		model, want string
+	}{
+		{"openai", "gpt-4", "gpt-4o"},
+		{"azure", "gpt-4", "gpt-4o"},
+		{"anthropic", "claude-2.1", "claude-sonnet-4-5"},
+		{"openrouter", "claude-2.1", "claude-2.1"},
+		{"openai", "gpt-4o", "gpt-4o"},
+	} {
+		if got := r.Alias(tt.provider, tt.model); got != tt.want {
+			t.Errorf("Alias(%s, %s) = %s, want %s", tt.provider, tt.model, got, tt.want)
+		}
+	}
+	if got := (*Rules)(nil).Alias("openai", "gpt-4"); got != "gpt-4" {
+		t.Errorf("nil rules alias = %s", got)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	r, err := Parse([]byte(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed := 1
+	history := []openai.ChatCompletionMessage{
+		{Role: chat.RoleSystem, Content: "Be brief."},
+		{Role: chat.RoleUser, Content: "Hi"},
+	}
+
+	var got openai.ChatCompletionRequest
+	h := chat.Chain(chat.HandlerFunc(func(_ context.Context, req *chat.Request) (*chat.Response, error) {
+		got = req.Params
+		return &chat.Response{}, nil
+	}), r.Middleware())
+	send := func(p catwalk.Provider, model string, params openai.ChatCompletionRequest) {
+		t.Helper()
+		req := &chat.Request{Provider: p, Model: catwalk.Model{ID: model}, Params: params}
+		if _, err := h.Complete(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	openaiProvider := catwalk.Provider{ID: "openai", Type: catwalk.TypeOpenAI}
+	send(openaiProvider, "gpt-4o-mini", openai.ChatCompletionRequest{Messages: history, MaxTokens: 500, Seed: &seed})
+	if got.Messages[0].Content != "Follow the policy.\n\nBe brief." || got.MaxTokens != 100 || got.Seed == nil {
+		t.Errorf("openai request = %+v", got)
+	}
+	if history[0].Content != "Be brief." {
+		t.Error("the system prefix changed the session history")
+	}
+
+	send(openaiProvider, "gpt-4o", openai.ChatCompletionRequest{Messages: history[1:], MaxTokens: 50})
+	if got.Messages[0].Role != chat.RoleSystem || len(got.Messages) != 2 || got.MaxTokens != 50 {
+		t.Errorf("request without a system prompt = %+v", got)
+	}
+
+	send(openaiProvider, "o3", openai.ChatCompletionRequest{Messages: history})
+	if got.MaxTokens != 0 || got.Messages[0].Content != "Be brief." {
+		t.Errorf("unmatched model = %+v", got)
+	}
+
+	send(catwalk.Provider{ID: "anthropic", Type: catwalk.TypeAnthropic}, "claude-sonnet-4-5",
+		openai.ChatCompletionRequest{Messages: history, FrequencyPenalty: 0.5, Seed: &seed, Temperature: 0.7})
+	if got.FrequencyPenalty != 0 || got.Seed != nil || got.Temperature != 0.7 {
+		t.Errorf("anthropic request = %+v", got)
+	}
+}
+
+func TestParseRejectsMistakes(t *testing.T) {
+	for _, bad := range []string{
+		"rules: [{strip: [temprature]}]",
+		"rules: [{max_tokens: -1}]",
+		"rules: [{match: {model: \"[\"}}]",
+		"rules: [{system: hi}]",
+		"aliases: {gpt-4: \"\"}",
+	} {
+		if _, err := Parse([]byte(bad)); err == nil || !strings.Contains(err.Error(), "invalid rules") {
+			t.Errorf("Parse(%q) = %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+// Package secrets finds credentials and personal data in text about to be
+// sent to a model, and masks or blocks them according to a [Policy].
+//
+// Detection is pattern based: API keys with a well-known prefix (OpenAI,
+// Anthropic, AWS, Google, GitHub, Slack, Hugging Face, and others) or
+// assigned to a key-like name, JWTs, PEM private keys, and email addresses.
+// Exact values, such as the API key the program itself uses, can be added
+// with [Scanner.Known]. Each finding is reported as an [Event] that carries a
+// fingerprint of the secret but never the secret itself, so events can be
+// logged safely.
+package secrets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Kind is a category of secret.
+type Kind string
+
+// Kinds of secret.
+const (
+	APIKey     Kind = "api_key"
+	JWT        Kind = "jwt"
+	PrivateKey Kind = "private_key"
+	Email      Kind = "email"
+)
+
+// Kinds lists every kind of secret, in the order findings are described.
+var Kinds = []Kind{PrivateKey, APIKey, JWT, Email}
+
+// String describes the kind for people, e.g. "API key".
+func (k Kind) String() string {
+	switch k {
+	case APIKey:
+		return "API key"
+	case JWT:
+		return "JWT"
+	case PrivateKey:
+		return "private key"
+	case Email:
+		return "email address"
+	}
+	return string(k)
+}
+
+// Action is what happens to text containing a kind of secret.
+type Action string
+
+// Actions.
+const (
+	// Allow sends the secret unchanged and does not report it.
+	Allow Action = "allow"
+	// Mask replaces the secret with a placeholder naming its kind.
+	Mask Action = "mask"
+	// Block refuses to send the text at all.
+	Block Action = "block"
+)
+
+// Policy maps each kind of secret to an action. Kinds missing from the
+// policy are allowed.
+type Policy map[Kind]Action
+
+// ParsePolicy parses a comma-separated policy: an action that applies to
+// every kind, followed by optional per-kind overrides, e.g. "mask" or
+// "mask,private_key=block,email=allow". The overrides may also be given on
+// their own, leaving the other kinds allowed.
+func ParsePolicy(s string) (Policy, error) {
+	p := Policy{}
+	for i, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			if i > 0 {
+				return nil, fmt.Errorf("invalid redaction policy %q: the action for every kind must come first", s)
+			}
+			a, err := parseAction(part)
+			if err != nil {
+				return nil, err
+			}
+			for _, k := range Kinds {
+				p[k] = a
+			}
+			continue
+		}
+		k := Kind(strings.TrimSpace(name))
+		if !slices.Contains(Kinds, k) {
+			return nil, fmt.Errorf("unknown secret kind %q (want %s)", k, kindList())
+		}
+		a, err := parseAction(strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+		p[k] = a
+	}
+	if len(p) == 0 {
+		return nil, fmt.Errorf("empty redaction policy")
+	}
+	return p, nil
+}
+
+func parseAction(s string) (Action, error) {
+	switch a := Action(s); a {
+	case Allow, Mask, Block:
+		return a, nil
+	}
+	return "", fmt.Errorf("unknown redaction action %q (want allow, mask, or block)", s)
+}
+
+func kindList() string {
+	names := make([]string, len(Kinds))
+	for i, k := range Kinds {
+		names[i] = string(k)
+	}
+	return strings.Join(names, ", ")
+}
+
+// detector finds one kind of secret. If the pattern has a group, only the
+// text it matches is the secret, e.g. the value of "api_key = ...".
+type detector struct {
+	kind    Kind
+	pattern *regexp.Regexp
+}
+
+var detectors = []detector{
+	// The END line may be missing from a key that was cut short when pasted
+	{PrivateKey, regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----(?:[\s\S]*?-----END (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----|[\s\S]*)`)},
+	{JWT, regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{APIKey, regexp.MustCompile(`\b(?:` +
+		`sk-(?:proj-|ant-(?:api|admin)\d\d-|or-v1-|svcacct-)?[A-Za-z0-9_-]{20,}` + // OpenAI, Anthropic, OpenRouter
+		`|(?:AKIA|ASIA)[0-9A-Z]{16}` + // AWS access key ID
+		`|AIza[0-9A-Za-z_-]{35}` + // Google
+		`|gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{50,}` + // GitHub
+		`|xox[abposr]-[A-Za-z0-9-]{10,}` + // Slack
+		`|hf_[A-Za-z0-9]{30,}` + // Hugging Face
+		`|gsk_[A-Za-z0-9]{40,}` + // Groq
+		`|xai-[A-Za-z0-9]{40,}` + // xAI
+		`|glpat-[A-Za-z0-9_-]{20,}` + // GitLab
+		`|(?:sk|rk)_live_[A-Za-z0-9]{20,}` + // Stripe
+		`)`)},
+	{APIKey, regexp.MustCompile(`(?i)\b(?:api[_-]?key|access[_-]?key|secret(?:[_-]?key)?|auth[_-]?token|access[_-]?token)["']?\s*[:=]\s*["']?([A-Za-z0-9_\-./+=]{16,})`)},
+	{Email, regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?)*\.[A-Za-z]{2,}\b`)},
+}
+
+// Finding is a secret found in text, at text[Start:End].
+type Finding struct {
+	Kind       Kind
+	Start, End int
+}
+
+// Event reports a secret found in text being sent and what was done about
+// it.
+type Event struct {
+	Time   time.Time `json:"time"`
+	Source string    `json:"source,omitempty"`
+	Kind   Kind      `json:"kind"`
+	Action Action    `json:"action"`
+
+	// Fingerprint is the start of the secret's SHA-256 hash, so repeated
+	// leaks of the same secret can be recognized without logging it.
+	Fingerprint string `json:"fingerprint"`
+}
+
+// BlockedError is returned for text that contains a secret the policy
+// blocks.
+type BlockedError struct {
+	Source string
+	Kinds  []Kind
+}
+
+func (e *BlockedError) Error() string {
+	names := make([]string, len(e.Kinds))
+	for i, k := range e.Kinds {
+		names[i] = count(k, 1)
+	}
+	msg := "contains " + joinAnd(names) + ", which the redaction policy blocks"
+	if e.Source != "" {
+		msg = e.Source + " " + msg
+	}
+	return msg
+}
+
+// ErrBlocked matches any [BlockedError] with [errors.Is].
+var ErrBlocked = errors.New("blocked by redaction policy")
+
+// Is reports whether target is [ErrBlocked].
+func (e *BlockedError) Is(target error) bool {
+	return target == ErrBlocked
+}
+
+// Placeholder returns the text that replaces a masked secret of kind k.
+func Placeholder(k Kind) string {
+	return "[REDACTED " + strings.ToUpper(strings.ReplaceAll(string(k), "_", " ")) + "]"
+}
+
+// minKnown is the shortest known value that is looked for, so that an empty
+// or trivial value does not match everywhere.
+const minKnown = 8
+
+// Scanner finds secrets in text and applies a policy to them.
+type Scanner struct {
+	Policy Policy
+
+	// Known lists exact values to treat as API keys wherever they appear,
+	// whether or not they look like one. Values shorter than 8 characters
+	// are ignored.
+	Known []string
+
+	// OnEvent, if set, is called for every secret that is masked or
+	// blocked.
+	OnEvent func(Event)
+}
+
+// Scan returns the secrets in text that the policy does not allow, in order
+// and without overlaps. Where two findings overlap, the one that starts
+// first, or the longer of two that start together, is kept.
+func (s *Scanner) Scan(text string) []Finding {
+	var found []Finding
+	add := func(k Kind, start, end int) {
+		if s.Policy[k] == Mask || s.Policy[k] == Block {
+			found = append(found, Finding{Kind: k, Start: start, End: end})
+		}
+	}
+	for _, d := range detectors {
+		for _, m := range d.pattern.FindAllStringSubmatchIndex(text, -1) {
+			if len(m) >= 4 && m[2] >= 0 {
+				add(d.kind, m[2], m[3])
+			} else {
+				add(d.kind, m[0], m[1])
+			}
+		}
+	}
+	for _, v := range s.Known {
+		if len(v) < minKnown {
+			continue
+		}
+		for i := 0; ; {
+			j := strings.Index(text[i:], v)
+			if j < 0 {
+				break
+			}
+			add(APIKey, i+j, i+j+len(v))
+			i += j + len(v)
+		}
+	}
+
+	slices.SortFunc(found, func(a, b Finding) int {
+		if a.Start != b.Start {
+			return a.Start - b.Start
+		}
+		return b.End - a.End
+	})
+	kept := found[:0]
+	for _, f := range found {
+		if len(kept) > 0 && f.Start < kept[len(kept)-1].End {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// Filter applies the policy to text from source, which names it in events
+// and errors (e.g. "message" or a file name). It returns text with masked
+// secrets replaced by their [Placeholder], or a [*BlockedError] if any
+// secret is blocked. Every finding is reported to OnEvent either way.
+func (s *Scanner) Filter(source, text string) (string, error) {
+	findings := s.Scan(text)
+	if len(findings) == 0 {
+		return text, nil
+	}
+
+	now := time.Now()
+	var blocked []Kind
+	for _, f := range findings {
+		action := s.Policy[f.Kind]
+		if action == Block && !slices.Contains(blocked, f.Kind) {
+			blocked = append(blocked, f.Kind)
+		}
+		if s.OnEvent != nil {
+			s.OnEvent(Event{Time: now, Source: source, Kind: f.Kind, Action: action, Fingerprint: fingerprint(text[f.Start:f.End])})
+		}
+	}
+	if len(blocked) > 0 {
+		slices.SortFunc(blocked, func(a, b Kind) int { return slices.Index(Kinds, a) - slices.Index(Kinds, b) })
+		return text, &BlockedError{Source: source, Kinds: blocked}
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, f := range findings {
+		sb.WriteString(text[last:f.Start])
+		sb.WriteString(Placeholder(f.Kind))
+		last = f.End
+	}
+	sb.WriteString(text[last:])
+	return sb.String(), nil
+}
+
+// Summary describes a list of secrets for people, e.g. "2 API keys and an
+// email address".
+func Summary(kinds []Kind) string {
+	counts := map[Kind]int{}
+	for _, k := range kinds {
+		counts[k]++
+	}
+	var parts []string
+	for _, k := range Kinds {
+		if n := counts[k]; n > 0 {
+			parts = append(parts, count(k, n))
+		}
+	}
+	return joinAnd(parts)
+}
+
+// count describes n secrets of kind k, e.g. "an API key" or "2 JWTs".
+func count(k Kind, n int) string {
+	name := k.String()
+	if n != 1 {
+		if strings.HasSuffix(name, "s") {
+			return fmt.Sprintf("%d %ses", n, name)
+		}
+		return fmt.Sprintf("%d %ss", n, name)
+	}
+	if strings.ContainsRune("AEIOUaeiou", rune(name[0])) {
+		return "an " + name
+	}
+	return "a " + name
+}
+
+func fingerprint(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:6])
+}
+
+// joinAnd joins words as in "a, b and c".
+func joinAnd(words []string) string {
+	switch len(words) {
+	case 0:
+		return ""
+	case 1:
+		return words[0]
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+}
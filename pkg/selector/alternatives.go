@@ -0,0 +1,142 @@
+package selector
+
+import (
+	"sort"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// AlternativeOptions narrows the search for cheaper substitutes of a model.
+type AlternativeOptions struct {
+	// MinContext is the smallest context window accepted. Zero requires at
+	// least the original's window.
+	MinContext int64
+	// OtherProviders leaves out the original's provider.
+	OtherProviders bool
+	// Providers restricts the search to these providers (empty = all).
+	Providers []catwalk.InferenceProvider
+	// AllowFree includes models with no listed price, as for
+	// [Requirements].
+	AllowFree bool
+	// InputTokens and OutputTokens are the workload costs and savings are
+	// estimated for. Both zero compares [BlendedCost].
+	InputTokens  int64
+	OutputTokens int64
+}
+
+// Alternative is a cheaper substitute for a model.
+type Alternative struct {
+	Match
+	// SameModel is set when the alternative is the original model offered
+	// by another provider or under another ID.
+	SameModel bool
+	// Similarity rates how close a substitute it is, from 0 to 100.
+	Similarity float64
+	// Cost is the workload's cost on the alternative, and Savings the
+	// fraction of the original's cost it saves.
+	Cost    float64
+	Savings float64
+}
+
+// Alternatives returns the models that cost less than original for the
+// workload and keep its capabilities: reasoning and vision if it has them,
+// and a context window of at least opts.MinContext. The closest substitutes
+// come first; ties go to the cheaper model, then the larger context window.
+//
+// Without quality data, similarity is a heuristic: the same model elsewhere
+// scores 100; otherwise models in the same latency class (see [IsFast])
+// and family, and with prices nearer the original's, score higher.
+func Alternatives(providers []catwalk.Provider, original Match, opts AlternativeOptions) []Alternative {
+	base := opts.Cost(original.Model)
+	if base <= 0 {
+		return nil
+	}
+	req := Requirements{
+		MinContext: opts.MinContext,
+		Reasoning:  original.Model.CanReason,
+		Vision:     original.Model.SupportsImages,
+		Providers:  opts.Providers,
+		AllowFree:  opts.AllowFree,
+	}
+	if req.MinContext == 0 {
+		req.MinContext = original.Model.ContextWindow
+	}
+
+	var out []Alternative
+	for _, p := range providers {
+		if opts.OtherProviders && p.ID == original.Provider.ID {
+			continue
+		}
+		for _, m := range p.Models {
+			if p.ID == original.Provider.ID && m.ID == original.Model.ID {
+				continue
+			}
+			cost := opts.Cost(m)
+			if cost >= base || !req.Satisfies(p, m) {
+				continue
+			}
+			alt := Alternative{Match: Match{p, m}, Cost: cost, Savings: 1 - cost/base}
+			alt.SameModel = modelKey(m.ID) == modelKey(original.Model.ID) ||
+				(m.Name != "" && strings.EqualFold(m.Name, original.Model.Name))
+			alt.Similarity = similarity(original, alt)
+			out = append(out, alt)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Similarity != out[j].Similarity {
+			return out[i].Similarity > out[j].Similarity
+		}
+		if out[i].Cost != out[j].Cost {
+			return out[i].Cost < out[j].Cost
+		}
+		return out[i].Model.ContextWindow > out[j].Model.ContextWindow
+	})
+	return out
+}
+
+// similarity scores an alternative to original from 0 to 100.
+func similarity(original Match, alt Alternative) float64 {
+	if alt.SameModel {
+		return 100
+	}
+	var score float64
+	if IsFast(original.Provider, original.Model) == IsFast(alt.Provider, alt.Model) {
+		score += 40
+	}
+	if family(original.Model.ID) == family(alt.Model.ID) {
+		score += 20
+	}
+	// A cheaper model priced near the original is more likely its peer
+	return score + 40*(1-alt.Savings)
+}
+
+// Cost returns what the workload costs on m, in USD, or m's [BlendedCost]
+// if there is no workload.
+func (o AlternativeOptions) Cost(m catwalk.Model) float64 {
+	if o.InputTokens == 0 && o.OutputTokens == 0 {
+		return BlendedCost(m)
+	}
+	return Workload{InputTokens: o.InputTokens, OutputTokens: o.OutputTokens}.Cost(m)
+}
+
+// modelKey normalizes a model ID for comparison across providers, dropping
+// an organization prefix, as in "anthropic/claude-sonnet-4", and a variant
+// suffix, as in ":free".
+func modelKey(id string) string {
+	id = strings.ToLower(id)
+	if i := strings.LastIndex(id, "/"); i >= 0 {
+		id = id[i+1:]
+	}
+	id, _, _ = strings.Cut(id, ":")
+	return id
+}
+
+// family returns the first word of a model ID, such as "claude" or "gpt".
+func family(id string) string {
+	key := modelKey(id)
+	if i := strings.IndexAny(key, "-_. "); i >= 0 {
+		return key[:i]
+	}
+	return key
+}
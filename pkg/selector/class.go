@@ -0,0 +1,184 @@
+package selector
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// Class is a model's place in its provider's lineup, used to line up
+// comparable models of different providers.
+type Class string
+
+// Model classes, from the cheapest tier to the dedicated reasoning models.
+const (
+	ClassSmall     Class = "small"
+	ClassMedium    Class = "medium"
+	ClassLarge     Class = "large"
+	ClassReasoning Class = "reasoning"
+)
+
+// Classes lists the classes in the order lineups are compared.
+var Classes = []Class{ClassSmall, ClassMedium, ClassLarge, ClassReasoning}
+
+// ParseClass parses a class name, ignoring case.
+func ParseClass(s string) (Class, error) {
+	c := Class(strings.ToLower(strings.TrimSpace(s)))
+	if !slices.Contains(Classes, c) {
+		return "", fmt.Errorf("unknown class %q (want small, medium, large, or reasoning)", s)
+	}
+	return c, nil
+}
+
+var (
+	// reasoningModelWords mark models built for long reasoning, as
+	// opposed to the many general models that can also reason.
+	reasoningModelWords = []string{"reasoner", "reasoning", "thinking", "r1", "qwq"}
+	// largeWords mark the flagship tiers of model families.
+	largeWords = []string{"opus", "pro", "large", "max", "ultra"}
+	// oSeries matches OpenAI's reasoning models, such as o3 and o4-mini.
+	oSeries = regexp.MustCompile(`^o\d$`)
+	// paramCount matches a parameter count, such as 8b or 1.5b.
+	paramCount = regexp.MustCompile(`^(\d+(?:\.\d+)?)b$`)
+)
+
+// ClassOf guesses a model's class. The catalog has no tiers, so this is a
+// heuristic on the ID: reasoning for dedicated reasoning models
+// such as o3 or deepseek-reasoner, small if [IsFast] or up to 15B
+// parameters, large for words such as opus or pro or from 100B parameters,
+// and medium otherwise.
+func ClassOf(p catwalk.Provider, m catwalk.Model) Class {
+	words := modelWords(m)
+	if len(words) > 0 && oSeries.MatchString(words[0]) {
+		return ClassReasoning
+	}
+	if !slices.Contains(words, "non") && slices.ContainsFunc(words, func(w string) bool { return slices.Contains(reasoningModelWords, w) }) {
+		return ClassReasoning
+	}
+	if IsFast(p, m) {
+		return ClassSmall
+	}
+	for _, w := range words {
+		if match := paramCount.FindStringSubmatch(w); match != nil {
+			switch n, _ := strconv.ParseFloat(match[1], 64); {
+			case n <= 15:
+				return ClassSmall
+			case n >= 100:
+				return ClassLarge
+			}
+			return ClassMedium
+		}
+	}
+	if slices.ContainsFunc(words, func(w string) bool { return slices.Contains(largeWords, w) }) {
+		return ClassLarge
+	}
+	return ClassMedium
+}
+
+// modelWords splits a model's ID, without any organization prefix, into
+// lowercase words.
+func modelWords(m catwalk.Model) []string {
+	return strings.FieldsFunc(modelKey(m.ID), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.'
+	})
+}
+
+// Pair is a model of one provider's lineup lined up with the closest model
+// of the same class in another's. A or B is nil when the other lineup has
+// no model left to pair it with.
+type Pair struct {
+	Class Class
+	A, B  *catwalk.Model
+}
+
+// AlignLineups pairs the models of providers a and b class by class, each
+// model with at most one of the other's. Models priced most alike are
+// paired first, then those with the closest context windows; what is left
+// over is listed alone. Pairs come in the order of [Classes], and within a
+// class from the cheapest.
+func AlignLineups(a, b catwalk.Provider) []Pair {
+	var pairs []Pair
+	for _, class := range Classes {
+		as := classModels(a, class)
+		bs := classModels(b, class)
+
+		type candidate struct {
+			i, j           int
+			price, context float64
+		}
+		var candidates []candidate
+		for i := range as {
+			for j := range bs {
+				candidates = append(candidates, candidate{i, j, priceDistance(*as[i], *bs[j]), logDistance(float64(as[i].ContextWindow), float64(bs[j].ContextWindow))})
+			}
+		}
+		slices.SortStableFunc(candidates, func(x, y candidate) int {
+			return cmp.Or(cmp.Compare(x.price, y.price), cmp.Compare(x.context, y.context))
+		})
+
+		var rows []Pair
+		pairedA := make([]bool, len(as))
+		pairedB := make([]bool, len(bs))
+		for _, c := range candidates {
+			if !pairedA[c.i] && !pairedB[c.j] {
+				pairedA[c.i], pairedB[c.j] = true, true
+				rows = append(rows, Pair{Class: class, A: as[c.i], B: bs[c.j]})
+			}
+		}
+		for i, m := range as {
+			if !pairedA[i] {
+				rows = append(rows, Pair{Class: class, A: m})
+			}
+		}
+		for j, m := range bs {
+			if !pairedB[j] {
+				rows = append(rows, Pair{Class: class, B: m})
+			}
+		}
+		slices.SortStableFunc(rows, func(x, y Pair) int {
+			mx, my := cmp.Or(x.A, x.B), cmp.Or(y.A, y.B)
+			return cmp.Or(cmp.Compare(BlendedCost(*mx), BlendedCost(*my)), strings.Compare(mx.ID, my.ID))
+		})
+		pairs = append(pairs, rows...)
+	}
+	return pairs
+}
+
+// classModels returns the models of p in class.
+func classModels(p catwalk.Provider, class Class) []*catwalk.Model {
+	var models []*catwalk.Model
+	for i := range p.Models {
+		if ClassOf(p, p.Models[i]) == class {
+			models = append(models, &p.Models[i])
+		}
+	}
+	return models
+}
+
+// priceDistance is how far apart two models' prices are, as a log ratio.
+// Unpriced models are farther from priced ones than any two prices.
+func priceDistance(a, b catwalk.Model) float64 {
+	pa, pb := BlendedCost(a), BlendedCost(b)
+	switch {
+	case pa > 0 && pb > 0:
+		return logDistance(pa, pb)
+	case pa == 0 && pb == 0:
+		return 0
+	}
+	return math.Inf(1)
+}
+
+// logDistance returns |log(a/b)|, or +Inf if either is not positive.
+func logDistance(a, b float64) float64 {
+	if a <= 0 || b <= 0 {
+		return math.Inf(1)
+	}
+	return math.Abs(math.Log(a / b))
+}
@@ -0,0 +1,120 @@
+package selector
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+func TestClassOf(t *testing.T) {
+	p := catwalk.Provider{ID: "a", DefaultSmallModelID: "house-small"}
+	for id, want := range map[string]Class{
+		"o3":                        ClassReasoning,
+		"o4-mini":                   ClassReasoning,
+		"deepseek-reasoner":         ClassReasoning,
+		"grok-4-fast-reasoning":     ClassReasoning,
+		"grok-4-fast-non-reasoning": ClassSmall,
+		"gpt-4o-mini":               ClassSmall,
+		"house-small":               ClassSmall,
+		"meta-llama/llama-3.1-8b":   ClassSmall,
+		"qwen/qwen2.5-72b":          ClassMedium,
+		"qwen/qwen3-235b-a22b":      ClassLarge,
+		"claude-opus-4":             ClassLarge,
+		"gemini-2.5-pro":            ClassLarge,
+		"claude-sonnet-4":           ClassMedium,
+		"gpt-4.1":                   ClassMedium,
+	} {
+		if got := ClassOf(p, catwalk.Model{ID: id}); got != want {
+			t.Errorf("ClassOf(%s) = %s, want %s", id, got, want)
+		}
+	}
+}
+
+func TestParseClass(t *testing.T) {
+	if c, err := ParseClass(" Large"); err != nil || c != ClassLarge {
+		t.Errorf("ParseClass = %q, %v", c, err)
+	}
+	if _, err := ParseClass("huge"); err == nil {
+		t.Error("expected an error for an unknown class")
+	}
+}
+
+func TestAlignLineups(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []catwalk.Model
+		want []string
+	}{
+		{
+			name: "closest price in each class",
+			a: []catwalk.Model{
+				{ID: "gpt-4o", CostPer1MIn: 2.5, CostPer1MOut: 10},
+				{ID: "gpt-4o-mini", CostPer1MIn: 0.15, CostPer1MOut: 0.6},
+				{ID: "gpt-4.1-nano", CostPer1MIn: 0.1, CostPer1MOut: 0.4},
+				{ID: "o3", CostPer1MIn: 2, CostPer1MOut: 8},
+			},
+			b: []catwalk.Model{
+				{ID: "claude-opus-4", CostPer1MIn: 15, CostPer1MOut: 75},
+				{ID: "claude-sonnet-4", CostPer1MIn: 3, CostPer1MOut: 15},
+				{ID: "claude-haiku-4", CostPer1MIn: 1, CostPer1MOut: 5},
+			},
+			want: []string{
+				"small gpt-4.1-nano -",
+				"small gpt-4o-mini claude-haiku-4",
+				"medium gpt-4o claude-sonnet-4",
+				"large - claude-opus-4",
+				"reasoning o3 -",
+			},
+		},
+		{
+			name: "unpriced models pair with each other",
+			a: []catwalk.Model{
+				{ID: "alpha", CostPer1MIn: 1, CostPer1MOut: 1},
+				{ID: "beta"},
+			},
+			b: []catwalk.Model{
+				{ID: "gamma"},
+				{ID: "delta", CostPer1MIn: 1.2, CostPer1MOut: 1.2},
+			},
+			want: []string{"medium beta gamma", "medium alpha delta"},
+		},
+		{
+			name: "context window breaks a price tie",
+			a:    []catwalk.Model{{ID: "x", CostPer1MIn: 1, CostPer1MOut: 1, ContextWindow: 128_000}},
+			b: []catwalk.Model{
+				{ID: "y", CostPer1MIn: 1, CostPer1MOut: 1, ContextWindow: 8_000},
+				{ID: "z", CostPer1MIn: 1, CostPer1MOut: 1, ContextWindow: 200_000},
+			},
+			want: []string{"medium x z", "medium - y"},
+		},
+		{
+			name: "one lineup empty",
+			a:    []catwalk.Model{{ID: "claude-opus-4"}, {ID: "claude-haiku-4"}},
+			want: []string{"small claude-haiku-4 -", "large claude-opus-4 -"},
+		},
+		{
+			name: "both empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := catwalk.Provider{ID: "a", Models: tt.a}
+			b := catwalk.Provider{ID: "b", Models: tt.b}
+			var got []string
+			for _, p := range AlignLineups(a, b) {
+				name := func(m *catwalk.Model) string {
+					if m == nil {
+						return "-"
+					}
+					return m.ID
+				}
+				got = append(got, fmt.Sprintf("%s %s %s", p.Class, name(p.A), name(p.B)))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package selector
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// LatencyTier is how quickly a model typically answers, from realtime
+// (voice and live use) to batch (minutes or more, such as batch APIs and
+// long-thinking models).
+type LatencyTier string
+
+// Latency tiers, fastest first.
+const (
+	LatencyRealtime LatencyTier = "realtime"
+	LatencyFast     LatencyTier = "fast"
+	LatencyStandard LatencyTier = "standard"
+	LatencyBatch    LatencyTier = "batch"
+)
+
+// LatencyTiers lists the tiers, fastest first.
+var LatencyTiers = []LatencyTier{LatencyRealtime, LatencyFast, LatencyStandard, LatencyBatch}
+
+// ParseLatencyTier parses a tier name, ignoring case.
+func ParseLatencyTier(s string) (LatencyTier, error) {
+	t := LatencyTier(strings.ToLower(strings.TrimSpace(s)))
+	if !slices.Contains(LatencyTiers, t) {
+		return "", fmt.Errorf("unknown latency tier %q (want realtime, fast, standard, or batch)", s)
+	}
+	return t, nil
+}
+
+// AtMost reports whether t is as fast as max or faster. Unknown tiers are
+// treated as standard.
+func (t LatencyTier) AtMost(max LatencyTier) bool {
+	return t.rank() <= max.rank()
+}
+
+func (t LatencyTier) rank() int {
+	if i := slices.Index(LatencyTiers, t); i >= 0 {
+		return i
+	}
+	return slices.Index(LatencyTiers, LatencyStandard)
+}
+
+// GuessLatencyTier guesses a model's tier when no annotation is available:
+// realtime if its ID says so, fast if [IsFast], and standard otherwise. It
+// never guesses batch.
+func GuessLatencyTier(p catwalk.Provider, m catwalk.Model) LatencyTier {
+	switch {
+	case strings.Contains(strings.ToLower(m.ID), "realtime"):
+		return LatencyRealtime
+	case IsFast(p, m):
+		return LatencyFast
+	}
+	return LatencyStandard
+}
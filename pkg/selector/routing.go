@@ -0,0 +1,124 @@
+package selector
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"charm.land/catwalk/pkg/tokenizer"
+)
+
+// Turn is a chat message to route: its text and the paths of the files
+// attached to it.
+type Turn struct {
+	Text  string
+	Files []string
+}
+
+// Route is the size of model a turn needs, with the signals that decided
+// it.
+type Route struct {
+	Size    Size
+	Score   int
+	Reasons []string
+}
+
+// largeScore is the score from which a turn goes to the large model.
+const largeScore = 2
+
+// reasoningWords are asks that small models tend to get wrong: analysis,
+// design, debugging, and proofs.
+var reasoningWords = regexp.MustCompile(`\b(why|prove|proof|derive|analy[sz]e|analysis|compare|trade-?offs?|design|architect(ure)?|debug|diagnose|optimi[sz]e|refactor|algorithm|complexity|step[- ]by[- ]step|reason|evaluate|plan|strategy|calculate|explain how)\b`)
+
+// codeLine matches lines that look like code rather than prose.
+var codeLine = regexp.MustCompile(`^\s*(func|def|class|import|package|return|if|for|while|const|let|var|public|private|#include|SELECT|select)\b|[;{}]\s*$|^\s{4,}\S|=>|:=`)
+
+// codeExts are attachments treated as code.
+var codeExts = map[string]bool{
+	".go": true, ".py": true, ".js": true, ".ts": true, ".tsx": true, ".jsx": true,
+	".java": true, ".kt": true, ".rs": true, ".c": true, ".h": true, ".cc": true,
+	".cpp": true, ".cs": true, ".rb": true, ".php": true, ".swift": true,
+	".scala": true, ".sql": true, ".sh": true,
+}
+
+// RouteTurn decides whether a turn needs a large model or a small one will
+// do, by how long it is, whether it holds or attaches code, how many
+// questions it asks, and whether it asks for reasoning. A turn scoring 2 or
+// more goes to the large model.
+func RouteTurn(t Turn) Route {
+	var r Route
+	add := func(score int, reason string) {
+		r.Score += score
+		r.Reasons = append(r.Reasons, reason)
+	}
+
+	switch tokens := tokenizer.Count(t.Text); {
+	case tokens >= 600:
+		add(2, fmt.Sprintf("long (~%d tokens)", tokens))
+	case tokens >= 150:
+		add(1, fmt.Sprintf("medium length (~%d tokens)", tokens))
+	}
+
+	code := strings.Contains(t.Text, "```")
+	if !code {
+		lines := 0
+		for _, line := range strings.Split(t.Text, "\n") {
+			if codeLine.MatchString(line) {
+				lines++
+			}
+		}
+		code = lines >= 3
+	}
+	if code {
+		add(2, "code")
+	}
+
+	if asks := uniqueMatches(reasoningWords, strings.ToLower(t.Text)); len(asks) > 0 {
+		add(min(len(asks), 2), "reasoning: "+strings.Join(asks, ", "))
+	}
+	if strings.Count(t.Text, "?") >= 3 {
+		add(1, "several questions")
+	}
+
+	var codeFiles, otherFiles int
+	for _, f := range t.Files {
+		if codeExts[strings.ToLower(filepath.Ext(f))] {
+			codeFiles++
+		} else {
+			otherFiles++
+		}
+	}
+	switch {
+	case codeFiles > 0:
+		add(2, fmt.Sprintf("%d code file(s) attached", codeFiles))
+	case otherFiles > 0:
+		add(1, fmt.Sprintf("%d file(s) attached", otherFiles))
+	}
+
+	r.Size = SizeSmall
+	if r.Score >= largeScore {
+		r.Size = SizeLarge
+	}
+	if len(r.Reasons) == 0 {
+		r.Reasons = []string{"short, no code or reasoning"}
+	}
+	return r
+}
+
+// uniqueMatches returns the distinct matches of re in s, in order.
+func uniqueMatches(re *regexp.Regexp, s string) []string {
+	var out []string
+	for _, m := range re.FindAllString(s, -1) {
+		if !slices.Contains(out, m) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// String describes the route, as in "large: code, reasoning: debug".
+func (r Route) String() string {
+	return string(r.Size) + ": " + strings.Join(r.Reasons, ", ")
+}
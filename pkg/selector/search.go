@@ -0,0 +1,163 @@
+package selector
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// Field is a part of a catalog entry that a search term can match.
+type Field string
+
+// Searched fields. The catalog has no descriptions, so a model's family and
+// organization are taken from its ID.
+const (
+	// FieldID is the model ID, matched without its organization prefix
+	// unless the term is the whole ID.
+	FieldID Field = "id"
+	// FieldName is the model's display name.
+	FieldName Field = "name"
+	// FieldFamily is the first word of the model ID, such as "llama".
+	FieldFamily Field = "family"
+	// FieldOrganization is the organization prefix of the model ID, as in
+	// "meta-llama/llama-3.3-70b".
+	FieldOrganization Field = "organization"
+	// FieldProvider is the provider's ID or name.
+	FieldProvider Field = "provider"
+)
+
+// SearchResult is a model matching a search query.
+type SearchResult struct {
+	Match
+	// Score ranks the result; higher is a closer match.
+	Score int
+	// Fields lists the fields the query matched, most relevant first.
+	Fields []Field
+}
+
+// fieldWeights rank the fields: a term in the model's own ID or name counts
+// more than one in its family or organization, and those more than the
+// provider, which matches every model it serves.
+var fieldWeights = map[Field]int{
+	FieldID:           3,
+	FieldName:         3,
+	FieldFamily:       2,
+	FieldOrganization: 2,
+	FieldProvider:     1,
+}
+
+// Search returns the models whose ID, name, family, organization, or
+// provider match every word of query, ignoring case, best match first. A
+// word matching a whole field scores highest, then one starting the field
+// or matching one of its words, then one starting a word, and last one
+// found anywhere in it. Ties go to the shorter model ID, then by provider
+// and model ID.
+func Search(providers []catwalk.Provider, query string) []SearchResult {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var results []SearchResult
+	for _, p := range providers {
+		for _, m := range p.Models {
+			fields := searchFields(p, m)
+			score := 0
+			matched := map[Field]int{}
+			for _, term := range terms {
+				best := 0
+				for _, f := range fields {
+					s := 0
+					if !f.whole || f.text == term {
+						s = fieldWeights[f.field] * matchQuality(term, f.text)
+					}
+					best = max(best, s)
+					if s > 0 {
+						matched[f.field] = max(matched[f.field], s)
+					}
+				}
+				if best == 0 {
+					score = 0
+					break
+				}
+				score += best
+			}
+			if score == 0 {
+				continue
+			}
+			r := SearchResult{Match: Match{Provider: p, Model: m}, Score: score}
+			for f := range matched {
+				r.Fields = append(r.Fields, f)
+			}
+			slices.SortFunc(r.Fields, func(a, b Field) int {
+				return cmp.Or(cmp.Compare(matched[b], matched[a]), strings.Compare(string(a), string(b)))
+			})
+			results = append(results, r)
+		}
+	}
+	slices.SortStableFunc(results, func(a, b SearchResult) int {
+		return cmp.Or(
+			cmp.Compare(b.Score, a.Score),
+			cmp.Compare(len(a.Model.ID), len(b.Model.ID)),
+			strings.Compare(string(a.Provider.ID), string(b.Provider.ID)),
+			strings.Compare(a.Model.ID, b.Model.ID),
+		)
+	})
+	return results
+}
+
+type searchField struct {
+	field Field
+	text  string
+	// whole fields only match a term equal to all of the text.
+	whole bool
+}
+
+// searchFields returns the lowercase text of the fields of model m at
+// provider p, leaving out those it does not have.
+func searchFields(p catwalk.Provider, m catwalk.Model) []searchField {
+	id := strings.ToLower(m.ID)
+	org, key := "", id
+	if i := strings.LastIndex(id, "/"); i >= 0 {
+		org, key = id[:i], id[i+1:]
+	}
+	fields := []searchField{
+		{field: FieldID, text: key},
+		{field: FieldID, text: id, whole: true},
+		{field: FieldName, text: strings.ToLower(m.Name)},
+		{field: FieldFamily, text: family(m.ID)},
+		{field: FieldOrganization, text: org},
+		{field: FieldProvider, text: strings.ToLower(string(p.ID))},
+		{field: FieldProvider, text: strings.ToLower(p.Name)},
+	}
+	return slices.DeleteFunc(fields, func(f searchField) bool { return f.text == "" || (f.whole && org == "") })
+}
+
+// matchQuality rates how well term matches text: 4 for the whole text, 3
+// for its start or one of its words, 2 for the start of a word, 1 for
+// anywhere in it, and 0 for not at all.
+func matchQuality(term, text string) int {
+	switch {
+	case text == term:
+		return 4
+	case strings.HasPrefix(text, term):
+		return 3
+	case !strings.Contains(text, term):
+		return 0
+	}
+	quality := 1
+	for _, w := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.'
+	}) {
+		switch {
+		case w == term:
+			return 3
+		case strings.HasPrefix(w, term):
+			quality = 2
+		}
+	}
+	return quality
+}
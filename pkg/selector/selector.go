@@ -0,0 +1,214 @@
+// Package selector picks models from the catwalk catalog that satisfy a set
+// of requirements.
+package selector
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// ErrNoMatch is returned when no model satisfies the requirements.
+var ErrNoMatch = errors.New("no model satisfies the requirements")
+
+// Requirements describes what a model must support.
+type Requirements struct {
+	// MinContext is the minimum context window in tokens (0 = any).
+	MinContext int64
+	// PromptTokens and OutputTokens require the context window to hold a
+	// request of that size; see [Fits]. Both zero means any.
+	PromptTokens int64
+	OutputTokens int64
+	// MaxCostPer1MIn is the maximum input price per 1M tokens (0 = any).
+	MaxCostPer1MIn float64
+	// Reasoning requires reasoning support.
+	Reasoning bool
+	// Vision requires image input support.
+	Vision bool
+	// Providers restricts the search to these providers (empty = all).
+	Providers []catwalk.InferenceProvider
+	// AllowFree includes models with no listed price. These are skipped by
+	// default because a zero price usually means pricing is unknown or
+	// covered by a subscription.
+	AllowFree bool
+}
+
+// Match is a model together with the provider offering it.
+type Match struct {
+	Provider catwalk.Provider
+	Model    catwalk.Model
+}
+
+// Selector searches a provider catalog.
+type Selector struct {
+	providers []catwalk.Provider
+}
+
+// New creates a selector over the given providers.
+func New(providers []catwalk.Provider) *Selector {
+	return &Selector{providers: providers}
+}
+
+// Satisfies reports whether the model on provider p meets the requirements.
+func (r Requirements) Satisfies(p catwalk.Provider, m catwalk.Model) bool {
+	if len(r.Providers) > 0 && !slices.Contains(r.Providers, p.ID) {
+		return false
+	}
+	if r.MinContext > 0 && m.ContextWindow < r.MinContext {
+		return false
+	}
+	if (r.PromptTokens > 0 || r.OutputTokens > 0) && !Fits(m, r.PromptTokens, r.OutputTokens) {
+		return false
+	}
+	if r.MaxCostPer1MIn > 0 && m.CostPer1MIn > r.MaxCostPer1MIn {
+		return false
+	}
+	if r.Reasoning && !m.CanReason {
+		return false
+	}
+	if r.Vision && !m.SupportsImages {
+		return false
+	}
+	if !r.AllowFree && BlendedCost(m) == 0 {
+		return false
+	}
+	return true
+}
+
+// Fits reports whether a request with promptTokens of input and a reply of
+// up to outputTokens fits in m's context window. An outputTokens above the
+// model's output limit ([catwalk.Model.OutputLimit]) never fits, and zero
+// reserves room for a default reply; see [ReservedOutput].
+func Fits(m catwalk.Model, promptTokens, outputTokens int64) bool {
+	if limit := m.OutputLimit(); limit > 0 && outputTokens > limit {
+		return false
+	}
+	return promptTokens+ReservedOutput(m, outputTokens) <= m.ContextWindow
+}
+
+// ReservedOutput returns the reply tokens to leave room for in m's context
+// window: outputTokens if set, else the catalog's default max tokens, which
+// is what a request without a reply limit gets.
+func ReservedOutput(m catwalk.Model, outputTokens int64) int64 {
+	if outputTokens == 0 {
+		return m.DefaultMaxTokens
+	}
+	return outputTokens
+}
+
+// Matching returns every model that satisfies the requirements, in catalog
+// order.
+func (s *Selector) Matching(req Requirements) []Match {
+	var matches []Match
+	for _, p := range s.providers {
+		for _, m := range p.Models {
+			if req.Satisfies(p, m) {
+				matches = append(matches, Match{Provider: p, Model: m})
+			}
+		}
+	}
+	return matches
+}
+
+// CheapestWith returns the lowest-cost model satisfying the requirements.
+// Cost is compared by BlendedCost; ties go to the larger context window.
+func (s *Selector) CheapestWith(req Requirements) (Match, error) {
+	matches := s.Matching(req)
+	if len(matches) == 0 {
+		return Match{}, ErrNoMatch
+	}
+
+	best := matches[0]
+	for _, m := range matches[1:] {
+		cost, bestCost := BlendedCost(m.Model), BlendedCost(best.Model)
+		if cost < bestCost || (cost == bestCost && m.Model.ContextWindow > best.Model.ContextWindow) {
+			best = m
+		}
+	}
+	return best, nil
+}
+
+// Offers returns every provider offering a model ID, ignoring case, in
+// catalog order. With providerID set, it looks only at that provider and
+// accepts the aliases [catwalk.Provider.FindModel] does. If nothing offers
+// the model, the error is a [catwalk.ModelNotFoundError] with suggestions.
+func Offers(providers []catwalk.Provider, modelID, providerID string) ([]Match, error) {
+	if providerID != "" {
+		p, err := catwalk.FindProvider(providers, providerID)
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		m, err := p.FindModel(modelID)
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		return []Match{{Provider: *p, Model: *m}}, nil
+	}
+
+	var matches []Match
+	var ids []string
+	for _, p := range providers {
+		for _, m := range p.Models {
+			if strings.EqualFold(m.ID, modelID) {
+				matches = append(matches, Match{Provider: p, Model: m})
+			}
+			ids = append(ids, m.ID)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, &catwalk.ModelNotFoundError{Model: modelID, Suggestions: catwalk.Suggest(modelID, ids, 3)}
+	}
+	return matches, nil
+}
+
+// BlendedCost returns the sum of the input and output price per 1M tokens,
+// used to rank models without a specific workload.
+func BlendedCost(m catwalk.Model) float64 {
+	return m.CostPer1MIn + m.CostPer1MOut
+}
+
+// Size selects between a provider's default small and large models.
+type Size string
+
+// Model sizes.
+const (
+	SizeSmall Size = "small"
+	SizeLarge Size = "large"
+)
+
+// ParseSize parses "small" or "large", ignoring case.
+func ParseSize(s string) (Size, error) {
+	switch size := Size(strings.ToLower(strings.TrimSpace(s))); size {
+	case SizeSmall, SizeLarge:
+		return size, nil
+	default:
+		return "", fmt.Errorf("invalid size %q (use small or large)", s)
+	}
+}
+
+// DefaultFor returns provider p's default model of the given size, from
+// DefaultSmallModelID or DefaultLargeModelID. It returns ErrNoMatch if the
+// provider has no default of that size or the ID is not in its model list.
+func DefaultFor(p catwalk.Provider, size Size) (catwalk.Model, error) {
+	var id string
+	switch size {
+	case SizeSmall:
+		id = p.DefaultSmallModelID
+	case SizeLarge:
+		id = p.DefaultLargeModelID
+	default:
+		return catwalk.Model{}, fmt.Errorf("invalid size %q (use small or large)", size)
+	}
+
+	if id != "" {
+		for _, m := range p.Models {
+			if m.ID == id {
+				return m, nil
+			}
+		}
+	}
+	return catwalk.Model{}, fmt.Errorf("%s has no default %s model: %w", p.ID, size, ErrNoMatch)
+}
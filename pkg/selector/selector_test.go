@@ -0,0 +1,367 @@
+package selector
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+func TestCheapestWith(t *testing.T) {
+	providers := []catwalk.Provider{
+		{ID: "a", Models: []catwalk.Model{
+			{ID: "big", CostPer1MIn: 3, CostPer1MOut: 15, ContextWindow: 200_000, CanReason: true, SupportsImages: true},
+			{ID: "free", ContextWindow: 200_000, CanReason: true},
+		}},
+		{ID: "b", Models: []catwalk.Model{
+			{ID: "small", CostPer1MIn: 0.1, CostPer1MOut: 0.4, ContextWindow: 128_000},
+			{ID: "mid", CostPer1MIn: 1, CostPer1MOut: 4, ContextWindow: 200_000, CanReason: true},
+		}},
+	}
+	s := New(providers)
+
+	tests := []struct {
+		name string
+		req  Requirements
+		want string
+	}{
+		{"any", Requirements{}, "small"},
+		{"context", Requirements{MinContext: 150_000}, "mid"},
+		{"vision", Requirements{Vision: true}, "big"},
+		{"provider", Requirements{Providers: []catwalk.InferenceProvider{"a"}}, "big"},
+		{"free", Requirements{Reasoning: true, AllowFree: true}, "free"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.CheapestWith(tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Model.ID != tt.want {
+				t.Errorf("got %q, want %q", got.Model.ID, tt.want)
+			}
+		})
+	}
+
+	if _, err := s.CheapestWith(Requirements{MinContext: 1_000_000}); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("expected ErrNoMatch, got %v", err)
+	}
+}
+
+func TestOffers(t *testing.T) {
+	providers := []catwalk.Provider{
+		{ID: "openai", Models: []catwalk.Model{{ID: "gpt-4o"}, {ID: "gpt-4o-mini"}}},
+		{ID: "azure", Models: []catwalk.Model{{ID: "GPT-4o"}}},
+		{ID: "anthropic", Models: []catwalk.Model{{ID: "claude-sonnet-4"}}},
+	}
+	tests := []struct {
+		model, provider string
+		want            []string
+		wantErr         error
+	}{
+		{"gpt-4o", "", []string{"openai/gpt-4o", "azure/GPT-4o"}, nil},
+		{"gpt-4o", "azure", []string{"azure/GPT-4o"}, nil},
+		{"gpt-4o-mini", "azure", nil, &catwalk.ModelNotFoundError{}},
+		{"gpt-4o", "nope", nil, &catwalk.ProviderNotFoundError{}},
+		{"gpt-4", "", nil, &catwalk.ModelNotFoundError{}},
+	}
+	for _, tt := range tests {
+		matches, err := Offers(providers, tt.model, tt.provider)
+		if tt.wantErr != nil {
+			if err == nil || reflect.TypeOf(err) != reflect.TypeOf(tt.wantErr) {
+				t.Errorf("Offers(%s, %q) error = %v, want a %T", tt.model, tt.provider, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Offers(%s, %q): %v", tt.model, tt.provider, err)
+			continue
+		}
+		var got []string
+		for _, m := range matches {
+			got = append(got, string(m.Provider.ID)+"/"+m.Model.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Offers(%s, %q) = %q, want %q", tt.model, tt.provider, got, tt.want)
+		}
+	}
+
+	var notFound *catwalk.ModelNotFoundError
+	if _, err := Offers(providers, "gpt-4", ""); !errors.As(err, &notFound) || !slices.Contains(notFound.Suggestions, "gpt-4o") {
+		t.Errorf("Offers(gpt-4) error = %v, want a suggestion of gpt-4o", err)
+	}
+}
+
+func TestFits(t *testing.T) {
+	m := catwalk.Model{ContextWindow: 128_000, DefaultMaxTokens: 16_000, MaxOutputTokens: 32_000}
+
+	tests := []struct {
+		prompt, output int64
+		want           bool
+	}{
+		{100_000, 16_000, true},
+		{120_000, 8_000, true},
+		{120_000, 8_001, false},
+		{100_000, 0, true},     // reserves the 16K default
+		{113_000, 0, false},    // 113K + 16K overflows
+		{96_000, 32_000, true}, // above the default, within the output limit
+		{1_000, 32_001, false},
+	}
+	for _, tt := range tests {
+		if got := Fits(m, tt.prompt, tt.output); got != tt.want {
+			t.Errorf("Fits(%d, %d) = %v; want %v", tt.prompt, tt.output, got, tt.want)
+		}
+	}
+
+	// Without a listed output limit, the context window is the limit
+	window := catwalk.Model{ContextWindow: 128_000, DefaultMaxTokens: 16_000}
+	if !Fits(window, 1_000, 64_000) || Fits(window, 1_000, 128_000) {
+		t.Error("Fits without MaxOutputTokens does not use the context window")
+	}
+}
+
+func TestDefaultFor(t *testing.T) {
+	p := catwalk.Provider{
+		ID:                  "openai",
+		DefaultLargeModelID: "gpt-4o",
+		DefaultSmallModelID: "gpt-4o-mini",
+		Models:              []catwalk.Model{{ID: "gpt-4o"}, {ID: "gpt-4o-mini"}},
+	}
+
+	for size, want := range map[Size]string{SizeSmall: "gpt-4o-mini", SizeLarge: "gpt-4o"} {
+		m, err := DefaultFor(p, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.ID != want {
+			t.Errorf("DefaultFor(%s) = %q, want %q", size, m.ID, want)
+		}
+	}
+
+	p.DefaultSmallModelID = "retired-model"
+	if _, err := DefaultFor(p, SizeSmall); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("expected ErrNoMatch for missing default, got %v", err)
+	}
+	if _, err := ParseSize("medium"); err == nil {
+		t.Error("expected error for invalid size")
+	}
+}
+
+func TestUseCase(t *testing.T) {
+	providers := []catwalk.Provider{
+		{ID: "a", DefaultSmallModelID: "small", Models: []catwalk.Model{
+			{ID: "thinker", CostPer1MIn: 3, CostPer1MOut: 15, ContextWindow: 200_000, CanReason: true},
+			{ID: "cheap-thinker", CostPer1MIn: 0.5, CostPer1MOut: 2, ContextWindow: 128_000, CanReason: true},
+			{ID: "small", CostPer1MIn: 0.1, CostPer1MOut: 0.4, ContextWindow: 128_000},
+			{ID: "gemini-long", Name: "Gemini Long", CostPer1MIn: 1, CostPer1MOut: 4, ContextWindow: 1_000_000},
+		}},
+		{ID: "b", Models: []catwalk.Model{
+			{ID: "b-flash-1", CostPer1MIn: 0.2, CostPer1MOut: 0.8, ContextWindow: 1_000_000},
+		}},
+	}
+
+	tests := []struct {
+		useCase string
+		want    []string
+	}{
+		{"Code Review", []string{"cheap-thinker", "thinker"}},
+		{"agent", []string{"cheap-thinker", "thinker"}},
+		{"long_document_summarization", []string{"b-flash-1", "gemini-long", "thinker"}},
+		{"chat", []string{"b-flash-1", "small"}}, // "gemini" is not "mini"
+	}
+	for _, tt := range tests {
+		t.Run(tt.useCase, func(t *testing.T) {
+			u, err := FindUseCase(tt.useCase)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range u.Rank(providers) {
+				got = append(got, r.Model.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := FindUseCase("code reveiw"); err == nil || !strings.Contains(err.Error(), "did you mean code-review") {
+		t.Errorf("expected a suggestion, got %v", err)
+	}
+}
+
+func TestLatencyTier(t *testing.T) {
+	if tier, err := ParseLatencyTier(" Fast"); err != nil || tier != LatencyFast {
+		t.Errorf("ParseLatencyTier = %q, %v", tier, err)
+	}
+	if _, err := ParseLatencyTier("slow"); err == nil {
+		t.Error("expected an error for an unknown tier")
+	}
+	if !LatencyRealtime.AtMost(LatencyFast) || !LatencyStandard.AtMost(LatencyStandard) || LatencyBatch.AtMost(LatencyStandard) {
+		t.Error("AtMost ordering is wrong")
+	}
+
+	p := catwalk.Provider{ID: "a", DefaultSmallModelID: "small"}
+	for id, want := range map[string]LatencyTier{
+		"gpt-4o-realtime-preview": LatencyRealtime,
+		"small":                   LatencyFast,
+		"gemini-flash":            LatencyFast,
+		"big":                     LatencyStandard,
+	} {
+		if got := GuessLatencyTier(p, catwalk.Model{ID: id}); got != want {
+			t.Errorf("GuessLatencyTier(%s) = %s, want %s", id, got, want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	providers := []catwalk.Provider{
+		{ID: "groq", Name: "Groq", Models: []catwalk.Model{
+			{ID: "llama-3.3-70b-versatile", Name: "Llama 3.3 70B"},
+			{ID: "gemma2-9b-it", Name: "Gemma 2 9B"},
+		}},
+		{ID: "openrouter", Name: "OpenRouter", Models: []catwalk.Model{
+			{ID: "meta-llama/llama-3.1-8b-instruct", Name: "Meta: Llama 3.1 8B Instruct"},
+			{ID: "meta-llama/codellama-34b", Name: "Meta: CodeLlama 34B"},
+			{ID: "openai/gpt-4o", Name: "OpenAI: GPT-4o"},
+		}},
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"llama", []string{"groq/llama-3.3-70b-versatile", "openrouter/meta-llama/llama-3.1-8b-instruct", "openrouter/meta-llama/codellama-34b"}},
+		{"LLAMA 8b", []string{"openrouter/meta-llama/llama-3.1-8b-instruct"}},
+		{"groq", []string{"groq/gemma2-9b-it", "groq/llama-3.3-70b-versatile"}},
+		{"gpt-4o", []string{"openrouter/openai/gpt-4o"}},
+		{"openai/gpt-4o", []string{"openrouter/openai/gpt-4o"}},
+		{"mistral", nil},
+		{" ", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, r := range Search(providers, tt.query) {
+			got = append(got, string(r.Provider.ID)+"/"+r.Model.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	for _, r := range Search(providers, "meta-llama") {
+		if r.Fields[0] != FieldOrganization {
+			t.Errorf("Search(meta-llama) matched %s on %v, want the organization first", r.Model.ID, r.Fields)
+		}
+	}
+}
+
+func TestAlternatives(t *testing.T) {
+	opus := catwalk.Model{ID: "claude-opus-4", Name: "Claude Opus 4", CostPer1MIn: 15, CostPer1MOut: 75, ContextWindow: 200_000, CanReason: true, SupportsImages: true}
+	providers := []catwalk.Provider{
+		{ID: "anthropic", DefaultSmallModelID: "claude-haiku-4", Models: []catwalk.Model{
+			opus,
+			{ID: "claude-sonnet-4", CostPer1MIn: 3, CostPer1MOut: 15, ContextWindow: 200_000, CanReason: true, SupportsImages: true},
+			{ID: "claude-haiku-4", CostPer1MIn: 1, CostPer1MOut: 5, ContextWindow: 200_000, CanReason: true, SupportsImages: true},
+		}},
+		{ID: "openrouter", Models: []catwalk.Model{
+			{ID: "anthropic/claude-opus-4", CostPer1MIn: 14, CostPer1MOut: 70, ContextWindow: 200_000, CanReason: true, SupportsImages: true},
+			{ID: "openai/gpt-5", CostPer1MIn: 1.25, CostPer1MOut: 10, ContextWindow: 400_000, CanReason: true, SupportsImages: true},
+			{ID: "openai/gpt-4o", CostPer1MIn: 2.5, CostPer1MOut: 10, ContextWindow: 128_000, SupportsImages: true},
+			{ID: "expensive", CostPer1MIn: 20, CostPer1MOut: 80, ContextWindow: 200_000, CanReason: true, SupportsImages: true},
+		}},
+	}
+	original := Match{Provider: providers[0], Model: opus}
+
+	ids := func(alts []Alternative) []string {
+		var out []string
+		for _, a := range alts {
+			out = append(out, a.Model.ID)
+		}
+		return out
+	}
+
+	alts := Alternatives(providers, original, AlternativeOptions{})
+	if want := []string{"anthropic/claude-opus-4", "claude-sonnet-4", "openai/gpt-5", "claude-haiku-4"}; !slices.Equal(ids(alts), want) {
+		t.Errorf("got %v, want %v", ids(alts), want)
+	}
+	if !alts[0].SameModel || alts[0].Similarity != 100 {
+		t.Errorf("expected the same model first, got %+v", alts[0])
+	}
+	if got := alts[1].Savings; got != 0.8 {
+		t.Errorf("sonnet savings = %v, want 0.8", got)
+	}
+
+	alts = Alternatives(providers, original, AlternativeOptions{OtherProviders: true, MinContext: 300_000})
+	if want := []string{"openai/gpt-5"}; !slices.Equal(ids(alts), want) {
+		t.Errorf("other providers: got %v, want %v", ids(alts), want)
+	}
+
+	// An input-heavy workload is priced by its own mix of tokens
+	alts = Alternatives(providers, original, AlternativeOptions{InputTokens: 1_000_000})
+	if got, want := alts[0].Cost, 14.0; got != want {
+		t.Errorf("workload cost = %v, want %v", got, want)
+	}
+}
+
+func TestWorkload(t *testing.T) {
+	w, err := ParseWorkload("input=2000, output=800,calls=10_000,cached=0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Workload{InputTokens: 2000, OutputTokens: 800, Cached: 0.5, Calls: 10_000}); w != want {
+		t.Errorf("ParseWorkload = %+v, want %+v", w, want)
+	}
+	if got, want := w.String(), "2,000 in + 800 out tokens (50% cached) x 10,000 calls"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+
+	// Anthropic's prices: cache writes at $3.75 (the cached input price),
+	// cache reads at $0.30 (the cached output price)
+	m := catwalk.Model{CostPer1MIn: 3, CostPer1MInCached: 3.75, CostPer1MOutCached: 0.3, CostPer1MOut: 15}
+	// 20M input tokens, half of them cached, and 8M output tokens
+	if got, want := w.Cost(m), 30+3+120.0; math.Abs(got-want) > 1e-9 {
+		t.Errorf("Cost = %v, want %v", got, want)
+	}
+	// The same as pricing one call's tokens with usage.Cost
+	call := Workload{InputTokens: 2000, OutputTokens: 800, Cached: 0.5}
+	if got, want := call.Cost(m), (1000*3+1000*0.3+800*15)/1_000_000; math.Abs(got-want) > 1e-12 {
+		t.Errorf("one call costs %v, want %v", got, want)
+	}
+	// Without a cache read price, cached input costs the normal price
+	if got, want := w.Cost(catwalk.Model{CostPer1MIn: 3, CostPer1MOut: 15}), 60+120.0; math.Abs(got-want) > 1e-9 {
+		t.Errorf("Cost without cache prices = %v, want %v", got, want)
+	}
+	if got := (Workload{InputTokens: 1_000_000}).Cost(m); got != 3 {
+		t.Errorf("a workload without calls costs %v, want one call's $3", got)
+	}
+
+	for _, bad := range []string{"", "calls=5", "input=-1", "input", "tokens=5", "input=10,cached=2"} {
+		if _, err := ParseWorkload(bad); err == nil {
+			t.Errorf("ParseWorkload(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestRouteTurn(t *testing.T) {
+	for _, tt := range []struct {
+		turn Turn
+		want Size
+	}{
+		{Turn{Text: "hi there"}, SizeSmall},
+		{Turn{Text: "What is the capital of France?"}, SizeSmall},
+		{Turn{Text: "Why does this panic?\n```go\nvar m map[string]int\nm[\"a\"] = 1\n```"}, SizeLarge},
+		{Turn{Text: "Compare these designs and explain the trade-offs."}, SizeLarge},
+		{Turn{Text: "Summarize this", Files: []string{"notes.txt"}}, SizeSmall},
+		{Turn{Text: "Review this", Files: []string{"main.go"}}, SizeLarge},
+		{Turn{Text: strings.Repeat("word ", 700)}, SizeLarge},
+	} {
+		if got := RouteTurn(tt.turn); got.Size != tt.want {
+			t.Errorf("RouteTurn(%.30q) = %s, want %s", tt.turn.Text, got, tt.want)
+		}
+	}
+}
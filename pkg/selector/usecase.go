@@ -0,0 +1,220 @@
+package selector
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// UseCase is a curated profile for a kind of task: the requirements a model
+// must meet and how candidates are ranked. It lets users who don't know
+// which knobs matter ask for "code review" instead.
+type UseCase struct {
+	Name        string
+	Aliases     []string
+	Description string
+
+	// Requirements are hard filters.
+	Requirements Requirements
+	// Fast keeps only low-latency models; see [IsFast].
+	Fast bool
+	// Tools records that the task needs tool calling. The catalog has no
+	// tool-calling flag, so this is shown to users rather than filtered on.
+	Tools bool
+
+	// Weights rank the models that meet the requirements.
+	Weights Weights
+}
+
+// Weights are the relative importance of each ranking factor. Each factor
+// scores a model from 0 to 1, and the weighted average is scaled to 100.
+type Weights struct {
+	// Cost favors low prices: 1 for free, 0.5 at $10 per 1M input plus
+	// output tokens.
+	Cost float64
+	// Context favors large windows, on a log scale from 8K (0) to 1M (1).
+	Context float64
+	// Reasoning favors models that can reason.
+	Reasoning float64
+	// Capability favors a family's larger tiers over its fast ones (see
+	// [IsFast]), as the catalog has no quality data.
+	Capability float64
+	// Speed favors low-latency models.
+	Speed float64
+}
+
+var useCases = []UseCase{
+	{
+		Name:        "code-review",
+		Aliases:     []string{"review", "pr-review"},
+		Description: "Review diffs for bugs and style: needs reasoning and room for a large diff plus context files",
+		Requirements: Requirements{
+			MinContext: 64_000,
+			Reasoning:  true,
+		},
+		Weights: Weights{Capability: 3, Cost: 2, Context: 1},
+	},
+	{
+		Name:        "agentic-coding",
+		Aliases:     []string{"agent", "coding-agent", "coding"},
+		Description: "Multi-step coding with tool calls: needs reasoning, tool calling, and a long context for file contents and tool output",
+		Requirements: Requirements{
+			MinContext: 128_000,
+			Reasoning:  true,
+		},
+		Tools:   true,
+		Weights: Weights{Capability: 3, Context: 2, Cost: 1},
+	},
+	{
+		Name:        "long-document-summarization",
+		Aliases:     []string{"summarization", "summarize", "long-documents"},
+		Description: "Summarize reports, books, or transcripts in one pass: needs a very large context, priced per input token",
+		Requirements: Requirements{
+			MinContext: 200_000,
+		},
+		Weights: Weights{Context: 3, Cost: 3, Speed: 1},
+	},
+	{
+		Name:        "chat",
+		Aliases:     []string{"assistant", "support", "customer-support"},
+		Description: "Interactive chat where replies must start quickly: low-latency models, cheap enough for high volume",
+		Requirements: Requirements{
+			MinContext: 16_000,
+		},
+		Fast:    true,
+		Weights: Weights{Cost: 3, Context: 1},
+	},
+	{
+		Name:        "image-understanding",
+		Aliases:     []string{"vision", "ocr", "screenshots"},
+		Description: "Describe images, read screenshots, or extract data from scans: needs image input",
+		Requirements: Requirements{
+			Vision: true,
+		},
+		Weights: Weights{Cost: 2, Reasoning: 1, Context: 1},
+	},
+}
+
+// UseCases returns the curated use-case profiles.
+func UseCases() []UseCase {
+	return slices.Clone(useCases)
+}
+
+// FindUseCase returns the profile named name or one of its aliases. Case,
+// spaces, and underscores are ignored, so "Code Review" finds code-review.
+func FindUseCase(name string) (UseCase, error) {
+	key := useCaseKey(name)
+	var names []string
+	for _, u := range useCases {
+		if u.Name == key || slices.Contains(u.Aliases, key) {
+			return u, nil
+		}
+		names = append(names, u.Name)
+		names = append(names, u.Aliases...)
+	}
+
+	msg := fmt.Sprintf("unknown use case %q", name)
+	if suggestions := catwalk.Suggest(key, names, 3); len(suggestions) > 0 {
+		msg += " (did you mean " + strings.Join(suggestions, ", ") + "?)"
+	}
+	return UseCase{}, fmt.Errorf("%s; available: %s", msg, strings.Join(useCaseNames(), ", "))
+}
+
+func useCaseKey(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return r == ' ' || r == '_' || r == '-'
+	}), "-")
+}
+
+func useCaseNames() []string {
+	names := make([]string, len(useCases))
+	for i, u := range useCases {
+		names[i] = u.Name
+	}
+	return names
+}
+
+// Satisfies reports whether the model on provider p meets the use case's
+// requirements.
+func (u UseCase) Satisfies(p catwalk.Provider, m catwalk.Model) bool {
+	return u.Requirements.Satisfies(p, m) && (!u.Fast || IsFast(p, m))
+}
+
+// Score rates how well the model on provider p suits the use case, from 0
+// to 100, ignoring the requirements.
+func (u UseCase) Score(p catwalk.Provider, m catwalk.Model) float64 {
+	w := u.Weights
+	total := w.Cost + w.Context + w.Reasoning + w.Capability + w.Speed
+	if total == 0 {
+		return 0
+	}
+
+	score := w.Cost * (1 / (1 + BlendedCost(m)/10))
+	if m.ContextWindow > 0 {
+		ctx := math.Log2(float64(m.ContextWindow)/8_000) / math.Log2(1_000_000.0/8_000)
+		score += w.Context * math.Max(0, math.Min(1, ctx))
+	}
+	if m.CanReason {
+		score += w.Reasoning
+	}
+	if IsFast(p, m) {
+		score += w.Speed
+	} else {
+		score += w.Capability
+	}
+	return 100 * score / total
+}
+
+// Ranked is a match with its use-case score.
+type Ranked struct {
+	Match
+	Score float64
+}
+
+// Rank returns the models meeting the use case's requirements, best first.
+// Ties go to the cheaper model.
+func (u UseCase) Rank(providers []catwalk.Provider) []Ranked {
+	var ranked []Ranked
+	for _, p := range providers {
+		for _, m := range p.Models {
+			if u.Satisfies(p, m) {
+				ranked = append(ranked, Ranked{Match{p, m}, u.Score(p, m)})
+			}
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return BlendedCost(ranked[i].Model) < BlendedCost(ranked[j].Model)
+	})
+	return ranked
+}
+
+// fastWords mark the small, low-latency tiers of model families.
+var fastWords = []string{"mini", "nano", "flash", "haiku", "lite", "small", "fast", "instant", "turbo"}
+
+// IsFast reports whether a model is in a low-latency tier. The catalog has
+// no latency data, so this is a heuristic: the provider's default small
+// model, or an ID or name with a word such as mini, flash, or haiku.
+func IsFast(p catwalk.Provider, m catwalk.Model) bool {
+	if m.ID == p.DefaultSmallModelID {
+		return true
+	}
+	for _, s := range []string{m.ID, m.Name} {
+		for _, word := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if slices.Contains(fastWords, word) {
+				return true
+			}
+		}
+	}
+	return false
+}
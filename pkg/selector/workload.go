@@ -0,0 +1,122 @@
+package selector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// Workload is a volume of similar requests, priced as a whole to compare
+// models by what the job would cost rather than by their per-1M prices.
+type Workload struct {
+	// InputTokens and OutputTokens are per call.
+	InputTokens  int64
+	OutputTokens int64
+	// Cached is the fraction of input tokens read from the prompt cache, at
+	// the model's cache read price: its cached output price, following the
+	// catalog's convention (see usage.Cost). Models without one are charged
+	// the normal input price.
+	Cached float64
+	// Calls is the number of requests; zero counts as one.
+	Calls int64
+}
+
+// ParseWorkload parses a workload given as comma-separated key=value pairs,
+// as in "input=2000,output=800,calls=10000". Keys are input, output, calls,
+// and cached (0-1); those left out are zero.
+func ParseWorkload(s string) (Workload, error) {
+	var w Workload
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Workload{}, fmt.Errorf("%q is not key=value (keys: input, output, calls, cached)", part)
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		if key == "cached" {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil || v < 0 || v > 1 {
+				return Workload{}, fmt.Errorf("invalid cached ratio %q (want 0-1)", value)
+			}
+			w.Cached = v
+			continue
+		}
+
+		var dst *int64
+		switch key {
+		case "input", "in":
+			dst = &w.InputTokens
+		case "output", "out":
+			dst = &w.OutputTokens
+		case "calls":
+			dst = &w.Calls
+		default:
+			return Workload{}, fmt.Errorf("unknown workload key %q (want input, output, calls, or cached)", key)
+		}
+		v, err := strconv.ParseInt(strings.ReplaceAll(value, "_", ""), 10, 64)
+		if err != nil || v < 0 {
+			return Workload{}, fmt.Errorf("invalid %s %q", key, value)
+		}
+		*dst = v
+	}
+	if w.InputTokens == 0 && w.OutputTokens == 0 {
+		return Workload{}, fmt.Errorf("workload %q has no input or output tokens", s)
+	}
+	return w, nil
+}
+
+// calls returns the number of requests, at least one.
+func (w Workload) calls() float64 {
+	return float64(max(w.Calls, 1))
+}
+
+// InputCost returns what the workload's input tokens cost on m, in USD.
+func (w Workload) InputCost(m catwalk.Model) float64 {
+	tokens := float64(w.InputTokens) * w.calls()
+	read := m.CostPer1MOutCached
+	if read == 0 {
+		read = m.CostPer1MIn
+	}
+	return (tokens*(1-w.Cached)*m.CostPer1MIn + tokens*w.Cached*read) / 1_000_000
+}
+
+// OutputCost returns what the workload's output tokens cost on m, in USD.
+func (w Workload) OutputCost(m catwalk.Model) float64 {
+	return float64(w.OutputTokens) * w.calls() * m.CostPer1MOut / 1_000_000
+}
+
+// Cost returns the workload's total cost on m, in USD.
+func (w Workload) Cost(m catwalk.Model) float64 {
+	return w.InputCost(m) + w.OutputCost(m)
+}
+
+// PerCall returns the cost of one of the workload's requests on m, in USD.
+func (w Workload) PerCall(m catwalk.Model) float64 {
+	return w.Cost(m) / w.calls()
+}
+
+// String describes the workload, as in "2,000 in + 800 out tokens x 10,000
+// calls".
+func (w Workload) String() string {
+	s := fmt.Sprintf("%s in + %s out tokens", group(w.InputTokens), group(w.OutputTokens))
+	if w.Cached > 0 {
+		s += fmt.Sprintf(" (%.0f%% cached)", w.Cached*100)
+	}
+	if w.Calls > 1 {
+		s += " x " + group(w.Calls) + " calls"
+	}
+	return s
+}
+
+// group formats n with thousands separators.
+func group(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
@@ -0,0 +1,213 @@
+// Package snapshot stores point-in-time copies of the provider catalog so
+// cost reports and scripts can be reproduced after pricing changes.
+//
+// Snapshots are kept in a directory (by default under the user cache
+// directory) next to an index.json file listing each snapshot's ETag and
+// fetch time.
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// ErrNotFound is returned when no snapshot matches a requested version.
+var ErrNotFound = errors.New("snapshot not found")
+
+const indexFile = "index.json"
+
+// Entry describes a stored snapshot.
+type Entry struct {
+	ETag    string    `json:"etag"`
+	Fetched time.Time `json:"fetched"`
+	File    string    `json:"file"`
+}
+
+// Store is a directory of catalog snapshots.
+type Store struct {
+	dir string
+}
+
+// DefaultDir returns the default snapshot directory,
+// <user cache dir>/aimodels/snapshots.
+func DefaultDir() (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine cache directory: %w", err)
+	}
+	return filepath.Join(cache, "aimodels", "snapshots"), nil
+}
+
+// Open opens the store in dir, creating the directory if needed.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// OpenDefault opens the store in DefaultDir.
+func OpenDefault() (*Store, error) {
+	dir, err := DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return Open(dir)
+}
+
+// List returns all snapshots, oldest first.
+func (s *Store) List() ([]Entry, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, indexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot index: %w", err)
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot index: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Fetched.Before(entries[j].Fetched)
+	})
+	return entries, nil
+}
+
+// Save stores the catalog unless a snapshot with the same ETag already
+// exists, and returns its entry.
+func (s *Store) Save(providers []catwalk.Provider) (Entry, error) {
+	data, err := json.Marshal(providers)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to encode catalog: %w", err)
+	}
+	etag := normalizeETag(catwalk.Etag(data))
+
+	entries, err := s.List()
+	if err != nil {
+		return Entry{}, err
+	}
+	for _, e := range entries {
+		if e.ETag == etag {
+			return e, nil
+		}
+	}
+
+	e := Entry{ETag: etag, Fetched: time.Now().UTC(), File: etag + ".json"}
+	if err := os.WriteFile(filepath.Join(s.dir, e.File), data, 0o644); err != nil {
+		return Entry{}, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	index, err := json.MarshalIndent(append(entries, e), "", "  ")
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to encode snapshot index: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, indexFile), index, 0o644); err != nil {
+		return Entry{}, fmt.Errorf("failed to write snapshot index: %w", err)
+	}
+	return e, nil
+}
+
+// Resolve finds the snapshot for a version, which is either an ETag (or a
+// unique prefix of at least 6 characters), a date (YYYY-MM-DD) meaning the
+// latest snapshot fetched on or before that day, or "latest".
+func (s *Store) Resolve(version string) (Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(entries) == 0 {
+		return Entry{}, fmt.Errorf("%w: no snapshots stored yet", ErrNotFound)
+	}
+
+	if version == "latest" {
+		return entries[len(entries)-1], nil
+	}
+
+	if day, err := time.Parse(time.DateOnly, version); err == nil {
+		cutoff := day.AddDate(0, 0, 1)
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].Fetched.Before(cutoff) {
+				return entries[i], nil
+			}
+		}
+		return Entry{}, fmt.Errorf("%w: none fetched on or before %s", ErrNotFound, version)
+	}
+
+	etag := normalizeETag(version)
+	var matches []Entry
+	for _, e := range entries {
+		if e.ETag == etag {
+			return e, nil
+		}
+		if len(etag) >= 6 && strings.HasPrefix(e.ETag, etag) {
+			matches = append(matches, e)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Entry{}, fmt.Errorf("%w: %s", ErrNotFound, version)
+	case 1:
+		return matches[0], nil
+	default:
+		return Entry{}, fmt.Errorf("ambiguous snapshot version %q matches %d snapshots", version, len(matches))
+	}
+}
+
+// Load reads the catalog stored in a snapshot.
+func (s *Store) Load(e Entry) ([]catwalk.Provider, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, e.File))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var providers []catwalk.Provider
+	if err := json.Unmarshal(data, &providers); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	return providers, nil
+}
+
+// Fetch returns the catalog for a version. With an empty version it fetches
+// live data from the client and records a snapshot of it (failures to record
+// are ignored), unless the data came from the catalog embedded in the
+// binary; otherwise it loads the matching stored snapshot.
+func Fetch(ctx context.Context, client *catwalk.Client, version string) ([]catwalk.Provider, error) {
+	store, storeErr := OpenDefault()
+
+	if version != "" {
+		if storeErr != nil {
+			return nil, storeErr
+		}
+		e, err := store.Resolve(version)
+		if err != nil {
+			return nil, err
+		}
+		return store.Load(e)
+	}
+
+	providers, err := client.GetProviders(ctx, "")
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if storeErr == nil && client.Source() != catwalk.EmbeddedURL {
+		_, _ = store.Save(providers)
+	}
+	return providers, nil
+}
+
+// normalizeETag strips the weak prefix and quotes from an ETag so it can be
+// used as a file name and compared.
+func normalizeETag(etag string) string {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	return strings.Trim(etag, `"`)
+}
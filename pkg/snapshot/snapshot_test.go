@@ -0,0 +1,61 @@
+package snapshot
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+func TestSaveAndResolve(t *testing.T) {
+	store, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old, err := store.Save([]catwalk.Provider{{ID: "a", Models: []catwalk.Model{{ID: "m", CostPer1MIn: 1}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := store.Save([]catwalk.Provider{{ID: "a", Models: []catwalk.Model{{ID: "m", CostPer1MIn: 1}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != old {
+		t.Errorf("saving identical catalog created a new snapshot")
+	}
+	latest, err := store.Save([]catwalk.Provider{{ID: "a", Models: []catwalk.Model{{ID: "m", CostPer1MIn: 2}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for version, want := range map[string]Entry{
+		"latest":                               latest,
+		old.ETag:                               old,
+		`"` + old.ETag + `"`:                   old,
+		old.ETag[:8]:                           old,
+		time.Now().UTC().Format(time.DateOnly): latest,
+	} {
+		got, err := store.Resolve(version)
+		if err != nil {
+			t.Errorf("Resolve(%q): %v", version, err)
+			continue
+		}
+		if got.ETag != want.ETag {
+			t.Errorf("Resolve(%q) = %s, want %s", version, got.ETag, want.ETag)
+		}
+	}
+
+	if _, err := store.Resolve("2001-01-01"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for old date, got %v", err)
+	}
+
+	providers, err := store.Load(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if providers[0].Models[0].CostPer1MIn != 1 {
+		t.Errorf("loaded wrong snapshot")
+	}
+}
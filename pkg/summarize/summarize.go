@@ -0,0 +1,373 @@
+// Package summarize summarizes documents of any length with a chat model.
+// A document that fits the model's context window is summarized in one
+// request; a longer one is split into overlapping chunks that each fit,
+// every chunk is summarized (map), and the summaries are combined (reduce),
+// in groups and over several passes if they do not fit together, until one
+// summary is left.
+package summarize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/tokenizer"
+	"github.com/sashabaranov/go-openai"
+)
+
+// DefaultReplyTokens is the reply limit of each request when
+// Options.MaxTokens is zero.
+const DefaultReplyTokens = 1024
+
+// DefaultOverlap is how many tokens a chunk repeats from the one before
+// when Options.Overlap is zero.
+const DefaultOverlap = 200
+
+// Target is the model to summarize with.
+type Target struct {
+	Client   *openai.Client
+	Provider catwalk.Provider
+	Model    catwalk.Model
+}
+
+// Options control a summary.
+type Options struct {
+	// Instructions are added to every prompt, to say what the summary
+	// should focus on or how long it should be.
+	Instructions string
+
+	// ChunkTokens is the most document tokens sent in one request. Zero
+	// fits chunks to the model's context window; see [ChunkTokens].
+	ChunkTokens int
+
+	// Overlap is how many tokens each chunk repeats from the end of the one
+	// before, so text cut at a boundary is read whole by one of them. Zero
+	// means DefaultOverlap; a negative value, none.
+	Overlap int
+
+	// MaxTokens limits each reply. Zero means DefaultReplyTokens.
+	MaxTokens int
+
+	// Parallel is how many chunks are summarized at once. Zero means one.
+	Parallel int
+
+	// Progress, if set, is called before each request.
+	Progress func(Step)
+}
+
+// Step is a request about to be sent: request Index (from 1) of Count in a
+// pass. Pass 0 summarizes the document's chunks, and later passes combine
+// the summaries of the pass before.
+type Step struct {
+	Pass  int
+	Index int
+	Count int
+}
+
+// Result is the outcome of a summary.
+type Result struct {
+	Summary string
+	// Chunks is how many parts the document was split into, and Passes how
+	// many rounds of combining their summaries took; a document that fit
+	// in one request has one chunk and no passes.
+	Chunks int
+	Passes int
+
+	Requests     int
+	InputTokens  int
+	OutputTokens int
+	Cost         float64
+}
+
+// Prompts for each kind of request; %s is the instructions, if any.
+const (
+	singlePrompt = `Summarize the document the user sends. Keep the names, numbers, dates, and decisions that matter, and do not add anything the document does not say.%s`
+	mapPrompt    = `The user sends one part of a longer document, which may start or end mid-sentence and repeats a little of the part before. Summarize this part on its own. Keep the names, numbers, dates, and decisions that matter, and do not add anything it does not say.%s`
+	reducePrompt = `The user sends summaries of consecutive parts of one document, separated by lines of dashes. Combine them into a single summary of the whole, in the order of the document, merging what they repeat. Do not add anything they do not say.%s`
+)
+
+// separator goes between the summaries combined in one request.
+const separator = "\n\n---\n\n"
+
+// ChunkTokens returns the most document tokens m can take in one request
+// that leaves room for the prompt and a reply of replyTokens, with a tenth
+// to spare since token counts are estimates. It returns 0 if m's context
+// window is unknown or too small.
+func ChunkTokens(m catwalk.Model, instructions string, replyTokens int) int {
+	if m.ContextWindow <= 0 {
+		return 0
+	}
+	prompt := max(tokenizer.Count(mapPrompt), tokenizer.Count(reducePrompt)) + tokenizer.Count(instructions) +
+		2*tokenizer.MessageOverhead + tokenizer.ReplyOverhead + tokenizer.Count(chat.RoleSystem+chat.RoleUser)
+	room := int(m.ContextWindow) - replyTokens - prompt
+	return max(room*9/10, 0)
+}
+
+// Fits reports whether document fits m's context window in one request
+// with a reply of replyTokens.
+func Fits(m catwalk.Model, document, instructions string, replyTokens int) bool {
+	return tokenizer.Count(document) <= ChunkTokens(m, instructions, replyTokens)
+}
+
+// Split splits text into chunks of at most size tokens, each repeating up
+// to overlap tokens from the end of the one before. Chunks end at line
+// breaks where they can, and otherwise between words.
+func Split(text string, size, overlap int) []string {
+	// Lines, with lines longer than a chunk broken into words
+	var pieces []string
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if tokenizer.Count(line) <= size {
+			pieces = append(pieces, line)
+			continue
+		}
+		pieces = append(pieces, strings.SplitAfter(line, " ")...)
+	}
+	tokens := make([]int, len(pieces))
+	for i, p := range pieces {
+		tokens[i] = tokenizer.Count(p)
+	}
+
+	var chunks []string
+	for start := 0; start < len(pieces); {
+		end, n := start, 0
+		for end < len(pieces) && (end == start || n+tokens[end] <= size) {
+			n += tokens[end]
+			end++
+		}
+		if chunk := strings.Join(pieces[start:end], ""); strings.TrimSpace(chunk) != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(pieces) {
+			break
+		}
+		// Back up over the overlap, always moving forward
+		next, repeated := end, 0
+		for next-1 > start && repeated+tokens[next-1] <= overlap {
+			next--
+			repeated += tokens[next]
+		}
+		start = next
+	}
+	return chunks
+}
+
+// Plan is how a document will be summarized, with the tokens it will take
+// at most: every reply is counted at the full reply limit.
+type Plan struct {
+	// ChunkTokens is the chunk size, and Chunks and Passes are as in
+	// [Result].
+	ChunkTokens int
+	Chunks      int
+	Passes      int
+
+	Requests     int
+	InputTokens  int
+	OutputTokens int
+}
+
+// Cost returns the plan's cost on m at its listed prices.
+func (p Plan) Cost(m catwalk.Model) float64 {
+	return chat.Cost(m, p.InputTokens, p.OutputTokens)
+}
+
+// settings resolves opts for m: the reply limit, chunk size, and overlap.
+func settings(m catwalk.Model, opts Options) (reply, size, overlap int, err error) {
+	reply = opts.MaxTokens
+	if reply == 0 {
+		reply = DefaultReplyTokens
+	}
+	overlap = opts.Overlap
+	switch {
+	case overlap == 0:
+		overlap = DefaultOverlap
+	case overlap < 0:
+		overlap = 0
+	}
+	size = opts.ChunkTokens
+	if size == 0 {
+		size = ChunkTokens(m, opts.Instructions, reply)
+	}
+	switch {
+	case size <= 0:
+		return 0, 0, 0, fmt.Errorf("the context window of %s is unknown or too small; set a chunk size", m.ID)
+	case size < 2*reply:
+		// Combining summaries needs room for at least two at a time
+		return 0, 0, 0, fmt.Errorf("chunks of %d tokens cannot hold two %d-token summaries; lower the reply limit", size, reply)
+	case overlap >= size/2:
+		return 0, 0, 0, fmt.Errorf("an overlap of %d tokens must be under half the %d-token chunks", overlap, size)
+	}
+	return reply, size, overlap, nil
+}
+
+// NewPlan returns how [Summarize] would summarize document with m, without
+// sending anything.
+func NewPlan(m catwalk.Model, document string, opts Options) (Plan, error) {
+	reply, size, overlap, err := settings(m, opts)
+	if err != nil {
+		return Plan{}, err
+	}
+	// The system prompt and framing of each request
+	overhead := tokenizer.CountMessage(chat.RoleSystem, systemPrompt(mapPrompt, opts.Instructions)) +
+		tokenizer.MessageOverhead + tokenizer.Count(chat.RoleUser) + tokenizer.ReplyOverhead
+	p := Plan{ChunkTokens: size}
+	add := func(text int) {
+		p.Requests++
+		p.InputTokens += overhead + text
+		p.OutputTokens += reply
+	}
+
+	if tokens := tokenizer.Count(document); tokens <= size {
+		p.Chunks = 1
+		add(tokens)
+		return p, nil
+	}
+	chunks := Split(document, size, overlap)
+	p.Chunks = len(chunks)
+	for _, c := range chunks {
+		add(tokenizer.Count(c))
+	}
+	// Each pass combines as many full-length summaries as fit a chunk
+	perGroup := max(size/(reply+tokenizer.Count(separator)), 2)
+	for n := len(chunks); n > 1; {
+		p.Passes++
+		groups := (n + perGroup - 1) / perGroup
+		for g := range groups {
+			add(min(perGroup, n-g*perGroup) * reply)
+		}
+		n = groups
+	}
+	return p, nil
+}
+
+// Summarize summarizes document with t's model. If a request fails, the
+// result still holds the usage of the requests sent, so the cost is known.
+func Summarize(ctx context.Context, t Target, document string, opts Options) (Result, error) {
+	reply, size, overlap, err := settings(t.Model, opts)
+	if err != nil {
+		return Result{}, err
+	}
+	if strings.TrimSpace(document) == "" {
+		return Result{}, errors.New("the document is empty")
+	}
+
+	s := &summarizer{target: t, opts: opts, reply: reply}
+	if tokenizer.Count(document) <= size {
+		s.progress(0, 1, 1)
+		summary, err := s.send(ctx, singlePrompt, document)
+		s.result.Summary, s.result.Chunks = summary, 1
+		return s.result, err
+	}
+
+	chunks := Split(document, size, overlap)
+	s.result.Chunks = len(chunks)
+	summaries, err := s.all(ctx, 0, mapPrompt, chunks)
+	for err == nil && len(summaries) > 1 {
+		s.result.Passes++
+		summaries, err = s.all(ctx, s.result.Passes, reducePrompt, group(summaries, size))
+	}
+	if err != nil {
+		return s.result, err
+	}
+	s.result.Summary = summaries[0]
+	return s.result, nil
+}
+
+// systemPrompt returns prompt with the instructions, if any, added.
+func systemPrompt(prompt, instructions string) string {
+	if instructions != "" {
+		instructions = "\n\n" + instructions
+	}
+	return fmt.Sprintf(prompt, instructions)
+}
+
+// summarizer sends a summary's requests and totals their usage.
+type summarizer struct {
+	target Target
+	opts   Options
+	reply  int
+
+	mu     sync.Mutex
+	result Result
+}
+
+// all summarizes each text with prompt, opts.Parallel at a time, and
+// returns the summaries in order.
+func (s *summarizer) all(ctx context.Context, pass int, prompt string, texts []string) ([]string, error) {
+	summaries := make([]string, len(texts))
+	errs := make([]error, len(texts))
+	sem := make(chan struct{}, max(s.opts.Parallel, 1))
+	var wg sync.WaitGroup
+	for i, text := range texts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				return
+			}
+			s.progress(pass, i+1, len(texts))
+			summaries[i], errs[i] = s.send(ctx, prompt, text)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return summaries, nil
+}
+
+// send summarizes one text in a new session.
+func (s *summarizer) send(ctx context.Context, prompt, text string) (string, error) {
+	session := chat.New(s.target.Client, s.target.Provider, s.target.Model)
+	session.SetSystem(systemPrompt(prompt, s.opts.Instructions))
+	session.SetConfig(chat.Config{MaxTokens: s.reply})
+	resp, err := session.Send(ctx, text)
+	if resp != nil {
+		s.mu.Lock()
+		s.result.Requests++
+		s.result.InputTokens += resp.InputTokens
+		s.result.OutputTokens += resp.OutputTokens
+		s.result.Cost += resp.Cost
+		s.mu.Unlock()
+	}
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	return strings.TrimSpace(resp.Content), nil
+}
+
+func (s *summarizer) progress(pass, index, count int) {
+	if s.opts.Progress == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.opts.Progress(Step{Pass: pass, Index: index, Count: count})
+}
+
+// group joins consecutive summaries into texts of at most size tokens, at
+// least two to a text but for the last, so that every pass shortens the
+// list.
+func group(summaries []string, size int) []string {
+	var groups []string
+	var current []string
+	n := 0
+	for _, summary := range summaries {
+		tokens := tokenizer.Count(summary + separator)
+		if len(current) >= 2 && n+tokens > size {
+			groups = append(groups, strings.Join(current, separator))
+			current, n = nil, 0
+		}
+		current = append(current, summary)
+		n += tokens
+	}
+	return append(groups, strings.Join(current, separator))
+}
@@ -0,0 +1,114 @@
+package summarize
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/catwalk/pkg/chat"
+	"charm.land/catwalk/pkg/tokenizer"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestSplit(t *testing.T) {
+	var lines []string
+	for i := range 40 {
+		lines = append(lines, fmt.Sprintf("line %d says something", i))
+	}
+	text := strings.Join(lines, "\n")
+
+	chunks := Split(text, 30, 10)
+	if len(chunks) < 2 {
+		t.Fatalf("chunks = %q", chunks)
+	}
+	for i, c := range chunks {
+		if n := tokenizer.Count(c); n > 30 {
+			t.Errorf("chunk %d has %d tokens", i, n)
+		}
+		if i > 0 {
+			// Each chunk starts with the last line of the one before
+			prev := strings.Split(strings.TrimSpace(chunks[i-1]), "\n")
+			if !strings.HasPrefix(c, prev[len(prev)-1]) {
+				t.Errorf("chunk %d does not overlap: %q after %q", i, c, chunks[i-1])
+			}
+		}
+	}
+	if !strings.HasSuffix(chunks[len(chunks)-1], "line 39 says something") {
+		t.Errorf("last chunk = %q", chunks[len(chunks)-1])
+	}
+
+	// A line longer than a chunk is split between words
+	if chunks := Split(strings.Repeat("word ", 100), 20, 0); len(chunks) != 5 {
+		t.Errorf("split a long line into %d chunks", len(chunks))
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	var mu sync.Mutex
+	var prompts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		prompts = append(prompts, req.Messages[0].Content)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"short summary\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":1000,\"completion_tokens\":100}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+	provider := catwalk.Provider{ID: "test", APIEndpoint: srv.URL}
+	target := Target{
+		Client:   chat.NewClient(provider, "key", nil),
+		Provider: provider,
+		Model:    catwalk.Model{ID: "m", ContextWindow: 8000, CostPer1MIn: 1, CostPer1MOut: 2},
+	}
+	document := strings.Repeat("The committee met and agreed on a budget.\n", 400)
+
+	var steps []Step
+	opts := Options{ChunkTokens: 1000, Overlap: -1, MaxTokens: 100, Parallel: 3, Instructions: "Focus on money.", Progress: func(s Step) { steps = append(steps, s) }}
+	r, err := Summarize(context.Background(), target, document, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := len(Split(document, 1000, 0))
+	if r.Summary != "short summary" || r.Chunks != chunks || r.Passes != 1 || r.Requests != chunks+1 || len(steps) != chunks+1 {
+		t.Errorf("result = %+v, steps %v", r, steps)
+	}
+	if want := float64(chunks+1) * (1000*1.0 + 100*2.0) / 1_000_000; math.Abs(r.Cost-want) > 1e-12 {
+		t.Errorf("cost = %g; want %g", r.Cost, want)
+	}
+	if !strings.Contains(prompts[0], "one part of a longer document") || !strings.Contains(prompts[chunks], "Combine them") || !strings.HasSuffix(prompts[chunks], "Focus on money.") {
+		t.Errorf("prompts = %q", prompts)
+	}
+
+	// The plan counts the same requests, and no fewer tokens than were sent
+	plan, err := NewPlan(target.Model, document, opts)
+	if err != nil || plan.Chunks != chunks || plan.Passes != 1 || plan.Requests != r.Requests || plan.OutputTokens != r.Requests*100 {
+		t.Errorf("plan = %+v, %v", plan, err)
+	}
+
+	// A document that fits is summarized in one request
+	prompts = nil
+	r, err = Summarize(context.Background(), target, "Short.", Options{})
+	if err != nil || r.Chunks != 1 || r.Passes != 0 || r.Requests != 1 || !strings.HasPrefix(prompts[0], "Summarize the document") {
+		t.Errorf("result = %+v, %v", r, err)
+	}
+
+	if _, err := Summarize(context.Background(), target, document, Options{ChunkTokens: 150, MaxTokens: 100}); err == nil {
+		t.Error("chunks too small for two summaries were accepted")
+	}
+	if ChunkTokens(catwalk.Model{ID: "x"}, "", 100) != 0 || ChunkTokens(target.Model, "", 100) < 6000 {
+		t.Errorf("chunk tokens = %d", ChunkTokens(target.Model, "", 100))
+	}
+}
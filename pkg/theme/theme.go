@@ -0,0 +1,276 @@
+// Package theme holds the colors of terminal output, by role, so every
+// program draws headers, model names, costs, and warnings alike. The
+// built-in themes are dark, light, high-contrast, and plain; auto, the
+// default, picks dark or light from the terminal's background.
+//
+// The theme is chosen by AIMODELS_THEME, else by the theme setting of the
+// aimodels settings file (see pkg/config). A user theme file,
+// ~/.config/aimodels/theme.yaml, recolors any roles of the chosen theme:
+//
+//	base: dark          # optional: the theme to start from
+//	accent: "#ff87d7"   # hex, or an ANSI color number 0-255
+//	highlight: "220"
+//
+// AIMODELS_THEME may also be the path of such a file, used in its place.
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"charm.land/catwalk/pkg/config"
+	"charm.land/catwalk/pkg/render"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"go.yaml.in/yaml/v2"
+)
+
+// EnvVar names the environment variable that overrides the theme setting.
+const EnvVar = "AIMODELS_THEME"
+
+// Theme is the colors of terminal output by role. Colors are lipgloss
+// colors: hex such as "#ff87d7", or ANSI numbers such as "212".
+type Theme struct {
+	// Name is the built-in theme this one is, or starts from.
+	Name config.Theme `yaml:"-"`
+
+	// Header colors headings and prompts.
+	Header lipgloss.Color `yaml:"header,omitempty"`
+	// Accent colors model names, the user's turns, and selections.
+	Accent lipgloss.Color `yaml:"accent,omitempty"`
+	// Highlight colors costs, scores, and counts.
+	Highlight lipgloss.Color `yaml:"highlight,omitempty"`
+	// Secondary colors other values set apart, such as context windows,
+	// model types, and provider names.
+	Secondary lipgloss.Color `yaml:"secondary,omitempty"`
+	// OK colors success: passes, capabilities, and the assistant's turns.
+	OK lipgloss.Color `yaml:"ok,omitempty"`
+	// Warn colors warnings.
+	Warn lipgloss.Color `yaml:"warn,omitempty"`
+	// Error colors errors and failures.
+	Error lipgloss.Color `yaml:"error,omitempty"`
+	// Text colors emphasized plain text.
+	Text lipgloss.Color `yaml:"text,omitempty"`
+	// Muted colors details, labels, and IDs.
+	Muted lipgloss.Color `yaml:"muted,omitempty"`
+	// Border colors table borders, rules, and archived entries.
+	Border lipgloss.Color `yaml:"border,omitempty"`
+	// Inverse colors text drawn on a Header or OK background.
+	Inverse lipgloss.Color `yaml:"inverse,omitempty"`
+}
+
+// Built-in themes.
+var (
+	// Dark is bright colors, for dark terminal backgrounds.
+	Dark = Theme{
+		Name: config.ThemeDark, Header: "86", Accent: "212", Highlight: "228", Secondary: "81",
+		OK: "120", Warn: "214", Error: "196", Text: "255", Muted: "245", Border: "240", Inverse: "0",
+	}
+	// Light is deeper colors, for light terminal backgrounds.
+	Light = Theme{
+		Name: config.ThemeLight, Header: "30", Accent: "162", Highlight: "130", Secondary: "25",
+		OK: "28", Warn: "166", Error: "160", Text: "235", Muted: "242", Border: "248", Inverse: "15",
+	}
+	// HighContrast is the brightest colors, for dark backgrounds; on a
+	// light background, [Builtin] returns the darkest instead.
+	HighContrast = Theme{
+		Name: config.ThemeHighContrast, Header: "15", Accent: "13", Highlight: "11", Secondary: "14",
+		OK: "10", Warn: "11", Error: "9", Text: "15", Muted: "7", Border: "7", Inverse: "0",
+	}
+	highContrastLight = Theme{
+		Name: config.ThemeHighContrast, Header: "0", Accent: "90", Highlight: "18", Secondary: "18",
+		OK: "22", Warn: "130", Error: "124", Text: "0", Muted: "238", Border: "238", Inverse: "15",
+	}
+	// Plain is no color: [Use] turns color off for it.
+	Plain = Theme{Name: config.ThemePlain}
+)
+
+// Detect returns Dark or Light, whichever suits the terminal's background.
+// Terminals that do not report it, and output that is not a terminal, get
+// Dark.
+func Detect() Theme {
+	if lipgloss.HasDarkBackground() {
+		return Dark
+	}
+	return Light
+}
+
+// Builtin returns the built-in theme with a name, detecting the terminal's
+// background for auto (or an empty name) and high-contrast.
+func Builtin(name config.Theme) (Theme, bool) {
+	switch name {
+	case "", config.ThemeAuto:
+		return Detect(), true
+	case config.ThemeDark:
+		return Dark, true
+	case config.ThemeLight:
+		return Light, true
+	case config.ThemeHighContrast:
+		if lipgloss.HasDarkBackground() {
+			return HighContrast, true
+		}
+		return highContrastLight, true
+	case config.ThemePlain:
+		return Plain, true
+	}
+	return Theme{}, false
+}
+
+// DefaultPath returns the default user theme file,
+// <user config dir>/aimodels/theme.yaml.
+func DefaultPath() (string, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine config directory: %w", err)
+	}
+	return filepath.Join(config, "aimodels", "theme.yaml"), nil
+}
+
+// Resolve returns the theme a name selects: a built-in theme, recolored by
+// the user theme file if there is one, or the theme file at the path name.
+func Resolve(name string) (Theme, error) {
+	if t, ok := Builtin(config.Theme(name)); ok {
+		path, err := DefaultPath()
+		if err != nil {
+			return t, nil //nolint:nilerr // no config directory, so no theme file
+		}
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return t, nil
+		}
+		return Load(path, t)
+	}
+	if _, err := os.Stat(name); err != nil {
+		return Theme{}, fmt.Errorf("unknown theme %q (use %s, or a theme file)", name, joinThemes())
+	}
+	return Load(name, Detect())
+}
+
+// Select returns the theme named by AIMODELS_THEME if set, else by setting,
+// the theme of the settings file.
+func Select(setting config.Theme) (Theme, error) {
+	if name := os.Getenv(EnvVar); name != "" {
+		t, err := Resolve(name)
+		if err != nil {
+			return t, fmt.Errorf("%s: %w", EnvVar, err)
+		}
+		return t, nil
+	}
+	return Resolve(string(setting))
+}
+
+// Current returns the theme selected by AIMODELS_THEME or the default
+// settings file, after passing it to [Use]. Programs call it once, to build
+// their styles. A bad setting or theme file is reported on stderr and the
+// detected theme used instead.
+func Current() Theme {
+	settings, err := config.Open("")
+	if err != nil {
+		settings = &config.Config{}
+	}
+	t, err := Select(settings.Theme)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "theme: %v; using default colors\n", err)
+		t = Detect()
+	}
+	Use(t)
+	return t
+}
+
+// Use sets up output for a theme: tables take its border color, and the
+// plain theme turns color off and makes tables and marks ASCII, as with
+// NO_COLOR.
+func Use(t Theme) {
+	if t.Name == config.ThemePlain {
+		lipgloss.SetColorProfile(termenv.Ascii)
+		_ = os.Setenv("NO_COLOR", "1")
+		return
+	}
+	render.BorderColor = t.Border
+}
+
+// Load reads a theme file, recoloring base.
+func Load(path string, base Theme) (Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, fmt.Errorf("failed to read theme: %w", err)
+	}
+	t, err := Parse(data, base)
+	if err != nil {
+		return Theme{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// themeFile is the YAML of a theme file.
+type themeFile struct {
+	// Base is the built-in theme to start from instead of the one chosen.
+	Base  config.Theme `yaml:"base,omitempty"`
+	Theme `yaml:",inline"`
+}
+
+// Parse decodes a theme file, which sets the colors of any roles of base or
+// of the built-in theme it names. Unknown fields and colors that are not
+// hex or ANSI numbers are errors.
+func Parse(data []byte, base Theme) (Theme, error) {
+	var f themeFile
+	if err := yaml.UnmarshalStrict(data, &f); err != nil {
+		return Theme{}, fmt.Errorf("invalid theme: %w", err)
+	}
+	t := base
+	if f.Base != "" {
+		var ok bool
+		if t, ok = Builtin(f.Base); !ok {
+			return Theme{}, fmt.Errorf("invalid theme: unknown base %q (use %s)", f.Base, joinThemes())
+		}
+	}
+	for _, c := range []struct {
+		name string
+		from lipgloss.Color
+		to   *lipgloss.Color
+	}{
+		{"header", f.Header, &t.Header},
+		{"accent", f.Accent, &t.Accent},
+		{"highlight", f.Highlight, &t.Highlight},
+		{"secondary", f.Secondary, &t.Secondary},
+		{"ok", f.OK, &t.OK},
+		{"warn", f.Warn, &t.Warn},
+		{"error", f.Error, &t.Error},
+		{"text", f.Text, &t.Text},
+		{"muted", f.Muted, &t.Muted},
+		{"border", f.Border, &t.Border},
+		{"inverse", f.Inverse, &t.Inverse},
+	} {
+		if c.from == "" {
+			continue
+		}
+		if !validColor(string(c.from)) {
+			return Theme{}, fmt.Errorf("invalid theme: %s: %q is not a hex color or an ANSI color number 0-255", c.name, c.from)
+		}
+		*c.to = c.from
+	}
+	return t, nil
+}
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// validColor reports whether c is a hex color or an ANSI color number.
+func validColor(c string) bool {
+	if hexColor.MatchString(c) {
+		return true
+	}
+	n, err := strconv.Atoi(c)
+	return err == nil && n >= 0 && n <= 255
+}
+
+func joinThemes() string {
+	names := make([]string, len(config.Themes))
+	for i, t := range config.Themes {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
+}
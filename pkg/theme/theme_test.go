@@ -0,0 +1,86 @@
+package theme
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"charm.land/catwalk/pkg/config"
+)
+
+func TestParse(t *testing.T) {
+	th, err := Parse([]byte("accent: \"#ff87d7\"\nhighlight: \"220\""), Dark)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if th.Name != config.ThemeDark || th.Accent != "#ff87d7" || th.Highlight != "220" || th.Header != Dark.Header {
+		t.Errorf("recolored dark = %+v", th)
+	}
+
+	th, err = Parse([]byte("base: light\nok: \"2\""), Dark)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if th.Name != config.ThemeLight || th.OK != "2" || th.Error != Light.Error {
+		t.Errorf("recolored light base = %+v", th)
+	}
+
+	for _, data := range []string{
+		"acent: \"212\"",
+		"accent: \"256\"",
+		"accent: pink",
+		"accent: \"#ff87d\"",
+		"base: sepia",
+	} {
+		if _, err := Parse([]byte(data), Dark); err == nil || !strings.Contains(err.Error(), "invalid theme") {
+			t.Errorf("Parse(%q) = %v, want an invalid theme error", data, err)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+
+	if th, err := Resolve("light"); err != nil || th != Light {
+		t.Errorf("Resolve(light) = %+v, %v", th, err)
+	}
+	if _, err := Resolve("sepia"); err == nil || !strings.Contains(err.Error(), `unknown theme "sepia"`) {
+		t.Errorf("Resolve(sepia) = %v, want an unknown theme error", err)
+	}
+
+	// The user theme file recolors the chosen theme
+	path, err := DefaultPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("warn: \"#ffaf00\""), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	th, err := Resolve("high-contrast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if th.Name != config.ThemeHighContrast || th.Warn != "#ffaf00" {
+		t.Errorf("Resolve(high-contrast) with a theme file = %+v", th)
+	}
+
+	// AIMODELS_THEME overrides the setting, and may name a file
+	other := filepath.Join(dir, "mine.yaml")
+	if err := os.WriteFile(other, []byte("base: dark\nborder: \"236\""), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvVar, other)
+	if th, err := Select(config.ThemeLight); err != nil || th.Name != config.ThemeDark || th.Border != "236" {
+		t.Errorf("Select with %s=%s = %+v, %v", EnvVar, other, th, err)
+	}
+	t.Setenv(EnvVar, "sepia")
+	if _, err := Select(config.ThemeLight); err == nil || !strings.HasPrefix(err.Error(), EnvVar) {
+		t.Errorf("Select with a bad %s = %v", EnvVar, err)
+	}
+}
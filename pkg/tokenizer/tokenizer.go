@@ -0,0 +1,54 @@
+// Package tokenizer provides a fast, dependency-free estimate of how many
+// tokens a piece of text will use. It is a rough heuristic, not exact for
+// any particular provider: an ASCII word costs a token per four characters,
+// and each punctuation mark, symbol and non-ASCII character costs one. Use
+// it for budgeting and context warnings, with some headroom, not billing.
+package tokenizer
+
+import "unicode"
+
+// MessageOverhead is the number of tokens chat APIs add around each message
+// for role and delimiter markup.
+const MessageOverhead = 4
+
+// ReplyOverhead is the number of tokens used to prime the assistant reply.
+const ReplyOverhead = 3
+
+// charsPerToken is the average number of characters of a word that fit in a
+// single token.
+const charsPerToken = 4
+
+// Count returns the estimated number of tokens in text.
+func Count(text string) int {
+	tokens := 0
+	word := 0
+	flush := func() {
+		if word > 0 {
+			tokens += (word + charsPerToken - 1) / charsPerToken
+			word = 0
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			// Punctuation, symbols and non-ASCII runes (CJK, emoji) are
+			// usually a token of their own.
+			flush()
+			tokens++
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// CountMessage returns the estimated number of tokens a single chat message
+// uses, including its role and framing overhead.
+func CountMessage(role, content string) int {
+	return Count(role) + Count(content) + MessageOverhead
+}
@@ -0,0 +1,45 @@
+package tokenizer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"empty", "", 0},
+		{"whitespace", "   \n\t", 0},
+		{"prose", "Hello, world!", 6},
+		{"sentence", "The quick brown fox jumps over the lazy dog.", 13},
+		{"long word", "internationalization", 5},
+		{"numbers", "3.14159 and 42", 6},
+		{"code", "func main() {\n\tfmt.Println(\"hi\")\n}", 15},
+		{"operators", "if (x >= 10) { return y[i]; }", 16},
+		{"chinese", "你好，世界", 5},
+		{"japanese", "こんにちは", 5},
+		{"accents", "naïve café", 5},
+		{"emoji", "😀😀", 2},
+		{"base64", "aGVsbG8gd29ybGQhIGhvdyBhcmUgeW91IHRvZGF5Pw==", 13},
+		{"long run", strings.Repeat("A", 400), 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Count(tt.text); got != tt.want {
+				t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountMessage(t *testing.T) {
+	if got, want := CountMessage("user", "Hello, world!"), 1+6+MessageOverhead; got != want {
+		t.Errorf("CountMessage = %d, want %d", got, want)
+	}
+	if got := CountMessage("", ""); got != MessageOverhead {
+		t.Errorf("CountMessage of nothing = %d, want %d", got, MessageOverhead)
+	}
+}
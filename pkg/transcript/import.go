@@ -0,0 +1,357 @@
+package transcript
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sources of imported conversations.
+const (
+	SourceChatGPT    = "chatgpt"
+	SourceClaude     = "claude"
+	SourceTranscript = "transcript"
+)
+
+// Conversation is a chat read from another application's export or from a
+// transcript, ready to be continued or replayed against another model.
+type Conversation struct {
+	ID      string
+	Title   string
+	Source  string
+	Created time.Time
+
+	// Model is the model that answered, when the export records it.
+	Model string
+
+	Messages []Message
+}
+
+// Entries returns the conversation as transcript entries, one per assistant
+// reply with the messages before it as the request.
+func (c Conversation) Entries() []Entry {
+	provider := map[string]string{SourceChatGPT: "openai", SourceClaude: "anthropic"}[c.Source]
+	var entries []Entry
+	for i, m := range c.Messages {
+		if m.Role != "assistant" {
+			continue
+		}
+		reply := m
+		entries = append(entries, Entry{
+			Time:     c.Created,
+			Session:  c.ID,
+			Provider: provider,
+			Model:    c.Model,
+			Request:  slices.Clone(c.Messages[:i]),
+			Response: &reply,
+		})
+	}
+	return entries
+}
+
+// FindConversation returns the conversation key refers to: its 1-based
+// number in convs, its ID, or a unique case-insensitive part of its title.
+func FindConversation(convs []Conversation, key string) (Conversation, error) {
+	if n, err := strconv.Atoi(key); err == nil {
+		if n < 1 || n > len(convs) {
+			return Conversation{}, fmt.Errorf("no conversation %d: the export has %d", n, len(convs))
+		}
+		return convs[n-1], nil
+	}
+
+	var matches []Conversation
+	for _, c := range convs {
+		if c.ID == key {
+			return c, nil
+		}
+		if strings.Contains(strings.ToLower(c.Title), strings.ToLower(key)) {
+			matches = append(matches, c)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Conversation{}, fmt.Errorf("no conversation matches %q", key)
+	case 1:
+		return matches[0], nil
+	default:
+		return Conversation{}, fmt.Errorf("%d conversations match %q; use a number or ID", len(matches), key)
+	}
+}
+
+// ReadExport reads the conversations in a ChatGPT or Claude data export
+// (the zip file, or the conversations.json inside it) or a JSONL transcript.
+// The format is detected from the content.
+func ReadExport(path string) ([]Conversation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export: %w", err)
+	}
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		if data, err = unzipConversations(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	conversations, err := ParseExport(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return conversations, nil
+}
+
+// unzipConversations returns conversations.json from an export archive.
+func unzipConversations(data []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid zip file: %w", err)
+	}
+	for _, f := range zr.File {
+		if f.Name != "conversations.json" && !strings.HasSuffix(f.Name, "/conversations.json") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+		}
+		defer rc.Close() //nolint:errcheck
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		return data, nil
+	}
+	return nil, errors.New("no conversations.json in archive")
+}
+
+// ParseExport reads a ChatGPT or Claude conversations.json, either a list of
+// conversations or a single one, or a JSONL transcript. Messages that are
+// not plain text, such as tool calls and hidden system messages, are left
+// out, and consecutive messages from the same role are joined.
+func ParseExport(r io.Reader) ([]Conversation, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export: %w", err)
+	}
+
+	var raw []json.RawMessage
+	switch trimmed := bytes.TrimSpace(data); {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		if err := json.Unmarshal(trimmed, &raw); err != nil {
+			return nil, fmt.Errorf("invalid export: %w", err)
+		}
+	case bytes.HasPrefix(trimmed, []byte("{")) && json.Valid(trimmed):
+		raw = []json.RawMessage{trimmed}
+	default:
+		return parseTranscript(data)
+	}
+
+	var probe struct {
+		Mapping      json.RawMessage `json:"mapping"`
+		ChatMessages json.RawMessage `json:"chat_messages"`
+		Request      json.RawMessage `json:"request"`
+	}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw[0], &probe); err != nil {
+			return nil, fmt.Errorf("invalid export: %w", err)
+		}
+	}
+
+	conversations := make([]Conversation, 0, len(raw))
+	for i, item := range raw {
+		var c Conversation
+		var err error
+		switch {
+		case probe.Mapping != nil:
+			c, err = parseChatGPT(item)
+		case probe.ChatMessages != nil:
+			c, err = parseClaude(item)
+		case probe.Request != nil:
+			return parseTranscript(data)
+		default:
+			return nil, errors.New("unrecognized export: expected a ChatGPT or Claude conversations.json, or a JSONL transcript")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("conversation %d: %w", i+1, err)
+		}
+		if len(c.Messages) > 0 {
+			conversations = append(conversations, c)
+		}
+	}
+	return conversations, nil
+}
+
+// chatGPTConversation is a conversation in a ChatGPT export. Messages form a
+// tree, as edits and regenerations branch; current_node is the leaf of the
+// branch that was last shown.
+type chatGPTConversation struct {
+	ID          string                 `json:"id"`
+	Title       string                 `json:"title"`
+	CreateTime  float64                `json:"create_time"`
+	CurrentNode string                 `json:"current_node"`
+	Mapping     map[string]chatGPTNode `json:"mapping"`
+}
+
+type chatGPTNode struct {
+	Parent  string `json:"parent"`
+	Message *struct {
+		Author struct {
+			Role string `json:"role"`
+		} `json:"author"`
+		Content struct {
+			ContentType string            `json:"content_type"`
+			Parts       []json.RawMessage `json:"parts"`
+		} `json:"content"`
+		Metadata struct {
+			ModelSlug    string `json:"model_slug"`
+			HiddenInChat bool   `json:"is_visually_hidden_from_conversation"`
+		} `json:"metadata"`
+	} `json:"message"`
+}
+
+func parseChatGPT(data []byte) (Conversation, error) {
+	var conv chatGPTConversation
+	if err := json.Unmarshal(data, &conv); err != nil {
+		return Conversation{}, fmt.Errorf("invalid ChatGPT conversation: %w", err)
+	}
+	c := Conversation{ID: conv.ID, Title: conv.Title, Source: SourceChatGPT}
+	if conv.CreateTime > 0 {
+		sec, frac := math.Modf(conv.CreateTime)
+		c.Created = time.Unix(int64(sec), int64(frac*1e9)).UTC()
+	}
+
+	// Walk up from the current node, then reverse into reading order
+	var branch []chatGPTNode
+	for id, seen := conv.CurrentNode, map[string]bool{}; id != "" && !seen[id]; {
+		seen[id] = true
+		node, ok := conv.Mapping[id]
+		if !ok {
+			break
+		}
+		branch = append(branch, node)
+		id = node.Parent
+	}
+	slices.Reverse(branch)
+
+	for _, node := range branch {
+		m := node.Message
+		if m == nil || m.Metadata.HiddenInChat {
+			continue
+		}
+		if m.Content.ContentType != "text" && m.Content.ContentType != "multimodal_text" {
+			continue
+		}
+		var parts []string
+		for _, raw := range m.Content.Parts {
+			// Non-string parts are attachments such as images
+			var s string
+			if json.Unmarshal(raw, &s) == nil && strings.TrimSpace(s) != "" {
+				parts = append(parts, s)
+			}
+		}
+		switch m.Author.Role {
+		case "system", "user", "assistant":
+			c.Messages = appendMessage(c.Messages, m.Author.Role, strings.Join(parts, "\n\n"))
+		}
+		if m.Author.Role == "assistant" && m.Metadata.ModelSlug != "" {
+			c.Model = m.Metadata.ModelSlug
+		}
+	}
+	return c, nil
+}
+
+// claudeConversation is a conversation in a Claude export.
+type claudeConversation struct {
+	UUID         string    `json:"uuid"`
+	Name         string    `json:"name"`
+	CreatedAt    time.Time `json:"created_at"`
+	ChatMessages []struct {
+		Sender  string `json:"sender"`
+		Text    string `json:"text"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	} `json:"chat_messages"`
+}
+
+func parseClaude(data []byte) (Conversation, error) {
+	var conv claudeConversation
+	if err := json.Unmarshal(data, &conv); err != nil {
+		return Conversation{}, fmt.Errorf("invalid Claude conversation: %w", err)
+	}
+	c := Conversation{ID: conv.UUID, Title: conv.Name, Source: SourceClaude, Created: conv.CreatedAt.UTC()}
+	for _, m := range conv.ChatMessages {
+		text := m.Text
+		if text == "" {
+			var parts []string
+			for _, part := range m.Content {
+				if part.Type == "text" && strings.TrimSpace(part.Text) != "" {
+					parts = append(parts, part.Text)
+				}
+			}
+			text = strings.Join(parts, "\n\n")
+		}
+		switch m.Sender {
+		case "human":
+			c.Messages = appendMessage(c.Messages, "user", text)
+		case "assistant":
+			c.Messages = appendMessage(c.Messages, "assistant", text)
+		}
+	}
+	return c, nil
+}
+
+// parseTranscript reads JSONL transcript entries. Each session becomes a
+// conversation: the request and reply of its last successful entry, which
+// hold the whole history.
+func parseTranscript(data []byte) ([]Conversation, error) {
+	entries, err := Read(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unrecognized export: %w", err)
+	}
+	var conversations []Conversation
+	index := map[string]int{}
+	for _, e := range entries {
+		if e.Response == nil {
+			continue
+		}
+		c := Conversation{ID: e.Session, Source: SourceTranscript, Created: e.Time, Model: e.Model}
+		for _, m := range append(slices.Clone(e.Request), *e.Response) {
+			c.Messages = appendMessage(c.Messages, m.Role, m.Content)
+		}
+		if i, ok := index[e.Session]; ok {
+			c.Created = conversations[i].Created
+			conversations[i] = c
+			continue
+		}
+		index[e.Session] = len(conversations)
+		conversations = append(conversations, c)
+	}
+	if len(conversations) == 0 {
+		return nil, errors.New("unrecognized export: expected a ChatGPT or Claude conversations.json, or a JSONL transcript")
+	}
+	return conversations, nil
+}
+
+// appendMessage adds a message, joining it to the last one if it has the
+// same role. Empty messages are dropped.
+func appendMessage(messages []Message, role, content string) []Message {
+	content = strings.TrimSpace(content)
+	switch {
+	case content == "":
+		return messages
+	case len(messages) > 0 && messages[len(messages)-1].Role == role:
+		messages[len(messages)-1].Content += "\n\n" + content
+		return messages
+	default:
+		return append(messages, Message{Role: role, Content: content})
+	}
+}
@@ -0,0 +1,119 @@
+package transcript
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// chatGPTExport has an edited first message: the branch ending at
+// current_node is kept and the abandoned one dropped.
+const chatGPTExport = `[{
+  "id": "c1",
+  "title": "Primes",
+  "create_time": 1700000000.5,
+  "current_node": "a2",
+  "mapping": {
+    "root": {"parent": null, "message": null},
+    "s":  {"parent": "root", "message": {"author": {"role": "system"}, "content": {"content_type": "text", "parts": [""]}, "metadata": {"is_visually_hidden_from_conversation": true}}},
+    "u1": {"parent": "s",  "message": {"author": {"role": "user"}, "content": {"content_type": "text", "parts": ["Is 7 prime?"]}, "metadata": {}}},
+    "u1b": {"parent": "s", "message": {"author": {"role": "user"}, "content": {"content_type": "text", "parts": ["Abandoned edit"]}, "metadata": {}}},
+    "a1": {"parent": "u1", "message": {"author": {"role": "assistant"}, "content": {"content_type": "text", "parts": ["Yes."]}, "metadata": {"model_slug": "gpt-4o"}}},
+    "u2": {"parent": "a1", "message": {"author": {"role": "user"}, "content": {"content_type": "multimodal_text", "parts": [{"asset_pointer": "file-1"}, "And this?"]}, "metadata": {}}},
+    "t":  {"parent": "u2", "message": {"author": {"role": "tool"}, "content": {"content_type": "text", "parts": ["tool output"]}, "metadata": {}}},
+    "a2": {"parent": "t",  "message": {"author": {"role": "assistant"}, "content": {"content_type": "text", "parts": ["That is 9, not prime."]}, "metadata": {"model_slug": "gpt-4o"}}}
+  }
+}]`
+
+const claudeExport = `[{
+  "uuid": "c2",
+  "name": "Haiku",
+  "created_at": "2024-05-01T10:00:00Z",
+  "chat_messages": [
+    {"sender": "human", "text": "Write a haiku."},
+    {"sender": "assistant", "text": "", "content": [{"type": "text", "text": "Autumn moonlight"}, {"type": "tool_use"}]},
+    {"sender": "assistant", "text": "a worm digs silently"}
+  ]
+}]`
+
+func TestParseExport(t *testing.T) {
+	convs, err := ParseExport(strings.NewReader(chatGPTExport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(convs) != 1 {
+		t.Fatalf("got %d conversations; want 1", len(convs))
+	}
+	c := convs[0]
+	want := []Message{
+		{Role: "user", Content: "Is 7 prime?"},
+		{Role: "assistant", Content: "Yes."},
+		{Role: "user", Content: "And this?"},
+		{Role: "assistant", Content: "That is 9, not prime."},
+	}
+	if !slices.Equal(c.Messages, want) {
+		t.Errorf("messages = %+v", c.Messages)
+	}
+	if c.Title != "Primes" || c.Model != "gpt-4o" || c.Source != SourceChatGPT || c.Created.Unix() != 1700000000 {
+		t.Errorf("conversation = %+v", c)
+	}
+
+	convs, err = ParseExport(strings.NewReader(claudeExport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Message{
+		{Role: "user", Content: "Write a haiku."},
+		{Role: "assistant", Content: "Autumn moonlight\n\na worm digs silently"},
+	}
+	if len(convs) != 1 || !slices.Equal(convs[0].Messages, want) || convs[0].Title != "Haiku" {
+		t.Fatalf("conversations = %+v", convs)
+	}
+
+	// Converted entries read back as the same conversation.
+	var jsonl bytes.Buffer
+	enc := json.NewEncoder(&jsonl)
+	for _, e := range convs[0].Entries() {
+		if err := enc.Encode(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	back, err := ParseExport(&jsonl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(back) != 1 || !slices.Equal(back[0].Messages, want) || back[0].ID != "c2" {
+		t.Errorf("round trip = %+v", back)
+	}
+
+	if _, err := ParseExport(strings.NewReader(`[{"foo": 1}]`)); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestReadExportZip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("conversations.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(claudeExport)) //nolint:errcheck
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "export.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	convs, err := ReadExport(path)
+	if err != nil || len(convs) != 1 || convs[0].Source != SourceClaude {
+		t.Fatalf("ReadExport = %+v, %v", convs, err)
+	}
+}
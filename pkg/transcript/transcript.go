@@ -0,0 +1,254 @@
+// Package transcript records chat request/response pairs as JSON Lines so
+// sessions can be analyzed for cost or turned into datasets later. It also
+// imports conversations from ChatGPT and Claude data exports, so they can be
+// continued or replayed against other models.
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Message is a single chat message.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Params are the sampling parameters a request was made with. Unset values
+// mean the provider default was used.
+type Params struct {
+	MaxTokens        int      `json:"max_tokens,omitempty"`
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"top_p,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	Seed             *int     `json:"seed,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+}
+
+// Usage is the token usage reported for a request. ReasoningTokens are the
+// part of OutputTokens a reasoning model spent thinking, and
+// CacheReadTokens and CacheWriteTokens the part of InputTokens read from
+// and written to the provider's prompt cache.
+type Usage struct {
+	InputTokens      int `json:"input_tokens"`
+	OutputTokens     int `json:"output_tokens"`
+	ReasoningTokens  int `json:"reasoning_tokens,omitempty"`
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+}
+
+// Entry is one request/response pair.
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Session   string    `json:"session,omitempty"`
+	Provider  string    `json:"provider"`
+	Model     string    `json:"model"`
+	Params    Params    `json:"params"`
+	Request   []Message `json:"request"`
+	Response  *Message  `json:"response,omitempty"`
+	Usage     Usage     `json:"usage"`
+	Cost      float64   `json:"cost"`
+	LatencyMS int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+
+	// Reasoning is the reasoning trace a model sent apart from its reply.
+	// It is kept out of Response, so datasets built from transcripts
+	// train on the replies alone.
+	Reasoning string `json:"reasoning,omitempty"`
+
+	// Billed is set when Cost and Usage were reported by the provider (such
+	// as OpenRouter's usage accounting) rather than estimated.
+	Billed bool `json:"billed,omitempty"`
+
+	// Truncated is set when the request was cut off mid-reply, such as by
+	// a timeout: Response is the partial reply, and Usage and Cost are
+	// estimated for the part that streamed, which was billed.
+	Truncated bool `json:"truncated,omitempty"`
+
+	// Upstream is the provider a router such as OpenRouter sent the request
+	// to, and ServedModel the model that answered when it differs from Model.
+	Upstream    string `json:"upstream,omitempty"`
+	ServedModel string `json:"served_model,omitempty"`
+
+	// FailoverReason is why ServedModel answered instead of Model when the
+	// request failed over to it, such as "gpt-4o: HTTP 503". Cost is at
+	// ServedModel's rates.
+	FailoverReason string `json:"failover_reason,omitempty"`
+
+	// Tags label the entry for spend reports, such as a project or client.
+	Tags []string `json:"tags,omitempty"`
+
+	// Rating is the user's score of the response, from RatingBad to
+	// RatingGood; zero means unrated. Feedback is the reason given with it.
+	// Datasets built from transcripts can keep only the well-rated replies.
+	Rating   int    `json:"rating,omitempty"`
+	Feedback string `json:"feedback,omitempty"`
+}
+
+// UsedModel returns the model that answered: ServedModel if set, or else
+// Model. Spend reports attribute cost to it.
+func (e Entry) UsedModel() string {
+	if e.ServedModel != "" {
+		return e.ServedModel
+	}
+	return e.Model
+}
+
+// The ends of the rating scale, as recorded by chat-bot's /good and /bad.
+const (
+	RatingBad  = 1
+	RatingGood = 5
+)
+
+// rating is a line rating an earlier entry, the one with the time and
+// session in Rates and Session. Read applies it to that entry, so entries
+// are never rewritten and a later rating replaces an earlier one.
+type rating struct {
+	Rates    time.Time `json:"rates"`
+	Session  string    `json:"session,omitempty"`
+	Rating   int       `json:"rating"`
+	Feedback string    `json:"feedback,omitempty"`
+}
+
+// Writer appends entries to a JSONL file. It is safe for concurrent use.
+type Writer struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+
+	// last is the entry written last, kept so it can be rated.
+	last *Entry
+}
+
+// Open opens path for appending, creating it if needed.
+func Open(path string) (*Writer, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript: %w", err)
+	}
+	return &Writer{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Write appends an entry, stamping it with the current time if unset. Each
+// entry is flushed to disk so a crash loses at most the in-flight request.
+func (w *Writer) Write(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.write(e); err != nil {
+		return fmt.Errorf("failed to write transcript entry: %w", err)
+	}
+	w.last = &e
+	return nil
+}
+
+// write encodes v as a line and syncs it.
+func (w *Writer) write(v any) error {
+	if err := w.enc.Encode(v); err != nil {
+		return err //nolint:wrapcheck
+	}
+	return w.f.Sync() //nolint:wrapcheck
+}
+
+// Rate records a rating, from RatingBad to RatingGood, and the reason for
+// it, if any, on the last entry written. The rating is appended as a line of
+// its own, which Read applies to the entry. It fails if that entry has no
+// response.
+func (w *Writer) Rate(score int, feedback string) error {
+	if score < RatingBad || score > RatingGood {
+		return fmt.Errorf("rating %d is not between %d and %d", score, RatingBad, RatingGood)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.last == nil || w.last.Response == nil || w.last.Error != "" {
+		return errors.New("no reply has been logged to rate")
+	}
+	r := rating{Rates: w.last.Time, Session: w.last.Session, Rating: score, Feedback: feedback}
+	if err := w.write(r); err != nil {
+		return fmt.Errorf("failed to write transcript rating: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.f.Close(); err != nil {
+		return fmt.Errorf("failed to close transcript: %w", err)
+	}
+	return nil
+}
+
+// Read reads JSONL transcript entries, skipping blank lines. Ratings
+// written by [Writer.Rate] are applied to the entries they rate, and
+// ratings of entries not read are dropped.
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		// A line with "rates" is a rating, whose other fields share the
+		// names of the entry's
+		var e struct {
+			Entry
+			Rates *time.Time `json:"rates"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if e.Rates == nil {
+			entries = append(entries, e.Entry)
+			continue
+		}
+		// The rated entry is usually the last one, so look back from there
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].Time.Equal(*e.Rates) && entries[i].Session == e.Session {
+				entries[i].Rating, entries[i].Feedback = e.Rating, e.Feedback
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	return entries, nil
+}
+
+// ReadFile reads the JSONL transcript at path.
+func ReadFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+	entries, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// NewSessionID returns a random identifier used to group the entries of one
+// session.
+func NewSessionID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
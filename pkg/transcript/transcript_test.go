@@ -0,0 +1,105 @@
+package transcript
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWriterRate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chat.jsonl")
+	w, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close() //nolint:errcheck
+	if err := w.Rate(RatingGood, ""); err == nil {
+		t.Error("rated with nothing logged")
+	}
+	for _, e := range []Entry{
+		{Model: "a", Response: &Message{Role: "assistant", Content: "one"}},
+		{Model: "b", Response: &Message{Role: "assistant", Content: "two"}},
+	} {
+		if err := w.Write(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logged, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Rate(RatingGood, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another writer appending in between does not stop the rating
+	other, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Write(Entry{Model: "d", Response: &Message{Role: "assistant", Content: "three"}}); err != nil {
+		t.Fatal(err)
+	}
+	other.Close() //nolint:errcheck
+	if err := w.Rate(RatingBad, "too long"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Rate(9, ""); err == nil {
+		t.Error("rated 9")
+	}
+
+	// Entries are left as written, with the ratings appended after them
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, logged) {
+		t.Errorf("rating rewrote the logged entries:\n%s", data)
+	}
+	entries, err := ReadFile(path)
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("ReadFile = %+v, %v", entries, err)
+	}
+	if entries[0].Rating != 0 || entries[1].Rating != RatingBad || entries[1].Feedback != "too long" || entries[2].Rating != 0 {
+		t.Errorf("entries = %+v", entries)
+	}
+
+	if err := w.Write(Entry{Model: "c", Error: "timeout"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Rate(RatingGood, ""); err == nil {
+		t.Error("rated a failed request")
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chat.jsonl")
+	w, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []Entry{
+		{Provider: "openai", Model: "gpt-4o", Cost: 0.01, Tags: []string{"acme"}},
+		{Provider: "anthropic", Model: "claude-3-5-haiku-latest", Cost: 0.002},
+	} {
+		if err := w.Write(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close() //nolint:errcheck
+
+	entries, err := ReadFile(path)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("ReadFile = %+v, %v", entries, err)
+	}
+	if !slices.Equal(entries[0].Tags, []string{"acme"}) || entries[1].Time.IsZero() {
+		t.Errorf("entries = %+v", entries)
+	}
+
+	if _, err := Read(strings.NewReader("{}\n\nnot json\n")); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Read error = %v, want one naming line 3", err)
+	}
+}
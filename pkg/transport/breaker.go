@@ -0,0 +1,288 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultThreshold is how many consecutive failures open a provider's
+// circuit when [NewBreaker] is given zero.
+const DefaultThreshold = 5
+
+// DefaultCooldown is how long an open circuit refuses requests when
+// [NewBreaker] is given zero.
+const DefaultCooldown = 30 * time.Second
+
+// ErrCircuitOpen is wrapped by the *CircuitOpenError returned for requests
+// to a provider whose circuit is open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// CircuitOpenError is returned, without sending anything, for a request to
+// a provider that failed too often to keep trying until the cool-down ends.
+type CircuitOpenError struct {
+	Provider string
+	Failures int
+	Until    time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s: circuit open after %d consecutive failures; skipping it until %s",
+		e.Provider, e.Failures, e.Until.Format(time.TimeOnly))
+}
+
+// Unwrap returns [ErrCircuitOpen].
+func (e *CircuitOpenError) Unwrap() error { return ErrCircuitOpen }
+
+// State is the state of a provider's circuit.
+type State int
+
+const (
+	// StateClosed lets every request through.
+	StateClosed State = iota
+	// StateOpen refuses requests until the cool-down ends.
+	StateOpen
+	// StateHalfOpen lets one trial request through after the cool-down:
+	// its success closes the circuit, and its failure opens it again.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Event is a change in a provider's circuit, for logs and metrics. Err is
+// the failure that opened it, and Until when an open circuit lets a trial
+// request through.
+type Event struct {
+	Provider string
+	State    State
+	Failures int
+	Err      error
+	Until    time.Time
+}
+
+func (e Event) String() string {
+	switch e.State {
+	case StateOpen:
+		return fmt.Sprintf("%s: circuit open after %d consecutive failures (last: %v); skipping it until %s",
+			e.Provider, e.Failures, e.Err, e.Until.Format(time.TimeOnly))
+	case StateHalfOpen:
+		return e.Provider + ": cool-down over; sending a trial request"
+	default:
+		return e.Provider + ": circuit closed; requests resume"
+	}
+}
+
+// Breaker is a circuit breaker per provider. After Threshold consecutive
+// failures a provider's circuit opens, and its requests fail fast with a
+// *CircuitOpenError for Cooldown, so batch jobs stop hammering a provider
+// that is down; then one trial request decides whether it closes again.
+// A Breaker is safe for concurrent use, and a nil *Breaker lets everything
+// through.
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	// OnEvent, if set, is called each time a circuit changes state.
+	OnEvent func(Event)
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+	now      func() time.Time
+}
+
+// circuit is one provider's state. trial is set while a half-open
+// circuit's trial request is in flight.
+type circuit struct {
+	state    State
+	failures int
+	until    time.Time
+	trial    bool
+}
+
+// NewBreaker returns a Breaker that opens after threshold consecutive
+// failures for cooldown; zero values mean [DefaultThreshold] and
+// [DefaultCooldown].
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultCooldown
+	}
+	return &Breaker{Threshold: threshold, Cooldown: cooldown}
+}
+
+// State returns provider's circuit state. An open circuit whose cool-down
+// is over reports StateHalfOpen, since its next request is let through.
+func (b *Breaker) State(provider string) State {
+	if b == nil {
+		return StateClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(provider)
+	if c.state == StateOpen && !b.clock().Before(c.until) {
+		return StateHalfOpen
+	}
+	return c.state
+}
+
+// Allow returns a *CircuitOpenError if a request to provider must not be
+// sent. A nil error lets it through, and its outcome must then be recorded
+// with Success or Failure.
+func (b *Breaker) Allow(provider string) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	c := b.circuit(provider)
+	var event *Event
+	if c.state == StateOpen && !b.clock().Before(c.until) {
+		c.state, c.trial = StateHalfOpen, false
+		event = &Event{Provider: provider, State: StateHalfOpen, Failures: c.failures}
+	}
+	var err error
+	switch {
+	case c.state == StateOpen, c.state == StateHalfOpen && c.trial:
+		err = &CircuitOpenError{Provider: provider, Failures: c.failures, Until: c.until}
+	case c.state == StateHalfOpen:
+		c.trial = true
+	}
+	b.mu.Unlock()
+	b.emit(event)
+	return err
+}
+
+// Success records a request to provider that succeeded, closing its
+// circuit.
+func (b *Breaker) Success(provider string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	c := b.circuit(provider)
+	var event *Event
+	if c.state != StateClosed {
+		event = &Event{Provider: provider, State: StateClosed}
+	}
+	*c = circuit{}
+	b.mu.Unlock()
+	b.emit(event)
+}
+
+// Failure records a request to provider that failed with err, opening its
+// circuit at the threshold or when it was the trial request.
+func (b *Breaker) Failure(provider string, err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	c := b.circuit(provider)
+	c.failures++
+	var event *Event
+	if c.state == StateHalfOpen || c.state == StateClosed && c.failures >= b.threshold() {
+		c.state, c.trial = StateOpen, false
+		c.until = b.clock().Add(b.cooldown())
+		event = &Event{Provider: provider, State: StateOpen, Failures: c.failures, Err: err, Until: c.until}
+	}
+	b.mu.Unlock()
+	b.emit(event)
+}
+
+// abort records a request to provider that was canceled before it could
+// succeed or fail, so a trial request can be sent again.
+func (b *Breaker) abort(provider string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.circuit(provider).trial = false
+}
+
+// Transport returns a RoundTripper that sends requests to provider through
+// base (http.DefaultTransport if nil) while its circuit allows them. A
+// network error or an HTTP 408, 429, or 5xx status counts as a failure;
+// a canceled request counts as neither. A nil *Breaker returns base.
+func (b *Breaker) Transport(provider string, base http.RoundTripper) http.RoundTripper {
+	if b == nil {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if err := b.Allow(provider); err != nil {
+			return nil, err
+		}
+		resp, err := base.RoundTrip(req)
+		switch {
+		case req.Context().Err() != nil:
+			b.abort(provider)
+		case err != nil:
+			b.Failure(provider, err)
+		case failedStatus(resp.StatusCode):
+			b.Failure(provider, errors.New(resp.Status))
+		default:
+			b.Success(provider)
+		}
+		return resp, err //nolint:wrapcheck
+	})
+}
+
+// failedStatus reports whether an HTTP status means the provider is failing
+// rather than the request being wrong.
+func failedStatus(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func (b *Breaker) circuit(provider string) *circuit {
+	if b.circuits == nil {
+		b.circuits = map[string]*circuit{}
+	}
+	c, ok := b.circuits[provider]
+	if !ok {
+		c = &circuit{}
+		b.circuits[provider] = c
+	}
+	return c
+}
+
+func (b *Breaker) emit(event *Event) {
+	if event != nil && b.OnEvent != nil {
+		b.OnEvent(*event)
+	}
+}
+
+func (b *Breaker) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
+
+func (b *Breaker) threshold() int {
+	if b.Threshold <= 0 {
+		return DefaultThreshold
+	}
+	return b.Threshold
+}
+
+func (b *Breaker) cooldown() time.Duration {
+	if b.Cooldown <= 0 {
+		return DefaultCooldown
+	}
+	return b.Cooldown
+}
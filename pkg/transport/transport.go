@@ -0,0 +1,106 @@
+// Package transport builds HTTP clients that work behind corporate proxies
+// and TLS-intercepting middleboxes.
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Options configures the HTTP clients returned by [Options.Client].
+type Options struct {
+	// Proxy is the proxy URL to use for every request. When empty, the
+	// HTTPS_PROXY, HTTP_PROXY, and NO_PROXY environment variables apply.
+	Proxy string
+
+	// CACert is a PEM file with extra root certificates to trust in addition
+	// to the system pool, such as a corporate proxy's CA.
+	CACert string
+
+	// InsecureSkipVerify disables TLS certificate verification.
+	InsecureSkipVerify bool
+
+	// Timeout limits each request, including reading the body. Zero means
+	// no timeout.
+	Timeout time.Duration
+}
+
+// DefaultTimeout is the request timeout set by [RegisterFlags], matching the
+// catwalk client's default.
+const DefaultTimeout = 30 * time.Second
+
+// RegisterFlags registers --proxy, --ca-cert, and --insecure-skip-verify on
+// fs and returns the Options they populate, with Timeout set to
+// [DefaultTimeout].
+func RegisterFlags(fs *flag.FlagSet) *Options {
+	o := &Options{Timeout: DefaultTimeout}
+	fs.StringVar(&o.Proxy, "proxy", "", "Proxy URL (default: HTTPS_PROXY/HTTP_PROXY from the environment)")
+	fs.StringVar(&o.CACert, "ca-cert", "", "PEM file with additional CA certificates to trust")
+	fs.BoolVar(&o.InsecureSkipVerify, "insecure-skip-verify", false, "Skip TLS certificate verification (unsafe)")
+	return o
+}
+
+// Transport returns an [http.Transport] configured with o. It starts from
+// [http.DefaultTransport], so pooling and timeouts match the standard
+// library defaults.
+func (o Options) Transport() (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+
+	if o.Proxy != "" {
+		u, err := url.Parse(o.Proxy)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", o.Proxy)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+
+	if o.CACert != "" || o.InsecureSkipVerify {
+		cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+		if o.CACert != "" {
+			pool, err := certPool(o.CACert)
+			if err != nil {
+				return nil, err
+			}
+			cfg.RootCAs = pool
+		}
+		cfg.InsecureSkipVerify = o.InsecureSkipVerify //nolint:gosec
+		t.TLSClientConfig = cfg
+	}
+
+	return t, nil
+}
+
+// Client returns an [http.Client] configured with o.
+func (o Options) Client() (*http.Client, error) {
+	t, err := o.Transport()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: t, Timeout: o.Timeout}, nil
+}
+
+// certPool returns the system certificate pool with the certificates in the
+// PEM file at path added.
+func certPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("no certificates found in " + path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,142 @@
+package transport
+
+import (
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClientCACert(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer srv.Close()
+
+	// Without the server's CA the request must fail verification.
+	client, err := Options{}.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Get(srv.URL); err == nil {
+		t.Fatal("expected certificate verification error")
+	}
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(path, cert, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, opts := range map[string]Options{
+		"ca-cert":  {CACert: path},
+		"insecure": {InsecureSkipVerify: true},
+	} {
+		client, err := opts.Client()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		resp.Body.Close() //nolint:errcheck
+	}
+}
+
+func TestTransportProxy(t *testing.T) {
+	tr, err := Options{Proxy: "http://proxy.internal:3128"}.Transport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://api.openai.com/v1/models", nil)
+	u, err := tr.Proxy(req)
+	if err != nil || u == nil || u.Host != "proxy.internal:3128" {
+		t.Fatalf("proxy = %v, %v; want proxy.internal:3128", u, err)
+	}
+
+	if _, err := (Options{Proxy: "not a url"}).Transport(); err == nil {
+		t.Fatal("expected error for invalid proxy URL")
+	}
+	if _, err := (Options{CACert: filepath.Join(t.TempDir(), "missing.pem")}).Transport(); err == nil {
+		t.Fatal("expected error for missing CA file")
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits++
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	var events []Event
+	b := NewBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+	b.OnEvent = func(e Event) { events = append(events, e) }
+	client := &http.Client{Transport: b.Transport("p", nil)}
+	get := func() error {
+		resp, err := client.Get(srv.URL)
+		if err == nil {
+			resp.Body.Close() //nolint:errcheck
+		}
+		return err
+	}
+
+	// Two failures open the circuit, and the next request is not sent
+	for range 2 {
+		if err := get(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := get(); !errors.Is(err, ErrCircuitOpen) || hits != 2 {
+		t.Fatalf("err = %v after %d requests; want ErrCircuitOpen after 2", err, hits)
+	}
+	if b.State("p") != StateOpen || b.State("other") != StateClosed {
+		t.Fatalf("states = %v, %v; want open, closed", b.State("p"), b.State("other"))
+	}
+
+	// After the cool-down a failed trial opens it again at once
+	now = now.Add(time.Minute)
+	if b.State("p") != StateHalfOpen {
+		t.Fatalf("state = %v; want half-open", b.State("p"))
+	}
+	if err := get(); err != nil || hits != 3 {
+		t.Fatalf("trial: %v after %d requests", err, hits)
+	}
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v; want ErrCircuitOpen", err)
+	}
+
+	// A successful trial closes it
+	now, status = now.Add(time.Minute), http.StatusOK
+	if err := get(); err != nil || b.State("p") != StateClosed {
+		t.Fatalf("trial: %v, state %v; want closed", err, b.State("p"))
+	}
+
+	var states []State
+	for _, e := range events {
+		states = append(states, e.State)
+	}
+	want := []State{StateOpen, StateHalfOpen, StateOpen, StateHalfOpen, StateClosed}
+	if len(states) != len(want) {
+		t.Fatalf("events = %v; want %v", states, want)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Fatalf("events = %v; want %v", states, want)
+		}
+	}
+}
+
+func TestBreakerNil(t *testing.T) {
+	var b *Breaker
+	if b.Transport("p", http.DefaultTransport) != http.DefaultTransport || b.Allow("p") != nil || b.State("p") != StateClosed {
+		t.Fatal("a nil Breaker must let everything through")
+	}
+}
@@ -0,0 +1,211 @@
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// Estimate is a request described the way people say it, as in
+// "gpt-4o: 1.5k in, 600 out, 40% cached": the models to price, if any, then
+// the tokens of one call and how many calls there are.
+type Estimate struct {
+	Models []string
+
+	// PerCall holds the tokens of one call. Its InputTokens exclude the
+	// cached and written tokens, which are part of the input as typed.
+	PerCall Record
+
+	// Calls is the number of calls, at least 1.
+	Calls int64
+
+	// Input is the total input per call as typed, and CachedShare the
+	// fraction of it read from the cache, for display.
+	Input       int64
+	CachedShare float64
+}
+
+// ParseEstimate parses an estimate. Models come first, comma-separated and
+// ended by a colon; without them, Models is empty. The rest is a list of
+// amounts, each followed by what it counts:
+//
+//	1.5k in          input tokens per call (in, input)
+//	600 out          output tokens per call (out, output)
+//	40% cached       share of the input read from the cache, or a token count
+//	2k written       input tokens written to the cache (written, write)
+//	x1000            calls (also 1000 calls)
+//
+// Amounts may use k and m suffixes and _ separators. Terms are separated by
+// commas or spaces.
+func ParseEstimate(s string) (Estimate, error) {
+	// Model IDs may hold colons, as in meta/llama:free, so the models end at
+	// the last colon after which the rest parses
+	var firstErr error
+	for i := strings.LastIndex(s, ":"); i >= 0; i = strings.LastIndex(s[:i], ":") {
+		e, err := parseTerms(s[i+1:])
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for _, m := range strings.Split(s[:i], ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				e.Models = append(e.Models, m)
+			}
+		}
+		return e, nil
+	}
+	e, err := parseTerms(s)
+	if err != nil && firstErr != nil {
+		return Estimate{}, firstErr
+	}
+	return e, err
+}
+
+// parseTerms parses the token amounts of an estimate.
+func parseTerms(s string) (Estimate, error) {
+	e := Estimate{Calls: 1}
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+	if len(fields) == 0 {
+		return Estimate{}, errors.New("no token counts (as in 1.5k in, 600 out)")
+	}
+
+	var in, cached, written int64
+	var hasIn, hasOut, hasCalls bool
+	share := -1.0
+	for i := 0; i < len(fields); i++ {
+		f := strings.ToLower(fields[i])
+		if n, ok := strings.CutPrefix(f, "x"); ok {
+			calls, err := parseAmount(n)
+			if err != nil || calls < 1 {
+				return Estimate{}, fmt.Errorf("invalid number of calls %q", fields[i])
+			}
+			e.Calls, hasCalls = calls, true
+			continue
+		}
+		if i+1 == len(fields) {
+			return Estimate{}, fmt.Errorf("%q counts nothing (add in, out, cached, written, or calls)", fields[i])
+		}
+		word := strings.ToLower(fields[i+1])
+		i++
+
+		if pct, ok := strings.CutSuffix(f, "%"); ok {
+			v, err := strconv.ParseFloat(pct, 64)
+			if err != nil || v < 0 || v > 100 {
+				return Estimate{}, fmt.Errorf("invalid percentage %q", fields[i-1])
+			}
+			if word != "cached" && word != "cache" {
+				return Estimate{}, fmt.Errorf("only cached tokens can be a percentage, not %q", word)
+			}
+			share = v / 100
+			continue
+		}
+		n, err := parseAmount(f)
+		if err != nil {
+			return Estimate{}, fmt.Errorf("invalid amount %q", fields[i-1])
+		}
+		switch word {
+		case "in", "input", "inputs":
+			in, hasIn = n, true
+		case "out", "output", "outputs":
+			e.PerCall.OutputTokens, hasOut = n, true
+		case "cached", "cache", "read":
+			cached = n
+		case "written", "write", "writes":
+			written = n
+		case "calls", "call", "requests", "request":
+			if n < 1 {
+				return Estimate{}, fmt.Errorf("invalid number of calls %q", fields[i-1])
+			}
+			e.Calls, hasCalls = n, true
+		default:
+			return Estimate{}, fmt.Errorf("unknown term %q (use in, out, cached, written, or calls)", fields[i])
+		}
+	}
+	if !hasIn && !hasOut && cached == 0 && written == 0 {
+		if hasCalls {
+			return Estimate{}, errors.New("no token counts, only calls")
+		}
+		return Estimate{}, errors.New("no token counts (as in 1.5k in, 600 out)")
+	}
+
+	if share >= 0 {
+		if !hasIn {
+			return Estimate{}, errors.New("a cached percentage needs the input tokens (as in 2k in, 40% cached)")
+		}
+		cached = int64(math.Round(float64(in) * share))
+	}
+	if !hasIn {
+		in = cached + written
+	}
+	if cached+written > in {
+		return Estimate{}, fmt.Errorf("cached and written tokens (%d) exceed the input (%d)", cached+written, in)
+	}
+	e.Input = in
+	if in > 0 {
+		e.CachedShare = float64(cached) / float64(in)
+	}
+	e.PerCall.InputTokens = in - cached - written
+	e.PerCall.CacheReadTokens = cached
+	e.PerCall.CacheWriteTokens = written
+	e.PerCall.Requests = 1
+	return e, nil
+}
+
+// parseAmount parses a token count such as 600, 1.5k, 2M, or 10_000.
+func parseAmount(s string) (int64, error) {
+	s = strings.ReplaceAll(strings.ToLower(s), "_", "")
+	scale := 1.0
+	switch {
+	case strings.HasSuffix(s, "k"):
+		scale, s = 1_000, strings.TrimSuffix(s, "k")
+	case strings.HasSuffix(s, "m"):
+		scale, s = 1_000_000, strings.TrimSuffix(s, "m")
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 || math.IsInf(v, 0) || v*scale > math.MaxInt64/2 {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return int64(math.Round(v * scale)), nil
+}
+
+// Cost returns the cost of all the estimate's calls on m.
+func (e Estimate) Cost(m catwalk.Model) float64 {
+	return Cost(m, e.PerCall) * float64(max(e.Calls, 1))
+}
+
+// String describes the estimate's tokens, as in "1,500 in (40% cached),
+// 600 out x 1,000 calls".
+func (e Estimate) String() string {
+	s := count(e.Input) + " in"
+	var notes []string
+	if e.PerCall.CacheReadTokens > 0 {
+		notes = append(notes, fmt.Sprintf("%.0f%% cached", e.CachedShare*100))
+	}
+	if e.PerCall.CacheWriteTokens > 0 {
+		notes = append(notes, count(e.PerCall.CacheWriteTokens)+" written")
+	}
+	if len(notes) > 0 {
+		s += " (" + strings.Join(notes, ", ") + ")"
+	}
+	s += ", " + count(e.PerCall.OutputTokens) + " out"
+	if e.Calls > 1 {
+		s += " x " + count(e.Calls) + " calls"
+	}
+	return s
+}
+
+// count formats n with thousands separators.
+func count(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
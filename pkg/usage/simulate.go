@@ -0,0 +1,113 @@
+package usage
+
+import "charm.land/catwalk/pkg/catwalk"
+
+// Conversation describes a multi-turn chat or agent session. Every turn
+// resends the system prompt and the whole history so far, so the prompt
+// grows by UserTokens+ReplyTokens per turn.
+type Conversation struct {
+	Turns int
+
+	// SystemTokens are sent at the start of every prompt: the system prompt,
+	// tool definitions, and any fixed context.
+	SystemTokens int64
+
+	// UserTokens are the new input of each turn; ReplyTokens the output.
+	UserTokens  int64
+	ReplyTokens int64
+
+	// CachePrefix caches each prompt, so the next turn reads everything but
+	// the previous reply and its new message from the cache.
+	CachePrefix bool
+}
+
+// Turn is one request of a simulated conversation.
+type Turn struct {
+	Record
+
+	// Context is the prompt size of the turn.
+	Context int64
+
+	Cost       float64
+	Cumulative float64
+}
+
+// Simulate returns the turns of conversation c on model m with their cost.
+// With CachePrefix, turn n writes the previous reply and its new message to
+// the cache and reads the rest of the prompt from it. Models without a
+// cache write or read price are charged the normal input price instead.
+func Simulate(m catwalk.Model, c Conversation) []Turn {
+	turns := make([]Turn, 0, c.Turns)
+	var cumulative float64
+	for n := 1; n <= c.Turns; n++ {
+		context := c.SystemTokens + int64(n)*c.UserTokens + int64(n-1)*c.ReplyTokens
+		rec := Record{Requests: 1, OutputTokens: c.ReplyTokens}
+		if c.CachePrefix {
+			written := c.UserTokens + c.ReplyTokens
+			if n == 1 {
+				written = c.SystemTokens + c.UserTokens
+			}
+			read := context - written
+			if m.CostPer1MInCached > 0 {
+				rec.CacheWriteTokens = written
+			} else {
+				rec.InputTokens += written
+			}
+			if m.CostPer1MOutCached > 0 {
+				rec.CacheReadTokens = read
+			} else {
+				rec.InputTokens += read
+			}
+		} else {
+			rec.InputTokens = context
+		}
+
+		cost := Cost(m, rec)
+		cumulative += cost
+		turns = append(turns, Turn{Record: rec, Context: context, Cost: cost, Cumulative: cumulative})
+	}
+	return turns
+}
+
+// NaiveCost returns the estimate that ignores history: Turns independent
+// calls of SystemTokens+UserTokens in and ReplyTokens out, without caching.
+func (c Conversation) NaiveCost(m catwalk.Model) float64 {
+	return float64(c.Turns) * Cost(m, Record{
+		InputTokens:  c.SystemTokens + c.UserTokens,
+		OutputTokens: c.ReplyTokens,
+	})
+}
+
+// CachePrefix returns requests as model m would bill them with prefix
+// caching, by the same rule as Simulate: each request reads the previous
+// request's prompt from the cache and writes the rest of its own. Requests
+// hold full prompt sizes in InputTokens. A prompt shorter than the one
+// before, as after the history is cleared, reads nothing. Models without a
+// cache write or read price are charged the normal input price instead.
+func CachePrefix(m catwalk.Model, requests []Record) []Record {
+	out := make([]Record, len(requests))
+	var previous int64
+	for i, r := range requests {
+		prompt := r.InputTokens
+		read := int64(0)
+		if previous <= prompt {
+			read = previous
+		}
+		written := prompt - read
+		previous = prompt
+
+		r.InputTokens = 0
+		if m.CostPer1MInCached > 0 {
+			r.CacheWriteTokens = written
+		} else {
+			r.InputTokens += written
+		}
+		if m.CostPer1MOutCached > 0 {
+			r.CacheReadTokens = read
+		} else {
+			r.InputTokens += read
+		}
+		out[i] = r
+	}
+	return out
+}
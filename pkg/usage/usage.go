@@ -0,0 +1,285 @@
+// Package usage parses provider billing exports and recomputes their cost
+// from catalog pricing.
+package usage
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// Record is one row of a usage export, normalized across providers.
+type Record struct {
+	Model            string
+	Requests         int64
+	InputTokens      int64 // uncached input tokens
+	CacheReadTokens  int64
+	CacheWriteTokens int64
+	OutputTokens     int64
+	Cost             float64 // cost reported by the provider
+	HasCost          bool
+}
+
+// Format describes the columns of a provider's CSV usage export. Column
+// names are matched case-insensitively, with spaces treated as underscores;
+// the first alias present in the header is used.
+type Format struct {
+	Name     string
+	Provider catwalk.InferenceProvider
+
+	Model      []string
+	Requests   []string
+	Input      []string
+	CacheRead  []string
+	CacheWrite []string
+	Output     []string
+	Cost       []string
+
+	// InputIncludesCached is set when the input column counts cached
+	// tokens too, as in OpenAI exports.
+	InputIncludesCached bool
+}
+
+// Supported export formats.
+var (
+	OpenAI = Format{
+		Name:                "openai",
+		Provider:            catwalk.InferenceProviderOpenAI,
+		Model:               []string{"model", "snapshot_id", "model_id"},
+		Requests:            []string{"num_model_requests", "n_requests", "requests"},
+		Input:               []string{"input_tokens", "n_context_tokens_total", "prompt_tokens"},
+		CacheRead:           []string{"input_cached_tokens", "n_cached_context_tokens_total", "cached_tokens"},
+		Output:              []string{"output_tokens", "n_generated_tokens_total", "completion_tokens"},
+		Cost:                []string{"cost", "cost_usd", "amount_value", "amount"},
+		InputIncludesCached: true,
+	}
+	Anthropic = Format{
+		Name:       "anthropic",
+		Provider:   catwalk.InferenceProviderAnthropic,
+		Model:      []string{"model", "model_version"},
+		Requests:   []string{"requests", "request_count"},
+		Input:      []string{"uncached_input_tokens", "input_tokens"},
+		CacheRead:  []string{"cache_read_input_tokens", "cache_read_tokens"},
+		CacheWrite: []string{"cache_creation_input_tokens", "cache_write_tokens"},
+		Output:     []string{"output_tokens"},
+		Cost:       []string{"cost_usd", "cost", "amount"},
+	}
+	OpenRouter = Format{
+		Name:      "openrouter",
+		Provider:  catwalk.InferenceProviderOpenRouter,
+		Model:     []string{"model_permaslug", "model", "slug"},
+		Requests:  []string{"requests", "count"},
+		Input:     []string{"tokens_prompt", "native_tokens_prompt", "prompt_tokens"},
+		CacheRead: []string{"native_tokens_cached", "cached_tokens"},
+		Output:    []string{"tokens_completion", "native_tokens_completion", "completion_tokens"},
+		Cost:      []string{"cost_total", "usage", "cost"},
+	}
+)
+
+// ParseCSV reads a CSV usage export in format f. Rows without a model are
+// skipped; each remaining row counts as one request unless the export has
+// a request count column.
+func ParseCSV(r io.Reader, f Format) ([]Record, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	cols := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		cols[strings.ReplaceAll(name, " ", "_")] = i
+	}
+	find := func(aliases []string) int {
+		for _, a := range aliases {
+			if i, ok := cols[a]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+
+	var (
+		model      = find(f.Model)
+		requests   = find(f.Requests)
+		input      = find(f.Input)
+		cacheRead  = find(f.CacheRead)
+		cacheWrite = find(f.CacheWrite)
+		output     = find(f.Output)
+		cost       = find(f.Cost)
+	)
+	if model < 0 || (input < 0 && output < 0) {
+		return nil, fmt.Errorf("not a %s usage export: need model and token columns", f.Name)
+	}
+
+	var records []Record
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rec := Record{Model: field(row, model), Requests: 1}
+		if rec.Model == "" {
+			continue
+		}
+
+		if requests >= 0 {
+			rec.Requests, err = parseInt(field(row, requests))
+		}
+		if err == nil && input >= 0 {
+			rec.InputTokens, err = parseInt(field(row, input))
+		}
+		if err == nil && cacheRead >= 0 {
+			rec.CacheReadTokens, err = parseInt(field(row, cacheRead))
+		}
+		if err == nil && cacheWrite >= 0 {
+			rec.CacheWriteTokens, err = parseInt(field(row, cacheWrite))
+		}
+		if err == nil && output >= 0 {
+			rec.OutputTokens, err = parseInt(field(row, output))
+		}
+		if err == nil && cost >= 0 && field(row, cost) != "" {
+			rec.Cost, err = strconv.ParseFloat(strings.TrimPrefix(field(row, cost), "$"), 64)
+			rec.HasCost = err == nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if f.InputIncludesCached {
+			rec.InputTokens = max(rec.InputTokens-rec.CacheReadTokens, 0)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func field(row []string, i int) string {
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+func parseInt(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return int64(math.Round(v)), nil
+}
+
+// Cost returns the cost of rec at the model's catalog prices. Following the
+// catalog's convention, cached input prices cache writes and cached output
+// prices cache reads.
+func Cost(m catwalk.Model, rec Record) float64 {
+	return (float64(rec.InputTokens)*m.CostPer1MIn +
+		float64(rec.CacheWriteTokens)*m.CostPer1MInCached +
+		float64(rec.CacheReadTokens)*m.CostPer1MOutCached +
+		float64(rec.OutputTokens)*m.CostPer1MOut) / 1_000_000
+}
+
+// FindModel returns the catalog model for a model name from a usage export.
+// Exact IDs win; otherwise the longest catalog ID that prefixes the name is
+// used, so dated snapshots such as gpt-4o-2024-08-06 map to gpt-4o.
+func FindModel(p catwalk.Provider, name string) (catwalk.Model, bool) {
+	var best catwalk.Model
+	found := false
+	for _, m := range p.Models {
+		id := strings.ToLower(m.ID)
+		n := strings.ToLower(name)
+		if id == n {
+			return m, true
+		}
+		if strings.HasPrefix(n, id+"-") && len(m.ID) > len(best.ID) {
+			best, found = m, true
+		}
+	}
+	return best, found
+}
+
+// ModelSpend aggregates the usage of one model.
+type ModelSpend struct {
+	Model    string // catalog ID, or the name from the export if unmatched
+	Matched  bool   // whether a catalog model was found
+	Catalog  catwalk.Model
+	Rows     int
+	Usage    Record // summed token counts and reported cost
+	Computed float64
+}
+
+// Difference returns the reported cost minus the recomputed one.
+func (s ModelSpend) Difference() float64 { return s.Usage.Cost - s.Computed }
+
+// Discrepant reports whether the reported and recomputed costs differ by
+// more than tolerance (a fraction of the recomputed cost) and a cent.
+func (s ModelSpend) Discrepant(tolerance float64) bool {
+	if !s.Matched || !s.Usage.HasCost {
+		return false
+	}
+	diff := math.Abs(s.Difference())
+	return diff > 0.01 && diff > tolerance*s.Computed
+}
+
+// Summarize groups records by catalog model, recomputing costs with the
+// prices of provider p. Snapshots of the same model are grouped together. The result is sorted by reported (or else computed) cost,
+// highest first.
+func Summarize(p catwalk.Provider, records []Record) []ModelSpend {
+	byModel := map[string]*ModelSpend{}
+	var order []string
+	for _, rec := range records {
+		key := rec.Model
+		m, matched := FindModel(p, rec.Model)
+		if matched {
+			key = m.ID
+		}
+		s, ok := byModel[key]
+		if !ok {
+			s = &ModelSpend{Model: key, Matched: matched, Catalog: m}
+			byModel[key] = s
+			order = append(order, key)
+		}
+		s.Rows++
+		s.Usage.Requests += rec.Requests
+		s.Usage.InputTokens += rec.InputTokens
+		s.Usage.CacheReadTokens += rec.CacheReadTokens
+		s.Usage.CacheWriteTokens += rec.CacheWriteTokens
+		s.Usage.OutputTokens += rec.OutputTokens
+		s.Usage.Cost += rec.Cost
+		s.Usage.HasCost = s.Usage.HasCost || rec.HasCost
+		if s.Matched {
+			s.Computed += Cost(s.Catalog, rec)
+		}
+	}
+
+	spend := make([]ModelSpend, 0, len(order))
+	for _, name := range order {
+		spend = append(spend, *byModel[name])
+	}
+	sort.SliceStable(spend, func(i, j int) bool {
+		return spendOf(spend[i]) > spendOf(spend[j])
+	})
+	return spend
+}
+
+func spendOf(s ModelSpend) float64 {
+	if s.Usage.HasCost {
+		return s.Usage.Cost
+	}
+	return s.Computed
+}
@@ -0,0 +1,156 @@
+package usage
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+func TestParseCSVAndSummarize(t *testing.T) {
+	const export = `start_time,model,input_tokens,input_cached_tokens,output_tokens,num_model_requests,cost
+2025-06-01,gpt-4o-2024-08-06,"1,000,000",200000,100000,10,3.25
+2025-06-02,gpt-4o,1000000,0,0,5,2.50
+2025-06-02,gpt-4o-mini,1000000,0,1000000,3,9.99
+2025-06-02,text-embedding-3-small,1000,0,0,1,0.00002
+`
+	records, err := ParseCSV(strings.NewReader(export), OpenAI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want 4", len(records))
+	}
+	if got := records[0].InputTokens; got != 800_000 {
+		t.Errorf("uncached input = %d, want 800000", got)
+	}
+
+	p := catwalk.Provider{ID: "openai", Models: []catwalk.Model{
+		{ID: "gpt-4o", CostPer1MIn: 2.5, CostPer1MOut: 10, CostPer1MOutCached: 1.25},
+		{ID: "gpt-4o-mini", CostPer1MIn: 0.15, CostPer1MOut: 0.6},
+	}}
+	spend := Summarize(p, records)
+	if len(spend) != 3 {
+		t.Fatalf("got %d models, want 3", len(spend))
+	}
+
+	mini := spend[0]
+	if mini.Model != "gpt-4o-mini" || !mini.Discrepant(0.01) {
+		t.Errorf("expected gpt-4o-mini first and discrepant, got %+v", mini)
+	}
+
+	// The dated snapshot is grouped with gpt-4o:
+	// 0.8M*2.5 + 0.2M*1.25 + 0.1M*10 = 3.25, plus 1M*2.5 = 2.5
+	full := spend[1]
+	if full.Model != "gpt-4o" || full.Rows != 2 || full.Usage.Requests != 15 {
+		t.Errorf("dated snapshot not grouped with gpt-4o: %+v", full)
+	}
+	if math.Abs(full.Computed-5.75) > 1e-9 || full.Discrepant(0.01) {
+		t.Errorf("computed = %v, want 5.75 with no discrepancy", full.Computed)
+	}
+
+	if spend[2].Matched {
+		t.Errorf("expected %s to be unmatched", spend[2].Model)
+	}
+}
+
+func TestParseCSVWrongFormat(t *testing.T) {
+	if _, err := ParseCSV(strings.NewReader("date,amount\n2025-06-01,1\n"), Anthropic); err == nil {
+		t.Fatal("expected error for unrecognized export")
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	m := catwalk.Model{CostPer1MIn: 3, CostPer1MOut: 15, CostPer1MInCached: 3.75, CostPer1MOutCached: 0.3}
+	c := Conversation{Turns: 3, SystemTokens: 1000, UserTokens: 100, ReplyTokens: 200}
+
+	// Prompts of 1100, 1400, and 1700 tokens: 4200*3 + 600*15
+	turns := Simulate(m, c)
+	if len(turns) != 3 || turns[2].Context != 1700 {
+		t.Fatalf("unexpected turns: %+v", turns)
+	}
+	if got := turns[2].Cumulative; math.Abs(got-0.0216) > 1e-9 {
+		t.Errorf("uncached cost = %v, want 0.0216", got)
+	}
+	if got := c.NaiveCost(m); math.Abs(got-0.0189) > 1e-9 {
+		t.Errorf("naive cost = %v, want 0.0189", got)
+	}
+
+	// Writes of 1100, 300, and 300; reads of 0, 1100, and 1400
+	c.CachePrefix = true
+	turns = Simulate(m, c)
+	if turns[1].CacheReadTokens != 1100 || turns[1].CacheWriteTokens != 300 {
+		t.Errorf("turn 2 = %+v, want 1100 read and 300 written", turns[1].Record)
+	}
+	if got := turns[2].Cumulative; math.Abs(got-0.016125) > 1e-9 {
+		t.Errorf("cached cost = %v, want 0.016125", got)
+	}
+
+	// Without cache prices, caching changes nothing
+	m.CostPer1MInCached, m.CostPer1MOutCached = 0, 0
+	if got := Simulate(m, c)[2].Cumulative; math.Abs(got-0.0216) > 1e-9 {
+		t.Errorf("cost without cache pricing = %v, want 0.0216", got)
+	}
+}
+
+func TestCachePrefix(t *testing.T) {
+	m := catwalk.Model{CostPer1MIn: 3, CostPer1MOut: 15, CostPer1MInCached: 3.75, CostPer1MOutCached: 0.3}
+	requests := []Record{
+		{Requests: 1, InputTokens: 1100, OutputTokens: 200},
+		{Requests: 1, InputTokens: 1400, OutputTokens: 200},
+		{Requests: 1, InputTokens: 500, OutputTokens: 100}, // after /clear
+	}
+
+	got := CachePrefix(m, requests)
+	for i, want := range []Record{
+		{Requests: 1, CacheWriteTokens: 1100, OutputTokens: 200},
+		{Requests: 1, CacheWriteTokens: 300, CacheReadTokens: 1100, OutputTokens: 200},
+		{Requests: 1, CacheWriteTokens: 500, OutputTokens: 100},
+	} {
+		if got[i] != want {
+			t.Errorf("request %d = %+v, want %+v", i+1, got[i], want)
+		}
+	}
+	if requests[1].InputTokens != 1400 {
+		t.Errorf("input modified: %+v", requests[1])
+	}
+
+	// Without a write price, writes are charged as input
+	m.CostPer1MInCached = 0
+	if got := CachePrefix(m, requests)[1]; got.InputTokens != 300 || got.CacheReadTokens != 1100 {
+		t.Errorf("without write price = %+v", got)
+	}
+}
+
+func TestParseEstimate(t *testing.T) {
+	e, err := ParseEstimate("gpt-4o, meta/llama:free: 1.5k in, 600 out, 40% cached x1_000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Models) != 2 || e.Models[1] != "meta/llama:free" {
+		t.Errorf("models = %q", e.Models)
+	}
+	want := Record{InputTokens: 900, CacheReadTokens: 600, OutputTokens: 600, Requests: 1}
+	if e.PerCall != want || e.Calls != 1000 {
+		t.Errorf("got %+v x %d, want %+v x 1000", e.PerCall, e.Calls, want)
+	}
+	if got := e.String(); got != "1,500 in (40% cached), 600 out x 1,000 calls" {
+		t.Errorf("String = %q", got)
+	}
+
+	// 1000 x (900*2 + 600*0.5 + 600*10) / 1M = 8.1
+	m := catwalk.Model{CostPer1MIn: 2, CostPer1MOut: 10, CostPer1MOutCached: 0.5}
+	if got := e.Cost(m); math.Abs(got-8.1) > 1e-9 {
+		t.Errorf("Cost = %v, want 8.1", got)
+	}
+
+	if e, err := ParseEstimate("2M input 100 output"); err != nil || len(e.Models) != 0 || e.PerCall.InputTokens != 2_000_000 {
+		t.Errorf("no models: %+v, %v", e, err)
+	}
+	for _, bad := range []string{"gpt-4o:", "gpt-4o: 1k", "1k in, 2k cached", "40% cached, 1k out", "1k in, 5 apples"} {
+		if _, err := ParseEstimate(bad); err == nil {
+			t.Errorf("ParseEstimate(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+// Package vertex adapts Google Cloud Vertex AI to the OpenAI-compatible chat
+// API that pkg/chat speaks, so the catalog's Vertex models are callable like
+// any other provider's.
+//
+// Requests go to a project's regional endpoint, named by VERTEXAI_PROJECT
+// and VERTEXAI_LOCATION (or GOOGLE_CLOUD_PROJECT and GOOGLE_CLOUD_LOCATION),
+// and are authenticated with Google application default credentials; see
+// pkg/auth. Gemini and other Google models use Vertex AI's
+// OpenAI-compatible endpoint under their publisher path, google/<model>.
+// Claude models have none, so [Transport] translates their requests to the
+// Anthropic Messages API at publishers/anthropic/models/<model> and the
+// replies back, text only: requests with tools or images are refused.
+package vertex
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"charm.land/catwalk/pkg/anthropic"
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/sashabaranov/go-openai"
+)
+
+// DefaultLocation is used when no location is set. The global endpoint
+// serves current Gemini and Claude models without picking a region.
+const DefaultLocation = "global"
+
+// anthropicVersion is the Messages API version Vertex AI requires in the
+// request body, in place of Anthropic's header.
+const anthropicVersion = "vertex-2023-10-16"
+
+// chatPath is the path suffix go-openai requests completions at.
+const chatPath = "/endpoints/openapi/chat/completions"
+
+// Project returns the Google Cloud project requests are billed to, from
+// VERTEXAI_PROJECT, GOOGLE_CLOUD_PROJECT, or CLOUDSDK_CORE_PROJECT.
+func Project() string {
+	return firstEnv("VERTEXAI_PROJECT", "GOOGLE_CLOUD_PROJECT", "CLOUDSDK_CORE_PROJECT")
+}
+
+// Location returns the region requests are served from, such as
+// us-east5, from VERTEXAI_LOCATION or GOOGLE_CLOUD_LOCATION, or
+// [DefaultLocation].
+func Location() string {
+	if l := firstEnv("VERTEXAI_LOCATION", "GOOGLE_CLOUD_LOCATION"); l != "" {
+		return l
+	}
+	return DefaultLocation
+}
+
+// Endpoint returns the base URL of the models a project can use in a
+// location.
+func Endpoint(project, location string) string {
+	host := location + "-aiplatform.googleapis.com"
+	if location == "global" {
+		host = "aiplatform.googleapis.com"
+	}
+	return fmt.Sprintf("https://%s/v1/projects/%s/locations/%s", host, project, location)
+}
+
+// BaseURL returns the OpenAI-compatible base URL for provider p: under its
+// API endpoint if it sets one, which may name an environment variable as
+// $VAR, or else under the endpoint for [Project] and [Location].
+func BaseURL(p catwalk.Provider) string {
+	endpoint := p.APIEndpoint
+	if name, ok := strings.CutPrefix(endpoint, "$"); ok {
+		endpoint = os.Getenv(name)
+	}
+	if endpoint == "" {
+		endpoint = Endpoint(Project(), Location())
+	}
+	return strings.TrimSuffix(endpoint, "/") + "/endpoints/openapi"
+}
+
+// Transport returns a RoundTripper that sends chat completion requests made
+// against [BaseURL] to the model's publisher through base, which should add
+// the credentials. Other requests pass through unchanged.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	prefix, ok := strings.CutSuffix(req.URL.Path, chatPath)
+	if !ok || req.Method != http.MethodPost || req.Body == nil {
+		return t.base.RoundTrip(req)
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close() //nolint:errcheck
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request: %w", err)
+	}
+	if strings.Contains(prefix, "/projects//") {
+		return nil, errors.New("no Google Cloud project set (VERTEXAI_PROJECT or GOOGLE_CLOUD_PROJECT)")
+	}
+
+	var params openai.ChatCompletionRequest
+	if err := json.Unmarshal(body, &params); err != nil {
+		return nil, fmt.Errorf("invalid chat request: %w", err)
+	}
+	if publisher(params.Model) == "anthropic" {
+		return t.anthropic(req, prefix, params)
+	}
+
+	// The OpenAI-compatible endpoint names models by publisher; the other
+	// fields are passed through untouched
+	if !strings.Contains(params.Model, "/") {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return nil, fmt.Errorf("invalid chat request: %w", err)
+		}
+		fields["model"], _ = json.Marshal(publisher(params.Model) + "/" + params.Model)
+		if body, err = json.Marshal(fields); err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+	return t.base.RoundTrip(withBody(req, req.URL.Path, body))
+}
+
+// publisher returns the Vertex AI publisher of a catalog model ID.
+func publisher(model string) string {
+	if strings.HasPrefix(strings.ToLower(model), "claude") {
+		return "anthropic"
+	}
+	return "google"
+}
+
+// withBody returns a copy of req sent to path with body.
+func withBody(req *http.Request, path string, body []byte) *http.Request {
+	req = req.Clone(req.Context())
+	req.URL.Path = path
+	req.URL.RawPath = ""
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	return req
+}
+
+// anthropicUsage is a Messages API token count.
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// anthropic sends a request to a Claude model, converting the reply to an
+// OpenAI-style completion or stream.
+func (t *transport) anthropic(req *http.Request, prefix string, r openai.ChatCompletionRequest) (*http.Response, error) {
+	params, err := anthropic.Request(r, 0)
+	if err != nil {
+		return nil, fmt.Errorf("vertex: %w for Claude models", err)
+	}
+	// The model is in the path, and the version in the body
+	params.Model, params.AnthropicVersion = "", anthropicVersion
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	method := ":rawPredict"
+	if r.Stream {
+		method = ":streamRawPredict"
+	}
+	resp, err := t.base.RoundTrip(withBody(req, prefix+"/publishers/anthropic/models/"+r.Model+method, body))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		// Errors are {"error": {...}} objects, which go-openai reads as is
+		return resp, err
+	}
+
+	if r.Stream {
+		includeUsage := r.StreamOptions != nil && r.StreamOptions.IncludeUsage
+		events := resp.Body
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(convertStream(events, pw, r.Model, includeUsage))
+			events.Close() //nolint:errcheck
+		}()
+		resp.Body = pr
+		resp.ContentLength = -1
+		resp.Header.Del("Content-Length")
+		return resp, nil
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close() //nolint:errcheck
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	out, err := convertMessage(data, r.Model)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(out))
+	resp.ContentLength = int64(len(out))
+	resp.Header.Set("Content-Length", fmt.Sprint(len(out)))
+	resp.Header.Set("Content-Type", "application/json")
+	return resp, nil
+}
+
+// finishReasons maps Messages API stop reasons to OpenAI finish reasons.
+var finishReasons = map[string]openai.FinishReason{
+	"end_turn":      openai.FinishReasonStop,
+	"stop_sequence": openai.FinishReasonStop,
+	"max_tokens":    openai.FinishReasonLength,
+	"refusal":       openai.FinishReasonContentFilter,
+}
+
+// convertMessage converts a Messages API reply to an OpenAI completion.
+func convertMessage(data []byte, model string) ([]byte, error) {
+	var msg struct {
+		ID      string `json:"id"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		StopReason string         `json:"stop_reason"`
+		Usage      anthropicUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	var text strings.Builder
+	for _, c := range msg.Content {
+		if c.Type == "text" {
+			text.WriteString(c.Text)
+		}
+	}
+	out, err := json.Marshal(openai.ChatCompletionResponse{
+		ID:      msg.ID,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []openai.ChatCompletionChoice{{
+			Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: text.String()},
+			FinishReason: finishReasons[msg.StopReason],
+		}},
+		Usage: openai.Usage{
+			PromptTokens:     msg.Usage.InputTokens,
+			CompletionTokens: msg.Usage.OutputTokens,
+			TotalTokens:      msg.Usage.InputTokens + msg.Usage.OutputTokens,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode response: %w", err)
+	}
+	return out, nil
+}
+
+// anthropicEvent is a Messages API stream event; each type fills in some
+// of the fields.
+type anthropicEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		ID    string         `json:"id"`
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"`
+	Delta struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage anthropicUsage  `json:"usage"`
+	Error json.RawMessage `json:"error"`
+}
+
+// convertStream rewrites a Messages API event stream from r as OpenAI
+// chunks on w, ending with a usage chunk if includeUsage is set. A stream
+// error is passed on as an error chunk, which go-openai reports.
+func convertStream(r io.Reader, w io.Writer, model string, includeUsage bool) error {
+	var (
+		id      string
+		created = time.Now().Unix()
+		usage   anthropicUsage
+	)
+	send := func(choices []openai.ChatCompletionStreamChoice, u *openai.Usage) error {
+		data, err := json.Marshal(openai.ChatCompletionStreamResponse{
+			ID: id, Object: "chat.completion.chunk", Created: created, Model: model,
+			Choices: choices, Usage: u,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode chunk: %w", err)
+		}
+		_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+		return err //nolint:wrapcheck
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		var e anthropicEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &e); err != nil {
+			return fmt.Errorf("invalid stream event: %w", err)
+		}
+
+		var err error
+		switch e.Type {
+		case "message_start":
+			id, usage = e.Message.ID, e.Message.Usage
+			err = send([]openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Role: openai.ChatMessageRoleAssistant}}}, nil)
+		case "content_block_delta":
+			if e.Delta.Type == "text_delta" && e.Delta.Text != "" {
+				err = send([]openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: e.Delta.Text}}}, nil)
+			}
+		case "message_delta":
+			// Output tokens are cumulative; input tokens come with message_start
+			usage.OutputTokens = e.Usage.OutputTokens
+			if e.Delta.StopReason != "" {
+				err = send([]openai.ChatCompletionStreamChoice{{FinishReason: finishReasons[e.Delta.StopReason]}}, nil)
+			}
+		case "message_stop":
+			if includeUsage {
+				err = send([]openai.ChatCompletionStreamChoice{}, &openai.Usage{
+					PromptTokens:     usage.InputTokens,
+					CompletionTokens: usage.OutputTokens,
+					TotalTokens:      usage.InputTokens + usage.OutputTokens,
+				})
+			}
+			if err == nil {
+				_, err = io.WriteString(w, "data: [DONE]\n\n")
+			}
+		case "error":
+			_, err = fmt.Fprintf(w, "data: {\"error\": %s}\n\n", e.Error)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err() //nolint:wrapcheck
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package vertex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const claudeStream = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":12,"output_tokens":1}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hel"}}
+
+event: ping
+data: {"type":"ping"}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"lo"}}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":5}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+func TestTransport(t *testing.T) {
+	var path string
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		switch {
+		case strings.HasSuffix(path, ":streamRawPredict"):
+			_, _ = io.WriteString(w, claudeStream)
+		case strings.HasSuffix(path, ":rawPredict"):
+			_, _ = io.WriteString(w, `{"id":"msg_2","content":[{"type":"text","text":"Hi"}],"stop_reason":"max_tokens","usage":{"input_tokens":3,"output_tokens":4}}`)
+		default:
+			_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+		}
+	}))
+	defer srv.Close()
+
+	config := openai.DefaultConfig("")
+	config.BaseURL = srv.URL + "/v1/projects/p/locations/us-east5/endpoints/openapi"
+	config.HTTPClient = &http.Client{Transport: Transport(nil)}
+	client := openai.NewClientWithConfig(config)
+	ctx := context.Background()
+	messages := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "Be brief."},
+		{Role: openai.ChatMessageRoleUser, Content: "Hi"},
+		{Role: openai.ChatMessageRoleUser, Content: "there"},
+	}
+
+	// Gemini goes to the OpenAI-compatible endpoint under its publisher
+	if _, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{Model: "gemini-2.5-pro", Messages: messages}); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v1/projects/p/locations/us-east5/endpoints/openapi/chat/completions" || body["model"] != "google/gemini-2.5-pro" {
+		t.Errorf("gemini: sent %v to %s", body["model"], path)
+	}
+
+	// Claude is translated to the Messages API and back
+	stream, err := client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
+		Model: "claude-sonnet-4-5@20250929", Messages: messages, Stream: true,
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content string
+	var usage *openai.Usage
+	var finish openai.FinishReason
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chunk.Choices) > 0 {
+			content += chunk.Choices[0].Delta.Content
+			if chunk.Choices[0].FinishReason != "" {
+				finish = chunk.Choices[0].FinishReason
+			}
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+	stream.Close() //nolint:errcheck
+	if path != "/v1/projects/p/locations/us-east5/publishers/anthropic/models/claude-sonnet-4-5@20250929:streamRawPredict" {
+		t.Errorf("claude: sent to %s", path)
+	}
+	if body["anthropic_version"] != anthropicVersion || body["system"] != "Be brief." || body["model"] != nil {
+		t.Errorf("claude: sent %v", body)
+	}
+	if msgs, _ := body["messages"].([]any); len(msgs) != 1 {
+		t.Errorf("claude: consecutive user messages not merged: %v", body["messages"])
+	}
+	if content != "Hello" || finish != openai.FinishReasonStop || usage == nil || usage.PromptTokens != 12 || usage.CompletionTokens != 5 {
+		t.Errorf("claude stream: %q, %q, %+v", content, finish, usage)
+	}
+
+	resp, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{Model: "claude-haiku-4-5@20251001", Messages: messages})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(path, ":rawPredict") || resp.Choices[0].Message.Content != "Hi" ||
+		resp.Choices[0].FinishReason != openai.FinishReasonLength || resp.Usage.CompletionTokens != 4 {
+		t.Errorf("claude: %s: %+v", path, resp)
+	}
+
+	_, err = client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: "claude-haiku-4-5@20251001", Messages: messages,
+		Tools: []openai.Tool{{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{Name: "f"}}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "tools are not supported") {
+		t.Errorf("claude with tools: %v", err)
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	t.Setenv("VERTEXAI_PROJECT", "")
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "acme")
+	t.Setenv("CLOUDSDK_CORE_PROJECT", "")
+	t.Setenv("VERTEXAI_LOCATION", "")
+	t.Setenv("GOOGLE_CLOUD_LOCATION", "europe-west1")
+	if got, want := Endpoint(Project(), Location()), "https://europe-west1-aiplatform.googleapis.com/v1/projects/acme/locations/europe-west1"; got != want {
+		t.Errorf("Endpoint = %s, want %s", got, want)
+	}
+	if got := Endpoint("acme", "global"); !strings.HasPrefix(got, "https://aiplatform.googleapis.com/") {
+		t.Errorf("global endpoint = %s", got)
+	}
+
+	config := openai.DefaultConfig("")
+	config.BaseURL = Endpoint("", "global") + "/endpoints/openapi"
+	config.HTTPClient = &http.Client{Transport: Transport(nil)}
+	_, err := openai.NewClientWithConfig(config).CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{Model: "gemini-2.5-pro"})
+	if err == nil || !strings.Contains(err.Error(), "no Google Cloud project") {
+		t.Errorf("missing project: %v", err)
+	}
+}